@@ -1,108 +1,614 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
 	"runtime"
+	"strings"
+	"syscall"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
-	// Internal packages (these would be real imports in the refactored version)
-	// "knative-lambda-builder/internal/config"
-	// "knative-lambda-builder/internal/events"
-	// "knative-lambda-builder/internal/build"
-	// "knative-lambda-builder/internal/aws"
-	// "knative-lambda-builder/internal/k8s"
-	// "knative-lambda-builder/internal/services"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+
+	"knative-lambda-builder/internal/api"
+	"knative-lambda-builder/internal/auth"
+	"knative-lambda-builder/internal/aws"
+	"knative-lambda-builder/internal/build"
+	"knative-lambda-builder/internal/buildruns"
+	"knative-lambda-builder/internal/callbacks"
+	"knative-lambda-builder/internal/config"
+	"knative-lambda-builder/internal/encryption"
+	"knative-lambda-builder/internal/events"
+	"knative-lambda-builder/internal/history"
+	"knative-lambda-builder/internal/k8s"
+	"knative-lambda-builder/internal/leader"
+	"knative-lambda-builder/internal/observability"
+	"knative-lambda-builder/internal/reconcile"
+	"knative-lambda-builder/internal/rpc"
+	"knative-lambda-builder/internal/rpc/buildv1"
+	"knative-lambda-builder/internal/services"
+	"knative-lambda-builder/internal/share"
+	"knative-lambda-builder/internal/sidecars"
+	"knative-lambda-builder/internal/templates"
+	"knative-lambda-builder/internal/tenants"
+	"knative-lambda-builder/internal/transform"
+	"knative-lambda-builder/internal/transport"
+	"knative-lambda-builder/storage"
 )
 
 // =============================================================================
-// 🏁 REFACTORED MAIN FUNCTION
+// 🏁 MAIN FUNCTION
 // =============================================================================
-// This shows how the new package structure would work
 // 🎯 PURPOSE: Clean, focused entry point with separated concerns
 
 func main() {
-	log.Println("Starting refactored knative-lambda-builder...")
+	log.Println("Starting knative-lambda-builder...")
 	log.Printf("Go version: %s", runtime.Version())
 
 	// =============================================================================
 	// 📍 STEP 1: LOAD CONFIGURATION
 	// =============================================================================
-	// All environment variable handling is now centralized
+	// All environment variable handling is centralized
+
+	cfg := config.Load()
+	log.Printf("Loaded configuration: JobTemplate=%s, ServiceTemplate=%s",
+		cfg.JobTemplatePath, cfg.ServiceTemplatePath)
+
+	// 🛑 SIGTERM (a rollout, a scale down) stops the replica gracefully
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
 
-	// cfg := config.Load()
-	// log.Printf("Loaded configuration: JobTemplate=%s, ServiceTemplate=%s",
-	//     cfg.JobTemplatePath, cfg.ServiceTemplatePath)
+	sampling, err := observability.ParseSamplingPolicy(cfg.EventSampleRates, cfg.EventSampleRateDefault)
+	if err != nil {
+		log.Fatalf("Invalid event sampling configuration: %v", err)
+	}
+	shutdownTracing, err := observability.InitTracing(ctx, sampling)
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
 
 	// =============================================================================
 	// 📍 STEP 2: INITIALIZE AWS CLIENTS
 	// =============================================================================
-	// AWS authentication and client setup is now isolated
+	// AWS authentication and client setup is isolated
+
+	awsOptions := aws.ClientOptions{
+		Retry: aws.RetryOptions{Mode: cfg.AWSRetryMode, MaxAttempts: cfg.AWSMaxAttempts, CallTimeout: cfg.AWSCallTimeout},
+		Endpoints: aws.Endpoints{URL: cfg.AWSEndpointURL, S3: cfg.AWSS3EndpointURL, ECR: cfg.AWSECREndpointURL,
+			STS: cfg.AWSSTSEndpointURL, S3PathStyle: cfg.AWSS3UsePathStyle},
+	}
+	if err := aws.ValidateRetryOptions(awsOptions.Retry); err != nil {
+		log.Fatalf("Invalid AWS retry configuration: %v", err)
+	}
+	if err := awsOptions.Endpoints.Validate(); err != nil {
+		log.Fatalf("Invalid AWS endpoint configuration: %v", err)
+	}
+	if awsOptions.Endpoints != (aws.Endpoints{}) {
+		log.Printf("🧪 Sending AWS calls to %+v instead of AWS", awsOptions.Endpoints)
+	}
+	awsClient, err := aws.NewClient(ctx, awsOptions)
+	if err != nil {
+		log.Fatalf("Failed to create AWS client: %v", err)
+	}
+	log.Printf("Connected to AWS account: %s in region: %s",
+		awsClient.AccountID, awsClient.Config.Region)
+
+	// ☁️ Parser sources: S3 by default, or GCS (SOURCE_URI=gs://...)
+	sourceBackend, sourceBucket, err := cfg.SourceLocation()
+	if err != nil {
+		log.Fatalf("Invalid source configuration: %v", err)
+	}
+	log.Printf("Reading parser sources from %s bucket %s", sourceBackend, sourceBucket)
+
+	// 🔐 Build contexts: compliance may require KMS encryption at rest
+	if cfg.ContextRequireEncryption {
+		keyID, ok, err := storage.NewS3ObjectStore(awsClient.S3).DefaultKMSKey(ctx, cfg.S3TmpBucket)
+		if err != nil {
+			log.Fatalf("Failed to check the encryption of %s: %v", config.EnvS3TmpBucket, err)
+		}
+		if !ok {
+			log.Fatalf("%s is set but s3://%s doesn't encrypt with SSE-KMS by default", config.EnvContextRequireEncryption, cfg.S3TmpBucket)
+		}
+		if keyID == "" && cfg.ContextKMSKeyARN == "" {
+			log.Printf("WARNING: s3://%s encrypts with the AWS managed key; set %s to use a customer managed one", cfg.S3TmpBucket, config.EnvContextKMSKeyARN)
+		}
+	}
+	// 🔗 Build jobs may get a presigned URL of their context instead of bucket access
+	if cfg.ContextPresignTTL < 0 || cfg.ContextPresignTTL > config.MaxContextPresignTTL {
+		log.Fatalf("Invalid %s %s: must be between 0 and %s", config.EnvContextPresignTTL, cfg.ContextPresignTTL, config.MaxContextPresignTTL)
+	}
+
+	// 🐳 Parser images: ECR by default, Artifact Registry (REGISTRY_PROVIDER=gar),
+	// ACR (acr), Harbor (harbor) or any other registry (generic)
+	switch cfg.RegistryProvider {
+	case config.RegistryProviderECR:
+		if _, err := cfg.ECRRepositoryLifecyclePolicy(); err != nil {
+			log.Fatalf("Invalid registry configuration: %v", err)
+		}
+		if err := cfg.ValidateECRRepositorySettings(); err != nil {
+			log.Fatalf("Invalid registry configuration: %v", err)
+		}
+		if cfg.AWSPushRoleARN != "" {
+			if aws.RoleAccountID(cfg.AWSPushRoleARN) == "" {
+				log.Fatalf("Invalid registry configuration: %s %q is not an IAM role ARN", config.EnvAWSPushRoleARN, cfg.AWSPushRoleARN)
+			}
+			log.Printf("🎭 Pushing parser images as %s", cfg.AWSPushRoleARN)
+		}
+		if !cfg.ECRScanOnPush && cfg.ScanMaxCritical >= 0 {
+			log.Printf("WARNING: %s=false with the scan gate on: images won't be scanned, so deploys will be blocked", config.EnvEcrScanOnPush)
+		}
+	case config.RegistryProviderGAR:
+		registryURL, err := cfg.GARRegistry()
+		if err != nil {
+			log.Fatalf("Invalid registry configuration: %v", err)
+		}
+		log.Printf("Pushing parser images to Artifact Registry %s", registryURL)
+	case config.RegistryProviderACR:
+		registryURL, err := cfg.ACRRegistry()
+		if err != nil {
+			log.Fatalf("Invalid registry configuration: %v", err)
+		}
+		log.Printf("Pushing parser images to ACR %s", registryURL)
+	case config.RegistryProviderHarbor:
+		registryURL, err := cfg.HarborRegistry()
+		if err != nil {
+			log.Fatalf("Invalid registry configuration: %v", err)
+		}
+		log.Printf("Pushing parser images to Harbor %s, one project per tenant", registryURL)
+	case config.RegistryProviderGeneric:
+		if cfg.RegistryURL == "" {
+			log.Fatalf("Invalid registry configuration: %s=generic needs %s", config.EnvRegistryProvider, config.EnvRegistryURL)
+		}
+		if cfg.RegistryInsecure {
+			log.Printf("WARNING: TLS verification of %s is off (%s)", cfg.RegistryURL, config.EnvRegistryInsecure)
+		}
+		log.Printf("Pushing parser images to %s with the credentials in secret %s", cfg.RegistryURL, cfg.RegistryAuthSecret)
+	default:
+		log.Fatalf("Invalid registry configuration: %s %q: expected ecr, gar, acr, harbor or generic", config.EnvRegistryProvider, cfg.RegistryProvider)
+	}
 
-	ctx := context.Background()
-	// awsClient, err := aws.NewClient(ctx)
-	// if err != nil {
-	//     log.Fatalf("Failed to create AWS client: %v", err)
-	// }
-	// log.Printf("Connected to AWS account: %s in region: %s",
-	//     awsClient.AccountID, awsClient.Config.Region)
+	// 🗂️ Templates: remote overrides > mounted files > embedded defaults
+	if cfg.TemplatesRemoteURI != "" {
+		bucket, prefix, err := templates.ParseS3URI(cfg.TemplatesRemoteURI)
+		if err != nil {
+			log.Fatalf("Invalid %s: %v", config.EnvTemplatesRemoteURI, err)
+		}
+		templates.Use(templates.NewResolver().WithRemote(storage.NewS3ObjectStore(awsClient.S3), bucket, prefix))
+	}
+
+	// 📸 Optional profiling snapshots when heap or goroutines run away
+	if cfg.SelfProfileInterval > 0 {
+		profiler := &observability.SelfProfiler{
+			Service:    "builder",
+			Dir:        cfg.SelfProfileDir,
+			Interval:   cfg.SelfProfileInterval,
+			Cooldown:   cfg.SelfProfileCooldown,
+			HeapBytes:  uint64(cfg.SelfProfileHeapBytes),
+			Goroutines: uint64(cfg.SelfProfileGoroutines),
+		}
+		if cfg.SelfProfileS3URI != "" {
+			bucket, prefix, err := templates.ParseS3URI(cfg.SelfProfileS3URI)
+			if err != nil {
+				log.Fatalf("Invalid %s: %v", config.EnvSelfProfileS3URI, err)
+			}
+			profiles := storage.NewS3ObjectStore(awsClient.S3)
+			profiler.Upload = func(ctx context.Context, name string, data []byte) error {
+				return profiles.Put(ctx, bucket, path.Join(prefix, name), bytes.NewReader(data))
+			}
+		}
+		go profiler.Start(ctx)
+	}
+
+	// 🔖 Fail fast on templates from a chart this builder doesn't support
+	templatePaths := append(cfg.TemplatePaths(), templates.EmbeddedPaths(cfg.TemplatesDir)...)
+	if err := templates.CheckCompatibility(templatePaths...); err != nil {
+		log.Fatalf("Incompatible templates: %v", err)
+	}
+	log.Printf("Templates compatible (schemaVersion %d-%d)", templates.MinSchemaVersion, templates.MaxSchemaVersion)
 
 	// =============================================================================
 	// 📍 STEP 3: INITIALIZE KUBERNETES CLIENTS
 	// =============================================================================
-	// Kubernetes operations are now in their own package
+	// Kubernetes operations are in their own package
 
-	// k8sClient, err := k8s.NewClient()
-	// if err != nil {
-	//     log.Fatalf("Failed to create Kubernetes client: %v", err)
-	// }
+	k8sClient, err := k8s.NewClient()
+	if err != nil {
+		log.Fatalf("Failed to create Kubernetes client: %v", err)
+	}
 
 	// =============================================================================
 	// 📍 STEP 4: CREATE SERVICE COMPONENTS
 	// =============================================================================
-	// Each major function is now a separate service
+	// Each major function is a separate service
+
+	tenantStore := tenants.NewConfigMapStore(k8sClient.Clientset, cfg.KubernetesNamespace)
+
+	// Tenants with a KMS key get their build records and artifacts encrypted
+	tenantKeys := encryption.NewTenantKeys(tenantStore, awsClient.KMS)
+
+	if !build.ValidBackend(cfg.BuildBackend) {
+		log.Fatalf("Invalid %s %q (kaniko or buildkit)", config.EnvBuildBackend, cfg.BuildBackend)
+	}
+	buildPlatforms, err := tenants.ParsePlatforms(cfg.BuildPlatforms)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", config.EnvBuildPlatforms, err)
+	}
+	buildResources, err := tenants.ParseBuildResources(cfg.BuildResourceRequests, cfg.BuildResourceLimits)
+	if err != nil {
+		log.Fatalf("Invalid %s/%s: %v", config.EnvBuildResourceRequests, config.EnvBuildResourceLimits, err)
+	}
+	buildResourceMax, err := build.ParseResources(cfg.BuildResourceMax)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", config.EnvBuildResourceMax, err)
+	}
+	if err := build.ValidateResources(buildResources, buildResourceMax); err != nil {
+		log.Fatalf("Invalid %s/%s: %v", config.EnvBuildResourceRequests, config.EnvBuildResourceLimits, err)
+	}
+	buildOrchestrator := build.NewOrchestrator(cfg, awsClient, k8sClient).
+		WithEncryptor(tenantKeys).
+		WithRetentionPolicy(tenants.NewContextRetention(tenantStore, cfg.ContextRetention)).
+		WithPlatformPolicy(tenants.NewBuildPlatforms(tenantStore, buildPlatforms)).
+		WithResourceSizing(build.ResourceSizing{Defaults: buildResources, Max: buildResourceMax, Tenants: tenants.NewBuildResources(tenantStore)}).
+		WithSourceAccessPolicy(tenants.NewSourceAccess(tenantStore))
+	if cfg.GitToken != "" && len(cfg.GitAllowedHosts) == 0 {
+		log.Printf("WARNING: %s not set, %s is not sent to any host", config.EnvGitAllowedHosts, config.EnvGitToken)
+	}
+	// Tenants pick the sidecars their parsers run with from a vetted catalog
+	sidecarCatalog, err := sidecars.Load(cfg.SidecarCatalogFile)
+	if err != nil {
+		log.Fatalf("Invalid sidecar catalog: %v", err)
+	}
+	canarySteps, err := services.ParseCanarySteps(cfg.CanarySteps)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", config.EnvCanarySteps, err)
+	}
+	if _, err := tenants.ParseDeployStrategy(cfg.DeployStrategy); err != nil {
+		log.Fatalf("Invalid %s: %v", config.EnvDeployStrategy, err)
+	}
+	parserService := services.NewParserService(cfg, awsClient, k8sClient).
+		WithSidecars(sidecars.NewResolver(sidecarCatalog, tenantStore)).
+		WithCanarySteps(canarySteps).
+		WithStrategyPolicy(tenants.NewDeployStrategyPolicy(tenantStore, cfg.DeployStrategy))
+	// 🏘️ Parsers in their tenant's namespace, with a quota and a NetworkPolicy
+	if cfg.TenantNamespaces {
+		quota, err := tenants.ParseQuota(cfg.TenantNamespaceQuota)
+		if err != nil {
+			log.Fatalf("Invalid %s: %v", config.EnvTenantNamespaceQuota, err)
+		}
+		parserService.WithTenantNamespaces(tenants.NamespacePolicy{Quota: quota, AllowedNamespaces: cfg.TenantNamespaceIngressFrom()})
+	}
 
-	// buildOrchestrator := build.NewOrchestrator(cfg, awsClient, k8sClient)
-	// parserService := services.NewParserService(cfg, awsClient, k8sClient)
+	tenantProvisioner := tenants.NewProvisioner(cfg, awsClient, k8sClient.Clientset, buildOrchestrator, tenantStore).
+		WithSidecarCatalog(sidecarCatalog)
+
+	// 📝 NOTE: Badges and the reconciler only read statuses, which stay in
+	// plaintext; they use the underlying store and never call KMS
+	buildHistory := history.NewConfigMapStore(k8sClient.Clientset, cfg.KubernetesNamespace)
+	encryptedHistory := history.NewEncryptedStore(buildHistory, tenantKeys)
+	reencryptor := encryption.NewReencryptor(tenantKeys, encryptedHistory, buildOrchestrator)
+
+	reconciler := reconcile.NewReconciler(cfg, k8sClient, buildOrchestrator, tenantStore, buildHistory)
 
 	// =============================================================================
 	// 📍 STEP 5: SETUP EVENT HANDLER
 	// =============================================================================
-	// Event routing is now cleanly separated
+	// Event routing is cleanly separated
+
+	// ⚡ On NATS, lifecycle events are published to JetStream instead of K_SINK
+	var emitter *events.CloudEventEmitter
+	var natsClient *transport.NATSClient
+	if cfg.Transport == transport.NATS {
+		natsClient, err = transport.NewNATSClient(transport.NATSConfig{
+			URL:           cfg.NATSURL,
+			Stream:        cfg.NATSStream,
+			Subject:       cfg.NATSSubject,
+			Durable:       cfg.NATSDurable,
+			EventsSubject: cfg.NATSEventsSubject,
+			MaxDeliveries: cfg.NATSMaxDeliveries,
+		})
+		if err != nil {
+			log.Fatalf("Failed to create NATS client: %v", err)
+		}
+		emitter = events.NewSenderEmitter(natsClient.Publish)
+	} else {
+		emitter, err = events.NewEmitter(cfg.EventSink)
+		if err != nil {
+			log.Fatalf("Failed to create event emitter: %v", err)
+		}
+	}
+
+	transformer, err := transform.Load(cfg.EventTransformsFile)
+	if err != nil {
+		log.Fatalf("Invalid event transform rules: %v", err)
+	}
+
+	buildRateLimit, err := tenants.ParseRateLimit(cfg.BuildRateLimit)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", config.EnvBuildRateLimit, err)
+	}
+
+	eventHandler := events.NewHandler(buildOrchestrator, parserService, emitter, encryptedHistory, transformer, sampling).
+		WithDeduplication(cfg.BuildDedupWindow, cfg.BuildDedupHistory).
+		WithRateLimits(tenants.NewBuildRateLimits(tenantStore, buildRateLimit)).
+		WithBuildQueueSize(cfg.BuildQueueSize).
+		WithBuildWorkers(cfg.BuildWorkers)
+	if cfg.EventSigningSecret != "" {
+		verifier, err := events.NewSignatureVerifier([]byte(cfg.EventSigningSecret))
+		if err != nil {
+			log.Fatalf("Invalid %s: %v", config.EnvEventSigningSecret, err)
+		}
+		eventHandler.WithSignatureVerifier(verifier)
+	} else {
+		log.Printf("WARNING: %s not set, build requests are not authenticated", config.EnvEventSigningSecret)
+	}
+
+	// 📞 Builds may carry a callbackUrl once callbacks can be signed
+	if cfg.CallbackSigningSecret != "" {
+		notifier, err := callbacks.NewNotifier(callbacks.Config{
+			Secret:       []byte(cfg.CallbackSigningSecret),
+			AllowedHosts: cfg.CallbackAllowedHosts,
+		})
+		if err != nil {
+			log.Fatalf("Invalid %s: %v", config.EnvCallbackSigningSecret, err)
+		}
+		eventHandler.WithCallbacks(notifier)
+	}
+
+	// 🪦 Requests of builds that failed for good: a broker or an S3 prefix
+	if strings.HasPrefix(cfg.DeadLetterSink, "s3://") {
+		bucket, prefix, err := templates.ParseS3URI(cfg.DeadLetterSink)
+		if err != nil {
+			log.Fatalf("Invalid %s: %v", config.EnvDeadLetterSink, err)
+		}
+		eventHandler.WithDeadLetterSink(events.NewObjectDeadLetters(storage.NewS3ObjectStore(awsClient.S3), bucket, prefix, tenantKeys))
+	} else if cfg.DeadLetterSink != "" {
+		deadLetterEmitter, err := events.NewEmitter(cfg.DeadLetterSink)
+		if err != nil {
+			log.Fatalf("Failed to create dead-letter emitter: %v", err)
+		}
+		eventHandler.WithDeadLetterSink(events.NewEmitterDeadLetters(deadLetterEmitter))
+	}
+
+	// 👀 Learn about finished jobs from the API server, not from resource.update events
+	if cfg.JobWatch {
+		eventHandler.WithJobWatcher(k8sClient.Clientset, cfg.KubernetesNamespace)
+		go eventHandler.WatchJobs(ctx)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "knative-lambda-builder"
+	}
+
+	// 🏃 Builds as BuildRun objects: status kept up to date, requests started
+	// 🤝 Pending BuildRuns not heartbeated within BUILD_VISIBILITY_TIMEOUT are
+	// taken over by a replica with idle workers
+	if cfg.BuildRuns {
+		eventHandler.WithBuildRuns(buildruns.NewStore(k8sClient.Dynamic, cfg.KubernetesNamespace).WithSealer(tenantKeys), cfg.BuildRunTTL).
+			WithWorkStealing(hostname, cfg.BuildVisibilityTimeout)
+		go eventHandler.WatchBuildRuns(ctx)
+	}
 
-	// eventHandler := events.NewHandler(buildOrchestrator, parserService)
+	// 🧵 Accepted builds wait in the build queue for one of a fixed pool of workers
+	// 📝 NOTE: Not bound to ctx: the workers keep starting accepted builds while
+	// the shutdown drains them
+	if cfg.BuildWorkers <= 0 {
+		log.Fatalf("Invalid %s %d: must be at least 1", config.EnvBuildWorkers, cfg.BuildWorkers)
+	}
+	go eventHandler.RunBuildWorkers(context.Background())
+
+	// 👑 Loops sweeping the whole namespace run in one replica, the leader
+	election := leader.Config{
+		Enabled:       cfg.LeaderElection,
+		Namespace:     cfg.KubernetesNamespace,
+		LeaseName:     leaderLeaseName,
+		Identity:      hostname,
+		LeaseDuration: cfg.LeaderElectionLeaseDuration,
+	}
+	if err := election.Validate(); err != nil {
+		log.Fatalf("Invalid %s: %v", config.EnvLeaderElection, err)
+	}
+	// 🗑️ Temp dirs are pod-local: every replica removes its own abandoned ones
+	go buildOrchestrator.StartTempDirCollector(ctx)
+
+	go leader.Run(ctx, k8sClient.Clientset, election, func(ctx context.Context) {
+		// 🐤 Step canary rollouts forward (or abort them)
+		go parserService.StartCanaryController(ctx)
+
+		// 🧹 Find (and delete) resources no parser owns anymore
+		go reconciler.Start(ctx)
+
+		// ⏱️ Fail builds whose jobs are stuck well past BUILD_TIMEOUT
+		go eventHandler.StartReaper(ctx, cfg.BuildReaperInterval)
+
+		// 🗑️ Collect old jobs and orphaned build contexts
+		go buildOrchestrator.StartGarbageCollector(ctx)
+
+		// 🐳 Bring existing tenant repositories in line with the repository settings
+		go buildOrchestrator.ReconcileRepositories(ctx)
+	})
 
 	// =============================================================================
-	// 📍 STEP 6: START CLOUDEVENTS RECEIVER
+	// 📍 STEP 6: START HTTP SERVER (CLOUDEVENTS + API)
 	// =============================================================================
-	// Same as before, but much cleaner
+	// CloudEvents are received on "/", the admin API lives next to it
 
 	p, err := cloudevents.NewHTTP()
 	if err != nil {
 		log.Fatalf("Failed to create CloudEvents protocol: %v", err)
 	}
 
-	c, err := cloudevents.NewClient(p)
+	receiver, err := cloudevents.NewHTTPReceiveHandler(ctx, p, eventHandler.HandleCloudEvent)
 	if err != nil {
-		log.Fatalf("Failed to create CloudEvents client: %v", err)
+		log.Fatalf("Failed to create CloudEvents receiver: %v", err)
 	}
 
-	log.Println("Starting CloudEvents receiver...")
+	server := api.NewServer()
+	server.RegisterTenantRoutes(tenantProvisioner, tenantStore)
+	server.RegisterReencryptRoutes(reencryptor, tenantStore)
+	server.RegisterBadgeRoutes(buildHistory)
+	server.RegisterBuildRoutes(eventHandler, buildHistory, encryptedHistory)
+	server.RegisterRevisionRoutes(buildOrchestrator, eventHandler)
+	server.RegisterOrphanRoutes(reconciler)
+	server.RegisterQueueRoutes(buildOrchestrator, eventHandler, api.Auditor{Replica: hostname, Emitter: emitter})
+	if cfg.ShareLinkSecret != "" {
+		signer, err := share.NewSigner([]byte(cfg.ShareLinkSecret))
+		if err != nil {
+			log.Fatalf("Invalid %s: %v", config.EnvShareLinkSecret, err)
+		}
+		server.RegisterShareRoutes(api.ShareLinks{
+			Signer:     signer,
+			DefaultTTL: cfg.ShareLinkDefaultTTL,
+			MaxTTL:     cfg.ShareLinkMaxTTL,
+			BaseURL:    cfg.ShareLinkBaseURL,
+		}, buildOrchestrator, encryptedHistory)
+	} else {
+		log.Printf("WARNING: %s not set, build share links are disabled", config.EnvShareLinkSecret)
+	}
+	server.Handle("GET /metrics", promhttp.Handler())
+	server.Handle("/", receiver)
+
+	// 🚚 Build requests from a broker instead of (next to) the HTTP receiver
+	// 📝 NOTE: Queue transports ack a request (commit its offset) once its
+	// build's job exists, so requests survive restarts
+	handleAcked := eventHandler.HandleAckedCloudEvent
+	switch cfg.Transport {
+	case transport.HTTP:
+	case transport.Kafka:
+		consumer, err := transport.NewKafkaConsumer(transport.KafkaConfig{
+			Brokers: cfg.KafkaBrokers,
+			Topic:   cfg.KafkaTopic,
+			Group:   cfg.KafkaGroup,
+		}, handleAcked)
+		if err != nil {
+			log.Fatalf("Failed to create Kafka consumer: %v", err)
+		}
+		go consumer.Start(ctx)
+	case transport.RabbitMQ:
+		consumer, err := transport.NewRabbitMQConsumer(transport.RabbitMQConfig{
+			URL:           cfg.RabbitMQURL,
+			Queue:         cfg.RabbitMQQueue,
+			Prefetch:      cfg.RabbitMQPrefetch,
+			MaxDeliveries: cfg.RabbitMQMaxDeliveries,
+		}, handleAcked)
+		if err != nil {
+			log.Fatalf("Failed to create RabbitMQ consumer: %v", err)
+		}
+		go consumer.Start(ctx)
+	case transport.NATS:
+		go func() {
+			if err := natsClient.Consume(ctx, handleAcked); err != nil {
+				log.Fatalf("Failed to consume from NATS: %v", err)
+			}
+		}()
+	default:
+		log.Fatalf("Invalid %s %q (http, kafka, rabbitmq or nats)", config.EnvTransport, cfg.Transport)
+	}
 
-	// In the refactored version, this would be:
-	// if err := c.StartReceiver(ctx, eventHandler.HandleCloudEvent); err != nil {
-	//     log.Fatalf("Failed to start receiver: %v", err)
-	// }
+	// 🔐 Bearer tokens on the receiver, the API and gRPC
+	var handler http.Handler = server
+	var authenticator *auth.Authenticator
+	if cfg.OIDCIssuers != "" {
+		var err error
+		authenticator, err = auth.NewAuthenticator(ctx, auth.Config{
+			Issuers:      config.List(cfg.OIDCIssuers),
+			Audiences:    config.List(cfg.OIDCAudiences),
+			TenantsClaim: cfg.OIDCTenantsClaim,
 
-	// For now, show the structure:
-	if err := c.StartReceiver(ctx, func(ctx context.Context, event cloudevents.Event) error {
-		log.Printf("📨 Received event: %s (would route to appropriate handler)", event.Type())
-		return nil
-	}); err != nil {
-		log.Fatalf("Failed to start receiver: %v", err)
+			TrustedSubjects: config.List(cfg.OIDCTrustedSubjects),
+		})
+		if err != nil {
+			log.Fatalf("Failed to set up OIDC authentication: %v", err)
+		}
+		handler = authenticator.Middleware(server)
+	} else {
+		log.Printf("WARNING: %s not set, the receiver, the API and gRPC are not authenticated", config.EnvOIDCIssuers)
 	}
+
+	// 📡 Optional gRPC BuildService on a secondary port
+	var grpcServer *grpc.Server
+	if cfg.GRPCPort != "" {
+		listener, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+		if err != nil {
+			log.Fatalf("Failed to listen on gRPC port %s: %v", cfg.GRPCPort, err)
+		}
+		var options []grpc.ServerOption
+		if authenticator != nil {
+			options = append(options,
+				grpc.UnaryInterceptor(authenticator.UnaryInterceptor()),
+				grpc.StreamInterceptor(authenticator.StreamInterceptor()))
+		}
+		grpcServer = grpc.NewServer(options...)
+		buildv1.RegisterBuildServiceServer(grpcServer, rpc.NewServer(eventHandler, buildHistory, encryptedHistory))
+		go func() {
+			log.Printf("Starting gRPC BuildService on :%s...", cfg.GRPCPort)
+			if err := grpcServer.Serve(listener); err != nil {
+				log.Fatalf("Failed to serve gRPC: %v", err)
+			}
+		}()
+	}
+
+	// 🛑 On SIGTERM: stop taking requests (gRPC and HTTP), let accepted builds
+	// get their jobs, then exit (releasing the leader lease)
+	httpServer := &http.Server{Addr: ":" + cfg.Port, Handler: handler}
+	serversStopped := make(chan struct{})
+	go func() {
+		defer close(serversStopped)
+		<-ctx.Done()
+		log.Printf("🛑 Shutting down, waiting up to %s for accepted builds", cfg.ShutdownTimeout)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		if grpcServer != nil {
+			stopGRPC(shutdownCtx, grpcServer)
+		}
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			log.Printf("WARNING: Failed to stop the HTTP server gracefully: %v", err)
+		}
+	}()
+
+	log.Printf("Starting CloudEvents receiver and API on :%s...", cfg.Port)
+	if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatalf("Failed to start server: %v", err)
+	}
+	// 📝 NOTE: ListenAndServe returns as soon as the shutdown starts; builds
+	// submitted until both servers stopped must be drained too
+	<-serversStopped
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if waiting := eventHandler.Drain(drainCtx); waiting > 0 {
+		log.Printf("WARNING: Stopped with %d accepted builds still waiting for their job", waiting)
+	}
+	log.Println("Stopped knative-lambda-builder")
 }
 
+// stopGRPC stops the gRPC server once its calls finished, or at once when ctx
+// is done first (e.g. a WatchBuild stream that never ends)
+func stopGRPC(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		log.Printf("WARNING: gRPC calls still running after the shutdown timeout, closing them")
+		server.Stop()
+	}
+}
+
+// leaderLeaseName names the Lease builder replicas elect their leader with
+const leaderLeaseName = "knative-lambda-builder"
+
 // =============================================================================
 // 🎯 BENEFITS OF THIS REFACTORED STRUCTURE
 // =============================================================================
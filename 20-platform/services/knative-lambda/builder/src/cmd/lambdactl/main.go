@@ -0,0 +1,308 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"knative-lambda-builder/internal/encryption"
+	"knative-lambda-builder/internal/tenants"
+)
+
+// =============================================================================
+// 🛠️ LAMBDACTL - ADMIN CLI FOR THE KNATIVE-LAMBDA BUILDER
+// =============================================================================
+// Thin client over the builder's admin API
+// 🎯 PURPOSE: Onboard and inspect tenants and share builds without crafting HTTP requests by hand
+//
+// 💡 USAGE:
+//   lambdactl tenant create --third-party-id acme [--role-arn ARN] [--notify URL] [--kms-key ARN] [--context-retention 72h]
+//       [--sidecar redis-cache] [--sidecar invoice-created=soap-adapter] [--build-rate-limit 30/1h]
+//       [--source-role-arn ARN] [--source-bucket acme-parsers]
+//   lambdactl tenant list
+//   lambdactl tenant get acme
+//   lambdactl tenant reencrypt acme
+//   lambdactl build share acme invoice-created [--ttl 24h] [--job build-...]
+//
+// The builder URL comes from --server or $LAMBDACTL_SERVER
+// (default http://localhost:8080, e.g. via kubectl port-forward)
+
+const defaultServer = "http://localhost:8080"
+
+func main() {
+	if len(os.Args) < 3 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] + " " + os.Args[2] {
+	case "tenant create":
+		err = tenantCreate(os.Args[3:])
+	case "tenant list":
+		err = tenantList(os.Args[3:])
+	case "tenant get":
+		err = tenantGet(os.Args[3:])
+	case "tenant reencrypt":
+		err = tenantReencrypt(os.Args[3:])
+	case "build share":
+		err = buildShare(os.Args[3:])
+	default:
+		usage()
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: lambdactl tenant <create|list|get|reencrypt> [flags]")
+	fmt.Fprintln(os.Stderr, "       lambdactl build share <thirdPartyId> <parserId> [flags]")
+	os.Exit(2)
+}
+
+// serverFlag registers the --server flag shared by all commands
+func serverFlag(fs *flag.FlagSet) *string {
+	server := os.Getenv("LAMBDACTL_SERVER")
+	if server == "" {
+		server = defaultServer
+	}
+	return fs.String("server", server, "builder base URL")
+}
+
+// =============================================================================
+// 🏢 TENANT COMMANDS
+// =============================================================================
+
+func tenantCreate(args []string) error {
+	fs := flag.NewFlagSet("tenant create", flag.ExitOnError)
+	server := serverFlag(fs)
+	req := tenants.Request{}
+	fs.StringVar(&req.ThirdPartyId, "third-party-id", "", "tenant identifier (required)")
+	fs.StringVar(&req.RoleARN, "role-arn", "", "IAM role granted access to the tenant's S3 prefix")
+	fs.StringVar(&req.SourceRoleARN, "source-role-arn", "", "IAM role the builder assumes to read the tenant's parser sources")
+	fs.StringVar(&req.SourceBucket, "source-bucket", "", "the tenant's own S3 bucket holding its parser sources (default: the platform's)")
+	fs.StringVar(&req.NotificationChannel, "notify", "", "http(s) URL receiving build notifications")
+	fs.StringVar(&req.KMSKeyARN, "kms-key", "", "KMS key encrypting the tenant's build records and artifacts")
+	fs.StringVar(&req.ContextRetention, "context-retention", "", "how long build contexts are kept once built (default: the builder's CONTEXT_RETENTION)")
+	fs.StringVar(&req.BuildRateLimit, "build-rate-limit", "", "builds the tenant may submit per period, e.g. 30/1h (default: the builder's BUILD_RATE_LIMIT)")
+	fs.StringVar(&req.Platforms, "platforms", "", "platforms the tenant's images are built for, e.g. linux/amd64,linux/arm64 (default: the builder's BUILD_PLATFORMS)")
+	fs.StringVar(&req.DeployStrategy, "deploy-strategy", "", "how redeploys shift traffic: rolling, canary or blue-green (default: the builder's DEPLOY_STRATEGY)")
+	fs.StringVar(&req.BuildRequests, "build-requests", "", "requests of the tenant's build pods, e.g. cpu=1,memory=2Gi (default: the builder's BUILD_RESOURCE_REQUESTS)")
+	fs.StringVar(&req.BuildLimits, "build-limits", "", "limits of the tenant's build pods, e.g. memory=6Gi (default: the builder's BUILD_RESOURCE_LIMITS)")
+	fs.Func("sidecar", "catalog sidecar for every parser (name) or one parser (parserId=name), repeatable", func(value string) error {
+		parserId, name, found := strings.Cut(value, "=")
+		if !found {
+			parserId, name = "*", value
+		}
+		if parserId == "" || name == "" {
+			return fmt.Errorf("expected name or parserId=name, got %q", value)
+		}
+		if req.Sidecars == nil {
+			req.Sidecars = map[string][]string{}
+		}
+		req.Sidecars[parserId] = append(req.Sidecars[parserId], name)
+		return nil
+	})
+	fs.Parse(args)
+
+	if req.ThirdPartyId == "" {
+		return fmt.Errorf("--third-party-id is required")
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	var report tenants.Report
+	status, err := call(http.MethodPost, *server+"/admin/tenants", body, &report)
+	if err != nil {
+		return err
+	}
+
+	printReport(req.ThirdPartyId, report)
+	if status != http.StatusOK || !report.Success {
+		return fmt.Errorf("tenant %s was not fully provisioned; re-run after fixing the failed steps", req.ThirdPartyId)
+	}
+	return nil
+}
+
+func tenantList(args []string) error {
+	fs := flag.NewFlagSet("tenant list", flag.ExitOnError)
+	server := serverFlag(fs)
+	fs.Parse(args)
+
+	var list []tenants.Tenant
+	if _, err := call(http.MethodGet, *server+"/admin/tenants", nil, &list); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "THIRD PARTY ID\tNAMESPACE\tECR REPOSITORY\tCREATED")
+	for _, t := range list {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", t.ThirdPartyId, t.Namespace, t.ECRRepository, t.CreatedAt.Format(time.RFC3339))
+	}
+	return w.Flush()
+}
+
+func tenantGet(args []string) error {
+	fs := flag.NewFlagSet("tenant get", flag.ExitOnError)
+	server := serverFlag(fs)
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: lambdactl tenant get <thirdPartyId>")
+	}
+
+	var tenant tenants.Tenant
+	if _, err := call(http.MethodGet, *server+"/admin/tenants/"+fs.Arg(0), nil, &tenant); err != nil {
+		return err
+	}
+
+	out, err := json.MarshalIndent(tenant, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// tenantReencrypt rewrites a tenant's stored data with its current KMS key
+// 📝 NOTE: Run after changing the key with "tenant create --kms-key"; keep the
+// old key enabled until this reports no errors
+func tenantReencrypt(args []string) error {
+	fs := flag.NewFlagSet("tenant reencrypt", flag.ExitOnError)
+	server := serverFlag(fs)
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: lambdactl tenant reencrypt <thirdPartyId>")
+	}
+
+	var report encryption.ReencryptReport
+	status, err := call(http.MethodPost, *server+"/admin/tenants/"+fs.Arg(0)+"/reencrypt", nil, &report)
+	if err != nil {
+		return err
+	}
+
+	key := report.KeyID
+	if key == "" {
+		key = "(none, stored unencrypted)"
+	}
+	fmt.Printf("Tenant %s re-encrypted with %s\n", report.ThirdPartyId, key)
+	fmt.Printf("  parsers:   %d\n  records:   %d\n  artifacts: %d\n", report.Parsers, report.Records, report.Artifacts)
+	for _, e := range report.Errors {
+		fmt.Printf("  ❌ %s\n", e)
+	}
+
+	if status != http.StatusOK || len(report.Errors) > 0 {
+		return fmt.Errorf("re-encryption of %s incomplete; keep the previous key enabled and re-run", report.ThirdPartyId)
+	}
+	return nil
+}
+
+// =============================================================================
+// 🏗️ BUILD COMMANDS
+// =============================================================================
+
+// buildShare mints an expiring link to a build's logs and artifacts
+func buildShare(args []string) error {
+	fs := flag.NewFlagSet("build share", flag.ExitOnError)
+	server := serverFlag(fs)
+	ttl := fs.String("ttl", "", "link lifetime, e.g. 24h (default: builder's SHARE_LINK_TTL)")
+	job := fs.String("job", "", "build job to share (default: the parser's last build)")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: lambdactl build share <thirdPartyId> <parserId> [--ttl 24h] [--job build-...]")
+	}
+
+	issuedBy := os.Getenv("USER")
+	body, err := json.Marshal(map[string]string{"ttl": *ttl, "jobName": *job, "issuedBy": issuedBy})
+	if err != nil {
+		return err
+	}
+
+	var link struct {
+		URL       string            `json:"url"`
+		ExpiresAt time.Time         `json:"expiresAt"`
+		Artifacts map[string]string `json:"artifacts"`
+	}
+	url := fmt.Sprintf("%s/admin/builds/%s/%s/share", *server, fs.Arg(0), fs.Arg(1))
+	if _, err := call(http.MethodPost, url, body, &link); err != nil {
+		return err
+	}
+
+	fmt.Printf("🔗 %s\n   expires %s\n", link.URL, link.ExpiresAt.Local().Format(time.RFC1123))
+	for _, name := range []string{"logs.txt", "test-report.txt", "inputs.json", "context.tar.gz"} {
+		if u, ok := link.Artifacts[name]; ok {
+			fmt.Printf("   %-16s %s\n", name, u)
+		}
+	}
+	return nil
+}
+
+// =============================================================================
+// 🔧 HELPERS
+// =============================================================================
+
+// call performs an API request and decodes the JSON response into out
+// 📝 NOTE: 207 Multi-Status is decoded too (partial provisioning or re-encryption)
+func call(method, url string, body []byte, out interface{}) (int, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 2 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusMultiStatus {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(raw, &apiErr) == nil && apiErr.Error != "" {
+			return resp.StatusCode, fmt.Errorf("%s: %s", resp.Status, apiErr.Error)
+		}
+		return resp.StatusCode, fmt.Errorf("%s: %s", resp.Status, string(raw))
+	}
+
+	return resp.StatusCode, json.Unmarshal(raw, out)
+}
+
+// printReport renders the provisioning report as a table
+func printReport(thirdPartyId string, report tenants.Report) {
+	fmt.Printf("Tenant %s onboarding report:\n\n", thirdPartyId)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "STEP\tSTATUS\tDETAILS")
+	for _, step := range report.Steps {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", step.Step, step.Status, step.Message)
+	}
+	w.Flush()
+
+	if report.Success {
+		fmt.Println("\n✅ Tenant ready")
+	} else {
+		fmt.Println("\n❌ Some steps failed")
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"knative-lambda-builder/internal/k8s"
+	"knative-lambda-builder/internal/statuspage"
+)
+
+// =============================================================================
+// 🚦 STATUSPAGE - PLATFORM STATUS PAGE
+// =============================================================================
+// Polls the readiness and key metrics of the builder and the stooges, keeps
+// 90 days of uptime in a ConfigMap and serves a public status page
+//
+// 💡 CONFIGURATION:
+//   STATUSPAGE_TARGETS_FILE  JSON list of targets (default: builder + stooges)
+//   STATUSPAGE_INTERVAL      polling interval (default 30s)
+//   STATUSPAGE_TIMEOUT       timeout of a single probe (default 5s)
+//   STATUSPAGE_NAMESPACE     namespace of the uptime ConfigMap (default knative-lambda)
+//   PORT                     listen port (default 8080)
+//
+// Without Kubernetes access (local runs) the uptime history is kept in memory
+
+func main() {
+	log.Println("Starting statuspage...")
+
+	targets, err := statuspage.LoadTargets(os.Getenv("STATUSPAGE_TARGETS_FILE"))
+	if err != nil {
+		log.Fatalf("Invalid targets: %v", err)
+	}
+
+	var store statuspage.Store = statuspage.NewMemoryStore()
+	if k8sClient, err := k8s.NewClient(); err != nil {
+		log.Printf("WARNING: No Kubernetes access, uptime history is kept in memory: %v", err)
+	} else {
+		store = statuspage.NewConfigMapStore(k8sClient.Clientset, getEnvOrDefault("STATUSPAGE_NAMESPACE", "knative-lambda"))
+	}
+
+	monitor := statuspage.NewMonitor(targets, store,
+		getEnvDurationOrDefault("STATUSPAGE_INTERVAL", 30*time.Second),
+		getEnvDurationOrDefault("STATUSPAGE_TIMEOUT", 5*time.Second))
+	go monitor.Start(context.Background())
+
+	port := getEnvOrDefault("PORT", "8080")
+	log.Printf("Monitoring %d services, serving the status page on :%s", len(targets), port)
+	if err := http.ListenAndServe(":"+port, monitor.Handler()); err != nil {
+		log.Fatalf("Failed to start server: %v", err)
+	}
+}
+
+func getEnvOrDefault(envVar, defaultValue string) string {
+	if value := os.Getenv(envVar); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvDurationOrDefault(envVar string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(envVar)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("WARNING: Invalid %s=%q, using default %s", envVar, value, defaultValue)
+		return defaultValue
+	}
+	return d
+}
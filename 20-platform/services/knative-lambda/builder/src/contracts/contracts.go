@@ -0,0 +1,219 @@
+package contracts
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// =============================================================================
+// 📜 EVENT CONTRACTS
+// =============================================================================
+// The payloads of the CloudEvents the builder exchanges are a public API.
+// Every event type has versioned JSON Schemas with a golden example:
+//
+//   schemas/<event type>/v<N>.schema.json
+//   schemas/<event type>/v<N>.example.json
+//
+// 🎯 PURPOSE: Downstream consumers verify against the schemas (see Verify),
+// the builder verifies its payloads against them in its own tests, and
+// schemas.sum freezes published versions so a breaking change can only ship
+// as a new version
+// 📝 NOTE: Consumers must ignore unknown fields: adding an optional field is
+// a compatible change within a version, everything else needs v<N+1>
+
+//go:embed schemas schemas.sum
+var files embed.FS
+
+// Direction tells who produces an event
+type Direction string
+
+const (
+	Emitted  Direction = "emitted"  // Produced by the builder
+	Consumed Direction = "consumed" // Produced by others, consumed by the builder
+)
+
+// Contract is one version of an event type's payload schema
+type Contract struct {
+	Type      string
+	Version   int
+	Direction Direction
+}
+
+// registry lists every published contract
+// 📋 Adding a version: add the schema + example files, the entry below and
+// the schema's line in schemas.sum (sha256sum schemas/*/*.schema.json)
+var registry = []Contract{
+	{Type: "network.notifi.lambda.build.start", Version: 1, Direction: Consumed},
+	{Type: "network.notifi.lambda.teardown", Version: 1, Direction: Consumed},
+	{Type: "network.notifi.lambda.rebuild", Version: 1, Direction: Consumed},
+	{Type: "network.notifi.lambda.build.batch", Version: 1, Direction: Consumed},
+	{Type: "network.notifi.lambda.rollback", Version: 1, Direction: Consumed},
+	{Type: "dev.knative.apiserver.resource.update", Version: 1, Direction: Consumed},
+	{Type: "network.notifi.lambda.build.accepted", Version: 1, Direction: Emitted},
+	{Type: "network.notifi.lambda.build.started", Version: 1, Direction: Emitted},
+	{Type: "network.notifi.lambda.build.skipped", Version: 1, Direction: Emitted},
+	{Type: "network.notifi.lambda.build.image.pushed", Version: 1, Direction: Emitted},
+	{Type: "network.notifi.lambda.build.deployed", Version: 1, Direction: Emitted},
+	{Type: "network.notifi.lambda.build.failed", Version: 1, Direction: Emitted},
+	{Type: "network.notifi.lambda.build.retrying", Version: 1, Direction: Emitted},
+	{Type: "network.notifi.lambda.build.timeout", Version: 1, Direction: Emitted},
+	{Type: "network.notifi.lambda.build.rejected", Version: 1, Direction: Emitted},
+	{Type: "network.notifi.lambda.build.blocked", Version: 1, Direction: Emitted},
+	{Type: "network.notifi.lambda.build.deadletter", Version: 1, Direction: Emitted},
+	{Type: "network.notifi.lambda.batch.completed", Version: 1, Direction: Emitted},
+	{Type: "network.notifi.lambda.trigger.failed", Version: 1, Direction: Emitted},
+	{Type: "network.notifi.lambda.rollback.completed", Version: 1, Direction: Emitted},
+	{Type: "network.notifi.lambda.admin.audit", Version: 1, Direction: Emitted},
+}
+
+// All returns every contract, ordered by type and version
+func All() []Contract {
+	all := append([]Contract(nil), registry...)
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Type != all[j].Type {
+			return all[i].Type < all[j].Type
+		}
+		return all[i].Version < all[j].Version
+	})
+	return all
+}
+
+// Latest returns the newest contract of an event type
+func Latest(eventType string) (Contract, bool) {
+	var latest Contract
+	for _, c := range registry {
+		if c.Type == eventType && c.Version > latest.Version {
+			latest = c
+		}
+	}
+	return latest, latest.Version > 0
+}
+
+// ForEvent returns the contract an incoming payload follows: the version its
+// dataschema attribute names, else the newest one of the event type
+func ForEvent(eventType, dataSchema string) (Contract, bool) {
+	for _, c := range registry {
+		if c.Type == eventType && c.SchemaURI() == dataSchema {
+			return c, true
+		}
+	}
+	return Latest(eventType)
+}
+
+// SchemaURI identifies the schema (used as the CloudEvents dataschema attribute)
+func (c Contract) SchemaURI() string {
+	return fmt.Sprintf("urn:knative-lambda:schema:%s:v%d", c.Type, c.Version)
+}
+
+// SchemaPath is the schema's path in this package
+func (c Contract) SchemaPath() string {
+	return path.Join("schemas", c.Type, fmt.Sprintf("v%d.schema.json", c.Version))
+}
+
+// ExamplePath is the golden example's path in this package
+func (c Contract) ExamplePath() string {
+	return path.Join("schemas", c.Type, fmt.Sprintf("v%d.example.json", c.Version))
+}
+
+// Schema returns the raw JSON Schema
+func (c Contract) Schema() ([]byte, error) {
+	return files.ReadFile(c.SchemaPath())
+}
+
+// Example returns the golden example payload
+func (c Contract) Example() ([]byte, error) {
+	return files.ReadFile(c.ExamplePath())
+}
+
+// Validate checks a JSON payload against the contract's schema
+func (c Contract) Validate(data []byte) error {
+	schema, err := c.compile()
+	if err != nil {
+		return err
+	}
+
+	var payload interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		return fmt.Errorf("invalid %s v%d payload: %w", c.Type, c.Version, err)
+	}
+	if err := schema.Validate(payload); err != nil {
+		return fmt.Errorf("%s v%d payload violates its contract: %w", c.Type, c.Version, err)
+	}
+	return nil
+}
+
+// Violations lists what a Validate error found wrong, one "<location>: <problem>"
+// per violated keyword (e.g. "/thirdPartyId: length must be >= 1, but got 0")
+func Violations(err error) []string {
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return []string{err.Error()}
+	}
+
+	var violations []string
+	var walk func(*jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			location := e.InstanceLocation
+			if location == "" {
+				location = "/"
+			}
+			violations = append(violations, location+": "+e.Message)
+		}
+		for _, cause := range e.Causes {
+			walk(cause)
+		}
+	}
+	walk(validationErr)
+	return violations
+}
+
+// ValidateValue marshals a Go value and validates it
+func (c Contract) ValidateValue(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s payload: %w", c.Type, err)
+	}
+	return c.Validate(data)
+}
+
+// Compiled schemas, keyed by SchemaURI
+var (
+	compiledMu sync.Mutex
+	compiled   = map[string]*jsonschema.Schema{}
+)
+
+func (c Contract) compile() (*jsonschema.Schema, error) {
+	compiledMu.Lock()
+	defer compiledMu.Unlock()
+
+	uri := c.SchemaURI()
+	if schema, ok := compiled[uri]; ok {
+		return schema, nil
+	}
+
+	raw, err := c.Schema()
+	if err != nil {
+		return nil, fmt.Errorf("no schema for %s v%d: %w", c.Type, c.Version, err)
+	}
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(uri, bytes.NewReader(raw)); err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %w", c.SchemaPath(), err)
+	}
+	schema, err := compiler.Compile(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %w", c.SchemaPath(), err)
+	}
+	compiled[uri] = schema
+	return schema, nil
+}
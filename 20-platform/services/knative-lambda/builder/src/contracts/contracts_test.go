@@ -0,0 +1,266 @@
+package contracts_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"knative-lambda-builder/contracts"
+	"knative-lambda-builder/internal/api"
+	"knative-lambda-builder/internal/events"
+	"knative-lambda-builder/internal/types"
+)
+
+// emittedPayloads are fully populated payloads, as the builder emits them
+var emittedPayloads = map[string]interface{}{
+	events.EventTypeTriggerFailed: types.TriggerFailedEventData{
+		ThirdPartyId: "acme",
+		ParserId:     "invoice-created",
+		Kind:         "Trigger",
+		Namespace:    "knative-lambda",
+		Name:         "lambda-acme-invoice-created",
+		Condition:    "Ready",
+		Reason:       "Timeout",
+		Message:      "not Ready after 2m0s",
+	},
+	events.EventTypeRollbackCompleted: types.RollbackCompletedEventData{
+		ThirdPartyId: "acme",
+		ParserId:     "invoice-created",
+		RollbackId:   "r-1",
+		ImageTag:     "invoice-created-v3",
+		Image:        "123456789012.dkr.ecr.us-west-2.amazonaws.com/knative-lambdas/acme:invoice-created-v3",
+		ImageDigest:  "sha256:aaa",
+		DeployMode:   "knative",
+	},
+	events.EventTypeBuildAccepted: types.BuildLifecycleEventData{
+		ThirdPartyId: "acme",
+		ParserId:     "invoice-created",
+		BuildId:      "b-1",
+	},
+	events.EventTypeBuildStarted: types.BuildLifecycleEventData{
+		ThirdPartyId: "acme",
+		ParserId:     "invoice-created",
+		Attempt:      1,
+		JobName:      "build-acme-invoice-created-1",
+		Image:        "registry/knative-lambdas/acme:invoice-created",
+	},
+	events.EventTypeBuildSkipped: types.BuildLifecycleEventData{
+		ThirdPartyId: "acme",
+		ParserId:     "invoice-created",
+		Image:        "registry/knative-lambdas/acme:invoice-created-v3",
+		ImageDigest:  "sha256:aaa",
+		Cached:       true,
+		SourceSHA256: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+		SourceETag:   "d41d8cd98f00b204e9800998ecf8427e",
+	},
+	events.EventTypeBuildRetrying: types.BuildLifecycleEventData{
+		ThirdPartyId: "acme",
+		ParserId:     "invoice-created",
+		BuildId:      "b-1",
+		Retry:        1,
+		JobName:      "build-acme-invoice-created-1",
+		RetryIn:      31,
+		Error:        "build job build-acme-invoice-created-1 failed (BackoffLimitExceeded: Job has reached the specified backoff limit)",
+	},
+	events.EventTypeBuildTimeout: types.BuildLifecycleEventData{
+		ThirdPartyId: "acme",
+		ParserId:     "invoice-created",
+		BuildId:      "b-1",
+		JobName:      "build-acme-invoice-created-1",
+		Reason:       events.TimeoutDeadlineExceeded,
+		Error:        "build job build-acme-invoice-created-1 exceeded its deadline of 30m0s",
+	},
+	events.EventTypeBuildImagePushed: types.BuildLifecycleEventData{
+		ThirdPartyId: "acme",
+		ParserId:     "invoice-created",
+		JobName:      "build-acme-invoice-created-1",
+		Image:        "registry/knative-lambdas/acme:invoice-created",
+		ImageDigest:  "sha256:aaa",
+	},
+	events.EventTypeBuildDeployed: types.BuildLifecycleEventData{
+		ThirdPartyId: "acme",
+		ParserId:     "invoice-created",
+		Image:        "registry/knative-lambdas/acme:invoice-created",
+		Cached:       true,
+		DeployMode:   "fallback",
+	},
+	events.EventTypeBuildFailed: types.BuildLifecycleEventData{
+		ThirdPartyId: "acme",
+		ParserId:     "invoice-created",
+		Stage:        events.StageDeploy,
+		Error:        "failed to render service template",
+	},
+	events.EventTypeBuildBlocked: types.BuildLifecycleEventData{
+		ThirdPartyId: "acme",
+		ParserId:     "invoice-created",
+		BuildId:      "b-1",
+		Image:        "registry/knative-lambdas/acme:invoice-created-v4",
+		ScanStatus:   "COMPLETE",
+		Findings:     map[string]int{"CRITICAL": 2, "HIGH": 5},
+		Error:        "2 critical findings (at most 0 allowed)",
+	},
+	events.EventTypeBuildRejected: types.BuildLifecycleEventData{
+		ThirdPartyId: "acme",
+		ParserId:     "invoice-created",
+		BuildId:      "b-1",
+		Reason:       events.RejectedRateLimited,
+		RetryAfter:   120,
+		Error:        "tenant acme exceeded its build rate limit (30 per 1h0m0s), retry in 2m0s",
+	},
+	events.EventTypeBuildDeadLetter: types.DeadLetterEventData{
+		ThirdPartyId: "acme",
+		ParserId:     "invoice-created",
+		BuildId:      "b-1",
+		Stage:        events.StageTest,
+		JobName:      "test-acme-invoice-created-1",
+		Error:        "parser tests failed in job test-acme-invoice-created-1",
+		FailedAt:     time.Date(2024, 5, 2, 10, 15, 0, 0, time.UTC),
+		Request: types.RequestOrigin{
+			Type:   events.EventTypeBuildStart,
+			ID:     "b-1",
+			Source: "parser-upload",
+			Data:   json.RawMessage(`{"thirdPartyId":"acme","parserId":"invoice-created","id":"b-1"}`),
+		},
+	},
+	api.EventTypeAdminAudit: types.AdminAuditEventData{
+		Caller:       "oncall@example.com",
+		Issuer:       "https://accounts.example.com",
+		Replica:      "knative-lambda-builder-7d9f8c6b5-x2k4p",
+		Method:       "POST",
+		Path:         "/admin/queue/b-1/drop",
+		Action:       "drop",
+		Status:       200,
+		BuildId:      "b-1",
+		ThirdPartyId: "acme",
+		ParserId:     "invoice-created",
+		At:           time.Date(2024, 5, 2, 10, 15, 0, 0, time.UTC),
+	},
+	events.EventTypeBatchCompleted: types.BatchCompletedEventData{
+		ThirdPartyId: "acme",
+		BatchId:      "batch-1",
+		Deployed:     1,
+		Failed:       1,
+		Builds: []types.BatchBuildResult{
+			{ParserId: "invoice-created", BuildId: "batch-1-invoice-created", Status: events.BatchBuildDeployed},
+			{ParserId: "invoice-paid", BuildId: "batch-1-invoice-paid", Status: events.BatchBuildFailed, Stage: events.StageBuild, Error: "build job failed"},
+		},
+	},
+}
+
+// TestContracts runs the suite with the builder as consumer of the events it handles
+func TestContracts(t *testing.T) {
+	contracts.Verify(t, func(eventType string, version int, data []byte) error {
+		switch eventType {
+		case events.EventTypeBuildStart, events.EventTypeRebuild:
+			var be types.BuildEvent
+			if err := json.Unmarshal(data, &be); err != nil {
+				return err
+			}
+			if be.ThirdPartyId == "" || be.ParserId == "" {
+				return fmt.Errorf("decoded build event lacks ids: %+v", be)
+			}
+		case events.EventTypeTeardown:
+			var teardown types.TeardownEvent
+			if err := json.Unmarshal(data, &teardown); err != nil {
+				return err
+			}
+			if teardown.ThirdPartyId == "" || teardown.ParserId == "" {
+				return fmt.Errorf("decoded teardown event lacks ids: %+v", teardown)
+			}
+		case events.EventTypeRollback:
+			var rollback types.RollbackEvent
+			if err := json.Unmarshal(data, &rollback); err != nil {
+				return err
+			}
+			if rollback.ThirdPartyId == "" || rollback.ParserId == "" || rollback.ImageTag == "" {
+				return fmt.Errorf("decoded rollback event lacks ids or tag: %+v", rollback)
+			}
+		case events.EventTypeBuildBatch:
+			var batch types.BatchBuildEvent
+			if err := json.Unmarshal(data, &batch); err != nil {
+				return err
+			}
+			if batch.ThirdPartyId == "" || len(batch.ParserIds) == 0 {
+				return fmt.Errorf("decoded batch event lacks tenant or parsers: %+v", batch)
+			}
+		case events.EventTypeResourceUpdate:
+			var resource types.ResourceEventData
+			if err := json.Unmarshal(data, &resource); err != nil {
+				return err
+			}
+			if resource.Kind == "" || len(resource.Metadata.Labels) == 0 {
+				return fmt.Errorf("decoded resource event lacks kind or labels: %+v", resource)
+			}
+		}
+		return nil
+	})
+}
+
+// TestEmittedPayloads fails when a Go payload type drifts from its contract
+func TestEmittedPayloads(t *testing.T) {
+	for _, c := range contracts.All() {
+		if c.Direction != contracts.Emitted {
+			continue
+		}
+		if latest, _ := contracts.Latest(c.Type); latest.Version != c.Version {
+			continue // Older versions are no longer emitted
+		}
+		payload, ok := emittedPayloads[c.Type]
+		if !ok {
+			t.Errorf("no payload for emitted event %s in emittedPayloads", c.Type)
+			continue
+		}
+		if err := c.ValidateValue(payload); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if _, ok := contracts.Latest(events.EventTypeTriggerFailed); !ok {
+		t.Errorf("%s has no contract", events.EventTypeTriggerFailed)
+	}
+}
+
+func TestValidateRejectsBreakingPayloads(t *testing.T) {
+	c, _ := contracts.Latest(events.EventTypeTriggerFailed)
+	if err := c.Validate([]byte(`{"thirdPartyId":"acme","parserId":"p1"}`)); err == nil {
+		t.Error("payload missing required fields should violate the contract")
+	}
+	if err := c.Validate([]byte(`{"thirdPartyId":"acme","parserId":"p1","kind":"Trigger","namespace":"ns","name":"t","condition":"Ready","extra":true}`)); err != nil {
+		t.Errorf("additional fields are a compatible change: %v", err)
+	}
+}
+
+func TestViolations(t *testing.T) {
+	c, _ := contracts.ForEvent(events.EventTypeBuildStart, "")
+	err := c.Validate([]byte(`{"thirdPartyID":"acme","parserId":""}`))
+	if err == nil {
+		t.Fatal("typo'd and empty ids should violate the contract")
+	}
+
+	got := strings.Join(contracts.Violations(err), "\n")
+	for _, want := range []string{"/: missing properties: 'thirdPartyId'", "/parserId: length must be >= 1"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Violations() = %q, want it to mention %q", got, want)
+		}
+	}
+}
+
+func TestBuildStartRejectsUnsafeIDs(t *testing.T) {
+	c, _ := contracts.ForEvent(events.EventTypeBuildStart, "")
+	for _, payload := range []string{
+		`{"thirdPartyId":"..","parserId":"p1"}`,
+		`{"thirdPartyId":"acme","parserId":"../../etc"}`,
+		`{"thirdPartyId":"acme/other","parserId":"p1"}`,
+		`{"thirdPartyId":"Acme","parserId":"p1"}`,
+		`{"thirdPartyId":"acme","parserId":"p1-"}`,
+	} {
+		if err := c.Validate([]byte(payload)); err == nil {
+			t.Errorf("%s should violate the contract", payload)
+		}
+	}
+	if err := c.Validate([]byte(`{"thirdPartyId":"acme-1","parserId":"p1"}`)); err != nil {
+		t.Errorf("DNS-1123 ids should satisfy the contract: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package contracts
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// =============================================================================
+// ✅ VERIFICATION SUITE
+// =============================================================================
+// Run by the builder's own tests and by downstream consumers:
+//
+//	func TestBuilderContracts(t *testing.T) {
+//		contracts.Verify(t, func(eventType string, version int, data []byte) error {
+//			return myconsumer.Handle(eventType, data) // Must accept the golden example
+//		})
+//	}
+//
+// Consumers in other languages can use the schema and example files directly.
+
+// Consumer decodes a payload the way a consumer does; nil consumer skips that part
+type Consumer func(eventType string, version int, data []byte) error
+
+// Verify checks every contract: schemas compile, are frozen (schemas.sum),
+// golden examples satisfy them and, if given, the consumer accepts the examples
+func Verify(t *testing.T, consume Consumer) {
+	t.Helper()
+
+	sums, err := publishedSums()
+	if err != nil {
+		t.Fatalf("failed to read schemas.sum: %v", err)
+	}
+
+	for _, c := range All() {
+		t.Run(fmt.Sprintf("%s/v%d", c.Type, c.Version), func(t *testing.T) {
+			schema, err := c.Schema()
+			if err != nil {
+				t.Fatalf("missing schema: %v", err)
+			}
+			sum := sha256.Sum256(schema)
+			switch published, ok := sums[c.SchemaPath()]; {
+			case !ok:
+				t.Errorf("%s is not listed in schemas.sum", c.SchemaPath())
+			case published != hex.EncodeToString(sum[:]):
+				t.Errorf("%s changed after it was published; add v%d instead of editing it", c.SchemaPath(), c.Version+1)
+			}
+
+			example, err := c.Example()
+			if err != nil {
+				t.Fatalf("missing golden example: %v", err)
+			}
+			if err := c.Validate(example); err != nil {
+				t.Errorf("golden example: %v", err)
+			}
+
+			if consume != nil {
+				if err := consume(c.Type, c.Version, example); err != nil {
+					t.Errorf("consumer rejected the golden example: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// publishedSums reads schemas.sum ("<sha256>  <path>" per line, as sha256sum writes it)
+func publishedSums() (map[string]string, error) {
+	raw, err := files.ReadFile("schemas.sum")
+	if err != nil {
+		return nil, err
+	}
+	sums := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		sums[fields[1]] = fields[0]
+	}
+	return sums, scanner.Err()
+}
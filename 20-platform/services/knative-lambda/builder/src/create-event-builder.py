@@ -1,11 +1,23 @@
 #!/usr/bin/env python3
 # Create a CloudEvent named network.notifi.lambda.build
 # it shall have thirdPartyId and parserId as payload
+import hashlib
+import hmac
 import json
+import os
 import uuid
 import datetime
 import pika
 
+# Signs the event when the builder requires it (same secret as the builder's)
+SIGNING_SECRET = os.environ.get("EVENT_SIGNING_SECRET", "")
+
+# Sign a CloudEvent: sha256=HMAC(secret, id \n type \n source \n data)
+def sign_cloud_event(event, secret):
+    message = f"{event['id']}\n{event['type']}\n{event['source']}\n".encode() + json.dumps(event["data"]).encode()
+    event["signature"] = "sha256=" + hmac.new(secret.encode(), message, hashlib.sha256).hexdigest()
+    return event
+
 # Create the CloudEvent
 def create_cloud_event(third_party_id, parser_id):
     event = {
@@ -62,6 +74,8 @@ if __name__ == "__main__":
     third_party_id = "test-lambda-builder-123"
     parser_id = "index-0001"
     event = create_cloud_event(third_party_id, parser_id)
+    if SIGNING_SECRET:
+        event = sign_cloud_event(event, SIGNING_SECRET)
     publish_to_rabbitmq(event)
 
     # # Build test-lambda-builder-456.index-0002
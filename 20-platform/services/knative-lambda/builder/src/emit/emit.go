@@ -0,0 +1,17 @@
+package emit
+
+import (
+	"context"
+)
+
+// =============================================================================
+// 📤 EMITTER
+// =============================================================================
+// The builder publishes its own events through an Emitter (implemented by
+// events.CloudEventEmitter, and FakeEmitter in tests)
+// 📝 NOTE: Outside internal/ so that code built on the builder can fake it
+
+// Emitter publishes the builder's own events
+type Emitter interface {
+	Emit(ctx context.Context, eventType, subject string, data interface{}) error
+}
@@ -0,0 +1,50 @@
+package emit
+
+import (
+	"context"
+	"sync"
+)
+
+// =============================================================================
+// 🧪 FAKE EMITTER
+// =============================================================================
+// In-memory Emitter for unit tests of code built on the builder
+
+// EmittedEvent is an event recorded by FakeEmitter
+type EmittedEvent struct {
+	Type    string
+	Subject string
+	Data    interface{}
+}
+
+// FakeEmitter records emitted events instead of sending them
+type FakeEmitter struct {
+	mu     sync.Mutex
+	events []EmittedEvent
+
+	// Err, when set, is returned by Emit (to test failure paths)
+	Err error
+}
+
+// NewFakeEmitter creates an empty fake emitter
+func NewFakeEmitter() *FakeEmitter {
+	return &FakeEmitter{}
+}
+
+// Emit implements Emitter
+func (f *FakeEmitter) Emit(ctx context.Context, eventType, subject string, data interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return f.Err
+	}
+	f.events = append(f.events, EmittedEvent{Type: eventType, Subject: subject, Data: data})
+	return nil
+}
+
+// Events returns the events emitted so far (test assertions)
+func (f *FakeEmitter) Events() []EmittedEvent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]EmittedEvent(nil), f.events...)
+}
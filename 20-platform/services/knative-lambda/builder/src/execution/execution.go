@@ -0,0 +1,44 @@
+package execution
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// =============================================================================
+// ⚙️ BUILD EXECUTION
+// =============================================================================
+// The executor launches the rendered build job objects (implemented by
+// build.KubernetesExecutor, and FakeExecutor in tests)
+// 📝 NOTE: Outside internal/ so that code built on the builder can fake it
+
+// BuildJob is a build (or parser test) job found in the cluster
+type BuildJob struct {
+	Name         string
+	ThirdPartyId string
+	ParserId     string
+	Test         bool // A parser test job
+	SBOM         bool // An SBOM job
+	CreatedAt    time.Time
+	Finished     bool      // Complete or Failed
+	FinishedAt   time.Time // When it became Complete or Failed
+}
+
+// Executor launches the objects that make up a build (the Kaniko job)
+type Executor interface {
+	Launch(ctx context.Context, obj *unstructured.Unstructured) error
+	// Disruption returns why a build job's pod was preempted/evicted ("" if it wasn't)
+	Disruption(ctx context.Context, namespace, jobName string) (string, error)
+	// Logs returns the last tailLines lines of output of a job's newest pod
+	Logs(ctx context.Context, namespace, jobName string, tailLines int64) (string, error)
+	// RunningBuilds counts the build jobs (not test jobs) that haven't finished
+	RunningBuilds(ctx context.Context, namespace string) (int, error)
+	// Jobs lists the build and parser test jobs
+	Jobs(ctx context.Context, namespace string) ([]BuildJob, error)
+	// DeleteJob deletes a job and its pods
+	DeleteJob(ctx context.Context, namespace, jobName string) error
+	// ApplySecret creates or updates a Secret build jobs mount (e.g. the .npmrc)
+	ApplySecret(ctx context.Context, namespace, name string, data map[string][]byte) error
+}
@@ -0,0 +1,153 @@
+package execution
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// =============================================================================
+// 🧪 FAKE EXECUTOR
+// =============================================================================
+// In-memory Executor for unit tests of code built on the builder
+
+// FakeExecutor records the objects it was asked to launch
+type FakeExecutor struct {
+	mu          sync.Mutex
+	launched    []*unstructured.Unstructured
+	disruptions map[string]string            // jobName -> reason
+	logs        map[string]string            // jobName -> pod output
+	running     int                          // Build jobs reported by RunningBuilds
+	jobs        []BuildJob                   // Jobs reported by Jobs
+	secrets     map[string]map[string][]byte // Secrets applied, by name
+
+	// Err, when set, is returned by Launch (to test failure paths)
+	Err error
+}
+
+// NewFakeExecutor creates an empty fake executor
+func NewFakeExecutor() *FakeExecutor {
+	return &FakeExecutor{}
+}
+
+// Launch implements Executor
+func (f *FakeExecutor) Launch(ctx context.Context, obj *unstructured.Unstructured) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return f.Err
+	}
+	f.launched = append(f.launched, obj.DeepCopy())
+	return nil
+}
+
+// Launched returns copies of the objects launched so far (test assertions)
+func (f *FakeExecutor) Launched() []*unstructured.Unstructured {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]*unstructured.Unstructured, 0, len(f.launched))
+	for _, obj := range f.launched {
+		out = append(out, obj.DeepCopy())
+	}
+	return out
+}
+
+// Disrupt marks a job's pod as preempted/evicted (test setup)
+func (f *FakeExecutor) Disrupt(jobName, reason string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.disruptions == nil {
+		f.disruptions = map[string]string{}
+	}
+	f.disruptions[jobName] = reason
+}
+
+// Disruption implements Executor
+func (f *FakeExecutor) Disruption(ctx context.Context, namespace, jobName string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.disruptions[jobName], nil
+}
+
+// SetLogs sets the output of a job's pod (test setup)
+func (f *FakeExecutor) SetLogs(jobName, logs string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.logs == nil {
+		f.logs = map[string]string{}
+	}
+	f.logs[jobName] = logs
+}
+
+// Logs implements Executor
+func (f *FakeExecutor) Logs(ctx context.Context, namespace, jobName string, tailLines int64) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	lines := strings.SplitAfter(f.logs[jobName], "\n")
+	if int64(len(lines)) > tailLines {
+		lines = lines[int64(len(lines))-tailLines:]
+	}
+	return strings.Join(lines, ""), nil
+}
+
+// SetRunning sets how many build jobs RunningBuilds reports (test setup)
+func (f *FakeExecutor) SetRunning(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.running = n
+}
+
+// RunningBuilds implements Executor
+func (f *FakeExecutor) RunningBuilds(ctx context.Context, namespace string) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.running, nil
+}
+
+// SetJobs sets the jobs Jobs reports (test setup)
+func (f *FakeExecutor) SetJobs(jobs ...BuildJob) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.jobs = jobs
+}
+
+// Jobs implements Executor
+func (f *FakeExecutor) Jobs(ctx context.Context, namespace string) ([]BuildJob, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]BuildJob(nil), f.jobs...), nil
+}
+
+// DeleteJob implements Executor (the job is also dropped from Jobs)
+func (f *FakeExecutor) DeleteJob(ctx context.Context, namespace, jobName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, job := range f.jobs {
+		if job.Name == jobName {
+			f.jobs = append(f.jobs[:i], f.jobs[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// ApplySecret implements Executor
+func (f *FakeExecutor) ApplySecret(ctx context.Context, namespace, name string, data map[string][]byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.secrets == nil {
+		f.secrets = map[string]map[string][]byte{}
+	}
+	f.secrets[name] = data
+	return nil
+}
+
+// Secret returns the data of an applied Secret (test assertions)
+func (f *FakeExecutor) Secret(name string) (map[string][]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, ok := f.secrets[name]
+	return data, ok
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"knative-lambda-builder/emit"
+	"knative-lambda-builder/internal/auth"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 📝 ADMIN AUDIT
+// =============================================================================
+// Every admin API call, reads included, is audited with the caller's identity:
+// an "AUDIT:" JSON log line, and a network.notifi.lambda.admin.audit event
+// 🎯 WHY: Admin calls reveal and change other tenants' builds
+
+// EventTypeAdminAudit is emitted for every admin API call
+const EventTypeAdminAudit = "network.notifi.lambda.admin.audit"
+
+// Auditor records admin API calls
+type Auditor struct {
+	Replica string       // This builder replica (its pod name)
+	Emitter emit.Emitter // nil = log only
+}
+
+// audit records an admin call answered with status, filling in who made it
+// and where
+func (a Auditor) audit(r *http.Request, status int, event types.AdminAuditEventData) {
+	event.Caller = "anonymous"
+	if principal, ok := auth.PrincipalFrom(r.Context()); ok {
+		event.Caller, event.Issuer = principal.Subject, principal.Issuer
+	}
+	event.Replica, event.Method, event.Path, event.Status = a.Replica, r.Method, r.URL.Path, status
+	event.At = time.Now().UTC()
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("ERROR: Failed to encode audit event: %v", err)
+		return
+	}
+	log.Printf("📝 AUDIT: %s", line)
+	if a.Emitter == nil {
+		return
+	}
+	if err := a.Emitter.Emit(r.Context(), EventTypeAdminAudit, a.Replica, event); err != nil {
+		log.Printf("WARNING: Failed to emit audit event: %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strings"
+
+	"knative-lambda-builder/internal/history"
+)
+
+// =============================================================================
+// 🏷️ BUILD STATUS BADGE
+// =============================================================================
+// GET /badge/{thirdPartyId}/{parserId}.svg -> SVG badge with the parser's
+// latest build status (building/passing/failing) and when it last changed
+// 🎯 PURPOSE: Embed live parser build status in dashboards and wikis
+
+// badgeColors maps build status to the badge's right-hand color
+var badgeColors = map[string]string{
+	history.StatusBuilding: "#dfb317",
+	history.StatusTesting:  "#dfb317",
+	history.StatusPassing:  "#4c1",
+	history.StatusFailing:  "#e05d44",
+}
+
+// badgeTemplate is a flat shields.io-style badge
+const badgeTemplate = `<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[3]s: %[4]s">
+<title>%[3]s: %[4]s</title>
+<rect width="%[2]d" height="20" fill="#555"/>
+<rect x="%[2]d" width="%[5]d" height="20" fill="%[6]s"/>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="%[7]d" y="14">%[3]s</text>
+<text x="%[8]d" y="14">%[4]s</text>
+</g>
+</svg>
+`
+
+// RegisterBadgeRoutes mounts the public build status badge endpoint
+func (s *Server) RegisterBadgeRoutes(store history.Store) {
+	s.mux.HandleFunc("GET /badge/{thirdPartyId}/{file}", func(w http.ResponseWriter, r *http.Request) {
+		parserId, ok := strings.CutSuffix(r.PathValue("file"), ".svg")
+		if !ok || parserId == "" {
+			writeError(w, http.StatusNotFound, "badge must be requested as <parserId>.svg")
+			return
+		}
+
+		status, color := "unknown", "#9f9f9f"
+		latest, err := history.Latest(r.Context(), store, r.PathValue("thirdPartyId"), parserId)
+		if err != nil {
+			// 📝 NOTE: Badges are embedded in pages; render "unknown" rather than a broken image
+			log.Printf("ERROR: Failed to read build history for badge: %v", err)
+		}
+		if latest != nil {
+			status = fmt.Sprintf("%s · %s", latest.Status, latest.UpdatedAt.Format("2006-01-02 15:04Z"))
+			color = badgeColors[latest.Status]
+		}
+
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.Header().Set("Cache-Control", "no-cache, max-age=0")
+		fmt.Fprint(w, renderBadge("build", status, color))
+	})
+}
+
+// renderBadge renders a two-part badge, sizing each part from its text
+func renderBadge(label, message, color string) string {
+	textWidth := func(s string) int { return len([]rune(s))*7 + 10 }
+	labelWidth, messageWidth := textWidth(label), textWidth(message)
+
+	return fmt.Sprintf(badgeTemplate,
+		labelWidth+messageWidth, labelWidth,
+		html.EscapeString(label), html.EscapeString(message),
+		messageWidth, color,
+		labelWidth/2, labelWidth+messageWidth/2)
+}
@@ -0,0 +1,397 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"knative-lambda-builder/internal/auth"
+	"knative-lambda-builder/internal/build"
+	"knative-lambda-builder/internal/history"
+	"knative-lambda-builder/internal/tenants"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🏗️ BUILD MANAGEMENT ENDPOINTS
+// =============================================================================
+// POST /v1/builds                -> start a build (body: a build.start payload, plus "rebuild"; see buildRequest),
+//                                    400 for a field that can't be used, 429 when the tenant is over its build rate limit,
+//                                    503 when the build queue is full
+//                                    With "dryRun": the rendered manifests instead, 200 when the API server
+//                                    accepts them, 422 otherwise
+// GET  /v1/builds/{id}           -> a build's status, job, image and error
+// GET  /v1/builds/{id}/events    -> Server-Sent Events: the build again whenever it changes, until it is ready or failed
+// GET  /v1/builds?tenant=[&parser=] -> a tenant's recorded builds, newest first
+//
+// 📝 NOTE: Same pipeline as the build.start/rebuild CloudEvents; the build is
+// recorded once it starts, so a GET right after the POST may still be a 404
+
+// BuildSubmitter starts builds (implemented by events.Handler)
+type BuildSubmitter interface {
+	SubmitBuild(ctx context.Context, be types.BuildEvent) (types.BuildEvent, error)
+	DryRun(ctx context.Context, be types.BuildEvent) (*types.DryRunResult, error)
+}
+
+// invalidRequest is implemented by the errors of builds refused because of
+// their request (events.InvalidCallbackError, build.InvalidVariablesError,
+// build.InvalidInlineSourceError, build.InvalidDependenciesError,
+// build.InvalidChecksumError, build.InvalidResourcesError)
+type invalidRequest interface {
+	InvalidRequest() bool
+}
+
+// rateLimited is implemented by the error of a build refused by its tenant's
+// rate limit (events.RateLimitedError)
+type rateLimited interface {
+	RetryAfterSeconds() int
+}
+
+// queueFull is implemented by the error of a build refused because too many
+// builds are waiting to start (events.QueueFullError)
+type queueFull interface {
+	QueueFull() bool
+	RetryAfterSeconds() int
+}
+
+// buildRequest is the body of POST /v1/builds: the fields of a build.start
+// payload (types.BuildEvent), plus rebuild
+type buildRequest struct {
+	ThirdPartyId string `json:"thirdPartyId"`
+	ParserId     string `json:"parserId"`
+	ID           string `json:"id,omitempty"`          // Generated when empty
+	Rebuild      bool   `json:"rebuild,omitempty"`     // Ignore the build cache, roll a new revision
+	Priority     string `json:"priority,omitempty"`    // high, normal (default) or low
+	Region       string `json:"region,omitempty"`      // AWS region whose ECR the image is pushed to (see AWS_PUSH_REGIONS_ALLOWED)
+	PushRoleArn  string `json:"pushRoleArn,omitempty"` // IAM role the image is pushed with (see AWS_PUSH_ROLES_ALLOWED)
+	Backend      string `json:"backend,omitempty"`     // kaniko or buildkit (default BUILD_BACKEND)
+	Runtime      string `json:"runtime,omitempty"`     // node (default), python or go
+	CallbackURL  string `json:"callbackUrl,omitempty"`
+
+	// Requests and limits of the build pod (see BUILD_RESOURCE_MAX)
+	Resources *types.BuildResources `json:"resources,omitempty"`
+
+	DeployStrategy string `json:"deployStrategy,omitempty"` // rolling, canary or blue-green (default: the tenant's)
+	DryRun         bool   `json:"dryRun,omitempty"`         // Only render and validate the manifests (200 or 422 with them)
+
+	BuildArgs map[string]string `json:"buildArgs,omitempty"` // Dockerfile ARGs of the build
+	Env       map[string]string `json:"env,omitempty"`       // Environment of the deployed parser
+	// Extra npm dependencies merged into package.json (see NPM_DEPENDENCY_ALLOWLIST)
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+
+	Source       *types.BuildSource `json:"source,omitempty"`       // {"git": {"url", "ref", "path"}} (default: the source bucket)
+	SourceSHA256 string             `json:"sourceSha256,omitempty"` // SHA-256 the parser source must have
+}
+
+// buildResponse describes a build
+type buildResponse struct {
+	ID           string     `json:"id"`
+	ThirdPartyId string     `json:"thirdPartyId"`
+	ParserId     string     `json:"parserId"`
+	Status       string     `json:"status"`          // accepted, or a history status (building, testing, passing, failing)
+	Phase        string     `json:"phase,omitempty"` // queued, building, pushing, testing, deploying, ready or failed
+	JobName      string     `json:"jobName,omitempty"`
+	Image        string     `json:"image,omitempty"`
+	Commit       string     `json:"commit,omitempty"` // Git commit built (builds from git)
+	DeployMode   string     `json:"deployMode,omitempty"`
+	SBOM         string     `json:"sbom,omitempty"`       // s3:// prefix of the image's SBOMs (SPDX and CycloneDX)
+	TestReport   string     `json:"testReport,omitempty"` // Output of the parser's tests (tail), passed or failed
+	Error        string     `json:"error,omitempty"`      // Failure details (status failing)
+	StartedAt    *time.Time `json:"startedAt,omitempty"`
+	UpdatedAt    *time.Time `json:"updatedAt,omitempty"`
+}
+
+// statusAccepted is reported for a build that was submitted but hasn't started
+const statusAccepted = "accepted"
+
+// Build event streams (GET /v1/builds/{id}/events)
+const (
+	// watchInterval is how often a stream polls the build history
+	// 🎯 WHY: The history is shared by every builder replica; polling sees
+	// builds run by any of them (like the gRPC WatchBuild)
+	watchInterval = 2 * time.Second
+	// watchStartTimeout is how long a stream waits for the build to be recorded
+	// 📝 NOTE: Submitted builds are recorded once they start, in the background
+	watchStartTimeout = time.Minute
+	// keepAliveInterval spaces the comments keeping idle streams open
+	// 🎯 WHY: Proxies close connections that stay silent too long
+	keepAliveInterval = 15 * time.Second
+)
+
+// RegisterBuildRoutes mounts the build management endpoints
+// 📝 NOTE: index is searched for build ids (statuses are plaintext, no KMS
+// calls); records returns the entries with their error details opened
+func (s *Server) RegisterBuildRoutes(submitter BuildSubmitter, index, records history.Store) {
+	s.mux.HandleFunc("POST /v1/builds", func(w http.ResponseWriter, r *http.Request) {
+		var req buildRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+		if req.ThirdPartyId == "" || req.ParserId == "" {
+			writeError(w, http.StatusBadRequest, "thirdPartyId and parserId are required")
+			return
+		}
+		if err := build.CheckIDs(types.BuildEvent{ThirdPartyId: req.ThirdPartyId, ParserId: req.ParserId}); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if !build.ValidPriority(req.Priority) {
+			writeError(w, http.StatusBadRequest, "priority must be high, normal or low")
+			return
+		}
+		if !build.ValidBackend(req.Backend) {
+			writeError(w, http.StatusBadRequest, "backend must be kaniko or buildkit")
+			return
+		}
+		if !build.ValidRuntime(req.Runtime) {
+			writeError(w, http.StatusBadRequest, "runtime must be one of "+strings.Join(build.Runtimes(), ", "))
+			return
+		}
+		if _, err := tenants.ParseDeployStrategy(req.DeployStrategy); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := auth.Authorize(r.Context(), req.ThirdPartyId); err != nil {
+			writeError(w, http.StatusForbidden, err.Error())
+			return
+		}
+
+		request := types.BuildEvent{
+			ThirdPartyId: req.ThirdPartyId,
+			ParserId:     req.ParserId,
+			ID:           req.ID,
+			Rebuild:      req.Rebuild,
+			Priority:     req.Priority,
+			Region:       req.Region,
+			PushRoleArn:  req.PushRoleArn,
+			Backend:      req.Backend,
+			Runtime:      req.Runtime,
+			CallbackURL:  req.CallbackURL,
+
+			DeployStrategy: req.DeployStrategy,
+			DryRun:         req.DryRun,
+			Resources:      req.Resources,
+			BuildArgs:      req.BuildArgs,
+			Dependencies:   req.Dependencies,
+			Env:            req.Env,
+			Source:         req.Source,
+			SourceSHA256:   req.SourceSHA256,
+		}
+		if err := build.CheckVariables(request); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if req.DryRun {
+			result, err := submitter.DryRun(r.Context(), request)
+			var invalid invalidRequest
+			switch {
+			case errors.As(err, &invalid):
+				writeError(w, http.StatusBadRequest, err.Error())
+			case err != nil:
+				writeError(w, http.StatusUnprocessableEntity, "dry run failed: "+err.Error())
+			case len(result.Rejected) > 0:
+				writeJSON(w, http.StatusUnprocessableEntity, result)
+			default:
+				writeJSON(w, http.StatusOK, result)
+			}
+			return
+		}
+
+		be, err := submitter.SubmitBuild(r.Context(), request)
+		var invalid invalidRequest
+		if errors.As(err, &invalid) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		var full queueFull
+		if errors.As(err, &full) {
+			w.Header().Set("Retry-After", strconv.Itoa(full.RetryAfterSeconds()))
+			writeError(w, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+		var limited rateLimited
+		if errors.As(err, &limited) {
+			w.Header().Set("Retry-After", strconv.Itoa(limited.RetryAfterSeconds()))
+			writeError(w, http.StatusTooManyRequests, err.Error())
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.Header().Set("Location", "/v1/builds/"+be.ID)
+		writeJSON(w, http.StatusAccepted, buildResponse{
+			ID:           be.ID,
+			ThirdPartyId: be.ThirdPartyId,
+			ParserId:     be.ParserId,
+			Status:       statusAccepted,
+			Phase:        history.PhaseQueued,
+		})
+	})
+
+	s.mux.HandleFunc("GET /v1/builds/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		entry, err := history.LoadBuild(r.Context(), index, records, id)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		// 📝 NOTE: Other tenants' builds look missing rather than forbidden
+		if entry == nil || auth.Authorize(r.Context(), entry.ThirdPartyId) != nil {
+			writeError(w, http.StatusNotFound, "no recorded build "+id)
+			return
+		}
+		writeJSON(w, http.StatusOK, newBuildResponse(*entry))
+	})
+
+	s.mux.HandleFunc("GET /v1/builds/{id}/events", func(w http.ResponseWriter, r *http.Request) {
+		streamBuild(w, r, index, records)
+	})
+
+	s.mux.HandleFunc("GET /v1/builds", func(w http.ResponseWriter, r *http.Request) {
+		thirdPartyId := r.URL.Query().Get("tenant")
+		if thirdPartyId == "" {
+			writeError(w, http.StatusBadRequest, "tenant is required")
+			return
+		}
+		if err := auth.Authorize(r.Context(), thirdPartyId); err != nil {
+			writeError(w, http.StatusForbidden, err.Error())
+			return
+		}
+
+		var entries []history.Entry
+		var err error
+		if parserId := r.URL.Query().Get("parser"); parserId != "" {
+			entries, err = records.List(r.Context(), thirdPartyId, parserId)
+		} else {
+			entries, err = history.Builds(r.Context(), records, thirdPartyId)
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		builds := make([]buildResponse, 0, len(entries))
+		for _, entry := range entries {
+			builds = append(builds, newBuildResponse(entry))
+		}
+		writeJSON(w, http.StatusOK, builds)
+	})
+}
+
+// streamBuild sends a build's state as Server-Sent Events
+// 📋 STEPS:
+//  1. Wait (up to watchStartTimeout) for the build to be recorded
+//  2. Send it ("event: build"), then again whenever its status or phase changes
+//  3. Return once it is ready or failed
+//
+// 📝 NOTE: A build missing after the wait ends the stream with "event: error"
+func streamBuild(w http.ResponseWriter, r *http.Request, index, records history.Store) {
+	ctx, id := r.Context(), r.PathValue("id")
+	load := func() (*history.Entry, error) {
+		entry, err := history.LoadBuild(ctx, index, records, id)
+		// 📝 NOTE: Other tenants' builds look missing rather than forbidden
+		if err != nil || entry == nil || auth.Authorize(ctx, entry.ThirdPartyId) != nil {
+			return nil, err
+		}
+		return entry, nil
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+	entry, err := load()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // Don't let nginx buffer the stream
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	deadline, lastWrite := time.Now().Add(watchStartTimeout), time.Now()
+	var lastStatus, lastPhase string
+	var lastUpdate time.Time
+	for {
+		switch {
+		case err != nil:
+			log.Printf("ERROR: Failed to load build %s for its event stream: %v", id, err)
+		case entry == nil && time.Now().After(deadline):
+			writeEvent(w, "error", errorResponse{Error: "no recorded build " + id})
+			flusher.Flush()
+			return
+		case entry != nil:
+			if entry.Status != lastStatus || entry.CurrentPhase() != lastPhase || !entry.UpdatedAt.Equal(lastUpdate) {
+				lastStatus, lastPhase, lastUpdate = entry.Status, entry.CurrentPhase(), entry.UpdatedAt
+				if err := writeEvent(w, "build", newBuildResponse(*entry)); err != nil {
+					return // Client gone
+				}
+				flusher.Flush()
+				lastWrite = time.Now()
+			}
+			if entry.Status == history.StatusPassing || entry.Status == history.StatusFailing {
+				return
+			}
+		}
+		if time.Since(lastWrite) >= keepAliveInterval {
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+			lastWrite = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		entry, err = load()
+	}
+}
+
+// writeEvent writes one Server-Sent Event with a JSON payload
+func writeEvent(w http.ResponseWriter, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", event, err)
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	return err
+}
+
+// newBuildResponse describes a recorded build
+func newBuildResponse(entry history.Entry) buildResponse {
+	build := buildResponse{
+		ID:           entry.BuildId,
+		ThirdPartyId: entry.ThirdPartyId,
+		ParserId:     entry.ParserId,
+		Status:       entry.Status,
+		Phase:        entry.CurrentPhase(),
+		JobName:      entry.JobName,
+		Image:        entry.Image,
+		DeployMode:   entry.DeployMode,
+		SBOM:         entry.SBOM,
+		Commit:       entry.Commit,
+		TestReport:   entry.TestReport,
+		StartedAt:    &entry.StartedAt,
+		UpdatedAt:    &entry.UpdatedAt,
+	}
+	if entry.Status == history.StatusFailing {
+		build.Error = entry.Message
+	}
+	return build
+}
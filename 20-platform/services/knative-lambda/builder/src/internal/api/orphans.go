@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"knative-lambda-builder/internal/reconcile"
+)
+
+// =============================================================================
+// 🧹 ORPHAN RECONCILER ENDPOINTS
+// =============================================================================
+// GET  /admin/orphans                    -> report of the last reconcile pass
+// POST /admin/orphans/reconcile?dryRun=  -> run a pass now (dry-run unless dryRun=false)
+
+// RegisterOrphanRoutes mounts the orphan reconciler endpoints
+func (s *Server) RegisterOrphanRoutes(reconciler *reconcile.Reconciler) {
+	s.mux.HandleFunc("GET /admin/orphans", func(w http.ResponseWriter, r *http.Request) {
+		report := reconciler.LastReport()
+		if report == nil {
+			writeError(w, http.StatusNotFound, "no reconcile pass has run yet")
+			return
+		}
+		writeJSON(w, http.StatusOK, report)
+	})
+
+	s.mux.HandleFunc("POST /admin/orphans/reconcile", func(w http.ResponseWriter, r *http.Request) {
+		// 📝 NOTE: Deleting requires an explicit dryRun=false
+		dryRun := true
+		if raw := r.URL.Query().Get("dryRun"); raw != "" {
+			parsed, err := strconv.ParseBool(raw)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "dryRun must be true or false")
+				return
+			}
+			dryRun = parsed
+		}
+		writeJSON(w, http.StatusOK, reconciler.Run(r.Context(), dryRun))
+	})
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"net/http"
+
+	"knative-lambda-builder/internal/auth"
+	"knative-lambda-builder/internal/build"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🚦 BUILD QUEUE ENDPOINTS
+// =============================================================================
+// GET  /admin/queue               -> the builds waiting for a slot (position, age, tenant)
+// POST /admin/queue/{id}/promote  -> move a waiting build to the head of the queue
+// POST /admin/queue/{id}/demote   -> move a waiting build to the back of the queue
+// POST /admin/queue/{id}/drop     -> take a waiting build out of the queue (it fails)
+//
+// 🎯 WHY: During incidents, operators must be able to let an urgent fix
+// through, or clear out a flood of builds, without restarting the builder
+// 📝 NOTE: Each replica has its own queue, so these only act on the replica
+// that serves them. The listing names that replica, and changes must name it
+// too (?replica=): one served by another replica is refused (421) rather
+// than silently missing the build. Every call is audited (see Auditor)
+
+// BuildQueue lists and reorders the waiting builds (implemented by build.Orchestrator)
+type BuildQueue interface {
+	QueuedBuilds() []build.QueuedBuild
+	PromoteQueued(id string) (build.QueuedBuild, bool)
+	DemoteQueued(id string) (build.QueuedBuild, bool)
+	DropQueued(id, by string) (build.QueuedBuild, bool)
+}
+
+// BacklogCounter counts the accepted builds waiting for their job (implemented by events.Handler)
+type BacklogCounter interface {
+	Backlog() int
+}
+
+// queueResponse is the body of GET /admin/queue
+type queueResponse struct {
+	Replica string              `json:"replica"` // The replica whose queue this is
+	Backlog int                 `json:"backlog"` // Accepted builds without a job, queued or not yet
+	Waiting []build.QueuedBuild `json:"waiting"`
+}
+
+// RegisterQueueRoutes mounts the build queue endpoints
+func (s *Server) RegisterQueueRoutes(queue BuildQueue, backlog BacklogCounter, auditor Auditor) {
+	s.mux.HandleFunc("GET /admin/queue", func(w http.ResponseWriter, r *http.Request) {
+		waiting := queue.QueuedBuilds()
+		if waiting == nil {
+			waiting = []build.QueuedBuild{}
+		}
+		auditor.audit(r, http.StatusOK, types.AdminAuditEventData{Action: "list"})
+		writeJSON(w, http.StatusOK, queueResponse{Replica: auditor.Replica, Backlog: backlog.Backlog(), Waiting: waiting})
+	})
+
+	s.mux.HandleFunc("POST /admin/queue/{id}/{action}", func(w http.ResponseWriter, r *http.Request) {
+		id, action := r.PathValue("id"), r.PathValue("action")
+		event := types.AdminAuditEventData{Action: action, BuildId: id}
+		fail := func(status int, message string) {
+			auditor.audit(r, status, event)
+			writeError(w, status, message)
+		}
+
+		// 🎯 The queue is per replica: act only on the one the caller listed
+		if replica := r.URL.Query().Get("replica"); replica != auditor.Replica {
+			fail(http.StatusMisdirectedRequest, "build queues are per replica: this is replica "+auditor.Replica+
+				", pass the replica that listed the build as ?replica= and retry until it serves the request")
+			return
+		}
+
+		var queued build.QueuedBuild
+		var ok bool
+		switch action {
+		case "promote":
+			queued, ok = queue.PromoteQueued(id)
+		case "demote":
+			queued, ok = queue.DemoteQueued(id)
+		case "drop":
+			queued, ok = queue.DropQueued(id, caller(r))
+		default:
+			fail(http.StatusNotFound, "action must be promote, demote or drop")
+			return
+		}
+		if !ok {
+			fail(http.StatusNotFound, "build "+id+" is not in the queue")
+			return
+		}
+		event.ThirdPartyId, event.ParserId = queued.ThirdPartyId, queued.ParserId
+		auditor.audit(r, http.StatusOK, event)
+		writeJSON(w, http.StatusOK, queued)
+	})
+}
+
+// caller names who made a request, e.g. who dropped a build
+func caller(r *http.Request) string {
+	if principal, ok := auth.PrincipalFrom(r.Context()); ok {
+		return principal.Subject
+	}
+	return "anonymous"
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"knative-lambda-builder/internal/auth"
+	"knative-lambda-builder/internal/build"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// ⏪ IMAGE REVISIONS AND ROLLBACKS
+// =============================================================================
+// GET  /v1/parsers/{thirdPartyId}/{parserId}/revisions -> the parser's recorded image revisions, newest first
+// POST /v1/parsers/{thirdPartyId}/{parserId}/rollback  -> redeploy a revision (body: {"imageTag", "id"}),
+//                                                         404 when it can't be deployed (unknown, never
+//                                                         deployed or gone from the registry)
+//
+// 📝 NOTE: Same as the lambda.rollback CloudEvent, but synchronous: the
+// response is the rollback.completed payload
+
+// RevisionLister lists a parser's image revisions (implemented by build.Orchestrator)
+type RevisionLister interface {
+	Revisions(ctx context.Context, be types.BuildEvent) ([]build.Revision, error)
+}
+
+// Rollbacker redeploys earlier revisions (implemented by events.Handler)
+type Rollbacker interface {
+	Rollback(ctx context.Context, rollback types.RollbackEvent) (types.RollbackCompletedEventData, error)
+}
+
+// rollbackRequest is the body of the rollback endpoint
+type rollbackRequest struct {
+	ImageTag string `json:"imageTag"`
+	ID       string `json:"id,omitempty"`
+}
+
+// RegisterRevisionRoutes mounts the revision and rollback endpoints
+func (s *Server) RegisterRevisionRoutes(revisions RevisionLister, rollbacker Rollbacker) {
+	s.mux.HandleFunc("GET /v1/parsers/{thirdPartyId}/{parserId}/revisions", func(w http.ResponseWriter, r *http.Request) {
+		be := types.BuildEvent{ThirdPartyId: r.PathValue("thirdPartyId"), ParserId: r.PathValue("parserId")}
+		if err := auth.Authorize(r.Context(), be.ThirdPartyId); err != nil {
+			writeError(w, http.StatusForbidden, err.Error())
+			return
+		}
+
+		recorded, err := revisions.Revisions(r.Context(), be)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		newestFirst := make([]build.Revision, 0, len(recorded))
+		for i := len(recorded) - 1; i >= 0; i-- {
+			newestFirst = append(newestFirst, recorded[i])
+		}
+		writeJSON(w, http.StatusOK, newestFirst)
+	})
+
+	s.mux.HandleFunc("POST /v1/parsers/{thirdPartyId}/{parserId}/rollback", func(w http.ResponseWriter, r *http.Request) {
+		var req rollbackRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+		if req.ImageTag == "" {
+			writeError(w, http.StatusBadRequest, "imageTag is required")
+			return
+		}
+		thirdPartyId := r.PathValue("thirdPartyId")
+		if err := auth.Authorize(r.Context(), thirdPartyId); err != nil {
+			writeError(w, http.StatusForbidden, err.Error())
+			return
+		}
+
+		completed, err := rollbacker.Rollback(r.Context(), types.RollbackEvent{
+			ThirdPartyId: thirdPartyId,
+			ParserId:     r.PathValue("parserId"),
+			ImageTag:     req.ImageTag,
+			ID:           req.ID,
+		})
+		var unavailable *build.RevisionUnavailableError
+		if errors.As(err, &unavailable) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, completed)
+	})
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// =============================================================================
+// 🌐 HTTP API
+// =============================================================================
+// This package exposes the builder's HTTP endpoints next to the CloudEvents
+// receiver: health checks and the admin/management API
+// 🎯 PURPOSE: Let operators and tools talk to the builder without CloudEvents
+
+// Server routes HTTP requests to the builder's API handlers
+type Server struct {
+	mux *http.ServeMux
+}
+
+// NewServer creates a new API server with the health endpoints registered
+func NewServer() *Server {
+	s := &Server{mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /health", s.handleHealth)
+	s.mux.HandleFunc("GET /readyz", s.handleReady)
+	return s
+}
+
+// Handle registers an additional handler (e.g. the CloudEvents receiver on "/")
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// ServeHTTP makes Server an http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handleHealth answers liveness probes (used by the Dockerfile HEALTHCHECK)
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "healthy"})
+}
+
+// handleReady answers readiness checks (Knative probes, the status page)
+// 📝 NOTE: The server only listens once every dependency is initialized, so
+// being able to answer means being ready
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+}
+
+// =============================================================================
+// 🔧 RESPONSE HELPERS
+// =============================================================================
+
+// errorResponse is the JSON body returned for every API error
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// writeJSON encodes a response body with the given status code
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("ERROR: Failed to encode response: %v", err)
+	}
+}
+
+// writeError returns a JSON error body
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
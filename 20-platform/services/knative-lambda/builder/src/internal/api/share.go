@@ -0,0 +1,243 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"knative-lambda-builder/internal/build"
+	"knative-lambda-builder/internal/history"
+	"knative-lambda-builder/internal/share"
+	"knative-lambda-builder/internal/types"
+	"knative-lambda-builder/storage"
+)
+
+// =============================================================================
+// 🔗 BUILD SHARE LINKS
+// =============================================================================
+// POST /admin/builds/{thirdPartyId}/{parserId}/share -> mint a link (body: {"jobName", "ttl", "issuedBy"})
+// GET  /share/{token}                                -> build status and artifact links (JSON)
+// GET  /share/{token}/{artifact}                     -> logs.txt, test-report.txt, inputs.json, context.tar.gz
+//
+// 📝 NOTE: /share/* is meant to be exposed to tenant developers; the signed
+// token is the only credential and only grants access to one build
+
+// artifactTestReport is served from the build history rather than S3
+const artifactTestReport = "test-report.txt"
+
+// BuildArtifacts reads what share links expose (implemented by build.Orchestrator)
+type BuildArtifacts interface {
+	LatestJobName(ctx context.Context, be types.BuildEvent) (string, error)
+	SnapshotLogs(ctx context.Context, be types.BuildEvent, jobName string) error
+	OpenArtifact(ctx context.Context, be types.BuildEvent, jobName, name string) (io.ReadCloser, error)
+}
+
+// ShareLinks configures share link minting
+type ShareLinks struct {
+	Signer     *share.Signer
+	DefaultTTL time.Duration
+	MaxTTL     time.Duration
+	BaseURL    string // Public URL of /share (e.g. https://builder.example.com); relative links if empty
+}
+
+// shareRequest is the body of the mint endpoint
+type shareRequest struct {
+	JobName  string `json:"jobName,omitempty"` // Defaults to the parser's last build
+	TTL      string `json:"ttl,omitempty"`     // Go duration, e.g. "24h"
+	IssuedBy string `json:"issuedBy,omitempty"`
+}
+
+// shareResponse describes a minted link
+type shareResponse struct {
+	URL       string            `json:"url"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+	Artifacts map[string]string `json:"artifacts"`
+}
+
+// sharedBuild is what a link's landing endpoint returns
+type sharedBuild struct {
+	ThirdPartyId string            `json:"thirdPartyId"`
+	ParserId     string            `json:"parserId"`
+	JobName      string            `json:"jobName"`
+	ExpiresAt    time.Time         `json:"expiresAt"`
+	Build        *history.Entry    `json:"build,omitempty"` // Latest recorded build of the parser
+	Artifacts    map[string]string `json:"artifacts"`
+}
+
+// RegisterShareRoutes mounts the share link endpoints
+func (s *Server) RegisterShareRoutes(links ShareLinks, builds BuildArtifacts, store history.Store) {
+	artifactURLs := func(token string) map[string]string {
+		urls := map[string]string{}
+		for _, name := range append(build.ShareableArtifacts, artifactTestReport) {
+			urls[name] = links.BaseURL + "/share/" + token + "/" + name
+		}
+		return urls
+	}
+
+	s.mux.HandleFunc("POST /admin/builds/{thirdPartyId}/{parserId}/share", func(w http.ResponseWriter, r *http.Request) {
+		var req shareRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+				return
+			}
+		}
+
+		ttl := links.DefaultTTL
+		if req.TTL != "" {
+			parsed, err := time.ParseDuration(req.TTL)
+			if err != nil || parsed <= 0 {
+				writeError(w, http.StatusBadRequest, "ttl must be a positive duration such as 24h")
+				return
+			}
+			ttl = parsed
+		}
+		if ttl > links.MaxTTL {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("ttl must not exceed %s", links.MaxTTL))
+			return
+		}
+
+		be := types.BuildEvent{ThirdPartyId: r.PathValue("thirdPartyId"), ParserId: r.PathValue("parserId")}
+		jobName := req.JobName
+		if jobName == "" {
+			latest, err := builds.LatestJobName(r.Context(), be)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			if latest == "" {
+				writeError(w, http.StatusNotFound, "no recorded build job for this parser; pass jobName")
+				return
+			}
+			jobName = latest
+		}
+		if !strings.HasPrefix(jobName, "build-") {
+			writeError(w, http.StatusBadRequest, "jobName must be a build job")
+			return
+		}
+
+		// 📝 NOTE: The job's pod may already be gone; the link still serves the rest
+		if err := builds.SnapshotLogs(r.Context(), be, jobName); err != nil {
+			log.Printf("WARNING: No log snapshot for share link of %s: %v", jobName, err)
+		}
+
+		claims := share.Claims{ThirdPartyId: be.ThirdPartyId, ParserId: be.ParserId, JobName: jobName, IssuedBy: req.IssuedBy}
+		token, err := links.Signer.Mint(claims, time.Now(), ttl)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		expiresAt := time.Now().Add(ttl).UTC().Truncate(time.Second)
+		log.Printf("🔗 Share link for %s/%s (%s) issued by %q, expires %s",
+			be.ThirdPartyId, be.ParserId, jobName, req.IssuedBy, expiresAt.Format(time.RFC3339))
+
+		writeJSON(w, http.StatusOK, shareResponse{
+			URL:       links.BaseURL + "/share/" + token,
+			ExpiresAt: expiresAt,
+			Artifacts: artifactURLs(token),
+		})
+	})
+
+	// verify resolves a request's token, answering the error itself
+	verify := func(w http.ResponseWriter, r *http.Request) (*share.Claims, bool) {
+		claims, err := links.Signer.Verify(r.PathValue("token"), time.Now())
+		switch {
+		case errors.Is(err, share.ErrExpiredToken):
+			writeError(w, http.StatusGone, err.Error())
+			return nil, false
+		case err != nil:
+			writeError(w, http.StatusForbidden, err.Error())
+			return nil, false
+		}
+		w.Header().Set("Cache-Control", "private, no-store")
+		return claims, true
+	}
+
+	s.mux.HandleFunc("GET /share/{token}", func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := verify(w, r)
+		if !ok {
+			return
+		}
+		latest, err := history.Latest(r.Context(), store, claims.ThirdPartyId, claims.ParserId)
+		if err != nil {
+			log.Printf("ERROR: Failed to read build history for share link: %v", err)
+		}
+		writeJSON(w, http.StatusOK, sharedBuild{
+			ThirdPartyId: claims.ThirdPartyId,
+			ParserId:     claims.ParserId,
+			JobName:      claims.JobName,
+			ExpiresAt:    claims.Expiry(),
+			Build:        latest,
+			Artifacts:    artifactURLs(r.PathValue("token")),
+		})
+	})
+
+	s.mux.HandleFunc("GET /share/{token}/{artifact}", func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := verify(w, r)
+		if !ok {
+			return
+		}
+		be := types.BuildEvent{ThirdPartyId: claims.ThirdPartyId, ParserId: claims.ParserId}
+		name := r.PathValue("artifact")
+
+		if name == artifactTestReport {
+			latest, err := history.Latest(r.Context(), store, be.ThirdPartyId, be.ParserId)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			if latest == nil || latest.TestReport == "" {
+				writeError(w, http.StatusNotFound, "no test report recorded for this build")
+				return
+			}
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			io.WriteString(w, latest.TestReport)
+			return
+		}
+
+		body, err := builds.OpenArtifact(r.Context(), be, claims.JobName, name)
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			writeError(w, http.StatusNotFound, name+" is no longer available")
+			return
+		case err != nil && !isShareable(name):
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		case err != nil:
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		defer body.Close()
+
+		w.Header().Set("Content-Type", artifactContentType(name))
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", claims.JobName+"-"+name))
+		if _, err := io.Copy(w, body); err != nil {
+			log.Printf("ERROR: Failed to stream shared %s of %s: %v", name, claims.JobName, err)
+		}
+	})
+}
+
+func isShareable(name string) bool {
+	for _, shareable := range build.ShareableArtifacts {
+		if name == shareable {
+			return true
+		}
+	}
+	return false
+}
+
+func artifactContentType(name string) string {
+	switch {
+	case strings.HasSuffix(name, ".json"):
+		return "application/json"
+	case strings.HasSuffix(name, ".tar.gz"):
+		return "application/gzip"
+	}
+	return "text/plain; charset=utf-8"
+}
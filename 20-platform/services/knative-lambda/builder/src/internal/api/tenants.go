@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"knative-lambda-builder/internal/encryption"
+	"knative-lambda-builder/internal/tenants"
+)
+
+// =============================================================================
+// 🏢 TENANT ADMIN ENDPOINTS
+// =============================================================================
+// POST /admin/tenants              -> onboard (idempotent), returns a report
+// GET  /admin/tenants              -> list tenant records
+// GET  /admin/tenants/{thirdPartyId} -> single tenant record
+// POST /admin/tenants/{thirdPartyId}/reencrypt -> re-encrypt stored data with the current key
+
+// RegisterTenantRoutes mounts the tenant onboarding endpoints
+func (s *Server) RegisterTenantRoutes(provisioner *tenants.Provisioner, store tenants.Store) {
+	s.mux.HandleFunc("POST /admin/tenants", func(w http.ResponseWriter, r *http.Request) {
+		var req tenants.Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+
+		report, err := provisioner.Provision(r.Context(), req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		// 📝 NOTE: Partial failures still return the report so the operator sees what's left
+		status := http.StatusOK
+		if !report.Success {
+			status = http.StatusMultiStatus
+		}
+		writeJSON(w, status, report)
+	})
+
+	s.mux.HandleFunc("GET /admin/tenants", func(w http.ResponseWriter, r *http.Request) {
+		list, err := store.List(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, list)
+	})
+
+	s.mux.HandleFunc("GET /admin/tenants/{thirdPartyId}", func(w http.ResponseWriter, r *http.Request) {
+		tenant, err := store.Get(r.Context(), r.PathValue("thirdPartyId"))
+		if errors.Is(err, tenants.ErrNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, tenant)
+	})
+}
+
+// RegisterReencryptRoutes mounts the key rotation endpoint
+func (s *Server) RegisterReencryptRoutes(reencryptor *encryption.Reencryptor, store tenants.Store) {
+	s.mux.HandleFunc("POST /admin/tenants/{thirdPartyId}/reencrypt", func(w http.ResponseWriter, r *http.Request) {
+		thirdPartyId := r.PathValue("thirdPartyId")
+		if _, err := store.Get(r.Context(), thirdPartyId); errors.Is(err, tenants.ErrNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		} else if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		report, err := reencryptor.Tenant(r.Context(), thirdPartyId)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		status := http.StatusOK
+		if len(report.Errors) > 0 {
+			status = http.StatusMultiStatus
+		}
+		writeJSON(w, status, report)
+	})
+}
@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryInterceptor(t *testing.T) {
+	interceptor := (&Authenticator{}).UnaryInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/build.v1.BuildService/GetBuild"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+	for _, authorization := range []string{"", "Basic dXNlcjpwYXNz", "Bearer e30.e30.sig"} { // The last has an untrusted issuer
+		ctx := context.Background()
+		if authorization != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", authorization))
+		}
+		if _, err := interceptor(ctx, nil, info, handler); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("GetBuild (%q) = %v, want Unauthenticated", authorization, err)
+		}
+	}
+}
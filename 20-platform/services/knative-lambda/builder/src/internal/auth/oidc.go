@@ -0,0 +1,249 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+)
+
+// =============================================================================
+// 🔐 OIDC AUTHENTICATION
+// =============================================================================
+// With trusted issuers configured, every request to the CloudEvents receiver
+// and the management API must carry an OIDC Bearer token (JWT):
+//   - Issuers are discovered at startup (.well-known/openid-configuration);
+//     their signing keys (JWKS) are cached and refetched on an unknown key id
+//   - The token's tenants claim lists the ThirdPartyIds the caller may act
+//     for; "*" grants every tenant and the /admin API
+// 📝 NOTE: Several issuers can be trusted at once, e.g. the company IdP for
+// people and tools plus the cluster's issuer for Knative (OIDC sender
+// identity). Knative tokens carry no tenants: the broker forwards other
+// producers' events, so its subject is listed in TrustedSubjects (every tenant)
+
+// AllTenants in the tenants claim grants every tenant (admin)
+const AllTenants = "*"
+
+// Errors returned by Authenticate and Authorize
+var (
+	ErrUnauthenticated = errors.New("missing or invalid bearer token")
+	ErrForbidden       = errors.New("not allowed for this tenant")
+)
+
+// Config configures token validation
+type Config struct {
+	Issuers      []string // Trusted issuer URLs
+	Audiences    []string // A token must be issued for one of them
+	TenantsClaim string   // Claim listing the ThirdPartyIds a caller may act for
+
+	// TrustedSubjects act for every tenant whatever their claims (e.g.
+	// system:serviceaccount:knative-eventing:mt-broker-ingress)
+	TrustedSubjects []string
+}
+
+// Principal is an authenticated caller
+type Principal struct {
+	Issuer  string
+	Subject string
+	Tenants []string // ThirdPartyIds from the tenants claim
+}
+
+// Admin reports whether the caller may act for every tenant
+func (p *Principal) Admin() bool {
+	return slices.Contains(p.Tenants, AllTenants)
+}
+
+// Allows reports whether the caller may act for a tenant
+func (p *Principal) Allows(thirdPartyId string) bool {
+	return p.Admin() || slices.Contains(p.Tenants, thirdPartyId)
+}
+
+// Authenticator validates bearer tokens against the trusted issuers
+type Authenticator struct {
+	cfg       Config
+	verifiers map[string]*oidc.IDTokenVerifier // issuer -> verifier
+}
+
+// NewAuthenticator discovers every issuer
+// 📝 NOTE: ctx bounds the JWKS refreshes too; pass the builder's lifetime context
+func NewAuthenticator(ctx context.Context, cfg Config) (*Authenticator, error) {
+	if len(cfg.Issuers) == 0 || len(cfg.Audiences) == 0 || cfg.TenantsClaim == "" {
+		return nil, errors.New("oidc issuers, audiences and tenants claim are required")
+	}
+
+	verifiers := make(map[string]*oidc.IDTokenVerifier, len(cfg.Issuers))
+	for _, issuer := range cfg.Issuers {
+		provider, err := oidc.NewProvider(ctx, issuer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover oidc issuer %s: %w", issuer, err)
+		}
+		// Audiences are checked against the whole list below
+		verifiers[issuer] = provider.Verifier(&oidc.Config{SkipClientIDCheck: true})
+	}
+	return &Authenticator{cfg: cfg, verifiers: verifiers}, nil
+}
+
+// Authenticate validates a raw token and returns its caller
+// 📋 STEPS:
+//  1. Pick the verifier of the token's (not yet verified) issuer
+//  2. Verify signature, expiry and issuer, then the audience
+//  3. Read the tenants claim (unless the subject is trusted)
+func (a *Authenticator) Authenticate(ctx context.Context, rawToken string) (*Principal, error) {
+	issuer, err := unverifiedIssuer(rawToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+	verifier, ok := a.verifiers[issuer]
+	if !ok {
+		return nil, fmt.Errorf("%w: untrusted issuer %q", ErrUnauthenticated, issuer)
+	}
+
+	token, err := verifier.Verify(ctx, rawToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+	if !slices.ContainsFunc(token.Audience, func(aud string) bool { return slices.Contains(a.cfg.Audiences, aud) }) {
+		return nil, fmt.Errorf("%w: token not issued for this audience", ErrUnauthenticated)
+	}
+
+	principal := &Principal{Issuer: token.Issuer, Subject: token.Subject}
+	if slices.Contains(a.cfg.TrustedSubjects, token.Subject) {
+		principal.Tenants = []string{AllTenants}
+		return principal, nil
+	}
+
+	var claims map[string]json.RawMessage
+	if err := token.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+	principal.Tenants = tenantsClaim(claims[a.cfg.TenantsClaim])
+	return principal, nil
+}
+
+// unverifiedIssuer reads the iss claim of a JWT without verifying it
+func unverifiedIssuer(rawToken string) (string, error) {
+	parts := strings.Split(rawToken, ".")
+	if len(parts) != 3 {
+		return "", errors.New("malformed jwt")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("malformed jwt payload: %w", err)
+	}
+	var claims struct {
+		Issuer string `json:"iss"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", fmt.Errorf("malformed jwt payload: %w", err)
+	}
+	return claims.Issuer, nil
+}
+
+// tenantsClaim reads the tenants claim: a list of ThirdPartyIds, or a single
+// space/comma separated string (missing = no tenant)
+func tenantsClaim(raw json.RawMessage) []string {
+	var list []string
+	if json.Unmarshal(raw, &list) == nil {
+		return list
+	}
+	var single string
+	if json.Unmarshal(raw, &single) == nil {
+		return strings.FieldsFunc(single, func(r rune) bool { return r == ',' || r == ' ' })
+	}
+	return nil
+}
+
+// =============================================================================
+// 🚪 HTTP MIDDLEWARE
+// =============================================================================
+
+// publicPaths are served without a token
+// 🎯 WHY: Probes and Prometheus don't authenticate, badges are embedded in
+// READMEs, share links carry their own signed token
+var publicPaths = []string{"/health", "/readyz", "/metrics", "/badge/", "/share/"}
+
+// Middleware authenticates every request outside publicPaths and puts its
+// Principal in the request context; /admin needs the "*" tenant
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isPublic(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rawToken, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || rawToken == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer`)
+			writeError(w, http.StatusUnauthorized, ErrUnauthenticated.Error())
+			return
+		}
+		principal, err := a.Authenticate(r.Context(), rawToken)
+		if err != nil {
+			log.Printf("WARNING: Rejected %s %s: %v", r.Method, r.URL.Path, err)
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			writeError(w, http.StatusUnauthorized, ErrUnauthenticated.Error())
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/admin/") && !principal.Admin() {
+			writeError(w, http.StatusForbidden, "admin access required")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// isPublic reports whether a path is served without a token
+func isPublic(path string) bool {
+	for _, public := range publicPaths {
+		if path == public || (strings.HasSuffix(public, "/") && strings.HasPrefix(path, public)) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeError returns a JSON error body (same shape as the API's)
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]string{"error": message}); err != nil {
+		log.Printf("ERROR: Failed to encode response: %v", err)
+	}
+}
+
+// =============================================================================
+// 🎫 PRINCIPALS IN CONTEXTS
+// =============================================================================
+
+// principalKey stores the caller in a request context
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying the caller
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom returns the caller of a request, if it was authenticated
+func PrincipalFrom(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok
+}
+
+// Authorize checks that the caller may act for a tenant
+// 📝 NOTE: Contexts without a principal are allowed: authentication is off,
+// or the request came from a queue transport (trusted by its broker's ACLs)
+func Authorize(ctx context.Context, thirdPartyId string) error {
+	principal, ok := PrincipalFrom(ctx)
+	if !ok || principal.Allows(thirdPartyId) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s (subject %s)", ErrForbidden, thirdPartyId, principal.Subject)
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestTenantsClaim(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want []string
+	}{
+		{raw: `["acme","globex"]`, want: []string{"acme", "globex"}},
+		{raw: `"acme globex"`, want: []string{"acme", "globex"}},
+		{raw: `"acme,globex"`, want: []string{"acme", "globex"}},
+		{raw: ``, want: nil},
+		{raw: `42`, want: nil},
+	}
+	for _, tt := range tests {
+		if got := tenantsClaim(json.RawMessage(tt.raw)); !slices.Equal(got, tt.want) {
+			t.Errorf("tenantsClaim(%s) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestUnverifiedIssuer(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"iss":"https://idp.example.com","sub":"ci"}`))
+	if got, err := unverifiedIssuer("e30." + payload + ".sig"); err != nil || got != "https://idp.example.com" {
+		t.Errorf("unverifiedIssuer() = %q, %v; want https://idp.example.com", got, err)
+	}
+	if _, err := unverifiedIssuer("not-a-jwt"); err == nil {
+		t.Error("unverifiedIssuer(not-a-jwt) error = nil, want an error")
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	// No caller: authentication off, or a queue transport
+	if err := Authorize(context.Background(), "acme"); err != nil {
+		t.Errorf("Authorize(no principal) error = %v, want nil", err)
+	}
+
+	tenant := WithPrincipal(context.Background(), &Principal{Subject: "dev", Tenants: []string{"acme"}})
+	if err := Authorize(tenant, "acme"); err != nil {
+		t.Errorf("Authorize(own tenant) error = %v, want nil", err)
+	}
+	if err := Authorize(tenant, "globex"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Authorize(other tenant) error = %v, want %v", err, ErrForbidden)
+	}
+
+	admin := WithPrincipal(context.Background(), &Principal{Subject: "ops", Tenants: []string{AllTenants}})
+	if err := Authorize(admin, "globex"); err != nil {
+		t.Errorf("Authorize(admin) error = %v, want nil", err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	handler := (&Authenticator{}).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		path, authorization string
+		want                int
+	}{
+		{path: "/health", want: http.StatusNoContent},
+		{path: "/badge/acme/p1.svg", want: http.StatusNoContent},
+		{path: "/", want: http.StatusUnauthorized},
+		{path: "/v1/builds", authorization: "Basic dXNlcjpwYXNz", want: http.StatusUnauthorized},
+		{path: "/admin/tenants", authorization: "Bearer e30.e30.sig", want: http.StatusUnauthorized}, // Untrusted issuer
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.authorization != "" {
+			req.Header.Set("Authorization", tt.authorization)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("GET %s (%q) = %d, want %d", tt.path, tt.authorization, rec.Code, tt.want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package aws
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -17,6 +18,12 @@ import (
 // =============================================================================
 // This package handles AWS SDK configuration and client creation
 // 🎯 PURPOSE: Centralize AWS authentication and client management
+// 📝 NOTE: The builder creates one Client at startup and shares it: the
+// configuration is loaded and the account looked up once, not per build
+
+// credentialsExpiryWindow is how long before they expire the shared
+// credentials are refreshed (jittered, so replicas don't refresh together)
+const credentialsExpiryWindow = 5 * time.Minute
 
 // Client holds AWS service clients and configuration
 type Client struct {
@@ -24,12 +31,25 @@ type Client struct {
 	ECR       *ecr.Client
 	S3        *s3.Client
 	STS       *sts.Client
+	KMS       *KMS
 	AccountID string
+	Endpoints Endpoints // Where calls go instead of AWS (local development)
+
+	rolesMu sync.Mutex
+	roles   map[string]*Roles // By external ID
+}
+
+// ClientOptions tune the clients NewClient creates
+// 📝 NOTE: They apply to every client derived from the configuration, the
+// ones for assumed roles included
+type ClientOptions struct {
+	Retry     RetryOptions
+	Endpoints Endpoints // Empty = AWS
 }
 
 // NewClient creates a new AWS client with all necessary services
 // 🎯 PURPOSE: Set up authenticated AWS clients for ECR, S3, and STS operations
-func NewClient(ctx context.Context) (*Client, error) {
+func NewClient(ctx context.Context, opts ClientOptions) (*Client, error) {
 	// =========================================================================
 	// 📍 STEP 1: LOAD AWS CONFIGURATION
 	// =========================================================================
@@ -39,38 +59,56 @@ func NewClient(ctx context.Context) (*Client, error) {
 	// - AWS profiles (~/.aws/credentials)
 	// - EKS Pod Identity (service account tokens)
 
-	cfg, err := config.LoadDefaultConfig(ctx)
+	// 🔄 Credentials are cached and refreshed ahead of their expiry
+	loadOpts := append(retryConfig(opts.Retry), config.WithCredentialsCacheOptions(func(o *aws.CredentialsCacheOptions) {
+		o.ExpiryWindow = credentialsExpiryWindow
+		o.ExpiryWindowJitterFrac = 0.5
+	}))
+	cfg, err := config.LoadDefaultConfig(ctx, loadOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
+	// 🧪 LocalStack, MinIO... instead of AWS
+	opts.Endpoints.apply(&cfg)
 
 	// =========================================================================
 	// 📍 STEP 2: CREATE SERVICE CLIENTS
 	// =========================================================================
 
-	ecrClient := ecr.NewFromConfig(cfg)
-	s3Client := s3.NewFromConfig(cfg)
-	stsClient := sts.NewFromConfig(cfg)
+	client := &Client{Config: cfg, Endpoints: opts.Endpoints}
+	client.ECR = ecr.NewFromConfig(cfg)
+	client.S3 = client.NewS3(cfg)
+	client.STS = sts.NewFromConfig(cfg)
+	client.KMS = NewKMS(cfg)
 
 	// =========================================================================
 	// 📍 STEP 3: GET AWS ACCOUNT ID
 	// =========================================================================
 	// We need this for constructing ECR registry URLs
 
-	callerIdentity, err := stsClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	callerIdentity, err := client.STS.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get AWS caller identity: %w", err)
 	}
 
-	accountID := aws.ToString(callerIdentity.Account)
+	client.AccountID = aws.ToString(callerIdentity.Account)
+	return client, nil
+}
 
-	return &Client{
-		Config:    cfg,
-		ECR:       ecrClient,
-		S3:        s3Client,
-		STS:       stsClient,
-		AccountID: accountID,
-	}, nil
+// Roles returns the role assumer passing externalID ("" = none), shared so
+// every user of a role reuses its cached credentials
+func (c *Client) Roles(externalID string) *Roles {
+	c.rolesMu.Lock()
+	defer c.rolesMu.Unlock()
+	if roles, ok := c.roles[externalID]; ok {
+		return roles
+	}
+	if c.roles == nil {
+		c.roles = map[string]*Roles{}
+	}
+	roles := NewRoles(c.Config, externalID)
+	c.roles[externalID] = roles
+	return roles
 }
 
 // GetECRRegistryURL constructs the ECR registry URL for this account and region
@@ -81,9 +119,9 @@ func (c *Client) GetECRRegistryURL() string {
 
 // NewClientWithTimeout creates an AWS client with a specified timeout
 // 🎯 PURPOSE: For operations that need custom timeout handling
-func NewClientWithTimeout(timeout time.Duration) (*Client, error) {
+func NewClientWithTimeout(timeout time.Duration, opts ClientOptions) (*Client, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	return NewClient(ctx)
+	return NewClient(ctx, opts)
 }
@@ -0,0 +1,143 @@
+package aws
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+func TestEndpointsValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		endpoints Endpoints
+		wantErr   string
+	}{
+		{name: "AWS", endpoints: Endpoints{}},
+		{name: "LocalStack and MinIO", endpoints: Endpoints{URL: "http://localstack:4566", S3: "https://minio.local:9000"}},
+		{name: "no scheme", endpoints: Endpoints{URL: "localstack:4566"}, wantErr: `AWS endpoint "localstack:4566"`},
+		{name: "no host", endpoints: Endpoints{S3: "http://"}, wantErr: "AWS S3 endpoint"},
+		{name: "unsupported scheme", endpoints: Endpoints{ECR: "ftp://registry.local"}, wantErr: "AWS ECR endpoint"},
+		{name: "unparsable", endpoints: Endpoints{STS: "http://[::1"}, wantErr: "AWS STS endpoint"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.endpoints.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want an error about %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestS3Endpoint(t *testing.T) {
+	if got := (Endpoints{URL: "http://localstack:4566"}).S3Endpoint(); got != "http://localstack:4566" {
+		t.Errorf("S3Endpoint() = %q, want the shared endpoint", got)
+	}
+	if got := (Endpoints{URL: "http://localstack:4566", S3: "http://minio:9000"}).S3Endpoint(); got != "http://minio:9000" {
+		t.Errorf("S3Endpoint() = %q, want the S3 override", got)
+	}
+	if got := (Endpoints{}).S3Endpoint(); got != "" {
+		t.Errorf("S3Endpoint() = %q, want AWS", got)
+	}
+}
+
+func TestServiceURL(t *testing.T) {
+	cfg := aws.Config{Region: "eu-west-1"}
+	if got := serviceURL(cfg, "kms"); got != "https://kms.eu-west-1.amazonaws.com/" {
+		t.Errorf("serviceURL() = %q, want AWS's", got)
+	}
+	Endpoints{URL: "http://localstack:4566/"}.apply(&cfg)
+	if got := serviceURL(cfg, "kms"); got != "http://localstack:4566/" {
+		t.Errorf("serviceURL() = %q, want the base endpoint", got)
+	}
+	cfg.BaseEndpoint = aws.String("")
+	if got := serviceURL(cfg, "secretsmanager"); got != "https://secretsmanager.eu-west-1.amazonaws.com/" {
+		t.Errorf("serviceURL() with an empty base endpoint = %q, want AWS's", got)
+	}
+}
+
+// recordingClient records the URLs of the requests it is sent, and answers
+// them all with an empty 200
+type recordingClient struct {
+	mu   sync.Mutex
+	urls []string
+}
+
+func (c *recordingClient) Do(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.urls = append(c.urls, req.URL.Scheme+"://"+req.URL.Host+req.URL.Path)
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+}
+
+// last returns the URL of the last request
+func (c *recordingClient) last() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.urls) == 0 {
+		return ""
+	}
+	return c.urls[len(c.urls)-1]
+}
+
+func TestEndpointsApply(t *testing.T) {
+	endpoints := Endpoints{URL: "http://localstack:4566", S3: "http://minio:9000", STS: "http://sts.local:8080", S3PathStyle: true}
+	recorder := &recordingClient{}
+	cfg := aws.Config{
+		Region:     "us-east-1",
+		HTTPClient: recorder,
+		Retryer:    func() aws.Retryer { return aws.NopRetryer{} },
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "s3cret"}, nil
+		}),
+	}
+	endpoints.apply(&cfg)
+	ctx := context.Background()
+	head := &s3.HeadObjectInput{Bucket: aws.String("sources"), Key: aws.String("acme/p1.js")}
+
+	// 🪣 S3 goes to its own endpoint, the bucket in the path
+	client := &Client{Endpoints: endpoints}
+	client.NewS3(cfg).HeadObject(ctx, head)
+	if got := recorder.last(); got != "http://minio:9000/sources/acme/p1.js" {
+		t.Errorf("S3 call went to %s, want the bucket in the path on the S3 endpoint", got)
+	}
+	// Without path style, the bucket is in the host name
+	client.Endpoints.S3PathStyle = false
+	client.NewS3(cfg).HeadObject(ctx, head)
+	if got := recorder.last(); got != "http://sources.minio:9000/acme/p1.js" {
+		t.Errorf("S3 call went to %s, want the bucket in the host name", got)
+	}
+
+	// 🪪 STS has its own endpoint too
+	sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if got := recorder.last(); !strings.HasPrefix(got, "http://sts.local:8080") {
+		t.Errorf("STS call went to %s, want the STS endpoint", got)
+	}
+	// 🐳 ECR has no override: the shared endpoint
+	ecr.NewFromConfig(cfg).DescribeRepositories(ctx, &ecr.DescribeRepositoriesInput{})
+	if got := recorder.last(); !strings.HasPrefix(got, "http://localstack:4566") {
+		t.Errorf("ECR call went to %s, want the shared endpoint", got)
+	}
+
+	// No endpoints: AWS
+	unchanged := aws.Config{Region: "us-east-1", HTTPClient: recorder, Retryer: cfg.Retryer, Credentials: cfg.Credentials}
+	Endpoints{}.apply(&unchanged)
+	ecr.NewFromConfig(unchanged).DescribeRepositories(ctx, &ecr.DescribeRepositoriesInput{})
+	if got := recorder.last(); !strings.HasPrefix(got, "https://api.ecr.us-east-1.amazonaws.com") {
+		t.Errorf("ECR call went to %s, want AWS", got)
+	}
+}
@@ -0,0 +1,171 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+
+	"knative-lambda-builder/internal/observability"
+)
+
+// =============================================================================
+// 🔁 RETRIES
+// =============================================================================
+// Every AWS call the builder makes (ECR, S3, STS, KMS, Secrets Manager) is
+// retried the same way, configured once here
+// 🎯 WHY: ECR and S3 throttle bursts of builds; without retries the first
+// ThrottlingException or SlowDown failed the build that hit it
+// 📝 NOTE: Adaptive mode backs off exponentially like standard mode and also
+// slows the whole client down while throttling lasts, so a busy builder
+// doesn't keep hammering a throttled API
+
+// Retry modes
+const (
+	RetryModeAdaptive = "adaptive"
+	RetryModeStandard = "standard"
+)
+
+// RetryOptions controls how AWS calls are retried
+type RetryOptions struct {
+	Mode        string        // adaptive or standard
+	MaxAttempts int           // Attempts per call, the first included
+	CallTimeout time.Duration // How long one attempt waits for a response (0 = no limit)
+}
+
+// ValidateRetryOptions checks the retry mode and attempts are usable
+func ValidateRetryOptions(opts RetryOptions) error {
+	if opts.Mode != RetryModeAdaptive && opts.Mode != RetryModeStandard {
+		return fmt.Errorf("unknown AWS retry mode %q (want %s or %s)", opts.Mode, RetryModeAdaptive, RetryModeStandard)
+	}
+	if opts.MaxAttempts < 1 {
+		return fmt.Errorf("AWS max attempts must be at least 1, got %d", opts.MaxAttempts)
+	}
+	if opts.CallTimeout < 0 {
+		return fmt.Errorf("AWS call timeout must not be negative, got %s", opts.CallTimeout)
+	}
+	return nil
+}
+
+// retryConfig returns the configuration options applying opts to every client
+func retryConfig(opts RetryOptions) []func(*config.LoadOptions) error {
+	loadOpts := []func(*config.LoadOptions) error{
+		config.WithRetryMode(aws.RetryMode(opts.Mode)),
+		config.WithRetryMaxAttempts(opts.MaxAttempts),
+		config.WithAPIOptions([]func(*middleware.Stack) error{countRetries}),
+	}
+	if opts.CallTimeout > 0 {
+		// 📝 NOTE: A response header timeout, not http.Client.Timeout: the
+		// latter would also cut off S3 downloads still streaming their body
+		httpClient := awshttp.NewBuildableClient().WithTransportOptions(func(tr *http.Transport) {
+			tr.ResponseHeaderTimeout = opts.CallTimeout
+		})
+		loadOpts = append(loadOpts, config.WithHTTPClient(httpClient))
+	}
+	return loadOpts
+}
+
+// countRetries adds the middleware counting the retries of each SDK call
+func countRetries(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("CountRetries",
+		func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+			out, metadata, err := next.HandleInitialize(ctx, in)
+			if results, ok := retry.GetAttemptResults(metadata); ok && len(results.Results) > 1 {
+				observability.AWSRetries.WithLabelValues(
+					awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx),
+				).Add(float64(len(results.Results) - 1))
+			}
+			return out, metadata, err
+		}), middleware.After)
+}
+
+// withRetries runs an attempt of a hand-rolled client (KMS, Secrets Manager)
+// until it succeeds or cfg's retryer gives up, the way the SDK retries its own
+func withRetries(ctx context.Context, cfg aws.Config, service, operation string, attempt func() error) error {
+	if cfg.Retryer == nil {
+		return attempt()
+	}
+	retryer := cfg.Retryer()
+	for n := 1; ; n++ {
+		release := func(error) error { return nil }
+		if v2, ok := retryer.(aws.RetryerV2); ok {
+			var err error
+			if release, err = v2.GetAttemptToken(ctx); err != nil {
+				return fmt.Errorf("failed to get AWS retry token: %w", err)
+			}
+		}
+		err := attempt()
+		_ = release(err)
+		if err == nil || n >= retryer.MaxAttempts() || !retryer.IsErrorRetryable(err) {
+			return err
+		}
+		if _, tokenErr := retryer.GetRetryToken(ctx, err); tokenErr != nil {
+			return err // Retry quota exhausted
+		}
+		delay, delayErr := retryer.RetryDelay(n, err)
+		if delayErr != nil {
+			return err
+		}
+		observability.AWSRetries.WithLabelValues(service, operation).Inc()
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// jsonAPIError is an error returned by an AWS JSON protocol API; like the
+// SDK's errors it is a smithy.APIError, with the status the retryer also
+// classifies errors by (see internal/awserrors)
+type jsonAPIError struct {
+	Code    string
+	Message string
+	Status  int
+}
+
+// newJSONAPIError decodes the error body of a failed JSON protocol call
+func newJSONAPIError(raw []byte, status int) *jsonAPIError {
+	var body struct {
+		Type    string `json:"__type"`
+		Message string `json:"message"`
+	}
+	_ = json.Unmarshal(raw, &body)
+	// 📝 NOTE: __type may be namespaced (com.amazonaws.kms#ThrottlingException)
+	code := body.Type
+	if i := strings.LastIndex(code, "#"); i >= 0 {
+		code = code[i+1:]
+	}
+	return &jsonAPIError{Code: code, Message: body.Message, Status: status}
+}
+
+func (e *jsonAPIError) Error() string {
+	return fmt.Sprintf("%s: %s (HTTP %d)", e.Code, e.Message, e.Status)
+}
+
+// ErrorCode returns the AWS error code, e.g. ThrottlingException
+func (e *jsonAPIError) ErrorCode() string { return e.Code }
+
+// HTTPStatusCode returns the HTTP status of the failed call
+func (e *jsonAPIError) HTTPStatusCode() int { return e.Status }
+
+// ErrorMessage returns the message AWS sent with the error
+func (e *jsonAPIError) ErrorMessage() string { return e.Message }
+
+// ErrorFault tells server errors (5xx) from the caller's
+func (e *jsonAPIError) ErrorFault() smithy.ErrorFault {
+	if e.Status >= http.StatusInternalServerError {
+		return smithy.FaultServer
+	}
+	return smithy.FaultClient
+}
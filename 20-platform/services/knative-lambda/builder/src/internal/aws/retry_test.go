@@ -0,0 +1,146 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go"
+)
+
+// testConfig returns a configuration calling endpoint, retrying up to
+// maxAttempts times without waiting
+func testConfig(endpoint string, maxAttempts int) aws.Config {
+	return aws.Config{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(endpoint),
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "s3cret"}, nil
+		}),
+		Retryer: func() aws.Retryer {
+			return retry.NewStandard(func(o *retry.StandardOptions) {
+				o.MaxAttempts = maxAttempts
+				o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return 0, nil })
+			})
+		},
+	}
+}
+
+// kmsResponse is what a test KMS answers a call with
+type kmsResponse struct {
+	status int
+	body   string
+}
+
+// kmsServer answers each KMS call with the next of responses (the last one
+// once they run out)
+func kmsServer(t *testing.T, responses ...kmsResponse) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&calls, 1)) - 1
+		if r.Header.Get("X-Amz-Target") != "TrentService.GenerateDataKey" || r.Header.Get("Authorization") == "" {
+			http.Error(w, "unexpected request", http.StatusTeapot)
+			return
+		}
+		if n >= len(responses) {
+			n = len(responses) - 1
+		}
+		w.WriteHeader(responses[n].status)
+		io.WriteString(w, responses[n].body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+const dataKey = `{"Plaintext": "a2V5", "CiphertextBlob": "c2VhbGVk"}`
+
+func TestWithRetriesThrottledThenSucceeds(t *testing.T) {
+	server, calls := kmsServer(t,
+		kmsResponse{http.StatusBadRequest, `{"__type": "com.amazonaws.kms#ThrottlingException", "message": "Rate exceeded"}`},
+		kmsResponse{http.StatusInternalServerError, `{"__type": "KMSInternalException", "message": "try again"}`},
+		kmsResponse{http.StatusOK, dataKey},
+	)
+	kms := NewKMS(testConfig(server.URL, 3))
+
+	plaintext, encrypted, err := kms.GenerateDataKey(context.Background(), "alias/acme")
+	if err != nil {
+		t.Fatalf("GenerateDataKey() = %v, want it to succeed on the third attempt", err)
+	}
+	if string(plaintext) != "key" || string(encrypted) != "sealed" || atomic.LoadInt32(calls) != 3 {
+		t.Errorf("GenerateDataKey() = %q, %q after %d calls, want key, sealed after 3", plaintext, encrypted, *calls)
+	}
+}
+
+func TestWithRetriesGivesUp(t *testing.T) {
+	throttled := kmsResponse{http.StatusBadRequest, `{"__type": "ThrottlingException", "message": "Rate exceeded"}`}
+	server, calls := kmsServer(t, throttled)
+	_, _, err := NewKMS(testConfig(server.URL, 2)).GenerateDataKey(context.Background(), "alias/acme")
+
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "ThrottlingException" || atomic.LoadInt32(calls) != 2 {
+		t.Errorf("GenerateDataKey() = %v after %d calls, want ThrottlingException after 2", err, *calls)
+	}
+}
+
+func TestWithRetriesNonRetryable(t *testing.T) {
+	server, calls := kmsServer(t,
+		kmsResponse{http.StatusBadRequest, `{"__type": "NotFoundException", "message": "Alias alias/acme is not found."}`},
+		kmsResponse{http.StatusOK, dataKey},
+	)
+	_, _, err := NewKMS(testConfig(server.URL, 3)).GenerateDataKey(context.Background(), "alias/acme")
+
+	var apiErr *jsonAPIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "NotFoundException" || apiErr.Status != http.StatusBadRequest {
+		t.Fatalf("GenerateDataKey() = %v, want the NotFoundException", err)
+	}
+	if n := atomic.LoadInt32(calls); n != 1 {
+		t.Errorf("GenerateDataKey() made %d calls, want 1 (a 400 isn't retried)", n)
+	}
+}
+
+func TestWithRetriesWithoutRetryer(t *testing.T) {
+	attempts := 0
+	err := withRetries(context.Background(), aws.Config{}, "KMS", "Decrypt", func() error {
+		attempts++
+		return &jsonAPIError{Code: "ThrottlingException", Status: http.StatusBadRequest}
+	})
+	if err == nil || attempts != 1 {
+		t.Errorf("withRetries() without a retryer = %v after %d attempts, want one failed attempt", err, attempts)
+	}
+}
+
+func TestNewJSONAPIError(t *testing.T) {
+	tests := []struct {
+		name      string
+		raw       string
+		status    int
+		wantCode  string
+		wantFault smithy.ErrorFault
+	}{
+		{name: "namespaced", raw: `{"__type": "com.amazonaws.kms#ThrottlingException", "message": "Rate exceeded"}`,
+			status: http.StatusBadRequest, wantCode: "ThrottlingException", wantFault: smithy.FaultClient},
+		{name: "plain", raw: `{"__type": "ResourceNotFoundException", "message": "Secrets Manager can't find the secret"}`,
+			status: http.StatusBadRequest, wantCode: "ResourceNotFoundException", wantFault: smithy.FaultClient},
+		{name: "server", raw: `{"__type": "KMSInternalException"}`,
+			status: http.StatusInternalServerError, wantCode: "KMSInternalException", wantFault: smithy.FaultServer},
+		{name: "not json", raw: `<html>Bad Gateway</html>`,
+			status: http.StatusBadGateway, wantFault: smithy.FaultServer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newJSONAPIError([]byte(tt.raw), tt.status)
+			if err.ErrorCode() != tt.wantCode || err.HTTPStatusCode() != tt.status || err.ErrorFault() != tt.wantFault {
+				t.Errorf("newJSONAPIError() = %+v (fault %v), want code %q, status %d, fault %v",
+					err, err.ErrorFault(), tt.wantCode, tt.status, tt.wantFault)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// =============================================================================
+// 🎭 CROSS-ACCOUNT ROLES
+// =============================================================================
+// Images can be pushed into another AWS account (e.g. a central tooling
+// account) than the one the builder runs in: the builder assumes an IAM role
+// of that account for its ECR calls, and hands build jobs the role's
+// temporary credentials
+// 📝 NOTE: Credentials are cached per role and refreshed before they expire
+
+// roleSessionName names the builder's role sessions in CloudTrail
+const roleSessionName = "knative-lambda-builder"
+
+// Roles assumes IAM roles with the builder's own credentials
+type Roles struct {
+	cfg        aws.Config
+	sts        *sts.Client
+	externalID string // Passed along when set (the roles' trust policy may require it)
+
+	mu        sync.Mutex
+	providers map[string]*aws.CredentialsCache // roleARN -> its credentials
+}
+
+// NewRoles creates a role assumer on top of an AWS configuration
+func NewRoles(cfg aws.Config, externalID string) *Roles {
+	return &Roles{
+		cfg:        cfg,
+		sts:        sts.NewFromConfig(cfg),
+		externalID: externalID,
+		providers:  map[string]*aws.CredentialsCache{},
+	}
+}
+
+// provider returns the (cached) credentials of a role
+func (r *Roles) provider(roleARN string) *aws.CredentialsCache {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if provider, ok := r.providers[roleARN]; ok {
+		return provider
+	}
+	provider := aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(r.sts, roleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = roleSessionName
+		if r.externalID != "" {
+			o.ExternalID = aws.String(r.externalID)
+		}
+	}))
+	r.providers[roleARN] = provider
+	return provider
+}
+
+// Config returns the AWS configuration of a role, for service clients acting as it
+func (r *Roles) Config(roleARN string) aws.Config {
+	cfg := r.cfg.Copy()
+	cfg.Credentials = r.provider(roleARN)
+	return cfg
+}
+
+// Credentials returns temporary credentials of a role
+func (r *Roles) Credentials(ctx context.Context, roleARN string) (aws.Credentials, error) {
+	credentials, err := r.provider(roleARN).Retrieve(ctx)
+	if err != nil {
+		return aws.Credentials{}, fmt.Errorf("failed to assume role %s: %w", roleARN, err)
+	}
+	return credentials, nil
+}
+
+// RoleAccountID returns the account of an IAM role ARN
+// (arn:aws:iam::<account>:role/<name>; "" if it isn't one)
+func RoleAccountID(roleARN string) string {
+	parts := strings.Split(roleARN, ":")
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != "iam" || !strings.HasPrefix(parts[5], "role/") {
+		return ""
+	}
+	return parts[4]
+}
@@ -0,0 +1,70 @@
+package awserrors
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go"
+)
+
+// =============================================================================
+// 🧯 AWS ERRORS
+// =============================================================================
+// AWS API errors are told apart by their error code (smithy.APIError), never
+// by matching their message: the code survives wrapping (fmt.Errorf %w) and
+// SDK upgrades, the message doesn't
+// 📝 NOTE: Errors are also classified as retryable (throttling, 5xx, dropped
+// connections) or terminal, the same way the SDK's retryer does, so a build
+// that failed on a transient error can be retried instead of failed
+
+// Error codes the builder handles
+const (
+	// ECR
+	RepositoryNotFound      = "RepositoryNotFoundException"
+	RepositoryAlreadyExists = "RepositoryAlreadyExistsException"
+	ImageNotFound           = "ImageNotFoundException"
+	ScanNotFound            = "ScanNotFoundException"
+
+	// S3
+	NoSuchBucketPolicy                      = "NoSuchBucketPolicy"
+	ServerSideEncryptionConfigurationAbsent = "ServerSideEncryptionConfigurationNotFoundError"
+)
+
+// Code returns the AWS error code of err ("" if it isn't an AWS API error)
+func Code(err error) string {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+	return ""
+}
+
+// HasCode reports whether err is an AWS API error with one of the codes
+func HasCode(err error, codes ...string) bool {
+	code := Code(err)
+	if code == "" {
+		return false
+	}
+	for _, c := range codes {
+		if code == c {
+			return true
+		}
+	}
+	return false
+}
+
+// retryables are the SDK retryer's checks
+var retryables = retry.IsErrorRetryables(retry.DefaultRetryables)
+
+// IsRetryable reports whether err is transient, so the call (or the build
+// making it) may succeed when tried again: throttling, server errors, dropped
+// connections. Canceled contexts are not
+func IsRetryable(err error) bool {
+	return err != nil && retryables.IsErrorRetryable(err).Bool()
+}
+
+// IsTerminal reports whether err won't go away by trying again, e.g. access
+// denied or a missing resource
+func IsTerminal(err error) bool {
+	return err != nil && !IsRetryable(err)
+}
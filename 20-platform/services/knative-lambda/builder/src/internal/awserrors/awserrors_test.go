@@ -0,0 +1,41 @@
+package awserrors
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go"
+)
+
+func TestClassification(t *testing.T) {
+	notFound := fmt.Errorf("failed to describe ECR repository acme: %w",
+		&smithy.GenericAPIError{Code: RepositoryNotFound, Message: "The repository does not exist"})
+	if !HasCode(notFound, ImageNotFound, RepositoryNotFound) || HasCode(notFound, ImageNotFound) {
+		t.Errorf("HasCode(%v) doesn't see the wrapped code", notFound)
+	}
+	if Code(fmt.Errorf("not an AWS error")) != "" {
+		t.Error("Code() of a plain error isn't empty")
+	}
+
+	for _, tc := range []struct {
+		err       error
+		retryable bool
+	}{
+		{&smithy.GenericAPIError{Code: "ThrottlingException"}, true},
+		{&smithy.GenericAPIError{Code: "SlowDown"}, true},
+		{&retry.MaxAttemptsError{Attempt: 5, Err: &smithy.GenericAPIError{Code: "ThrottlingException"}}, true},
+		{&smithy.GenericAPIError{Code: "AccessDeniedException"}, false},
+		{notFound, false},
+		{context.Canceled, false},
+		{nil, false},
+	} {
+		if got := IsRetryable(tc.err); got != tc.retryable {
+			t.Errorf("IsRetryable(%v) = %t, want %t", tc.err, got, tc.retryable)
+		}
+		if tc.err != nil && IsTerminal(tc.err) == tc.retryable {
+			t.Errorf("IsTerminal(%v) = %t, want %t", tc.err, !tc.retryable, !tc.retryable)
+		}
+	}
+}
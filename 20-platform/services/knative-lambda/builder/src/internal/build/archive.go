@@ -0,0 +1,190 @@
+package build
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// =============================================================================
+// 🗜️ BUILD CONTEXT ARCHIVE
+// =============================================================================
+// Build contexts are packed in-process with archive/tar and compress/gzip:
+// the builder image needs no tar binary (busybox tar has none of the flags
+// reproducible builds relied on), entries are always in name order, and
+// BUILD_CONTEXT_EXCLUDE can leave files out
+//
+// Before a context is packed it is checked against BUILD_CONTEXT_MAX_BYTES,
+// BUILD_CONTEXT_MAX_FILES and BUILD_CONTEXT_DENIED_EXTENSIONS: a context
+// breaking them fails the build with stage validate, saying why, rather than
+// shipping a 2 GB tarball to the build job
+// 📝 NOTE: Patterns (path.Match) are matched against a file's name and its
+// path in the context, e.g. *.map, docs or docs/*.md; excluding a directory
+// excludes everything under it
+
+// packOptions configure how a build context is packed
+type packOptions struct {
+	Reproducible bool     // Fixed timestamps and ownership (entries are always in name order)
+	Exclude      []string // Patterns of the files left out
+
+	MaxBytes         int64    // Largest total size of the files packed (0 = no limit)
+	MaxFiles         int      // Most files packed (0 = no limit)
+	DeniedExtensions []string // Extensions of files refused, e.g. ".exe"
+}
+
+// packOptions returns how the orchestrator packs build contexts
+func (o *Orchestrator) packOptions() packOptions {
+	return packOptions{
+		Reproducible:     o.cfg.ReproducibleBuilds,
+		Exclude:          o.cfg.ContextExclude,
+		MaxBytes:         int64(o.cfg.ContextMaxBytes),
+		MaxFiles:         o.cfg.ContextMaxFiles,
+		DeniedExtensions: o.cfg.ContextDeniedExtensions,
+	}
+}
+
+// ContextRejectedError is returned for a build context breaking the context limits
+type ContextRejectedError struct {
+	Reason string
+}
+
+func (e *ContextRejectedError) Error() string {
+	return "build context rejected: " + e.Reason
+}
+
+// excluded reports whether a context path (slash-separated) is left out
+func (p packOptions) excluded(rel string) bool {
+	for _, pattern := range p.Exclude {
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, path.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// deniedExtension returns the refused extension of a context path ("" if allowed)
+func (p packOptions) deniedExtension(rel string) string {
+	ext := strings.ToLower(path.Ext(rel))
+	for _, denied := range p.DeniedExtensions {
+		denied = strings.ToLower(denied)
+		if !strings.HasPrefix(denied, ".") {
+			denied = "." + denied
+		}
+		if ext == denied {
+			return denied
+		}
+	}
+	return ""
+}
+
+// checkContext refuses a context breaking the context limits
+// 📝 NOTE: Excluded files don't count, they are never packed
+func checkContext(dir string, opts packOptions) error {
+	var files int
+	var total int64
+	return filepath.WalkDir(dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || file == dir {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		switch {
+		case opts.excluded(rel) && entry.IsDir():
+			return filepath.SkipDir
+		case opts.excluded(rel) || entry.IsDir():
+			return nil
+		}
+		if ext := opts.deniedExtension(rel); ext != "" {
+			return &ContextRejectedError{Reason: fmt.Sprintf("%s: %s files are not allowed (BUILD_CONTEXT_DENIED_EXTENSIONS)", rel, ext)}
+		}
+		if files++; opts.MaxFiles > 0 && files > opts.MaxFiles {
+			return &ContextRejectedError{Reason: fmt.Sprintf("more than %d files (BUILD_CONTEXT_MAX_FILES)", opts.MaxFiles)}
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if total += info.Size(); opts.MaxBytes > 0 && total > opts.MaxBytes {
+			return &ContextRejectedError{Reason: fmt.Sprintf("larger than %d bytes (BUILD_CONTEXT_MAX_BYTES)", opts.MaxBytes)}
+		}
+		return nil
+	})
+}
+
+// packContext writes dir to w as a tar.gz
+// 🎯 WHY: In reproducible mode fixed mtime/owner/order and a gzip header
+// without name or timestamp make the tarball byte-identical for identical inputs
+func packContext(w io.Writer, dir string, opts packOptions) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	err := filepath.WalkDir(dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || file == dir {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if opts.excluded(rel) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.IsDir() && !entry.Type().IsRegular() {
+			return fmt.Errorf("%s is not a regular file or directory", rel)
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = rel
+		if entry.IsDir() {
+			header.Name += "/"
+		}
+		if opts.Reproducible {
+			header.ModTime = time.Unix(0, 0)
+			header.AccessTime, header.ChangeTime = time.Time{}, time.Time{}
+			header.Uid, header.Gid = 0, 0
+			header.Uname, header.Gname = "", ""
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
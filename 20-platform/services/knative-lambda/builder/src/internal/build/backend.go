@@ -0,0 +1,129 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"knative-lambda-builder/internal/config"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🧱 BUILD BACKENDS
+// =============================================================================
+// A backend is the tool a build job runs to turn the context into an image:
+//   - kaniko (default): the executor builds and pushes inside the job's pod
+//   - buildkit: buildctl hands the context to the in-cluster buildkitd
+//
+// BUILD_BACKEND picks the default; a build may pick its own (BuildEvent.Backend).
+// Multi-platform builds default to BuildKit (see target platforms)
+// 🎯 WHY: Kaniko mishandles some multi-stage Dockerfiles that BuildKit builds fine
+// 📝 NOTE: Both render build-* jobs with the same labels, so the rest of the
+// pipeline (queue, retries, timeouts, tests) doesn't care which one ran
+
+// Build backends
+const (
+	BackendKaniko   = "kaniko"
+	BackendBuildKit = "buildkit"
+)
+
+// progressLogLines is how much of a failed job's output is searched for its last step
+const progressLogLines = 200
+
+// Backend describes how build jobs of one build tool are rendered and read
+type Backend interface {
+	// Name identifies the backend (BUILD_BACKEND, BuildEvent.Backend)
+	Name() string
+	// TemplatePath is the job template build jobs are rendered from
+	TemplatePath() string
+	// Image is the image doing the build (part of the build inputs)
+	Image() string
+	// Progress finds the last build step reached in a job's output
+	Progress(logs string) (Progress, bool)
+}
+
+// Progress is the build step a job reached
+type Progress struct {
+	Step        int    // 1-based (0 = unknown)
+	Total       int    // Steps of the stage (0 = unknown)
+	Instruction string // e.g. "RUN npm install"
+}
+
+// String describes the step, e.g. "step 4/5 (RUN npm install)"
+func (p Progress) String() string {
+	if p.Step == 0 || p.Total == 0 {
+		return fmt.Sprintf("step %q", p.Instruction)
+	}
+	return fmt.Sprintf("step %d/%d (%s)", p.Step, p.Total, p.Instruction)
+}
+
+// ValidBackend reports whether b is a known backend ("" = BUILD_BACKEND)
+func ValidBackend(b string) bool {
+	switch b {
+	case "", BackendKaniko, BackendBuildKit:
+		return true
+	}
+	return false
+}
+
+// newBackends creates the known backends
+func newBackends(cfg *config.Config) map[string]Backend {
+	return map[string]Backend{
+		BackendKaniko:   kanikoBackend{cfg: cfg},
+		BackendBuildKit: buildKitBackend{cfg: cfg},
+	}
+}
+
+// backend returns a backend by name
+func (o *Orchestrator) backend(name string) (Backend, error) {
+	backend, ok := o.backends[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown build backend %q (kaniko or buildkit)", name)
+	}
+	return backend, nil
+}
+
+// BuildProgress returns the last step a build job reached, read from its output
+// 📝 NOTE: false when the output is gone or shows no step
+func (o *Orchestrator) BuildProgress(ctx context.Context, be types.BuildEvent, jobName string) (Progress, bool) {
+	t, err := o.target(ctx, be)
+	if err != nil {
+		return Progress{}, false
+	}
+	logs, err := o.executor.Logs(ctx, o.cfg.KubernetesNamespace, jobName, progressLogLines)
+	if err != nil {
+		return Progress{}, false
+	}
+	return t.backend.Progress(logs)
+}
+
+// =============================================================================
+// 📦 KANIKO
+// =============================================================================
+
+// kanikoInstruction matches the Dockerfile instructions Kaniko logs as it runs
+// them, in both its color (INFO[0010] RUN ...) and text (msg="RUN ...") formats
+var kanikoInstruction = regexp.MustCompile(`(?:\] |msg=")((?:FROM|RUN|COPY|ADD|WORKDIR|ENV|ARG|USER|ENTRYPOINT|CMD|LABEL|EXPOSE|VOLUME|SHELL) .*?)"?$`)
+
+// kanikoBackend builds in the job's pod with the Kaniko executor
+type kanikoBackend struct {
+	cfg *config.Config
+}
+
+func (k kanikoBackend) Name() string         { return BackendKaniko }
+func (k kanikoBackend) TemplatePath() string { return k.cfg.JobTemplatePath }
+func (k kanikoBackend) Image() string        { return k.cfg.KanikoImage }
+
+// Progress implements Backend
+// 📝 NOTE: Kaniko doesn't number its steps, only the instruction is known
+func (k kanikoBackend) Progress(logs string) (Progress, bool) {
+	var last Progress
+	for _, line := range strings.Split(logs, "\n") {
+		if match := kanikoInstruction.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+			last = Progress{Instruction: match[1]}
+		}
+	}
+	return last, last.Instruction != ""
+}
@@ -0,0 +1,46 @@
+package build
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"knative-lambda-builder/internal/config"
+)
+
+// =============================================================================
+// 🐋 BUILDKIT
+// =============================================================================
+// BuildKit jobs download the context from S3, then run buildctl against the
+// buildkitd deployment (BUILDKIT_ADDR), which builds and pushes the image
+// 📝 NOTE: buildctl runs with --progress=plain, whose step lines look like
+//
+//	#7 [4/5] RUN npm install
+//	#9 [build 2/4] RUN npm ci
+
+// buildKitStep matches a step line of buildctl's plain progress output
+var buildKitStep = regexp.MustCompile(`^#\d+ \[(?:[^\]]* )?(\d+)/(\d+)\] (.+)$`)
+
+// buildKitBackend builds with buildctl against an in-cluster buildkitd
+type buildKitBackend struct {
+	cfg *config.Config
+}
+
+func (b buildKitBackend) Name() string         { return BackendBuildKit }
+func (b buildKitBackend) TemplatePath() string { return b.cfg.BuildKitJobTemplatePath }
+func (b buildKitBackend) Image() string        { return b.cfg.BuildKitImage }
+
+// Progress implements Backend
+func (b buildKitBackend) Progress(logs string) (Progress, bool) {
+	var last Progress
+	for _, line := range strings.Split(logs, "\n") {
+		match := buildKitStep.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		step, _ := strconv.Atoi(match[1])
+		total, _ := strconv.Atoi(match[2])
+		last = Progress{Step: step, Total: total, Instruction: match[3]}
+	}
+	return last, last.Instruction != ""
+}
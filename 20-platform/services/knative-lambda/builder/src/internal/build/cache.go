@@ -0,0 +1,226 @@
+package build
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"knative-lambda-builder/internal/templates"
+	"knative-lambda-builder/internal/types"
+	"knative-lambda-builder/storage"
+)
+
+// =============================================================================
+// ⚡ PER-STAGE BUILD CACHE
+// =============================================================================
+// Every build is keyed by a hash of its inputs: the source (and tests) ETags,
+// the templates' content and the runtime (base image, Kaniko image, flags).
+// When the inputs of the previous build are unchanged we:
+//   - skip Fetch/Prepare if its context tarball is still in the tmp bucket
+//   - skip the build entirely if the registry still serves the image that
+//     build pushed (same digest), going straight to deployment
+//
+// A source uploaded again gets a new ETag (GCS and Azure generations always
+// change), so entries also record a content hash of the inputs, with the
+// source identified by its SHA-256 instead: when only the ETag changed, the
+// source is downloaded and hashed, and an identical one still skips the build
+//
+// The cache entry lives next to the build context: cache/<tid>/<pid>.json
+
+// CacheEntry records the inputs (and output) of a parser's last build
+type CacheEntry struct {
+	InputsHash  string `json:"inputsHash"`
+	ContextKey  string `json:"contextKey"`
+	JobName     string `json:"jobName"`
+	ImageDigest string `json:"imageDigest,omitempty"` // Set once the job pushed the image
+	ImageTag    string `json:"imageTag,omitempty"`    // Revision the job pushed ("" = legacy <parserId> tag)
+
+	// The parser source the context was assembled from (see integrity.go)
+	SourceSHA256 string `json:"sourceSha256,omitempty"`
+	SourceETag   string `json:"sourceEtag,omitempty"`
+	// The inputs hash with the source identified by SourceSHA256 ("" for git
+	// and inline sources, whose versions are content hashes already)
+	ContentHash string `json:"contentHash,omitempty"`
+}
+
+// CacheKey returns the S3 key of a parser's cache entry
+func CacheKey(be types.BuildEvent) string {
+	return fmt.Sprintf("cache/%s/%s.json", be.ThirdPartyId, be.ParserId)
+}
+
+// inputsHash hashes everything that determines the build's output
+// 📝 NOTE: The source is identified by its ETag (its commit for builds from
+// git) so it doesn't need to be downloaded
+func (o *Orchestrator) inputsHash(ctx context.Context, be types.BuildEvent, t target) (string, error) {
+	source, err := o.sourceVersion(ctx, be)
+	if err != nil {
+		return "", err
+	}
+	return o.hashInputs(ctx, be, t, source)
+}
+
+// contentHash is inputsHash with the source identified by its SHA-256
+func (o *Orchestrator) contentHash(ctx context.Context, be types.BuildEvent, t target, sourceSHA256 string) (string, error) {
+	return o.hashInputs(ctx, be, t, "sha256:"+sourceSHA256)
+}
+
+// hashInputs hashes a build's inputs, its source identified by source
+func (o *Orchestrator) hashInputs(ctx context.Context, be types.BuildEvent, t target, source string) (string, error) {
+	tests, err := o.testSource(ctx, be)
+	if err != nil {
+		return "", err
+	}
+
+	dockerfile, err := o.customDockerfile(ctx, be)
+	if err != nil {
+		return "", err
+	}
+
+	deps, err := o.depsSource(ctx, be)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "source=%s\n", source)
+	if tests != nil {
+		fmt.Fprintf(h, "tests=%s\n", tests.ETag)
+	}
+	if dockerfile != nil {
+		fmt.Fprintf(h, "customDockerfile=%s\n", dockerfile.ETag)
+	}
+	if deps != nil {
+		fmt.Fprintf(h, "deps=%s\n", deps.ETag)
+	}
+
+	rt := o.runtime(be)
+	templatePaths := []string{t.backend.TemplatePath()}
+	templatePaths = append(templatePaths, o.templatePaths(rt)...)
+	for _, path := range templatePaths {
+		content, err := templates.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "template:%s=%x\n", filepath.Base(path), sha256.Sum256(content))
+	}
+
+	for i, image := range rt.Images(o.cfg) {
+		if i == 0 {
+			fmt.Fprintf(h, "baseImage=%s\n", image.Ref)
+		} else {
+			fmt.Fprintf(h, "image:%s=%s\n", image.Setting, image.Ref)
+		}
+	}
+	fmt.Fprintf(h, "%sImage=%s\n", t.backend.Name(), t.backend.Image()) // "kanikoImage=..." as before backends
+	fmt.Fprintf(h, "platforms=%s\n", strings.Join(t.platforms, ","))
+	fmt.Fprintf(h, "dockerfile=%s\n", o.cfg.DefaultDockerfileName)
+	fmt.Fprintf(h, "reproducible=%s\n", strconv.FormatBool(o.cfg.ReproducibleBuilds))
+	for _, arg := range BuildArgs(be) {
+		fmt.Fprintf(h, "buildArg:%s=%s\n", arg.Name, arg.Value)
+	}
+	for _, dep := range NpmDependencies(be) {
+		fmt.Fprintf(h, "dependency:%s=%s\n", dep.Name, dep.Version)
+	}
+	// 📝 Only when set, so entries from before exclusions keep matching
+	if len(o.cfg.ContextExclude) > 0 {
+		fmt.Fprintf(h, "contextExclude=%s\n", strings.Join(o.cfg.ContextExclude, ","))
+	}
+	// 📦 Only whether there is an .npmrc, never its content
+	if secret := o.npmrcSecret(); secret != "" {
+		fmt.Fprintf(h, "npmrc=%s\n", secret)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// sameContent reports whether a build's source, though its ETag changed, is
+// the one entry was built from; returns its current ETag
+// 📝 NOTE: Downloads the source to hash it; only bucket sources are compared
+func (o *Orchestrator) sameContent(ctx context.Context, be types.BuildEvent, t target, entry *CacheEntry) (string, bool) {
+	if entry.ContentHash == "" || gitSource(be) != nil || inlineSource(be) != nil {
+		return "", false
+	}
+	key, info, err := o.parserSource(ctx, be)
+	if err != nil {
+		return "", false
+	}
+	sum, err := o.objectSHA256(ctx, be, key)
+	if err != nil {
+		log.Printf("WARNING: Failed to hash %s: %v", o.SourceURI(key), err)
+		return "", false
+	}
+	hash, err := o.contentHash(ctx, be, t, sum)
+	if err != nil || hash != entry.ContentHash {
+		return "", false
+	}
+	return info.ETag, true
+}
+
+// loadCacheEntry returns a parser's cache entry (nil if there is none)
+func (o *Orchestrator) loadCacheEntry(ctx context.Context, be types.BuildEvent) (*CacheEntry, error) {
+	raw, err := o.getSealed(ctx, CacheKey(be))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entry CacheEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		// A corrupt entry is a cache miss, not a build failure
+		log.Printf("WARNING: Ignoring invalid cache entry %s: %v", CacheKey(be), err)
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+// saveCacheEntry writes a parser's cache entry
+func (o *Orchestrator) saveCacheEntry(ctx context.Context, be types.BuildEvent, entry CacheEntry) error {
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+	if err := o.putSealed(ctx, be.ThirdPartyId, CacheKey(be), raw); err != nil {
+		return fmt.Errorf("failed to save cache entry: %w", err)
+	}
+	return nil
+}
+
+// contextExists reports whether the uploaded build context is still available
+func (o *Orchestrator) contextExists(ctx context.Context, key string) bool {
+	_, err := o.store.Head(ctx, o.cfg.S3TmpBucket, key)
+	return err == nil
+}
+
+// RecordImage stores the digest a finished build job pushed in its revision
+// and in the cache entry
+// 🎯 PURPOSE: Lets the next build with identical inputs skip Kaniko entirely
+// 📝 NOTE: The cache entry is left alone if a newer build of the parser
+// started since jobName
+func (o *Orchestrator) RecordImage(ctx context.Context, be types.BuildEvent, jobName string) error {
+	digest, err := o.ImageDigest(ctx, be)
+	if err != nil || digest == "" {
+		return err
+	}
+	if be.ImageTag != "" {
+		if err := o.updateRevision(ctx, be, func(revision *Revision) { revision.Digest = digest }); err != nil {
+			return err
+		}
+	}
+	if !o.cfg.BuildCacheEnabled {
+		return nil
+	}
+
+	entry, err := o.loadCacheEntry(ctx, be)
+	if err != nil || entry == nil || entry.JobName != jobName {
+		return err
+	}
+	entry.ImageDigest = digest
+	return o.saveCacheEntry(ctx, be, *entry)
+}
@@ -0,0 +1,249 @@
+package build
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"knative-lambda-builder/internal/templates"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 📦 BUILD CONTEXT PREPARATION
+// =============================================================================
+// Kaniko reads its build context from S3 as a tar.gz. This file assembles it:
+// parser source + rendered wrapper files, packed and uploaded to the tmp bucket
+
+// prepareBuildContext assembles the build context and uploads it to S3
+// Returns the parser source it was assembled from (see integrity.go)
+// 📋 STEPS:
+//  1. Download the parser source (its source tarball, unpacked, if the
+//     runtime takes one and it ships one; its directory of a git repository
+//     for builds from git; the request's content for inline builds) and its
+//     optional tests and dependencies into a temp dir, verify its checksum if
+//     the build asked for one, drop what the bundle's .lambdaignore lists, and
+//     check that they compile
+//  2. Render the runtime's wrapper templates next to it, with the build's
+//     dependencies merged into package.json (the parser's custom Dockerfile,
+//     if it ships one, replaces the templated one)
+//  3. Check the directory against the context limits, then tar + gzip it,
+//     without BUILD_CONTEXT_EXCLUDE (normalized in reproducible mode),
+//     streamed straight into the tmp bucket
+//  4. Record the inputs next to it (reproducible mode only)
+func (o *Orchestrator) prepareBuildContext(ctx context.Context, be types.BuildEvent, t target) (sourceDigest, error) {
+	var digest sourceDigest
+	tempDir, err := os.MkdirTemp("", fmt.Sprintf("%s%s-%s-", tempPrefix, be.ThirdPartyId, be.ParserId))
+	if err != nil {
+		return digest, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer logCleanup(tempDir)
+
+	// =========================================================================
+	// 📍 STEP 1: DOWNLOAD AND CHECK PARSER SOURCE
+	// =========================================================================
+	rt := o.runtime(be)
+	var sources []string
+	if gitSource(be) != nil {
+		files, err := o.checkoutGit(ctx, be, filepath.Join(tempDir, rt.SourceDir))
+		if err != nil {
+			return digest, err
+		}
+		for _, file := range files {
+			sources = append(sources, filepath.Join(rt.SourceDir, file))
+		}
+	} else if inlineSource(be) != nil {
+		source, err := o.writeInline(be, tempDir)
+		if err != nil {
+			return digest, err
+		}
+		if digest.SHA256, err = fileSHA256(filepath.Join(tempDir, source)); err != nil {
+			return digest, err
+		}
+		sources = append(sources, source)
+	} else if sourceKey, info, err := o.parserSource(ctx, be); err != nil {
+		return digest, err
+	} else if sourceKey == ModuleSourceKey(be) {
+		files, sum, err := o.downloadModule(ctx, be, filepath.Join(tempDir, rt.SourceDir))
+		if err != nil {
+			return digest, err
+		}
+		digest = sourceDigest{ETag: info.ETag, SHA256: sum}
+		for _, file := range files {
+			sources = append(sources, filepath.Join(rt.SourceDir, file))
+		}
+	} else {
+		source := filepath.Join(rt.SourceDir, be.ParserId+rt.Extension)
+		if err := os.MkdirAll(filepath.Join(tempDir, rt.SourceDir), 0o755); err != nil {
+			return digest, fmt.Errorf("failed to create %s: %w", rt.SourceDir, err)
+		}
+		if err := o.download(ctx, be, sourceKey, filepath.Join(tempDir, source)); err != nil {
+			return digest, fmt.Errorf("failed to download parser source: %w", err)
+		}
+		sum, err := fileSHA256(filepath.Join(tempDir, source))
+		if err != nil {
+			return digest, err
+		}
+		digest = sourceDigest{ETag: info.ETag, SHA256: sum}
+		sources = append(sources, source)
+	}
+	// 🔏 Nothing is built from a source other than the one asked for
+	if err := verifyChecksum(be, digest); err != nil {
+		return digest, err
+	}
+	// 🙈 Bundles leave out what their .lambdaignore lists
+	if sources, err = applyLambdaIgnore(tempDir, rt.SourceDir, sources); err != nil {
+		return digest, err
+	}
+	if tests, err := o.testSource(ctx, be); err != nil {
+		return digest, err
+	} else if tests != nil {
+		testPath := filepath.Join(tempDir, be.ParserId+".test.js")
+		if err := o.download(ctx, be, TestSourceKey(be), testPath); err != nil {
+			return digest, fmt.Errorf("failed to download parser tests: %w", err)
+		}
+		sources = append(sources, be.ParserId+".test.js")
+	}
+	if deps, err := o.depsSource(ctx, be); err != nil {
+		return digest, err
+	} else if deps != nil {
+		if err := o.download(ctx, be, o.depsSourceKey(be), filepath.Join(tempDir, "parser-"+rt.Deps)); err != nil {
+			return digest, fmt.Errorf("failed to download parser dependencies: %w", err)
+		}
+	}
+	if err := o.checkSource(ctx, tempDir, rt, sources...); err != nil {
+		return digest, err
+	}
+
+	// =========================================================================
+	// 📍 STEP 2: RENDER WRAPPER TEMPLATES
+	// =========================================================================
+	data := o.wrapperData(rt, be, t.backend.Name())
+	for _, name := range rt.Templates {
+		content, err := templates.RenderFile(o.templatePath(rt, name), data)
+		if err != nil {
+			return digest, err
+		}
+		if name == rt.Manifest {
+			if content, err = mergeDependencies(content, NpmDependencies(be)); err != nil {
+				return digest, err
+			}
+		}
+		if err := os.WriteFile(filepath.Join(tempDir, templateTarget(name)), content, 0o644); err != nil {
+			return digest, fmt.Errorf("failed to write %s: %w", templateTarget(name), err)
+		}
+	}
+	// 🐳 The parser's own Dockerfile replaces the templated one
+	var dockerfileSource string
+	if dockerfile, err := o.customDockerfile(ctx, be); err != nil {
+		return digest, err
+	} else if dockerfile != nil {
+		if dockerfileSource, err = o.useCustomDockerfile(ctx, be, filepath.Join(tempDir, o.cfg.DefaultDockerfileName)); err != nil {
+			return digest, err
+		}
+		log.Printf("🐳 Building %s/%s with its custom Dockerfile", be.ThirdPartyId, be.ParserId)
+	}
+
+	// =========================================================================
+	// 📍 STEP 3: PACK AND UPLOAD TO S3
+	// =========================================================================
+	if err := checkContext(tempDir, o.packOptions()); err != nil {
+		return digest, err
+	}
+	contextSHA256, err := o.uploadContext(ctx, be, tempDir)
+	if err != nil {
+		return digest, err
+	}
+
+	// =========================================================================
+	// 📍 STEP 4: RECORD INPUTS
+	// =========================================================================
+	if o.cfg.ReproducibleBuilds {
+		return digest, o.recordInputs(ctx, be, tempDir, contextSHA256, dockerfileSource)
+	}
+	return digest, nil
+}
+
+// download fetches one of a build's sources
+// 🎯 WHY: A source larger than BUILD_CONTEXT_MAX_BYTES is refused before it
+// is fetched (its size is checked first), and never written past the limit
+// (one whose size changed meanwhile is cut off), not only once on disk
+func (o *Orchestrator) download(ctx context.Context, be types.BuildEvent, key, dest string) error {
+	sources, bucket, err := o.tenantSources(ctx, be)
+	if err != nil {
+		return err
+	}
+	uri := o.sourceURI(bucket, key)
+	maxBytes := int64(o.cfg.ContextMaxBytes)
+	tooLarge := &ContextRejectedError{Reason: fmt.Sprintf("%s is larger than %d bytes (BUILD_CONTEXT_MAX_BYTES)", uri, maxBytes)}
+	if maxBytes > 0 {
+		info, err := sources.Head(ctx, bucket, key)
+		if err != nil {
+			return err
+		}
+		if info.Size > maxBytes {
+			return tooLarge
+		}
+	}
+	log.Printf("Downloading %s", uri)
+
+	body, err := sources.Get(ctx, bucket, key)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	f, err := os.Create(dest)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dest, err)
+	}
+	defer f.Close()
+
+	var src io.Reader = body
+	if maxBytes > 0 {
+		src = io.LimitReader(body, maxBytes+1)
+	}
+	n, err := io.Copy(f, src)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	if maxBytes > 0 && n > maxBytes {
+		return tooLarge
+	}
+	return nil
+}
+
+// uploadContext packs dir and streams the tarball into the tmp bucket, with
+// no temp file in between; returns the tarball's sha256
+func (o *Orchestrator) uploadContext(ctx context.Context, be types.BuildEvent, dir string) (string, error) {
+	key := ContextKey(be)
+	log.Printf("Uploading build context to s3://%s/%s", o.cfg.S3TmpBucket, key)
+
+	pr, pw := io.Pipe()
+	packed := make(chan error, 1)
+	go func() {
+		err := packContext(pw, dir, o.packOptions())
+		pw.CloseWithError(err)
+		packed <- err
+	}()
+	h := sha256.New()
+	err := o.putContext(ctx, be.ThirdPartyId, key, io.TeeReader(pr, h))
+	// 📝 Unblocks the packer if the upload stopped reading
+	pr.CloseWithError(err)
+	packErr := <-packed
+
+	// 📝 A failed upload fails the packer with its error, and a failed packer the upload
+	switch {
+	case err != nil && (packErr == nil || errors.Is(packErr, err)):
+		return "", fmt.Errorf("failed to upload build context: %w", err)
+	case packErr != nil:
+		return "", fmt.Errorf("failed to create build context tarball: %w", packErr)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
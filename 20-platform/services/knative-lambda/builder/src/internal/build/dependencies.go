@@ -0,0 +1,134 @@
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 📦 DEPENDENCY OVERRIDES
+// =============================================================================
+// A build event may pin extra npm dependencies, name -> version:
+//
+//	"dependencies": {"@notifi/sdk": "2.3.1", "lodash": "^4.17.21"}
+//
+// They are merged into the rendered package.json (overriding the wrapper's
+// own on a name clash), so a parser can pull a specific SDK version without
+// shipping a custom Dockerfile. Only packages matching NPM_DEPENDENCY_ALLOWLIST
+// may be asked for: exact names, or patterns such as @notifi/* (empty = none)
+// 🎯 WHY: Versions are registry versions, ranges or tags; URLs, git, file:
+// and npm: aliases are refused, they would fetch code from anywhere
+// 📝 NOTE: Dependencies change the image, so they are part of the build cache key
+
+// Limits on a build event's dependencies
+const (
+	maxDependencies       = 64
+	maxDependencyNameLen  = 214 // npm's own limit
+	maxDependencyRangeLen = 64
+)
+
+// npmPackageName matches a (possibly scoped) npm package name
+var npmPackageName = regexp.MustCompile(`^(@[a-z0-9][a-z0-9._~-]*/)?[a-z0-9][a-z0-9._~-]*$`)
+
+// npmVersionRange matches versions, ranges (^1.2, >=1 <2, 1.x || 2.x) and dist-tags
+var npmVersionRange = regexp.MustCompile(`^[0-9A-Za-z.^~<>=|*+ -]+$`)
+
+// InvalidDependenciesError is returned for a build whose dependencies can't be used
+type InvalidDependenciesError struct {
+	Name   string // The offending package ("" when the build can't have any)
+	Reason string
+}
+
+func (e *InvalidDependenciesError) Error() string {
+	if e.Name == "" {
+		return "invalid dependencies: " + e.Reason
+	}
+	return fmt.Sprintf("invalid dependency %q: %s", e.Name, e.Reason)
+}
+
+// InvalidRequest marks the error as the requester's fault (API: 400)
+func (e *InvalidDependenciesError) InvalidRequest() bool {
+	return true
+}
+
+// CheckDependencies refuses a build whose dependencies can't be used
+func (o *Orchestrator) CheckDependencies(be types.BuildEvent) error {
+	if len(be.Dependencies) == 0 {
+		return nil
+	}
+	rt := o.runtime(be)
+	if rt.Manifest == "" {
+		return &InvalidDependenciesError{Reason: fmt.Sprintf("the %s runtime doesn't take dependencies", rt.Name)}
+	}
+	if len(be.Dependencies) > maxDependencies {
+		return &InvalidDependenciesError{Reason: fmt.Sprintf("at most %d dependencies", maxDependencies)}
+	}
+	for _, name := range sortedNames(be.Dependencies) {
+		version := be.Dependencies[name]
+		switch {
+		case len(name) > maxDependencyNameLen || !npmPackageName.MatchString(name):
+			return &InvalidDependenciesError{Name: name, Reason: "not an npm package name"}
+		case !o.dependencyAllowed(name):
+			return &InvalidDependenciesError{Name: name, Reason: "not in NPM_DEPENDENCY_ALLOWLIST"}
+		case len(version) > maxDependencyRangeLen || !npmVersionRange.MatchString(strings.TrimSpace(version)):
+			return &InvalidDependenciesError{Name: name, Reason: fmt.Sprintf("version %q must be a version, range or tag", version)}
+		}
+	}
+	return nil
+}
+
+// dependencyAllowed reports whether NPM_DEPENDENCY_ALLOWLIST lets builds ask for a package
+func (o *Orchestrator) dependencyAllowed(name string) bool {
+	for _, pattern := range o.cfg.NpmDependencyAllowlist {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// NpmDependencies returns a build's dependencies sorted by name
+// 🎯 WHY: Same dependencies, same build cache key
+func NpmDependencies(be types.BuildEvent) []types.Dependency {
+	var deps []types.Dependency
+	for _, name := range sortedNames(be.Dependencies) {
+		deps = append(deps, types.Dependency{Name: name, Version: be.Dependencies[name]})
+	}
+	return deps
+}
+
+// mergeDependencies adds a build's dependencies to a rendered package.json
+// 📝 NOTE: The other fields are kept as they are (re-indented, keys sorted)
+func mergeDependencies(manifest []byte, deps []types.Dependency) ([]byte, error) {
+	if len(deps) == 0 {
+		return manifest, nil
+	}
+	var pkg map[string]json.RawMessage
+	if err := json.Unmarshal(manifest, &pkg); err != nil {
+		return nil, fmt.Errorf("failed to parse package.json: %w", err)
+	}
+	dependencies := map[string]string{}
+	if raw, ok := pkg["dependencies"]; ok {
+		if err := json.Unmarshal(raw, &dependencies); err != nil {
+			return nil, fmt.Errorf("failed to parse package.json dependencies: %w", err)
+		}
+	}
+	for _, dep := range deps {
+		dependencies[dep.Name] = dep.Version
+	}
+	raw, err := json.Marshal(dependencies)
+	if err != nil {
+		return nil, err
+	}
+	pkg["dependencies"] = raw
+	merged, err := json.MarshalIndent(pkg, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(merged, '\n'), nil
+}
@@ -0,0 +1,269 @@
+package build
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"knative-lambda-builder/internal/types"
+	"knative-lambda-builder/storage"
+)
+
+// =============================================================================
+// 🐳 CUSTOM DOCKERFILES
+// =============================================================================
+// Parsers needing native modules the default image can't build may ship
+// {parserId}.Dockerfile next to their source. It replaces the templated
+// Dockerfile once it passes the policy:
+//   - every FROM, COPY --from and RUN --mount from= is an allowed base
+//     (DOCKERFILE_ALLOWED_BASES) or an earlier stage, and is pinned by digest
+//     in reproducible mode
+//   - no ADD from URLs (remote files would bypass review and the build cache)
+//   - no syntax or escape parser directive (under BuildKit, "# syntax=" runs
+//     the frontend image it names)
+//   - the final stage has a USER, and it isn't root
+// 📝 NOTE: The rest of the context (index.js, package.json, the parser) is
+// the same as with the templated Dockerfile
+
+// maxDockerfileBytes caps the size of a custom Dockerfile
+const maxDockerfileBytes = 64 << 10
+
+// DockerfileRejectedError is returned when a custom Dockerfile breaks the policy
+type DockerfileRejectedError struct {
+	File   string // e.g. "p1.Dockerfile"
+	Reason string // e.g. "line 1: base image ubuntu:22.04 is not allowed"
+}
+
+func (e *DockerfileRejectedError) Error() string {
+	return fmt.Sprintf("custom Dockerfile %s rejected: %s", e.File, e.Reason)
+}
+
+// DockerfileSourceKey returns the S3 key of a parser's (optional) custom Dockerfile
+func DockerfileSourceKey(be types.BuildEvent) string {
+	return fmt.Sprintf("%s/%s.Dockerfile", be.ThirdPartyId, be.ParserId)
+}
+
+// customDockerfile returns the custom Dockerfile's object info (nil if the
+// parser ships none, or custom Dockerfiles are disabled)
+func (o *Orchestrator) customDockerfile(ctx context.Context, be types.BuildEvent) (*storage.ObjectInfo, error) {
+	if !o.cfg.CustomDockerfilesEnabled {
+		return nil, nil
+	}
+	info, err := o.headSource(ctx, be, DockerfileSourceKey(be))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat custom Dockerfile: %w", err)
+	}
+	return &info, nil
+}
+
+// useCustomDockerfile downloads a parser's custom Dockerfile over the
+// templated one at dest, once it passes the policy; returns where it came
+// from (e.g. s3://sources/acme/p1.Dockerfile)
+func (o *Orchestrator) useCustomDockerfile(ctx context.Context, be types.BuildEvent, dest string) (string, error) {
+	_, bucket, err := o.tenantSources(ctx, be)
+	if err != nil {
+		return "", err
+	}
+	if err := o.download(ctx, be, DockerfileSourceKey(be), dest); err != nil {
+		return "", fmt.Errorf("failed to download custom Dockerfile: %w", err)
+	}
+	content, err := os.ReadFile(dest)
+	if err != nil {
+		return "", fmt.Errorf("failed to read custom Dockerfile: %w", err)
+	}
+	if err := o.checkDockerfile(content); err != nil {
+		return "", &DockerfileRejectedError{File: customDockerfileName(be), Reason: err.Error()}
+	}
+	return o.sourceURI(bucket, DockerfileSourceKey(be)), nil
+}
+
+// customDockerfileName names a parser's custom Dockerfile, e.g. "p1.Dockerfile"
+func customDockerfileName(be types.BuildEvent) string {
+	return be.ParserId + ".Dockerfile"
+}
+
+// dockerInstruction is one (continuation-joined) Dockerfile instruction
+type dockerInstruction struct {
+	Line    int    // Where it starts (1-based)
+	Command string // Upper case, e.g. "FROM"
+	Args    string
+}
+
+// parseDockerfile splits a Dockerfile into instructions, joining continued
+// lines and dropping comments
+func parseDockerfile(content []byte) []dockerInstruction {
+	var instructions []dockerInstruction
+	var current *dockerInstruction
+	for i, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") || (trimmed == "" && current == nil) {
+			continue
+		}
+		continued := strings.HasSuffix(trimmed, "\\")
+		trimmed = strings.TrimSuffix(trimmed, "\\")
+		if current == nil {
+			command, args, _ := strings.Cut(trimmed, " ")
+			current = &dockerInstruction{Line: i + 1, Command: strings.ToUpper(command), Args: strings.TrimSpace(args)}
+		} else {
+			current.Args = strings.TrimSpace(current.Args + " " + trimmed)
+		}
+		if !continued {
+			instructions = append(instructions, *current)
+			current = nil
+		}
+	}
+	if current != nil {
+		instructions = append(instructions, *current)
+	}
+	return instructions
+}
+
+// remoteSource matches ADD sources fetched over the network
+var remoteSource = regexp.MustCompile(`(?i)(^|\s)(https?|git)://|(^|\s)git@`)
+
+// parserDirective matches a Dockerfile parser directive, e.g. "# syntax=docker/dockerfile:1"
+var parserDirective = regexp.MustCompile(`^#\s*([A-Za-z]+)\s*=`)
+
+// parserDirectives returns the (lower case) parser directives at the top of a
+// Dockerfile: they end at the first line that isn't one
+func parserDirectives(content []byte) []string {
+	var directives []string
+	for _, line := range strings.Split(string(content), "\n") {
+		match := parserDirective.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			break
+		}
+		directives = append(directives, strings.ToLower(match[1]))
+	}
+	return directives
+}
+
+// flagValues returns the values of an instruction's --name= flags
+func flagValues(args, name string) []string {
+	var values []string
+	for _, field := range strings.Fields(args) {
+		if value, ok := strings.CutPrefix(field, "--"+name+"="); ok {
+			values = append(values, value)
+		} else if !strings.HasPrefix(field, "--") {
+			break // Flags come first
+		}
+	}
+	return values
+}
+
+// mountSources returns the images and stages a RUN instruction's --mount
+// flags take files from (from=)
+func mountSources(args string) []string {
+	var sources []string
+	for _, mount := range flagValues(args, "mount") {
+		for _, option := range strings.Split(mount, ",") {
+			if key, value, ok := strings.Cut(option, "="); ok && strings.EqualFold(key, "from") {
+				sources = append(sources, value)
+			}
+		}
+	}
+	return sources
+}
+
+// checkDockerfile applies the custom Dockerfile policy
+func (o *Orchestrator) checkDockerfile(content []byte) error {
+	if len(content) > maxDockerfileBytes {
+		return fmt.Errorf("larger than %d bytes", maxDockerfileBytes)
+	}
+	for _, directive := range parserDirectives(content) {
+		if directive == "syntax" || directive == "escape" {
+			return fmt.Errorf("the %s parser directive is not allowed", directive)
+		}
+	}
+	stages := map[string]bool{}
+	user := ""
+	froms := 0
+	for _, in := range parseDockerfile(content) {
+		switch in.Command {
+		case "FROM":
+			froms++
+			user = "" // Each stage starts as its base image's user
+			fields := strings.Fields(in.Args)
+			var image string
+			for _, field := range fields {
+				if !strings.HasPrefix(field, "--") { // Skip --platform=...
+					image = field
+					break
+				}
+			}
+			if err := o.checkBaseImage(image, stages); err != nil {
+				return fmt.Errorf("line %d: %w", in.Line, err)
+			}
+			if len(fields) >= 2 && strings.EqualFold(fields[len(fields)-2], "AS") {
+				stages[strings.ToLower(fields[len(fields)-1])] = true
+			}
+			stages[strconv.Itoa(froms-1)] = true // Stages can be named by index
+		case "COPY":
+			for _, from := range flagValues(in.Args, "from") {
+				if err := o.checkBaseImage(from, stages); err != nil {
+					return fmt.Errorf("line %d: COPY --from: %w", in.Line, err)
+				}
+			}
+		case "RUN":
+			for _, from := range mountSources(in.Args) {
+				if err := o.checkBaseImage(from, stages); err != nil {
+					return fmt.Errorf("line %d: RUN --mount from: %w", in.Line, err)
+				}
+			}
+		case "ADD":
+			if remoteSource.MatchString(in.Args) {
+				return fmt.Errorf("line %d: ADD from a URL is not allowed, COPY files from the build context instead", in.Line)
+			}
+		case "USER":
+			user = strings.TrimSpace(in.Args)
+		}
+	}
+	if froms == 0 {
+		return errors.New("no FROM instruction")
+	}
+	name, _, _ := strings.Cut(user, ":")
+	switch {
+	case name == "":
+		return errors.New("the final stage has no USER, it would run as its base image's user (often root)")
+	case strings.Contains(name, "$"):
+		return fmt.Errorf("the final stage's USER %s uses a build arg, it can't be checked", name)
+	case name == "root" || name == "0":
+		return errors.New("the final stage runs as root")
+	}
+	return nil
+}
+
+// checkBaseImage checks that an image (FROM, COPY --from, RUN --mount from=)
+// is an allowed base or an earlier stage
+func (o *Orchestrator) checkBaseImage(image string, stages map[string]bool) error {
+	if image == "" {
+		return errors.New("no image")
+	}
+	if stages[strings.ToLower(image)] {
+		return nil
+	}
+	if strings.Contains(image, "$") {
+		return fmt.Errorf("base image %s uses a build arg, it can't be checked", image)
+	}
+	allowed := false
+	for _, base := range o.cfg.DockerfileAllowedBases {
+		if image == base || strings.HasPrefix(image, base+":") || strings.HasPrefix(image, base+"@") {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("base image %s is not allowed (allowed: %s)", image, strings.Join(o.cfg.DockerfileAllowedBases, ", "))
+	}
+	if o.cfg.ReproducibleBuilds && !isPinned(image) {
+		return fmt.Errorf("reproducible builds require base image %s pinned by digest (image@sha256:...)", image)
+	}
+	return nil
+}
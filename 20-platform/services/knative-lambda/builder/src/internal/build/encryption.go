@@ -0,0 +1,183 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"knative-lambda-builder/internal/types"
+	"knative-lambda-builder/storage"
+)
+
+// =============================================================================
+// 🔐 ARTIFACT ENCRYPTION
+// =============================================================================
+// Tenants with a KMS key in the tenant registry get their build artifacts
+// encrypted with it:
+//   - the build context tarball with S3 SSE-KMS (Kaniko reads it from S3
+//     directly, so its role needs kms:Decrypt on the key)
+//   - the cache entry, inputs record and image revisions with envelope
+//     encryption, since the builder reads them back itself
+//
+// Contexts of other tenants use CONTEXT_KMS_KEY_ARN when set. With
+// CONTEXT_REQUIRE_ENCRYPTION, contexts the builder reads back (shared
+// artifacts) must be KMS encrypted, with the key they were uploaded with
+
+// Encryptor seals values per tenant (implemented by encryption.TenantKeys)
+type Encryptor interface {
+	KeyID(ctx context.Context, thirdPartyId string) (string, error)
+	Seal(ctx context.Context, thirdPartyId string, plaintext []byte) ([]byte, error)
+	Open(ctx context.Context, data []byte) ([]byte, error)
+}
+
+// noEncryption stores everything in plaintext (no tenant registry configured)
+type noEncryption struct{}
+
+func (noEncryption) KeyID(context.Context, string) (string, error) { return "", nil }
+func (noEncryption) Seal(_ context.Context, _ string, plaintext []byte) ([]byte, error) {
+	return plaintext, nil
+}
+func (noEncryption) Open(_ context.Context, data []byte) ([]byte, error) { return data, nil }
+
+// WithEncryptor encrypts the artifacts of tenants that have a KMS key
+func (o *Orchestrator) WithEncryptor(e Encryptor) *Orchestrator {
+	o.encryptor = e
+	return o
+}
+
+// putSealed seals body with the tenant's key and uploads it to the tmp bucket
+func (o *Orchestrator) putSealed(ctx context.Context, thirdPartyId, key string, body []byte) error {
+	sealed, err := o.encryptor.Seal(ctx, thirdPartyId, body)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt s3://%s/%s: %w", o.cfg.S3TmpBucket, key, err)
+	}
+	return o.store.Put(ctx, o.cfg.S3TmpBucket, key, bytes.NewReader(sealed))
+}
+
+// getSealed downloads an object of the tmp bucket and opens it
+func (o *Orchestrator) getSealed(ctx context.Context, key string) ([]byte, error) {
+	body, err := o.store.Get(ctx, o.cfg.S3TmpBucket, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read s3://%s/%s: %w", o.cfg.S3TmpBucket, key, err)
+	}
+	plaintext, err := o.encryptor.Open(ctx, raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt s3://%s/%s: %w", o.cfg.S3TmpBucket, key, err)
+	}
+	return plaintext, nil
+}
+
+// contextKMSKey returns the KMS key a tenant's contexts are encrypted with:
+// its own, else CONTEXT_KMS_KEY_ARN ("" = the bucket default)
+func (o *Orchestrator) contextKMSKey(ctx context.Context, thirdPartyId string) (string, error) {
+	keyID, err := o.encryptor.KeyID(ctx, thirdPartyId)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up the KMS key of %s: %w", thirdPartyId, err)
+	}
+	if keyID == "" {
+		keyID = o.cfg.ContextKMSKeyARN
+	}
+	return keyID, nil
+}
+
+// putContext uploads a build context, SSE-KMS encrypted when there is a key
+func (o *Orchestrator) putContext(ctx context.Context, thirdPartyId, key string, body io.Reader) error {
+	keyID, err := o.contextKMSKey(ctx, thirdPartyId)
+	if err != nil {
+		return err
+	}
+	if keyID == "" {
+		return o.store.Put(ctx, o.cfg.S3TmpBucket, key, body)
+	}
+	return o.store.PutEncrypted(ctx, o.cfg.S3TmpBucket, key, body, keyID)
+}
+
+// openContext opens a build's context, checking its encryption first
+// 📝 NOTE: Without CONTEXT_REQUIRE_ENCRYPTION, only contexts uploaded with a
+// key are checked
+func (o *Orchestrator) openContext(ctx context.Context, be types.BuildEvent) (io.ReadCloser, error) {
+	key := ContextKey(be)
+	keyID, err := o.contextKMSKey(ctx, be.ThirdPartyId)
+	if err != nil {
+		return nil, err
+	}
+	if keyID != "" || o.cfg.ContextRequireEncryption {
+		info, err := o.store.Head(ctx, o.cfg.S3TmpBucket, key)
+		if err != nil {
+			return nil, err
+		}
+		if !kmsKeyMatches(keyID, info.KMSKeyID) {
+			return nil, fmt.Errorf("build context s3://%s/%s is not encrypted with the expected KMS key (want %q, got %q)",
+				o.cfg.S3TmpBucket, key, keyID, info.KMSKeyID)
+		}
+	}
+	// SSE-KMS contexts are decrypted by S3
+	return o.store.Get(ctx, o.cfg.S3TmpBucket, key)
+}
+
+// kmsKeyMatches reports whether an object encrypted with key got (as S3
+// reports it: a key ARN) is encrypted with want (a key ID or ARN, or an alias,
+// which can't be told apart from other keys: any KMS key matches it)
+func kmsKeyMatches(want, got string) bool {
+	switch {
+	case got == "":
+		return false
+	case want == "" || strings.Contains(want, "alias/"):
+		return true
+	}
+	return got == want || strings.HasSuffix(got, ":key/"+want)
+}
+
+// ReencryptArtifacts encrypts a parser's stored artifacts again with its tenant's current key
+// 🎯 PURPOSE: Key rotation (or a key added to an existing tenant)
+// Returns the number of objects rewritten; missing artifacts are skipped
+func (o *Orchestrator) ReencryptArtifacts(ctx context.Context, be types.BuildEvent) (int, error) {
+	rewritten := 0
+
+	for _, key := range []string{CacheKey(be), InputsKey(be), RevisionsKey(be)} {
+		plaintext, err := o.getSealed(ctx, key)
+		if errors.Is(err, storage.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return rewritten, err
+		}
+		if err := o.putSealed(ctx, be.ThirdPartyId, key, plaintext); err != nil {
+			return rewritten, err
+		}
+		rewritten++
+	}
+
+	// 📝 NOTE: SSE-KMS objects are decrypted transparently by S3; uploading the
+	// context again encrypts it with the current key
+	key := ContextKey(be)
+	body, err := o.store.Get(ctx, o.cfg.S3TmpBucket, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return rewritten, nil
+	}
+	if err != nil {
+		return rewritten, err
+	}
+	content, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return rewritten, fmt.Errorf("failed to read s3://%s/%s: %w", o.cfg.S3TmpBucket, key, err)
+	}
+	if err := o.putContext(ctx, be.ThirdPartyId, key, bytes.NewReader(content)); err != nil {
+		return rewritten, err
+	}
+	rewritten++
+
+	log.Printf("🔐 Re-encrypted %d artifact(s) of %s/%s", rewritten, be.ThirdPartyId, be.ParserId)
+	return rewritten, nil
+}
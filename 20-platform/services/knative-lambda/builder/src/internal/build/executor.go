@@ -0,0 +1,191 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"knative-lambda-builder/execution"
+	"knative-lambda-builder/internal/k8s"
+)
+
+// =============================================================================
+// ⚙️ BUILD EXECUTION
+// =============================================================================
+// The executor launches the rendered build job objects
+// 🎯 PURPOSE: Keep the orchestrator independent of the cluster (and easy to fake)
+
+// Labels set on every build and test job (mirror tenants.LabelThirdPartyId/LabelParserId)
+const (
+	thirdPartyIdLabel = "knative-lambda.notifi.network/third-party-id"
+	parserIdLabel     = "knative-lambda.notifi.network/parser-id"
+)
+
+// KubernetesExecutor launches build objects by creating them in the cluster
+type KubernetesExecutor struct {
+	client *k8s.Client
+}
+
+// NewKubernetesExecutor creates an executor backed by the Kubernetes API
+func NewKubernetesExecutor(client *k8s.Client) *KubernetesExecutor {
+	return &KubernetesExecutor{client: client}
+}
+
+// Launch implements Executor
+func (e *KubernetesExecutor) Launch(ctx context.Context, obj *unstructured.Unstructured) error {
+	_, err := e.client.Create(ctx, obj)
+	return err
+}
+
+// Disruption implements Executor by inspecting the job's pods
+// 📝 NOTE: Preempted pods are deleted, so this only finds pods still terminating
+// or evicted in place; callers fall back to the Job's failure condition
+func (e *KubernetesExecutor) Disruption(ctx context.Context, namespace, jobName string) (string, error) {
+	pods, err := e.client.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "job-name=" + jobName,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods of job %s: %w", jobName, err)
+	}
+	for _, pod := range pods.Items {
+		for _, c := range pod.Status.Conditions {
+			if c.Type == corev1.DisruptionTarget && c.Status == corev1.ConditionTrue {
+				return c.Reason, nil // PreemptionByScheduler, EvictionByEvictionAPI, ...
+			}
+		}
+		if pod.Status.Reason == "Evicted" || pod.Status.Reason == "Preempting" {
+			return pod.Status.Reason, nil
+		}
+	}
+	return "", nil
+}
+
+// RunningBuilds implements Executor by listing the namespace's jobs
+// 📝 NOTE: Build jobs carry the parser-id label (job.yaml.tpl); a job still
+// has active pods, or no terminal condition yet, until it finishes
+func (e *KubernetesExecutor) RunningBuilds(ctx context.Context, namespace string) (int, error) {
+	jobs, err := e.client.Clientset.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: parserIdLabel,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list build jobs: %w", err)
+	}
+	running := 0
+	for _, job := range jobs.Items {
+		if IsTestJob(job.Name) || IsSBOMJob(job.Name) {
+			continue
+		}
+		finished := false
+		for _, c := range job.Status.Conditions {
+			if (c.Type == batchv1.JobComplete || c.Type == batchv1.JobFailed) && c.Status == corev1.ConditionTrue {
+				finished = true
+			}
+		}
+		if !finished {
+			running++
+		}
+	}
+	return running, nil
+}
+
+// Jobs implements Executor by listing the namespace's jobs
+func (e *KubernetesExecutor) Jobs(ctx context.Context, namespace string) ([]execution.BuildJob, error) {
+	jobs, err := e.client.Clientset.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: parserIdLabel,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list build jobs: %w", err)
+	}
+	var builds []execution.BuildJob
+	for _, job := range jobs.Items {
+		build := execution.BuildJob{
+			Name:         job.Name,
+			ThirdPartyId: job.Labels[thirdPartyIdLabel],
+			ParserId:     job.Labels[parserIdLabel],
+			Test:         IsTestJob(job.Name),
+			SBOM:         IsSBOMJob(job.Name),
+			CreatedAt:    job.CreationTimestamp.Time,
+		}
+		for _, c := range job.Status.Conditions {
+			if (c.Type == batchv1.JobComplete || c.Type == batchv1.JobFailed) && c.Status == corev1.ConditionTrue {
+				build.Finished = true
+				build.FinishedAt = c.LastTransitionTime.Time
+			}
+		}
+		builds = append(builds, build)
+	}
+	return builds, nil
+}
+
+// DeleteJob implements Executor
+// 📝 NOTE: Background propagation deletes the job's pods too
+func (e *KubernetesExecutor) DeleteJob(ctx context.Context, namespace, jobName string) error {
+	propagation := metav1.DeletePropagationBackground
+	return e.client.Clientset.BatchV1().Jobs(namespace).Delete(ctx, jobName, metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	})
+}
+
+// Logs implements Executor by reading the logs of the job's newest pod
+func (e *KubernetesExecutor) Logs(ctx context.Context, namespace, jobName string, tailLines int64) (string, error) {
+	pods, err := e.client.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "job-name=" + jobName,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods of job %s: %w", jobName, err)
+	}
+	if len(pods.Items) == 0 {
+		return "", fmt.Errorf("job %s has no pods", jobName)
+	}
+	newest := pods.Items[0]
+	for _, pod := range pods.Items[1:] {
+		if pod.CreationTimestamp.After(newest.CreationTimestamp.Time) {
+			newest = pod
+		}
+	}
+
+	stream, err := e.client.Clientset.CoreV1().Pods(namespace).GetLogs(newest.Name, &corev1.PodLogOptions{
+		TailLines: &tailLines,
+	}).Stream(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get logs of pod %s: %w", newest.Name, err)
+	}
+	defer stream.Close()
+
+	raw, err := io.ReadAll(stream)
+	if err != nil {
+		return "", fmt.Errorf("failed to read logs of pod %s: %w", newest.Name, err)
+	}
+	return string(raw), nil
+}
+
+// ApplySecret implements Executor: updates the Secret, or creates it if it's missing
+func (e *KubernetesExecutor) ApplySecret(ctx context.Context, namespace, name string, data map[string][]byte) error {
+	secrets := e.client.Clientset.CoreV1().Secrets(namespace)
+	secret, err := secrets.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = secrets.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       data,
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to create secret %s: %w", name, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get secret %s: %w", name, err)
+	}
+	secret.Data = data
+	if _, err := secrets.Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update secret %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package build
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"knative-lambda-builder/internal/observability"
+)
+
+// =============================================================================
+// 🗑️ GARBAGE COLLECTION
+// =============================================================================
+// What builds leave behind is normally removed as they go: jobs by their
+// ttlSecondsAfterFinished, temp dirs once the context is uploaded, contexts
+// once the image is confirmed (CleanupContext). A periodic pass catches
+// what slipped through:
+//   - finished build/test jobs older than BuildGCJobAge (TTL controller off,
+//     templates without a TTL)
+//   - context temp dirs left by a builder that crashed mid-build
+//   - contexts of builds that never pushed an image, older than
+//     BuildGCContextAge (or the tenant's retention, if longer)
+//
+// 📝 NOTE: Jobs and contexts are collected by the leader only (see the leader
+// package); temp dirs are pod-local, so every replica collects its own
+
+// tempPrefix starts the names of context temp dirs and tarballs
+const tempPrefix = "build-"
+
+// tempMaxAge is how old a context temp dir must be to be considered abandoned
+// 🎯 WHY: Packaging a context takes seconds; an hour-old one is left over
+const tempMaxAge = time.Hour
+
+// contextPrefix is where build contexts live in the tmp bucket (see ContextKey)
+const contextPrefix = "builds/"
+
+// GCReport counts what a garbage collection pass deleted
+type GCReport struct {
+	Jobs     int
+	Contexts int
+}
+
+// StartGarbageCollector collects old jobs and orphaned contexts every
+// BuildGCInterval until ctx is done
+func (o *Orchestrator) StartGarbageCollector(ctx context.Context) {
+	o.everyGCInterval(ctx, func(now time.Time) {
+		if report := o.CollectGarbage(ctx, now); report.Jobs+report.Contexts > 0 {
+			log.Printf("🗑️ Garbage collection: deleted %d jobs, %d contexts", report.Jobs, report.Contexts)
+		}
+	})
+}
+
+// StartTempDirCollector removes this replica's abandoned temp dirs every
+// BuildGCInterval until ctx is done
+func (o *Orchestrator) StartTempDirCollector(ctx context.Context) {
+	o.everyGCInterval(ctx, func(now time.Time) {
+		if removed := o.CollectTempDirs(now); removed > 0 {
+			log.Printf("🗑️ Garbage collection: removed %d temp dirs", removed)
+		}
+	})
+}
+
+// everyGCInterval calls collect every BuildGCInterval until ctx is done
+func (o *Orchestrator) everyGCInterval(ctx context.Context, collect func(now time.Time)) {
+	if o.cfg.BuildGCInterval <= 0 {
+		log.Printf("Build garbage collection disabled (interval %s)", o.cfg.BuildGCInterval)
+		return
+	}
+
+	ticker := time.NewTicker(o.cfg.BuildGCInterval)
+	defer ticker.Stop()
+	for {
+		collect(time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CollectGarbage deletes old finished jobs and orphaned contexts, logging
+// (not failing) on error
+func (o *Orchestrator) CollectGarbage(ctx context.Context, now time.Time) GCReport {
+	var report GCReport
+	jobs, err := o.executor.Jobs(ctx, o.cfg.KubernetesNamespace)
+	if err != nil {
+		log.Printf("ERROR: Failed to list jobs for garbage collection: %v", err)
+		return report
+	}
+
+	unfinished := map[string]bool{} // thirdPartyId/parserId with a job still running
+	for _, job := range jobs {
+		if !job.Finished {
+			unfinished[job.ThirdPartyId+"/"+job.ParserId] = true
+			continue
+		}
+		if o.cfg.BuildGCJobAge <= 0 || now.Sub(job.FinishedAt) < o.cfg.BuildGCJobAge {
+			continue
+		}
+		err := o.executor.DeleteJob(ctx, o.cfg.KubernetesNamespace, job.Name)
+		if err != nil && !apierrors.IsNotFound(err) {
+			log.Printf("WARNING: Failed to delete finished job %s: %v", job.Name, err)
+			continue
+		}
+		report.Jobs++
+		observability.GarbageCollected.WithLabelValues("job").Inc()
+	}
+
+	report.Contexts = o.collectContexts(ctx, now, unfinished)
+	return report
+}
+
+// CollectTempDirs removes context temp dirs (and tarballs) older than tempMaxAge
+func (o *Orchestrator) CollectTempDirs(now time.Time) int {
+	entries, err := os.ReadDir(os.TempDir())
+	if err != nil {
+		log.Printf("WARNING: Failed to read %s: %v", os.TempDir(), err)
+		return 0
+	}
+	removed := 0
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), tempPrefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || now.Sub(info.ModTime()) < tempMaxAge {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(os.TempDir(), entry.Name())); err != nil {
+			log.Printf("WARNING: Failed to remove %s: %v", entry.Name(), err)
+			continue
+		}
+		removed++
+		observability.GarbageCollected.WithLabelValues("tempdir").Inc()
+	}
+	return removed
+}
+
+// collectContexts deletes the contexts no build will read anymore
+// 📝 NOTE: Skipped when context cleanup is disabled; contexts of parsers with
+// a running job are kept whatever their age
+func (o *Orchestrator) collectContexts(ctx context.Context, now time.Time, unfinished map[string]bool) int {
+	if !o.cfg.ContextCleanupEnabled || o.cfg.BuildGCContextAge <= 0 {
+		return 0
+	}
+	objects, err := o.store.List(ctx, o.cfg.S3TmpBucket, contextPrefix)
+	if err != nil {
+		log.Printf("ERROR: Failed to list build contexts for garbage collection: %v", err)
+		return 0
+	}
+
+	deleted := 0
+	for _, object := range objects {
+		parser, ok := strings.CutSuffix(strings.TrimPrefix(object.Key, contextPrefix), ".tar.gz")
+		thirdPartyId, _, found := strings.Cut(parser, "/")
+		if !ok || !found || unfinished[parser] {
+			continue
+		}
+
+		maxAge := o.cfg.BuildGCContextAge
+		if retention, err := o.retention.ContextRetention(ctx, thirdPartyId); err != nil {
+			log.Printf("WARNING: Failed to resolve the context retention of %s, keeping its contexts: %v", thirdPartyId, err)
+			continue
+		} else if retention > maxAge {
+			maxAge = retention
+		}
+		if now.Sub(object.LastModified) < maxAge {
+			continue
+		}
+
+		if err := o.store.Delete(ctx, o.cfg.S3TmpBucket, object.Key); err != nil {
+			log.Printf("WARNING: Failed to delete orphaned context s3://%s/%s: %v", o.cfg.S3TmpBucket, object.Key, err)
+			continue
+		}
+		log.Printf("🗑️ Orphaned build context s3://%s/%s deleted (%d bytes)", o.cfg.S3TmpBucket, object.Key, object.Size)
+		deleted++
+		observability.GarbageCollected.WithLabelValues("context").Inc()
+	}
+	return deleted
+}
@@ -0,0 +1,360 @@
+package build
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"knative-lambda-builder/internal/config"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🌿 GIT SOURCES
+// =============================================================================
+// A build may take its parser from a git repository instead of the source bucket:
+//
+//	"source": {"git": {"url": "https://github.com/acme/parsers", "ref": "main", "path": "invoice"}}
+//
+// The ref is resolved to a commit (git ls-remote) when the build is accepted,
+// so the idempotency key, the build cache, the image revision and the image
+// labels all name the exact commit built. The repository is then fetched at
+// that commit (shallow), and the files under path go where the runtime
+// expects the parser: path must hold {parserId}.js (.py, .go), or for Go
+// parsers any .go files.
+// 📝 NOTE: Parser tests, dependency files and custom Dockerfiles still come
+// from the source bucket
+// 🔐 GIT_TOKEN (from a Secret) authenticates to private repositories as HTTP
+// basic auth, passed to git through its environment: never on a command line,
+// in a URL or in the logs. It is only sent to GIT_ALLOWED_HOSTS, which are
+// also the only hosts repositories may come from (when set)
+
+// gitTimeout bounds a single git command
+const gitTimeout = 2 * time.Minute
+
+// commitSHA matches a full commit SHA
+var commitSHA = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// Image labels of builds from git
+const (
+	LabelSource   = "org.opencontainers.image.source"
+	LabelRevision = "org.opencontainers.image.revision"
+)
+
+// InvalidGitSourceError is returned for a git source that can't be built
+type InvalidGitSourceError struct {
+	Reason string
+}
+
+func (e *InvalidGitSourceError) Error() string {
+	return "invalid git source: " + e.Reason
+}
+
+// InvalidRequest marks the error as the requester's (API: 400)
+func (e *InvalidGitSourceError) InvalidRequest() bool { return true }
+
+// gitSource returns a build's git source (nil when it builds from the source bucket)
+func gitSource(be types.BuildEvent) *types.GitSource {
+	if be.Source == nil {
+		return nil
+	}
+	return be.Source.Git
+}
+
+// CheckGitSource validates a build's git source, if it has one
+// Returns an *InvalidGitSourceError naming what's wrong
+func (o *Orchestrator) CheckGitSource(be types.BuildEvent) error {
+	git := gitSource(be)
+	if git == nil {
+		return nil
+	}
+	u, err := url.Parse(git.URL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return &InvalidGitSourceError{Reason: "url must be an https:// repository URL"}
+	}
+	if u.User != nil {
+		return &InvalidGitSourceError{Reason: "url must not carry credentials (GIT_TOKEN authenticates)"}
+	}
+	if len(o.cfg.GitAllowedHosts) > 0 && !o.gitHostAllowed(u.Hostname()) {
+		return &InvalidGitSourceError{Reason: fmt.Sprintf("host %s is not allowed (see %s)", u.Hostname(), config.EnvGitAllowedHosts)}
+	}
+	if strings.HasPrefix(git.Ref, "-") || strings.ContainsAny(git.Ref, " \t\n:~^?*[\\") {
+		return &InvalidGitSourceError{Reason: fmt.Sprintf("ref %q is not a branch, tag or commit", git.Ref)}
+	}
+	if git.Path != "" && (path.IsAbs(git.Path) || path.Clean(git.Path) == ".." || strings.HasPrefix(path.Clean(git.Path), "../")) {
+		return &InvalidGitSourceError{Reason: fmt.Sprintf("path %q is outside the repository", git.Path)}
+	}
+	if git.Commit != "" && !commitSHA.MatchString(git.Commit) {
+		return &InvalidGitSourceError{Reason: fmt.Sprintf("commit %q is not a full commit SHA", git.Commit)}
+	}
+	return nil
+}
+
+// ResolveSource pins a build's git source to the commit its ref points at
+// (no-op for builds from the source bucket, or already resolved)
+func (o *Orchestrator) ResolveSource(ctx context.Context, be types.BuildEvent) (types.BuildEvent, error) {
+	git := gitSource(be)
+	if git == nil || git.Commit != "" {
+		return be, nil
+	}
+	if err := o.CheckGitSource(be); err != nil {
+		return be, err
+	}
+	commit, err := o.resolveRef(ctx, git.URL, git.Ref)
+	if err != nil {
+		return be, err
+	}
+	resolved := *git
+	resolved.Commit = commit
+	be.Source = &types.BuildSource{Git: &resolved}
+	log.Printf("🌿 %s/%s builds %s at %s (%s)", be.ThirdPartyId, be.ParserId, resolved.URL, commit, refName(resolved.Ref))
+	return be, nil
+}
+
+// gitHostAllowed reports whether host is on GIT_ALLOWED_HOSTS
+// (".example.com" allows its subdomains)
+func (o *Orchestrator) gitHostAllowed(host string) bool {
+	host = strings.ToLower(host)
+	for _, allowed := range o.cfg.GitAllowedHosts {
+		allowed = strings.ToLower(allowed)
+		if host == allowed || (strings.HasPrefix(allowed, ".") && strings.HasSuffix(host, allowed)) {
+			return true
+		}
+	}
+	return false
+}
+
+// refName names a ref in logs and errors
+func refName(ref string) string {
+	if ref == "" {
+		return "HEAD"
+	}
+	return ref
+}
+
+// resolveRef returns the commit a ref points at in a remote repository
+// 📝 NOTE: A commit SHA is taken as is; an annotated tag resolves to its commit
+func (o *Orchestrator) resolveRef(ctx context.Context, repository, ref string) (string, error) {
+	if commitSHA.MatchString(ref) {
+		return ref, nil
+	}
+	output, err := o.git(ctx, "", repository, "ls-remote", "--", repository, refName(ref))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s of %s: %w", refName(ref), repository, err)
+	}
+
+	// Prefer exact refs, then peeled tags, then branches
+	found := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		if sha, name, ok := strings.Cut(scanner.Text(), "\t"); ok {
+			found[name] = sha
+		}
+	}
+	for _, name := range []string{refName(ref), "refs/tags/" + ref + "^{}", "refs/heads/" + ref, "refs/tags/" + ref} {
+		if sha, ok := found[name]; ok {
+			return sha, nil
+		}
+	}
+	return "", &InvalidGitSourceError{Reason: fmt.Sprintf("%s has no branch or tag %s", repository, refName(ref))}
+}
+
+// checkoutGit fetches a build's git source at its commit and copies the
+// files under its path into dest
+// Returns the copied files with the runtime's extension, relative to dest
+func (o *Orchestrator) checkoutGit(ctx context.Context, be types.BuildEvent, dest string) ([]string, error) {
+	git := gitSource(be)
+	repoDir, err := os.MkdirTemp("", fmt.Sprintf("%sgit-%s-%s-", tempPrefix, be.ThirdPartyId, be.ParserId))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer logCleanup(repoDir)
+
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"fetch", "--quiet", "--depth=1", "--", git.URL, git.Commit},
+		{"checkout", "--quiet", "FETCH_HEAD"},
+	} {
+		if _, err := o.git(ctx, repoDir, git.URL, args...); err != nil {
+			return nil, fmt.Errorf("failed to fetch %s at %s: %w", git.URL, git.Commit, err)
+		}
+	}
+
+	rt := o.runtime(be)
+	src := filepath.Join(repoDir, filepath.FromSlash(path.Clean("/"+git.Path)))
+	files, err := copyTree(src, dest, rt.Extension)
+	if err != nil {
+		return nil, &SourceInvalidError{File: git.URL + "/" + git.Path, Diagnostics: err.Error()}
+	}
+	parser := be.ParserId + rt.Extension
+	if !rt.Archive && !slices.Contains(files, parser) {
+		return nil, &SourceInvalidError{File: git.URL + "/" + git.Path, Diagnostics: "no " + parser}
+	}
+	if len(files) == 0 {
+		return nil, &SourceInvalidError{File: git.URL + "/" + git.Path, Diagnostics: "no " + rt.Extension + " files"}
+	}
+	return files, nil
+}
+
+// copyTree copies the regular files and directories under src into dst,
+// without .git; returns the files ending in ext, relative to dst
+// 🎯 WHY: Symbolic links are refused, they could pull builder files into the image
+func copyTree(src, dst, ext string) ([]string, error) {
+	var files []string
+	var total int64
+	err := filepath.WalkDir(src, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && file == src {
+				return errors.New("no such directory in the repository")
+			}
+			return err
+		}
+		rel, _ := filepath.Rel(src, file)
+		target := filepath.Join(dst, rel)
+		switch {
+		case entry.IsDir() && entry.Name() == ".git":
+			return filepath.SkipDir
+		case entry.IsDir():
+			return os.MkdirAll(target, 0o755)
+		case !entry.Type().IsRegular():
+			return fmt.Errorf("%s is not a regular file or directory", filepath.ToSlash(rel))
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if total += info.Size(); total > maxModuleBytes {
+			return fmt.Errorf("larger than %d bytes", maxModuleBytes)
+		}
+		in, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		if err := writeModuleFile(in, target); err != nil {
+			return err
+		}
+		if strings.HasSuffix(rel, ext) {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// git runs a git command on a repository in dir ("" = the working directory)
+// 📝 NOTE: GIT_TOKEN is configured as an HTTP header through git's
+// environment config, and never prompted for
+func (o *Orchestrator) git(ctx context.Context, dir, repository string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, o.cfg.GitBinary, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if prefix := o.tokenURL(repository); prefix != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + o.cfg.GitToken))
+		cmd.Env = append(cmd.Env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http."+prefix+".extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+credentials,
+		)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if len(message) > maxDiagnosticsBytes {
+			message = message[len(message)-maxDiagnosticsBytes:]
+		}
+		return nil, fmt.Errorf("git %s: %w: %s", args[0], err, message)
+	}
+	return output, nil
+}
+
+// tokenURL returns the URL prefix GIT_TOKEN is sent to when git talks to a
+// repository: its scheme and host, if the host is on GIT_ALLOWED_HOSTS ("" =
+// no token)
+// 🎯 WHY: Scoped to the host (http.<url>.extraHeader), the token isn't sent
+// to any other host, e.g. one redirected to
+func (o *Orchestrator) tokenURL(repository string) string {
+	if o.cfg.GitToken == "" {
+		return ""
+	}
+	u, err := url.Parse(repository)
+	if err != nil || u.Host == "" || !o.gitHostAllowed(u.Hostname()) {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host + "/"
+}
+
+// gitVersion identifies the parser source of a git build, like an ETag does
+// for the source bucket
+func gitVersion(git *types.GitSource) string {
+	return fmt.Sprintf("git:%s@%s:%s", git.URL, git.Commit, path.Clean("/"+git.Path))
+}
+
+// sourceVersion identifies a build's parser source: its git commit and path,
+// the hash of its inline content, or its ETag in the source bucket
+func (o *Orchestrator) sourceVersion(ctx context.Context, be types.BuildEvent) (string, error) {
+	if inlineSource(be) != nil {
+		content, err := o.inlineContent(be)
+		if err != nil {
+			return "", err
+		}
+		return inlineVersion(content), nil
+	}
+	if gitSource(be) != nil {
+		resolved, err := o.ResolveSource(ctx, be)
+		if err != nil {
+			return "", err
+		}
+		return gitVersion(gitSource(resolved)), nil
+	}
+	_, source, err := o.parserSource(ctx, be)
+	if err != nil {
+		return "", err
+	}
+	return source.ETag, nil
+}
+
+// imageLabels returns the labels of a build's image, sorted by name: its
+// repository and commit for builds from git, its parser source's checksums
+// otherwise (see integrity.go)
+func imageLabels(be types.BuildEvent) []types.ImageLabel {
+	git := gitSource(be)
+	if git == nil {
+		return sourceLabels(be)
+	}
+	if git.Commit == "" {
+		return nil
+	}
+	return []types.ImageLabel{
+		{Name: LabelRevision, Value: git.Commit},
+		{Name: LabelSource, Value: git.URL},
+	}
+}
+
+// sourceCommit returns the commit a build's parser comes from ("" when it's
+// not from git)
+func sourceCommit(be types.BuildEvent) string {
+	if git := gitSource(be); git != nil {
+		return git.Commit
+	}
+	return ""
+}
@@ -0,0 +1,187 @@
+package build
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"knative-lambda-builder/internal/config"
+	"knative-lambda-builder/internal/templates"
+	"knative-lambda-builder/internal/types"
+	"knative-lambda-builder/storage"
+)
+
+// =============================================================================
+// 🐹 GO PARSERS
+// =============================================================================
+// A Go parser is package parser, exposing
+//
+//	func Handle(ctx context.Context, data []byte) (any, error)
+//
+// shipped as a single {parserId}.go, or as a module tarball {parserId}.tar.gz
+// (the package's source tree, sub-packages imported as lambda/parser/...).
+// It is unpacked under parser/ next to the main.go wrapper; `go mod tidy`
+// resolves its imports when the image is built.
+// 📝 NOTE: The tarball's go.mod/go.sum are ignored: the wrapper's module is
+// the only one
+
+func init() {
+	RegisterRuntime(Runtime{
+		Name:        RuntimeGo,
+		Extension:   ".go",
+		TemplateDir: templates.RuntimesDir + "/" + RuntimeGo,
+		Templates:   []string{"Dockerfile.tpl", "main.go.tpl", "go.mod.tpl"},
+		Images: func(cfg *config.Config) []RuntimeImage {
+			return []RuntimeImage{
+				{Setting: config.EnvGoBaseImage, Ref: cfg.GoBaseImage},
+				{Setting: config.EnvGoRuntimeImage, Ref: cfg.GoRuntimeImage},
+			}
+		},
+		// The static binary is copied into the runtime image
+		Data: func(cfg *config.Config, be types.BuildEvent) interface{} {
+			return types.WrapperTemplateData{ParserId: be.ParserId, BaseImage: cfg.GoBaseImage, RuntimeImage: cfg.GoRuntimeImage}
+		},
+		Parse:     parseGo,
+		SourceDir: "parser",
+		Archive:   true,
+	})
+}
+
+// maxModuleBytes caps the unpacked size of a module tarball
+const maxModuleBytes = 32 << 20
+
+// ModuleSourceKey returns the S3 key of a parser's (optional) source tarball
+func ModuleSourceKey(be types.BuildEvent) string {
+	return fmt.Sprintf("%s/%s.tar.gz", be.ThirdPartyId, be.ParserId)
+}
+
+// parserSource returns the key and object info of a build's parser source:
+// its source tarball if the runtime takes one and it ships one, else its source file
+func (o *Orchestrator) parserSource(ctx context.Context, be types.BuildEvent) (string, storage.ObjectInfo, error) {
+	if o.runtime(be).Archive {
+		info, err := o.headSource(ctx, be, ModuleSourceKey(be))
+		if err == nil {
+			return ModuleSourceKey(be), info, nil
+		}
+		if !errors.Is(err, storage.ErrNotFound) {
+			return "", storage.ObjectInfo{}, fmt.Errorf("failed to stat parser module: %w", err)
+		}
+	}
+	info, err := o.headSource(ctx, be, SourceKey(be))
+	if err != nil {
+		return "", storage.ObjectInfo{}, fmt.Errorf("failed to stat parser source: %w", err)
+	}
+	return SourceKey(be), info, nil
+}
+
+// downloadModule unpacks a Go parser's module tarball into dir
+// Returns the .go files it holds, relative to dir, and the tarball's SHA-256
+func (o *Orchestrator) downloadModule(ctx context.Context, be types.BuildEvent, dir string) ([]string, string, error) {
+	body, err := o.getSource(ctx, be, ModuleSourceKey(be))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download parser module: %w", err)
+	}
+	defer body.Close()
+
+	h := sha256.New()
+	files, err := unpackModule(io.TeeReader(body, h), dir)
+	if err != nil {
+		return nil, "", &SourceInvalidError{File: be.ParserId + ".tar.gz", Diagnostics: err.Error()}
+	}
+	if len(files) == 0 {
+		return nil, "", &SourceInvalidError{File: be.ParserId + ".tar.gz", Diagnostics: "no .go files"}
+	}
+	// 🔏 The tarball may go on past the end of its archive (padding, gzip trailer)
+	if _, err := io.Copy(h, body); err != nil {
+		return nil, "", fmt.Errorf("failed to download parser module: %w", err)
+	}
+	return files, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// unpackModule extracts a module tarball's regular files and directories
+// 🎯 WHY: Entries escaping dir, links and oversized archives are refused
+func unpackModule(r io.Reader, dir string) ([]string, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a gzip archive: %w", err)
+	}
+	defer gz.Close()
+
+	var files []string
+	var total int64
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid tar archive: %w", err)
+		}
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if name == "." {
+			continue
+		}
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, fmt.Errorf("%s is outside the module", header.Name)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return nil, err
+			}
+		case tar.TypeReg:
+			if name == "go.mod" || name == "go.sum" {
+				log.Printf("Ignoring %s of the parser module", name)
+				continue
+			}
+			if total += header.Size; total > maxModuleBytes {
+				return nil, fmt.Errorf("larger than %d bytes unpacked", maxModuleBytes)
+			}
+			if err := writeModuleFile(tr, target); err != nil {
+				return nil, err
+			}
+			if strings.HasSuffix(name, ".go") {
+				files = append(files, filepath.FromSlash(name))
+			}
+		default:
+			return nil, fmt.Errorf("%s is not a regular file or directory", header.Name)
+		}
+	}
+}
+
+// writeModuleFile writes one file of a module tarball
+func writeModuleFile(r io.Reader, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, r); err != nil {
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	return nil
+}
+
+// parseGo checks that a Go source parses
+func parseGo(path string) error {
+	_, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.AllErrors)
+	return err
+}
@@ -0,0 +1,41 @@
+package build
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🔑 IDEMPOTENCY KEYS
+// =============================================================================
+// A redelivered build request must not start a second Kaniko job; requests are
+// recognized by an idempotency key:
+//   - id:<build id> when the request carries an id
+//   - source:<sha256 of tenant, parser and source ETag> otherwise (git commit
+//     and path for builds from git), so the same source isn't built twice in a row
+//     (per region: pushing it to another region isn't a duplicate)
+
+// IdempotencyKey returns the key identifying a build request
+func (o *Orchestrator) IdempotencyKey(ctx context.Context, be types.BuildEvent) (string, error) {
+	if be.ID != "" {
+		return "id:" + be.ID, nil
+	}
+
+	source, err := o.sourceVersion(ctx, be)
+	if err != nil {
+		return "", err
+	}
+	if region := o.pushRegion(be); region != "" {
+		source += "\n" + region
+	}
+	return SourceIdempotencyKey(be.ThirdPartyId, be.ParserId, source), nil
+}
+
+// SourceIdempotencyKey is the key of a request without id
+func SourceIdempotencyKey(thirdPartyId, parserId, sourceVersion string) string {
+	sum := sha256.Sum256([]byte(thirdPartyId + "\n" + parserId + "\n" + sourceVersion))
+	return "source:" + hex.EncodeToString(sum[:])
+}
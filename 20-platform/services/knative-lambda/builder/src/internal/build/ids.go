@@ -0,0 +1,60 @@
+package build
+
+import (
+	"fmt"
+	"regexp"
+
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🪪 BUILD IDENTIFIERS
+// =============================================================================
+// A build's thirdPartyId and parserId name its source and context files, its
+// S3 keys, its image and its Kubernetes objects, so they must be DNS-1123
+// labels: lowercase alphanumerics and '-', starting and ending alphanumeric
+// 🎯 WHY: A parserId such as "../../x" would otherwise write outside the
+// build context (the event schemas carry the same pattern)
+
+// Identifier length limits
+// 📝 NOTE: A thirdPartyId also names the tenant namespace (lambda-<id>), so it
+// stays as short as tenant onboarding wants; a parserId fits a label value
+const (
+	maxThirdPartyIdLength = 40
+	maxParserIdLength     = 63
+)
+
+// validID is what a thirdPartyId or parserId looks like
+var validID = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// InvalidIDError is returned for a build whose thirdPartyId or parserId can't be used
+type InvalidIDError struct {
+	Field string // thirdPartyId or parserId
+	Value string
+	Max   int
+}
+
+func (e *InvalidIDError) Error() string {
+	return fmt.Sprintf("invalid %s %q: must be 1-%d lowercase alphanumerics or '-', starting and ending alphanumeric", e.Field, e.Value, e.Max)
+}
+
+// InvalidRequest marks the error as the requester's fault (API: 400)
+func (e *InvalidIDError) InvalidRequest() bool {
+	return true
+}
+
+// CheckIDs refuses a build whose thirdPartyId or parserId can't be used in
+// paths, keys and object names
+func CheckIDs(be types.BuildEvent) error {
+	if !validIDOf(be.ThirdPartyId, maxThirdPartyIdLength) {
+		return &InvalidIDError{Field: "thirdPartyId", Value: be.ThirdPartyId, Max: maxThirdPartyIdLength}
+	}
+	if !validIDOf(be.ParserId, maxParserIdLength) {
+		return &InvalidIDError{Field: "parserId", Value: be.ParserId, Max: maxParserIdLength}
+	}
+	return nil
+}
+
+func validIDOf(id string, max int) bool {
+	return len(id) <= max && validID.MatchString(id)
+}
@@ -0,0 +1,200 @@
+package build
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// =============================================================================
+// 🙈 .LAMBDAIGNORE
+// =============================================================================
+// A multi-file bundle (a Go module tarball, a parser directory in git) may
+// ship a .lambdaignore at its root, in .dockerignore syntax, listing what
+// never goes into the build context: tests, docs, local artifacts
+//
+//	# comments and blank lines are skipped
+//	*.md
+//	**/testdata
+//	coverage/
+//	!README.md
+//
+// Patterns are relative to the bundle root; * and ? don't cross "/", **
+// matches any number of directories, and a pattern matching a directory
+// ignores everything under it. The last matching pattern wins, so !pattern
+// takes back files an earlier one ignored
+// 📝 NOTE: The .lambdaignore itself is never packed
+
+// lambdaIgnoreFile is the name of the ignore file at the root of a bundle
+const lambdaIgnoreFile = ".lambdaignore"
+
+// ignoreRule is one line of a .lambdaignore
+type ignoreRule struct {
+	pattern *regexp.Regexp
+	negate  bool
+}
+
+// ignoreRules are the rules of a .lambdaignore, in order
+type ignoreRules []ignoreRule
+
+// parseIgnore reads .lambdaignore rules
+func parseIgnore(content string) (ignoreRules, error) {
+	var rules ignoreRules
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		rule := ignoreRule{}
+		if strings.HasPrefix(text, "!") {
+			rule.negate = true
+			text = strings.TrimSpace(text[1:])
+		}
+		text = strings.TrimPrefix(path.Clean("/"+text), "/")
+		if text == "" {
+			continue
+		}
+		pattern, err := ignorePattern(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", lambdaIgnoreFile, line, err)
+		}
+		rule.pattern = pattern
+		rules = append(rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+// ignorePattern compiles a .dockerignore pattern into a regexp
+func ignorePattern(pattern string) (*regexp.Regexp, error) {
+	var re strings.Builder
+	re.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if strings.HasPrefix(pattern[i:], "**") {
+				i++
+				if strings.HasPrefix(pattern[i+1:], "/") {
+					// "**/" matches zero or more directories
+					i++
+					re.WriteString("(.*/)?")
+				} else {
+					re.WriteString(".*")
+				}
+			} else {
+				re.WriteString("[^/]*")
+			}
+		case '?':
+			re.WriteString("[^/]")
+		case '\\':
+			if i+1 == len(pattern) {
+				return nil, errors.New("pattern ends with a backslash")
+			}
+			i++
+			re.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated [ in %q", pattern)
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			re.WriteString("[" + class + "]")
+			i += end
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	re.WriteString("$")
+	return regexp.Compile(re.String())
+}
+
+// ignored reports whether a bundle path (slash-separated) is ignored: the
+// last rule matching it, or one of its parent directories, decides
+func (rules ignoreRules) ignored(rel string) bool {
+	parents := strings.Split(rel, "/")
+	ignored := false
+	for _, rule := range rules {
+		for i := range parents {
+			if rule.pattern.MatchString(strings.Join(parents[:i+1], "/")) {
+				ignored = !rule.negate
+				break
+			}
+		}
+	}
+	return ignored
+}
+
+// negates reports whether any rule takes files back
+func (rules ignoreRules) negates() bool {
+	for _, rule := range rules {
+		if rule.negate {
+			return true
+		}
+	}
+	return false
+}
+
+// applyLambdaIgnore removes what the .lambdaignore of the bundle in
+// contextDir/sourceDir ignores (and the .lambdaignore itself); sources are
+// the bundle's files relative to contextDir, of which the kept ones are returned
+func applyLambdaIgnore(contextDir, sourceDir string, sources []string) ([]string, error) {
+	dir := filepath.Join(contextDir, sourceDir)
+	content, err := os.ReadFile(filepath.Join(dir, lambdaIgnoreFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return sources, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", lambdaIgnoreFile, err)
+	}
+	rules, err := parseIgnore(string(content))
+	if err != nil {
+		return nil, &SourceInvalidError{File: lambdaIgnoreFile, Diagnostics: err.Error()}
+	}
+	if err := os.Remove(filepath.Join(dir, lambdaIgnoreFile)); err != nil {
+		return nil, err
+	}
+
+	// 📝 Ignored directories are walked too when a rule may take files back
+	negates := rules.negates()
+	err = filepath.WalkDir(dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || file == dir {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		switch {
+		case !rules.ignored(filepath.ToSlash(rel)):
+			return nil
+		case !entry.IsDir():
+			return os.Remove(file)
+		case !negates:
+			if err := os.RemoveAll(file); err != nil {
+				return err
+			}
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply %s: %w", lambdaIgnoreFile, err)
+	}
+
+	var kept []string
+	for _, source := range sources {
+		if _, err := os.Stat(filepath.Join(contextDir, source)); err == nil {
+			kept = append(kept, source)
+		}
+	}
+	return kept, nil
+}
@@ -0,0 +1,139 @@
+package build
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 📎 INLINE SOURCES
+// =============================================================================
+// Small parsers may be sent in the build request itself, skipping the source
+// bucket round trip:
+//
+//	"source": {"inline": {"content": "<base64>", "encoding": "gzip"}}
+//
+// content is the parser file ({parserId}.js, .py or .go), base64-encoded,
+// gzipped first with "encoding": "gzip". The decoded file is capped at
+// INLINE_SOURCE_MAX_BYTES, and goes through the same checks as a downloaded one.
+// Its SHA-256 takes the place of the ETag in the build cache and idempotency keys
+// 📝 NOTE: Parser tests, dependency files and custom Dockerfiles still come
+// from the source bucket
+
+// Encodings of inline sources
+const (
+	InlineEncodingBase64 = "base64"
+	InlineEncodingGzip   = "gzip"
+)
+
+// InvalidInlineSourceError is returned for an inline source that can't be built
+type InvalidInlineSourceError struct {
+	Reason string
+}
+
+func (e *InvalidInlineSourceError) Error() string {
+	return "invalid inline source: " + e.Reason
+}
+
+// InvalidRequest marks the error as the requester's (API: 400)
+func (e *InvalidInlineSourceError) InvalidRequest() bool { return true }
+
+// inlineSource returns a build's inline source (nil when it has none)
+func inlineSource(be types.BuildEvent) *types.InlineSource {
+	if be.Source == nil {
+		return nil
+	}
+	return be.Source.Inline
+}
+
+// CheckInlineSource validates a build's inline source, if it has one
+// Returns an *InvalidInlineSourceError naming what's wrong
+func (o *Orchestrator) CheckInlineSource(be types.BuildEvent) error {
+	if inlineSource(be) == nil {
+		return nil
+	}
+	_, err := o.inlineContent(be)
+	return err
+}
+
+// inlineContent decodes a build's inline source
+func (o *Orchestrator) inlineContent(be types.BuildEvent) ([]byte, error) {
+	inline := inlineSource(be)
+	if gitSource(be) != nil {
+		return nil, &InvalidInlineSourceError{Reason: "a source is either git or inline, not both"}
+	}
+	return decodeInline(inline, o.cfg.InlineSourceMaxBytes)
+}
+
+// decodeInline decodes an inline source of at most maxBytes (decoded)
+// 🎯 WHY: Both the encoded and the gunzipped sizes are bounded, so a small
+// gzip bomb can't exhaust the builder's memory
+func decodeInline(inline *types.InlineSource, maxBytes int) ([]byte, error) {
+	if maxBytes <= 0 {
+		return nil, &InvalidInlineSourceError{Reason: "inline sources are disabled (INLINE_SOURCE_MAX_BYTES=0)"}
+	}
+	if len(inline.Content) > base64.StdEncoding.EncodedLen(maxBytes) {
+		return nil, &InvalidInlineSourceError{Reason: fmt.Sprintf("content is larger than %d bytes", maxBytes)}
+	}
+	raw, err := base64.StdEncoding.DecodeString(inline.Content)
+	if err != nil {
+		return nil, &InvalidInlineSourceError{Reason: "content is not valid base64"}
+	}
+
+	content := raw
+	switch inline.Encoding {
+	case "", InlineEncodingBase64:
+	case InlineEncodingGzip:
+		gz, err := gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, &InvalidInlineSourceError{Reason: "content is not gzipped"}
+		}
+		content, err = io.ReadAll(io.LimitReader(gz, int64(maxBytes)+1))
+		if err != nil {
+			return nil, &InvalidInlineSourceError{Reason: "failed to gunzip content: " + err.Error()}
+		}
+	default:
+		return nil, &InvalidInlineSourceError{Reason: fmt.Sprintf("encoding %q must be %s or %s", inline.Encoding, InlineEncodingBase64, InlineEncodingGzip)}
+	}
+	if len(content) > maxBytes {
+		return nil, &InvalidInlineSourceError{Reason: fmt.Sprintf("content is larger than %d bytes", maxBytes)}
+	}
+	if len(content) == 0 {
+		return nil, &InvalidInlineSourceError{Reason: "content is empty"}
+	}
+	return content, nil
+}
+
+// inlineVersion identifies the parser source of an inline build, like an
+// ETag does for the source bucket
+func inlineVersion(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "inline:sha256:" + hex.EncodeToString(sum[:])
+}
+
+// writeInline writes a build's inline source where the runtime expects the
+// parser; returns its path relative to dir
+func (o *Orchestrator) writeInline(be types.BuildEvent, dir string) (string, error) {
+	content, err := o.inlineContent(be)
+	if err != nil {
+		return "", err
+	}
+	rt := o.runtime(be)
+	source := filepath.Join(rt.SourceDir, be.ParserId+rt.Extension)
+	if err := os.MkdirAll(filepath.Join(dir, rt.SourceDir), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", rt.SourceDir, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, source), content, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write parser source: %w", err)
+	}
+	return source, nil
+}
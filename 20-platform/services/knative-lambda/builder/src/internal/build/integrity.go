@@ -0,0 +1,120 @@
+package build
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🔏 SOURCE INTEGRITY
+// =============================================================================
+// Every build records which parser source it was built from: the SHA-256 of
+// the source as downloaded (its source file, or its module tarball; the
+// decoded content of an inline source) and its ETag in the source bucket.
+// Both are labeled on the image, kept with the image revision and the build
+// cache entry, and reported by build.started, build.image.pushed and
+// build.deployed.
+//
+// A build event may carry the sourceSha256 it expects: the build then fails
+// with stage validate, before any job runs, if the source differs
+// 📝 NOTE: Builds from git are identified by their commit instead, and can't
+// ask for a checksum
+
+// Image labels of the parser source
+const (
+	LabelSourceSHA256 = "network.notifi.lambda.source.sha256"
+	LabelSourceETag   = "network.notifi.lambda.source.etag"
+)
+
+// sha256Hex matches a hex-encoded SHA-256
+var sha256Hex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// sourceDigest identifies the parser source a build context was assembled from
+type sourceDigest struct {
+	ETag   string // In the source bucket ("" for inline and git sources)
+	SHA256 string // Of the source as downloaded ("" for git sources)
+}
+
+// InvalidChecksumError is returned for a sourceSha256 that can't be checked
+type InvalidChecksumError struct {
+	Reason string
+}
+
+func (e *InvalidChecksumError) Error() string {
+	return "invalid sourceSha256: " + e.Reason
+}
+
+// InvalidRequest marks the error as the requester's fault (API: 400)
+func (e *InvalidChecksumError) InvalidRequest() bool { return true }
+
+// SourceChecksumError is returned when a build's parser source doesn't have
+// the sourceSha256 its build event asked for
+type SourceChecksumError struct {
+	Want string
+	Got  string
+}
+
+func (e *SourceChecksumError) Error() string {
+	return fmt.Sprintf("parser source has sha256 %s, not the requested %s", e.Got, e.Want)
+}
+
+// CheckSourceChecksum refuses a build whose sourceSha256 can't be checked
+func CheckSourceChecksum(be types.BuildEvent) error {
+	switch {
+	case be.SourceSHA256 == "":
+		return nil
+	case !sha256Hex.MatchString(be.SourceSHA256):
+		return &InvalidChecksumError{Reason: "must be 64 lowercase hex digits"}
+	case gitSource(be) != nil:
+		return &InvalidChecksumError{Reason: "builds from git are pinned by commit instead"}
+	}
+	return nil
+}
+
+// verifyChecksum fails when a build asked for another source than digest's
+func verifyChecksum(be types.BuildEvent, digest sourceDigest) error {
+	if be.SourceSHA256 != "" && digest.SHA256 != be.SourceSHA256 {
+		return &SourceChecksumError{Want: be.SourceSHA256, Got: digest.SHA256}
+	}
+	return nil
+}
+
+// recordDigest sets the source a build was built from on its event
+func recordDigest(be types.BuildEvent, digest sourceDigest) types.BuildEvent {
+	be.SourceSHA256 = digest.SHA256
+	be.SourceETag = digest.ETag
+	return be
+}
+
+// sourceLabels returns the image labels naming a build's parser source
+func sourceLabels(be types.BuildEvent) []types.ImageLabel {
+	var labels []types.ImageLabel
+	if etag := strings.Trim(be.SourceETag, `"`); etag != "" {
+		labels = append(labels, types.ImageLabel{Name: LabelSourceETag, Value: etag})
+	}
+	if be.SourceSHA256 != "" {
+		labels = append(labels, types.ImageLabel{Name: LabelSourceSHA256, Value: be.SourceSHA256})
+	}
+	return labels
+}
+
+// objectSHA256 returns the hex SHA-256 of one of a build's sources
+func (o *Orchestrator) objectSHA256(ctx context.Context, be types.BuildEvent, key string) (string, error) {
+	body, err := o.getSource(ctx, be, key)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, body); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
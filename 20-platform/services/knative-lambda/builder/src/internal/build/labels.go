@@ -0,0 +1,36 @@
+package build
+
+import (
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🏷️ RESOURCE LABELS
+// =============================================================================
+// Every object created for a build (build, test and SBOM jobs, the parser's
+// Service and trigger) carries the same labels, whatever the templates set
+// 💡 EXAMPLE: kubectl get jobs,ksvc,rabbitmqsources -A -l knative-lambda.notifi.network/build-id=7f3c...
+
+// Labels of the objects created for a build
+const (
+	LabelBuildId   = "knative-lambda.notifi.network/build-id"
+	LabelManagedBy = "app.kubernetes.io/managed-by"
+	ManagedBy      = "knative-lambda-builder"
+)
+
+// ResourceLabels returns the labels of the objects created for a build
+// 📝 NOTE: The build id is left out when the request had none, or one that
+// isn't a valid label value
+func ResourceLabels(be types.BuildEvent) map[string]string {
+	labels := map[string]string{
+		LabelManagedBy:    ManagedBy,
+		thirdPartyIdLabel: be.ThirdPartyId,
+		parserIdLabel:     be.ParserId,
+	}
+	if be.ID != "" && len(validation.IsValidLabelValue(be.ID)) == 0 {
+		labels[LabelBuildId] = be.ID
+	}
+	return labels
+}
@@ -0,0 +1,89 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"knative-lambda-builder/internal/config"
+)
+
+// =============================================================================
+// 📦 PRIVATE NPM REGISTRY
+// =============================================================================
+// Parsers depending on our private npm scope need registry credentials during
+// npm install. They come from an .npmrc kept in a Kubernetes Secret
+// (NPMRC_SECRET, key .npmrc), mounted into build jobs:
+//   - kaniko: at /kaniko/npmrc, which Kaniko never snapshots; the Dockerfile
+//     points npm install at it with --userconfig
+//   - buildkit: passed as the build secret npmrc, mounted for the npm install
+//     step only (RUN --mount=type=secret)
+//
+// With NPMRC_SECRET_ARN, the .npmrc is kept in AWS Secrets Manager instead and
+// the builder copies it into the Secret (NPMRC_SECRET, default
+// knative-lambda-npmrc) before launching build jobs
+// 🔐 Either way the credentials never end up in the build context or an image layer
+
+// npmrcKey is the key of the .npmrc in its Secret
+const npmrcKey = ".npmrc"
+
+// npmrcSyncInterval is how long a copy of the Secrets Manager secret is trusted
+const npmrcSyncInterval = 5 * time.Minute
+
+// SecretReader reads secret values (implemented by aws.SecretsManager)
+type SecretReader interface {
+	SecretString(ctx context.Context, secretID string) (string, error)
+}
+
+// WithSecrets reads NPMRC_SECRET_ARN with r (default: Secrets Manager)
+func (o *Orchestrator) WithSecrets(r SecretReader) *Orchestrator {
+	o.secrets = r
+	return o
+}
+
+// npmrcSecret returns the Secret build jobs mount the .npmrc from ("" = none)
+func (o *Orchestrator) npmrcSecret() string {
+	if o.cfg.NpmrcSecret == "" && o.cfg.NpmrcSecretARN != "" {
+		return config.DefaultNpmrcSecret
+	}
+	return o.cfg.NpmrcSecret
+}
+
+// buildSecrets returns the BUILDKIT_SECRETS passed to BuildKit builds
+// 📝 NOTE: With an .npmrc Secret, it is the npmrc build secret
+func (o *Orchestrator) buildSecrets() []string {
+	if o.npmrcSecret() == "" {
+		return o.cfg.BuildKitSecrets
+	}
+	return slices.DeleteFunc(slices.Clone(o.cfg.BuildKitSecrets), func(key string) bool { return key == "npmrc" })
+}
+
+// syncNpmrc copies the .npmrc from Secrets Manager into its Secret, at most
+// once per npmrcSyncInterval (no-op without NPMRC_SECRET_ARN)
+// 📝 NOTE: A build fails rather than run npm install without credentials
+func (o *Orchestrator) syncNpmrc(ctx context.Context) error {
+	if o.cfg.NpmrcSecretARN == "" {
+		return nil
+	}
+	o.npmrcMu.Lock()
+	defer o.npmrcMu.Unlock()
+	if time.Since(o.npmrcSynced) < npmrcSyncInterval {
+		return nil
+	}
+	if o.secrets == nil {
+		return fmt.Errorf("failed to read %s: no Secrets Manager client", o.cfg.NpmrcSecretARN)
+	}
+	npmrc, err := o.secrets.SecretString(ctx, o.cfg.NpmrcSecretARN)
+	if err != nil {
+		return fmt.Errorf("failed to read the .npmrc: %w", err)
+	}
+	data := map[string][]byte{npmrcKey: []byte(npmrc)}
+	if err := o.executor.ApplySecret(ctx, o.cfg.KubernetesNamespace, o.npmrcSecret(), data); err != nil {
+		return fmt.Errorf("failed to copy the .npmrc: %w", err)
+	}
+	o.npmrcSynced = time.Now()
+	log.Printf("📦 Copied the .npmrc from %s into secret %s", o.cfg.NpmrcSecretARN, o.npmrcSecret())
+	return nil
+}
@@ -0,0 +1,672 @@
+package build
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+
+	"knative-lambda-builder/execution"
+	"knative-lambda-builder/internal/aws"
+	"knative-lambda-builder/internal/config"
+	"knative-lambda-builder/internal/k8s"
+	"knative-lambda-builder/internal/templates"
+	"knative-lambda-builder/internal/types"
+	"knative-lambda-builder/registry"
+	"knative-lambda-builder/storage"
+)
+
+// =============================================================================
+// 🏗️ BUILD ORCHESTRATION
+// =============================================================================
+// This package turns a BuildEvent into a running Kaniko job
+// 🎯 PURPOSE: Prepare the build context, make sure ECR is ready, launch the job
+
+// DefaultRepositoryPrefix is used when ECR_BASE_REGISTRY is not configured
+const DefaultRepositoryPrefix = "knative-lambdas"
+
+// Orchestrator coordinates everything needed to build a parser image
+type Orchestrator struct {
+	cfg       *config.Config
+	awsClient *aws.Client // Region and account ID for the job template
+	store     storage.ObjectStore
+	sources   storage.SourceStore // Parser sources (the store, unless they're kept in GCS or Azure Blob)
+	registry  registry.Registry
+	executor  execution.Executor
+	encryptor Encryptor
+	secrets   SecretReader // Reads NPMRC_SECRET_ARN
+
+	sourceBackend string // s3, gcs or azure
+	sourceBucket  string // account/container for azure
+
+	sourceAccess     SourceAccessPolicy                       // Tenants' own buckets and roles (nil = none)
+	sourceRoles      func(roleARN string) storage.SourceStore // Reads sources as a tenant's role
+	sourceRoleStores sync.Map                                 // roleARN -> its store
+
+	retention RetentionPolicy
+	platforms PlatformPolicy
+	sizing    ResourceSizing     // Requests and limits of build pods
+	backends  map[string]Backend // Build tools, by name (see BUILD_BACKEND)
+	queue     *buildQueue        // Holds builds back while MaxConcurrentBuilds jobs run
+
+	revisionsMu sync.Mutex // Serializes revision history updates
+
+	npmrcMu     sync.Mutex // Serializes .npmrc copies
+	npmrcSynced time.Time  // Last .npmrc copy from Secrets Manager
+
+	registryAuthMu     sync.Mutex // Serializes docker config writes
+	registryAuthSynced bool       // The docker config Secret was written by this process
+	registryAuthDue    time.Time  // When it must be rewritten (zero = never)
+
+	roles               RoleAssumer                    // Assumes push roles (nil = none)
+	pushRoleMu          sync.Mutex                     // Serializes push role credential writes
+	pushRoleCredentials map[string]pushRoleCredentials // Written into AWS_PUSH_ROLE_SECRET, by role
+}
+
+// Dependencies are the external systems the orchestrator talks to
+// 🧪 TESTING: Pass storage.FakeObjectStore, registry.FakeRegistry and execution.FakeExecutor
+type Dependencies struct {
+	Store    storage.ObjectStore
+	Sources  storage.SourceStore // nil = Store
+	Registry registry.Registry
+	Executor execution.Executor
+}
+
+// NewOrchestrator creates a new build orchestrator backed by S3, ECR and Kubernetes
+func NewOrchestrator(cfg *config.Config, awsClient *aws.Client, k8sClient *k8s.Client) *Orchestrator {
+	o := &Orchestrator{cfg: cfg, awsClient: awsClient}
+	roles := awsClient.Roles(cfg.AWSPushRoleExternalID)
+	var repositories registry.Registry = registry.Unmanaged{URL: o.Registry()}
+	// 🐳 Images go to Artifact Registry (GKE) or ACR (AKS) when configured, else ECR
+	switch {
+	case cfg.RegistryProvider == config.RegistryProviderGAR:
+		repositories = registry.NewGAR(cfg.GARLocation, storage.MetadataTokens(&http.Client{Timeout: 30 * time.Second}))
+	case cfg.RegistryProvider == config.RegistryProviderACR:
+		entraTokens := storage.WorkloadIdentityTokens(&http.Client{Timeout: 30 * time.Second}, registry.ACRScope)
+		repositories = registry.NewACR(cfg.ACRLoginServer(), entraTokens)
+	case cfg.RegistryProvider == config.RegistryProviderHarbor:
+		repositories = registry.NewHarbor(cfg.HarborURL, cfg.HarborUsername, cfg.HarborPassword, registry.HarborProject{
+			StorageLimit: int64(cfg.HarborProjectQuota),
+			AutoScan:     cfg.HarborAutoScan,
+		})
+	case registry.IsECR(o.Registry()):
+		// 🎭 ECR calls go through the push role when images live in another account
+		ecrClient := awsClient.ECR
+		if cfg.AWSPushRoleARN != "" {
+			ecrClient = ecr.NewFromConfig(roles.Config(cfg.AWSPushRoleARN))
+		}
+		policy, _ := cfg.ECRRepositoryLifecyclePolicy() // 📝 Checked at startup
+		repositories = registry.NewECR(ecrClient).WithLifecyclePolicy(policy).WithSettings(registry.ECRSettings{
+			TagMutability: cfg.ECRTagMutability,
+			ScanOnPush:    cfg.ECRScanOnPush,
+			Encryption:    cfg.ECREncryption,
+			KMSKey:        cfg.ECRKMSKey,
+		}).WithClients(func(roleARN, region string) *ecr.Client {
+			cfg := awsClient.Config
+			if roleARN != "" {
+				cfg = roles.Config(roleARN)
+			}
+			// 🌍 Regional pushes call the ECR of their region
+			return ecr.NewFromConfig(cfg, func(o *ecr.Options) {
+				if region != "" {
+					o.Region = region
+				}
+			})
+		})
+	}
+	deps := Dependencies{
+		Store:    storage.NewS3ObjectStore(awsClient.S3),
+		Registry: repositories,
+		Executor: NewKubernetesExecutor(k8sClient),
+	}
+	// ☁️ Sources may be kept in GCS (GKE) or Azure Blob (AKS), everything else stays in S3
+	switch backend, _, _ := cfg.SourceLocation(); backend {
+	case config.SourceBackendGCS:
+		deps.Sources = storage.NewGCSSourceStore()
+	case config.SourceBackendAzure:
+		deps.Sources = storage.NewAzureBlobSourceStore()
+	}
+	// 🔑 Tenants' own sources are read as their role (no external ID: the
+	// roles are set by platform admins at onboarding)
+	sourceRoles := awsClient.Roles("")
+	return NewOrchestratorWithDependencies(cfg, awsClient, deps).
+		WithSecrets(aws.NewSecretsManager(awsClient.Config)).
+		WithRoles(roles).
+		WithSourceRoles(func(roleARN string) storage.SourceStore {
+			return storage.NewS3ObjectStore(awsClient.NewS3(sourceRoles.Config(roleARN)))
+		})
+}
+
+// NewOrchestratorWithDependencies creates a build orchestrator with explicit dependencies
+func NewOrchestratorWithDependencies(cfg *config.Config, awsClient *aws.Client, deps Dependencies) *Orchestrator {
+	o := &Orchestrator{
+		cfg:       cfg,
+		awsClient: awsClient,
+		store:     deps.Store,
+		registry:  deps.Registry,
+		executor:  deps.Executor,
+		encryptor: noEncryption{},
+		retention: fixedRetention(cfg.ContextRetention),
+		platforms: fixedPlatforms(config.List(cfg.BuildPlatforms)),
+		backends:  newBackends(cfg),
+	}
+	// 📝 NOTE: An invalid SOURCE_URI is refused at startup
+	o.sourceBackend, o.sourceBucket, _ = cfg.SourceLocation()
+	o.sources = deps.Sources
+	if o.sources == nil {
+		o.sources = deps.Store
+	}
+	o.queue = newBuildQueue(cfg.MaxConcurrentBuilds, func(ctx context.Context) (int, error) {
+		return o.executor.RunningBuilds(ctx, cfg.KubernetesNamespace)
+	})
+	return o
+}
+
+// Result describes what CreateKanikoJob did
+type Result struct {
+	JobName    string // Kaniko job launched ("" when the build was skipped)
+	Cached     bool   // The image for these exact inputs is already in the registry
+	InputsHash string // Hash of the build inputs ("" when the cache is disabled)
+	ImageTag   string // Tag the job pushes, or the cached image's ("" = legacy <parserId> tag)
+	Commit     string // Commit the parser was built from ("" unless it comes from git)
+
+	ImageDigest string // Digest of the cached image (cached builds only)
+
+	// The parser source built (see integrity.go)
+	SourceSHA256 string
+	SourceETag   string
+}
+
+// CreateKanikoJob runs the whole build pipeline for a single BuildEvent
+// 🎯 PURPOSE: From "please build parser X" to "Kaniko job is running"
+// 📋 STEPS:
+//  1. Make sure the ECR repository exists
+//  2. Check the build cache (may skip steps 3-4, or the whole build; never
+//     for rebuilds)
+//  3. Assemble and upload the build context to S3
+//  4. Wait for a build slot (priority queue), record the build's image
+//     revision, render and create the build job with the build's backend
+//     (Kaniko unless BuildKit is picked or several platforms are built)
+func (o *Orchestrator) CreateKanikoJob(ctx context.Context, be types.BuildEvent) (*Result, error) {
+	// 🚦 An operator may have dropped the build while it was queued
+	if err := o.queue.droppedError(ctx); err != nil {
+		return nil, err
+	}
+	t, err := o.target(ctx, be)
+	if err != nil {
+		return nil, err
+	}
+	backend := t.backend
+	log.Printf("Creating %s job for ThirdPartyId=%s, ParserId=%s (platforms %s)",
+		backend.Name(), be.ThirdPartyId, be.ParserId, strings.Join(t.platforms, ","))
+
+	if err := o.validateReproducible(o.runtime(be)); err != nil {
+		return nil, err
+	}
+	if err := o.checkPushRole(be); err != nil {
+		return nil, err
+	}
+	if err := o.checkRegion(be); err != nil {
+		return nil, err
+	}
+	ctx = o.registryContext(ctx, be)
+	if be, err = o.ResolveSource(ctx, be); err != nil {
+		return nil, err
+	}
+
+	// =========================================================================
+	// 📍 STEP 1: ENSURE ECR REPOSITORY EXISTS
+	// =========================================================================
+	if _, err := o.EnsureRepository(ctx, be.ThirdPartyId); err != nil {
+		return nil, err
+	}
+
+	// =========================================================================
+	// 📍 STEP 2: CHECK THE BUILD CACHE
+	// =========================================================================
+	result := &Result{Commit: sourceCommit(be)}
+	contextReady := false
+	if o.cfg.BuildCacheEnabled {
+		if result.InputsHash, err = o.inputsHash(ctx, be, t); err != nil {
+			return nil, err
+		}
+		entry, err := o.loadCacheEntry(ctx, be)
+		if err != nil {
+			log.Printf("WARNING: Build cache unavailable, building from scratch: %v", err)
+		}
+		if be.Rebuild {
+			log.Printf("🔁 Rebuild requested, ignoring the build cache")
+			entry = nil
+		}
+		// 🔏 Entries from before checksums were recorded can't vouch for their source
+		if entry != nil && be.SourceSHA256 != "" && entry.SourceSHA256 == "" {
+			entry = nil
+		}
+		// ♻️ The same source uploaded again: only its ETag changed
+		if entry != nil && entry.InputsHash != result.InputsHash {
+			if etag, ok := o.sameContent(ctx, be, t, entry); ok {
+				log.Printf("⚡ %s/%s source uploaded again unchanged (sha256 %s)", be.ThirdPartyId, be.ParserId, entry.SourceSHA256)
+				entry.InputsHash, entry.SourceETag = result.InputsHash, etag
+				if err := o.saveCacheEntry(ctx, be, *entry); err != nil {
+					log.Printf("WARNING: %v", err)
+				}
+			}
+		}
+		if entry != nil && entry.InputsHash == result.InputsHash {
+			digest := sourceDigest{ETag: entry.SourceETag, SHA256: entry.SourceSHA256}
+			if err := verifyChecksum(be, digest); err != nil {
+				return nil, err
+			}
+			result.SourceSHA256, result.SourceETag = digest.SHA256, digest.ETag
+			if entry.ImageDigest != "" {
+				cached := be
+				cached.ImageTag = entry.ImageTag
+				digest, err := o.ImageDigest(ctx, cached)
+				if err != nil {
+					log.Printf("WARNING: Failed to check registry digest: %v", err)
+				}
+				if digest == entry.ImageDigest {
+					log.Printf("⚡ Inputs unchanged and %s still at %s, skipping build", o.ImageURI(cached), digest)
+					result.Cached = true
+					result.ImageTag = entry.ImageTag
+					result.ImageDigest = digest
+					return result, nil
+				}
+			}
+			contextReady = o.contextExists(ctx, entry.ContextKey)
+		}
+	}
+
+	// =========================================================================
+	// 📍 STEP 3: PREPARE BUILD CONTEXT
+	// =========================================================================
+	if contextReady {
+		log.Printf("⚡ Inputs unchanged, reusing build context %s", o.ContextURI(be))
+	} else {
+		digest, err := o.prepareBuildContext(ctx, be, t)
+		if err != nil {
+			return nil, err
+		}
+		result.SourceSHA256, result.SourceETag = digest.SHA256, digest.ETag
+	}
+	be = recordDigest(be, sourceDigest{ETag: result.SourceETag, SHA256: result.SourceSHA256})
+
+	// =========================================================================
+	// 📍 STEP 4: RENDER AND CREATE THE JOB
+	// =========================================================================
+	// 🚦 Cached builds never queue: only new Kaniko jobs count
+	release, err := o.queue.acquire(ctx, be)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// 📦 Registry credentials must be in place before the job mounts them
+	if err := o.syncNpmrc(ctx); err != nil {
+		return nil, err
+	}
+	if err := o.syncRegistryAuth(ctx); err != nil {
+		return nil, err
+	}
+	if err := o.syncPushRole(ctx, be); err != nil {
+		return nil, err
+	}
+
+	// 🏷️ Every job pushes a new, never reused tag
+	revision, err := o.newRevision(ctx, be, result.InputsHash)
+	if err != nil {
+		return nil, err
+	}
+	be.ImageTag = revision.Tag
+	result.ImageTag = revision.Tag
+
+	contextURL, err := o.contextURL(ctx, be)
+	if err != nil {
+		return nil, err
+	}
+	jobData, manifest, err := o.renderJob(be, t, contextURL)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := k8s.DecodeManifests(manifest)
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range objects {
+		k8s.SetLabels(obj, ResourceLabels(be))
+		if err := o.executor.Launch(ctx, obj); err != nil {
+			return nil, err
+		}
+	}
+	result.JobName = jobData.Name
+
+	if o.cfg.BuildCacheEnabled {
+		entry := CacheEntry{InputsHash: result.InputsHash, ContextKey: ContextKey(be), JobName: jobData.Name, ImageTag: revision.Tag,
+			SourceSHA256: result.SourceSHA256, SourceETag: result.SourceETag}
+		if result.SourceETag != "" {
+			if entry.ContentHash, err = o.contentHash(ctx, be, t, result.SourceSHA256); err != nil {
+				log.Printf("WARNING: %v", err)
+			}
+		}
+		if err := o.saveCacheEntry(ctx, be, entry); err != nil {
+			log.Printf("WARNING: %v", err)
+		}
+	}
+
+	log.Printf("✅ %s job %s created (image: %s)", backend.Name(), jobData.Name, jobData.ImageTag)
+	return result, nil
+}
+
+// renderJob renders the job template of a build's backend
+func (o *Orchestrator) renderJob(be types.BuildEvent, t target, contextURL string) (types.JobTemplateData, []byte, error) {
+	jobData := o.JobTemplateData(be)
+	jobData.Platforms = strings.Join(t.platforms, ",")
+	jobData.NodeArch = t.nodeArch()
+	jobData.Resources = t.resources
+	jobData.ContextURL = contextURL
+	manifest, err := templates.RenderFile(t.backend.TemplatePath(), jobData)
+	if err != nil {
+		return jobData, nil, fmt.Errorf("failed to render job template: %w", err)
+	}
+	return jobData, manifest, nil
+}
+
+// RenderJob renders the job a build would create, without creating anything:
+// no context upload, no revision recorded, the build cache not consulted
+// Returns the manifest and the image tag the job would push
+func (o *Orchestrator) RenderJob(ctx context.Context, be types.BuildEvent) ([]byte, string, error) {
+	t, err := o.target(ctx, be)
+	if err != nil {
+		return nil, "", err
+	}
+	if be, err = o.ResolveSource(ctx, be); err != nil {
+		return nil, "", err
+	}
+	revisions, err := o.Revisions(ctx, be)
+	if err != nil {
+		return nil, "", err
+	}
+	be.ImageTag = RevisionTag(be.ParserId, nextVersion(revisions))
+	contextURL := ""
+	if o.cfg.ContextPresignTTL > 0 {
+		contextURL = dryRunContextURL
+	}
+	_, manifest, err := o.renderJob(be, t, contextURL)
+	return manifest, be.ImageTag, err
+}
+
+// =============================================================================
+// 🏷️ NAMING HELPERS
+// =============================================================================
+
+// JobTemplateData builds the data passed to the job template
+func (o *Orchestrator) JobTemplateData(be types.BuildEvent) types.JobTemplateData {
+	return types.JobTemplateData{
+		Name:         JobName(be),
+		Dockerfile:   o.cfg.DefaultDockerfileName,
+		Context:      o.ContextURI(be),
+		ImageTag:     o.ImageURI(be),
+		Tag:          imageTag(be),
+		BucketName:   o.cfg.S3TmpBucket,
+		ThirdPartyId: be.ThirdPartyId,
+		ParserId:     be.ParserId,
+		Region:       o.awsClient.Config.Region,
+		AccountId:    o.awsClient.AccountID,
+		KanikoImage:  o.cfg.KanikoImage,
+		Reproducible: o.cfg.ReproducibleBuilds,
+		Attempt:      be.Attempt,
+		Retry:        be.Retry,
+
+		PriorityClassName:     o.cfg.BuildPriorityClass,
+		ActiveDeadlineSeconds: int64(o.cfg.BuildTimeout.Seconds()),
+
+		CacheRepo: o.CacheRepo(be),
+		CacheTTL:  o.cfg.KanikoCacheTTL.String(),
+
+		BuildKitAddr:  o.cfg.BuildKitAddr,
+		BuildKitImage: o.cfg.BuildKitImage,
+		BuildSecrets:  o.buildSecrets(),
+		NpmrcSecret:   o.npmrcSecret(),
+
+		RegistryAuthSecret: o.registryAuthSecret(),
+		InsecureRegistry:   o.insecureRegistry(),
+		PushRole:           o.pushRole(be),
+		PushRoleSecret:     o.pushRoleSecret(be),
+		PushRoleKey:        o.pushRoleSecretKey(be),
+		PushRegion:         o.pushRegion(be),
+
+		S3Endpoint:       o.awsClient.Endpoints.S3Endpoint(),
+		S3ForcePathStyle: o.awsClient.Endpoints.S3PathStyle,
+
+		BuildArgs:   BuildArgs(be),
+		ImageLabels: imageLabels(be),
+	}
+}
+
+// JobName returns a unique, DNS-compatible name for a build job
+// 📝 NOTE: Job names are limited to 63 characters
+func JobName(be types.BuildEvent) string {
+	name := fmt.Sprintf("build-%s-%s-%d", be.ThirdPartyId, be.ParserId, time.Now().Unix())
+	name = strings.ToLower(name)
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-.")
+	}
+	return name
+}
+
+// Registry returns the registry (host + optional path prefix) images are pushed to
+// 💡 EXAMPLES: 123456789012.dkr.ecr.us-west-2.amazonaws.com/knative-lambdas
+//
+//	localhost:5001/knative-lambdas
+func (o *Orchestrator) Registry() string {
+	switch o.cfg.RegistryProvider {
+	case config.RegistryProviderGAR:
+		url, _ := o.cfg.GARRegistry() // 📝 Checked at startup
+		return url
+	case config.RegistryProviderACR:
+		url, _ := o.cfg.ACRRegistry() // 📝 Checked at startup
+		return url
+	case config.RegistryProviderHarbor:
+		url, _ := o.cfg.HarborRegistry() // 📝 Checked at startup
+		return url
+	case config.RegistryProviderGeneric:
+		return o.cfg.RegistryURL
+	}
+	if o.cfg.ECRBaseRegistry != "" {
+		return strings.TrimSuffix(o.cfg.ECRBaseRegistry, "/")
+	}
+	// 🎭 Images go to the push role's account
+	if account := aws.RoleAccountID(o.cfg.AWSPushRoleARN); account != "" {
+		return fmt.Sprintf("%s.dkr.ecr.%s.amazonaws.com/%s", account, o.awsClient.Config.Region, DefaultRepositoryPrefix)
+	}
+	return o.awsClient.GetECRRegistryURL() + "/" + DefaultRepositoryPrefix
+}
+
+// tenantPath returns a tenant's repository path under the registry
+// 📝 NOTE: Harbor keeps every tenant in a project of its own: <project>/parsers
+func (o *Orchestrator) tenantPath(thirdPartyId string) string {
+	if o.cfg.RegistryProvider == config.RegistryProviderHarbor {
+		return o.cfg.HarborProjectPrefix + thirdPartyId + "/" + registry.HarborRepository
+	}
+	return thirdPartyId
+}
+
+// RepositoryName returns a tenant's repository name (registry path without host)
+// 📝 NOTE: One repository per tenant, one tag per parser revision
+func (o *Orchestrator) RepositoryName(thirdPartyId string) string {
+	registry := o.Registry()
+	if i := strings.Index(registry, "/"); i >= 0 {
+		return registry[i+1:] + "/" + o.tenantPath(thirdPartyId)
+	}
+	return o.tenantPath(thirdPartyId)
+}
+
+// CacheRepositoryName returns a tenant's Kaniko layer cache repository name
+// 📝 NOTE: Nested under the tenant repository, so TenantRepositories skips it
+func (o *Orchestrator) CacheRepositoryName(thirdPartyId string) string {
+	return o.RepositoryName(thirdPartyId) + "/cache"
+}
+
+// CacheRepo returns the --cache-repo of a build's job, in the registry it
+// pushes to ("" when the layer cache is disabled)
+func (o *Orchestrator) CacheRepo(be types.BuildEvent) string {
+	if !o.cfg.KanikoCacheEnabled {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/cache", o.buildRegistry(be), o.tenantPath(be.ThirdPartyId))
+}
+
+// TenantRepositories lists the tenant repositories in the registry, keyed by thirdPartyId
+func (o *Orchestrator) TenantRepositories(ctx context.Context) (map[string]string, error) {
+	// 📝 The thirdPartyId may be followed by more path (Harbor's /parsers)
+	prefix, suffix, _ := strings.Cut(o.RepositoryName("\x00"), "\x00")
+	names, err := o.registry.ListRepositories(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	repositories := make(map[string]string, len(names))
+	for _, name := range names {
+		thirdPartyId, ok := strings.CutSuffix(strings.TrimPrefix(name, prefix), suffix)
+		if !ok || thirdPartyId == "" || strings.Contains(thirdPartyId, "/") {
+			continue // Not a tenant repository (e.g. a cache repository)
+		}
+		repositories[thirdPartyId] = name
+	}
+	return repositories, nil
+}
+
+// ImageURI returns the full image reference of a build (see image revisions)
+func (o *Orchestrator) ImageURI(be types.BuildEvent) string {
+	return fmt.Sprintf("%s/%s:%s", o.buildRegistry(be), o.tenantPath(be.ThirdPartyId), imageTag(be))
+}
+
+// ImageDigest returns the digest the registry serves for a build's image
+// ("" when unknown, e.g. for registries that can't be queried)
+func (o *Orchestrator) ImageDigest(ctx context.Context, be types.BuildEvent) (string, error) {
+	return o.registry.ImageDigest(o.registryContext(ctx, be), o.RepositoryName(be.ThirdPartyId), imageTag(be))
+}
+
+// DeleteImage removes every image tag of a parser from the registry: its
+// recorded revisions and the legacy <parserId> tag
+func (o *Orchestrator) DeleteImage(ctx context.Context, be types.BuildEvent) error {
+	revisions, err := o.Revisions(ctx, be)
+	if err != nil {
+		return err
+	}
+	repository := o.RepositoryName(be.ThirdPartyId)
+	ctx = o.registryContext(ctx, be)
+	for _, revision := range revisions {
+		if err := o.registry.DeleteImage(ctx, repository, revision.Tag); err != nil {
+			return err
+		}
+	}
+	if err := o.registry.DeleteImage(ctx, repository, be.ParserId); err != nil {
+		return err
+	}
+	if err := o.store.Delete(ctx, o.cfg.S3TmpBucket, RevisionsKey(be)); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("failed to delete image revisions: %w", err)
+	}
+	return nil
+}
+
+// ContextKey returns the S3 key of the uploaded build context tarball
+// 📝 NOTE: Must match the --context path in job.yaml.tpl
+func ContextKey(be types.BuildEvent) string {
+	return fmt.Sprintf("builds/%s/%s.tar.gz", be.ThirdPartyId, be.ParserId)
+}
+
+// ContextURI returns the s3:// URI Kaniko reads the build context from
+func (o *Orchestrator) ContextURI(be types.BuildEvent) string {
+	return fmt.Sprintf("s3://%s/%s", o.cfg.S3TmpBucket, ContextKey(be))
+}
+
+// InputsKey returns the S3 key of a build's inputs record (reproducible mode)
+func InputsKey(be types.BuildEvent) string {
+	return fmt.Sprintf("builds/%s/%s.inputs.json", be.ThirdPartyId, be.ParserId)
+}
+
+// SourceURI returns the URI of an object of the source bucket, e.g. s3://sources/acme/p1.js
+func (o *Orchestrator) SourceURI(key string) string {
+	return o.sourceURI(o.sourceBucket, key)
+}
+
+// sourceURI returns the URI of an object of a source bucket (the platform's or a tenant's)
+func (o *Orchestrator) sourceURI(bucket, key string) string {
+	switch o.sourceBackend {
+	case config.SourceBackendGCS:
+		return fmt.Sprintf("gs://%s/%s", bucket, key)
+	case config.SourceBackendAzure:
+		return fmt.Sprintf("az://%s/%s", bucket, key)
+	}
+	return fmt.Sprintf("s3://%s/%s", bucket, key)
+}
+
+// SourceKey returns the key of the parser source in the source bucket
+// (e.g. acme/p1.js, or acme/p1.py for Python parsers)
+func SourceKey(be types.BuildEvent) string {
+	return fmt.Sprintf("%s/%s%s", be.ThirdPartyId, be.ParserId, lookupRuntime(be).Extension)
+}
+
+// EnsureRepository makes sure a tenant's image repository (and layer cache
+// repository) exists, and reports whether the image repository was created
+// 🎯 PURPOSE: Also used by tenant onboarding so the first build doesn't pay for it
+// 📝 NOTE: A cache repository that can't be ensured only costs the cache
+func (o *Orchestrator) EnsureRepository(ctx context.Context, thirdPartyId string) (bool, error) {
+	created, err := o.registry.EnsureRepository(ctx, o.RepositoryName(thirdPartyId))
+	if err != nil {
+		return false, err
+	}
+	if o.cfg.KanikoCacheEnabled {
+		if err := o.registry.EnsureCacheRepository(ctx, o.CacheRepositoryName(thirdPartyId), o.cfg.KanikoCacheTTL); err != nil {
+			log.Printf("WARNING: Layer cache of %s unavailable: %v", thirdPartyId, err)
+		}
+	}
+	return created, nil
+}
+
+// repositoryReconciler is implemented by registries whose existing
+// repositories can drift from the configured settings (ECR)
+type repositoryReconciler interface {
+	// ReconcileRepository returns what it changed
+	ReconcileRepository(ctx context.Context, repositoryName string) ([]string, error)
+}
+
+// ReconcileRepositories brings the settings of existing tenant repositories
+// (tag mutability, scan on push) in line with the configuration
+// 🎯 WHY: Changed settings would otherwise only reach tenants onboarded later
+// 📝 NOTE: Run once at startup; logs (doesn't fail) on error
+func (o *Orchestrator) ReconcileRepositories(ctx context.Context) {
+	reconciler, ok := o.registry.(repositoryReconciler)
+	if !ok {
+		return
+	}
+	tenants, err := o.TenantRepositories(ctx)
+	if err != nil {
+		log.Printf("WARNING: Failed to list tenant repositories to reconcile: %v", err)
+		return
+	}
+	for thirdPartyId, repositoryName := range tenants {
+		changed, err := reconciler.ReconcileRepository(ctx, repositoryName)
+		if err != nil {
+			log.Printf("WARNING: Failed to reconcile the repository of %s: %v", thirdPartyId, err)
+		}
+		if len(changed) > 0 {
+			log.Printf("🐳 Reconciled repository %s: %s", repositoryName, strings.Join(changed, ", "))
+		}
+	}
+}
+
+// logCleanup removes a temporary directory, logging (not failing) on error
+func logCleanup(dir string) {
+	if err := os.RemoveAll(dir); err != nil {
+		log.Printf("WARNING: Failed to clean up %s: %v", dir, err)
+	}
+}
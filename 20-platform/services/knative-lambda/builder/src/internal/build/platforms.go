@@ -0,0 +1,98 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🖥️ TARGET PLATFORMS
+// =============================================================================
+// Images are built for BUILD_PLATFORMS (default linux/amd64), or the platforms
+// in the tenant's record:
+//   - one platform: any backend; Kaniko jobs run on a node of that
+//     architecture, since Kaniko can't build for another one
+//   - several: BuildKit builds them all and pushes one manifest list, so
+//     builds that didn't pick a backend move to BuildKit
+//
+// 🎯 WHY: Parser services on Graviton (arm64) nodes need arm64 images
+
+// PlatformPolicy decides which platforms a tenant's images are built for
+// (implemented by tenants.BuildPlatforms)
+type PlatformPolicy interface {
+	BuildPlatforms(ctx context.Context, thirdPartyId string) ([]string, error)
+}
+
+// fixedPlatforms builds every tenant's images for the same platforms
+type fixedPlatforms []string
+
+func (p fixedPlatforms) BuildPlatforms(ctx context.Context, thirdPartyId string) ([]string, error) {
+	return p, nil
+}
+
+// WithPlatformPolicy overrides the platforms images are built for
+// (BUILD_PLATFORMS for every tenant by default)
+func (o *Orchestrator) WithPlatformPolicy(p PlatformPolicy) *Orchestrator {
+	o.platforms = p
+	return o
+}
+
+// target is what a build runs on and builds for
+type target struct {
+	backend   Backend
+	platforms []string                     // Empty = the build node's platform
+	resources *corev1.ResourceRequirements // Sizing of the build pod (Kaniko only, nil = unsized)
+}
+
+// multiPlatform reports whether the build pushes a manifest list
+func (t target) multiPlatform() bool {
+	return len(t.platforms) > 1
+}
+
+// nodeArch is the architecture the build pod must run on ("" = any)
+func (t target) nodeArch() string {
+	if t.backend.Name() != BackendKaniko || len(t.platforms) != 1 {
+		return ""
+	}
+	_, arch, _ := strings.Cut(t.platforms[0], "/")
+	return arch
+}
+
+// target resolves the backend and platforms of a build
+func (o *Orchestrator) target(ctx context.Context, be types.BuildEvent) (target, error) {
+	platforms, err := o.platforms.BuildPlatforms(ctx, be.ThirdPartyId)
+	if err != nil {
+		return target{}, fmt.Errorf("failed to resolve the build platforms of %s: %w", be.ThirdPartyId, err)
+	}
+	t := target{platforms: platforms}
+
+	name := be.Backend
+	if name == "" {
+		name = o.cfg.BuildBackend
+		if t.multiPlatform() {
+			name = BackendBuildKit
+		}
+	}
+	if name == "" {
+		name = BackendKaniko
+	}
+	if name == BackendKaniko && t.multiPlatform() {
+		return target{}, fmt.Errorf("kaniko can't build for several platforms (%s), use buildkit", strings.Join(platforms, ","))
+	}
+	if t.backend, err = o.backend(name); err != nil {
+		return target{}, err
+	}
+	// 📏 Checked for every backend, only Kaniko jobs run the build themselves
+	if t.resources, err = o.buildResources(ctx, be); err != nil {
+		return target{}, err
+	}
+	if name != BackendKaniko {
+		t.resources = nil
+	}
+	return t, nil
+}
@@ -0,0 +1,54 @@
+package build
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+)
+
+// =============================================================================
+// 🪂 PREEMPTION AND EVICTION
+// =============================================================================
+// Build pods run at low priority and can be preempted by the scheduler or
+// evicted (node drain, pressure). The job template fails the Job as soon as
+// its pod gets a DisruptionTarget condition, and the builder requeues the
+// build with backoff instead of treating it as a build failure.
+
+// MaxPreemptionBackoff caps the requeue delay
+const MaxPreemptionBackoff = 10 * time.Minute
+
+// PreemptionReason reports why a failed build job was disrupted ("" if it simply failed)
+// 📋 CHECKS:
+//  1. The job's pods (DisruptionTarget condition or Evicted status)
+//  2. The Job's Failed condition, set by the DisruptionTarget podFailurePolicy rule
+func (o *Orchestrator) PreemptionReason(ctx context.Context, jobName, failureReason, failureMessage string) string {
+	reason, err := o.executor.Disruption(ctx, o.cfg.KubernetesNamespace, jobName)
+	if err != nil {
+		log.Printf("WARNING: %v", err)
+	}
+	if reason != "" {
+		return reason
+	}
+	if failureReason == "PodFailurePolicy" && strings.Contains(failureMessage, "DisruptionTarget") {
+		return "DisruptionTarget"
+	}
+	return ""
+}
+
+// MaxPreemptionRetries is how often a disrupted build is requeued before giving up
+func (o *Orchestrator) MaxPreemptionRetries() int {
+	return o.cfg.BuildPreemptionRetries
+}
+
+// PreemptionBackoff returns how long to wait before requeueing attempt n (1-based)
+func (o *Orchestrator) PreemptionBackoff(attempt int) time.Duration {
+	backoff := o.cfg.BuildPreemptionBackoff
+	for i := 1; i < attempt && backoff < MaxPreemptionBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > MaxPreemptionBackoff {
+		backoff = MaxPreemptionBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,38 @@
+package build
+
+import (
+	"context"
+	"fmt"
+
+	"knative-lambda-builder/internal/types"
+	"knative-lambda-builder/storage"
+)
+
+// =============================================================================
+// 🔗 PRESIGNED CONTEXT HANDOFF
+// =============================================================================
+// Build jobs read their context from S3 with their own credentials by
+// default, so their role needs s3:GetObject on S3_TMP_BUCKET. With
+// CONTEXT_PRESIGN_TTL, the builder hands every job a presigned URL of its own
+// context instead, valid that long
+// 🎯 WHY: A compromised build pod can then read one context, for a while,
+// rather than every tenant's
+// 📝 NOTE: A job whose pod starts after its URL expired fails, and is retried
+// with a new URL like any failed build (BUILD_RETRIES)
+
+// dryRunContextURL stands in for the presigned URL in rendered dry runs, so
+// they hand out no working URL
+const dryRunContextURL = "https://presigned.invalid/context.tar.gz"
+
+// contextURL returns the presigned URL a build job downloads its context
+// from ("" = CONTEXT_PRESIGN_TTL unset: the job reads S3 itself)
+func (o *Orchestrator) contextURL(ctx context.Context, be types.BuildEvent) (string, error) {
+	if o.cfg.ContextPresignTTL <= 0 {
+		return "", nil
+	}
+	presigner, ok := o.store.(storage.Presigner)
+	if !ok {
+		return "", fmt.Errorf("failed to presign %s: the store can't presign URLs", o.ContextURI(be))
+	}
+	return presigner.PresignGet(ctx, o.cfg.S3TmpBucket, ContextKey(be), o.cfg.ContextPresignTTL)
+}
@@ -0,0 +1,145 @@
+package build
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+
+	"knative-lambda-builder/internal/config"
+	"knative-lambda-builder/internal/types"
+	"knative-lambda-builder/registry"
+)
+
+// =============================================================================
+// 🎭 CROSS-ACCOUNT PUSH ROLES
+// =============================================================================
+// Images may be pushed into another AWS account than the builder's, e.g. a
+// central tooling account. The builder then assumes an IAM role of that
+// account for its ECR calls (AWS_PUSH_ROLE_ARN), and build jobs push with the
+// role's temporary credentials, kept in the Secret AWS_PUSH_ROLE_SECRET
+// 🎯 WHY: Per tenant roles (pushRoleArn in the build request) scope what a
+// build may push to; they must start with one of AWS_PUSH_ROLES_ALLOWED
+// 📝 NOTE: One Secret holds the credentials of every role, under
+// <role key>.<variable>, so the builder only needs access to that Secret
+
+// AnnotationPushRole carries the role a job pushes with, so job updates
+// received after a builder restart check the image as the same role
+const AnnotationPushRole = "knative-lambda.notifi.network/push-role"
+
+// RoleAssumer hands out temporary credentials of IAM roles
+// 🧪 TESTING: aws.Roles in production, a stub in tests
+type RoleAssumer interface {
+	Credentials(ctx context.Context, roleARN string) (awssdk.Credentials, error)
+}
+
+// WithRoles sets how push roles are assumed
+func (o *Orchestrator) WithRoles(r RoleAssumer) *Orchestrator {
+	o.roles = r
+	return o
+}
+
+// pushRole returns the IAM role a build pushes with ("" = the job's own credentials)
+func (o *Orchestrator) pushRole(be types.BuildEvent) string {
+	if be.PushRoleArn != "" {
+		return be.PushRoleArn
+	}
+	return o.cfg.AWSPushRoleARN
+}
+
+// checkPushRole refuses builds naming a role that isn't allowed
+func (o *Orchestrator) checkPushRole(be types.BuildEvent) error {
+	if be.PushRoleArn == "" || be.PushRoleArn == o.cfg.AWSPushRoleARN {
+		return nil
+	}
+	if !registry.IsECR(o.Registry()) {
+		return fmt.Errorf("push role %s: push roles only apply to ECR", be.PushRoleArn)
+	}
+	for _, prefix := range config.List(o.cfg.AWSPushRolesAllowed) {
+		if strings.HasPrefix(be.PushRoleArn, prefix) {
+			return nil
+		}
+	}
+	return fmt.Errorf("push role %s is not allowed (see %s)", be.PushRoleArn, config.EnvAWSPushRolesAllowed)
+}
+
+// pushRoleKey returns the key prefix of a role's credentials in the Secret
+func pushRoleKey(roleARN string) string {
+	sum := sha256.Sum256([]byte(roleARN))
+	return "role-" + hex.EncodeToString(sum[:8])
+}
+
+// pushRoleSecret returns the Secret a build job takes its push role's
+// credentials from ("" = none: no role, or not ECR)
+func (o *Orchestrator) pushRoleSecret(be types.BuildEvent) string {
+	if o.pushRole(be) == "" || !registry.IsECR(o.Registry()) {
+		return ""
+	}
+	return o.cfg.AWSPushRoleSecret
+}
+
+// pushRoleSecretKey returns the key prefix of a build's push role credentials in the Secret
+func (o *Orchestrator) pushRoleSecretKey(be types.BuildEvent) string {
+	if o.pushRoleSecret(be) == "" {
+		return ""
+	}
+	return pushRoleKey(o.pushRole(be))
+}
+
+// pushRoleCredentials are the credentials of a role written into the Secret
+type pushRoleCredentials struct {
+	credentials awssdk.Credentials
+	due         time.Time // When they must be rewritten
+}
+
+// syncPushRole writes the temporary credentials of a build's push role into
+// the Secret, unless the ones written are still fresh (no-op without a role)
+// 📝 NOTE: Credentials are rewritten once half their lifetime has passed, so
+// a job started with them keeps at least half of it (30 minutes for the usual hour)
+func (o *Orchestrator) syncPushRole(ctx context.Context, be types.BuildEvent) error {
+	if o.pushRoleSecret(be) == "" {
+		return nil
+	}
+	roleARN := o.pushRole(be)
+	o.pushRoleMu.Lock()
+	defer o.pushRoleMu.Unlock()
+	if written, ok := o.pushRoleCredentials[roleARN]; ok && time.Now().Before(written.due) {
+		return nil
+	}
+	if o.roles == nil {
+		return fmt.Errorf("failed to assume push role %s: no STS client", roleARN)
+	}
+	credentials, err := o.roles.Credentials(ctx, roleARN)
+	if err != nil {
+		return err
+	}
+	if o.pushRoleCredentials == nil {
+		o.pushRoleCredentials = map[string]pushRoleCredentials{}
+	}
+	due := time.Now().Add(time.Until(credentials.Expires) / 2)
+	o.pushRoleCredentials[roleARN] = pushRoleCredentials{credentials: credentials, due: due}
+
+	// 🔐 Every role's credentials are written, expired ones are dropped
+	data := map[string][]byte{}
+	for role, written := range o.pushRoleCredentials {
+		if written.credentials.Expired() {
+			delete(o.pushRoleCredentials, role)
+			continue
+		}
+		key := pushRoleKey(role)
+		data[key+".AWS_ACCESS_KEY_ID"] = []byte(written.credentials.AccessKeyID)
+		data[key+".AWS_SECRET_ACCESS_KEY"] = []byte(written.credentials.SecretAccessKey)
+		data[key+".AWS_SESSION_TOKEN"] = []byte(written.credentials.SessionToken)
+	}
+	if err := o.executor.ApplySecret(ctx, o.cfg.KubernetesNamespace, o.cfg.AWSPushRoleSecret, data); err != nil {
+		delete(o.pushRoleCredentials, roleARN)
+		return fmt.Errorf("failed to write the credentials of push role %s: %w", roleARN, err)
+	}
+	log.Printf("🎭 Wrote the credentials of push role %s into secret %s", roleARN, o.cfg.AWSPushRoleSecret)
+	return nil
+}
@@ -0,0 +1,32 @@
+package build
+
+import (
+	"knative-lambda-builder/internal/config"
+	"knative-lambda-builder/internal/templates"
+)
+
+// 🐍 Python parsers: {parserId}.py, wrapped by main.py + requirements.txt; the
+// parser's own {parserId}.requirements.txt is installed too
+func init() {
+	RegisterRuntime(Runtime{
+		Name:        RuntimePython,
+		Extension:   ".py",
+		TemplateDir: templates.RuntimesDir + "/" + RuntimePython,
+		Templates:   []string{"Dockerfile.tpl", "main.py.tpl", "requirements.txt.tpl"},
+		Images: func(cfg *config.Config) []RuntimeImage {
+			return []RuntimeImage{{Setting: config.EnvPythonBaseImage, Ref: cfg.PythonBaseImage}}
+		},
+		CheckWith: func(cfg *config.Config) string { return cfg.PythonBinary },
+		CheckArgs: func(path string) []string { return []string{"-c", pythonCheck, path} },
+		Deps:      "requirements.txt",
+	})
+}
+
+// pythonCheck parses a Python source without running it or writing bytecode
+// into the build context; a syntax error is printed as "file:line: message"
+const pythonCheck = `import ast, sys
+path = sys.argv[1]
+try:
+    ast.parse(open(path).read(), path)
+except SyntaxError as e:
+    sys.exit(f"{e.filename}:{e.lineno}: SyntaxError: {e.msg}")`
@@ -0,0 +1,529 @@
+package build
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"knative-lambda-builder/internal/observability"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🚦 PRIORITY BUILD QUEUE
+// =============================================================================
+// With MAX_CONCURRENT_BUILDS set, a build only launches its Kaniko job while
+// fewer build jobs than that are running; the others wait in a queue ordered
+// by priority, then arrival
+// 🎯 WHY: An urgent production fix must not wait behind a bulk backfill
+// 🧵 Accepted builds join the queue right away; a fixed pool of build workers
+// (BUILD_WORKERS per replica) takes them in the same order, prepares their
+// context and waits for their slot. A burst of builds waits in the queue, not
+// in a goroutine each
+// 📝 NOTE: Running jobs are counted in the cluster, so all replicas share the
+// limit; each replica has its own queue, so N replicas may overshoot it by
+// up to N-1 jobs at once. With BuildRuns, builds a replica leaves waiting
+// are taken over by the others (see WithWorkStealing in internal/events)
+// 🚨 During incidents, operators can list the queue and promote, demote or
+// drop its builds (GET /admin/queue, see internal/api/queue.go)
+
+// Build priorities (BuildEvent.Priority)
+const (
+	PriorityHigh   = "high"
+	PriorityNormal = "normal" // Default
+	PriorityLow    = "low"
+)
+
+// queuePollInterval is how often the head of the queue recounts running jobs
+// 🎯 WHY: Jobs finish in the cluster (or on other replicas) without telling us
+const queuePollInterval = 5 * time.Second
+
+// ValidPriority reports whether p is a known priority ("" = normal)
+func ValidPriority(p string) bool {
+	switch p {
+	case "", PriorityHigh, PriorityNormal, PriorityLow:
+		return true
+	}
+	return false
+}
+
+// priorityRank orders priorities; higher goes first
+func priorityRank(p string) int {
+	switch p {
+	case PriorityHigh:
+		return 2
+	case PriorityLow:
+		return 0
+	}
+	return 1
+}
+
+// Ranks of builds moved by an operator: ahead of, or behind, every priority
+const (
+	promotedRank = 3
+	demotedRank  = -1
+)
+
+// priorityLabel is the metric label of a priority
+func priorityLabel(p string) string {
+	if p == "" {
+		return PriorityNormal
+	}
+	return p
+}
+
+// States of a queued build
+const (
+	queuedPending   = iota // Waiting for a build worker
+	queuedPreparing        // A build worker prepares its context
+	queuedWaiting          // Waiting for a slot
+)
+
+// queuedBuild is a build in the queue
+type queuedBuild struct {
+	ctx        context.Context // The build's context, for the worker that takes it
+	be         types.BuildEvent
+	enqueuedAt time.Time
+	rank       int
+	seq        int64  // Arrival order among equal ranks
+	index      int    // Position in the heap (-1 once removed)
+	state      int    // queuedPending, queuedPreparing or queuedWaiting
+	droppedBy  string // Who dropped the build from the queue ("" = not dropped)
+}
+
+// queuedKey carries the queued build a worker took in its context
+type queuedKey struct{}
+
+// QueuedBuild describes a build waiting in the queue
+type QueuedBuild struct {
+	Position     int       `json:"position"` // 1 = next to launch
+	ID           string    `json:"id"`
+	ThirdPartyId string    `json:"thirdPartyId"`
+	ParserId     string    `json:"parserId"`
+	Priority     string    `json:"priority"`
+	Moved        string    `json:"moved,omitempty"` // "promoted" or "demoted" by an operator
+	EnqueuedAt   time.Time `json:"enqueuedAt"`
+	WaitSeconds  int       `json:"waitSeconds"`
+}
+
+// BuildDroppedError is returned for a build an operator dropped from the queue
+type BuildDroppedError struct {
+	By string
+}
+
+func (e *BuildDroppedError) Error() string {
+	return "build was dropped from the build queue by " + e.By
+}
+
+// waitingBuilds is a heap of queued builds, highest rank and earliest first
+type waitingBuilds []*queuedBuild
+
+func (w waitingBuilds) Len() int { return len(w) }
+func (w waitingBuilds) Less(i, j int) bool {
+	if w[i].rank != w[j].rank {
+		return w[i].rank > w[j].rank
+	}
+	return w[i].seq < w[j].seq
+}
+func (w waitingBuilds) Swap(i, j int) {
+	w[i], w[j] = w[j], w[i]
+	w[i].index, w[j].index = i, j
+}
+func (w *waitingBuilds) Push(x interface{}) {
+	entry := x.(*queuedBuild)
+	entry.index = len(*w)
+	*w = append(*w, entry)
+}
+func (w *waitingBuilds) Pop() interface{} {
+	old := *w
+	entry := old[len(old)-1]
+	entry.index = -1
+	*w = old[:len(old)-1]
+	return entry
+}
+
+// buildQueue hands out build slots by priority
+type buildQueue struct {
+	limit   int                                    // 0 = unlimited
+	running func(ctx context.Context) (int, error) // Build jobs running in the cluster
+
+	mu        sync.Mutex
+	waiting   waitingBuilds
+	dropped   []*queuedBuild // Pending builds dropped before a worker took them
+	seq       int64          // Last arrival
+	front     int64          // Last promotion (counts down, ahead of every arrival)
+	launching int            // Slots handed out whose job may not be counted yet
+	changed   chan struct{}  // Closed (and replaced) whenever the queue changes
+}
+
+// newBuildQueue creates a queue allowing limit running build jobs
+func newBuildQueue(limit int, running func(ctx context.Context) (int, error)) *buildQueue {
+	return &buildQueue{limit: limit, running: running, changed: make(chan struct{})}
+}
+
+// enqueue queues an accepted build for the build workers
+func (q *buildQueue) enqueue(ctx context.Context, be types.BuildEvent) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pushLocked(ctx, be)
+	q.notifyLocked()
+}
+
+// work takes pending builds, highest priority first, and starts them until
+// ctx is done
+func (q *buildQueue) work(ctx context.Context, start func(ctx context.Context, be types.BuildEvent)) {
+	for {
+		entry, err := q.next(ctx)
+		if err != nil {
+			return
+		}
+		start(context.WithValue(entry.ctx, queuedKey{}, entry), entry.be)
+		q.leave(entry)
+	}
+}
+
+// next waits for a pending build and hands it to the calling worker
+// 📝 NOTE: Dropped builds come first, so that they fail without delay
+func (q *buildQueue) next(ctx context.Context) (*queuedBuild, error) {
+	for {
+		q.mu.Lock()
+		entry, changed := q.bestLocked(queuedPending), q.changed
+		if len(q.dropped) > 0 {
+			entry, q.dropped = q.dropped[0], q.dropped[1:]
+		}
+		if entry != nil {
+			entry.state = queuedPreparing
+			q.mu.Unlock()
+			return entry, nil
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// leave takes a build a worker started out of the queue, if still in it
+// (e.g. a cached build, which never waits for a slot)
+func (q *buildQueue) leave(entry *queuedBuild) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.removeLocked(entry)
+	q.notifyLocked()
+}
+
+// droppedError returns a BuildDroppedError if the build a worker took was
+// dropped from the queue
+func (q *buildQueue) droppedError(ctx context.Context) error {
+	entry, ok := ctx.Value(queuedKey{}).(*queuedBuild)
+	if q == nil || !ok {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if entry.droppedBy == "" {
+		return nil
+	}
+	return &BuildDroppedError{By: entry.droppedBy}
+}
+
+// acquire waits until the build may launch its job; release must be called
+// once the job is created (or failed to be)
+// 📋 STEPS:
+//  1. Wait in the queue (a build a worker took keeps its place, others join
+//     it behind builds of higher or equal priority)
+//  2. First of the builds waiting for a slot, recount running jobs until one
+//     is below the limit
+//  3. Leave the queue holding a launching slot
+func (q *buildQueue) acquire(ctx context.Context, be types.BuildEvent) (release func(), err error) {
+	if q == nil {
+		return func() {}, nil
+	}
+
+	q.mu.Lock()
+	entry := q.claimLocked(ctx)
+	if entry == nil && q.limit > 0 {
+		entry = q.pushLocked(ctx, be)
+	}
+	if entry != nil {
+		entry.state = queuedWaiting
+		q.notifyLocked()
+	}
+	q.mu.Unlock()
+	if q.limit <= 0 {
+		// Unlimited: a build a worker took only leaves the queue
+		if entry != nil {
+			q.leave(entry)
+		}
+		if err := q.droppedError(ctx); err != nil {
+			return nil, err
+		}
+		return func() {}, nil
+	}
+
+	label := priorityLabel(be.Priority)
+	start, logged := time.Now(), false
+
+	for {
+		q.mu.Lock()
+		first, changed, droppedBy := q.bestLocked(queuedWaiting) == entry, q.changed, entry.droppedBy
+		q.mu.Unlock()
+		if droppedBy != "" {
+			log.Printf("🚦 Build of %s/%s dropped from the queue by %s", be.ThirdPartyId, be.ParserId, droppedBy)
+			return nil, &BuildDroppedError{By: droppedBy}
+		}
+
+		if first {
+			running, err := q.running(ctx)
+			if err != nil {
+				log.Printf("WARNING: Failed to count running build jobs: %v", err)
+			} else {
+				q.mu.Lock()
+				// Still first (a higher priority build may have arrived)
+				if q.bestLocked(queuedWaiting) == entry && running+q.launching < q.limit {
+					q.removeLocked(entry)
+					q.launching++
+					q.notifyLocked()
+					q.mu.Unlock()
+					observability.BuildQueueWait.WithLabelValues(label).Observe(time.Since(entry.enqueuedAt).Seconds())
+					if logged {
+						log.Printf("🚦 Build of %s/%s leaves the queue after %s", be.ThirdPartyId, be.ParserId, time.Since(start).Round(time.Second))
+					}
+					return q.releaseFunc(), nil
+				}
+				q.mu.Unlock()
+			}
+		}
+		if !logged {
+			log.Printf("🚦 Build concurrency limit (%d) reached, build of %s/%s queued with priority %s",
+				q.limit, be.ThirdPartyId, be.ParserId, label)
+			logged = true
+		}
+
+		select {
+		case <-ctx.Done():
+			q.mu.Lock()
+			q.removeLocked(entry)
+			q.notifyLocked()
+			q.mu.Unlock()
+			return nil, fmt.Errorf("build of %s/%s left the queue: %w", be.ThirdPartyId, be.ParserId, ctx.Err())
+		case <-changed:
+		case <-time.After(queuePollInterval):
+		}
+	}
+}
+
+// pushLocked adds a build to the queue, behind builds of higher or equal
+// priority (q.mu must be held)
+func (q *buildQueue) pushLocked(ctx context.Context, be types.BuildEvent) *queuedBuild {
+	q.seq++
+	entry := &queuedBuild{ctx: ctx, be: be, enqueuedAt: time.Now(), rank: priorityRank(be.Priority), seq: q.seq}
+	heap.Push(&q.waiting, entry)
+	observability.BuildsQueued.WithLabelValues(priorityLabel(be.Priority)).Inc()
+	return entry
+}
+
+// removeLocked takes a build out of the queue, if still in it (q.mu must be held)
+func (q *buildQueue) removeLocked(entry *queuedBuild) {
+	if entry.index < 0 {
+		return
+	}
+	heap.Remove(&q.waiting, entry.index)
+	observability.BuildsQueued.WithLabelValues(priorityLabel(entry.be.Priority)).Dec()
+}
+
+// claimLocked returns the build a worker took and now waits for its slot, or
+// nil for a build started some other way (q.mu must be held)
+// 📝 NOTE: Only once: a retry started from the same context joins the queue anew
+func (q *buildQueue) claimLocked(ctx context.Context) *queuedBuild {
+	entry, ok := ctx.Value(queuedKey{}).(*queuedBuild)
+	if !ok || entry.state != queuedPreparing {
+		return nil
+	}
+	return entry
+}
+
+// bestLocked returns the build in a state that goes first (q.mu must be held)
+func (q *buildQueue) bestLocked(state int) *queuedBuild {
+	best := -1
+	for i, entry := range q.waiting {
+		if entry.state == state && (best < 0 || q.waiting.Less(i, best)) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return nil
+	}
+	return q.waiting[best]
+}
+
+// releaseFunc returns a slot's release, safe to call more than once
+func (q *buildQueue) releaseFunc() func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			q.launching--
+			q.notifyLocked()
+		})
+	}
+}
+
+// notifyLocked wakes the waiting builds (q.mu must be held)
+func (q *buildQueue) notifyLocked() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// list returns the waiting builds, next to launch first
+func (q *buildQueue) list() []QueuedBuild {
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	ordered := make(waitingBuilds, len(q.waiting))
+	copy(ordered, q.waiting)
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered.Less(i, j)
+	})
+
+	builds := make([]QueuedBuild, len(ordered))
+	for i, entry := range ordered {
+		builds[i] = entry.describe()
+		builds[i].Position = i + 1
+	}
+	return builds
+}
+
+// describe reports a queued build (without its position)
+func (entry *queuedBuild) describe() QueuedBuild {
+	build := QueuedBuild{
+		ID:           entry.be.ID,
+		ThirdPartyId: entry.be.ThirdPartyId,
+		ParserId:     entry.be.ParserId,
+		Priority:     priorityLabel(entry.be.Priority),
+		EnqueuedAt:   entry.enqueuedAt,
+		WaitSeconds:  int(time.Since(entry.enqueuedAt).Seconds()),
+	}
+	switch entry.rank {
+	case promotedRank:
+		build.Moved = "promoted"
+	case demotedRank:
+		build.Moved = "demoted"
+	}
+	return build
+}
+
+// promote moves a waiting build ahead of every other (false if it isn't queued)
+func (q *buildQueue) promote(id string) (QueuedBuild, bool) {
+	return q.move(id, func(entry *queuedBuild) {
+		q.front--
+		entry.rank, entry.seq = promotedRank, q.front
+	})
+}
+
+// demote moves a waiting build behind every other (false if it isn't queued)
+func (q *buildQueue) demote(id string) (QueuedBuild, bool) {
+	return q.move(id, func(entry *queuedBuild) {
+		q.seq++
+		entry.rank, entry.seq = demotedRank, q.seq
+	})
+}
+
+// drop takes a waiting build out of the queue; its build fails with a
+// BuildDroppedError (false if it isn't queued)
+func (q *buildQueue) drop(id, by string) (QueuedBuild, bool) {
+	if q == nil {
+		return QueuedBuild{}, false
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	entry := q.findLocked(id)
+	if entry == nil {
+		return QueuedBuild{}, false
+	}
+	q.removeLocked(entry)
+	entry.droppedBy = by
+	// 🧵 No one waits for a pending build yet: a worker must fail it
+	if entry.state == queuedPending {
+		q.dropped = append(q.dropped, entry)
+	}
+	q.notifyLocked()
+	return entry.describe(), true
+}
+
+// move reorders a waiting build
+func (q *buildQueue) move(id string, reorder func(entry *queuedBuild)) (QueuedBuild, bool) {
+	if q == nil {
+		return QueuedBuild{}, false
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	entry := q.findLocked(id)
+	if entry == nil {
+		return QueuedBuild{}, false
+	}
+	reorder(entry)
+	heap.Fix(&q.waiting, entry.index)
+	q.notifyLocked()
+	return entry.describe(), true
+}
+
+// findLocked returns the waiting build with an id (q.mu must be held)
+func (q *buildQueue) findLocked(id string) *queuedBuild {
+	for _, entry := range q.waiting {
+		if entry.be.ID == id {
+			return entry
+		}
+	}
+	return nil
+}
+
+// QueueBuild queues an accepted build; a build worker (RunBuildWorkers)
+// starts it with ctx
+func (o *Orchestrator) QueueBuild(ctx context.Context, be types.BuildEvent) {
+	o.queue.enqueue(ctx, be)
+}
+
+// RunBuildWorkers starts queued builds, highest priority first, in workers
+// goroutines until ctx is done
+func (o *Orchestrator) RunBuildWorkers(ctx context.Context, workers int, start func(ctx context.Context, be types.BuildEvent)) {
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			o.queue.work(ctx, start)
+		}()
+	}
+	wg.Wait()
+}
+
+// QueuedBuilds lists the queued builds, next to start first
+func (o *Orchestrator) QueuedBuilds() []QueuedBuild {
+	return o.queue.list()
+}
+
+// PromoteQueued moves a waiting build to the head of the queue
+func (o *Orchestrator) PromoteQueued(id string) (QueuedBuild, bool) {
+	return o.queue.promote(id)
+}
+
+// DemoteQueued moves a waiting build to the back of the queue
+func (o *Orchestrator) DemoteQueued(id string) (QueuedBuild, bool) {
+	return o.queue.demote(id)
+}
+
+// DropQueued takes a waiting build out of the queue and fails it
+func (o *Orchestrator) DropQueued(id, by string) (QueuedBuild, bool) {
+	return o.queue.drop(id, by)
+}
@@ -0,0 +1,63 @@
+package build
+
+import (
+	"context"
+	"fmt"
+
+	"knative-lambda-builder/internal/config"
+	"knative-lambda-builder/internal/types"
+	"knative-lambda-builder/registry"
+)
+
+// =============================================================================
+// 🌍 REGIONAL PUSHES
+// =============================================================================
+// A build request may name another AWS region (region), so one builder
+// produces images for several regional clusters: the image is pushed to the
+// same registry (account and path) in that region's ECR
+// 🎯 WHY: Clusters pull from the registry of their own region; pulling across
+// regions is slower and breaks when the builder's region is down
+// 📝 NOTE: The region must be one of AWS_PUSH_REGIONS_ALLOWED. ECR clients
+// are created once per region (and push role) and then reused
+
+// AnnotationRegion carries the region a job pushes to, so job updates
+// received after a builder restart look for the image in the same registry
+const AnnotationRegion = "knative-lambda.notifi.network/region"
+
+// pushRegion returns the AWS region a build pushes to ("" = the registry's own)
+func (o *Orchestrator) pushRegion(be types.BuildEvent) string {
+	if be.Region == "" || be.Region == registry.ECRRegion(o.Registry()) {
+		return ""
+	}
+	return be.Region
+}
+
+// checkRegion refuses builds naming a region that isn't allowed
+func (o *Orchestrator) checkRegion(be types.BuildEvent) error {
+	if o.pushRegion(be) == "" {
+		return nil
+	}
+	if !registry.IsECR(o.Registry()) {
+		return fmt.Errorf("region %s: regions only apply to ECR", be.Region)
+	}
+	for _, allowed := range config.List(o.cfg.AWSPushRegionsAllowed) {
+		if be.Region == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("region %s is not allowed (see %s)", be.Region, config.EnvAWSPushRegionsAllowed)
+}
+
+// buildRegistry returns the registry a build pushes to: the registry, in the
+// build's region
+func (o *Orchestrator) buildRegistry(be types.BuildEvent) string {
+	if region := o.pushRegion(be); region != "" {
+		return registry.ECRInRegion(o.Registry(), region)
+	}
+	return o.Registry()
+}
+
+// registryContext makes the registry calls of a build as its push role, in its region
+func (o *Orchestrator) registryContext(ctx context.Context, be types.BuildEvent) context.Context {
+	return registry.WithRegion(registry.WithRole(ctx, o.pushRole(be)), o.pushRegion(be))
+}
@@ -0,0 +1,83 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"knative-lambda-builder/internal/config"
+)
+
+// =============================================================================
+// 🔐 REGISTRY AUTH FOR BUILD JOBS
+// =============================================================================
+// Kaniko finds ECR credentials on its own (the ecr-login helper and the AWS
+// credentials of the job). Registries that need more hand the builder a
+// docker config, which it writes into a Secret (REGISTRY_AUTH_SECRET, key
+// .dockerconfigjson) mounted at /kaniko/.docker in Kaniko jobs. With
+// REGISTRY_PROVIDER=generic, the Secret is a kubernetes.io/dockerconfigjson
+// Secret of the operator's, e.g. with Docker Hub credentials
+// 💡 EXAMPLES: Artifact Registry's config points Kaniko at the gcr credential
+// helper, which authenticates as the job's service account (Workload
+// Identity); ACR's holds a refresh token, rewritten before it expires
+
+// registryAuthKey is the key of the docker config in its Secret
+// 📝 NOTE: The key of kubernetes.io/dockerconfigjson Secrets, so jobs mount
+// written and operator-managed Secrets the same way
+const registryAuthKey = ".dockerconfigjson"
+
+// dockerConfigurer is implemented by registries Kaniko needs a docker config for
+type dockerConfigurer interface {
+	// DockerConfig returns the config.json and how long to keep it (0 = for good)
+	DockerConfig(ctx context.Context) ([]byte, time.Duration, error)
+}
+
+// registryAuthSecret returns the Secret build jobs mount the docker config
+// from ("" = the build backend's default)
+func (o *Orchestrator) registryAuthSecret() string {
+	if _, ok := o.registry.(dockerConfigurer); !ok && o.cfg.RegistryProvider != config.RegistryProviderGeneric {
+		return ""
+	}
+	return o.cfg.RegistryAuthSecret
+}
+
+// insecureRegistry returns the registry host build jobs skip TLS verification
+// for ("" = none; REGISTRY_INSECURE, generic registries only)
+func (o *Orchestrator) insecureRegistry() string {
+	if o.cfg.RegistryProvider != config.RegistryProviderGeneric || !o.cfg.RegistryInsecure {
+		return ""
+	}
+	host, _, _ := strings.Cut(o.Registry(), "/")
+	return host
+}
+
+// syncRegistryAuth writes the registry's docker config into its Secret, once
+// per process or whenever the last one is due (no-op for registries without one)
+func (o *Orchestrator) syncRegistryAuth(ctx context.Context) error {
+	configurer, ok := o.registry.(dockerConfigurer)
+	if !ok {
+		return nil
+	}
+	o.registryAuthMu.Lock()
+	defer o.registryAuthMu.Unlock()
+	if o.registryAuthSynced && (o.registryAuthDue.IsZero() || time.Now().Before(o.registryAuthDue)) {
+		return nil
+	}
+	config, keep, err := configurer.DockerConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the registry docker config: %w", err)
+	}
+	data := map[string][]byte{registryAuthKey: config}
+	if err := o.executor.ApplySecret(ctx, o.cfg.KubernetesNamespace, o.cfg.RegistryAuthSecret, data); err != nil {
+		return fmt.Errorf("failed to write the registry docker config: %w", err)
+	}
+	o.registryAuthSynced = true
+	o.registryAuthDue = time.Time{}
+	if keep > 0 {
+		o.registryAuthDue = time.Now().Add(keep)
+	}
+	log.Printf("🔐 Wrote the registry docker config into secret %s", o.cfg.RegistryAuthSecret)
+	return nil
+}
@@ -0,0 +1,133 @@
+package build
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🔁 REPRODUCIBLE BUILDS
+// =============================================================================
+// Some compliance frameworks require that rebuilding the same source yields
+// the identical image digest. With REPRODUCIBLE_BUILDS=true we:
+//   - pin the base image by digest (refuse to build otherwise)
+//   - normalize file timestamps, ownership and ordering in the context tarball
+//   - run Kaniko with --reproducible (strips timestamps from the image)
+//   - record every input next to the build context (builds/<tid>/<pid>.inputs.json)
+//
+// 📝 NOTE: `npm install` still resolves dependency ranges at build time; pin
+// them in package.json.tpl if the dependency tree must be frozen too
+
+// validateReproducible checks the configuration required for reproducible builds
+func (o *Orchestrator) validateReproducible(rt Runtime) error {
+	if !o.cfg.ReproducibleBuilds {
+		return nil
+	}
+	for _, image := range rt.Images(o.cfg) {
+		if !isPinned(image.Ref) {
+			return fmt.Errorf("reproducible builds require %s pinned by digest (image@sha256:...), got %q", image.Setting, image.Ref)
+		}
+	}
+	if !isPinned(o.cfg.KanikoImage) {
+		log.Printf("WARNING: KANIKO_IMAGE %q is not pinned by digest, image digests may change with Kaniko upgrades", o.cfg.KanikoImage)
+	}
+	return nil
+}
+
+// isPinned reports whether an image reference includes a digest
+func isPinned(image string) bool {
+	return strings.Contains(image, "@sha256:")
+}
+
+// recordInputs uploads the inputs record of a build next to its context;
+// dockerfileSource is where its custom Dockerfile came from ("" = templated)
+func (o *Orchestrator) recordInputs(ctx context.Context, be types.BuildEvent, dir, contextSHA256, dockerfileSource string) error {
+	inputs := types.BuildInputs{
+		ThirdPartyId: be.ThirdPartyId,
+		ParserId:     be.ParserId,
+		BaseImage:    o.baseImage(o.runtime(be)),
+		KanikoImage:  o.cfg.KanikoImage,
+		Dockerfile:   o.cfg.DefaultDockerfileName,
+		BuildArgs:    be.BuildArgs,
+		Files:        map[string]string{},
+	}
+	// 🐳 A custom Dockerfile names its own base images, the runtime's isn't used
+	if dockerfileSource != "" {
+		inputs.BaseImage = ""
+		inputs.Dockerfile = customDockerfileName(be)
+		inputs.DockerfileSource = dockerfileSource
+	}
+	dockerfileSHA256, err := fileSHA256(filepath.Join(dir, o.cfg.DefaultDockerfileName))
+	if err != nil {
+		return fmt.Errorf("failed to hash Dockerfile: %w", err)
+	}
+	inputs.DockerfileSHA256 = dockerfileSHA256
+
+	// 📂 Every file packed, nested ones (parser/, git checkouts) included,
+	// by its slash-separated path in the context
+	opts := o.packOptions()
+	err = filepath.WalkDir(dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || file == dir {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		switch {
+		case opts.excluded(rel) && entry.IsDir():
+			return filepath.SkipDir
+		case opts.excluded(rel) || entry.IsDir():
+			return nil
+		}
+		sum, err := fileSHA256(file)
+		if err != nil {
+			return err
+		}
+		inputs.Files[rel] = sum
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list build context: %w", err)
+	}
+	inputs.ContextSHA256 = contextSHA256
+
+	body, err := json.MarshalIndent(inputs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode build inputs: %w", err)
+	}
+
+	key := InputsKey(be)
+	log.Printf("Recording build inputs to s3://%s/%s (context sha256 %s)", o.cfg.S3TmpBucket, key, inputs.ContextSHA256)
+	if err := o.putSealed(ctx, be.ThirdPartyId, key, body); err != nil {
+		return fmt.Errorf("failed to record build inputs: %w", err)
+	}
+	return nil
+}
+
+// fileSHA256 returns the hex sha256 of a file
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
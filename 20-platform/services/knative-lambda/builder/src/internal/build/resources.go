@@ -0,0 +1,211 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"knative-lambda-builder/internal/config"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 📏 BUILD RESOURCES
+// =============================================================================
+// Kaniko build pods get cpu, memory and ephemeral-storage requests and limits:
+// BUILD_RESOURCE_REQUESTS/BUILD_RESOURCE_LIMITS, overridden per resource by
+// the tenant's record (buildRequests/buildLimits), overridden by the build
+// request's resources. Nothing may go above BUILD_RESOURCE_MAX, and a
+// resource with a maximum but no limit is limited to the maximum
+// 🎯 WHY: Parsers with a large node_modules need more memory than most, and
+// unsized Kaniko pods can starve the node they run on
+// 📝 NOTE: A build whose requests exceed its limits, or go above the maximum,
+// is refused (API: 400) instead of being clamped. BuildKit builds run in
+// buildkitd, so their jobs (a buildctl client) aren't sized
+// 💡 EXAMPLE: {"resources": {"requests": {"memory": "3Gi"}, "limits": {"memory": "6Gi"}}}
+
+// BuildResourceNames are the resources build pods are sized by
+var BuildResourceNames = []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourceEphemeralStorage}
+
+// ResourcePolicy decides how a tenant's build pods are sized over the
+// platform's defaults (implemented by tenants.BuildResources)
+type ResourcePolicy interface {
+	BuildResources(ctx context.Context, thirdPartyId string) (corev1.ResourceRequirements, error)
+}
+
+// ResourceSizing is how build pods are sized
+type ResourceSizing struct {
+	Defaults corev1.ResourceRequirements // Every build's (BUILD_RESOURCE_REQUESTS, BUILD_RESOURCE_LIMITS)
+	Max      corev1.ResourceList         // No request or limit may go above (empty = no maximum)
+	Tenants  ResourcePolicy              // The tenants' own sizing (nil = none)
+}
+
+// WithResourceSizing sizes build pods (unsized by default)
+func (o *Orchestrator) WithResourceSizing(s ResourceSizing) *Orchestrator {
+	o.sizing = s
+	return o
+}
+
+// InvalidResourcesError is returned for a build whose resources can't be used
+type InvalidResourcesError struct {
+	Reason string
+}
+
+func (e *InvalidResourcesError) Error() string {
+	return "invalid build resources: " + e.Reason
+}
+
+// InvalidRequest marks the error as the requester's fault (API: 400)
+func (e *InvalidResourcesError) InvalidRequest() bool { return true }
+
+// ParseResources parses "resource=quantity" pairs, e.g. "cpu=1,memory=2Gi"
+// ("none" is empty)
+func ParseResources(value string) (corev1.ResourceList, error) {
+	quantities := map[string]string{}
+	if value == config.BuildResourcesNone {
+		value = ""
+	}
+	for _, pair := range config.List(value) {
+		name, quantity, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not resource=quantity", pair)
+		}
+		quantities[strings.TrimSpace(name)] = strings.TrimSpace(quantity)
+	}
+	return resourceList(quantities)
+}
+
+// resourceList parses quantities of build resources
+func resourceList(quantities map[string]string) (corev1.ResourceList, error) {
+	list := corev1.ResourceList{}
+	for name, value := range quantities {
+		if !isBuildResource(corev1.ResourceName(name)) {
+			return nil, fmt.Errorf("%q is not one of %s", name, resourceNames())
+		}
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s=%s: %w", name, value, err)
+		}
+		if quantity.Sign() < 0 {
+			return nil, fmt.Errorf("%s=%s is negative", name, value)
+		}
+		list[corev1.ResourceName(name)] = quantity
+	}
+	return list, nil
+}
+
+func isBuildResource(name corev1.ResourceName) bool {
+	for _, n := range BuildResourceNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func resourceNames() string {
+	names := make([]string, len(BuildResourceNames))
+	for i, name := range BuildResourceNames {
+		names[i] = string(name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// ValidateResources refuses sizing whose requests exceed its limits, or that
+// goes above max
+func ValidateResources(r corev1.ResourceRequirements, max corev1.ResourceList) error {
+	for _, name := range BuildResourceNames {
+		request, requested := r.Requests[name]
+		limit, limited := r.Limits[name]
+		if requested && limited && request.Cmp(limit) > 0 {
+			return fmt.Errorf("%s request %s is above its limit %s", name, request.String(), limit.String())
+		}
+		ceiling, ok := max[name]
+		if !ok {
+			continue
+		}
+		if requested && request.Cmp(ceiling) > 0 {
+			return fmt.Errorf("%s request %s is above the maximum %s (see %s)", name, request.String(), ceiling.String(), config.EnvBuildResourceMax)
+		}
+		if limited && limit.Cmp(ceiling) > 0 {
+			return fmt.Errorf("%s limit %s is above the maximum %s (see %s)", name, limit.String(), ceiling.String(), config.EnvBuildResourceMax)
+		}
+	}
+	return nil
+}
+
+// CheckResources refuses a build whose resources can't be used
+func (o *Orchestrator) CheckResources(ctx context.Context, be types.BuildEvent) error {
+	if be.Resources == nil {
+		return nil
+	}
+	_, err := o.buildResources(ctx, be)
+	return err
+}
+
+// buildResources resolves the sizing of a build pod: the defaults, overridden
+// by the tenant's, overridden by the request's (nil = unsized)
+func (o *Orchestrator) buildResources(ctx context.Context, be types.BuildEvent) (*corev1.ResourceRequirements, error) {
+	resources := corev1.ResourceRequirements{}
+	overlayResources(&resources, o.sizing.Defaults)
+	if o.sizing.Tenants != nil {
+		tenant, err := o.sizing.Tenants.BuildResources(ctx, be.ThirdPartyId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve the build resources of %s: %w", be.ThirdPartyId, err)
+		}
+		overlayResources(&resources, tenant)
+	}
+	if be.Resources != nil {
+		requests, err := resourceList(be.Resources.Requests)
+		if err != nil {
+			return nil, &InvalidResourcesError{Reason: "requests: " + err.Error()}
+		}
+		limits, err := resourceList(be.Resources.Limits)
+		if err != nil {
+			return nil, &InvalidResourcesError{Reason: "limits: " + err.Error()}
+		}
+		overlayResources(&resources, corev1.ResourceRequirements{Requests: requests, Limits: limits})
+	}
+	if err := ValidateResources(resources, o.sizing.Max); err != nil {
+		return nil, &InvalidResourcesError{Reason: err.Error()}
+	}
+	limitToMax(&resources, o.sizing.Max)
+	if len(resources.Requests) == 0 && len(resources.Limits) == 0 {
+		return nil, nil
+	}
+	return &resources, nil
+}
+
+// limitToMax limits the resources that have a maximum but no limit to the maximum
+// 🎯 WHY: An unlimited resource could use more than the maximum allows
+func limitToMax(r *corev1.ResourceRequirements, max corev1.ResourceList) {
+	for _, name := range BuildResourceNames {
+		ceiling, ok := max[name]
+		if _, limited := r.Limits[name]; !ok || limited {
+			continue
+		}
+		if r.Limits == nil {
+			r.Limits = corev1.ResourceList{}
+		}
+		r.Limits[name] = ceiling
+	}
+}
+
+// overlayResources sets the requests and limits of src on dst
+func overlayResources(dst *corev1.ResourceRequirements, src corev1.ResourceRequirements) {
+	for name, quantity := range src.Requests {
+		if dst.Requests == nil {
+			dst.Requests = corev1.ResourceList{}
+		}
+		dst.Requests[name] = quantity
+	}
+	for name, quantity := range src.Limits {
+		if dst.Limits == nil {
+			dst.Limits = corev1.ResourceList{}
+		}
+		dst.Limits[name] = quantity
+	}
+}
@@ -0,0 +1,108 @@
+package build
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"knative-lambda-builder/internal/observability"
+	"knative-lambda-builder/internal/types"
+	"knative-lambda-builder/storage"
+)
+
+// =============================================================================
+// 🧹 BUILD CONTEXT CLEANUP
+// =============================================================================
+// Every build uploads {parserId}.tar.gz to the tmp bucket. Once the registry
+// serves the image the build pushed, the context is only useful to skip the
+// packaging steps of an identical rebuild, so we either:
+//   - delete it right away (retention 0, the default)
+//   - tag it with its retention in days, for a bucket lifecycle rule to expire
+
+// RetentionTag is the object tag matched by the tmp bucket's lifecycle rules
+const RetentionTag = "knative-lambda/retention-days"
+
+// RetentionPolicy decides how long a tenant's build contexts are kept
+// (implemented by tenants.ContextRetention)
+type RetentionPolicy interface {
+	ContextRetention(ctx context.Context, thirdPartyId string) (time.Duration, error)
+}
+
+// fixedRetention applies the same retention to every tenant
+type fixedRetention time.Duration
+
+func (r fixedRetention) ContextRetention(ctx context.Context, thirdPartyId string) (time.Duration, error) {
+	return time.Duration(r), nil
+}
+
+// WithRetentionPolicy overrides the retention applied to build contexts
+// (CONTEXT_RETENTION for every tenant by default)
+func (o *Orchestrator) WithRetentionPolicy(p RetentionPolicy) *Orchestrator {
+	o.retention = p
+	return o
+}
+
+// RetentionDays rounds a retention up to whole days (lifecycle rules count in days)
+func RetentionDays(retention time.Duration) int {
+	return int(math.Ceil(retention.Hours() / 24))
+}
+
+// CleanupContext deletes or tags the context of a finished build job
+// 🎯 PURPOSE: Contexts otherwise accumulate in the tmp bucket forever
+// 📝 NOTE: Only done when the cache entry shows jobName uploaded the current
+// context (a newer build may already be reading the same key) and the
+// registry confirms the image was pushed
+func (o *Orchestrator) CleanupContext(ctx context.Context, be types.BuildEvent, jobName string) error {
+	if !o.cfg.ContextCleanupEnabled || !o.cfg.BuildCacheEnabled {
+		return nil
+	}
+
+	entry, err := o.loadCacheEntry(ctx, be)
+	if err != nil || entry == nil || entry.JobName != jobName {
+		return err
+	}
+	digest, err := o.ImageDigest(ctx, be)
+	if err != nil {
+		return fmt.Errorf("failed to confirm image digest: %w", err)
+	}
+	if digest == "" {
+		// Unmanaged registries can't be queried: keep the context
+		return nil
+	}
+
+	info, err := o.store.Head(ctx, o.cfg.S3TmpBucket, entry.ContextKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	retention, err := o.retention.ContextRetention(ctx, be.ThirdPartyId)
+	if err != nil {
+		return fmt.Errorf("failed to resolve context retention: %w", err)
+	}
+
+	action := "deleted"
+	if retention > 0 {
+		action = "tagged"
+		days := strconv.Itoa(RetentionDays(retention))
+		if err := o.store.Tag(ctx, o.cfg.S3TmpBucket, entry.ContextKey, map[string]string{RetentionTag: days}); err != nil {
+			return err
+		}
+		log.Printf("🧹 Build context s3://%s/%s tagged for expiry in %s day(s)", o.cfg.S3TmpBucket, entry.ContextKey, days)
+	} else {
+		if err := o.store.Delete(ctx, o.cfg.S3TmpBucket, entry.ContextKey); err != nil {
+			return err
+		}
+		log.Printf("🧹 Build context s3://%s/%s deleted (%d bytes)", o.cfg.S3TmpBucket, entry.ContextKey, info.Size)
+	}
+
+	observability.ContextCleanups.WithLabelValues(action).Inc()
+	observability.ContextReclaimedBytes.WithLabelValues(action).Add(float64(info.Size))
+	return nil
+}
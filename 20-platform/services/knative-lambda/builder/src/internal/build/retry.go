@@ -0,0 +1,38 @@
+package build
+
+import (
+	"math/rand"
+	"time"
+)
+
+// =============================================================================
+// 🔁 BUILD RETRIES
+// =============================================================================
+// A build job can fail for reasons that pass on their own (registry
+// throttling, a flaky base image pull). Failed builds are started again
+// (new job, retry+1) after an exponential backoff with jitter, up to
+// BuildRetries; preempted builds are requeued separately (see preemption.go)
+
+// MaxRetryBackoff caps the retry delay (before jitter)
+const MaxRetryBackoff = 10 * time.Minute
+
+// retryJitter is the fraction the retry delay is randomly shifted by, either way
+// 🎯 WHY: Builds failing together (registry outage) must not retry together
+const retryJitter = 0.2
+
+// MaxBuildRetries is how often a failed build is retried before giving up
+func (o *Orchestrator) MaxBuildRetries() int {
+	return o.cfg.BuildRetries
+}
+
+// RetryBackoff returns how long to wait before retry n (1-based)
+func (o *Orchestrator) RetryBackoff(retry int) time.Duration {
+	backoff := o.cfg.BuildRetryBackoff
+	for i := 1; i < retry && backoff < MaxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > MaxRetryBackoff {
+		backoff = MaxRetryBackoff
+	}
+	return backoff + time.Duration((rand.Float64()*2-1)*retryJitter*float64(backoff))
+}
@@ -0,0 +1,246 @@
+package build
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"knative-lambda-builder/internal/config"
+	"knative-lambda-builder/internal/types"
+	"knative-lambda-builder/registry"
+	"knative-lambda-builder/storage"
+)
+
+// =============================================================================
+// 🏷️ IMAGE REVISIONS
+// =============================================================================
+// Every build pushes a new tag, <parserId>-v<N>, instead of overwriting
+// <parserId>. Services are deployed pinned to the tag of the build that
+// deployed them, so a later build can't change what a running parser pulls,
+// and the images of earlier builds stay addressable.
+//
+// A parser's revisions (tag, build, digest) live next to its cache entry:
+// revisions/<tid>/<pid>.json
+// 📝 NOTE: Images built before revisions were pushed to the bare <parserId>
+// tag; builds that carry no tag (BuildEvent.ImageTag) still resolve to it
+
+// AnnotationImageTag carries a job's image tag, so job updates received after a
+// builder restart still deploy the image the job pushed
+const AnnotationImageTag = "knative-lambda.notifi.network/image-tag"
+
+// maxRevisions is how many revisions of a parser are remembered
+// 📝 NOTE: Older tags are left to the registry's lifecycle policy
+const maxRevisions = 50
+
+// Revision is the image one build of a parser pushed
+type Revision struct {
+	Version    int        `json:"version"`
+	Tag        string     `json:"tag"` // <parserId>-v<Version>
+	BuildId    string     `json:"buildId,omitempty"`
+	InputsHash string     `json:"inputsHash,omitempty"`
+	Digest     string     `json:"digest,omitempty"` // Set once the job pushed the image
+	CreatedAt  time.Time  `json:"createdAt"`
+	DeployedAt *time.Time `json:"deployedAt,omitempty"` // Last time the revision was deployed
+	SBOM       string     `json:"sbom,omitempty"`       // s3:// prefix of the image's SBOMs (SBOM_ENABLED)
+	Commit     string     `json:"commit,omitempty"`     // Git commit the parser was built from
+	// SHA-256 of the parser source the revision was built from (see integrity.go)
+	SourceSHA256 string `json:"sourceSha256,omitempty"`
+
+	Env map[string]string `json:"env,omitempty"` // Environment the build deployed the parser with (reused by rollbacks)
+}
+
+// RevisionTag returns the image tag of a parser's revision
+func RevisionTag(parserId string, version int) string {
+	return fmt.Sprintf("%s-v%d", parserId, version)
+}
+
+// RevisionsKey returns the S3 key of a parser's revision history
+func RevisionsKey(be types.BuildEvent) string {
+	return fmt.Sprintf("revisions/%s/%s.json", be.ThirdPartyId, be.ParserId)
+}
+
+// imageTag returns the tag a build's image is pushed to
+func imageTag(be types.BuildEvent) string {
+	if be.ImageTag != "" {
+		return be.ImageTag
+	}
+	return be.ParserId
+}
+
+// Revisions returns a parser's revisions, oldest first
+func (o *Orchestrator) Revisions(ctx context.Context, be types.BuildEvent) ([]Revision, error) {
+	raw, err := o.getSealed(ctx, RevisionsKey(be))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load image revisions: %w", err)
+	}
+	var revisions []Revision
+	if err := json.Unmarshal(raw, &revisions); err != nil {
+		return nil, fmt.Errorf("failed to decode image revisions %s: %w", RevisionsKey(be), err)
+	}
+	return revisions, nil
+}
+
+// saveRevisions writes a parser's revisions, dropping the oldest beyond maxRevisions
+func (o *Orchestrator) saveRevisions(ctx context.Context, be types.BuildEvent, revisions []Revision) error {
+	if len(revisions) > maxRevisions {
+		revisions = revisions[len(revisions)-maxRevisions:]
+	}
+	raw, err := json.Marshal(revisions)
+	if err != nil {
+		return fmt.Errorf("failed to encode image revisions: %w", err)
+	}
+	if err := o.putSealed(ctx, be.ThirdPartyId, RevisionsKey(be), raw); err != nil {
+		return fmt.Errorf("failed to save image revisions: %w", err)
+	}
+	return nil
+}
+
+// nextVersion returns the version the next revision of a parser gets
+func nextVersion(revisions []Revision) int {
+	if len(revisions) == 0 {
+		return 1
+	}
+	return revisions[len(revisions)-1].Version + 1
+}
+
+// newRevision records the next revision of a parser and returns it
+// 📝 NOTE: Serialized within a builder; two replicas starting a build of the
+// same parser at the same moment could still pick the same version
+func (o *Orchestrator) newRevision(ctx context.Context, be types.BuildEvent, inputsHash string) (Revision, error) {
+	o.revisionsMu.Lock()
+	defer o.revisionsMu.Unlock()
+
+	revisions, err := o.Revisions(ctx, be)
+	if err != nil {
+		return Revision{}, err
+	}
+	version := nextVersion(revisions)
+	revision := Revision{
+		Version:    version,
+		Tag:        RevisionTag(be.ParserId, version),
+		BuildId:    be.ID,
+		InputsHash: inputsHash,
+		CreatedAt:  time.Now().UTC(),
+		Commit:     sourceCommit(be),
+		Env:        be.Env,
+
+		SourceSHA256: be.SourceSHA256,
+	}
+	if err := o.saveRevisions(ctx, be, append(revisions, revision)); err != nil {
+		return Revision{}, err
+	}
+	return revision, nil
+}
+
+// updateRevision changes a build's revision (be.ImageTag)
+func (o *Orchestrator) updateRevision(ctx context.Context, be types.BuildEvent, change func(*Revision)) error {
+	o.revisionsMu.Lock()
+	defer o.revisionsMu.Unlock()
+
+	revisions, err := o.Revisions(ctx, be)
+	if err != nil {
+		return err
+	}
+	for i := range revisions {
+		if revisions[i].Tag == be.ImageTag {
+			change(&revisions[i])
+			return o.saveRevisions(ctx, be, revisions)
+		}
+	}
+	log.Printf("WARNING: No revision %s recorded for %s/%s", be.ImageTag, be.ThirdPartyId, be.ParserId)
+	return nil
+}
+
+// RecordDeploy notes that a build's revision was deployed
+// 🎯 WHY: Only revisions that passed the pipeline are offered for rollbacks
+func (o *Orchestrator) RecordDeploy(ctx context.Context, be types.BuildEvent) error {
+	if be.ImageTag == "" {
+		return nil // Legacy tag, not a revision
+	}
+	now := time.Now().UTC()
+	return o.updateRevision(ctx, be, func(revision *Revision) { revision.DeployedAt = &now })
+}
+
+// RevisionUnavailableError is returned for a revision that can't be deployed again
+type RevisionUnavailableError struct {
+	ThirdPartyId string
+	ParserId     string
+	Tag          string
+	Reason       string // e.g. "is not recorded"
+}
+
+func (e *RevisionUnavailableError) Error() string {
+	return fmt.Sprintf("image revision %s of %s/%s %s", e.Tag, e.ThirdPartyId, e.ParserId, e.Reason)
+}
+
+// registryQueryable reports whether the registry's API can be asked for image digests
+func (o *Orchestrator) registryQueryable() bool {
+	url := o.Registry()
+	return registry.IsECR(url) || registry.IsGAR(url) || registry.IsACR(url) ||
+		o.cfg.RegistryProvider == config.RegistryProviderHarbor
+}
+
+// CheckRevision makes sure a build's revision (be.ImageTag) can be deployed
+// again: it is recorded, was deployed before, and the registry still serves
+// its tag with the digest it was pushed with
+// Returns the digest ("" for registries that can't be queried, which are trusted)
+// 📝 NOTE: Fails with a *RevisionUnavailableError when it can't be deployed
+func (o *Orchestrator) CheckRevision(ctx context.Context, be types.BuildEvent) (string, error) {
+	unavailable := func(reason string) error {
+		return &RevisionUnavailableError{ThirdPartyId: be.ThirdPartyId, ParserId: be.ParserId, Tag: be.ImageTag, Reason: reason}
+	}
+
+	revisions, err := o.Revisions(ctx, be)
+	if err != nil {
+		return "", err
+	}
+	var revision *Revision
+	for i := range revisions {
+		if revisions[i].Tag == be.ImageTag {
+			revision = &revisions[i]
+		}
+	}
+	if revision == nil {
+		return "", unavailable("is not recorded")
+	}
+	if revision.DeployedAt == nil {
+		return "", unavailable("was never deployed")
+	}
+	if !o.registryQueryable() {
+		log.Printf("WARNING: Registry %s can't be queried, deploying %s unchecked", o.Registry(), o.ImageURI(be))
+		return "", nil
+	}
+
+	digest, err := o.ImageDigest(ctx, be)
+	if err != nil {
+		return "", fmt.Errorf("failed to check %s: %w", o.ImageURI(be), err)
+	}
+	if digest == "" {
+		return "", unavailable("is no longer in the registry")
+	}
+	if revision.Digest != "" && digest != revision.Digest {
+		return "", unavailable(fmt.Sprintf("now points to %s instead of %s", digest, revision.Digest))
+	}
+	return digest, nil
+}
+
+// RevisionEnv returns the environment a build's revision (be.ImageTag) was
+// deployed with (nil if it had none, or isn't recorded)
+func (o *Orchestrator) RevisionEnv(ctx context.Context, be types.BuildEvent) (map[string]string, error) {
+	revisions, err := o.Revisions(ctx, be)
+	if err != nil {
+		return nil, err
+	}
+	for _, revision := range revisions {
+		if revision.Tag == be.ImageTag {
+			return revision.Env, nil
+		}
+	}
+	return nil, nil
+}
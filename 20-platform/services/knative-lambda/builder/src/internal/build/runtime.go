@@ -0,0 +1,186 @@
+package build
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"knative-lambda-builder/internal/config"
+	"knative-lambda-builder/internal/types"
+	"knative-lambda-builder/storage"
+)
+
+// =============================================================================
+// 🧬 PARSER RUNTIMES
+// =============================================================================
+// A build event's runtime picks the language its parser is written in, and
+// with it the parser's source file, the wrapper templates and the base image.
+// Runtimes register themselves (see runtime_node.go, python.go, golang.go):
+// adding one takes a directory of wrapper templates under
+// templates/runtimes/<name>/ and a RegisterRuntime call describing it
+// 📝 NOTE: The build.start/rebuild schemas list the runtimes events may ask
+// for; extend their enum too
+
+// Parser runtimes
+const (
+	RuntimeNode   = "node"
+	RuntimePython = "python"
+	RuntimeGo     = "go"
+)
+
+// Runtime describes how the parsers of a runtime are built
+type Runtime struct {
+	Name      string
+	Extension string // Of the parser's source file, e.g. ".py"
+
+	// TemplateDir holds the wrapper templates, relative to TEMPLATES_DIR
+	// (runtimes/<name> by convention, "" for the Node.js templates at the top)
+	TemplateDir string
+	// Templates are rendered into the build context without their .tpl
+	// extension, e.g. main.py.tpl -> main.py
+	Templates []string
+	// Images returns the images the wrapper is built from, the Dockerfile
+	// FROM first; reproducible builds require each pinned by digest
+	Images func(cfg *config.Config) []RuntimeImage
+	// Data returns the template data of the wrapper templates
+	// (nil = types.WrapperTemplateData with the parser and base image)
+	Data func(cfg *config.Config, be types.BuildEvent) interface{}
+
+	// Source validation: CheckWith names a binary checking a source compiles,
+	// with CheckArgs; Parse checks it in-process instead (neither = no check)
+	CheckWith func(cfg *config.Config) string
+	CheckArgs func(path string) []string
+	Parse     func(path string) error
+
+	SourceDir string // Where the parser goes in the build context ("" = next to the wrapper)
+	Archive   bool   // The parser may ship as a source tarball {parserId}.tar.gz instead
+	// Optional dependency file shipped next to the parser, e.g.
+	// "requirements.txt" for {parserId}.requirements.txt ("" = none); it is
+	// packed as "parser-<name>"
+	Deps string
+	// Manifest is the wrapper template build events' dependencies are merged
+	// into, e.g. package.json.tpl ("" = the runtime takes none)
+	Manifest string
+}
+
+// RuntimeImage is an image a runtime's wrapper is built from
+type RuntimeImage struct {
+	Setting string // The environment variable configuring it, e.g. BASE_IMAGE
+	Ref     string
+}
+
+// runtimes holds the registered runtimes by name
+var runtimes = map[string]Runtime{}
+
+// RegisterRuntime makes a runtime available to build events
+// 📝 NOTE: Call it from an init function; registering a name twice panics
+func RegisterRuntime(rt Runtime) {
+	if rt.Name == "" || rt.Images == nil {
+		panic("build: runtime without a name or images")
+	}
+	if _, ok := runtimes[rt.Name]; ok {
+		panic("build: runtime " + rt.Name + " registered twice")
+	}
+	runtimes[rt.Name] = rt
+}
+
+// Runtimes lists the registered runtime names, sorted
+func Runtimes() []string {
+	names := make([]string, 0, len(runtimes))
+	for name := range runtimes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidRuntime reports whether r is a registered runtime ("" = node)
+func ValidRuntime(r string) bool {
+	_, ok := runtimes[r]
+	return r == "" || ok
+}
+
+// runtimeName returns a build's runtime, defaulting to node
+func runtimeName(be types.BuildEvent) string {
+	if be.Runtime == "" {
+		return RuntimeNode
+	}
+	return be.Runtime
+}
+
+// lookupRuntime returns a build's runtime (node for unknown ones, which
+// requests are validated against before they get here)
+func lookupRuntime(be types.BuildEvent) Runtime {
+	if rt, ok := runtimes[runtimeName(be)]; ok {
+		return rt
+	}
+	return runtimes[RuntimeNode]
+}
+
+// runtime returns how a build's parser is built
+func (o *Orchestrator) runtime(be types.BuildEvent) Runtime {
+	return lookupRuntime(be)
+}
+
+// baseImage returns the Dockerfile FROM of a runtime's wrapper
+func (o *Orchestrator) baseImage(rt Runtime) string {
+	return rt.Images(o.cfg)[0].Ref
+}
+
+// templatePath returns the mounted path of one of a runtime's templates
+func (o *Orchestrator) templatePath(rt Runtime, name string) string {
+	return filepath.Join(o.cfg.TemplatesDir, rt.TemplateDir, name)
+}
+
+// templatePaths returns the mounted paths of a runtime's templates
+func (o *Orchestrator) templatePaths(rt Runtime) []string {
+	paths := make([]string, 0, len(rt.Templates))
+	for _, name := range rt.Templates {
+		paths = append(paths, o.templatePath(rt, name))
+	}
+	return paths
+}
+
+// templateTarget returns the build context file a template renders to
+func templateTarget(name string) string {
+	return strings.TrimSuffix(name, ".tpl")
+}
+
+// wrapperData returns the template data of a build's wrapper templates
+// 📝 NOTE: Npmrc and Backend tell the Dockerfile how to reach the .npmrc
+func (o *Orchestrator) wrapperData(rt Runtime, be types.BuildEvent, backend string) interface{} {
+	if rt.Data != nil {
+		return rt.Data(o.cfg, be)
+	}
+	return types.WrapperTemplateData{ParserId: be.ParserId, BaseImage: o.baseImage(rt),
+		Npmrc: o.npmrcSecret() != "", Backend: backend}
+}
+
+// depsSourceKey returns the S3 key of a parser's (optional) dependency file,
+// e.g. acme/p1.requirements.txt ("" for runtimes without one)
+func (o *Orchestrator) depsSourceKey(be types.BuildEvent) string {
+	deps := o.runtime(be).Deps
+	if deps == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s.%s", be.ThirdPartyId, be.ParserId, deps)
+}
+
+// depsSource returns the parser's dependency file's object info (nil if it ships none)
+func (o *Orchestrator) depsSource(ctx context.Context, be types.BuildEvent) (*storage.ObjectInfo, error) {
+	key := o.depsSourceKey(be)
+	if key == "" {
+		return nil, nil
+	}
+	info, err := o.headSource(ctx, be, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat parser dependencies: %w", err)
+	}
+	return &info, nil
+}
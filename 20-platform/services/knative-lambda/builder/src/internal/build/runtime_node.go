@@ -0,0 +1,22 @@
+package build
+
+import (
+	"knative-lambda-builder/internal/config"
+)
+
+// 🟩 Node.js parsers: {parserId}.js, wrapped by index.js + package.json
+// 📝 NOTE: The default runtime; its templates stay at the top of TEMPLATES_DIR,
+// where overrides have always been looked up
+func init() {
+	RegisterRuntime(Runtime{
+		Name:      RuntimeNode,
+		Extension: ".js",
+		Templates: []string{"Dockerfile.tpl", "index.js.tpl", "package.json.tpl"},
+		Images: func(cfg *config.Config) []RuntimeImage {
+			return []RuntimeImage{{Setting: config.EnvBaseImage, Ref: cfg.BaseImage}}
+		},
+		CheckWith: func(cfg *config.Config) string { return cfg.NodeBinary },
+		CheckArgs: func(path string) []string { return []string{"--check", path} },
+		Manifest:  "package.json.tpl",
+	})
+}
@@ -0,0 +1,103 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"knative-lambda-builder/internal/k8s"
+	"knative-lambda-builder/internal/templates"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 📦 SBOMS
+// =============================================================================
+// With SBOM_ENABLED, every pushed image is inventoried by a short-lived job
+// running syft against the registry. It uploads an SPDX and a CycloneDX SBOM
+// to the tmp bucket: sboms/<tid>/<pid>/<tag>/sbom.{spdx,cdx}.json
+// The location is recorded on the build and on its image revision.
+// 📝 NOTE: The SBOM doesn't gate the deploy, a failed job only logs a warning
+
+// sbomJobPrefix distinguishes SBOM jobs from build jobs in resource events
+const sbomJobPrefix = "sbom-"
+
+// sbomPrefix is where SBOMs live in the tmp bucket
+const sbomPrefix = "sboms/"
+
+// SBOMJobName returns a unique, DNS-compatible name for an SBOM job
+func SBOMJobName(be types.BuildEvent) string {
+	name := fmt.Sprintf("%s%s-%s-%d", sbomJobPrefix, be.ThirdPartyId, be.ParserId, time.Now().Unix())
+	name = strings.ToLower(name)
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-.")
+	}
+	return name
+}
+
+// IsSBOMJob reports whether a job was launched by RunSBOM
+func IsSBOMJob(jobName string) bool {
+	return strings.HasPrefix(jobName, sbomJobPrefix)
+}
+
+// SBOMKey returns the S3 prefix of the SBOMs of a build's image
+func SBOMKey(be types.BuildEvent) string {
+	return fmt.Sprintf("%s%s/%s/%s/", sbomPrefix, be.ThirdPartyId, be.ParserId, imageTag(be))
+}
+
+// SBOMLocation returns where the SBOMs of a build's image are uploaded
+func (o *Orchestrator) SBOMLocation(be types.BuildEvent) string {
+	return fmt.Sprintf("s3://%s/%s", o.cfg.S3TmpBucket, SBOMKey(be))
+}
+
+// RunSBOM launches the SBOM job for a freshly pushed image
+// 📝 NOTE: Returns "" when SBOMs are disabled
+func (o *Orchestrator) RunSBOM(ctx context.Context, be types.BuildEvent) (string, error) {
+	if !o.cfg.SBOMEnabled {
+		return "", nil
+	}
+	keyID, err := o.encryptor.KeyID(ctx, be.ThirdPartyId)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up the KMS key of %s: %w", be.ThirdPartyId, err)
+	}
+
+	data := types.SBOMJobTemplateData{
+		Name:         SBOMJobName(be),
+		ThirdPartyId: be.ThirdPartyId,
+		ParserId:     be.ParserId,
+		Image:        o.ImageURI(be),
+		Tag:          imageTag(be),
+		SyftImage:    o.cfg.SyftImage,
+		Destination:  o.SBOMLocation(be),
+		Region:       o.awsClient.Config.Region,
+		KMSKeyID:     keyID,
+	}
+	manifest, err := templates.RenderFile(o.cfg.SBOMJobTemplatePath, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to render SBOM job template: %w", err)
+	}
+	objects, err := k8s.DecodeManifests(manifest)
+	if err != nil {
+		return "", err
+	}
+	for _, obj := range objects {
+		k8s.SetLabels(obj, ResourceLabels(be))
+		if err := o.executor.Launch(ctx, obj); err != nil {
+			return "", fmt.Errorf("failed to launch SBOM job: %w", err)
+		}
+	}
+
+	log.Printf("📦 SBOM job %s created (image: %s)", data.Name, data.Image)
+	return data.Name, nil
+}
+
+// RecordSBOM notes the SBOM location on a build's image revision
+func (o *Orchestrator) RecordSBOM(ctx context.Context, be types.BuildEvent) error {
+	if be.ImageTag == "" {
+		return nil // Legacy tag, not a revision
+	}
+	location := o.SBOMLocation(be)
+	return o.updateRevision(ctx, be, func(revision *Revision) { revision.SBOM = location })
+}
@@ -0,0 +1,91 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"knative-lambda-builder/internal/config"
+	"knative-lambda-builder/internal/types"
+	"knative-lambda-builder/registry"
+)
+
+// =============================================================================
+// 🛡️ VULNERABILITY SCAN GATE
+// =============================================================================
+// ECR scans every pushed image (ScanOnPush). With SCAN_MAX_CRITICAL set, a
+// parser is only deployed once its image's scan completed with at most that
+// many critical findings; otherwise the deploy is blocked.
+// 📝 NOTE: Other registries don't scan, their images are deployed unchecked
+
+// scanPollInterval is how often a pending scan is checked
+var scanPollInterval = 10 * time.Second
+
+// severityCritical is the severity counted against SCAN_MAX_CRITICAL
+const severityCritical = "CRITICAL"
+
+// ScanBlockedError is returned when an image's scan blocks its deploy
+type ScanBlockedError struct {
+	Image  string
+	Scan   registry.ScanResult // The last scan seen
+	Reason string              // e.g. "3 critical findings (at most 0 allowed)"
+}
+
+func (e *ScanBlockedError) Error() string {
+	return fmt.Sprintf("deploy of %s blocked by its vulnerability scan: %s", e.Image, e.Reason)
+}
+
+// CheckScan waits for the vulnerability scan of a build's image and checks it
+// against SCAN_MAX_CRITICAL
+// Returns the scan (nil when the gate is off or the registry doesn't scan)
+// 📝 NOTE: Fails with a *ScanBlockedError when the image may not be deployed:
+// too many critical findings, a failed scan, or none within SCAN_TIMEOUT
+func (o *Orchestrator) CheckScan(ctx context.Context, be types.BuildEvent) (*registry.ScanResult, error) {
+	if o.cfg.ScanMaxCritical < 0 {
+		return nil, nil
+	}
+	image := o.ImageURI(be)
+	if !registry.IsECR(o.Registry()) && o.cfg.RegistryProvider != config.RegistryProviderHarbor {
+		log.Printf("WARNING: Registry %s doesn't scan images, deploying %s unchecked", o.Registry(), image)
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(o.registryContext(ctx, be), o.cfg.ScanTimeout)
+	defer cancel()
+	scan := &registry.ScanResult{Status: registry.ScanPending}
+	for {
+		latest, err := o.registry.ImageScan(ctx, o.RepositoryName(be.ThirdPartyId), imageTag(be))
+		if err != nil {
+			log.Printf("WARNING: Failed to check the scan of %s: %v", image, err)
+		} else if latest != nil {
+			scan = latest
+		}
+
+		switch scan.Status {
+		case registry.ScanComplete, registry.ScanActive:
+			critical := scan.Findings[severityCritical]
+			if critical > o.cfg.ScanMaxCritical {
+				return scan, &ScanBlockedError{Image: image, Scan: *scan,
+					Reason: fmt.Sprintf("%d critical findings (at most %d allowed)", critical, o.cfg.ScanMaxCritical)}
+			}
+			log.Printf("🛡️ Scan of %s passed: %v", image, scan.Findings)
+			return scan, nil
+		case registry.ScanUnsupported:
+			log.Printf("WARNING: %s can't be scanned (%s), deploying it unchecked", image, scan.Description)
+			return scan, nil
+		case registry.ScanPending, registry.ScanInProgress:
+			// Keep waiting
+		default:
+			return scan, &ScanBlockedError{Image: image, Scan: *scan,
+				Reason: fmt.Sprintf("scan %s: %s", scan.Status, scan.Description)}
+		}
+
+		select {
+		case <-ctx.Done():
+			return scan, &ScanBlockedError{Image: image, Scan: *scan,
+				Reason: fmt.Sprintf("scan still %s after %s", scan.Status, o.cfg.ScanTimeout)}
+		case <-time.After(scanPollInterval):
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🔗 SHAREABLE BUILD ARTIFACTS
+// =============================================================================
+// What a share link exposes, read from the tmp bucket:
+//   - logs.txt        snapshot of the build job's logs, taken when the link
+//                     is minted (pods don't outlive the job's TTL)
+//   - inputs.json     the inputs record (reproducible builds)
+//   - context.tar.gz  the build context Kaniko built from
+// 📝 NOTE: The inputs record and context are per parser, so after a newer
+// build they show that build's
+
+// Shareable artifact names
+const (
+	ArtifactLogs    = "logs.txt"
+	ArtifactInputs  = "inputs.json"
+	ArtifactContext = "context.tar.gz"
+)
+
+// ShareableArtifacts lists the artifacts a share link can expose
+var ShareableArtifacts = []string{ArtifactLogs, ArtifactInputs, ArtifactContext}
+
+// shareLogLines caps the shared log snapshot
+const shareLogLines = 2000
+
+// ShareLogsKey returns the S3 key of a job's shared log snapshot
+func ShareLogsKey(be types.BuildEvent, jobName string) string {
+	return fmt.Sprintf("shares/%s/%s/%s.log", be.ThirdPartyId, be.ParserId, jobName)
+}
+
+// LatestJobName returns the job of a parser's last build ("" if unknown)
+// 📝 NOTE: Read from the cache entry, so it needs BUILD_CACHE_ENABLED
+func (o *Orchestrator) LatestJobName(ctx context.Context, be types.BuildEvent) (string, error) {
+	entry, err := o.loadCacheEntry(ctx, be)
+	if err != nil || entry == nil {
+		return "", err
+	}
+	return entry.JobName, nil
+}
+
+// SnapshotLogs stores the tail of a build job's logs for share links
+// 📝 NOTE: Encrypted with the tenant's key like the other artifacts
+func (o *Orchestrator) SnapshotLogs(ctx context.Context, be types.BuildEvent, jobName string) error {
+	logs, err := o.executor.Logs(ctx, o.cfg.KubernetesNamespace, jobName, shareLogLines)
+	if err != nil {
+		return err
+	}
+	key := ShareLogsKey(be, jobName)
+	if err := o.putSealed(ctx, be.ThirdPartyId, key, []byte(logs)); err != nil {
+		return fmt.Errorf("failed to store log snapshot: %w", err)
+	}
+	log.Printf("🔗 Stored log snapshot of %s at s3://%s/%s", jobName, o.cfg.S3TmpBucket, key)
+	return nil
+}
+
+// OpenArtifact opens a shareable artifact of a build; callers must close it
+// Returns an error wrapping storage.ErrNotFound when the artifact doesn't exist
+func (o *Orchestrator) OpenArtifact(ctx context.Context, be types.BuildEvent, jobName, name string) (io.ReadCloser, error) {
+	switch name {
+	case ArtifactLogs, ArtifactInputs:
+		key := ShareLogsKey(be, jobName)
+		if name == ArtifactInputs {
+			key = InputsKey(be)
+		}
+		content, err := o.getSealed(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(bytes.NewReader(content)), nil
+	case ArtifactContext:
+		return o.openContext(ctx, be)
+	}
+	return nil, fmt.Errorf("unknown artifact %q", name)
+}
@@ -0,0 +1,86 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"knative-lambda-builder/internal/config"
+	"knative-lambda-builder/internal/types"
+	"knative-lambda-builder/storage"
+)
+
+// =============================================================================
+// 🔑 TENANT SOURCE ROLES
+// =============================================================================
+// Parser sources are read from the platform's bucket with the builder's own
+// credentials, unless the tenant's record names an IAM role to read them as
+// (and maybe its own bucket, laid out the same way)
+// 🎯 WHY: Tenants keep their buckets private to their account; the builder
+// is trusted by one role per tenant instead of granted access to every bucket
+// 📝 NOTE: Only S3 sources; roles are ignored with SOURCE_URI=gs:// or az://
+
+// SourceAccessPolicy decides where a tenant's sources are read from
+// (implemented by tenants.SourceAccess)
+type SourceAccessPolicy interface {
+	// SourceAccess returns the tenant's bucket and the role reading it ("" = the defaults)
+	SourceAccess(ctx context.Context, thirdPartyId string) (bucket, roleARN string, err error)
+}
+
+// WithSourceAccessPolicy sets where tenants' sources are read from
+// (the platform's bucket, as the builder, by default)
+func (o *Orchestrator) WithSourceAccessPolicy(p SourceAccessPolicy) *Orchestrator {
+	o.sourceAccess = p
+	return o
+}
+
+// WithSourceRoles sets how sources are read as a tenant's role
+// 🧪 TESTING: A fake store per role
+func (o *Orchestrator) WithSourceRoles(storeFor func(roleARN string) storage.SourceStore) *Orchestrator {
+	o.sourceRoles = storeFor
+	return o
+}
+
+// tenantSources returns the store and bucket a build's sources are read from
+func (o *Orchestrator) tenantSources(ctx context.Context, be types.BuildEvent) (storage.SourceStore, string, error) {
+	if o.sourceAccess == nil || o.sourceBackend != config.SourceBackendS3 {
+		return o.sources, o.sourceBucket, nil
+	}
+	bucket, roleARN, err := o.sourceAccess.SourceAccess(ctx, be.ThirdPartyId)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to resolve the source access of %s: %w", be.ThirdPartyId, err)
+	}
+	if bucket == "" {
+		bucket = o.sourceBucket
+	}
+	if roleARN == "" {
+		return o.sources, bucket, nil
+	}
+	if o.sourceRoles == nil {
+		return nil, "", fmt.Errorf("failed to assume source role %s: no STS client", roleARN)
+	}
+	// 📝 One store per role, so its credentials are cached across builds
+	if store, ok := o.sourceRoleStores.Load(roleARN); ok {
+		return store.(storage.SourceStore), bucket, nil
+	}
+	store, _ := o.sourceRoleStores.LoadOrStore(roleARN, o.sourceRoles(roleARN))
+	return store.(storage.SourceStore), bucket, nil
+}
+
+// headSource returns the object info of one of a build's sources
+func (o *Orchestrator) headSource(ctx context.Context, be types.BuildEvent, key string) (storage.ObjectInfo, error) {
+	sources, bucket, err := o.tenantSources(ctx, be)
+	if err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	return sources.Head(ctx, bucket, key)
+}
+
+// getSource opens one of a build's sources
+func (o *Orchestrator) getSource(ctx context.Context, be types.BuildEvent, key string) (io.ReadCloser, error) {
+	sources, bucket, err := o.tenantSources(ctx, be)
+	if err != nil {
+		return nil, err
+	}
+	return sources.Get(ctx, bucket, key)
+}
@@ -0,0 +1,108 @@
+package build
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"knative-lambda-builder/internal/k8s"
+	"knative-lambda-builder/internal/templates"
+	"knative-lambda-builder/internal/types"
+	"knative-lambda-builder/storage"
+)
+
+// =============================================================================
+// 🧪 PARSER TESTS
+// =============================================================================
+// Tenants may ship {parserId}.test.js next to their parser. It is packed into
+// the image with the parser, and once Kaniko pushed the image a short-lived
+// job runs `node --test` in it. The parser is only deployed if it passes.
+
+// testJobPrefix distinguishes test jobs from build jobs in resource events
+const testJobPrefix = "test-"
+
+// testReportLines is how much of a test job's output is kept as its report
+const testReportLines = 200
+
+// TestSourceKey returns the S3 key of a parser's (optional) test file
+func TestSourceKey(be types.BuildEvent) string {
+	return fmt.Sprintf("%s/%s.test.js", be.ThirdPartyId, be.ParserId)
+}
+
+// TestJobName returns a unique, DNS-compatible name for a test job
+func TestJobName(be types.BuildEvent) string {
+	name := fmt.Sprintf("%s%s-%s-%d", testJobPrefix, be.ThirdPartyId, be.ParserId, time.Now().Unix())
+	name = strings.ToLower(name)
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-.")
+	}
+	return name
+}
+
+// IsTestJob reports whether a job was launched by RunParserTests
+func IsTestJob(jobName string) bool {
+	return strings.HasPrefix(jobName, testJobPrefix)
+}
+
+// testSource returns the test file's object info (nil if the parser ships no tests)
+// 📝 NOTE: Only Node.js parsers are tested
+func (o *Orchestrator) testSource(ctx context.Context, be types.BuildEvent) (*storage.ObjectInfo, error) {
+	if runtimeName(be) != RuntimeNode {
+		return nil, nil
+	}
+	info, err := o.headSource(ctx, be, TestSourceKey(be))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat parser tests: %w", err)
+	}
+	return &info, nil
+}
+
+// RunParserTests launches the test job for a freshly built image
+// 📝 NOTE: Returns "" when there is nothing to run (no test file, or tests disabled)
+func (o *Orchestrator) RunParserTests(ctx context.Context, be types.BuildEvent) (string, error) {
+	if !o.cfg.ParserTestsEnabled {
+		return "", nil
+	}
+	tests, err := o.testSource(ctx, be)
+	if err != nil || tests == nil {
+		return "", err
+	}
+
+	data := types.TestJobTemplateData{
+		Name:           TestJobName(be),
+		ThirdPartyId:   be.ThirdPartyId,
+		ParserId:       be.ParserId,
+		Image:          o.ImageURI(be),
+		Tag:            imageTag(be),
+		TestFile:       be.ParserId + ".test.js",
+		TimeoutSeconds: int64(o.cfg.ParserTestTimeout.Seconds()),
+	}
+	manifest, err := templates.RenderFile(o.cfg.TestJobTemplatePath, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to render test job template: %w", err)
+	}
+	objects, err := k8s.DecodeManifests(manifest)
+	if err != nil {
+		return "", err
+	}
+	for _, obj := range objects {
+		k8s.SetLabels(obj, ResourceLabels(be))
+		if err := o.executor.Launch(ctx, obj); err != nil {
+			return "", fmt.Errorf("failed to launch test job: %w", err)
+		}
+	}
+
+	log.Printf("🧪 Test job %s created (image: %s)", data.Name, data.Image)
+	return data.Name, nil
+}
+
+// TestReport returns the output of a test job (the tail of its pod's logs)
+func (o *Orchestrator) TestReport(ctx context.Context, jobName string) (string, error) {
+	return o.executor.Logs(ctx, o.cfg.KubernetesNamespace, jobName, testReportLines)
+}
@@ -0,0 +1,49 @@
+package build
+
+import (
+	"context"
+	"time"
+
+	"knative-lambda-builder/execution"
+)
+
+// =============================================================================
+// ⏱️ BUILD TIMEOUTS
+// =============================================================================
+// Build jobs get activeDeadlineSeconds (BuildTimeout), so Kubernetes fails a
+// build that runs too long. The builder also reaps jobs still unfinished well
+// past the deadline (stuck, or whose failure never reached the builder)
+
+// staleGrace is how long past BuildTimeout a job may stay unfinished before
+// it is reaped
+// 🎯 WHY: Leaves the job controller time to enforce the deadline itself
+const staleGrace = 5 * time.Minute
+
+// BuildTimeout is how long a build job may run (0 = no deadline)
+func (o *Orchestrator) BuildTimeout() time.Duration {
+	return o.cfg.BuildTimeout
+}
+
+// StaleBuilds returns the unfinished build jobs created more than
+// BuildTimeout plus a grace period before now (none without a timeout)
+func (o *Orchestrator) StaleBuilds(ctx context.Context, now time.Time) ([]execution.BuildJob, error) {
+	if o.cfg.BuildTimeout <= 0 {
+		return nil, nil
+	}
+	jobs, err := o.executor.Jobs(ctx, o.cfg.KubernetesNamespace)
+	if err != nil {
+		return nil, err
+	}
+	var stale []execution.BuildJob
+	for _, job := range jobs {
+		if !job.Test && !job.SBOM && !job.Finished && now.Sub(job.CreatedAt) > o.cfg.BuildTimeout+staleGrace {
+			stale = append(stale, job)
+		}
+	}
+	return stale, nil
+}
+
+// DeleteBuildJob deletes a build job and its pods
+func (o *Orchestrator) DeleteBuildJob(ctx context.Context, jobName string) error {
+	return o.executor.DeleteJob(ctx, o.cfg.KubernetesNamespace, jobName)
+}
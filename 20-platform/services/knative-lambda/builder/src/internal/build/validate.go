@@ -0,0 +1,105 @@
+package build
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// =============================================================================
+// 🔎 SOURCE VALIDATION
+// =============================================================================
+// Before anything is built, the downloaded parser (and its tests) must parse:
+// `node --check` runs on each file, and a syntax error fails the build right
+// away with node's diagnostics. Otherwise it only surfaces once the deployed
+// container crashes on startup.
+// 📝 NOTE: Skipped with a warning when the builder has no node binary
+
+// sourceCheckTimeout bounds a single `node --check`
+const sourceCheckTimeout = 30 * time.Second
+
+// maxDiagnosticsBytes caps the node output kept in the error
+const maxDiagnosticsBytes = 1 << 10
+
+// SourceInvalidError is returned when a parser's source doesn't compile
+type SourceInvalidError struct {
+	File        string // e.g. "p1.js"
+	Diagnostics string // node's message, e.g. "p1.js:3\n}\n^\n\nSyntaxError: Unexpected token '}'"
+}
+
+func (e *SourceInvalidError) Error() string {
+	return fmt.Sprintf("parser source %s doesn't compile: %s", e.File, e.Diagnostics)
+}
+
+// checkSource checks that source files in dir compile (`node --check` for
+// Node.js, Python's parser for Python, go/parser for Go)
+func (o *Orchestrator) checkSource(ctx context.Context, dir string, rt Runtime, files ...string) error {
+	if !o.cfg.SourceValidationEnabled {
+		return nil
+	}
+	for _, file := range files {
+		if rt.Parse != nil {
+			if err := rt.Parse(filepath.Join(dir, file)); err != nil {
+				return &SourceInvalidError{File: file, Diagnostics: diagnostics(err.Error(), dir)}
+			}
+			continue
+		}
+		if rt.CheckWith == nil {
+			return nil
+		}
+		checkWith := rt.CheckWith(o.cfg)
+		checkCtx, cancel := context.WithTimeout(ctx, sourceCheckTimeout)
+		output, err := exec.CommandContext(checkCtx, checkWith, rt.CheckArgs(filepath.Join(dir, file))...).CombinedOutput()
+		timedOut := checkCtx.Err() != nil
+		cancel()
+
+		var exitErr *exec.ExitError
+		switch {
+		case err == nil:
+			continue
+		case errors.Is(err, exec.ErrNotFound):
+			log.Printf("WARNING: %s not found, building %s without checking it", checkWith, file)
+			return nil
+		case errors.As(err, &exitErr) && !timedOut:
+			return &SourceInvalidError{File: file, Diagnostics: diagnostics(string(output), dir)}
+		default:
+			return fmt.Errorf("failed to check %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// diagnostics keeps what node says about the source: the location relative to
+// the build context and the error, without node's own stack trace
+func diagnostics(output, dir string) string {
+	output = strings.ReplaceAll(output, dir+string(filepath.Separator), "")
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "at ") || strings.HasPrefix(trimmed, "Node.js v") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	kept := strings.TrimSpace(strings.Join(lines, "\n"))
+	if len(kept) > maxDiagnosticsBytes {
+		kept = kept[:maxDiagnosticsBytes] + "…"
+	}
+	return kept
+}
+
+// IsValidationError reports whether a build was refused for its sources
+// (a parser that doesn't compile, a custom Dockerfile breaking the policy, a
+// source without the requested checksum, or a context breaking the context limits)
+func IsValidationError(err error) bool {
+	var invalid *SourceInvalidError
+	var rejected *DockerfileRejectedError
+	var mismatch *SourceChecksumError
+	var limits *ContextRejectedError
+	return errors.As(err, &invalid) || errors.As(err, &rejected) || errors.As(err, &mismatch) || errors.As(err, &limits)
+}
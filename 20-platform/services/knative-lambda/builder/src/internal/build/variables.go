@@ -0,0 +1,132 @@
+package build
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🔧 BUILD ARGS AND PARSER ENVIRONMENT
+// =============================================================================
+// A build event may carry buildArgs, passed to the build (Kaniko --build-arg,
+// BuildKit --opt build-arg:), and env, set on the deployed parser container.
+// Both are maps of variable names to values, checked before the build is
+// accepted.
+// 📝 NOTE: Build args change the image, so they are part of the build cache
+// key; env doesn't, a new env is only a redeploy
+
+// Limits on a build event's variables
+const (
+	maxVariables     = 64
+	maxVariableBytes = 4 << 10
+)
+
+// variableName is what a build arg or env var may be called
+var variableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// reservedBuildArgs are set by the builder itself
+var reservedBuildArgs = map[string]bool{
+	"SOURCE_DATE_EPOCH": true, // Reproducible builds
+}
+
+// buildKitSyntaxArg makes BuildKit run the frontend image it names, like a
+// "# syntax=" directive (refused in custom Dockerfiles)
+const buildKitSyntaxArg = "BUILDKIT_SYNTAX"
+
+// reservedEnv are set by the builder, the image or Knative
+var reservedEnv = map[string]bool{
+	"PORT":            true,
+	"NODE_PATH":       true,
+	"K_SERVICE":       true,
+	"K_CONFIGURATION": true,
+	"K_REVISION":      true,
+}
+
+// InvalidVariablesError is returned for a build whose buildArgs or env can't be used
+type InvalidVariablesError struct {
+	Field  string // buildArgs or env
+	Name   string // The offending variable ("" when there are too many)
+	Reason string
+}
+
+func (e *InvalidVariablesError) Error() string {
+	if e.Name == "" {
+		return fmt.Sprintf("invalid %s: %s", e.Field, e.Reason)
+	}
+	return fmt.Sprintf("invalid %s %q: %s", e.Field, e.Name, e.Reason)
+}
+
+// InvalidRequest marks the error as the requester's fault (API: 400)
+func (e *InvalidVariablesError) InvalidRequest() bool {
+	return true
+}
+
+// CheckVariables refuses a build whose buildArgs or env can't be used
+func CheckVariables(be types.BuildEvent) error {
+	if err := checkVariables("buildArgs", be.BuildArgs, reservedBuildArgs); err != nil {
+		return err
+	}
+	if _, ok := be.BuildArgs[buildKitSyntaxArg]; ok {
+		return &InvalidVariablesError{Field: "buildArgs", Name: buildKitSyntaxArg, Reason: "picks the BuildKit frontend, like a syntax directive"}
+	}
+	for name, value := range be.BuildArgs {
+		// 📝 NOTE: BuildKit's build args are passed on a shell command line
+		if strings.ContainsAny(value, "\r\n") {
+			return &InvalidVariablesError{Field: "buildArgs", Name: name, Reason: "values can't span several lines"}
+		}
+	}
+	return checkVariables("env", be.Env, reservedEnv)
+}
+
+// checkVariables checks the names and sizes of a map of variables
+func checkVariables(field string, variables map[string]string, reserved map[string]bool) error {
+	if len(variables) > maxVariables {
+		return &InvalidVariablesError{Field: field, Reason: fmt.Sprintf("at most %d variables", maxVariables)}
+	}
+	for name, value := range variables {
+		switch {
+		case !variableName.MatchString(name):
+			return &InvalidVariablesError{Field: field, Name: name, Reason: "names are letters, digits and underscores, not starting with a digit"}
+		case reserved[name]:
+			return &InvalidVariablesError{Field: field, Name: name, Reason: "set by the builder"}
+		case len(value) > maxVariableBytes:
+			return &InvalidVariablesError{Field: field, Name: name, Reason: fmt.Sprintf("longer than %d bytes", maxVariableBytes)}
+		}
+	}
+	return nil
+}
+
+// BuildArgs returns a build's build args sorted by name
+// 🎯 WHY: Same args, same job manifest and build cache key
+func BuildArgs(be types.BuildEvent) []types.BuildArg {
+	var args []types.BuildArg
+	for _, name := range sortedNames(be.BuildArgs) {
+		args = append(args, types.BuildArg{Name: name, Value: be.BuildArgs[name]})
+	}
+	return args
+}
+
+// EnvVars returns the environment of a build's parser container sorted by name
+func EnvVars(be types.BuildEvent) []corev1.EnvVar {
+	var env []corev1.EnvVar
+	for _, name := range sortedNames(be.Env) {
+		env = append(env, corev1.EnvVar{Name: name, Value: be.Env[name]})
+	}
+	return env
+}
+
+// sortedNames returns the names of a map of variables in order
+func sortedNames(variables map[string]string) []string {
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
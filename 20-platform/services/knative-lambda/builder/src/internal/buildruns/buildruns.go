@@ -0,0 +1,397 @@
+package buildruns
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/util/retry"
+
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🏃 BUILD RUNS
+// =============================================================================
+// A BuildRun (buildruns.knative-lambda.notifi.network) is a build as a
+// Kubernetes object: its spec is the build request (a build.start payload),
+// its status where the build is (phase, conditions, job, image, timestamps)
+// 🎯 WHY: kubectl shows every build and its state, builds can be requested by
+// creating a BuildRun, and a restarted builder finds the request of a job it
+// didn't create
+// 📝 NOTE: The BuildRun of a build is named by its build id; builds whose id
+// isn't a valid object name have none
+// 🤝 Pending BuildRuns are the replicas' shared build queue: the replica
+// holding one (status.owner) heartbeats it while a worker starts its build,
+// and one left without a heartbeat past the visibility timeout is taken over
+// by a replica with an idle worker (see Heartbeat and Stale)
+// 🔐 The values of env and buildArgs and the inline source's content are
+// sealed with the tenant's key (spec.sealed): the spec only shows their names
+// 💡 EXAMPLE: kubectl get buildruns -n knative-lambda -l knative-lambda.notifi.network/parser-id=p1
+
+// Resource is the BuildRun resource
+var Resource = schema.GroupVersionResource{Group: "knative-lambda.notifi.network", Version: "v1alpha1", Resource: "buildruns"}
+
+// Kind of BuildRun objects
+const Kind = "BuildRun"
+
+// Phases of a BuildRun
+const (
+	PhasePending   = "Pending"   // Accepted, waiting for its job
+	PhaseBuilding  = "Building"  // Job created (or image found in the build cache)
+	PhaseRetrying  = "Retrying"  // Job failed, retried after a backoff
+	PhasePushed    = "Pushed"    // Image pushed, being tested and deployed
+	PhaseSucceeded = "Succeeded" // Deployed
+	PhaseFailed    = "Failed"    // Failed for good (or refused)
+)
+
+// ConditionSucceeded is Unknown while the build runs, then True or False
+const ConditionSucceeded = "Succeeded"
+
+// BuildRun is a build as a Kubernetes object
+type BuildRun struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Spec   Spec   `json:"spec"`
+	Status Status `json:"status,omitempty"`
+}
+
+// Spec is the build request of a BuildRun
+type Spec struct {
+	types.BuildEvent `json:",inline"`
+
+	// The request's secret values (see sealedValues), sealed with the
+	// tenant's key and base64-encoded; set on the BuildRuns the builder creates
+	Sealed string `json:"sealed,omitempty"`
+}
+
+// sealedValues are the values of a build request kept out of a BuildRun's spec
+// 🎯 WHY: Objects are stored in etcd in plaintext; these values are
+// encrypted with the tenant's key everywhere else
+type sealedValues struct {
+	Env           map[string]string `json:"env,omitempty"`
+	BuildArgs     map[string]string `json:"buildArgs,omitempty"`
+	InlineContent string            `json:"inlineContent,omitempty"`
+}
+
+// Sealer encrypts values per tenant (implemented by encryption.TenantKeys)
+// 📝 NOTE: Seal returns the plaintext for tenants without a key, Open returns
+// values that aren't sealed as is
+type Sealer interface {
+	Seal(ctx context.Context, thirdPartyId string, plaintext []byte) ([]byte, error)
+	Open(ctx context.Context, data []byte) ([]byte, error)
+}
+
+// noSealing keeps values in plaintext (no tenant registry configured)
+type noSealing struct{}
+
+func (noSealing) Seal(_ context.Context, _ string, plaintext []byte) ([]byte, error) {
+	return plaintext, nil
+}
+func (noSealing) Open(_ context.Context, data []byte) ([]byte, error) { return data, nil }
+
+// redact splits a build request into its spec without secret values (names
+// kept, values emptied) and those values
+func redact(be types.BuildEvent) (types.BuildEvent, sealedValues) {
+	values := sealedValues{Env: be.Env, BuildArgs: be.BuildArgs}
+	be.Env = redactedValues(be.Env)
+	be.BuildArgs = redactedValues(be.BuildArgs)
+	if be.Source != nil && be.Source.Inline != nil {
+		values.InlineContent = be.Source.Inline.Content
+		inline := *be.Source.Inline
+		inline.Content = ""
+		be.Source = &types.BuildSource{Git: be.Source.Git, Inline: &inline}
+	}
+	return be, values
+}
+
+// redactedValues returns the names of variables, with empty values
+func redactedValues(variables map[string]string) map[string]string {
+	if variables == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(variables))
+	for name := range variables {
+		redacted[name] = ""
+	}
+	return redacted
+}
+
+// restore puts the values redact took out back into a request
+func restore(be types.BuildEvent, values sealedValues) types.BuildEvent {
+	be.Env = values.Env
+	be.BuildArgs = values.BuildArgs
+	if be.Source != nil && be.Source.Inline != nil {
+		inline := *be.Source.Inline
+		inline.Content = values.InlineContent
+		be.Source = &types.BuildSource{Git: be.Source.Git, Inline: &inline}
+	}
+	return be
+}
+
+// Status is where a build is
+type Status struct {
+	Phase       string             `json:"phase,omitempty"`
+	Conditions  []metav1.Condition `json:"conditions,omitempty"`
+	JobName     string             `json:"jobName,omitempty"`
+	Image       string             `json:"image,omitempty"`
+	ImageTag    string             `json:"imageTag,omitempty"` // The image revision, e.g. "p1-v3"
+	ImageDigest string             `json:"imageDigest,omitempty"`
+	DeployMode  string             `json:"deployMode,omitempty"`
+	Retry       int                `json:"retry,omitempty"`
+	Stage       string             `json:"stage,omitempty"` // Failed: validate, build, test, scan or deploy
+	Message     string             `json:"message,omitempty"`
+	StartedAt   *metav1.Time       `json:"startedAt,omitempty"`
+	CompletedAt *metav1.Time       `json:"completedAt,omitempty"`
+	Owner       string             `json:"owner,omitempty"`       // Replica holding the build until its job exists
+	HeartbeatAt *metav1.Time       `json:"heartbeatAt,omitempty"` // When the owner last renewed its hold
+}
+
+// Finished reports whether the build succeeded or failed for good
+func (s Status) Finished() bool {
+	return s.Phase == PhaseSucceeded || s.Phase == PhaseFailed
+}
+
+// SetPhase moves the build to a phase, with the Succeeded condition following it
+func (s *Status) SetPhase(phase, message string) {
+	s.Phase = phase
+	s.Message = message
+	condition := metav1.Condition{Type: ConditionSucceeded, Status: metav1.ConditionUnknown, Reason: phase, Message: message}
+	switch phase {
+	case PhaseSucceeded:
+		condition.Status = metav1.ConditionTrue
+	case PhaseFailed:
+		condition.Status = metav1.ConditionFalse
+	}
+	meta.SetStatusCondition(&s.Conditions, condition)
+	if s.Finished() && s.CompletedAt == nil {
+		now := metav1.Now()
+		s.CompletedAt = &now
+	}
+}
+
+// Hold records a replica as the build's owner, as of now
+func (s *Status) Hold(owner string) {
+	now := metav1.Now()
+	s.Owner = owner
+	s.HeartbeatAt = &now
+}
+
+// Stale reports whether a Pending build's owner missed its heartbeats for
+// longer than visibility, so another replica may take it over
+func (s Status) Stale(visibility time.Duration, now time.Time) bool {
+	return s.Phase == PhasePending && s.Owner != "" && s.HeartbeatAt != nil && now.Sub(s.HeartbeatAt.Time) > visibility
+}
+
+// Name returns the name of the BuildRun of a build id ("" = it can't name one)
+func Name(buildId string) string {
+	if buildId == "" || len(validation.IsDNS1123Subdomain(buildId)) > 0 {
+		return ""
+	}
+	return buildId
+}
+
+// FromUnstructured decodes a BuildRun read with the dynamic client
+func FromUnstructured(obj *unstructured.Unstructured) (*BuildRun, error) {
+	var run BuildRun
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &run); err != nil {
+		return nil, fmt.Errorf("failed to decode BuildRun %s: %w", obj.GetName(), err)
+	}
+	return &run, nil
+}
+
+// toUnstructured encodes a BuildRun for the dynamic client
+func toUnstructured(run *BuildRun) (*unstructured.Unstructured, error) {
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(run)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode BuildRun %s: %w", run.Name, err)
+	}
+	return &unstructured.Unstructured{Object: obj}, nil
+}
+
+// Store reads and writes the BuildRuns of a namespace
+type Store struct {
+	client    dynamic.Interface
+	namespace string
+	sealer    Sealer
+}
+
+// NewStore creates a BuildRun store
+func NewStore(client dynamic.Interface, namespace string) *Store {
+	return &Store{client: client, namespace: namespace, sealer: noSealing{}}
+}
+
+// WithSealer seals the secret values of the requests with their tenant's key
+func (s *Store) WithSealer(sealer Sealer) *Store {
+	s.sealer = sealer
+	return s
+}
+
+// Namespace returns where the BuildRuns live
+func (s *Store) Namespace() string {
+	return s.namespace
+}
+
+// InformerFactory returns an informer factory over the namespace's BuildRuns
+func (s *Store) InformerFactory() dynamicinformer.DynamicSharedInformerFactory {
+	return dynamicinformer.NewFilteredDynamicSharedInformerFactory(s.client, 0, s.namespace, nil)
+}
+
+func (s *Store) resource() dynamic.ResourceInterface {
+	return s.client.Resource(Resource).Namespace(s.namespace)
+}
+
+// Create records a build as a BuildRun (Pending) with the given labels
+// 📝 NOTE: A BuildRun that already exists (one created with kubectl) is kept
+func (s *Store) Create(ctx context.Context, be types.BuildEvent, labels map[string]string) error {
+	name := Name(be.ID)
+	if name == "" {
+		return nil
+	}
+	spec, values := redact(be)
+	plaintext, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("failed to encode the values of BuildRun %s: %w", name, err)
+	}
+	sealed, err := s.sealer.Seal(ctx, be.ThirdPartyId, plaintext)
+	if err != nil {
+		return fmt.Errorf("failed to seal the values of BuildRun %s: %w", name, err)
+	}
+	run := &BuildRun{
+		TypeMeta:   metav1.TypeMeta{APIVersion: Resource.GroupVersion().String(), Kind: Kind},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: s.namespace, Labels: labels},
+		Spec:       Spec{BuildEvent: spec, Sealed: base64.StdEncoding.EncodeToString(sealed)},
+	}
+	obj, err := toUnstructured(run)
+	if err != nil {
+		return err
+	}
+	if _, err := s.resource().Create(ctx, obj, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create BuildRun %s: %w", name, err)
+	}
+	return nil
+}
+
+// Get returns a BuildRun by name, with its sealed values opened
+func (s *Store) Get(ctx context.Context, name string) (*BuildRun, error) {
+	obj, err := s.resource().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get BuildRun %s: %w", name, err)
+	}
+	run, err := FromUnstructured(obj)
+	if err != nil || run.Spec.Sealed == "" {
+		return run, err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(run.Spec.Sealed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the values of BuildRun %s: %w", name, err)
+	}
+	plaintext, err := s.sealer.Open(ctx, sealed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the values of BuildRun %s: %w", name, err)
+	}
+	var values sealedValues
+	if err := json.Unmarshal(plaintext, &values); err != nil {
+		return nil, fmt.Errorf("failed to decode the values of BuildRun %s: %w", name, err)
+	}
+	run.Spec.BuildEvent = restore(run.Spec.BuildEvent, values)
+	run.Spec.Sealed = ""
+	return run, nil
+}
+
+// UpdateStatus changes the status of a BuildRun, retrying on conflicts
+func (s *Store) UpdateStatus(ctx context.Context, name string, change func(*Status)) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := s.resource().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		return s.writeStatus(ctx, obj, change)
+	})
+}
+
+// Claim changes the status of a BuildRun as it was read; false when it
+// changed since (e.g. another replica claimed it)
+func (s *Store) Claim(ctx context.Context, obj *unstructured.Unstructured, change func(*Status)) (bool, error) {
+	err := s.writeStatus(ctx, obj.DeepCopy(), change)
+	if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Heartbeat renews a replica's hold on a Pending BuildRun; false when
+// another replica holds it (took it over)
+// 📝 NOTE: A BuildRun past Pending (or gone) needs no hold and reports true
+func (s *Store) Heartbeat(ctx context.Context, name, owner string) (bool, error) {
+	held := true
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := s.resource().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		run, err := FromUnstructured(obj)
+		if err != nil {
+			return err
+		}
+		if run.Status.Phase != PhasePending {
+			return nil
+		}
+		if held = run.Status.Owner == "" || run.Status.Owner == owner; !held {
+			return nil
+		}
+		return s.writeStatus(ctx, obj, func(s *Status) {
+			s.Hold(owner)
+		})
+	})
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	}
+	return held, err
+}
+
+// writeStatus applies a change to a BuildRun's status and writes it, with
+// the object's resourceVersion
+func (s *Store) writeStatus(ctx context.Context, obj *unstructured.Unstructured, change func(*Status)) error {
+	run, err := FromUnstructured(obj)
+	if err != nil {
+		return err
+	}
+	change(&run.Status)
+	updated, err := toUnstructured(run)
+	if err != nil {
+		return err
+	}
+	_, err = s.resource().UpdateStatus(ctx, updated, metav1.UpdateOptions{})
+	return err
+}
+
+// DeleteFinished deletes the BuildRuns that finished more than ttl ago
+func (s *Store) DeleteFinished(ctx context.Context, runs []*BuildRun, ttl time.Duration) int {
+	deleted := 0
+	for _, run := range runs {
+		if !run.Status.Finished() || run.Status.CompletedAt == nil || time.Since(run.Status.CompletedAt.Time) < ttl {
+			continue
+		}
+		err := s.resource().Delete(ctx, run.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			log.Printf("WARNING: Failed to delete BuildRun %s: %v", run.Name, err)
+			continue
+		}
+		deleted++
+	}
+	return deleted
+}
@@ -0,0 +1,71 @@
+package buildruns
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"knative-lambda-builder/internal/types"
+)
+
+// fakeSealer "seals" by hex-encoding after the tenant's name
+type fakeSealer struct{}
+
+func (fakeSealer) Seal(_ context.Context, thirdPartyId string, plaintext []byte) ([]byte, error) {
+	return []byte(thirdPartyId + ":" + hex.EncodeToString(plaintext)), nil
+}
+
+func (fakeSealer) Open(_ context.Context, data []byte) ([]byte, error) {
+	_, encoded, _ := bytes.Cut(data, []byte(":"))
+	return hex.DecodeString(string(encoded))
+}
+
+func TestCreateSealsSecretValues(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()), "knative-lambda").WithSealer(fakeSealer{})
+	be := types.BuildEvent{
+		ID: "b1", ThirdPartyId: "acme", ParserId: "p1",
+		Env:       map[string]string{"API_KEY": "hunter2"},
+		BuildArgs: map[string]string{"NPM_TOKEN": "npm_s3cret"},
+		Source:    &types.BuildSource{Inline: &types.InlineSource{Content: "bW9kdWxlLmV4cG9ydHM=", Encoding: "base64"}},
+	}
+	if err := store.Create(ctx, be, nil); err != nil {
+		t.Fatalf("Create() = %v", err)
+	}
+
+	// What etcd gets: the names, not the values
+	obj, err := store.resource().Get(ctx, "b1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get(b1) = %v", err)
+	}
+	stored, _ := json.Marshal(obj.Object["spec"])
+	for _, secret := range []string{"hunter2", "npm_s3cret", "bW9kdWxlLmV4cG9ydHM="} {
+		if strings.Contains(string(stored), secret) {
+			t.Errorf("spec stores %q in plaintext: %s", secret, stored)
+		}
+	}
+	if !strings.Contains(string(stored), `"API_KEY":""`) || !strings.Contains(string(stored), `"sealed"`) {
+		t.Errorf("spec = %s, want the env names and the sealed values", stored)
+	}
+	if be.Env["API_KEY"] != "hunter2" || be.Source.Inline.Content == "" {
+		t.Errorf("Create() changed the request: %+v", be)
+	}
+
+	// What the builder reads back: the full request
+	run, err := store.Get(ctx, "b1")
+	if err != nil {
+		t.Fatalf("Get() = %v", err)
+	}
+	got := run.Spec.BuildEvent
+	if got.Env["API_KEY"] != "hunter2" || got.BuildArgs["NPM_TOKEN"] != "npm_s3cret" ||
+		got.Source.Inline.Content != "bW9kdWxlLmV4cG9ydHM=" || got.Source.Inline.Encoding != "base64" || run.Spec.Sealed != "" {
+		t.Errorf("Get() = %+v, want the request with its values opened", run.Spec)
+	}
+}
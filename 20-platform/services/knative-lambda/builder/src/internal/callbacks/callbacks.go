@@ -0,0 +1,182 @@
+package callbacks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// =============================================================================
+// 📞 BUILD CALLBACKS
+// =============================================================================
+// A build request may carry a callbackUrl; once the build deploys or fails,
+// the builder POSTs its status there as signed JSON
+// 🎯 PURPOSE: Systems without CloudEvents infrastructure can still follow
+// their builds
+//
+//	X-Lambda-Timestamp: <unix seconds>
+//	X-Lambda-Signature: sha256=hex(HMAC-SHA256(secret, timestamp "." body))
+//	X-Lambda-Delivery:  <id, the same for every retry of a callback>
+//
+// 📝 NOTE: The URL comes from the requester, so only https URLs (on the
+// allowed hosts, when configured) are called
+
+// Callback headers
+const (
+	HeaderTimestamp = "X-Lambda-Timestamp"
+	HeaderSignature = "X-Lambda-Signature"
+	HeaderDelivery  = "X-Lambda-Delivery"
+)
+
+// signaturePrefix names the signature's algorithm
+const signaturePrefix = "sha256="
+
+// Delivery defaults
+const (
+	DefaultAttempts = 5
+	DefaultBackoff  = 2 * time.Second
+	requestTimeout  = 10 * time.Second
+)
+
+// ErrInvalidURL is returned for callback URLs the builder won't call
+var ErrInvalidURL = errors.New("invalid callback url")
+
+// Config configures callback deliveries
+type Config struct {
+	Secret       []byte        // Signs every callback (at least 32 bytes)
+	AllowedHosts []string      // Hosts callbacks may go to ("" = any); ".example.com" allows subdomains
+	Attempts     int           // Deliveries before giving up (DefaultAttempts when 0)
+	Backoff      time.Duration // Delay before the first retry, doubles per attempt (DefaultBackoff when 0)
+}
+
+// Notifier delivers build callbacks
+type Notifier struct {
+	cfg    Config
+	client *http.Client
+}
+
+// NewNotifier creates a notifier signing callbacks with cfg.Secret
+func NewNotifier(cfg Config) (*Notifier, error) {
+	if len(cfg.Secret) < 32 {
+		return nil, fmt.Errorf("callback signing secret must be at least 32 bytes, got %d", len(cfg.Secret))
+	}
+	if cfg.Attempts < 1 {
+		cfg.Attempts = DefaultAttempts
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = DefaultBackoff
+	}
+	return &Notifier{cfg: cfg, client: &http.Client{
+		Timeout: requestTimeout,
+		// 🎯 WHY: A redirect could lead to a host that isn't allowed
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}}, nil
+}
+
+// Check validates a callback URL: https, on an allowed host
+func (n *Notifier) Check(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("%w: %q is not an https url", ErrInvalidURL, rawURL)
+	}
+	if !n.allowed(u.Hostname()) {
+		return fmt.Errorf("%w: host %s is not allowed", ErrInvalidURL, u.Hostname())
+	}
+	return nil
+}
+
+// allowed reports whether callbacks may go to host
+func (n *Notifier) allowed(host string) bool {
+	if len(n.cfg.AllowedHosts) == 0 {
+		return true
+	}
+	host = strings.ToLower(host)
+	for _, allowed := range n.cfg.AllowedHosts {
+		allowed = strings.ToLower(allowed)
+		if host == allowed || (strings.HasPrefix(allowed, ".") && strings.HasSuffix(host, allowed)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign returns the signature of a callback body sent at timestamp
+func (n *Notifier) Sign(timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, n.cfg.Secret)
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Deliver POSTs payload to the callback URL, retrying with backoff
+// 📋 RETRIES: Network errors, 429 and 5xx are retried up to Attempts times;
+// other statuses mean the receiver refused the callback
+func (n *Notifier) Deliver(ctx context.Context, callbackURL string, payload interface{}) error {
+	if err := n.Check(callbackURL); err != nil {
+		return err
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode callback: %w", err)
+	}
+
+	delivery := uuid.NewString()
+	backoff := n.cfg.Backoff
+	for attempt := 1; ; attempt++ {
+		retry, err := n.post(ctx, callbackURL, delivery, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt == n.cfg.Attempts {
+			return fmt.Errorf("callback to %s failed after %d attempts: %w", callbackURL, attempt, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// post sends one delivery; reports whether a failure is worth retrying
+func (n *Notifier) post(ctx context.Context, callbackURL, delivery string, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, n.Sign(timestamp, body))
+	req.Header.Set(HeaderDelivery, delivery)
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("receiver answered %s", resp.Status)
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
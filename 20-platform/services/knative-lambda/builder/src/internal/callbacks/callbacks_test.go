@@ -0,0 +1,87 @@
+package callbacks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte(strings.Repeat("s", 32))
+
+func TestDeliverRetriesAndSigns(t *testing.T) {
+	var deliveries []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deliveries = append(deliveries, r.Header.Get(HeaderDelivery))
+		if len(deliveries) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		n, _ := NewNotifier(Config{Secret: testSecret})
+		if r.Header.Get(HeaderSignature) != n.Sign(timestamp, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	n, err := NewNotifier(Config{Secret: testSecret, Backoff: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.client = server.Client()
+
+	if err := n.Deliver(context.Background(), server.URL+"/hook", map[string]string{"status": "deployed"}); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if len(deliveries) != 2 || deliveries[0] != deliveries[1] {
+		t.Errorf("deliveries = %q, want one retry with the same delivery id", deliveries)
+	}
+}
+
+func TestDeliverGivesUpOnClientErrors(t *testing.T) {
+	calls := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer server.Close()
+
+	n, _ := NewNotifier(Config{Secret: testSecret, Backoff: time.Millisecond})
+	n.client = server.Client()
+	if err := n.Deliver(context.Background(), server.URL, struct{}{}); err == nil || calls != 1 {
+		t.Errorf("Deliver() = %v after %d calls, want an error after 1", err, calls)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	n, _ := NewNotifier(Config{Secret: testSecret, AllowedHosts: []string{"hooks.acme.com", ".globex.io"}})
+	for rawURL, ok := range map[string]bool{
+		"https://hooks.acme.com/builds": true,
+		"https://ci.globex.io/x":        true,
+		"http://hooks.acme.com/builds":  false,
+		"https://evil.com/":             false,
+		"https://globex.io.evil.com/":   false,
+		"not a url":                     false,
+	} {
+		err := n.Check(rawURL)
+		if (err == nil) != ok {
+			t.Errorf("Check(%q) = %v, want ok=%t", rawURL, err, ok)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidURL) {
+			t.Errorf("Check(%q) = %v, want ErrInvalidURL", rawURL, err)
+		}
+	}
+
+	if _, err := NewNotifier(Config{Secret: []byte("short")}); err == nil {
+		t.Error("NewNotifier() with a short secret: want an error")
+	}
+}
@@ -1,7 +1,14 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
+	"log"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // =============================================================================
@@ -17,39 +24,660 @@ type Config struct {
 	S3TmpBucket    string
 
 	// ECR Configuration
-	ECRBaseRegistry string
+	ECRBaseRegistry    string
+	ECRLifecyclePolicy string // Lifecycle policy (JSON) put on new tenant repositories ("none" = no policy)
+	ECRTagMutability   string // MUTABLE or IMMUTABLE tags in tenant repositories
+	ECRScanOnPush      bool   // Scan images pushed to tenant repositories
+	ECREncryption      string // AES256 or KMS encryption of new repositories
+	ECRKMSKey          string // KMS key of KMS encryption ("" = the AWS managed key)
+
+	// Cross-account ECR (images pushed into another AWS account)
+	AWSPushRoleARN        string // IAM role assumed for ECR calls and build jobs ("" = the builder's own credentials)
+	AWSPushRoleExternalID string // External ID passed when assuming push roles
+	AWSPushRolesAllowed   string // Comma separated ARN prefixes of the roles build requests may name (pushRoleArn; "" = none)
+	AWSPushRoleSecret     string // Secret holding the push roles' temporary credentials for build jobs
+
+	// Regional ECR (images pushed into other regions' registries)
+	AWSPushRegionsAllowed string // Comma separated regions build requests may push to (region; "" = none)
+
+	// AWS API Retries (every ECR, S3, STS, KMS and Secrets Manager call)
+	AWSRetryMode   string        // adaptive (backs off harder while throttled) or standard
+	AWSMaxAttempts int           // Attempts per call, the first included
+	AWSCallTimeout time.Duration // How long one attempt waits for a response (0 = no limit)
+
+	// AWS Endpoints (local development and integration tests: LocalStack, MinIO)
+	AWSEndpointURL    string // Every AWS service ("" = AWS)
+	AWSS3EndpointURL  string // S3 only, instead of AWSEndpointURL
+	AWSECREndpointURL string // ECR only, instead of AWSEndpointURL
+	AWSSTSEndpointURL string // STS only, instead of AWSEndpointURL
+	AWSS3UsePathStyle bool   // Address buckets in the path, not the host name (MinIO)
+
+	// Image Registry (where parser images are pushed; ECR by default)
+	RegistryProvider   string // ecr, gar, acr, harbor or generic
+	GARProject         string // Google Cloud project of the gar backend
+	GARLocation        string // Artifact Registry location of the gar backend, e.g. us-central1
+	GARRepository      string // Artifact Registry Docker repository of the gar backend
+	RegistryAuthSecret string // Secret with the docker config (.dockerconfigjson) build jobs push with (gar, acr, harbor, generic)
+
+	// Azure Container Registry (REGISTRY_PROVIDER=acr)
+	ACRName             string // Registry name, e.g. acmeplatform, or its login server
+	ACRRepositoryPrefix string // Path the tenant repositories are created under
+
+	// Harbor (REGISTRY_PROVIDER=harbor)
+	HarborURL           string // Harbor endpoint, e.g. https://harbor.lab (https when the scheme is left out)
+	HarborUsername      string // (Robot) account allowed to create projects and push
+	HarborPassword      string
+	HarborProjectPrefix string // Prepended to a thirdPartyId to name its project
+	HarborProjectQuota  int    // Storage quota of created projects, in bytes (-1 = unlimited)
+	HarborAutoScan      bool   // Created projects scan images on push
+
+	// Generic Docker Registry (REGISTRY_PROVIDER=generic)
+	RegistryURL      string // Host and optional path prefix, e.g. docker.io/acme or registry.lab:5000/knative-lambdas
+	RegistryInsecure bool   // Skip TLS verification of the registry (lab environments only)
 
 	// Template Paths
-	JobTemplatePath     string
-	ServiceTemplatePath string
-	TriggerTemplatePath string
+	JobTemplatePath      string
+	ServiceTemplatePath  string
+	FallbackTemplatePath string // Deployment + Service + HPA used when Knative Serving is unavailable
+	TriggerTemplatePath  string
+	TestJobTemplatePath  string
+	TemplatesDir         string // Directory holding the build context templates (Dockerfile.tpl, ...)
+	TemplatesRemoteURI   string // Optional s3://bucket/prefix overriding templates by file name
 
 	// Kubernetes Configuration
 	KubernetesNamespace string
+	DeployMode          string // "auto" (Knative unless Serving is unavailable), "knative" or "fallback"
+
+	// Fallback Deployments (when parsers don't run as Knative Services)
+	FallbackMinReplicas int
+	FallbackMaxReplicas int
+	FallbackTargetCPU   int           // HPA target, in percent of the CPU request
+	TriggerReadyTimeout time.Duration // How long to wait for a parser trigger to become Ready
+	DeletionTimeout     time.Duration // How long a recreated object (e.g. a trigger) may take to go away
+	JobWatch            bool          // Watch jobs directly (false = the ApiServerSource's resource.update events)
+
+	// Tenant Namespaces (parsers in lambda-<thirdPartyId> instead of KubernetesNamespace)
+	TenantNamespaces                 bool
+	TenantNamespaceQuota             string // ResourceQuota of each tenant namespace, e.g. "requests.cpu=4,pods=20" ("" = none)
+	TenantNamespaceAllowedNamespaces string // Namespaces that may reach parsers ("none" = no NetworkPolicy)
+
+	// BuildRuns (buildruns.knative-lambda.notifi.network objects)
+	BuildRuns              bool          // Record builds as BuildRuns and start the builds requested with them
+	BuildRunTTL            time.Duration // How long finished BuildRuns are kept (0 = forever)
+	BuildVisibilityTimeout time.Duration // Pending BuildRuns not heartbeated for longer are taken over by another replica (0 = never)
+
+	// High Availability (several builder replicas)
+	LeaderElection              bool          // Elect the replica running the singleton loops (false = every replica runs them)
+	LeaderElectionLeaseDuration time.Duration // How long a leader that stopped renewing keeps the lease
+	ShutdownTimeout             time.Duration // How long a stopping replica waits for its accepted builds to get their jobs
+
+	// Deploy Strategies (Knative Services only)
+	DeployStrategy     string        // rolling, canary or blue-green ("" = canary when CanarySteps is set, rolling otherwise)
+	CanarySteps        string        // Traffic percentages the new revision goes through, e.g. "10,50" ("" = no canary)
+	CanaryStepInterval time.Duration // How long each step lasts before the next one
+	BlueGreenSmokePath string        // Path the new revision must answer 2xx on before getting the traffic
+	BlueGreenTimeout   time.Duration // How long the new revision has to become Ready and pass the smoke test
+
+	// Vulnerability Scan Gate (ECR and Harbor)
+	ScanMaxCritical int           // Critical findings an image may have and still be deployed (-1 = no gate)
+	ScanTimeout     time.Duration // How long to wait for the image's scan before blocking the deploy
+
+	// Orphan Reconciler
+	OrphanReconcileInterval time.Duration // How often to look for orphaned resources (0 = never)
+	OrphanReconcileTimeout  time.Duration // Time budget of a single pass
+	OrphanReconcileDryRun   bool          // Only flag orphans, never delete them
+
+	// Event Emission
+	EventSink      string // Where the builder sends the events it emits (K_SINK from a SinkBinding)
+	DeadLetterSink string // Where builds that failed for good go: http(s) broker URI or s3://bucket/prefix (empty = nowhere)
+
+	// Build Callbacks
+	CallbackSigningSecret string   // HMAC secret callbacks are signed with (empty = callbackUrl refused)
+	CallbackAllowedHosts  []string // Hosts callbacks may go to (empty = any); ".example.com" allows subdomains
+
+	// Event Ingestion
+	EventTransformsFile string // JSON file of jq rules mapping legacy payloads (empty = none)
+	EventSigningSecret  string // HMAC secret build requests must be signed with (empty = not required)
+
+	// Event Transport (where build requests are consumed from)
+	Transport             string // How build requests arrive: "http" (CloudEvents receiver), "kafka", "rabbitmq" or "nats"
+	KafkaBrokers          string // Comma separated seed brokers (transport kafka)
+	KafkaTopic            string // Topic build requests are consumed from
+	KafkaGroup            string // Consumer group shared by the builder replicas
+	RabbitMQURL           string // amqp:// URL of the broker (transport rabbitmq)
+	RabbitMQQueue         string // Queue build requests are consumed from (must exist)
+	RabbitMQPrefetch      int    // Requests handled at once per replica
+	RabbitMQMaxDeliveries int    // Attempts before a failing request is dead-lettered
+	NATSURL               string // nats:// URL of the server (transport nats)
+	NATSStream            string // JetStream stream holding build requests and lifecycle events (must exist)
+	NATSSubject           string // Subject build requests are consumed from
+	NATSDurable           string // Durable consumer shared by the builder replicas
+	NATSEventsSubject     string // Lifecycle events are published to <subject>.<event type>
+	NATSMaxDeliveries     int    // Attempts before a failing request is dropped
+
+	// Parser Sidecars
+	SidecarCatalogFile string // JSON file of the sidecars tenants may run with their parsers (empty = none)
+
+	// Observability
+	EventSampleRates       string  // Per event type sampling ratios: "type=ratio,type=ratio"
+	EventSampleRateDefault float64 // Ratio for event types not listed in EventSampleRates
+
+	// Self-Profiling
+	SelfProfileInterval   time.Duration // How often heap/goroutines are checked (0 = never)
+	SelfProfileCooldown   time.Duration // Minimum time between two snapshots
+	SelfProfileHeapBytes  int           // Live heap that triggers a snapshot (0 = ignore)
+	SelfProfileGoroutines int           // Goroutine count that triggers a snapshot (0 = ignore)
+	SelfProfileDir        string        // Where snapshots are written
+	SelfProfileS3URI      string        // Optional s3://bucket/prefix snapshots are uploaded to
 
 	// Docker Configuration
 	DefaultDockerfileName string
+	BaseImage             string // Base image of parser images (Dockerfile FROM)
+	KanikoImage           string // Kaniko executor image used by build jobs
+	ReproducibleBuilds    bool   // Normalize the build context, require pinned images, record inputs
+	BuildCacheEnabled     bool   // Skip unchanged build stages (keyed by a hash of the inputs)
+
+	// Source Validation
+	SourceValidationEnabled bool   // `node --check` the parser source before building it
+	NodeBinary              string // node used for the check
+
+	// Custom Dockerfiles ({parserId}.Dockerfile next to the parser)
+	CustomDockerfilesEnabled bool     // Build parsers shipping a Dockerfile with it instead of the template
+	DockerfileAllowedBases   []string // Repositories custom Dockerfiles may build FROM
+
+	// Python Runtime (builds with runtime=python)
+	PythonBaseImage string // Base image of Python parser images
+	PythonBinary    string // python used to check Python sources
+
+	// Go Runtime (builds with runtime=go)
+	GoBaseImage    string // Image compiling Go parsers
+	GoRuntimeImage string // Image the static parser binary runs in
+
+	// Git Sources (builds with source.git)
+	GitBinary       string   // git used to resolve refs and fetch repositories
+	GitToken        string   // Token for private repositories, sent as HTTP basic auth (empty = public only)
+	GitAllowedHosts []string // Hosts repositories may come from (empty = any); GitToken only goes to them
+
+	// Source Store (where parser sources are read from; S3_SOURCE_BUCKET by default)
+	SourceBackend   string // s3, gcs or azure
+	SourceURI       string // s3://bucket, gs://bucket or az://account/container, overrides the backend and its bucket
+	GCSSourceBucket string // Bucket of the gcs backend
+
+	// Azure Blob Storage Sources (SOURCE_BACKEND=azure)
+	AzureStorageAccount  string // Storage account of the azure backend
+	AzureSourceContainer string // Container of the azure backend
+
+	// Inline Sources (builds with source.inline)
+	InlineSourceMaxBytes int // Largest decoded inline parser (0 = inline sources refused)
+
+	// Private npm Registry (npm install of Node.js parsers)
+	NpmrcSecret    string // Secret (key .npmrc) mounted into build jobs (empty = none, unless NpmrcSecretARN is set)
+	NpmrcSecretARN string // Secrets Manager secret the builder copies into NpmrcSecret before builds
+
+	// Dependency Overrides (build events' dependencies)
+	NpmDependencyAllowlist []string // Packages builds may add, exact or patterns like @notifi/* (empty = none)
+
+	// Build Context
+	ContextExclude []string // Files left out of build contexts: names or paths, patterns like *.map (empty = none)
+
+	// Build Context Limits (a context breaking them fails the build with stage validate)
+	ContextMaxBytes         int      // Largest total size of a context's files (0 = no limit)
+	ContextMaxFiles         int      // Most files in a context (0 = no limit)
+	ContextDeniedExtensions []string // File extensions refused in contexts, e.g. .exe,.pem (empty = none)
+
+	// Build Context Encryption
+	ContextKMSKeyARN         string // KMS key contexts of tenants without their own are uploaded with (SSE-KMS, "" = bucket default)
+	ContextRequireEncryption bool   // Refuse a S3_TMP_BUCKET without default SSE-KMS, and contexts that aren't KMS encrypted
+
+	// Build Context Handoff
+	ContextPresignTTL time.Duration // Build jobs download their context from a presigned URL valid this long (0 = from S3, with their own credentials)
+
+	// Build Backend
+	BuildBackend            string   // Tool build jobs run: "kaniko" (default) or "buildkit"; builds may pick their own
+	BuildKitJobTemplatePath string   // Job template of BuildKit builds
+	BuildKitAddr            string   // buildkitd the BuildKit jobs send their builds to
+	BuildKitImage           string   // Image with buildctl, run by BuildKit jobs
+	BuildKitSecrets         []string // Keys of the buildkit-secrets Secret passed as build secrets (RUN --mount=type=secret,id=<key>)
+	BuildPlatforms          string   // Platforms images are built for ("linux/amd64,linux/arm64"); tenants may have their own
+
+	// Kaniko Layer Cache
+	KanikoCacheEnabled bool          // Cache image layers in a per-tenant cache repository
+	KanikoCacheTTL     time.Duration // How long cached layers are used (and kept in ECR)
+
+	// Build Context Cleanup
+	ContextCleanupEnabled bool          // Delete (or tag) uploaded contexts once their image is confirmed
+	ContextRetention      time.Duration // How long contexts are kept for tenants without their own setting (0 = delete)
+	BuildGCInterval       time.Duration // How often leftover jobs, temp dirs and contexts are collected (0 = never)
+	BuildGCJobAge         time.Duration // How long finished jobs are kept before being collected (0 = never collect)
+	BuildGCContextAge     time.Duration // How old a context of a build without an image must be to be collected (0 = never)
+
+	// Build Scheduling
+	BuildPriorityClass     string        // PriorityClass of build pods (empty = cluster default)
+	BuildPreemptionRetries int           // How often a preempted/evicted build is requeued
+	BuildPreemptionBackoff time.Duration // Delay before the first requeue (doubles per attempt)
+	BuildRetries           int           // How often a build whose job failed is retried (0 = never)
+	BuildRetryBackoff      time.Duration // Delay before the first retry (doubles per retry, with jitter)
+	BuildTimeout           time.Duration // activeDeadlineSeconds of build jobs (0 = no deadline)
+	BuildReaperInterval    time.Duration // How often jobs stuck past the timeout are reaped (0 = never)
+	BuildDedupWindow       time.Duration // How long accepted build requests are remembered to drop duplicates (0 = never)
+	BuildDedupHistory      bool          // Also look build ids up in the build history (survives restarts)
+	BuildRateLimit         string        // Builds a tenant may submit per period ("30/1h", empty = unlimited)
+	MaxConcurrentBuilds    int           // Build jobs running at once; more wait in a priority queue (0 = unlimited)
+	BuildWorkers           int           // Accepted builds started at once per replica (prepared, waiting for a slot or launching)
+	BuildQueueSize         int           // Accepted builds that may wait for their job per replica; more are refused (0 = unbounded)
+
+	// Build Resources ("cpu=1,memory=2Gi,ephemeral-storage=10Gi"; tenants and requests may have their own)
+	BuildResourceRequests string // Requests of Kaniko build pods ("none" = no requests)
+	BuildResourceLimits   string // Limits of Kaniko build pods ("none" = no limits)
+	BuildResourceMax      string // No build may request or be limited above ("none" = no maximum)
+
+	// Parser Tests
+	ParserTestsEnabled bool          // Run {parserId}.test.js against the built image before deploying
+	ParserTestTimeout  time.Duration // Deadline of a test job
+
+	// SBOMs
+	SBOMEnabled         bool   // Inventory every pushed image (SPDX and CycloneDX, uploaded to the tmp bucket)
+	SBOMJobTemplatePath string // Job template of SBOM jobs
+	SyftImage           string // Image with syft, run by SBOM jobs
+
+	// Share Links
+	ShareLinkSecret     string        // HMAC secret of share links (empty = share links disabled)
+	ShareLinkDefaultTTL time.Duration // Lifetime of a link when none is requested
+	ShareLinkMaxTTL     time.Duration // Longest lifetime a link can be minted with
+	ShareLinkBaseURL    string        // Public URL share links are built on (relative if empty)
+
+	// Authentication (OIDC bearer tokens on the receiver and the API)
+	OIDCIssuers         string // Comma separated trusted issuer URLs (empty = authentication disabled)
+	OIDCAudiences       string // Comma separated audiences a token may be issued for
+	OIDCTenantsClaim    string // Claim listing the ThirdPartyIds a caller may act for ("*" = all, admin)
+	OIDCTrustedSubjects string // Comma separated token subjects acting for every tenant (Knative broker, sources)
+
+	// HTTP Configuration
+	Port string
+
+	// gRPC Configuration
+	GRPCPort string // Secondary port of the gRPC BuildService ("" disables it)
 }
 
 // Environment variable names
 const (
-	EnvEcrBaseRegistry     = "ECR_BASE_REGISTRY"
-	EnvS3SourceBucket      = "S3_SOURCE_BUCKET"
-	EnvS3TmpBucket         = "S3_TMP_BUCKET"
-	EnvJobTemplatePath     = "JOB_TEMPLATE_PATH"
-	EnvServiceTemplatePath = "SERVICE_TEMPLATE_PATH"
-	EnvTriggerTemplatePath = "TRIGGER_TEMPLATE_PATH"
+	EnvEcrBaseRegistry    = "ECR_BASE_REGISTRY"
+	EnvEcrLifecyclePolicy = "ECR_LIFECYCLE_POLICY"
+	EnvEcrTagMutability   = "ECR_TAG_MUTABILITY"
+	EnvEcrScanOnPush      = "ECR_SCAN_ON_PUSH"
+	EnvEcrEncryption      = "ECR_ENCRYPTION"
+	EnvEcrKMSKey          = "ECR_KMS_KEY"
+
+	EnvAWSPushRegionsAllowed = "AWS_PUSH_REGIONS_ALLOWED"
+
+	EnvAWSRetryMode   = "AWS_RETRY_MODE"
+	EnvAWSMaxAttempts = "AWS_MAX_ATTEMPTS"
+	EnvAWSCallTimeout = "AWS_CALL_TIMEOUT"
+
+	// 📝 NOTE: The endpoint variables are the AWS SDKs' own
+	EnvAWSEndpointURL    = "AWS_ENDPOINT_URL"
+	EnvAWSS3EndpointURL  = "AWS_ENDPOINT_URL_S3"
+	EnvAWSECREndpointURL = "AWS_ENDPOINT_URL_ECR"
+	EnvAWSSTSEndpointURL = "AWS_ENDPOINT_URL_STS"
+	EnvAWSS3UsePathStyle = "AWS_S3_USE_PATH_STYLE"
+
+	EnvAWSPushRoleARN        = "AWS_PUSH_ROLE_ARN"
+	EnvAWSPushRoleExternalID = "AWS_PUSH_ROLE_EXTERNAL_ID"
+	EnvAWSPushRolesAllowed   = "AWS_PUSH_ROLES_ALLOWED"
+	EnvAWSPushRoleSecret     = "AWS_PUSH_ROLE_SECRET"
+	EnvS3SourceBucket        = "S3_SOURCE_BUCKET"
+	EnvS3TmpBucket           = "S3_TMP_BUCKET"
+	EnvJobTemplatePath       = "JOB_TEMPLATE_PATH"
+	EnvServiceTemplatePath   = "SERVICE_TEMPLATE_PATH"
+	EnvFallbackTemplatePath  = "FALLBACK_TEMPLATE_PATH"
+	EnvTriggerTemplatePath   = "TRIGGER_TEMPLATE_PATH"
+	EnvTestJobTemplatePath   = "TEST_JOB_TEMPLATE_PATH"
+	EnvTemplatesDir          = "TEMPLATES_DIR"
+	EnvTemplatesRemoteURI    = "TEMPLATES_REMOTE_URI"
+	EnvPort                  = "PORT"
+	EnvGRPCPort              = "GRPC_PORT"
+	EnvTriggerReadyTimeout   = "TRIGGER_READY_TIMEOUT"
+	EnvDeletionTimeout       = "DELETION_TIMEOUT"
+	EnvJobWatch              = "JOB_WATCH"
+	EnvEventSink             = "K_SINK"
+	EnvDeadLetterSink        = "DEAD_LETTER_SINK"
+	EnvEventTransformsFile   = "EVENT_TRANSFORMS_FILE"
+	EnvEventSigningSecret    = "EVENT_SIGNING_SECRET"
+	EnvSidecarCatalogFile    = "SIDECAR_CATALOG_FILE"
+
+	EnvTenantNamespaces                 = "TENANT_NAMESPACES"
+	EnvTenantNamespaceQuota             = "TENANT_NAMESPACE_QUOTA"
+	EnvTenantNamespaceAllowedNamespaces = "TENANT_NAMESPACE_ALLOWED_NAMESPACES"
+
+	EnvBuildRuns              = "BUILD_RUNS"
+	EnvBuildRunTTL            = "BUILD_RUN_TTL"
+	EnvBuildVisibilityTimeout = "BUILD_VISIBILITY_TIMEOUT"
+
+	EnvLeaderElection              = "LEADER_ELECTION"
+	EnvLeaderElectionLeaseDuration = "LEADER_ELECTION_LEASE_DURATION"
+	EnvShutdownTimeout             = "SHUTDOWN_TIMEOUT"
+
+	EnvCallbackSigningSecret = "CALLBACK_SIGNING_SECRET"
+	EnvCallbackAllowedHosts  = "CALLBACK_ALLOWED_HOSTS"
+
+	EnvTransport             = "BUILDER_TRANSPORT"
+	EnvKafkaBrokers          = "KAFKA_BROKERS"
+	EnvKafkaTopic            = "KAFKA_TOPIC"
+	EnvKafkaGroup            = "KAFKA_GROUP"
+	EnvRabbitMQURL           = "RABBITMQ_URL"
+	EnvRabbitMQQueue         = "RABBITMQ_QUEUE"
+	EnvRabbitMQPrefetch      = "RABBITMQ_PREFETCH"
+	EnvRabbitMQMaxDeliveries = "RABBITMQ_MAX_DELIVERIES"
+	EnvNATSURL               = "NATS_URL"
+	EnvNATSStream            = "NATS_STREAM"
+	EnvNATSSubject           = "NATS_SUBJECT"
+	EnvNATSDurable           = "NATS_DURABLE"
+	EnvNATSEventsSubject     = "NATS_EVENTS_SUBJECT"
+	EnvNATSMaxDeliveries     = "NATS_MAX_DELIVERIES"
+
+	EnvDeployMode          = "DEPLOY_MODE"
+	EnvFallbackMinReplicas = "FALLBACK_MIN_REPLICAS"
+	EnvFallbackMaxReplicas = "FALLBACK_MAX_REPLICAS"
+	EnvFallbackTargetCPU   = "FALLBACK_TARGET_CPU"
+
+	EnvDeployStrategy     = "DEPLOY_STRATEGY"
+	EnvCanarySteps        = "CANARY_STEPS"
+	EnvCanaryStepInterval = "CANARY_STEP_INTERVAL"
+	EnvBlueGreenSmokePath = "BLUE_GREEN_SMOKE_PATH"
+	EnvBlueGreenTimeout   = "BLUE_GREEN_TIMEOUT"
+
+	EnvScanMaxCritical = "SCAN_MAX_CRITICAL"
+	EnvScanTimeout     = "SCAN_TIMEOUT"
+
+	EnvOrphanReconcileInterval = "ORPHAN_RECONCILE_INTERVAL"
+	EnvOrphanReconcileTimeout  = "ORPHAN_RECONCILE_TIMEOUT"
+	EnvOrphanReconcileDryRun   = "ORPHAN_RECONCILE_DRY_RUN"
+
+	EnvBaseImage          = "BASE_IMAGE"
+	EnvKanikoImage        = "KANIKO_IMAGE"
+	EnvReproducibleBuilds = "REPRODUCIBLE_BUILDS"
+	EnvBuildCacheEnabled  = "BUILD_CACHE_ENABLED"
+	EnvKanikoCacheEnabled = "KANIKO_CACHE_ENABLED"
+	EnvKanikoCacheTTL     = "KANIKO_CACHE_TTL"
+
+	EnvSourceValidationEnabled = "SOURCE_VALIDATION_ENABLED"
+	EnvNodeBinary              = "NODE_BINARY"
+
+	EnvCustomDockerfilesEnabled = "CUSTOM_DOCKERFILES_ENABLED"
+	EnvDockerfileAllowedBases   = "DOCKERFILE_ALLOWED_BASES"
+
+	EnvPythonBaseImage = "PYTHON_BASE_IMAGE"
+	EnvPythonBinary    = "PYTHON_BINARY"
+
+	EnvGoBaseImage    = "GO_BASE_IMAGE"
+	EnvGoRuntimeImage = "GO_RUNTIME_IMAGE"
+
+	EnvGitBinary       = "GIT_BINARY"
+	EnvGitToken        = "GIT_TOKEN"
+	EnvGitAllowedHosts = "GIT_ALLOWED_HOSTS"
+
+	EnvSourceBackend   = "SOURCE_BACKEND"
+	EnvSourceURI       = "SOURCE_URI"
+	EnvGCSSourceBucket = "GCS_SOURCE_BUCKET"
+
+	EnvAzureStorageAccount  = "AZURE_STORAGE_ACCOUNT"
+	EnvAzureSourceContainer = "AZURE_SOURCE_CONTAINER"
+
+	EnvInlineSourceMaxBytes = "INLINE_SOURCE_MAX_BYTES"
+
+	EnvNpmrcSecret    = "NPMRC_SECRET"
+	EnvNpmrcSecretARN = "NPMRC_SECRET_ARN"
+
+	EnvNpmDependencyAllowlist = "NPM_DEPENDENCY_ALLOWLIST"
+
+	EnvContextExclude = "BUILD_CONTEXT_EXCLUDE"
+
+	EnvRegistryProvider   = "REGISTRY_PROVIDER"
+	EnvGARProject         = "GAR_PROJECT"
+	EnvGARLocation        = "GAR_LOCATION"
+	EnvGARRepository      = "GAR_REPOSITORY"
+	EnvRegistryAuthSecret = "REGISTRY_AUTH_SECRET"
+
+	EnvACRName             = "ACR_NAME"
+	EnvACRRepositoryPrefix = "ACR_REPOSITORY_PREFIX"
+
+	EnvHarborURL           = "HARBOR_URL"
+	EnvHarborUsername      = "HARBOR_USERNAME"
+	EnvHarborPassword      = "HARBOR_PASSWORD"
+	EnvHarborProjectPrefix = "HARBOR_PROJECT_PREFIX"
+	EnvHarborProjectQuota  = "HARBOR_PROJECT_QUOTA"
+	EnvHarborAutoScan      = "HARBOR_AUTO_SCAN"
+
+	EnvRegistryURL      = "REGISTRY_URL"
+	EnvRegistryInsecure = "REGISTRY_INSECURE"
+
+	EnvContextMaxBytes         = "BUILD_CONTEXT_MAX_BYTES"
+	EnvContextMaxFiles         = "BUILD_CONTEXT_MAX_FILES"
+	EnvContextDeniedExtensions = "BUILD_CONTEXT_DENIED_EXTENSIONS"
+
+	EnvBuildBackend            = "BUILD_BACKEND"
+	EnvBuildKitJobTemplatePath = "BUILDKIT_JOB_TEMPLATE_PATH"
+	EnvBuildKitAddr            = "BUILDKIT_ADDR"
+	EnvBuildKitImage           = "BUILDKIT_IMAGE"
+	EnvBuildKitSecrets         = "BUILDKIT_SECRETS"
+	EnvBuildPlatforms          = "BUILD_PLATFORMS"
+
+	EnvContextKMSKeyARN         = "CONTEXT_KMS_KEY_ARN"
+	EnvContextRequireEncryption = "CONTEXT_REQUIRE_ENCRYPTION"
+
+	EnvContextPresignTTL = "CONTEXT_PRESIGN_TTL"
+
+	EnvContextCleanupEnabled = "CONTEXT_CLEANUP_ENABLED"
+	EnvContextRetention      = "CONTEXT_RETENTION"
+	EnvBuildGCInterval       = "BUILD_GC_INTERVAL"
+	EnvBuildGCJobAge         = "BUILD_GC_JOB_AGE"
+	EnvBuildGCContextAge     = "BUILD_GC_CONTEXT_AGE"
+
+	EnvBuildPriorityClass     = "BUILD_PRIORITY_CLASS"
+	EnvBuildPreemptionRetries = "BUILD_PREEMPTION_RETRIES"
+	EnvBuildPreemptionBackoff = "BUILD_PREEMPTION_BACKOFF"
+	EnvBuildRetries           = "BUILD_RETRIES"
+	EnvBuildRetryBackoff      = "BUILD_RETRY_BACKOFF"
+	EnvBuildTimeout           = "BUILD_TIMEOUT"
+	EnvBuildReaperInterval    = "BUILD_REAPER_INTERVAL"
+	EnvBuildDedupWindow       = "BUILD_DEDUP_WINDOW"
+	EnvBuildDedupHistory      = "BUILD_DEDUP_HISTORY"
+	EnvBuildRateLimit         = "BUILD_RATE_LIMIT"
+	EnvMaxConcurrentBuilds    = "MAX_CONCURRENT_BUILDS"
+	EnvBuildWorkers           = "BUILD_WORKERS"
+	EnvBuildQueueSize         = "BUILD_QUEUE_SIZE"
+
+	EnvBuildResourceRequests = "BUILD_RESOURCE_REQUESTS"
+	EnvBuildResourceLimits   = "BUILD_RESOURCE_LIMITS"
+	EnvBuildResourceMax      = "BUILD_RESOURCE_MAX"
+
+	EnvParserTestsEnabled = "PARSER_TESTS_ENABLED"
+	EnvParserTestTimeout  = "PARSER_TEST_TIMEOUT"
+
+	EnvSBOMEnabled         = "SBOM_ENABLED"
+	EnvSBOMJobTemplatePath = "SBOM_JOB_TEMPLATE_PATH"
+	EnvSyftImage           = "SYFT_IMAGE"
+
+	EnvEventSampleRates       = "EVENT_SAMPLE_RATES"
+	EnvEventSampleRateDefault = "EVENT_SAMPLE_RATE_DEFAULT"
+
+	EnvSelfProfileInterval   = "SELF_PROFILE_INTERVAL"
+	EnvSelfProfileCooldown   = "SELF_PROFILE_COOLDOWN"
+	EnvSelfProfileHeapBytes  = "SELF_PROFILE_HEAP_BYTES"
+	EnvSelfProfileGoroutines = "SELF_PROFILE_GOROUTINES"
+	EnvSelfProfileDir        = "SELF_PROFILE_DIR"
+	EnvSelfProfileS3URI      = "SELF_PROFILE_S3_URI"
+
+	EnvShareLinkSecret     = "SHARE_LINK_SECRET"
+	EnvShareLinkDefaultTTL = "SHARE_LINK_TTL"
+	EnvShareLinkMaxTTL     = "SHARE_LINK_MAX_TTL"
+	EnvShareLinkBaseURL    = "SHARE_LINK_BASE_URL"
+
+	EnvOIDCIssuers         = "OIDC_ISSUERS"
+	EnvOIDCAudiences       = "OIDC_AUDIENCES"
+	EnvOIDCTenantsClaim    = "OIDC_TENANTS_CLAIM"
+	EnvOIDCTrustedSubjects = "OIDC_TRUSTED_SUBJECTS"
 )
 
 // Default values
 const (
-	DefaultJobTemplatePath     = "templates/job.yaml.tpl"
-	DefaultServiceTemplatePath = "templates/service.yaml.tpl"
-	DefaultTriggerTemplatePath = "templates/trigger.yaml.tpl"
-	DefaultKubernetesNamespace = "knative-lambda"
-	DefaultDockerfileName      = "Dockerfile"
+	DefaultJobTemplatePath      = "templates/job.yaml.tpl"
+	DefaultServiceTemplatePath  = "templates/service.yaml.tpl"
+	DefaultFallbackTemplatePath = "templates/fallback-service.yaml.tpl"
+	DefaultTriggerTemplatePath  = "templates/trigger.yaml.tpl"
+	DefaultTestJobTemplatePath  = "templates/test-job.yaml.tpl"
+	DefaultTemplatesDir         = "templates"
+	DefaultKubernetesNamespace  = "knative-lambda"
+	DefaultDockerfileName       = "Dockerfile"
+	DefaultPort                 = "8080"
+	DefaultTriggerReadyTimeout  = 2 * time.Minute
+	DefaultDeletionTimeout      = time.Minute
+	DefaultJobWatch             = true
+	DefaultBaseImage            = "node:18-alpine"
+	DefaultKanikoImage          = "gcr.io/kaniko-project/executor:latest"
+	DefaultKanikoCacheTTL       = 7 * 24 * time.Hour
+	DefaultNodeBinary           = "node"
+
+	DefaultDockerfileAllowedBases = "node"
+
+	DefaultTenantNamespaces                 = false
+	DefaultTenantNamespaceAllowedNamespaces = "knative-serving,kourier-system,knative-eventing,knative-lambda"
+	TenantNamespaceAllowedNone              = "none"
+
+	DefaultBuildRuns              = false
+	DefaultBuildRunTTL            = 7 * 24 * time.Hour
+	DefaultBuildVisibilityTimeout = 5 * time.Minute
+
+	DefaultLeaderElection              = true
+	DefaultLeaderElectionLeaseDuration = 15 * time.Second
+	DefaultShutdownTimeout             = 30 * time.Second
+
+	DefaultPythonBaseImage = "python:3.12-slim"
+	DefaultPythonBinary    = "python3"
+
+	DefaultGoBaseImage    = "golang:1.23-alpine"
+	DefaultGoRuntimeImage = "gcr.io/distroless/static-debian12:nonroot"
+
+	DefaultGitBinary = "git"
+
+	SourceBackendS3    = "s3"
+	SourceBackendGCS   = "gcs"
+	SourceBackendAzure = "azure"
+
+	DefaultInlineSourceMaxBytes = 256 << 10
+
+	DefaultNpmrcSecret = "knative-lambda-npmrc" // NpmrcSecret when only NpmrcSecretARN is set
+
+	// DefaultECRLifecyclePolicy expires untagged images (overwritten tags) after 7 days
+	// 📝 NOTE: Tagged images are kept: a tenant repository holds every parser's
+	// revisions, so "keep the last N" could expire a deployed one
+	DefaultECRLifecyclePolicy = `{"rules":[{"rulePriority":1,"description":"Expire untagged images after 7 days",` +
+		`"selection":{"tagStatus":"untagged","countType":"sinceImagePushed","countUnit":"days","countNumber":7},` +
+		`"action":{"type":"expire"}}]}`
+	ECRLifecyclePolicyNone = "none"
+
+	ECRTagMutabilityMutable   = "MUTABLE"
+	ECRTagMutabilityImmutable = "IMMUTABLE"
+	ECREncryptionAES256       = "AES256"
+	ECREncryptionKMS          = "KMS"
+
+	DefaultAWSPushRoleSecret = "knative-lambda-push-roles"
+
+	DefaultAWSRetryMode   = "adaptive"
+	DefaultAWSMaxAttempts = 5
+	DefaultAWSCallTimeout = 30 * time.Second
+
+	RegistryProviderECR     = "ecr"
+	RegistryProviderGAR     = "gar"
+	RegistryProviderACR     = "acr"
+	RegistryProviderHarbor  = "harbor"
+	RegistryProviderGeneric = "generic"
+
+	DefaultGARRepository      = "knative-lambdas"
+	DefaultRegistryAuthSecret = "knative-lambda-registry-auth"
+
+	DefaultACRRepositoryPrefix = "knative-lambdas"
+
+	DefaultHarborProjectQuota = -1
+
+	DefaultContextMaxBytes = 512 << 20
+	DefaultContextMaxFiles = 10000
+
+	DefaultBuildBackend            = "kaniko"
+	DefaultBuildKitJobTemplatePath = "templates/buildkit-job.yaml.tpl"
+	DefaultBuildKitAddr            = "tcp://buildkitd.knative-lambda.svc.cluster.local:1234"
+	DefaultBuildKitImage           = "moby/buildkit:v0.13.2"
+	DefaultBuildPlatforms          = "linux/amd64"
+
+	DefaultTransport             = "http"
+	DefaultKafkaGroup            = "knative-lambda-builder"
+	DefaultRabbitMQQueue         = "knative-lambda-builds"
+	DefaultRabbitMQPrefetch      = 4
+	DefaultRabbitMQMaxDeliveries = 5
+	DefaultNATSStream            = "KNATIVE_LAMBDA"
+	DefaultNATSSubject           = "knative-lambda.builds"
+	DefaultNATSDurable           = "knative-lambda-builder"
+	DefaultNATSEventsSubject     = "knative-lambda.events"
+	DefaultNATSMaxDeliveries     = 5
+
+	DefaultDeployMode          = "auto"
+	DefaultFallbackMinReplicas = 1
+	DefaultFallbackMaxReplicas = 5
+	DefaultFallbackTargetCPU   = 70
+
+	DefaultCanaryStepInterval = 5 * time.Minute
+	DefaultBlueGreenSmokePath = "/health/readiness"
+	DefaultBlueGreenTimeout   = 5 * time.Minute
+
+	DefaultScanMaxCritical = -1
+	DefaultScanTimeout     = 10 * time.Minute
+
+	DefaultBuildPreemptionRetries = 3
+	DefaultBuildPreemptionBackoff = 30 * time.Second
+	DefaultBuildRetries           = 2
+	DefaultBuildRetryBackoff      = 30 * time.Second
+	DefaultBuildTimeout           = 30 * time.Minute
+	DefaultBuildReaperInterval    = time.Minute
+	DefaultBuildDedupWindow       = 10 * time.Minute
+	DefaultBuildQueueSize         = 100
+	DefaultMaxConcurrentBuilds    = 10
+	DefaultBuildWorkers           = 20
+	DefaultBuildGCInterval        = 15 * time.Minute
+	DefaultBuildGCJobAge          = time.Hour
+	DefaultBuildGCContextAge      = 7 * 24 * time.Hour
+
+	// 📝 NOTE: No CPU limit by default: builds are limited to the maximum's CPU
+	DefaultBuildResourceRequests = "cpu=500m,memory=1Gi,ephemeral-storage=2Gi"
+	DefaultBuildResourceLimits   = "memory=4Gi,ephemeral-storage=10Gi"
+	DefaultBuildResourceMax      = "cpu=4,memory=16Gi,ephemeral-storage=50Gi"
+	BuildResourcesNone           = "none"
+
+	DefaultParserTestTimeout = 5 * time.Minute
+
+	DefaultSBOMJobTemplatePath = "templates/sbom-job.yaml.tpl"
+	DefaultSyftImage           = "anchore/syft:v1.14.0"
+
+	DefaultOrphanReconcileInterval = 1 * time.Hour
+	DefaultOrphanReconcileTimeout  = 5 * time.Minute
+
+	// resource.update fires for every Job status change; sample 10% by default
+	DefaultEventSampleRates       = "dev.knative.apiserver.resource.update=0.1"
+	DefaultEventSampleRateDefault = 1.0
+
+	DefaultSelfProfileCooldown   = 15 * time.Minute
+	DefaultSelfProfileHeapBytes  = 512 << 20
+	DefaultSelfProfileGoroutines = 10000
+	DefaultSelfProfileDir        = "/tmp/profiles"
+
+	DefaultShareLinkDefaultTTL = 24 * time.Hour
+	DefaultShareLinkMaxTTL     = 7 * 24 * time.Hour
+
+	DefaultOIDCTenantsClaim = "third_party_ids"
 )
 
+// MaxContextPresignTTL is the longest lifetime of presigned S3 URLs (SigV4)
+const MaxContextPresignTTL = 7 * 24 * time.Hour
+
 // Load creates a new Config from environment variables with sensible defaults
 // 🎯 PURPOSE: Initialize configuration once at startup
 func Load() *Config {
@@ -59,12 +687,277 @@ func Load() *Config {
 		S3TmpBucket:    os.Getenv(EnvS3TmpBucket),
 
 		// ECR Configuration
-		ECRBaseRegistry: os.Getenv(EnvEcrBaseRegistry),
+		ECRBaseRegistry:    os.Getenv(EnvEcrBaseRegistry),
+		ECRLifecyclePolicy: getEnvOrDefault(EnvEcrLifecyclePolicy, DefaultECRLifecyclePolicy),
+		ECRTagMutability:   strings.ToUpper(getEnvOrDefault(EnvEcrTagMutability, ECRTagMutabilityMutable)),
+		ECRScanOnPush:      getEnvBoolOrDefault(EnvEcrScanOnPush, true),
+		ECREncryption:      strings.ToUpper(getEnvOrDefault(EnvEcrEncryption, ECREncryptionAES256)),
+		ECRKMSKey:          os.Getenv(EnvEcrKMSKey),
+
+		// Cross-account ECR
+		AWSPushRoleARN:        os.Getenv(EnvAWSPushRoleARN),
+		AWSPushRoleExternalID: os.Getenv(EnvAWSPushRoleExternalID),
+		AWSPushRolesAllowed:   os.Getenv(EnvAWSPushRolesAllowed),
+		AWSPushRoleSecret:     getEnvOrDefault(EnvAWSPushRoleSecret, DefaultAWSPushRoleSecret),
+
+		// Regional ECR
+		AWSPushRegionsAllowed: os.Getenv(EnvAWSPushRegionsAllowed),
+
+		// AWS API Retries
+		AWSRetryMode:   strings.ToLower(getEnvOrDefault(EnvAWSRetryMode, DefaultAWSRetryMode)),
+		AWSMaxAttempts: getEnvIntOrDefault(EnvAWSMaxAttempts, DefaultAWSMaxAttempts),
+		AWSCallTimeout: getEnvDurationOrDefault(EnvAWSCallTimeout, DefaultAWSCallTimeout),
+
+		// AWS Endpoints
+		AWSEndpointURL:    os.Getenv(EnvAWSEndpointURL),
+		AWSS3EndpointURL:  os.Getenv(EnvAWSS3EndpointURL),
+		AWSECREndpointURL: os.Getenv(EnvAWSECREndpointURL),
+		AWSSTSEndpointURL: os.Getenv(EnvAWSSTSEndpointURL),
+		AWSS3UsePathStyle: getEnvBoolOrDefault(EnvAWSS3UsePathStyle, false),
 
 		// Template Paths with defaults
-		JobTemplatePath:     getEnvOrDefault(EnvJobTemplatePath, DefaultJobTemplatePath),
-		ServiceTemplatePath: getEnvOrDefault(EnvServiceTemplatePath, DefaultServiceTemplatePath),
-		TriggerTemplatePath: getEnvOrDefault(EnvTriggerTemplatePath, DefaultTriggerTemplatePath),
+		JobTemplatePath:      getEnvOrDefault(EnvJobTemplatePath, DefaultJobTemplatePath),
+		ServiceTemplatePath:  getEnvOrDefault(EnvServiceTemplatePath, DefaultServiceTemplatePath),
+		FallbackTemplatePath: getEnvOrDefault(EnvFallbackTemplatePath, DefaultFallbackTemplatePath),
+		TriggerTemplatePath:  getEnvOrDefault(EnvTriggerTemplatePath, DefaultTriggerTemplatePath),
+		TestJobTemplatePath:  getEnvOrDefault(EnvTestJobTemplatePath, DefaultTestJobTemplatePath),
+		TemplatesDir:         getEnvOrDefault(EnvTemplatesDir, DefaultTemplatesDir),
+		TemplatesRemoteURI:   os.Getenv(EnvTemplatesRemoteURI),
+
+		// HTTP Configuration
+		Port: getEnvOrDefault(EnvPort, DefaultPort),
+
+		// gRPC Configuration
+		GRPCPort: os.Getenv(EnvGRPCPort),
+
+		// Kubernetes Configuration
+		TriggerReadyTimeout: getEnvDurationOrDefault(EnvTriggerReadyTimeout, DefaultTriggerReadyTimeout),
+		DeletionTimeout:     getEnvDurationOrDefault(EnvDeletionTimeout, DefaultDeletionTimeout),
+		JobWatch:            getEnvBoolOrDefault(EnvJobWatch, DefaultJobWatch),
+		DeployMode:          getEnvOrDefault(EnvDeployMode, DefaultDeployMode),
+
+		// Tenant Namespaces
+		TenantNamespaces:                 getEnvBoolOrDefault(EnvTenantNamespaces, DefaultTenantNamespaces),
+		TenantNamespaceQuota:             os.Getenv(EnvTenantNamespaceQuota),
+		TenantNamespaceAllowedNamespaces: getEnvOrDefault(EnvTenantNamespaceAllowedNamespaces, DefaultTenantNamespaceAllowedNamespaces),
+
+		// BuildRuns
+		BuildRuns:              getEnvBoolOrDefault(EnvBuildRuns, DefaultBuildRuns),
+		BuildRunTTL:            getEnvDurationOrDefault(EnvBuildRunTTL, DefaultBuildRunTTL),
+		BuildVisibilityTimeout: getEnvDurationOrDefault(EnvBuildVisibilityTimeout, DefaultBuildVisibilityTimeout),
+
+		// High Availability
+		LeaderElection:              getEnvBoolOrDefault(EnvLeaderElection, DefaultLeaderElection),
+		LeaderElectionLeaseDuration: getEnvDurationOrDefault(EnvLeaderElectionLeaseDuration, DefaultLeaderElectionLeaseDuration),
+		ShutdownTimeout:             getEnvDurationOrDefault(EnvShutdownTimeout, DefaultShutdownTimeout),
+
+		// Fallback Deployments
+		FallbackMinReplicas: getEnvIntOrDefault(EnvFallbackMinReplicas, DefaultFallbackMinReplicas),
+		FallbackMaxReplicas: getEnvIntOrDefault(EnvFallbackMaxReplicas, DefaultFallbackMaxReplicas),
+		FallbackTargetCPU:   getEnvIntOrDefault(EnvFallbackTargetCPU, DefaultFallbackTargetCPU),
+
+		// Deploy Strategies
+		DeployStrategy:     os.Getenv(EnvDeployStrategy),
+		CanarySteps:        os.Getenv(EnvCanarySteps),
+		CanaryStepInterval: getEnvDurationOrDefault(EnvCanaryStepInterval, DefaultCanaryStepInterval),
+		BlueGreenSmokePath: getEnvOrDefault(EnvBlueGreenSmokePath, DefaultBlueGreenSmokePath),
+		BlueGreenTimeout:   getEnvDurationOrDefault(EnvBlueGreenTimeout, DefaultBlueGreenTimeout),
+
+		// Vulnerability Scan Gate
+		ScanMaxCritical: getEnvIntOrDefault(EnvScanMaxCritical, DefaultScanMaxCritical),
+		ScanTimeout:     getEnvDurationOrDefault(EnvScanTimeout, DefaultScanTimeout),
+
+		// Orphan Reconciler
+		OrphanReconcileInterval: getEnvDurationOrDefault(EnvOrphanReconcileInterval, DefaultOrphanReconcileInterval),
+		OrphanReconcileTimeout:  getEnvDurationOrDefault(EnvOrphanReconcileTimeout, DefaultOrphanReconcileTimeout),
+		OrphanReconcileDryRun:   getEnvBoolOrDefault(EnvOrphanReconcileDryRun, true),
+
+		// Event Emission
+		EventSink:      os.Getenv(EnvEventSink),
+		DeadLetterSink: os.Getenv(EnvDeadLetterSink),
+
+		// Build Callbacks
+		CallbackSigningSecret: os.Getenv(EnvCallbackSigningSecret),
+		CallbackAllowedHosts:  List(os.Getenv(EnvCallbackAllowedHosts)),
+
+		// Event Ingestion
+		EventTransformsFile: os.Getenv(EnvEventTransformsFile),
+		EventSigningSecret:  os.Getenv(EnvEventSigningSecret),
+
+		// Event Transport
+		Transport:             getEnvOrDefault(EnvTransport, DefaultTransport),
+		KafkaBrokers:          os.Getenv(EnvKafkaBrokers),
+		KafkaTopic:            os.Getenv(EnvKafkaTopic),
+		KafkaGroup:            getEnvOrDefault(EnvKafkaGroup, DefaultKafkaGroup),
+		RabbitMQURL:           os.Getenv(EnvRabbitMQURL),
+		RabbitMQQueue:         getEnvOrDefault(EnvRabbitMQQueue, DefaultRabbitMQQueue),
+		RabbitMQPrefetch:      getEnvIntOrDefault(EnvRabbitMQPrefetch, DefaultRabbitMQPrefetch),
+		RabbitMQMaxDeliveries: getEnvIntOrDefault(EnvRabbitMQMaxDeliveries, DefaultRabbitMQMaxDeliveries),
+		NATSURL:               os.Getenv(EnvNATSURL),
+		NATSStream:            getEnvOrDefault(EnvNATSStream, DefaultNATSStream),
+		NATSSubject:           getEnvOrDefault(EnvNATSSubject, DefaultNATSSubject),
+		NATSDurable:           getEnvOrDefault(EnvNATSDurable, DefaultNATSDurable),
+		NATSEventsSubject:     getEnvOrDefault(EnvNATSEventsSubject, DefaultNATSEventsSubject),
+		NATSMaxDeliveries:     getEnvIntOrDefault(EnvNATSMaxDeliveries, DefaultNATSMaxDeliveries),
+
+		// Parser Sidecars
+		SidecarCatalogFile: os.Getenv(EnvSidecarCatalogFile),
+
+		// Observability
+		EventSampleRates:       getEnvOrDefault(EnvEventSampleRates, DefaultEventSampleRates),
+		EventSampleRateDefault: getEnvFloatOrDefault(EnvEventSampleRateDefault, DefaultEventSampleRateDefault),
+
+		// Self-Profiling
+		SelfProfileInterval:   getEnvDurationOrDefault(EnvSelfProfileInterval, 0),
+		SelfProfileCooldown:   getEnvDurationOrDefault(EnvSelfProfileCooldown, DefaultSelfProfileCooldown),
+		SelfProfileHeapBytes:  getEnvIntOrDefault(EnvSelfProfileHeapBytes, DefaultSelfProfileHeapBytes),
+		SelfProfileGoroutines: getEnvIntOrDefault(EnvSelfProfileGoroutines, DefaultSelfProfileGoroutines),
+		SelfProfileDir:        getEnvOrDefault(EnvSelfProfileDir, DefaultSelfProfileDir),
+		SelfProfileS3URI:      os.Getenv(EnvSelfProfileS3URI),
+
+		// Docker Configuration
+		BaseImage:          getEnvOrDefault(EnvBaseImage, DefaultBaseImage),
+		KanikoImage:        getEnvOrDefault(EnvKanikoImage, DefaultKanikoImage),
+		ReproducibleBuilds: getEnvBoolOrDefault(EnvReproducibleBuilds, false),
+		BuildCacheEnabled:  getEnvBoolOrDefault(EnvBuildCacheEnabled, true),
+
+		// Source Validation
+		SourceValidationEnabled: getEnvBoolOrDefault(EnvSourceValidationEnabled, true),
+		NodeBinary:              getEnvOrDefault(EnvNodeBinary, DefaultNodeBinary),
+
+		// Custom Dockerfiles
+		CustomDockerfilesEnabled: getEnvBoolOrDefault(EnvCustomDockerfilesEnabled, true),
+		DockerfileAllowedBases:   List(getEnvOrDefault(EnvDockerfileAllowedBases, DefaultDockerfileAllowedBases)),
+
+		// Python Runtime
+		PythonBaseImage: getEnvOrDefault(EnvPythonBaseImage, DefaultPythonBaseImage),
+		PythonBinary:    getEnvOrDefault(EnvPythonBinary, DefaultPythonBinary),
+
+		// Go Runtime
+		GoBaseImage:    getEnvOrDefault(EnvGoBaseImage, DefaultGoBaseImage),
+		GoRuntimeImage: getEnvOrDefault(EnvGoRuntimeImage, DefaultGoRuntimeImage),
+
+		// Git Sources
+		GitBinary:       getEnvOrDefault(EnvGitBinary, DefaultGitBinary),
+		GitToken:        os.Getenv(EnvGitToken),
+		GitAllowedHosts: List(os.Getenv(EnvGitAllowedHosts)),
+
+		// Source Store
+		SourceBackend:   getEnvOrDefault(EnvSourceBackend, SourceBackendS3),
+		SourceURI:       os.Getenv(EnvSourceURI),
+		GCSSourceBucket: os.Getenv(EnvGCSSourceBucket),
+
+		// Azure Blob Storage Sources
+		AzureStorageAccount:  os.Getenv(EnvAzureStorageAccount),
+		AzureSourceContainer: os.Getenv(EnvAzureSourceContainer),
+
+		// Inline Sources
+		InlineSourceMaxBytes: getEnvIntOrDefault(EnvInlineSourceMaxBytes, DefaultInlineSourceMaxBytes),
+
+		// Private npm Registry
+		NpmrcSecret:    os.Getenv(EnvNpmrcSecret),
+		NpmrcSecretARN: os.Getenv(EnvNpmrcSecretARN),
+
+		// Dependency Overrides
+		NpmDependencyAllowlist: List(os.Getenv(EnvNpmDependencyAllowlist)),
+
+		// Build Context
+		ContextExclude: List(os.Getenv(EnvContextExclude)),
+
+		// Image Registry
+		RegistryProvider:   getEnvOrDefault(EnvRegistryProvider, RegistryProviderECR),
+		GARProject:         os.Getenv(EnvGARProject),
+		GARLocation:        os.Getenv(EnvGARLocation),
+		GARRepository:      getEnvOrDefault(EnvGARRepository, DefaultGARRepository),
+		RegistryAuthSecret: getEnvOrDefault(EnvRegistryAuthSecret, DefaultRegistryAuthSecret),
+
+		// Azure Container Registry
+		ACRName:             os.Getenv(EnvACRName),
+		ACRRepositoryPrefix: getEnvOrDefault(EnvACRRepositoryPrefix, DefaultACRRepositoryPrefix),
+
+		// Harbor
+		HarborURL:           os.Getenv(EnvHarborURL),
+		HarborUsername:      os.Getenv(EnvHarborUsername),
+		HarborPassword:      os.Getenv(EnvHarborPassword),
+		HarborProjectPrefix: os.Getenv(EnvHarborProjectPrefix),
+		HarborProjectQuota:  getEnvIntOrDefault(EnvHarborProjectQuota, DefaultHarborProjectQuota),
+		HarborAutoScan:      getEnvBoolOrDefault(EnvHarborAutoScan, true),
+
+		// Generic Docker Registry
+		RegistryURL:      strings.TrimSuffix(os.Getenv(EnvRegistryURL), "/"),
+		RegistryInsecure: getEnvBoolOrDefault(EnvRegistryInsecure, false),
+
+		// Build Context Limits
+		ContextMaxBytes:         getEnvIntOrDefault(EnvContextMaxBytes, DefaultContextMaxBytes),
+		ContextMaxFiles:         getEnvIntOrDefault(EnvContextMaxFiles, DefaultContextMaxFiles),
+		ContextDeniedExtensions: List(os.Getenv(EnvContextDeniedExtensions)),
+
+		// Build Context Encryption
+		ContextKMSKeyARN:         os.Getenv(EnvContextKMSKeyARN),
+		ContextRequireEncryption: getEnvBoolOrDefault(EnvContextRequireEncryption, false),
+
+		// Build Context Handoff
+		ContextPresignTTL: getEnvDurationOrDefault(EnvContextPresignTTL, 0),
+
+		// Build Backend
+		BuildBackend:            getEnvOrDefault(EnvBuildBackend, DefaultBuildBackend),
+		BuildKitJobTemplatePath: getEnvOrDefault(EnvBuildKitJobTemplatePath, DefaultBuildKitJobTemplatePath),
+		BuildKitAddr:            getEnvOrDefault(EnvBuildKitAddr, DefaultBuildKitAddr),
+		BuildKitImage:           getEnvOrDefault(EnvBuildKitImage, DefaultBuildKitImage),
+		BuildKitSecrets:         List(os.Getenv(EnvBuildKitSecrets)),
+		BuildPlatforms:          getEnvOrDefault(EnvBuildPlatforms, DefaultBuildPlatforms),
+
+		// Kaniko Layer Cache
+		KanikoCacheEnabled: getEnvBoolOrDefault(EnvKanikoCacheEnabled, true),
+		KanikoCacheTTL:     getEnvDurationOrDefault(EnvKanikoCacheTTL, DefaultKanikoCacheTTL),
+
+		// Build Context Cleanup
+		ContextCleanupEnabled: getEnvBoolOrDefault(EnvContextCleanupEnabled, true),
+		ContextRetention:      getEnvDurationOrDefault(EnvContextRetention, 0),
+		BuildGCInterval:       getEnvDurationOrDefault(EnvBuildGCInterval, DefaultBuildGCInterval),
+		BuildGCJobAge:         getEnvDurationOrDefault(EnvBuildGCJobAge, DefaultBuildGCJobAge),
+		BuildGCContextAge:     getEnvDurationOrDefault(EnvBuildGCContextAge, DefaultBuildGCContextAge),
+
+		// Build Scheduling
+		BuildPriorityClass:     os.Getenv(EnvBuildPriorityClass),
+		BuildPreemptionRetries: getEnvIntOrDefault(EnvBuildPreemptionRetries, DefaultBuildPreemptionRetries),
+		BuildPreemptionBackoff: getEnvDurationOrDefault(EnvBuildPreemptionBackoff, DefaultBuildPreemptionBackoff),
+		BuildRetries:           getEnvIntOrDefault(EnvBuildRetries, DefaultBuildRetries),
+		BuildRetryBackoff:      getEnvDurationOrDefault(EnvBuildRetryBackoff, DefaultBuildRetryBackoff),
+		BuildTimeout:           getEnvDurationOrDefault(EnvBuildTimeout, DefaultBuildTimeout),
+		BuildReaperInterval:    getEnvDurationOrDefault(EnvBuildReaperInterval, DefaultBuildReaperInterval),
+		BuildDedupWindow:       getEnvDurationOrDefault(EnvBuildDedupWindow, DefaultBuildDedupWindow),
+		BuildDedupHistory:      getEnvBoolOrDefault(EnvBuildDedupHistory, false),
+		BuildRateLimit:         os.Getenv(EnvBuildRateLimit),
+		MaxConcurrentBuilds:    getEnvIntOrDefault(EnvMaxConcurrentBuilds, DefaultMaxConcurrentBuilds),
+		BuildWorkers:           getEnvIntOrDefault(EnvBuildWorkers, DefaultBuildWorkers),
+		BuildQueueSize:         getEnvIntOrDefault(EnvBuildQueueSize, DefaultBuildQueueSize),
+
+		// Build Resources
+		BuildResourceRequests: getEnvOrDefault(EnvBuildResourceRequests, DefaultBuildResourceRequests),
+		BuildResourceLimits:   getEnvOrDefault(EnvBuildResourceLimits, DefaultBuildResourceLimits),
+		BuildResourceMax:      getEnvOrDefault(EnvBuildResourceMax, DefaultBuildResourceMax),
+
+		// Parser Tests
+		ParserTestsEnabled: getEnvBoolOrDefault(EnvParserTestsEnabled, true),
+		ParserTestTimeout:  getEnvDurationOrDefault(EnvParserTestTimeout, DefaultParserTestTimeout),
+
+		// SBOMs
+		SBOMEnabled:         getEnvBoolOrDefault(EnvSBOMEnabled, false),
+		SBOMJobTemplatePath: getEnvOrDefault(EnvSBOMJobTemplatePath, DefaultSBOMJobTemplatePath),
+		SyftImage:           getEnvOrDefault(EnvSyftImage, DefaultSyftImage),
+
+		// Share Links
+		ShareLinkSecret:     os.Getenv(EnvShareLinkSecret),
+		ShareLinkDefaultTTL: getEnvDurationOrDefault(EnvShareLinkDefaultTTL, DefaultShareLinkDefaultTTL),
+		ShareLinkMaxTTL:     getEnvDurationOrDefault(EnvShareLinkMaxTTL, DefaultShareLinkMaxTTL),
+		ShareLinkBaseURL:    strings.TrimSuffix(os.Getenv(EnvShareLinkBaseURL), "/"),
+
+		// Authentication
+		OIDCIssuers:         os.Getenv(EnvOIDCIssuers),
+		OIDCAudiences:       os.Getenv(EnvOIDCAudiences),
+		OIDCTenantsClaim:    getEnvOrDefault(EnvOIDCTenantsClaim, DefaultOIDCTenantsClaim),
+		OIDCTrustedSubjects: os.Getenv(EnvOIDCTrustedSubjects),
 
 		// Constants
 		KubernetesNamespace:   DefaultKubernetesNamespace,
@@ -72,6 +965,163 @@ func Load() *Config {
 	}
 }
 
+// GARRegistry returns the registry URL of the gar backend,
+// <location>-docker.pkg.dev/<project>/<repository>
+func (c *Config) GARRegistry() (string, error) {
+	if c.GARProject == "" || c.GARLocation == "" || c.GARRepository == "" {
+		return "", fmt.Errorf("%s=gar needs %s, %s and %s", EnvRegistryProvider, EnvGARProject, EnvGARLocation, EnvGARRepository)
+	}
+	return fmt.Sprintf("%s-docker.pkg.dev/%s/%s", c.GARLocation, c.GARProject, c.GARRepository), nil
+}
+
+// ECRRepositoryLifecyclePolicy returns the lifecycle policy put on new tenant
+// repositories ("" for none)
+func (c *Config) ECRRepositoryLifecyclePolicy() (string, error) {
+	if c.ECRLifecyclePolicy == "" || c.ECRLifecyclePolicy == ECRLifecyclePolicyNone {
+		return "", nil
+	}
+	var policy struct {
+		Rules []json.RawMessage `json:"rules"`
+	}
+	if err := json.Unmarshal([]byte(c.ECRLifecyclePolicy), &policy); err != nil {
+		return "", fmt.Errorf("%s is not a lifecycle policy: %w", EnvEcrLifecyclePolicy, err)
+	}
+	if len(policy.Rules) == 0 {
+		return "", fmt.Errorf("%s has no rules (use %q for no policy)", EnvEcrLifecyclePolicy, ECRLifecyclePolicyNone)
+	}
+	return c.ECRLifecyclePolicy, nil
+}
+
+// TenantNamespaceIngressFrom returns the namespaces allowed to reach the
+// parsers of tenant namespaces (nil = no NetworkPolicy)
+func (c *Config) TenantNamespaceIngressFrom() []string {
+	if c.TenantNamespaceAllowedNamespaces == TenantNamespaceAllowedNone {
+		return nil
+	}
+	return List(c.TenantNamespaceAllowedNamespaces)
+}
+
+// ValidateECRRepositorySettings checks the settings of new tenant repositories
+func (c *Config) ValidateECRRepositorySettings() error {
+	if c.ECRTagMutability != ECRTagMutabilityMutable && c.ECRTagMutability != ECRTagMutabilityImmutable {
+		return fmt.Errorf("%s %q: expected %s or %s", EnvEcrTagMutability, c.ECRTagMutability, ECRTagMutabilityMutable, ECRTagMutabilityImmutable)
+	}
+	if c.ECREncryption != ECREncryptionAES256 && c.ECREncryption != ECREncryptionKMS {
+		return fmt.Errorf("%s %q: expected %s or %s", EnvEcrEncryption, c.ECREncryption, ECREncryptionAES256, ECREncryptionKMS)
+	}
+	if c.ECRKMSKey != "" && c.ECREncryption != ECREncryptionKMS {
+		return fmt.Errorf("%s needs %s=%s", EnvEcrKMSKey, EnvEcrEncryption, ECREncryptionKMS)
+	}
+	return nil
+}
+
+// ACRLoginServer returns the login server of the acr provider, e.g.
+// acmeplatform.azurecr.io ("" when ACR_NAME is not set)
+func (c *Config) ACRLoginServer() string {
+	if c.ACRName == "" || strings.Contains(c.ACRName, ".") {
+		return strings.TrimSuffix(c.ACRName, "/")
+	}
+	return strings.ToLower(c.ACRName) + ".azurecr.io"
+}
+
+// ACRRegistry returns the registry URL of the acr provider, <login server>/<prefix>
+func (c *Config) ACRRegistry() (string, error) {
+	if c.ACRName == "" {
+		return "", fmt.Errorf("%s=acr needs %s", EnvRegistryProvider, EnvACRName)
+	}
+	if prefix := strings.Trim(c.ACRRepositoryPrefix, "/"); prefix != "" {
+		return c.ACRLoginServer() + "/" + prefix, nil
+	}
+	return c.ACRLoginServer(), nil
+}
+
+// HarborRegistry returns the registry host of the harbor provider
+// (HARBOR_URL without its scheme)
+func (c *Config) HarborRegistry() (string, error) {
+	if c.HarborURL == "" {
+		return "", fmt.Errorf("%s=harbor needs %s", EnvRegistryProvider, EnvHarborURL)
+	}
+	host := c.HarborURL
+	if _, rest, ok := strings.Cut(host, "://"); ok {
+		host = rest
+	}
+	return strings.TrimSuffix(host, "/"), nil
+}
+
+// SourceLocation returns the backend (s3, gcs or azure) and bucket parser
+// sources are read from: SOURCE_URI's when set, else SOURCE_BACKEND's
+// 📝 NOTE: The "bucket" of the azure backend is account/container
+func (c *Config) SourceLocation() (backend, bucket string, err error) {
+	if c.SourceURI != "" {
+		scheme, rest, ok := strings.Cut(c.SourceURI, "://")
+		bucket = strings.TrimSuffix(rest, "/")
+		if scheme == "az" {
+			account, container, found := strings.Cut(bucket, "/")
+			if !ok || !found || account == "" || container == "" || strings.Contains(container, "/") {
+				return "", "", fmt.Errorf("invalid %s %q: expected az://account/container", EnvSourceURI, c.SourceURI)
+			}
+			return SourceBackendAzure, bucket, nil
+		}
+		if !ok || bucket == "" || strings.Contains(bucket, "/") {
+			return "", "", fmt.Errorf("invalid %s %q: expected s3://bucket, gs://bucket or az://account/container", EnvSourceURI, c.SourceURI)
+		}
+		switch scheme {
+		case "s3":
+			return SourceBackendS3, bucket, nil
+		case "gs":
+			return SourceBackendGCS, bucket, nil
+		}
+		return "", "", fmt.Errorf("invalid %s %q: unsupported scheme %s (s3, gs or az)", EnvSourceURI, c.SourceURI, scheme)
+	}
+	switch c.SourceBackend {
+	case "", SourceBackendS3:
+		return SourceBackendS3, c.S3SourceBucket, nil
+	case SourceBackendGCS:
+		return SourceBackendGCS, c.GCSSourceBucket, nil
+	case SourceBackendAzure:
+		if c.AzureStorageAccount == "" || c.AzureSourceContainer == "" {
+			return "", "", fmt.Errorf("%s=azure needs %s and %s", EnvSourceBackend, EnvAzureStorageAccount, EnvAzureSourceContainer)
+		}
+		return SourceBackendAzure, c.AzureStorageAccount + "/" + c.AzureSourceContainer, nil
+	}
+	return "", "", fmt.Errorf("invalid %s %q: expected s3, gcs or azure", EnvSourceBackend, c.SourceBackend)
+}
+
+// TemplatePaths lists every template the builder renders
+// 📝 NOTE: Explicit paths plus every *.tpl in TemplatesDir and its runtimes/<runtime>/
+// directories, without duplicates
+func (c *Config) TemplatePaths() []string {
+	paths := []string{c.JobTemplatePath, c.ServiceTemplatePath, c.FallbackTemplatePath, c.TriggerTemplatePath, c.TestJobTemplatePath,
+		c.BuildKitJobTemplatePath, c.SBOMJobTemplatePath}
+	for _, pattern := range []string{"*.tpl", "runtimes/*/*.tpl"} {
+		if bundled, err := filepath.Glob(filepath.Join(c.TemplatesDir, pattern)); err == nil {
+			paths = append(paths, bundled...)
+		}
+	}
+
+	seen := map[string]bool{}
+	unique := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if path == "" || seen[filepath.Clean(path)] {
+			continue
+		}
+		seen[filepath.Clean(path)] = true
+		unique = append(unique, path)
+	}
+	return unique
+}
+
+// List splits a comma separated setting, dropping blank items
+func List(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // getEnvOrDefault returns environment variable value or default if not set
 func getEnvOrDefault(envVar, defaultValue string) string {
 	if value := os.Getenv(envVar); value != "" {
@@ -79,3 +1129,59 @@ func getEnvOrDefault(envVar, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvDurationOrDefault parses a duration (e.g. "90s", "2m") or returns the default
+func getEnvDurationOrDefault(envVar string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(envVar)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("WARNING: Invalid %s=%q, using default %s", envVar, value, defaultValue)
+		return defaultValue
+	}
+	return d
+}
+
+// getEnvIntOrDefault parses an integer or returns the default
+func getEnvIntOrDefault(envVar string, defaultValue int) int {
+	value := os.Getenv(envVar)
+	if value == "" {
+		return defaultValue
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("WARNING: Invalid %s=%q, using default %d", envVar, value, defaultValue)
+		return defaultValue
+	}
+	return i
+}
+
+// getEnvFloatOrDefault parses a float or returns the default
+func getEnvFloatOrDefault(envVar string, defaultValue float64) float64 {
+	value := os.Getenv(envVar)
+	if value == "" {
+		return defaultValue
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("WARNING: Invalid %s=%q, using default %v", envVar, value, defaultValue)
+		return defaultValue
+	}
+	return f
+}
+
+// getEnvBoolOrDefault parses a boolean ("true", "1", ...) or returns the default
+func getEnvBoolOrDefault(envVar string, defaultValue bool) bool {
+	value := os.Getenv(envVar)
+	if value == "" {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("WARNING: Invalid %s=%q, using default %v", envVar, value, defaultValue)
+		return defaultValue
+	}
+	return b
+}
@@ -0,0 +1,120 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"knative-lambda-builder/internal/history"
+	"knative-lambda-builder/internal/tenants"
+	"knative-lambda-builder/internal/types"
+)
+
+// fakeTenants maps thirdPartyId to its KMS key ("" = registered without key)
+type fakeTenants map[string]string
+
+func (f fakeTenants) Get(ctx context.Context, thirdPartyId string) (*tenants.Tenant, error) {
+	keyID, ok := f[thirdPartyId]
+	if !ok {
+		return nil, tenants.ErrNotFound
+	}
+	return &tenants.Tenant{ThirdPartyId: thirdPartyId, KMSKeyARN: keyID}, nil
+}
+func (f fakeTenants) List(ctx context.Context) ([]tenants.Tenant, error)   { return nil, nil }
+func (f fakeTenants) Put(ctx context.Context, tenant tenants.Tenant) error { return nil }
+
+type fakeArtifacts []string
+
+func (f *fakeArtifacts) ReencryptArtifacts(ctx context.Context, be types.BuildEvent) (int, error) {
+	*f = append(*f, be.ParserId)
+	return 1, nil
+}
+
+func TestSealOpen(t *testing.T) {
+	ctx := context.Background()
+	keys := NewFakeKeyService("key-a", "key-b")
+	plaintext := []byte("SyntaxError: unexpected token in acme.js")
+
+	sealed, err := Seal(ctx, keys, "key-a", plaintext)
+	if err != nil {
+		t.Fatalf("Seal() error: %v", err)
+	}
+	if !IsSealed(sealed) || bytes.Contains(sealed, plaintext) {
+		t.Fatalf("sealed value leaks plaintext or lacks prefix: %s", sealed)
+	}
+	if keyID, _ := SealedKeyID(sealed); keyID != "key-a" {
+		t.Errorf("SealedKeyID() = %q, want key-a", keyID)
+	}
+
+	opened, err := Open(ctx, keys, sealed)
+	if err != nil || !bytes.Equal(opened, plaintext) {
+		t.Fatalf("Open() = %q, %v; want %q", opened, err, plaintext)
+	}
+
+	// Swapping the key ID in the envelope must not decrypt (it is authenticated)
+	tampered := bytes.Replace(sealed, []byte(`"key-a"`), []byte(`"key-b"`), 1)
+	if _, err := Open(ctx, keys, tampered); err == nil {
+		t.Error("Open() with a swapped key ID should fail")
+	}
+
+	// Tenants without a key (or unregistered) are stored in plaintext
+	tenantKeys := NewTenantKeys(fakeTenants{"acme": "key-a", "plain": ""}, keys)
+	for _, tid := range []string{"plain", "unknown"} {
+		out, err := tenantKeys.Seal(ctx, tid, plaintext)
+		if err != nil || !bytes.Equal(out, plaintext) {
+			t.Errorf("Seal(%s) = %q, %v; want plaintext", tid, out, err)
+		}
+	}
+	if out, err := tenantKeys.Open(ctx, plaintext); err != nil || !bytes.Equal(out, plaintext) {
+		t.Errorf("Open() of a plaintext value = %q, %v", out, err)
+	}
+}
+
+func TestReencrypt(t *testing.T) {
+	ctx := context.Background()
+	keys := NewFakeKeyService("old", "new")
+	registry := fakeTenants{"acme": ""}
+	tenantKeys := NewTenantKeys(registry, keys)
+
+	raw := history.NewMemoryStore()
+	store := history.NewEncryptedStore(raw, tenantKeys)
+
+	// Recorded before the tenant had a key, then with the old key
+	store.Record(ctx, "acme", "p1", history.StatusFailing, "plain failure")
+	registry["acme"] = "old"
+	store.Record(ctx, "acme", "p2", history.StatusTesting, "")
+	store.AttachReport(ctx, "acme", "p2", strings.Repeat("ok\n", 2000))
+
+	stored, _ := raw.List(ctx, "acme", "p2")
+	if keyID, _ := SealedKeyID([]byte(stored[0].TestReport)); keyID != "old" {
+		t.Fatalf("report should be sealed with the old key, got %q", stored[0].TestReport)
+	}
+	if len(stored[0].TestReport) > history.MaxReportBytes {
+		t.Errorf("sealed report is %d bytes, above MaxReportBytes", len(stored[0].TestReport))
+	}
+
+	registry["acme"] = "new"
+	artifacts := &fakeArtifacts{}
+	report, err := NewReencryptor(tenantKeys, store, artifacts).Tenant(ctx, "acme")
+	if err != nil {
+		t.Fatalf("Tenant() error: %v", err)
+	}
+	if report.Parsers != 2 || report.Records != 2 || report.Artifacts != 2 || len(report.Errors) != 0 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	for _, pid := range []string{"p1", "p2"} {
+		stored, _ := raw.List(ctx, "acme", pid)
+		for _, value := range []string{stored[0].Message, stored[0].TestReport} {
+			if keyID, ok := SealedKeyID([]byte(value)); value != "" && (!ok || keyID != "new") {
+				t.Errorf("%s: value not re-encrypted with the new key: %.40s", pid, value)
+			}
+		}
+	}
+
+	entries, _ := store.List(ctx, "acme", "p1")
+	if entries[0].Message != "plain failure" {
+		t.Errorf("List() should decrypt messages, got %q", entries[0].Message)
+	}
+}
@@ -0,0 +1,117 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+)
+
+// =============================================================================
+// ✉️ ENVELOPE ENCRYPTION
+// =============================================================================
+// Every sealed value gets its own AES-256 data key from KMS. The data key
+// encrypts the value (AES-GCM) and is stored next to it, encrypted under the
+// tenant's KMS key, so reading a value requires kms:Decrypt on that key
+// 🎯 PURPOSE: Tenants with strict isolation requirements hold the keys to their data
+
+// sealedPrefix marks sealed values (the rest is the JSON envelope)
+var sealedPrefix = []byte("enc:v1:")
+
+// KeyService generates and decrypts data keys (aws.KMS, FakeKeyService)
+type KeyService interface {
+	GenerateDataKey(ctx context.Context, keyID string) (plaintext, encrypted []byte, err error)
+	Decrypt(ctx context.Context, keyID string, encrypted []byte) ([]byte, error)
+}
+
+// envelope is what a sealed value is made of
+type envelope struct {
+	KeyID      string `json:"keyId"`   // KMS key the data key is encrypted under
+	DataKey    []byte `json:"dataKey"` // Encrypted data key
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// IsSealed reports whether data was produced by Seal
+func IsSealed(data []byte) bool {
+	return bytes.HasPrefix(data, sealedPrefix)
+}
+
+// SealedKeyID returns the KMS key a sealed value is encrypted under
+func SealedKeyID(data []byte) (string, bool) {
+	env, err := decodeEnvelope(data)
+	if err != nil {
+		return "", false
+	}
+	return env.KeyID, true
+}
+
+// Seal encrypts plaintext under a fresh data key protected by keyID
+func Seal(ctx context.Context, keys KeyService, keyID string, plaintext []byte) ([]byte, error) {
+	dataKey, encryptedKey, err := keys.GenerateDataKey(ctx, keyID)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	raw, err := json.Marshal(envelope{
+		KeyID:      keyID,
+		DataKey:    encryptedKey,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, []byte(keyID)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode envelope: %w", err)
+	}
+	return append(append([]byte{}, sealedPrefix...), raw...), nil
+}
+
+// Open decrypts a value produced by Seal
+func Open(ctx context.Context, keys KeyService, sealed []byte) ([]byte, error) {
+	env, err := decodeEnvelope(sealed)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := keys.Decrypt(ctx, env.KeyID, env.DataKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, env.Nonce, env.Ciphertext, []byte(env.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt value sealed with %s: %w", env.KeyID, err)
+	}
+	return plaintext, nil
+}
+
+func decodeEnvelope(data []byte) (*envelope, error) {
+	if !IsSealed(data) {
+		return nil, fmt.Errorf("value is not sealed")
+	}
+	var env envelope
+	if err := json.Unmarshal(data[len(sealedPrefix):], &env); err != nil {
+		return nil, fmt.Errorf("failed to decode envelope: %w", err)
+	}
+	return &env, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
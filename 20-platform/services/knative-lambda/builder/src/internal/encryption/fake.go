@@ -0,0 +1,84 @@
+package encryption
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"sync"
+)
+
+// =============================================================================
+// 🧪 FAKE KEY SERVICE
+// =============================================================================
+// In-memory stand-in for KMS: one random master key per key ID
+
+// FakeKeyService generates and decrypts data keys without KMS
+type FakeKeyService struct {
+	mu      sync.Mutex
+	masters map[string][]byte
+}
+
+// NewFakeKeyService creates a key service knowing the given key IDs
+func NewFakeKeyService(keyIDs ...string) *FakeKeyService {
+	f := &FakeKeyService{masters: map[string][]byte{}}
+	for _, keyID := range keyIDs {
+		f.AddKey(keyID)
+	}
+	return f
+}
+
+// AddKey creates a key (test setup)
+func (f *FakeKeyService) AddKey(keyID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	master := make([]byte, 32)
+	_, _ = rand.Read(master)
+	f.masters[keyID] = master
+}
+
+// GenerateDataKey implements KeyService
+func (f *FakeKeyService) GenerateDataKey(ctx context.Context, keyID string) ([]byte, []byte, error) {
+	master, err := f.master(keyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, nil, err
+	}
+	gcm, err := newGCM(master)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	return dataKey, gcm.Seal(nonce, nonce, dataKey, nil), nil
+}
+
+// Decrypt implements KeyService
+func (f *FakeKeyService) Decrypt(ctx context.Context, keyID string, encrypted []byte) ([]byte, error) {
+	master, err := f.master(keyID)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(master)
+	if err != nil {
+		return nil, err
+	}
+	if len(encrypted) < gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid data key")
+	}
+	return gcm.Open(nil, encrypted[:gcm.NonceSize()], encrypted[gcm.NonceSize():], nil)
+}
+
+func (f *FakeKeyService) master(keyID string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	master, ok := f.masters[keyID]
+	if !ok {
+		return nil, fmt.Errorf("NotFoundException: key %s does not exist", keyID)
+	}
+	return master, nil
+}
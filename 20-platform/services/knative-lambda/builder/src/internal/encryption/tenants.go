@@ -0,0 +1,54 @@
+package encryption
+
+import (
+	"context"
+	"errors"
+
+	"knative-lambda-builder/internal/tenants"
+)
+
+// =============================================================================
+// 🏢 PER-TENANT KEYS
+// =============================================================================
+// The KMS key of a tenant is part of its record in the tenant registry
+// (kmsKeyArn). Tenants without one are stored in plaintext, as before.
+
+// TenantKeys seals values with the KMS key configured for their tenant
+type TenantKeys struct {
+	tenants tenants.Store
+	keys    KeyService
+}
+
+// NewTenantKeys creates a per-tenant sealer
+func NewTenantKeys(store tenants.Store, keys KeyService) *TenantKeys {
+	return &TenantKeys{tenants: store, keys: keys}
+}
+
+// KeyID returns the KMS key of a tenant ("" if it has none or isn't registered)
+func (t *TenantKeys) KeyID(ctx context.Context, thirdPartyId string) (string, error) {
+	tenant, err := t.tenants.Get(ctx, thirdPartyId)
+	if errors.Is(err, tenants.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return tenant.KMSKeyARN, nil
+}
+
+// Seal encrypts plaintext with the tenant's key (returned as is without a key)
+func (t *TenantKeys) Seal(ctx context.Context, thirdPartyId string, plaintext []byte) ([]byte, error) {
+	keyID, err := t.KeyID(ctx, thirdPartyId)
+	if err != nil || keyID == "" {
+		return plaintext, err
+	}
+	return Seal(ctx, t.keys, keyID, plaintext)
+}
+
+// Open decrypts a sealed value (values that aren't sealed are returned as is)
+func (t *TenantKeys) Open(ctx context.Context, data []byte) ([]byte, error) {
+	if !IsSealed(data) {
+		return data, nil
+	}
+	return Open(ctx, t.keys, data)
+}
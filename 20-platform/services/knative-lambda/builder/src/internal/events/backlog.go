@@ -0,0 +1,135 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"knative-lambda-builder/internal/observability"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🚧 BUILD BACKLOG
+// =============================================================================
+// Every accepted build waits in the build queue until a build worker has
+// created its Kaniko job (behind MAX_CONCURRENT_BUILDS). BUILD_QUEUE_SIZE bounds
+// how many may wait in a replica; past it, build requests are refused (503)
+// and reported as build.rejected
+// 🎯 WHY: A burst of events would otherwise pile up waiting builds without limit
+// 📝 NOTE: Requeued builds were already accepted and don't count
+
+// RejectedQueueFull is the build.rejected reason of builds refused by a full backlog
+const RejectedQueueFull = "queue_full"
+
+// queueFullRetryAfter is suggested to requests refused by a full backlog
+const queueFullRetryAfter = 30 * time.Second
+
+// QueueFullError is returned for a build refused because the backlog is full
+type QueueFullError struct {
+	Size       int
+	RetryAfter time.Duration
+}
+
+func (e *QueueFullError) Error() string {
+	return fmt.Sprintf("build queue is full (%d builds waiting), retry in %s", e.Size, e.RetryAfter.Round(time.Second))
+}
+
+// RetryAfterSeconds rounds RetryAfter up to whole seconds (Retry-After)
+func (e *QueueFullError) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+// QueueFull marks the error as the builder being busy (API: 503)
+func (e *QueueFullError) QueueFull() bool {
+	return true
+}
+
+// buildBacklog counts the accepted builds whose job isn't created yet
+type buildBacklog struct {
+	mu      sync.Mutex
+	size    int // 0 = unbounded
+	waiting int
+}
+
+// admit takes a place in the backlog; false when it is full
+func (b *buildBacklog) admit() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.size > 0 && b.waiting >= b.size {
+		return false
+	}
+	b.waiting++
+	observability.BuildBacklog.Set(float64(b.waiting))
+	return true
+}
+
+// done gives back a place taken by admit
+func (b *buildBacklog) done() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.waiting--
+	observability.BuildBacklog.Set(float64(b.waiting))
+}
+
+// pending returns how many builds wait for their job
+func (b *buildBacklog) pending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.waiting
+}
+
+// Backlog returns how many accepted builds wait for their job
+func (h *Handler) Backlog() int {
+	return h.backlog.pending()
+}
+
+// drainPollInterval is how often Drain checks the backlog
+var drainPollInterval = time.Second
+
+// Drain waits until every accepted build has its job (or ctx is done), and
+// returns how many builds still wait
+// 🎯 WHY: A replica shutting down (e.g. during a rollout of the builder) must
+// not lose builds it accepted; once their jobs exist, any replica finishes them
+func (h *Handler) Drain(ctx context.Context) int {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for {
+		waiting := h.backlog.pending()
+		if waiting <= 0 {
+			return 0
+		}
+		select {
+		case <-ctx.Done():
+			return waiting
+		case <-ticker.C:
+		}
+	}
+}
+
+// WithBuildQueueSize bounds how many accepted builds may wait to start
+func (h *Handler) WithBuildQueueSize(size int) *Handler {
+	h.backlog.size = size
+	return h
+}
+
+// admitBuild takes a place in the backlog for a build, or reports it as rejected
+func (h *Handler) admitBuild(ctx context.Context, be types.BuildEvent) error {
+	if h.backlog.admit() {
+		return nil
+	}
+
+	err := &QueueFullError{Size: h.backlog.size, RetryAfter: queueFullRetryAfter}
+	observability.BuildsRefusedQueueFull.Inc()
+	log.Printf("ERROR: Rejected build of %s/%s: %v", be.ThirdPartyId, be.ParserId, err)
+
+	rejected := lifecycleData(be)
+	rejected.Reason = RejectedQueueFull
+	rejected.Error = err.Error()
+	rejected.RetryAfter = err.RetryAfterSeconds()
+	h.emitLifecycle(ctx, EventTypeBuildRejected, rejected)
+	return err
+}
@@ -0,0 +1,24 @@
+package events
+
+import "testing"
+
+func TestBuildBacklog(t *testing.T) {
+	backlog := buildBacklog{size: 2}
+	if !backlog.admit() || !backlog.admit() {
+		t.Fatal("admit() = false below the size, want true")
+	}
+	if backlog.admit() {
+		t.Error("admit() on a full backlog = true, want false")
+	}
+	backlog.done()
+	if !backlog.admit() {
+		t.Error("admit() after done() = false, want true")
+	}
+
+	unbounded := buildBacklog{}
+	for i := 0; i < 1000; i++ {
+		if !unbounded.admit() {
+			t.Fatalf("admit() #%d on an unbounded backlog = false", i+1)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 📦 BATCH BUILDS
+// =============================================================================
+// build.batch asks for several parsers of a tenant at once. It is fanned out
+// into one build per parser (build id <batchId>-<parserId>), each going
+// through the same pipeline as a build.start. Once every build has deployed,
+// failed or been rejected, batch.completed reports them all:
+//
+//	build.batch -> build.accepted ... build.deployed|build.failed (per parser)
+//	            -> batch.completed
+//
+// 📝 NOTE: Batches are tracked in memory by the replica that received them,
+// like the build registry
+
+// Batch CloudEvent types
+const (
+	EventTypeBuildBatch     = "network.notifi.lambda.build.batch"
+	EventTypeBatchCompleted = "network.notifi.lambda.batch.completed"
+)
+
+// Statuses of the builds of a batch
+const (
+	BatchBuildPending  = "pending"
+	BatchBuildDeployed = "deployed"
+	BatchBuildFailed   = "failed"
+	BatchBuildRejected = "rejected"
+)
+
+// batchState is a batch whose builds aren't all done
+type batchState struct {
+	thirdPartyId string
+	builds       []types.BatchBuildResult
+	pending      int
+	at           time.Time
+}
+
+// batchTracker follows the builds of the batches in flight
+type batchTracker struct {
+	mu      sync.Mutex
+	batches map[string]*batchState // batchId -> state
+}
+
+// start tracks a batch; false if it is already tracked (a redelivery)
+func (t *batchTracker) start(batchId, thirdPartyId string, builds []types.BuildEvent) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for id, batch := range t.batches {
+		if now.Sub(batch.at) > buildMemory {
+			log.Printf("WARNING: Forgetting batch %s of %s, %d builds never finished", id, batch.thirdPartyId, batch.pending)
+			delete(t.batches, id)
+		}
+	}
+	if _, ok := t.batches[batchId]; ok {
+		return false
+	}
+	if t.batches == nil {
+		t.batches = map[string]*batchState{}
+	}
+
+	batch := &batchState{thirdPartyId: thirdPartyId, pending: len(builds), at: now}
+	for _, be := range builds {
+		batch.builds = append(batch.builds, types.BatchBuildResult{ParserId: be.ParserId, BuildId: be.ID, Status: BatchBuildPending})
+	}
+	t.batches[batchId] = batch
+	return true
+}
+
+// finish records the outcome of a build; returns the batch's report once it
+// was the last one pending
+// 📝 NOTE: Only the first outcome of a build counts (a deploy that fails after
+// its tests passed is reported once, as failed)
+func (t *batchTracker) finish(batchId, buildId string, result types.BatchBuildResult) (types.BatchCompletedEventData, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	batch, ok := t.batches[batchId]
+	if !ok {
+		return types.BatchCompletedEventData{}, false
+	}
+	for i := range batch.builds {
+		build := &batch.builds[i]
+		if build.BuildId != buildId || build.Status != BatchBuildPending {
+			continue
+		}
+		build.Status, build.Stage, build.Error = result.Status, result.Stage, result.Error
+		batch.pending--
+	}
+	if batch.pending > 0 {
+		return types.BatchCompletedEventData{}, false
+	}
+	delete(t.batches, batchId)
+
+	completed := types.BatchCompletedEventData{ThirdPartyId: batch.thirdPartyId, BatchId: batchId, Builds: batch.builds}
+	for _, build := range batch.builds {
+		if build.Status == BatchBuildDeployed {
+			completed.Deployed++
+		} else {
+			completed.Failed++
+		}
+	}
+	return completed, true
+}
+
+// handleBatchBuild fans a build.batch out into one build per parser
+// 📝 NOTE: Builds refused by the rate limit are reported as rejected in
+// batch.completed; the event itself is never refused once fanned out
+func (h *Handler) handleBatchBuild(ctx context.Context, event cloudevents.Event) error {
+	var batch types.BatchBuildEvent
+	if err := event.DataAs(&batch); err != nil {
+		log.Printf("ERROR: Failed to parse batch build event: %v", err)
+		return fmt.Errorf("failed to parse batch build event: %w", err)
+	}
+	if err := authorize(ctx, event, batch.ThirdPartyId); err != nil {
+		return err
+	}
+	if batch.ID == "" {
+		batch.ID = event.ID() // Redeliveries share it
+	}
+
+	var builds []types.BuildEvent
+	seen := map[string]bool{}
+	for _, parserId := range batch.ParserIds {
+		if seen[parserId] {
+			continue
+		}
+		seen[parserId] = true
+		be := types.BuildEvent{ThirdPartyId: batch.ThirdPartyId, ParserId: parserId, ID: batch.ID + "-" + parserId,
+			Priority: batch.Priority, BatchId: batch.ID}
+		// Dead letters replay the parser's own build, not the whole batch
+		be.Origin = buildRequestOrigin(be, event.Source())
+		builds = append(builds, be)
+	}
+
+	if !h.batches.start(batch.ID, batch.ThirdPartyId, builds) {
+		log.Printf("🔁 Batch %s of %s is already in progress, ignoring the duplicate", batch.ID, batch.ThirdPartyId)
+		return nil
+	}
+	log.Printf("📦 Batch %s of %s: building %d parsers", batch.ID, batch.ThirdPartyId, len(builds))
+
+	for _, be := range builds {
+		if _, err := h.acceptBuild(ctx, be); err != nil {
+			log.Printf("WARNING: Build %s of batch %s not accepted: %v", be.ID, batch.ID, err)
+			h.finishBatchBuild(ctx, be, types.BatchBuildResult{Status: BatchBuildRejected, Error: err.Error()})
+		}
+	}
+	return nil
+}
+
+// finishBatchBuild records the outcome of a batch's build, emitting
+// batch.completed once it was the last one
+func (h *Handler) finishBatchBuild(ctx context.Context, be types.BuildEvent, result types.BatchBuildResult) {
+	if be.BatchId == "" {
+		return
+	}
+	completed, done := h.batches.finish(be.BatchId, be.ID, result)
+	if !done {
+		return
+	}
+
+	log.Printf("📦 Batch %s of %s completed: %d deployed, %d failed",
+		completed.BatchId, completed.ThirdPartyId, completed.Deployed, completed.Failed)
+	if err := h.emitter.Emit(ctx, EventTypeBatchCompleted, completed.ThirdPartyId, completed); err != nil {
+		log.Printf("ERROR: Failed to emit %s: %v", EventTypeBatchCompleted, err)
+	}
+}
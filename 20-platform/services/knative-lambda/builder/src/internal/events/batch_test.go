@@ -0,0 +1,41 @@
+package events
+
+import (
+	"testing"
+
+	"knative-lambda-builder/internal/types"
+)
+
+func TestBatchTracker(t *testing.T) {
+	var batches batchTracker
+	builds := []types.BuildEvent{
+		{ThirdPartyId: "acme", ParserId: "a", ID: "b1-a"},
+		{ThirdPartyId: "acme", ParserId: "b", ID: "b1-b"},
+	}
+	if !batches.start("b1", "acme", builds) {
+		t.Fatal("start(b1) = false, want the batch tracked")
+	}
+	if batches.start("b1", "acme", builds) {
+		t.Error("start(b1) again = true, want the redelivery ignored")
+	}
+
+	if _, done := batches.finish("b1", "b1-a", types.BatchBuildResult{Status: BatchBuildDeployed}); done {
+		t.Fatal("batch completed with a build still pending")
+	}
+	// Only the first outcome of a build counts
+	if _, done := batches.finish("b1", "b1-a", types.BatchBuildResult{Status: BatchBuildFailed}); done {
+		t.Fatal("batch completed by a second outcome of the same build")
+	}
+	completed, done := batches.finish("b1", "b1-b", types.BatchBuildResult{Status: BatchBuildFailed, Stage: StageTest, Error: "tests failed"})
+	if !done {
+		t.Fatal("batch not completed after its last build")
+	}
+	if completed.Deployed != 1 || completed.Failed != 1 || completed.Builds[0].Status != BatchBuildDeployed ||
+		completed.Builds[1].Stage != StageTest {
+		t.Errorf("completed = %+v", completed)
+	}
+
+	if _, done := batches.finish("b1", "b1-b", types.BatchBuildResult{Status: BatchBuildDeployed}); done {
+		t.Error("completed batch reported twice")
+	}
+}
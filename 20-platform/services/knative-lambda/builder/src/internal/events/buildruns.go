@@ -0,0 +1,476 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+
+	"knative-lambda-builder/contracts"
+	"knative-lambda-builder/internal/build"
+	"knative-lambda-builder/internal/buildruns"
+	"knative-lambda-builder/internal/observability"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🏃 BUILD RUN CONTROLLER
+// =============================================================================
+// With BuildRuns on, every accepted build gets a BuildRun whose status follows
+// its lifecycle events, and BuildRuns created by anyone else (kubectl, GitOps)
+// are builds to start
+//
+//	build.accepted -> Pending, build.started -> Building, build.retrying -> Retrying,
+//	build.image.pushed -> Pushed, build.deployed -> Succeeded, build.failed -> Failed
+//
+// 📝 NOTE: A BuildRun without a phase (and not created by the builder) is a
+// request: the replica that claims it (writes Pending with its
+// resourceVersion) starts the build. Its spec is checked against the
+// build.start contract first
+// 📝 NOTE: Jobs the builder doesn't track (e.g. created before a restart) get
+// their full build request back from their BuildRun
+// 🤝 WORK STEALING: With a visibility timeout, an accepted build's BuildRun
+// records the replica holding it, which heartbeats it while one of its
+// workers starts the build. A replica with idle workers takes over Pending
+// BuildRuns whose heartbeat is older than the timeout (e.g. their replica's
+// workers are all stuck on long builds, or it died) and queues their builds;
+// the old owner skips them when one of its workers gets to them
+
+// buildRunCollectInterval is how often finished BuildRuns past their TTL are deleted
+const buildRunCollectInterval = time.Hour
+
+// buildRunTimeout bounds each BuildRun read or write
+// 🎯 WHY: A slow API server must not hold up the build the status belongs to
+const buildRunTimeout = 10 * time.Second
+
+// buildRunController records builds as BuildRuns and starts requested ones
+type buildRunController struct {
+	store *buildruns.Store
+	ttl   time.Duration // How long finished BuildRuns are kept (0 = forever)
+
+	identity   string        // This replica, as the owner of the builds it holds
+	visibility time.Duration // How long a Pending BuildRun may miss heartbeats (0 = no work stealing)
+	mu         sync.Mutex
+	held       map[string]bool // BuildRuns a worker of this replica is starting
+}
+
+// WithBuildRuns records every accepted build as a BuildRun, and makes
+// WatchBuildRuns start the builds requested with BuildRuns
+func (h *Handler) WithBuildRuns(store *buildruns.Store, ttl time.Duration) *Handler {
+	h.runs = &buildRunController{store: store, ttl: ttl}
+	h.builds.restore = h.restoreBuild
+	return h
+}
+
+// WithWorkStealing makes this replica (identity) heartbeat the BuildRuns of
+// the builds it starts, and take over Pending BuildRuns whose heartbeat is
+// older than visibility
+// 📝 NOTE: Needs WithBuildRuns
+func (h *Handler) WithWorkStealing(identity string, visibility time.Duration) *Handler {
+	if h.runs != nil {
+		h.runs.identity, h.runs.visibility = identity, visibility
+		h.runs.held = map[string]bool{}
+	}
+	return h
+}
+
+// stealing reports whether Pending BuildRuns are heartbeated and taken over
+func (c *buildRunController) stealing() bool {
+	return c != nil && c.visibility > 0
+}
+
+// createBuildRun records an accepted build as a BuildRun, logging (not
+// failing) on error
+func (h *Handler) createBuildRun(ctx context.Context, be types.BuildEvent) {
+	if h.runs == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, buildRunTimeout)
+	defer cancel()
+	if err := h.runs.store.Create(ctx, be, build.ResourceLabels(be)); err != nil {
+		log.Printf("WARNING: Failed to record build %s as a BuildRun: %v", be.ID, err)
+	}
+}
+
+// updateBuildRun moves a build's BuildRun along with a lifecycle event
+func (h *Handler) updateBuildRun(ctx context.Context, eventType string, data types.BuildLifecycleEventData) {
+	if h.runs == nil {
+		return
+	}
+	name := buildruns.Name(data.BuildId)
+	if name == "" {
+		return
+	}
+	change := buildRunChange(eventType, data)
+	if change == nil {
+		return
+	}
+	if eventType == EventTypeBuildAccepted && h.runs.stealing() {
+		accepted := change
+		change = func(s *buildruns.Status) {
+			accepted(s)
+			s.Hold(h.runs.identity)
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, buildRunTimeout)
+	defer cancel()
+	if err := h.runs.store.UpdateStatus(ctx, name, change); err != nil {
+		log.Printf("WARNING: Failed to update BuildRun %s after %s: %v", name, eventType, err)
+	}
+}
+
+// buildRunChange maps a lifecycle event to a BuildRun status change (nil =
+// the event doesn't move the phase)
+func buildRunChange(eventType string, data types.BuildLifecycleEventData) func(*buildruns.Status) {
+	switch eventType {
+	case EventTypeBuildAccepted:
+		return func(s *buildruns.Status) {
+			s.SetPhase(buildruns.PhasePending, "")
+		}
+	case EventTypeBuildStarted:
+		return func(s *buildruns.Status) {
+			s.SetPhase(buildruns.PhaseBuilding, "")
+			s.JobName = data.JobName
+			s.Image = data.Image
+			s.ImageTag = imageTag(data.Image)
+			s.Retry = data.Retry
+			if s.StartedAt == nil {
+				now := metav1.Now()
+				s.StartedAt = &now
+			}
+		}
+	case EventTypeBuildRetrying:
+		return func(s *buildruns.Status) {
+			s.SetPhase(buildruns.PhaseRetrying, data.Error)
+			s.Retry = data.Retry
+		}
+	case EventTypeBuildImagePushed:
+		return func(s *buildruns.Status) {
+			s.SetPhase(buildruns.PhasePushed, "")
+			s.ImageDigest = data.ImageDigest
+		}
+	case EventTypeBuildDeployed:
+		return func(s *buildruns.Status) {
+			s.SetPhase(buildruns.PhaseSucceeded, "")
+			s.DeployMode = data.DeployMode
+		}
+	case EventTypeBuildFailed:
+		return func(s *buildruns.Status) {
+			s.SetPhase(buildruns.PhaseFailed, data.Error)
+			s.Stage = data.Stage
+		}
+	}
+	return nil
+}
+
+// imageTag returns the tag of an image reference (the image revision)
+func imageTag(image string) string {
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[i+1:]
+	}
+	return ""
+}
+
+// restoreBuild completes a build rebuilt from its job's labels with the
+// request kept in its BuildRun
+// 📝 NOTE: The job's annotations (image tag, role, region) win: they're what
+// the job actually did
+func (h *Handler) restoreBuild(be types.BuildEvent) types.BuildEvent {
+	name := buildruns.Name(be.ID)
+	if h.runs == nil || name == "" {
+		return be
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), buildRunTimeout)
+	defer cancel()
+	run, err := h.runs.store.Get(ctx, name)
+	if err != nil {
+		log.Printf("WARNING: Failed to restore build %s from its BuildRun: %v", be.ID, err)
+		return be
+	}
+	if run.Spec.ThirdPartyId != be.ThirdPartyId || run.Spec.ParserId != be.ParserId {
+		return be
+	}
+	restored := run.Spec.BuildEvent
+	restored.ID = be.ID
+	restored.ImageTag = be.ImageTag
+	restored.Retry = run.Status.Retry
+	if be.PushRoleArn != "" {
+		restored.PushRoleArn = be.PushRoleArn
+	}
+	if be.Region != "" {
+		restored.Region = be.Region
+	}
+	return restored
+}
+
+// WatchBuildRuns starts the builds requested with BuildRuns, and deletes
+// finished BuildRuns past their TTL, until ctx is done
+// 📝 NOTE: Does nothing without WithBuildRuns
+func (h *Handler) WatchBuildRuns(ctx context.Context) {
+	if h.runs == nil {
+		return
+	}
+	factory := h.runs.store.InformerFactory()
+	informer := factory.ForResource(buildruns.Resource).Informer()
+	onRun := func(obj interface{}) {
+		if run, ok := obj.(*unstructured.Unstructured); ok {
+			h.handleBuildRun(ctx, run)
+		}
+	}
+	if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    onRun,
+		UpdateFunc: func(_, obj interface{}) { onRun(obj) },
+	}); err != nil {
+		log.Printf("ERROR: Failed to watch BuildRuns: %v", err)
+		return
+	}
+
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		log.Printf("ERROR: BuildRun watcher stopped before listing the BuildRuns of %s", h.runs.store.Namespace())
+		return
+	}
+	log.Printf("🏃 Watching BuildRuns in %s", h.runs.store.Namespace())
+
+	ticker := time.NewTicker(buildRunCollectInterval)
+	defer ticker.Stop()
+	// 🤝 Heartbeats go out three times per visibility timeout
+	var heartbeats <-chan time.Time
+	if h.runs.stealing() {
+		heartbeat := time.NewTicker(max(h.runs.visibility/3, time.Second))
+		defer heartbeat.Stop()
+		heartbeats = heartbeat.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			factory.Shutdown()
+			return
+		case <-ticker.C:
+			h.collectBuildRuns(ctx, informer.GetStore().List())
+		case <-heartbeats:
+			h.heartbeatBuildRuns(ctx)
+			h.takeOverBuildRuns(ctx, informer.GetStore().List())
+		}
+	}
+}
+
+// holdBuildRun renews this replica's hold on the BuildRun of a build a worker
+// is about to start; false when another replica took the build over
+// 📝 NOTE: Builds without a BuildRun, or whose hold can't be renewed (API
+// server errors), are started anyway
+func (h *Handler) holdBuildRun(ctx context.Context, be types.BuildEvent) bool {
+	name := buildruns.Name(be.ID)
+	if !h.runs.stealing() || name == "" {
+		return true
+	}
+	heartbeatCtx, cancel := context.WithTimeout(ctx, buildRunTimeout)
+	defer cancel()
+	held, err := h.runs.store.Heartbeat(heartbeatCtx, name, h.runs.identity)
+	if err != nil {
+		log.Printf("WARNING: Failed to heartbeat BuildRun %s: %v", name, err)
+	}
+	if !held {
+		log.Printf("🤝 Build %s of %s/%s was taken over by another replica, skipping it", be.ID, be.ThirdPartyId, be.ParserId)
+		return false
+	}
+	h.runs.mu.Lock()
+	h.runs.held[name] = true
+	h.runs.mu.Unlock()
+	return true
+}
+
+// releaseBuildRun stops heartbeating the BuildRun of a build once started
+func (h *Handler) releaseBuildRun(be types.BuildEvent) {
+	if !h.runs.stealing() {
+		return
+	}
+	h.runs.mu.Lock()
+	delete(h.runs.held, buildruns.Name(be.ID))
+	h.runs.mu.Unlock()
+}
+
+// heartbeatBuildRuns renews the hold on the BuildRuns this replica's workers
+// are starting
+func (h *Handler) heartbeatBuildRuns(ctx context.Context) {
+	h.runs.mu.Lock()
+	names := make([]string, 0, len(h.runs.held))
+	for name := range h.runs.held {
+		names = append(names, name)
+	}
+	h.runs.mu.Unlock()
+
+	for _, name := range names {
+		heartbeatCtx, cancel := context.WithTimeout(ctx, buildRunTimeout)
+		held, err := h.runs.store.Heartbeat(heartbeatCtx, name, h.runs.identity)
+		cancel()
+		if err != nil {
+			log.Printf("WARNING: Failed to heartbeat BuildRun %s: %v", name, err)
+		} else if !held {
+			log.Printf("WARNING: BuildRun %s was taken over by another replica while this one started it", name)
+		}
+	}
+}
+
+// takeOverBuildRuns queues the builds of stale Pending BuildRuns (oldest
+// heartbeat first), as long as this replica has idle workers
+func (h *Handler) takeOverBuildRuns(ctx context.Context, objs []interface{}) {
+	now := time.Now()
+	var stale []*unstructured.Unstructured
+	for _, obj := range objs {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		run, err := buildruns.FromUnstructured(u)
+		if err != nil || run.Status.Owner == h.runs.identity || !run.Status.Stale(h.runs.visibility, now) {
+			continue
+		}
+		stale = append(stale, u)
+	}
+	sort.Slice(stale, func(i, j int) bool {
+		return heartbeatAt(stale[i]).Before(heartbeatAt(stale[j]))
+	})
+
+	for _, obj := range stale {
+		if h.backlog.pending() >= h.workers || !h.backlog.admit() {
+			return
+		}
+		if !h.takeOverBuildRun(ctx, obj) {
+			h.backlog.done()
+		}
+	}
+}
+
+// takeOverBuildRun claims a stale BuildRun and queues its build; false when
+// another replica claimed it first (or it can't be read)
+func (h *Handler) takeOverBuildRun(ctx context.Context, obj *unstructured.Unstructured) bool {
+	previous, _, _ := unstructured.NestedString(obj.Object, "status", "owner")
+	claimed, err := h.runs.store.Claim(ctx, obj, func(s *buildruns.Status) {
+		s.Hold(h.runs.identity)
+	})
+	if err != nil {
+		log.Printf("WARNING: Failed to take over BuildRun %s: %v", obj.GetName(), err)
+		return false
+	}
+	if !claimed {
+		return false
+	}
+	run, err := h.runs.store.Get(ctx, obj.GetName())
+	if err != nil {
+		log.Printf("ERROR: Took over BuildRun %s but failed to read its request: %v", obj.GetName(), err)
+		return false
+	}
+
+	be := run.Spec.BuildEvent
+	be.ID = run.Name
+	be.Retry = run.Status.Retry
+	observability.BuildsTakenOver.Inc()
+	log.Printf("🤝 Took over build %s of %s/%s from %s (no heartbeat for over %s)",
+		be.ID, be.ThirdPartyId, be.ParserId, previous, h.runs.visibility)
+	h.buildOrchestrator.QueueBuild(backgroundContext(ctx), be)
+	return true
+}
+
+// heartbeatAt returns when a BuildRun's owner last renewed its hold
+func heartbeatAt(obj *unstructured.Unstructured) time.Time {
+	value, _, _ := unstructured.NestedString(obj.Object, "status", "heartbeatAt")
+	at, _ := time.Parse(time.RFC3339, value)
+	return at
+}
+
+// handleBuildRun starts the build a new BuildRun requests, if this replica
+// claims it
+// 📝 NOTE: BuildRuns the builder created are skipped: their build is
+// already accepted, only their status isn't written yet
+func (h *Handler) handleBuildRun(ctx context.Context, obj *unstructured.Unstructured) {
+	if phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase"); phase != "" || obj.GetDeletionTimestamp() != nil {
+		return
+	}
+	if obj.GetLabels()[build.LabelManagedBy] == build.ManagedBy {
+		return
+	}
+	claimed, err := h.runs.store.Claim(ctx, obj, func(s *buildruns.Status) {
+		s.SetPhase(buildruns.PhasePending, "")
+	})
+	if err != nil {
+		log.Printf("WARNING: Failed to claim BuildRun %s: %v", obj.GetName(), err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	be, err := buildRunRequest(obj)
+	if err == nil {
+		var accepted types.BuildEvent
+		accepted, err = h.SubmitBuild(ctx, be)
+		if err == nil && accepted.ID != be.ID {
+			err = fmt.Errorf("duplicate of build %s", accepted.ID)
+		}
+	}
+	if err == nil {
+		log.Printf("🏃 Started build %s of BuildRun %s", be.ID, obj.GetName())
+		return
+	}
+	log.Printf("ERROR: Refused BuildRun %s: %v", obj.GetName(), err)
+	message := err.Error()
+	if err := h.runs.store.UpdateStatus(ctx, obj.GetName(), func(s *buildruns.Status) {
+		s.SetPhase(buildruns.PhaseFailed, message)
+		s.Stage = StageValidate
+	}); err != nil {
+		log.Printf("WARNING: Failed to fail BuildRun %s: %v", obj.GetName(), err)
+	}
+}
+
+// buildRunRequest reads the build a BuildRun requests: its spec, checked
+// against the build.start contract, with the BuildRun's name as build id
+func buildRunRequest(obj *unstructured.Unstructured) (types.BuildEvent, error) {
+	var be types.BuildEvent
+	spec, _, _ := unstructured.NestedMap(obj.Object, "spec")
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return be, fmt.Errorf("failed to encode the spec: %w", err)
+	}
+	if contract, ok := contracts.Latest(EventTypeBuildStart); ok {
+		if err := contract.Validate(data); err != nil {
+			return be, fmt.Errorf("spec violates the build.start contract: %s", strings.Join(contracts.Violations(err), "; "))
+		}
+	}
+	if err := json.Unmarshal(data, &be); err != nil {
+		return be, fmt.Errorf("failed to decode the spec: %w", err)
+	}
+	be.ID = obj.GetName()
+	return be, nil
+}
+
+// collectBuildRuns deletes the finished BuildRuns past their TTL
+func (h *Handler) collectBuildRuns(ctx context.Context, objs []interface{}) {
+	if h.runs.ttl <= 0 {
+		return
+	}
+	var runs []*buildruns.BuildRun
+	for _, obj := range objs {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		run, err := buildruns.FromUnstructured(u)
+		if err != nil {
+			log.Printf("WARNING: %v", err)
+			continue
+		}
+		runs = append(runs, run)
+	}
+	if deleted := h.runs.store.DeleteFinished(ctx, runs, h.runs.ttl); deleted > 0 {
+		log.Printf("🗑️ Deleted %d finished BuildRuns older than %s", deleted, h.runs.ttl)
+	}
+}
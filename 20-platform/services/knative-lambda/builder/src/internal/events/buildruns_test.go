@@ -0,0 +1,141 @@
+package events
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"knative-lambda-builder/internal/build"
+	"knative-lambda-builder/internal/buildruns"
+	"knative-lambda-builder/internal/config"
+	"knative-lambda-builder/internal/types"
+)
+
+func TestBuildRuns(t *testing.T) {
+	ctx := context.Background()
+	// 📝 NOTE: Created with kubectl, its spec breaking the build.start contract
+	request := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "knative-lambda.notifi.network/v1alpha1",
+		"kind":       buildruns.Kind,
+		"metadata":   map[string]interface{}{"name": "b2", "namespace": "knative-lambda"},
+		"spec":       map[string]interface{}{"thirdPartyId": "acme"},
+	}}
+	store := buildruns.NewStore(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), request), "knative-lambda")
+	h := (&Handler{}).WithBuildRuns(store, 0)
+
+	// The BuildRun of an accepted build follows its lifecycle events
+	be := types.BuildEvent{ID: "b1", ThirdPartyId: "acme", ParserId: "p1", Runtime: "python"}
+	h.createBuildRun(ctx, be)
+	h.updateBuildRun(ctx, EventTypeBuildAccepted, lifecycleData(be))
+	started := lifecycleData(be)
+	started.JobName = "build-acme-p1-1700000000"
+	started.Image = "123456789012.dkr.ecr.us-west-2.amazonaws.com/knative-lambdas/acme:p1-v3"
+	h.updateBuildRun(ctx, EventTypeBuildStarted, started)
+	failed := lifecycleData(be)
+	failed.Stage = StageBuild
+	failed.Error = "kaniko exited with 1"
+	h.updateBuildRun(ctx, EventTypeBuildFailed, failed)
+
+	run, err := store.Get(ctx, "b1")
+	if err != nil {
+		t.Fatalf("Get() = %v", err)
+	}
+	status := run.Status
+	if status.Phase != buildruns.PhaseFailed || status.Stage != StageBuild || status.JobName != started.JobName ||
+		status.ImageTag != "p1-v3" || status.StartedAt == nil || status.CompletedAt == nil {
+		t.Errorf("status after build.failed = %+v", status)
+	}
+	if len(status.Conditions) != 1 || status.Conditions[0].Status != "False" || status.Conditions[0].Reason != buildruns.PhaseFailed {
+		t.Errorf("conditions after build.failed = %+v", status.Conditions)
+	}
+
+	// A build rebuilt from its job's labels gets its request back
+	restored := h.restoreBuild(types.BuildEvent{ID: "b1", ThirdPartyId: "acme", ParserId: "p1", ImageTag: "p1-v3"})
+	if restored.Runtime != "python" || restored.ImageTag != "p1-v3" {
+		t.Errorf("restoreBuild() = %+v, want the BuildRun's runtime and the job's image tag", restored)
+	}
+
+	// A BuildRun requesting a build that breaks the build.start contract fails
+	h.handleBuildRun(ctx, request)
+	run, err = store.Get(ctx, "b2")
+	if err != nil {
+		t.Fatalf("Get() = %v", err)
+	}
+	if run.Status.Phase != buildruns.PhaseFailed || !strings.Contains(run.Status.Message, "contract") {
+		t.Errorf("status of an invalid request = %+v, want Failed naming the contract", run.Status)
+	}
+}
+
+func TestWorkStealing(t *testing.T) {
+	ctx := context.Background()
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{buildruns.Resource: "BuildRunList"})
+	store := buildruns.NewStore(client, "knative-lambda")
+	listRuns := func() []interface{} {
+		list, err := client.Resource(buildruns.Resource).Namespace("knative-lambda").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			t.Fatalf("List() = %v", err)
+		}
+		objs := make([]interface{}, len(list.Items))
+		for i := range list.Items {
+			objs[i] = &list.Items[i]
+		}
+		return objs
+	}
+
+	// Replica a accepted two builds; b1's heartbeat stopped long ago
+	a := (&Handler{}).WithBuildRuns(store, 0).WithWorkStealing("replica-a", time.Minute)
+	for _, be := range []types.BuildEvent{
+		{ID: "b1", ThirdPartyId: "acme", ParserId: "p1"},
+		{ID: "b2", ThirdPartyId: "acme", ParserId: "p2"},
+	} {
+		a.createBuildRun(ctx, be)
+		a.updateBuildRun(ctx, EventTypeBuildAccepted, lifecycleData(be))
+	}
+	if err := store.UpdateStatus(ctx, "b1", func(s *buildruns.Status) {
+		s.HeartbeatAt = &metav1.Time{Time: time.Now().Add(-2 * time.Minute)}
+	}); err != nil {
+		t.Fatalf("UpdateStatus() = %v", err)
+	}
+
+	// Replica b, with an idle worker, takes b1 over and queues it
+	orchestrator := build.NewOrchestratorWithDependencies(&config.Config{}, nil, build.Dependencies{})
+	b := (&Handler{buildOrchestrator: orchestrator}).WithBuildRuns(store, 0).WithWorkStealing("replica-b", time.Minute).WithBuildWorkers(1)
+	b.takeOverBuildRuns(ctx, listRuns())
+	if queued := orchestrator.QueuedBuilds(); len(queued) != 1 || queued[0].ID != "b1" || queued[0].ParserId != "p1" {
+		t.Fatalf("queued after the take over = %+v, want b1", queued)
+	}
+	run, err := store.Get(ctx, "b1")
+	if err != nil {
+		t.Fatalf("Get() = %v", err)
+	}
+	if run.Status.Owner != "replica-b" || run.Status.Phase != buildruns.PhasePending {
+		t.Errorf("status of b1 = %+v, want Pending, owned by replica-b", run.Status)
+	}
+
+	// Replica a skips b1 once a worker gets to it, and keeps b2
+	if a.holdBuildRun(ctx, types.BuildEvent{ID: "b1"}) {
+		t.Error("holdBuildRun(b1) on replica-a = true, want false (taken over)")
+	}
+	if !a.holdBuildRun(ctx, types.BuildEvent{ID: "b2"}) {
+		t.Error("holdBuildRun(b2) on replica-a = false, want true")
+	}
+
+	// Without an idle worker, replica b leaves other stale builds alone
+	if err := store.UpdateStatus(ctx, "b2", func(s *buildruns.Status) {
+		s.HeartbeatAt = &metav1.Time{Time: time.Now().Add(-2 * time.Minute)}
+	}); err != nil {
+		t.Fatalf("UpdateStatus() = %v", err)
+	}
+	b.takeOverBuildRuns(ctx, listRuns())
+	if queued := orchestrator.QueuedBuilds(); len(queued) != 1 {
+		t.Errorf("queued with every worker busy = %+v, want b1 only", queued)
+	}
+}
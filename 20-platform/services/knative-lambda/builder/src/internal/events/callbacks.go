@@ -0,0 +1,83 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"knative-lambda-builder/internal/callbacks"
+	"knative-lambda-builder/internal/observability"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 📞 BUILD CALLBACKS
+// =============================================================================
+// Builds requested with a callbackUrl get their outcome POSTed there once
+// they deploy or fail (see the callbacks package)
+// 📝 NOTE: The URL is checked when the build is accepted, so a request with a
+// URL the builder won't call is refused (400) instead of failing silently
+
+// Callback statuses
+const (
+	CallbackDeployed = "deployed"
+	CallbackFailed   = "failed"
+)
+
+// InvalidCallbackError is returned for a build whose callbackUrl can't be used
+type InvalidCallbackError struct {
+	Err error
+}
+
+func (e *InvalidCallbackError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *InvalidCallbackError) Unwrap() error {
+	return e.Err
+}
+
+// InvalidRequest marks the error as the requester's fault (API: 400)
+func (e *InvalidCallbackError) InvalidRequest() bool {
+	return true
+}
+
+// WithCallbacks delivers build callbacks through n
+func (h *Handler) WithCallbacks(n *callbacks.Notifier) *Handler {
+	h.callbacks = n
+	return h
+}
+
+// checkCallback refuses a build whose callbackUrl won't be called
+func (h *Handler) checkCallback(be types.BuildEvent) error {
+	if be.CallbackURL == "" {
+		return nil
+	}
+	if h.callbacks == nil {
+		return &InvalidCallbackError{Err: fmt.Errorf("%w: callbacks are not enabled", callbacks.ErrInvalidURL)}
+	}
+	if err := h.callbacks.Check(be.CallbackURL); err != nil {
+		return &InvalidCallbackError{Err: err}
+	}
+	return nil
+}
+
+// notifyCallback POSTs a build's outcome to its callbackUrl, in the background
+// 🎯 WHY: Retries take a while; they must never hold up the build pipeline
+func (h *Handler) notifyCallback(ctx context.Context, be types.BuildEvent, data types.BuildCallbackData) {
+	if be.CallbackURL == "" || h.callbacks == nil {
+		return
+	}
+	data.ThirdPartyId, data.ParserId, data.BuildId, data.Attempt = be.ThirdPartyId, be.ParserId, be.ID, be.Attempt
+	data.FinishedAt = time.Now().UTC()
+
+	go func() {
+		if err := h.callbacks.Deliver(backgroundContext(ctx), be.CallbackURL, data); err != nil {
+			observability.CallbacksDelivered.WithLabelValues("failed").Inc()
+			log.Printf("ERROR: Failed to deliver the callback of build %s of %s/%s: %v", be.ID, be.ThirdPartyId, be.ParserId, err)
+			return
+		}
+		observability.CallbacksDelivered.WithLabelValues("delivered").Inc()
+	}()
+}
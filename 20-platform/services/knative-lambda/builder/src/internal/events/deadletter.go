@@ -0,0 +1,178 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+
+	"knative-lambda-builder/emit"
+	"knative-lambda-builder/internal/observability"
+	"knative-lambda-builder/internal/types"
+	"knative-lambda-builder/storage"
+)
+
+// =============================================================================
+// 🪦 DEAD LETTERS
+// =============================================================================
+// A build that failed for good (after its job's retries and preemption
+// requeues) is sent to the dead-letter sink (DEAD_LETTER_SINK): the original
+// request plus why, where and when it failed
+// 🎯 WHY: Failures happen in background goroutines; without this the request
+// only survives in the builder's logs and can't be replayed
+// 📋 SINKS:
+//   - http(s)://... -> a build.deadletter CloudEvent (e.g. to a broker)
+//   - s3://bucket/prefix -> one JSON object per failed build
+
+// EventTypeBuildDeadLetter is emitted for builds that failed for good
+const EventTypeBuildDeadLetter = "network.notifi.lambda.build.deadletter"
+
+// requestSourceAPI is the origin source of builds submitted through the API
+const requestSourceAPI = "api"
+
+// DeadLetterSink keeps the requests of failed builds
+type DeadLetterSink interface {
+	Send(ctx context.Context, letter types.DeadLetterEventData) error
+}
+
+// WithDeadLetterSink sends builds that failed for good to sink
+func (h *Handler) WithDeadLetterSink(sink DeadLetterSink) *Handler {
+	h.deadLetters = sink
+	return h
+}
+
+// requestOrigin records the CloudEvent a build was requested with
+func requestOrigin(event cloudevents.Event) *types.RequestOrigin {
+	return &types.RequestOrigin{
+		Type:   event.Type(),
+		ID:     event.ID(),
+		Source: event.Source(),
+		Data:   append(json.RawMessage(nil), event.Data()...),
+	}
+}
+
+// buildRequestOrigin is the build.start (or rebuild) event that would have
+// requested a build, for builds that didn't come from one
+func buildRequestOrigin(be types.BuildEvent, source string) *types.RequestOrigin {
+	origin := &types.RequestOrigin{Type: EventTypeBuildStart, ID: be.ID, Source: source}
+	if be.Rebuild {
+		origin.Type = EventTypeRebuild
+	}
+	be.Attempt = 0
+	origin.Data, _ = json.Marshal(be)
+	return origin
+}
+
+// deadLetter sends a failed build to the dead-letter sink, logging (not
+// failing) on error
+// 📝 NOTE: Builds submitted through the API have no CloudEvent; their request
+// is rebuilt as the event that would have requested them
+func (h *Handler) deadLetter(ctx context.Context, be types.BuildEvent, failed types.BuildLifecycleEventData) {
+	if h.deadLetters == nil {
+		return
+	}
+
+	letter := types.DeadLetterEventData{
+		ThirdPartyId: be.ThirdPartyId,
+		ParserId:     be.ParserId,
+		BuildId:      be.ID,
+		Attempt:      be.Attempt,
+		Stage:        failed.Stage,
+		JobName:      failed.JobName,
+		Error:        failed.Error,
+		FailedAt:     time.Now().UTC(),
+	}
+	if be.Origin != nil {
+		letter.Request = *be.Origin
+	} else {
+		letter.Request = *buildRequestOrigin(be, requestSourceAPI)
+	}
+
+	if err := h.deadLetters.Send(ctx, letter); err != nil {
+		observability.DeadLetters.WithLabelValues("failed").Inc()
+		log.Printf("ERROR: Failed to dead-letter build %s of %s/%s: %v (request: %s)",
+			be.ID, be.ThirdPartyId, be.ParserId, err, string(letter.Request.Data))
+		return
+	}
+	observability.DeadLetters.WithLabelValues("sent").Inc()
+	log.Printf("🪦 Build %s of %s/%s dead-lettered", be.ID, be.ThirdPartyId, be.ParserId)
+}
+
+// EmitterDeadLetters sends dead letters as build.deadletter CloudEvents
+type EmitterDeadLetters struct {
+	emitter emit.Emitter
+}
+
+// NewEmitterDeadLetters creates a sink emitting dead letters through emitter
+// (e.g. NewEmitter with the dead-letter broker's URI)
+func NewEmitterDeadLetters(emitter emit.Emitter) *EmitterDeadLetters {
+	return &EmitterDeadLetters{emitter: emitter}
+}
+
+// Send emits the dead letter, with subject <thirdPartyId>/<parserId>
+func (d *EmitterDeadLetters) Send(ctx context.Context, letter types.DeadLetterEventData) error {
+	return d.emitter.Emit(ctx, EventTypeBuildDeadLetter, letter.ThirdPartyId+"/"+letter.ParserId, letter)
+}
+
+// KeyResolver resolves the KMS key of a tenant ("" = none)
+// (implemented by encryption.TenantKeys)
+type KeyResolver interface {
+	KeyID(ctx context.Context, thirdPartyId string) (string, error)
+}
+
+// ObjectDeadLetters writes dead letters to an object store, as
+// <prefix>/<thirdPartyId>/<parserId>/<failedAt>-<buildId>.json
+// 🔐 Objects of tenants with a KMS key are encrypted with it (SSE-KMS), like
+// their build contexts: dead letters carry failure messages
+type ObjectDeadLetters struct {
+	store  storage.ObjectStore
+	bucket string
+	prefix string
+	keys   KeyResolver // nil = no tenant keys
+}
+
+// NewObjectDeadLetters creates a sink writing to s3://bucket/prefix
+func NewObjectDeadLetters(store storage.ObjectStore, bucket, prefix string, keys KeyResolver) *ObjectDeadLetters {
+	return &ObjectDeadLetters{store: store, bucket: bucket, prefix: prefix, keys: keys}
+}
+
+// Send writes the dead letter as one object
+func (d *ObjectDeadLetters) Send(ctx context.Context, letter types.DeadLetterEventData) error {
+	body, err := json.MarshalIndent(letter, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode dead letter: %w", err)
+	}
+	key := d.key(letter)
+
+	keyID := ""
+	if d.keys != nil {
+		if keyID, err = d.keys.KeyID(ctx, letter.ThirdPartyId); err != nil {
+			return fmt.Errorf("failed to get the key of tenant %s: %w", letter.ThirdPartyId, err)
+		}
+	}
+	if keyID != "" {
+		err = d.store.PutEncrypted(ctx, d.bucket, key, bytes.NewReader(body), keyID)
+	} else {
+		err = d.store.Put(ctx, d.bucket, key, bytes.NewReader(body))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write dead letter s3://%s/%s: %w", d.bucket, key, err)
+	}
+	return nil
+}
+
+// key names a dead letter's object; sorted by failure time per parser
+func (d *ObjectDeadLetters) key(letter types.DeadLetterEventData) string {
+	id := letter.BuildId
+	if id == "" {
+		id = uuid.NewString()
+	}
+	name := letter.FailedAt.UTC().Format("20060102T150405Z") + "-" + id + ".json"
+	return path.Join(d.prefix, letter.ThirdPartyId, letter.ParserId, name)
+}
@@ -0,0 +1,56 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"knative-lambda-builder/internal/types"
+	"knative-lambda-builder/storage"
+)
+
+// tenantKeys gives the listed tenants a KMS key
+type tenantKeys map[string]string
+
+func (k tenantKeys) KeyID(_ context.Context, thirdPartyId string) (string, error) {
+	return k[thirdPartyId], nil
+}
+
+func TestObjectDeadLetters(t *testing.T) {
+	store := storage.NewFakeObjectStore()
+	sink := NewObjectDeadLetters(store, "dead", "letters", tenantKeys{"acme": "arn:aws:kms:acme"})
+	letter := types.DeadLetterEventData{
+		ThirdPartyId: "acme",
+		ParserId:     "invoice-created",
+		BuildId:      "b-1",
+		Stage:        StageBuild,
+		Error:        "build job failed",
+		FailedAt:     time.Date(2024, 5, 2, 10, 15, 0, 0, time.UTC),
+		Request:      types.RequestOrigin{Type: EventTypeBuildStart, ID: "b-1", Source: "upload"},
+	}
+	if err := sink.Send(context.Background(), letter); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	key := "letters/acme/invoice-created/20240502T101500Z-b-1.json"
+	body, ok := store.Object("dead", key)
+	if !ok {
+		t.Fatalf("no dead letter at %s", key)
+	}
+	var got types.DeadLetterEventData
+	if err := json.Unmarshal(body, &got); err != nil || got.Request.ID != "b-1" || got.Error != letter.Error {
+		t.Errorf("dead letter = %+v, %v", got, err)
+	}
+	if keyID := store.KMSKeyID("dead", key); keyID != "arn:aws:kms:acme" {
+		t.Errorf("dead letter encrypted with %q, want the tenant key", keyID)
+	}
+
+	letter.ThirdPartyId = "globex"
+	if err := sink.Send(context.Background(), letter); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if keyID := store.KMSKeyID("dead", "letters/globex/invoice-created/20240502T101500Z-b-1.json"); keyID != "" {
+		t.Errorf("dead letter of a tenant without key encrypted with %q", keyID)
+	}
+}
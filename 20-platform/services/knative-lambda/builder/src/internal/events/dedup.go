@@ -0,0 +1,127 @@
+package events
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"knative-lambda-builder/internal/observability"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🔁 DUPLICATE BUILD SUPPRESSION
+// =============================================================================
+// Brokers deliver at least once: a redelivered build.start must not spawn a
+// second Kaniko job. Accepted requests are remembered by idempotency key
+// (build.Orchestrator.IdempotencyKey) for the dedup window; a request whose
+// key was seen is answered with the build it duplicates.
+// 📝 NOTE: A failed build forgets its key, so it can be retried right away
+
+// dedupTracker remembers the idempotency keys of recently accepted builds
+type dedupTracker struct {
+	mu     sync.Mutex
+	window time.Duration        // 0 = suppression disabled
+	seen   map[string]seenBuild // idempotency key -> build
+}
+
+// seenBuild is the build an idempotency key was first accepted as
+type seenBuild struct {
+	buildId string
+	at      time.Time
+}
+
+// claim records a key for a build; when the key was already seen, returns the
+// id of the build that claimed it and false
+func (t *dedupTracker) claim(key, buildId string) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for k, seen := range t.seen {
+		if now.Sub(seen.at) > t.window {
+			delete(t.seen, k)
+		}
+	}
+	if seen, ok := t.seen[key]; ok {
+		return seen.buildId, false
+	}
+	if t.seen == nil {
+		t.seen = map[string]seenBuild{}
+	}
+	t.seen[key] = seenBuild{buildId: buildId, at: now}
+	return buildId, true
+}
+
+// release forgets a key
+func (t *dedupTracker) release(key string) {
+	if key == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.seen, key)
+}
+
+// WithDeduplication suppresses duplicate build requests seen within window
+// 📝 NOTE: checkHistory also looks the build id up in the build history, which
+// survives restarts and is shared by the replicas
+func (h *Handler) WithDeduplication(window time.Duration, checkHistory bool) *Handler {
+	h.dedup.window = window
+	h.dedupHistory = checkHistory
+	return h
+}
+
+// deduplicate assigns the build its idempotency key; returns the build it
+// duplicates and true if it was already accepted
+// 📝 NOTE: A rebuild without id is never a duplicate: its source is meant to
+// be unchanged
+func (h *Handler) deduplicate(ctx context.Context, be *types.BuildEvent) (types.BuildEvent, bool) {
+	if h.dedup.window <= 0 || (be.Rebuild && be.ID == "") {
+		return types.BuildEvent{}, false
+	}
+
+	key, err := h.buildOrchestrator.IdempotencyKey(ctx, *be)
+	if err != nil {
+		log.Printf("WARNING: No idempotency key for %s/%s, not deduplicating it: %v", be.ThirdPartyId, be.ParserId, err)
+		return types.BuildEvent{}, false
+	}
+
+	duplicate := *be
+	if be.ID != "" && h.dedupHistory && h.inHistory(ctx, *be) {
+		observability.BuildsDeduplicated.WithLabelValues("history").Inc()
+		log.Printf("🔁 Build %s of %s/%s is in the build history, ignoring the duplicate", be.ID, be.ThirdPartyId, be.ParserId)
+		return duplicate, true
+	}
+
+	if be.ID == "" {
+		be.ID = uuid.NewString()
+	}
+	original, first := h.dedup.claim(key, be.ID)
+	if !first {
+		observability.BuildsDeduplicated.WithLabelValues("memory").Inc()
+		log.Printf("🔁 Duplicate build request for %s/%s (build %s), ignoring it", be.ThirdPartyId, be.ParserId, original)
+		duplicate.ID = original
+		return duplicate, true
+	}
+	be.IdempotencyKey = key
+	return types.BuildEvent{}, false
+}
+
+// inHistory reports whether a build id was already recorded for its parser
+func (h *Handler) inHistory(ctx context.Context, be types.BuildEvent) bool {
+	entries, err := h.history.List(ctx, be.ThirdPartyId, be.ParserId)
+	if err != nil {
+		log.Printf("WARNING: Failed to check the build history of %s/%s for duplicates: %v", be.ThirdPartyId, be.ParserId, err)
+		return false
+	}
+	for _, entry := range entries {
+		if entry.BuildId == be.ID {
+			return true
+		}
+	}
+	return false
+}
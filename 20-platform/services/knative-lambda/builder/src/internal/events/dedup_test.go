@@ -0,0 +1,33 @@
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDedupTracker(t *testing.T) {
+	seen := dedupTracker{window: time.Minute}
+
+	if id, first := seen.claim("id:b1", "b1"); !first || id != "b1" {
+		t.Fatalf("claim(id:b1) = %s, %t; want b1, true", id, first)
+	}
+	// A redelivery is answered with the build it duplicates
+	if id, first := seen.claim("id:b1", "b1-redelivered"); first || id != "b1" {
+		t.Errorf("claim(id:b1) again = %s, %t; want b1, false", id, first)
+	}
+	if _, first := seen.claim("id:b2", "b2"); !first {
+		t.Error("claim(id:b2) = false, want another key to be accepted")
+	}
+
+	// A failed build releases its key so it can be retried
+	seen.release("id:b1")
+	if id, first := seen.claim("id:b1", "b1-retry"); !first || id != "b1-retry" {
+		t.Errorf("claim(id:b1) after release = %s, %t; want b1-retry, true", id, first)
+	}
+
+	// Keys expire after the window
+	seen.seen["id:b2"] = seenBuild{buildId: "b2", at: time.Now().Add(-2 * time.Minute)}
+	if _, first := seen.claim("id:b2", "b2-later"); !first {
+		t.Error("claim(id:b2) after the window = false, want true")
+	}
+}
@@ -0,0 +1,98 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+
+	"knative-lambda-builder/internal/build"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🧾 DRY-RUN BUILDS
+// =============================================================================
+// A build with dryRun set renders the build job, the parser's service objects
+// and its trigger, and submits them to the API server as a server-side dry
+// run. Nothing is uploaded, recorded, built or deployed.
+// 📝 NOTE: POST /v1/builds returns the result; build.start/rebuild events
+// only log it
+
+// manifestSeparator joins the rendered templates into one YAML stream
+var manifestSeparator = []byte("\n---\n")
+
+// DryRun renders and validates what a build would create
+// 📝 NOTE: Fails when a template can't be rendered; objects the API server
+// refuses are reported in the result
+func (h *Handler) DryRun(ctx context.Context, be types.BuildEvent) (*types.DryRunResult, error) {
+	if err := build.CheckIDs(be); err != nil {
+		return nil, err
+	}
+	if err := build.CheckVariables(be); err != nil {
+		return nil, err
+	}
+	if err := h.buildOrchestrator.CheckGitSource(be); err != nil {
+		return nil, err
+	}
+	if err := h.buildOrchestrator.CheckInlineSource(be); err != nil {
+		return nil, err
+	}
+	if err := h.buildOrchestrator.CheckDependencies(be); err != nil {
+		return nil, err
+	}
+	if err := h.buildOrchestrator.CheckResources(ctx, be); err != nil {
+		return nil, err
+	}
+	if err := build.CheckSourceChecksum(be); err != nil {
+		return nil, err
+	}
+	job, imageTag, err := h.buildOrchestrator.RenderJob(ctx, be)
+	if err != nil {
+		return nil, err
+	}
+	be.ImageTag = imageTag
+	mode, deploy, err := h.parserService.RenderManifests(ctx, be)
+	if err != nil {
+		return nil, err
+	}
+	manifests := append([][]byte{job}, deploy...)
+
+	rejected, err := h.parserService.ValidateManifests(ctx, manifests...)
+	if err != nil {
+		return nil, err
+	}
+	for i := range manifests {
+		manifests[i] = bytes.TrimSpace(manifests[i])
+	}
+	return &types.DryRunResult{
+		ThirdPartyId: be.ThirdPartyId,
+		ParserId:     be.ParserId,
+		ImageTag:     imageTag,
+		DeployMode:   mode,
+		Manifests:    string(bytes.Join(manifests, manifestSeparator)) + "\n",
+		Rejected:     rejected,
+	}, nil
+}
+
+// logDryRun dry-runs a build requested as an event and logs the result
+func (h *Handler) logDryRun(ctx context.Context, be types.BuildEvent) {
+	result, err := h.DryRun(ctx, be)
+	if err != nil {
+		log.Printf("ERROR: Dry run of %s/%s failed: %v", be.ThirdPartyId, be.ParserId, err)
+		return
+	}
+	for _, obj := range result.Rejected {
+		log.Printf("WARNING: Dry run of %s/%s: %s %s refused: %s", be.ThirdPartyId, be.ParserId, obj.Kind, obj.Name, obj.Error)
+	}
+	log.Printf("🧾 Dry run of %s/%s (%s, %s, %s):\n%s", be.ThirdPartyId, be.ParserId, result.ImageTag, result.DeployMode,
+		dryRunVerdict(result), result.Manifests)
+}
+
+// dryRunVerdict summarizes the server-side dry run of a result
+func dryRunVerdict(result *types.DryRunResult) string {
+	if len(result.Rejected) == 0 {
+		return "valid"
+	}
+	return fmt.Sprintf("%d object(s) refused", len(result.Rejected))
+}
@@ -0,0 +1,85 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+
+	"knative-lambda-builder/contracts"
+)
+
+// =============================================================================
+// 📤 CLOUDEVENTS EMITTER
+// =============================================================================
+// The builder publishes its own events (e.g. trigger.failed) to a sink
+// 🎯 PURPOSE: Let downstream systems react without scraping builder logs
+
+// EventSource is the CloudEvents source attribute of everything we emit
+const EventSource = "network.notifi.lambda.builder"
+
+// Sender delivers an emitted event
+type Sender func(ctx context.Context, event cloudevents.Event) error
+
+// CloudEventEmitter sends CloudEvents to the configured sink
+type CloudEventEmitter struct {
+	send Sender // nil = log only
+}
+
+// NewEmitter creates an emitter for the given sink URL
+// 📝 NOTE: An empty sink is allowed; events are then only logged
+func NewEmitter(sink string) (*CloudEventEmitter, error) {
+	if sink == "" {
+		log.Printf("WARNING: No event sink configured (K_SINK), emitted events will only be logged")
+		return &CloudEventEmitter{}, nil
+	}
+
+	client, err := cloudevents.NewClientHTTP()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CloudEvents client: %w", err)
+	}
+
+	return NewSenderEmitter(func(ctx context.Context, event cloudevents.Event) error {
+		if result := client.Send(cloudevents.ContextWithTarget(ctx, sink), event); cloudevents.IsUndelivered(result) {
+			return result
+		}
+		return nil
+	}), nil
+}
+
+// NewSenderEmitter creates an emitter delivering events through send
+// 🎯 PURPOSE: Transports other than HTTP (e.g. NATS) publish the same events
+func NewSenderEmitter(send Sender) *CloudEventEmitter {
+	return &CloudEventEmitter{send: send}
+}
+
+// Emit sends an event with a JSON payload
+// 🎯 PURPOSE: subject is "<thirdPartyId>/<parserId>" so consumers can filter per parser
+func (e *CloudEventEmitter) Emit(ctx context.Context, eventType, subject string, data interface{}) error {
+	event := cloudevents.NewEvent()
+	event.SetID(uuid.NewString())
+	event.SetSource(EventSource)
+	event.SetType(eventType)
+	event.SetSubject(subject)
+	if contract, ok := contracts.Latest(eventType); ok {
+		// 📜 Tells consumers which payload contract to validate against
+		event.SetDataSchema(contract.SchemaURI())
+	}
+	if err := event.SetData(cloudevents.ApplicationJSON, data); err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+
+	if e.send == nil {
+		log.Printf("📤 (no sink) %s %s: %s", eventType, subject, string(event.Data()))
+		return nil
+	}
+
+	if err := e.send(ctx, event); err != nil {
+		return fmt.Errorf("failed to send %s event: %w", eventType, err)
+	}
+
+	log.Printf("📤 Emitted %s for %s", eventType, subject)
+	return nil
+}
@@ -2,13 +2,24 @@ package events
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
+	"knative-lambda-builder/emit"
 	"knative-lambda-builder/internal/build"
+	"knative-lambda-builder/internal/callbacks"
+	"knative-lambda-builder/internal/history"
+	"knative-lambda-builder/internal/observability"
 	"knative-lambda-builder/internal/services"
+	"knative-lambda-builder/internal/transform"
 	"knative-lambda-builder/internal/types"
 )
 
@@ -22,20 +33,53 @@ import (
 const (
 	EventTypeBuildStart     = "network.notifi.lambda.build.start"
 	EventTypeResourceUpdate = "dev.knative.apiserver.resource.update"
+	EventTypeTeardown       = "network.notifi.lambda.teardown"
+	EventTypeRebuild        = "network.notifi.lambda.rebuild"
+	EventTypeRollback       = "network.notifi.lambda.rollback"
+)
+
+// CloudEvent types emitted by the builder
+const (
+	EventTypeTriggerFailed = "network.notifi.lambda.trigger.failed"
 )
 
 // Handler manages CloudEvent processing
 type Handler struct {
 	buildOrchestrator *build.Orchestrator
 	parserService     *services.ParserService
-	currentBuild      *types.BuildEvent // Track current build for resource events
+	emitter           emit.Emitter
+	history           history.Store                 // Build status per parser (badges, APIs)
+	transformer       *transform.Transformer        // Maps legacy payload shapes before parsing
+	sampling          *observability.SamplingPolicy // Decides tracing and verbose logging per event
+	builds            buildRegistry                 // Which build each job belongs to
+	requeues          requeueTracker                // Preempted jobs already requeued
+	dedup             dedupTracker                  // Idempotency keys of recently accepted builds
+	dedupHistory      bool                          // Also look build ids up in the history
+	limits            rateLimiter                   // Build token buckets per tenant
+	backlog           buildBacklog                  // Accepted builds waiting for their job
+	workers           int                           // Size of the build worker pool (RunBuildWorkers)
+	signatures        *SignatureVerifier            // Verifies signed build requests (nil = not required)
+	deadLetters       DeadLetterSink                // Keeps the requests of builds that failed for good (nil = none)
+	batches           batchTracker                  // Builds of the batches in flight
+	callbacks         *callbacks.Notifier           // Delivers callbackUrl notifications (nil = refused)
+	jobs              *jobWatcher                   // Watches the namespace's jobs (nil = resource.update events)
+	runs              *buildRunController           // Records builds as BuildRuns (nil = none)
 }
 
 // NewHandler creates a new CloudEvent handler
-func NewHandler(buildOrchestrator *build.Orchestrator, parserService *services.ParserService) *Handler {
+func NewHandler(buildOrchestrator *build.Orchestrator, parserService *services.ParserService,
+	emitter emit.Emitter, buildHistory history.Store, transformer *transform.Transformer,
+	sampling *observability.SamplingPolicy) *Handler {
+	observability.RegisterEventTypes(EventTypeBuildStart, EventTypeResourceUpdate, EventTypeTeardown, EventTypeRebuild,
+		EventTypeBuildBatch, EventTypeRollback)
+
 	return &Handler{
 		buildOrchestrator: buildOrchestrator,
 		parserService:     parserService,
+		emitter:           emitter,
+		history:           buildHistory,
+		transformer:       transformer,
+		sampling:          sampling,
 	}
 }
 
@@ -44,15 +88,79 @@ func NewHandler(buildOrchestrator *build.Orchestrator, parserService *services.P
 // 📨 EVENTS WE HANDLE:
 //  1. build.start -> Start a new container build
 //  2. resource.update -> Handle Kubernetes job status changes
-func (h *Handler) HandleCloudEvent(ctx context.Context, event cloudevents.Event) error {
+//  3. teardown -> Remove a deployed parser
+//  4. rebuild -> Build again ignoring the cache, roll a new revision
+//  5. build.batch -> Start a build per parser, report them together
+//  6. rollback -> Redeploy a parser with an earlier image revision
+func (h *Handler) HandleCloudEvent(ctx context.Context, event cloudevents.Event) (err error) {
 	log.Printf("Received CloudEvent: %s, ID: %s", event.Type(), event.ID())
-	log.Printf("CloudEvent source: %s", event.Source())
-	log.Printf("CloudEvent subject: %s", event.Subject())
 
-	// 🔍 DEBUG: Log raw event data to help troubleshoot issues
-	rawData := event.Data()
-	if len(rawData) > 0 {
-		log.Printf("CloudEvent raw data: %s", string(rawData))
+	// =============================================================================
+	// 📊 OBSERVABILITY: Aggregated metrics always, traces/verbose logs sampled
+	// =============================================================================
+	start := time.Now()
+	typeLabel := observability.EventTypeLabel(event.Type())
+	observability.EventsReceived.WithLabelValues(typeLabel).Inc()
+
+	ctx, span := observability.Tracer().Start(ctx, "handle "+event.Type(), trace.WithAttributes(
+		attribute.String(observability.AttrEventType, event.Type()),
+		attribute.String(observability.AttrEventID, event.ID()),
+		attribute.String("cloudevents.event_source", event.Source()),
+	))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		observability.EventHandlingDuration.WithLabelValues(typeLabel).Observe(time.Since(start).Seconds())
+	}()
+
+	verbose := h.sampling.Sampled(event.Type(), event.ID())
+	if verbose {
+		observability.EventsSampled.WithLabelValues(typeLabel).Inc()
+		log.Printf("CloudEvent source: %s", event.Source())
+		log.Printf("CloudEvent subject: %s", event.Subject())
+
+		// 🔍 DEBUG: Log raw event data to help troubleshoot issues
+		rawData := event.Data()
+		if len(rawData) > 0 {
+			log.Printf("CloudEvent raw data: %s", string(rawData))
+		}
+	}
+
+	// =============================================================================
+	// ✍️ AUTHENTICATION: Only signed build requests, when a secret is configured
+	// =============================================================================
+	if err := h.authenticate(event); err != nil {
+		return err
+	}
+
+	// =============================================================================
+	// 🔀 TRANSFORMATION: Map legacy payload shapes to the canonical one
+	// =============================================================================
+	if len(event.Data()) > 0 {
+		transformed, rule, err := h.transformer.Apply(event.Type(), event.Source(), event.Data())
+		if err != nil {
+			log.Printf("ERROR: Failed to transform event %s: %v", event.ID(), err)
+			return err
+		}
+		if rule != "" {
+			if err := event.SetData(cloudevents.ApplicationJSON, transformed); err != nil {
+				return fmt.Errorf("failed to set transformed data: %w", err)
+			}
+			span.SetAttributes(attribute.String("cloudevents.transform_rule", rule))
+			if verbose {
+				log.Printf("CloudEvent data transformed by rule %q: %s", rule, string(transformed))
+			}
+		}
+	}
+
+	// =============================================================================
+	// 📜 VALIDATION: Reject payloads that violate their contract
+	// =============================================================================
+	if err := validate(event); err != nil {
+		return err
 	}
 
 	// =============================================================================
@@ -71,10 +179,34 @@ func (h *Handler) HandleCloudEvent(ctx context.Context, event cloudevents.Event)
 	// 📊 CASE 2: RESOURCE UPDATE EVENT
 	// =========================================================================
 	case EventTypeResourceUpdate:
-		return h.handleResourceUpdate(ctx, event)
+		return h.handleResourceUpdate(ctx, event, verbose)
+
+	// =========================================================================
+	// 🗑️ CASE 3: TEARDOWN EVENT
+	// =========================================================================
+	case EventTypeTeardown:
+		return h.handleTeardown(ctx, event)
+
+	// =========================================================================
+	// 🔁 CASE 4: REBUILD EVENT
+	// =========================================================================
+	case EventTypeRebuild:
+		return h.handleRebuild(ctx, event)
+
+	// =========================================================================
+	// 📦 CASE 5: BATCH BUILD EVENT
+	// =========================================================================
+	case EventTypeBuildBatch:
+		return h.handleBatchBuild(ctx, event)
+
+	// =========================================================================
+	// ⏪ CASE 6: ROLLBACK EVENT
+	// =========================================================================
+	case EventTypeRollback:
+		return h.handleRollback(ctx, event)
 
 	// =========================================================================
-	// ❓ CASE 3: UNKNOWN EVENT TYPE
+	// ❓ CASE 7: UNKNOWN EVENT TYPE
 	// =========================================================================
 	default:
 		log.Printf("Received unknown event type: %s", event.Type())
@@ -92,31 +224,233 @@ func (h *Handler) handleBuildStart(ctx context.Context, event cloudevents.Event)
 		return fmt.Errorf("failed to parse build event: %w", err)
 	}
 
+	if err := authorize(ctx, event, buildEvent.ThirdPartyId); err != nil {
+		return err
+	}
+	buildEvent.Origin = requestOrigin(event)
+
 	log.Printf("Successfully parsed build event: %+v", buildEvent)
+	if _, err := h.acceptBuild(ctx, buildEvent); err != nil {
+		return refused(event, err)
+	}
+	return nil
+}
+
+// handleRebuild processes rebuild events: a build.start that skips the build
+// cache and makes the parser roll to a new revision even if its tag is unchanged
+func (h *Handler) handleRebuild(ctx context.Context, event cloudevents.Event) error {
+	log.Printf("Processing rebuild event")
+
+	var buildEvent types.BuildEvent
+	if err := event.DataAs(&buildEvent); err != nil {
+		log.Printf("ERROR: Failed to parse rebuild event: %v", err)
+		return fmt.Errorf("failed to parse rebuild event: %w", err)
+	}
+	buildEvent.Rebuild = true
+	if err := authorize(ctx, event, buildEvent.ThirdPartyId); err != nil {
+		return err
+	}
+	buildEvent.Origin = requestOrigin(event)
+
+	log.Printf("Successfully parsed rebuild event: %+v", buildEvent)
+	if _, err := h.acceptBuild(ctx, buildEvent); err != nil {
+		return refused(event, err)
+	}
+	return nil
+}
+
+// SubmitBuild starts a build requested through the API, like a build.start
+// (or rebuild) event would; returns it with its build id
+// 📝 NOTE: Fails with a *RateLimitedError when the tenant is over its limit,
+// an *InvalidCallbackError when its callbackUrl can't be used, a
+// *build.InvalidVariablesError when its buildArgs or env can't, a
+// *build.InvalidGitSourceError when its git source can't, a
+// *build.InvalidResourcesError when its resources can't
+func (h *Handler) SubmitBuild(ctx context.Context, buildEvent types.BuildEvent) (types.BuildEvent, error) {
+	return h.acceptBuild(ctx, buildEvent)
+}
+
+// acceptBuild reports a build as accepted and starts it
+// 📝 NOTE: Builds without an id get one, so they can be looked up (GET /v1/builds/{id});
+// a duplicate request returns the build it duplicates without starting anything
+func (h *Handler) acceptBuild(ctx context.Context, buildEvent types.BuildEvent) (types.BuildEvent, error) {
+	if err := build.CheckIDs(buildEvent); err != nil {
+		return buildEvent, err
+	}
+	if err := build.CheckVariables(buildEvent); err != nil {
+		return buildEvent, err
+	}
+	if err := h.buildOrchestrator.CheckGitSource(buildEvent); err != nil {
+		return buildEvent, err
+	}
+	if err := h.buildOrchestrator.CheckInlineSource(buildEvent); err != nil {
+		return buildEvent, err
+	}
+	if err := h.buildOrchestrator.CheckDependencies(buildEvent); err != nil {
+		return buildEvent, err
+	}
+	if err := h.buildOrchestrator.CheckResources(ctx, buildEvent); err != nil {
+		return buildEvent, err
+	}
+	if err := build.CheckSourceChecksum(buildEvent); err != nil {
+		return buildEvent, err
+	}
+	// 🧾 Dry runs are never accepted: no build, no lifecycle events, no rate limit
+	if buildEvent.DryRun {
+		go h.logDryRun(backgroundContext(ctx), buildEvent)
+		return buildEvent, nil
+	}
+	if err := h.checkCallback(buildEvent); err != nil {
+		return buildEvent, err
+	}
+	// 🌿 The idempotency key, the build and its retries all use the commit the ref points at now
+	buildEvent, err := h.buildOrchestrator.ResolveSource(ctx, buildEvent)
+	if err != nil {
+		return buildEvent, err
+	}
+	if duplicate, ok := h.deduplicate(ctx, &buildEvent); ok {
+		return duplicate, nil
+	}
+	if err := h.admitBuild(ctx, buildEvent); err != nil {
+		h.dedup.release(buildEvent.IdempotencyKey)
+		return buildEvent, err
+	}
+	if err := h.checkRateLimit(ctx, buildEvent); err != nil {
+		h.dedup.release(buildEvent.IdempotencyKey)
+		h.backlog.done()
+		return buildEvent, err
+	}
+	if buildEvent.ID == "" {
+		buildEvent.ID = uuid.NewString()
+	}
+	h.createBuildRun(ctx, buildEvent)
+	h.emitLifecycle(ctx, EventTypeBuildAccepted, lifecycleData(buildEvent))
 
-	// Store current build for resource update events
-	h.currentBuild = &buildEvent
+	if ctx.Value(syncStartKey{}) != nil {
+		defer h.backlog.done()
+		h.runBuild(backgroundContext(ctx), buildEvent)
+		return buildEvent, nil
+	}
 
-	// 🏃‍♂️ Start build process in background (don't block event handler)
+	// 🏃‍♂️ Queue the build for the build workers (don't block event handler)
 	// WHY BACKGROUND: Event handlers should respond quickly
-	go func(be types.BuildEvent) {
-		if err := h.buildOrchestrator.CreateKanikoJob(ctx, be); err != nil {
-			log.Printf("ERROR: Background job creation failed: %v", err)
+	h.buildOrchestrator.QueueBuild(backgroundContext(ctx), buildEvent)
+	return buildEvent, nil
+}
+
+// WithBuildWorkers sizes the pool of workers starting accepted builds
+func (h *Handler) WithBuildWorkers(workers int) *Handler {
+	h.workers = workers
+	return h
+}
+
+// RunBuildWorkers starts accepted builds in a fixed pool of workers, highest
+// priority first, until ctx is done
+// 📝 NOTE: Builds wait in the build queue, not in a goroutine each
+func (h *Handler) RunBuildWorkers(ctx context.Context) {
+	h.buildOrchestrator.RunBuildWorkers(ctx, h.workers, func(ctx context.Context, be types.BuildEvent) {
+		defer h.backlog.done()
+		h.runBuild(ctx, be)
+	})
+}
+
+// runBuild starts a build this replica holds; one another replica took over
+// (see WithWorkStealing) is skipped
+func (h *Handler) runBuild(ctx context.Context, be types.BuildEvent) {
+	if !h.holdBuildRun(ctx, be) {
+		return
+	}
+	defer h.releaseBuildRun(be)
+	h.startBuild(ctx, be)
+}
+
+// syncStartKey marks contexts whose builds start before the handler returns
+type syncStartKey struct{}
+
+// WithSyncStart makes build.start/rebuild handling create the build's job
+// before returning
+// 🎯 WHY: Queue transports ack a request once it's handled; a builder restart
+// right after must not lose the build
+func WithSyncStart(ctx context.Context) context.Context {
+	return context.WithValue(ctx, syncStartKey{}, true)
+}
+
+// HandleAckedCloudEvent processes a CloudEvent a queue transport acks (or
+// commits the offset of) once this returns: builds it accepts are started,
+// not just queued, by then
+// 🎯 WHY: Kafka, RabbitMQ and NATS don't redeliver an acked request; a
+// builder restart between the ack and the build's job must not lose it
+func (h *Handler) HandleAckedCloudEvent(ctx context.Context, event cloudevents.Event) error {
+	return h.HandleCloudEvent(WithSyncStart(ctx), event)
+}
+
+// startBuild creates the Kaniko job (or deploys right away on a cache hit)
+func (h *Handler) startBuild(ctx context.Context, be types.BuildEvent) {
+	ctx, span := observability.Tracer().Start(ctx, "build.create-kaniko-job")
+	defer span.End()
+	if be.Rebuild {
+		span.SetAttributes(attribute.Bool("build.rebuild", true))
+	}
+
+	h.recordStatus(ctx, be, history.StatusBuilding, "")
+	h.updateBuild(ctx, be, func(entry *history.Entry) {
+		entry.BuildId = be.ID
+		entry.Phase = history.PhaseQueued
+	})
+	result, err := h.buildOrchestrator.CreateKanikoJob(ctx, be)
+	if err != nil {
+		log.Printf("ERROR: Background job creation failed: %v", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		// 🔁 AWS throttling or an outage: retry with backoff first
+		if h.retryStartFailure(ctx, be, err) {
+			return
+		}
+		stage := StageBuild
+		if build.IsValidationError(err) {
+			stage = StageValidate
+		}
+		h.failBuild(ctx, be, stage, "", err.Error())
+		return
+	}
+	// 🏷️ From here on the build deploys the image revision it pushed (or the cached one)
+	be.ImageTag = result.ImageTag
+	be.SourceSHA256, be.SourceETag = result.SourceSHA256, result.SourceETag
+	h.builds.track(result.JobName, be)
+	h.updateBuild(ctx, be, func(entry *history.Entry) {
+		entry.Image = h.buildOrchestrator.ImageURI(be)
+		entry.Commit = result.Commit
+		if result.JobName != "" {
+			entry.JobName = result.JobName
+			entry.Phase = history.PhaseBuilding
 		}
-	}(buildEvent)
+	})
 
-	return nil
+	started := lifecycleData(be)
+	started.JobName = result.JobName
+	started.Image = h.buildOrchestrator.ImageURI(be)
+	started.Cached = result.Cached
+	started.SourceSHA256, started.SourceETag = be.SourceSHA256, be.SourceETag
+	h.emitLifecycle(ctx, EventTypeBuildStarted, started)
+
+	// ⚡ Image for these exact inputs already exists: no job, deploy right away
+	if result.Cached {
+		span.SetAttributes(attribute.Bool("build.cached", true))
+		skipped := lifecycleData(be)
+		skipped.Image = started.Image
+		skipped.ImageDigest = result.ImageDigest
+		skipped.Cached = true
+		skipped.SourceSHA256, skipped.SourceETag = be.SourceSHA256, be.SourceETag
+		h.emitLifecycle(ctx, EventTypeBuildSkipped, skipped)
+		h.deployParser(ctx, be)
+	}
 }
 
 // handleResourceUpdate processes Kubernetes resource update events
-func (h *Handler) handleResourceUpdate(ctx context.Context, event cloudevents.Event) error {
-	log.Printf("Processing resource update event")
-
+// 📝 NOTE: verbose is the sampling decision; these events are high-volume
+func (h *Handler) handleResourceUpdate(ctx context.Context, event cloudevents.Event, verbose bool) error {
 	var resourceEvent types.ResourceEventData
 
-	// 🔍 DEBUG: Log raw event data for troubleshooting
-	log.Printf("Raw event data: %s", string(event.Data()))
-
 	// 📥 Try to parse the event data
 	if err := event.DataAs(&resourceEvent); err != nil {
 		log.Printf("ERROR: Failed to parse resource event: %v", err)
@@ -124,11 +458,12 @@ func (h *Handler) handleResourceUpdate(ctx context.Context, event cloudevents.Ev
 		return nil
 	}
 
-	log.Printf("Received resource event: Kind=%s, Name=%s",
-		resourceEvent.Kind, resourceEvent.Name)
-
 	// 🔍 DEBUG: Log detailed status information
-	if resourceEvent.Status != nil {
+	if verbose {
+		log.Printf("Received resource event: Kind=%s, Name=%s",
+			resourceEvent.Kind, resourceEvent.Name)
+	}
+	if verbose && resourceEvent.Status != nil {
 		if conditions, ok := resourceEvent.Status["conditions"].([]interface{}); ok {
 			log.Printf("Job conditions:")
 			for _, c := range conditions {
@@ -140,26 +475,195 @@ func (h *Handler) handleResourceUpdate(ctx context.Context, event cloudevents.Ev
 		}
 	}
 
+	// 👀 The job watcher already sees every job update, straight from the API server
+	if resourceEvent.Kind == "Job" && h.jobs != nil {
+		if verbose {
+			log.Printf("Ignoring resource event of job %s, jobs are watched", resourceEvent.Name)
+		}
+		return nil
+	}
+
+	h.handleJobUpdate(ctx, &resourceEvent)
+	return nil
+}
+
+// handleJobUpdate acts on a job's status change: deploys what a build job
+// built, fails (or retries) the build of a failed one
+func (h *Handler) handleJobUpdate(ctx context.Context, resourceEvent *types.ResourceEventData) {
+	// 🧪 Parser test jobs gate the deployment of the image they tested
+	if resourceEvent.Kind == "Job" && build.IsTestJob(resourceEvent.Name) {
+		h.handleTestJobUpdate(ctx, resourceEvent)
+		return
+	}
+
+	// 📦 SBOM jobs only record where they uploaded the SBOMs
+	if resourceEvent.Kind == "Job" && build.IsSBOMJob(resourceEvent.Name) {
+		h.handleSBOMJobUpdate(ctx, resourceEvent)
+		return
+	}
+
 	// 🎯 THE IMPORTANT PART: Check if a build job completed successfully
 	if resourceEvent.Kind == "Job" && resourceEvent.IsJobComplete() {
-		log.Printf("Job completed, creating parser service")
-
-		// Use current build info if available, otherwise try from event
-		buildEvent := h.currentBuild
-		if buildEvent == nil {
-			buildEvent = &resourceEvent.BuildEvent
+		buildEvent, ok := h.builds.lookup(resourceEvent)
+		if !ok {
+			log.Printf("WARNING: Job %s completed but matches no build, ignoring it", resourceEvent.Name)
+			return
 		}
-
+		log.Printf("Job %s completed, testing and deploying parser", resourceEvent.Name)
 		log.Printf("Creating parser service for ThirdPartyId=%s, ParserId=%s",
 			buildEvent.ThirdPartyId, buildEvent.ParserId)
 
 		// 🏃‍♂️ Create service in background (don't block event handler)
-		go func(be *types.BuildEvent) {
-			if err := h.parserService.CreateParserService(ctx, *be); err != nil {
-				log.Printf("ERROR: Background parser service creation failed: %v", err)
+		go func(be types.BuildEvent, jobName string) {
+			ctx := backgroundContext(ctx)
+			h.recordPhase(ctx, be, history.PhasePushing)
+			if err := h.buildOrchestrator.RecordImage(ctx, be, jobName); err != nil {
+				log.Printf("WARNING: Failed to record image digest in the build cache: %v", err)
+			}
+			pushed := lifecycleData(be)
+			pushed.JobName = jobName
+			pushed.Image = h.buildOrchestrator.ImageURI(be)
+			pushed.SourceSHA256, pushed.SourceETag = be.SourceSHA256, be.SourceETag
+			if digest, err := h.buildOrchestrator.ImageDigest(ctx, be); err != nil {
+				log.Printf("WARNING: Failed to get the digest of %s: %v", pushed.Image, err)
+			} else {
+				pushed.ImageDigest = digest
+			}
+			h.emitLifecycle(ctx, EventTypeBuildImagePushed, pushed)
+
+			if err := h.buildOrchestrator.CleanupContext(ctx, be, jobName); err != nil {
+				log.Printf("WARNING: Failed to clean up build context: %v", err)
 			}
-		}(buildEvent)
+			h.generateSBOM(ctx, be)
+			h.testParser(ctx, be)
+		}(buildEvent, resourceEvent.Name)
 	}
 
-	return nil
+	// ❌ The Kaniko job itself failed (build error, backoff limit reached)
+	if resourceEvent.Kind == "Job" && resourceEvent.IsJobFailed() {
+		buildEvent, ok := h.builds.lookup(resourceEvent)
+		if !ok {
+			log.Printf("WARNING: Job %s failed but matches no build, ignoring it", resourceEvent.Name)
+			return
+		}
+
+		// 🪂 Preempted or evicted: not the build's fault, requeue it
+		if h.handlePreemptedBuild(ctx, buildEvent, resourceEvent.Name, resourceEvent) {
+			return
+		}
+
+		// ⏱️ Ran past BUILD_TIMEOUT: fail it without retrying
+		if h.handleTimedOutBuild(ctx, buildEvent, resourceEvent.Name, resourceEvent) {
+			return
+		}
+
+		// 🔁 Possibly transient (registry throttling): retry with backoff first
+		if h.retryFailedBuild(ctx, buildEvent, resourceEvent.Name, resourceEvent) {
+			return
+		}
+
+		log.Printf("Job %s failed for ThirdPartyId=%s, ParserId=%s",
+			resourceEvent.Name, buildEvent.ThirdPartyId, buildEvent.ParserId)
+		message := "build job " + resourceEvent.Name + " failed"
+		if progress, ok := h.buildOrchestrator.BuildProgress(ctx, buildEvent, resourceEvent.Name); ok {
+			message += " at " + progress.String()
+		}
+		if buildEvent.Retry > 0 {
+			message += fmt.Sprintf(", giving up after %d retries", buildEvent.Retry)
+		}
+		h.failBuild(ctx, buildEvent, StageBuild, resourceEvent.Name, message)
+	}
+}
+
+// deployParser creates the parser's Knative Service and trigger
+func (h *Handler) deployParser(ctx context.Context, be types.BuildEvent) {
+	ctx, span := observability.Tracer().Start(ctx, "services.create-parser-service")
+	defer span.End()
+
+	h.recordPhase(ctx, be, history.PhaseDeploying)
+	if !h.checkScan(ctx, be) {
+		return
+	}
+	// 🔧 A build started before a restart is only known by its job's labels
+	// (no build id): its environment was recorded with its revision
+	if be.ID == "" && be.ImageTag != "" {
+		env, err := h.buildOrchestrator.RevisionEnv(ctx, be)
+		if err != nil {
+			log.Printf("WARNING: Deploying %s/%s without its environment: %v", be.ThirdPartyId, be.ParserId, err)
+		}
+		be.Env = env
+	}
+	mode, err := h.parserService.CreateParserService(ctx, be)
+	span.SetAttributes(attribute.String("deploy.mode", mode))
+	h.updateBuild(ctx, be, func(entry *history.Entry) { entry.DeployMode = mode })
+	if err != nil {
+		log.Printf("ERROR: Background parser service creation failed: %v", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		h.emitTriggerFailed(ctx, be, err)
+		h.failBuild(ctx, be, StageDeploy, "", err.Error())
+		return
+	}
+	h.recordStatus(ctx, be, history.StatusPassing, "")
+	if err := h.buildOrchestrator.RecordDeploy(ctx, be); err != nil {
+		log.Printf("WARNING: Failed to record the deploy of %s: %v", h.buildOrchestrator.ImageURI(be), err)
+	}
+
+	deployed := lifecycleData(be)
+	deployed.Image = h.buildOrchestrator.ImageURI(be)
+	deployed.DeployMode = mode
+	deployed.SourceSHA256, deployed.SourceETag = be.SourceSHA256, be.SourceETag
+	h.emitLifecycle(ctx, EventTypeBuildDeployed, deployed)
+	h.notifyCallback(ctx, be, types.BuildCallbackData{Status: CallbackDeployed, Image: deployed.Image, DeployMode: mode})
+	h.finishBatchBuild(ctx, be, types.BatchBuildResult{Status: BatchBuildDeployed})
+}
+
+// recordStatus updates the build history, logging (not failing) on error
+func (h *Handler) recordStatus(ctx context.Context, be types.BuildEvent, status, message string) {
+	if err := h.history.Record(ctx, be.ThirdPartyId, be.ParserId, status, message); err != nil {
+		log.Printf("ERROR: Failed to record %s build status for %s/%s: %v",
+			status, be.ThirdPartyId, be.ParserId, err)
+	}
+}
+
+// updateBuild changes the build's history entry, logging (not failing) on error
+func (h *Handler) updateBuild(ctx context.Context, be types.BuildEvent, change func(*history.Entry)) {
+	if err := history.UpdateLatest(ctx, h.history, be.ThirdPartyId, be.ParserId, change); err != nil {
+		log.Printf("WARNING: Failed to update the build record of %s/%s: %v", be.ThirdPartyId, be.ParserId, err)
+	}
+}
+
+// recordPhase notes where the build is in the pipeline (GET /v1/builds/{id}/events)
+func (h *Handler) recordPhase(ctx context.Context, be types.BuildEvent, phase string) {
+	h.updateBuild(ctx, be, func(entry *history.Entry) { entry.Phase = phase })
+}
+
+// emitTriggerFailed publishes trigger.failed when a parser's trigger never became Ready
+// 🎯 WHY: Otherwise the parser looks deployed but silently never receives events
+func (h *Handler) emitTriggerFailed(ctx context.Context, be types.BuildEvent, err error) {
+	var notReady *services.TriggerNotReadyError
+	if !errors.As(err, &notReady) {
+		return
+	}
+
+	data := types.TriggerFailedEventData{
+		ThirdPartyId: be.ThirdPartyId,
+		ParserId:     be.ParserId,
+		Kind:         notReady.Kind,
+		Namespace:    notReady.Namespace,
+		Name:         notReady.Name,
+		Condition:    notReady.Condition,
+		Reason:       notReady.Reason,
+		Message:      notReady.Message,
+	}
+	if emitErr := h.emitter.Emit(ctx, EventTypeTriggerFailed, be.ThirdPartyId+"/"+be.ParserId, data); emitErr != nil {
+		log.Printf("ERROR: Failed to emit %s: %v", EventTypeTriggerFailed, emitErr)
+	}
+}
+
+// backgroundContext detaches background work from the request context
+// 🎯 WHY: The request context is cancelled as soon as the handler returns, but
+// the pipeline must keep running; we only carry over the trace
+func backgroundContext(ctx context.Context) context.Context {
+	return trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(ctx))
 }
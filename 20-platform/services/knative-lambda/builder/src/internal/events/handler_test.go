@@ -0,0 +1,87 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+
+	"knative-lambda-builder/emit"
+	"knative-lambda-builder/execution"
+
+	"knative-lambda-builder/internal/aws"
+	"knative-lambda-builder/internal/build"
+	"knative-lambda-builder/internal/config"
+	"knative-lambda-builder/internal/history"
+	"knative-lambda-builder/internal/transform"
+	"knative-lambda-builder/internal/types"
+	"knative-lambda-builder/registry"
+	"knative-lambda-builder/storage"
+)
+
+func TestAcceptBuildRefusesUnsafeIDs(t *testing.T) {
+	// The ids are checked before anything else, so a zero Handler will do
+	h := &Handler{}
+	for _, be := range []types.BuildEvent{
+		{ThirdPartyId: "..", ParserId: "p1"},
+		{ThirdPartyId: "acme", ParserId: "../../etc"},
+		{ThirdPartyId: "acme", ParserId: "a/b"},
+		{ThirdPartyId: "Acme", ParserId: "p1"},
+	} {
+		_, err := h.acceptBuild(context.Background(), be)
+		var invalid *build.InvalidIDError
+		if !errors.As(err, &invalid) || !invalid.InvalidRequest() {
+			t.Errorf("acceptBuild(%s/%s) = %v, want an InvalidIDError (400)", be.ThirdPartyId, be.ParserId, err)
+		}
+	}
+}
+
+func TestHandleAckedCloudEvent(t *testing.T) {
+	event := cloudevents.NewEvent()
+	event.SetID("evt-1")
+	event.SetType(EventTypeBuildStart)
+	event.SetSource("test")
+	event.SetData(cloudevents.ApplicationJSON, map[string]string{"thirdPartyId": "acme", "parserId": "p1"})
+
+	newHandler := func() (*Handler, *build.Orchestrator, *execution.FakeExecutor) {
+		cfg := &config.Config{
+			S3SourceBucket:        "sources",
+			S3TmpBucket:           "tmp",
+			ECRBaseRegistry:       "localhost:5001/knative-lambdas",
+			JobTemplatePath:       "../../templates/job.yaml.tpl",
+			TemplatesDir:          "../../templates",
+			DefaultDockerfileName: config.DefaultDockerfileName,
+			BaseImage:             "node:18-alpine",
+			KanikoImage:           config.DefaultKanikoImage,
+		}
+		store := storage.NewFakeObjectStore()
+		store.Seed("sources", build.SourceKey(types.BuildEvent{ThirdPartyId: "acme", ParserId: "p1"}), []byte("module.exports = () => {}"))
+		executor := execution.NewFakeExecutor()
+		orchestrator := build.NewOrchestratorWithDependencies(cfg, &aws.Client{}, build.Dependencies{
+			Store:    store,
+			Registry: registry.NewFakeRegistry(),
+			Executor: executor,
+		})
+		transformer, _ := transform.New(nil)
+		return NewHandler(orchestrator, nil, emit.NewFakeEmitter(), history.NewMemoryStore(), transformer, nil), orchestrator, executor
+	}
+
+	// The HTTP receiver answers once the build is queued for the workers
+	h, orchestrator, executor := newHandler()
+	if err := h.HandleCloudEvent(context.Background(), event); err != nil {
+		t.Fatalf("HandleCloudEvent() = %v", err)
+	}
+	if queued, launched := orchestrator.QueuedBuilds(), executor.Launched(); len(queued) != 1 || len(launched) != 0 {
+		t.Errorf("after HandleCloudEvent(): %d queued, %d jobs, want 1 queued", len(queued), len(launched))
+	}
+
+	// Queue transports (Kafka, RabbitMQ, NATS) ack once the build's job exists
+	h, orchestrator, executor = newHandler()
+	if err := h.HandleAckedCloudEvent(context.Background(), event); err != nil {
+		t.Fatalf("HandleAckedCloudEvent() = %v", err)
+	}
+	if queued, launched := orchestrator.QueuedBuilds(), executor.Launched(); len(queued) != 0 || len(launched) != 1 {
+		t.Errorf("after HandleAckedCloudEvent(): %d queued, %d jobs, want 1 job", len(queued), len(launched))
+	}
+}
@@ -0,0 +1,159 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"knative-lambda-builder/internal/tenants"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 👀 JOB WATCHER
+// =============================================================================
+// The builder watches the jobs of its namespace itself (an informer on the
+// jobs labelled with a parser id: build, test and SBOM jobs) and acts on
+// each job once it is Complete or Failed
+// 🎯 WHY: The ApiServerSource's resource.update events went through the
+// broker: they were sampled, could be dropped, and the builder needed an
+// external source (and its ClusterRole) to learn a build had finished
+// 📝 NOTE: Every replica watches every job, so a finished job is claimed
+// first: the replica whose claim annotation gets written handles it, the
+// others see the annotation. Jobs that finished while no builder was running
+// are claimed (and handled) when the watch starts
+
+// AnnotationJobHandledBy names the replica that handled a finished job
+const AnnotationJobHandledBy = "knative-lambda.notifi.network/handled-by"
+
+// jobWatcher watches the jobs of a namespace
+type jobWatcher struct {
+	clientset kubernetes.Interface
+	namespace string
+	replica   string // Written into AnnotationJobHandledBy
+}
+
+// WithJobWatcher makes the handler watch the jobs of a namespace, instead of
+// waiting for resource.update events (which are then ignored)
+func (h *Handler) WithJobWatcher(clientset kubernetes.Interface, namespace string) *Handler {
+	replica, err := os.Hostname()
+	if err != nil {
+		replica = "knative-lambda-builder"
+	}
+	h.jobs = &jobWatcher{clientset: clientset, namespace: namespace, replica: replica}
+	return h
+}
+
+// WatchJobs handles the namespace's finished jobs until ctx is done
+// 📝 NOTE: Does nothing without WithJobWatcher
+func (h *Handler) WatchJobs(ctx context.Context) {
+	if h.jobs == nil {
+		return
+	}
+	factory := informers.NewSharedInformerFactoryWithOptions(h.jobs.clientset, 0,
+		informers.WithNamespace(h.jobs.namespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.LabelSelector = tenants.LabelParserId
+		}))
+	informer := factory.Batch().V1().Jobs().Informer()
+	onJob := func(obj interface{}) {
+		if job, ok := obj.(*batchv1.Job); ok {
+			h.handleWatchedJob(ctx, job)
+		}
+	}
+	if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    onJob,
+		UpdateFunc: func(_, obj interface{}) { onJob(obj) },
+	}); err != nil {
+		log.Printf("ERROR: Failed to watch jobs: %v", err)
+		return
+	}
+
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		log.Printf("ERROR: Job watcher stopped before listing the jobs of %s", h.jobs.namespace)
+		return
+	}
+	log.Printf("👀 Watching jobs in %s", h.jobs.namespace)
+	<-ctx.Done()
+	factory.Shutdown()
+}
+
+// handleWatchedJob handles a job once it finished, if this replica claims it
+func (h *Handler) handleWatchedJob(ctx context.Context, job *batchv1.Job) {
+	if !jobFinished(job) || job.Annotations[AnnotationJobHandledBy] != "" {
+		return
+	}
+	claimed, err := h.jobs.claim(ctx, job)
+	if err != nil {
+		log.Printf("WARNING: Failed to claim finished job %s: %v", job.Name, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	resourceEvent, err := jobResourceEvent(job)
+	if err != nil {
+		log.Printf("ERROR: Failed to read the status of job %s: %v", job.Name, err)
+		return
+	}
+	h.handleJobUpdate(ctx, &resourceEvent)
+}
+
+// claim writes the replica into a finished job's annotations; false when
+// another replica claimed it (or the job changed) first
+// 📝 NOTE: The update carries the job's resourceVersion, so only one of
+// concurrent claims succeeds. A job that changed is seen again anyway
+func (w *jobWatcher) claim(ctx context.Context, job *batchv1.Job) (bool, error) {
+	claimed := job.DeepCopy()
+	if claimed.Annotations == nil {
+		claimed.Annotations = map[string]string{}
+	}
+	claimed.Annotations[AnnotationJobHandledBy] = w.replica
+	_, err := w.clientset.BatchV1().Jobs(job.Namespace).Update(ctx, claimed, metav1.UpdateOptions{})
+	if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// jobFinished reports whether a job is Complete or Failed
+func jobFinished(job *batchv1.Job) bool {
+	for _, c := range job.Status.Conditions {
+		if (c.Type == batchv1.JobComplete || c.Type == batchv1.JobFailed) && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// jobResourceEvent describes a job the way resource.update events do
+func jobResourceEvent(job *batchv1.Job) (types.ResourceEventData, error) {
+	status, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&job.Status)
+	if err != nil {
+		return types.ResourceEventData{}, fmt.Errorf("failed to convert job status: %w", err)
+	}
+	return types.ResourceEventData{
+		Kind: "Job",
+		Name: job.Name,
+		Metadata: types.ResourceMetadata{
+			Labels:      job.Labels,
+			Annotations: job.Annotations,
+		},
+		Status: status,
+	}, nil
+}
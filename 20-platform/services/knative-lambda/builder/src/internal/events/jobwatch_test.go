@@ -0,0 +1,58 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestJobWatcher(t *testing.T) {
+	ctx := context.Background()
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "build-acme-p1-1700000000", Namespace: "knative-lambda",
+			Labels: map[string]string{"knative-lambda.notifi.network/parser-id": "p1"}},
+		Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+			{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "DeadlineExceeded", Message: "Job was active longer than specified deadline"},
+		}},
+	}
+	if !jobFinished(job) {
+		t.Fatal("jobFinished() of a Failed job = false")
+	}
+
+	// Finished jobs read like resource.update events
+	resourceEvent, err := jobResourceEvent(job)
+	if err != nil {
+		t.Fatalf("jobResourceEvent() = %v", err)
+	}
+	if reason, _ := resourceEvent.JobFailure(); !resourceEvent.IsJobFailed() || resourceEvent.IsJobComplete() || reason != "DeadlineExceeded" {
+		t.Errorf("resource event of a failed job: failed=%t complete=%t reason=%q",
+			resourceEvent.IsJobFailed(), resourceEvent.IsJobComplete(), reason)
+	}
+
+	// One replica claims the job; the other loses the race
+	clientset := fake.NewSimpleClientset(job)
+	first := &jobWatcher{clientset: clientset, replica: "builder-a"}
+	if claimed, err := first.claim(ctx, job); !claimed || err != nil {
+		t.Fatalf("claim() = %t, %v, want the job claimed", claimed, err)
+	}
+	live, _ := clientset.BatchV1().Jobs("knative-lambda").Get(ctx, job.Name, metav1.GetOptions{})
+	if live.Annotations[AnnotationJobHandledBy] != "builder-a" {
+		t.Errorf("claimed job annotations = %v", live.Annotations)
+	}
+
+	clientset.PrependReactor("update", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewConflict(schema.GroupResource{Group: "batch", Resource: "jobs"}, job.Name, nil)
+	})
+	second := &jobWatcher{clientset: clientset, replica: "builder-b"}
+	if claimed, err := second.claim(ctx, job); claimed || err != nil {
+		t.Errorf("claim() of a job claimed meanwhile = %t, %v, want false", claimed, err)
+	}
+}
@@ -0,0 +1,81 @@
+package events
+
+import (
+	"context"
+	"log"
+
+	"knative-lambda-builder/internal/history"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 📣 BUILD LIFECYCLE EVENTS
+// =============================================================================
+// The builder reports each step of a build to its sink (K_SINK), with subject
+// <thirdPartyId>/<parserId>:
+//
+//	build.accepted -> build.started -> build.image.pushed -> build.deployed
+//	                  (any step)    -> build.failed
+//	                  (scan gate)   -> build.blocked -> build.failed
+//	                  (job failed)  -> build.retrying -> build.started ...
+//
+// Cached builds go from build.started (cached=true) and build.skipped straight
+// to build.deployed; a request that isn't accepted (rate limit) gets
+// build.rejected instead
+
+// Lifecycle CloudEvent types emitted by the builder
+const (
+	EventTypeBuildAccepted    = "network.notifi.lambda.build.accepted"
+	EventTypeBuildStarted     = "network.notifi.lambda.build.started"
+	EventTypeBuildSkipped     = "network.notifi.lambda.build.skipped"
+	EventTypeBuildImagePushed = "network.notifi.lambda.build.image.pushed"
+	EventTypeBuildDeployed    = "network.notifi.lambda.build.deployed"
+	EventTypeBuildFailed      = "network.notifi.lambda.build.failed"
+)
+
+// Stages reported by build.failed
+const (
+	StageValidate = "validate" // The parser source doesn't compile, or its Dockerfile breaks the policy
+	StageBuild    = "build"
+	StageTest     = "test"
+	StageScan     = "scan"
+	StageDeploy   = "deploy"
+)
+
+// lifecycleData starts a lifecycle payload for a build
+func lifecycleData(be types.BuildEvent) types.BuildLifecycleEventData {
+	return types.BuildLifecycleEventData{
+		ThirdPartyId: be.ThirdPartyId,
+		ParserId:     be.ParserId,
+		BuildId:      be.ID,
+		Attempt:      be.Attempt,
+		Retry:        be.Retry,
+	}
+}
+
+// emitLifecycle publishes a lifecycle event, logging (not failing) on error
+// 🎯 WHY: The sink being down must never stop a build
+// 📝 NOTE: The build's BuildRun, if any, follows the event
+func (h *Handler) emitLifecycle(ctx context.Context, eventType string, data types.BuildLifecycleEventData) {
+	h.updateBuildRun(ctx, eventType, data)
+	if err := h.emitter.Emit(ctx, eventType, data.ThirdPartyId+"/"+data.ParserId, data); err != nil {
+		log.Printf("ERROR: Failed to emit %s: %v", eventType, err)
+	}
+}
+
+// failBuild marks a build as failing, emits build.failed and dead-letters
+// its request
+// 📝 NOTE: Only called once the build is out of retries
+func (h *Handler) failBuild(ctx context.Context, be types.BuildEvent, stage, jobName, message string) {
+	h.recordStatus(ctx, be, history.StatusFailing, message)
+	h.dedup.release(be.IdempotencyKey)
+
+	data := lifecycleData(be)
+	data.Stage = stage
+	data.JobName = jobName
+	data.Error = message
+	h.emitLifecycle(ctx, EventTypeBuildFailed, data)
+	h.deadLetter(ctx, be, data)
+	h.notifyCallback(ctx, be, types.BuildCallbackData{Status: CallbackFailed, Stage: stage, Error: message})
+	h.finishBatchBuild(ctx, be, types.BatchBuildResult{Status: BatchBuildFailed, Stage: stage, Error: message})
+}
@@ -0,0 +1,95 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"knative-lambda-builder/internal/history"
+	"knative-lambda-builder/internal/observability"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🪂 REQUEUEING PREEMPTED BUILDS
+// =============================================================================
+// A preempted/evicted build job is not a build failure: the build is started
+// again (new job, attempt+1) after a backoff, up to BuildPreemptionRetries.
+
+// requeueMemory is how long a handled job name is remembered
+// 🎯 WHY: The apiserver source sends the Failed job several times
+const requeueMemory = time.Hour
+
+// requeueTracker deduplicates requeues per failed job
+type requeueTracker struct {
+	mu      sync.Mutex
+	handled map[string]time.Time // jobName -> when it was requeued
+}
+
+// claim returns true the first time it is called for a job
+func (t *requeueTracker) claim(jobName string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for name, at := range t.handled {
+		if now.Sub(at) > requeueMemory {
+			delete(t.handled, name)
+		}
+	}
+	if _, seen := t.handled[jobName]; seen {
+		return false
+	}
+	if t.handled == nil {
+		t.handled = map[string]time.Time{}
+	}
+	t.handled[jobName] = now
+	return true
+}
+
+// handlePreemptedBuild requeues a build whose job was preempted
+// 📝 NOTE: Returns false when the job failed for another reason
+func (h *Handler) handlePreemptedBuild(ctx context.Context, be types.BuildEvent, jobName string, resourceEvent *types.ResourceEventData) bool {
+	failureReason, failureMessage := resourceEvent.JobFailure()
+	reason := h.buildOrchestrator.PreemptionReason(ctx, jobName, failureReason, failureMessage)
+	if reason == "" {
+		return false
+	}
+	if !h.requeues.claim(jobName) {
+		return true // Already requeued on an earlier update of this job
+	}
+	observability.BuildPreemptions.WithLabelValues(reason).Inc()
+
+	be.Attempt++
+	if be.Attempt > h.buildOrchestrator.MaxPreemptionRetries() {
+		observability.BuildRequeues.WithLabelValues("exhausted").Inc()
+		message := fmt.Sprintf("build job %s %s, giving up after %d attempts", jobName, reason, be.Attempt)
+		log.Printf("ERROR: %s for ThirdPartyId=%s, ParserId=%s", message, be.ThirdPartyId, be.ParserId)
+		h.failBuild(ctx, be, StageBuild, jobName, message)
+		return true
+	}
+
+	backoff := h.buildOrchestrator.PreemptionBackoff(be.Attempt)
+	observability.BuildRequeues.WithLabelValues("requeued").Inc()
+	log.Printf("🪂 Build job %s disrupted (%s), requeueing ThirdPartyId=%s, ParserId=%s as attempt %d in %s",
+		jobName, reason, be.ThirdPartyId, be.ParserId, be.Attempt, backoff)
+	h.recordStatus(ctx, be, history.StatusBuilding, fmt.Sprintf("requeued after %s (attempt %d)", reason, be.Attempt))
+
+	ctx = backgroundContext(ctx)
+	time.AfterFunc(backoff, func() {
+		ctx, span := observability.Tracer().Start(ctx, "build.requeue", trace.WithAttributes(
+			attribute.Int("build.attempt", be.Attempt),
+			attribute.String("build.disruption_reason", reason),
+		))
+		defer span.End()
+
+		// startBuild tracks the new job, so its updates carry the new attempt
+		h.startBuild(ctx, be)
+	})
+	return true
+}
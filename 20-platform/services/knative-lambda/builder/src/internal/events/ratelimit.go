@@ -0,0 +1,136 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"knative-lambda-builder/internal/observability"
+	"knative-lambda-builder/internal/tenants"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🚦 BUILD RATE LIMITING
+// =============================================================================
+// Every tenant has a token bucket holding a period's worth of builds, refilled
+// evenly over the period. A build.start/rebuild (or API request) without a
+// token is refused (429) and reported as build.rejected
+// 🎯 WHY: One noisy tenant could otherwise fill the cluster with Kaniko jobs
+// 📝 NOTE: Buckets are per replica; duplicates and requeued builds don't
+// take a token
+
+// EventTypeBuildRejected is emitted when a build request is refused
+const EventTypeBuildRejected = "network.notifi.lambda.build.rejected"
+
+// RejectedRateLimited is the build.rejected reason of rate limited requests
+const RejectedRateLimited = "rate_limited"
+
+// RateLimitPolicy resolves a tenant's build rate limit
+// (implemented by tenants.BuildRateLimits)
+type RateLimitPolicy interface {
+	BuildRateLimit(ctx context.Context, thirdPartyId string) (tenants.RateLimit, error)
+}
+
+// RateLimitedError is returned for a build refused by the rate limit
+type RateLimitedError struct {
+	ThirdPartyId string
+	Limit        tenants.RateLimit
+	RetryAfter   time.Duration // When the next build would be allowed
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("tenant %s exceeded its build rate limit (%d per %s), retry in %s",
+		e.ThirdPartyId, e.Limit.Builds, e.Limit.Per, e.RetryAfter.Round(time.Second))
+}
+
+// RetryAfterSeconds rounds RetryAfter up to whole seconds (Retry-After)
+func (e *RateLimitedError) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+// tenantBucket is a tenant's token bucket and the limit it was sized for
+type tenantBucket struct {
+	limit   tenants.RateLimit
+	limiter *rate.Limiter
+}
+
+// rateLimiter keeps the token buckets of the tenants
+type rateLimiter struct {
+	mu      sync.Mutex
+	policy  RateLimitPolicy // nil = unlimited
+	buckets map[string]*tenantBucket
+}
+
+// take consumes a build token of a tenant; returns how long until one is
+// available when there is none
+// 📝 NOTE: A tenant whose limit can't be resolved isn't limited
+func (l *rateLimiter) take(ctx context.Context, thirdPartyId string, now time.Time) (tenants.RateLimit, time.Duration) {
+	if l.policy == nil {
+		return tenants.RateLimit{}, 0
+	}
+	limit, err := l.policy.BuildRateLimit(ctx, thirdPartyId)
+	if err != nil {
+		log.Printf("WARNING: Failed to resolve the build rate limit of %s, not limiting it: %v", thirdPartyId, err)
+		return limit, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if limit.Unlimited() {
+		delete(l.buckets, thirdPartyId)
+		return limit, 0
+	}
+
+	bucket, ok := l.buckets[thirdPartyId]
+	if !ok {
+		bucket = &tenantBucket{limit: limit, limiter: rate.NewLimiter(rate.Every(limit.Per/time.Duration(limit.Builds)), limit.Builds)}
+		if l.buckets == nil {
+			l.buckets = map[string]*tenantBucket{}
+		}
+		l.buckets[thirdPartyId] = bucket
+	} else if bucket.limit != limit {
+		// The tenant's limit changed: resize its bucket, keeping its tokens
+		bucket.limiter.SetLimitAt(now, rate.Every(limit.Per/time.Duration(limit.Builds)))
+		bucket.limiter.SetBurstAt(now, limit.Builds)
+		bucket.limit = limit
+	}
+
+	reservation := bucket.limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return limit, delay
+	}
+	return limit, 0
+}
+
+// WithRateLimits limits the builds tenants may submit
+func (h *Handler) WithRateLimits(policy RateLimitPolicy) *Handler {
+	h.limits.policy = policy
+	return h
+}
+
+// checkRateLimit takes a build token of the build's tenant, or reports the
+// build as rejected
+func (h *Handler) checkRateLimit(ctx context.Context, be types.BuildEvent) error {
+	limit, retryAfter := h.limits.take(ctx, be.ThirdPartyId, time.Now())
+	if retryAfter == 0 {
+		return nil
+	}
+
+	err := &RateLimitedError{ThirdPartyId: be.ThirdPartyId, Limit: limit, RetryAfter: retryAfter}
+	observability.BuildsRateLimited.Inc()
+	log.Printf("ERROR: Rejected build of %s/%s: %v", be.ThirdPartyId, be.ParserId, err)
+
+	rejected := lifecycleData(be)
+	rejected.Reason = RejectedRateLimited
+	rejected.Error = err.Error()
+	rejected.RetryAfter = err.RetryAfterSeconds()
+	h.emitLifecycle(ctx, EventTypeBuildRejected, rejected)
+	return err
+}
@@ -0,0 +1,59 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"knative-lambda-builder/internal/tenants"
+)
+
+// fixedLimits gives every tenant the same limit
+type fixedLimits struct{ limit tenants.RateLimit }
+
+func (f *fixedLimits) BuildRateLimit(context.Context, string) (tenants.RateLimit, error) {
+	return f.limit, nil
+}
+
+func TestRateLimiter(t *testing.T) {
+	policy := &fixedLimits{limit: tenants.RateLimit{Builds: 2, Per: time.Minute}}
+	limits := rateLimiter{policy: policy}
+	ctx, now := context.Background(), time.Now()
+
+	// A full bucket holds a period's worth of builds
+	for i := 0; i < 2; i++ {
+		if _, wait := limits.take(ctx, "acme", now); wait != 0 {
+			t.Fatalf("take(acme) #%d waits %s, want a token", i+1, wait)
+		}
+	}
+	if _, wait := limits.take(ctx, "acme", now); wait != 30*time.Second {
+		t.Errorf("take(acme) over the limit waits %s, want 30s", wait)
+	}
+	// Buckets are per tenant
+	if _, wait := limits.take(ctx, "globex", now); wait != 0 {
+		t.Errorf("take(globex) waits %s, want a token", wait)
+	}
+	// Refilled evenly over the period
+	if _, wait := limits.take(ctx, "acme", now.Add(30*time.Second)); wait != 0 {
+		t.Errorf("take(acme) 30s later waits %s, want a token", wait)
+	}
+
+	policy.limit = tenants.RateLimit{}
+	if _, wait := limits.take(ctx, "acme", now); wait != 0 {
+		t.Errorf("take(acme) unlimited waits %s, want a token", wait)
+	}
+}
+
+func TestParseRateLimit(t *testing.T) {
+	if got, err := tenants.ParseRateLimit("30/1h"); err != nil || got != (tenants.RateLimit{Builds: 30, Per: time.Hour}) {
+		t.Errorf("ParseRateLimit(30/1h) = %+v, %v", got, err)
+	}
+	if got, err := tenants.ParseRateLimit(""); err != nil || !got.Unlimited() {
+		t.Errorf("ParseRateLimit(\"\") = %+v, %v; want unlimited", got, err)
+	}
+	for _, invalid := range []string{"30", "0/1h", "30/h", "x/1h", "30/-1h"} {
+		if _, err := tenants.ParseRateLimit(invalid); err == nil {
+			t.Errorf("ParseRateLimit(%q) error = nil, want an error", invalid)
+		}
+	}
+}
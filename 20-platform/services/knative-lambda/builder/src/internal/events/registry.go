@@ -0,0 +1,115 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"knative-lambda-builder/internal/build"
+	"knative-lambda-builder/internal/tenants"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🗂️ BUILD REGISTRY
+// =============================================================================
+// Several builds run at once, so a Job update must be matched to the build
+// that created the Job, never to "the last build.start received". Builds are
+// tracked by job name; the tenant/parser labels on the Job are the fallback
+// for jobs the registry doesn't know (e.g. created before a builder restart),
+// with the build id from its labels and the image tag the job pushed (and the
+// role and region it pushed as and to) from its annotations.
+
+// buildMemory is how long a tracked job is remembered
+// 🎯 WHY: The apiserver source keeps sending updates of finished jobs until
+// their TTL (300s) deletes them
+const buildMemory = 6 * time.Hour
+
+// trackedBuild is a job and the build it belongs to
+type trackedBuild struct {
+	build types.BuildEvent
+	at    time.Time
+}
+
+// buildRegistry maps job names to the builds that created them
+type buildRegistry struct {
+	mu     sync.Mutex
+	byJob  map[string]trackedBuild // jobName -> build
+	latest map[string]string       // thirdPartyId/parserId -> most recent jobName
+
+	// restore completes builds rebuilt from a job's labels (nil = as rebuilt)
+	restore func(types.BuildEvent) types.BuildEvent
+}
+
+// track remembers which build a job belongs to
+func (r *buildRegistry) track(jobName string, be types.BuildEvent) {
+	if jobName == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for name, tracked := range r.byJob {
+		if now.Sub(tracked.at) > buildMemory {
+			delete(r.byJob, name)
+			if key := parserKey(tracked.build.ThirdPartyId, tracked.build.ParserId); r.latest[key] == name {
+				delete(r.latest, key)
+			}
+		}
+	}
+	if r.byJob == nil {
+		r.byJob = map[string]trackedBuild{}
+		r.latest = map[string]string{}
+	}
+	r.byJob[jobName] = trackedBuild{build: be, at: now}
+	r.latest[parserKey(be.ThirdPartyId, be.ParserId)] = jobName
+}
+
+// lookup returns the build a job belongs to
+// 📋 STEPS:
+//  1. The job name, as tracked when the job was created
+//  2. The job's tenant/parser labels (latest tracked build of that parser,
+//     unless the build id label names another build, or a build event
+//     rebuilt from the labels)
+//  3. The build event embedded in the resource event, if it carries one
+func (r *buildRegistry) lookup(resourceEvent *types.ResourceEventData) (types.BuildEvent, bool) {
+	be, ok, rebuilt := r.find(resourceEvent)
+	if rebuilt && r.restore != nil {
+		// 📝 NOTE: Outside the lock, restoring may call the API server
+		be = r.restore(be)
+	}
+	return be, ok
+}
+
+// find looks a job's build up; rebuilt tells it was rebuilt from the labels
+func (r *buildRegistry) find(resourceEvent *types.ResourceEventData) (be types.BuildEvent, ok, rebuilt bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if tracked, ok := r.byJob[resourceEvent.Name]; ok {
+		return tracked.build, true, false
+	}
+
+	labels := resourceEvent.Metadata.Labels
+	thirdPartyId, parserId, buildId := labels[tenants.LabelThirdPartyId], labels[tenants.LabelParserId], labels[build.LabelBuildId]
+	if thirdPartyId != "" && parserId != "" {
+		// 📝 NOTE: A build id label tells which build of the parser the job is
+		if jobName, ok := r.latest[parserKey(thirdPartyId, parserId)]; ok && (buildId == "" || r.byJob[jobName].build.ID == buildId) {
+			return r.byJob[jobName].build, true, false
+		}
+		annotations := resourceEvent.Metadata.Annotations
+		return types.BuildEvent{ID: buildId, ThirdPartyId: thirdPartyId, ParserId: parserId,
+			ImageTag: annotations[build.AnnotationImageTag], PushRoleArn: annotations[build.AnnotationPushRole],
+			Region: annotations[build.AnnotationRegion]}, true, true
+	}
+
+	if resourceEvent.BuildEvent.ThirdPartyId != "" && resourceEvent.BuildEvent.ParserId != "" {
+		return resourceEvent.BuildEvent, true, false
+	}
+	return types.BuildEvent{}, false, false
+}
+
+// parserKey identifies a parser in the registry
+func parserKey(thirdPartyId, parserId string) string {
+	return thirdPartyId + "/" + parserId
+}
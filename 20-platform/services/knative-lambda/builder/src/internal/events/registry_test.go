@@ -0,0 +1,44 @@
+package events
+
+import (
+	"testing"
+
+	"knative-lambda-builder/internal/build"
+	"knative-lambda-builder/internal/tenants"
+	"knative-lambda-builder/internal/types"
+)
+
+func TestBuildRegistry(t *testing.T) {
+	var builds buildRegistry
+	first := types.BuildEvent{ThirdPartyId: "acme", ParserId: "p1", ID: "b1"}
+	second := types.BuildEvent{ThirdPartyId: "globex", ParserId: "p2", ID: "b2"}
+	builds.track("kaniko-acme-p1", first)
+	builds.track("kaniko-globex-p2", second)
+
+	// The job that finishes first is matched to its own build, not the latest one
+	if got, ok := builds.lookup(&types.ResourceEventData{Name: "kaniko-acme-p1"}); !ok || got.ID != first.ID {
+		t.Errorf("lookup(kaniko-acme-p1) = %+v, %t; want %+v", got, ok, first)
+	}
+
+	// Unknown job: labels identify the parser
+	labelled := &types.ResourceEventData{Name: "kaniko-before-restart", Metadata: types.ResourceMetadata{
+		Labels: map[string]string{tenants.LabelThirdPartyId: "initech", tenants.LabelParserId: "p3"},
+	}}
+	labelled.Metadata.Annotations = map[string]string{build.AnnotationImageTag: "p3-v7"}
+	if got, ok := builds.lookup(labelled); !ok || got.ThirdPartyId != "initech" || got.ParserId != "p3" || got.ImageTag != "p3-v7" {
+		t.Errorf("lookup(labelled job) = %+v, %t; want initech/p3 at p3-v7", got, ok)
+	}
+	labelled.Metadata.Labels = map[string]string{tenants.LabelThirdPartyId: "globex", tenants.LabelParserId: "p2"}
+	if got, _ := builds.lookup(labelled); got.ID != second.ID {
+		t.Errorf("lookup(labelled job of a tracked parser) = %+v, want %+v", got, second)
+	}
+
+	labelled.Metadata.Labels[build.LabelBuildId] = "b0"
+	if got, _ := builds.lookup(labelled); got.ID != "b0" || got.ParserId != "p2" {
+		t.Errorf("lookup(labelled job of an earlier build) = %+v, want build b0 of globex/p2", got)
+	}
+
+	if got, ok := builds.lookup(&types.ResourceEventData{Name: "unrelated"}); ok {
+		t.Errorf("lookup(unrelated job) = %+v, want no match", got)
+	}
+}
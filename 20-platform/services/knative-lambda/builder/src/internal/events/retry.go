@@ -0,0 +1,88 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"knative-lambda-builder/internal/awserrors"
+	"knative-lambda-builder/internal/history"
+	"knative-lambda-builder/internal/observability"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🔁 RETRYING FAILED BUILDS
+// =============================================================================
+// A build whose Kaniko job failed is started again (new job, retry+1) after
+// an exponential backoff with jitter, up to BuildRetries, and reported as
+// build.retrying. Only then is it failed for good (build.failed)
+// 🎯 WHY: A registry throttle or a flaky pull shouldn't kill the build
+// 📝 NOTE: Builds whose job couldn't even be created are retried the same
+// way when the AWS error that stopped them is transient (see awserrors)
+// 📝 NOTE: Preempted builds are requeued instead and don't use up retries
+
+// EventTypeBuildRetrying is emitted when a failed build is retried
+const EventTypeBuildRetrying = "network.notifi.lambda.build.retrying"
+
+// retryFailedBuild retries a build whose job failed
+// 📝 NOTE: Returns false once the build is out of retries
+func (h *Handler) retryFailedBuild(ctx context.Context, be types.BuildEvent, jobName string, resourceEvent *types.ResourceEventData) bool {
+	if be.Retry >= h.buildOrchestrator.MaxBuildRetries() {
+		return false
+	}
+	if !h.requeues.claim(jobName) {
+		return true // Already retried on an earlier update of this job
+	}
+
+	failure := "build job " + jobName + " failed"
+	if reason, message := resourceEvent.JobFailure(); reason != "" {
+		failure = fmt.Sprintf("%s (%s: %s)", failure, reason, message)
+	}
+	h.scheduleRetry(ctx, be, jobName, failure, "the job failed")
+	return true
+}
+
+// retryStartFailure retries a build whose job couldn't be created because of
+// a transient AWS error: throttling, a server error, a dropped connection
+// 📝 NOTE: Returns false for any other error, and once the build is out of retries
+func (h *Handler) retryStartFailure(ctx context.Context, be types.BuildEvent, err error) bool {
+	if !awserrors.IsRetryable(err) || be.Retry >= h.buildOrchestrator.MaxBuildRetries() {
+		return false
+	}
+	h.scheduleRetry(ctx, be, "", "build job creation failed: "+err.Error(), "a transient AWS error")
+	return true
+}
+
+// scheduleRetry reports the build as retrying and starts it again after its
+// backoff; jobName is the failed job ("" if none was created)
+func (h *Handler) scheduleRetry(ctx context.Context, be types.BuildEvent, jobName, failure, cause string) {
+	be.Retry++
+	backoff := h.buildOrchestrator.RetryBackoff(be.Retry)
+	observability.BuildRetries.Inc()
+	log.Printf("🔁 %s, retrying ThirdPartyId=%s, ParserId=%s (retry %d of %d) in %s",
+		failure, be.ThirdPartyId, be.ParserId, be.Retry, h.buildOrchestrator.MaxBuildRetries(), backoff.Round(time.Second))
+	h.recordStatus(ctx, be, history.StatusBuilding, fmt.Sprintf("retrying after %s (retry %d)", cause, be.Retry))
+
+	retrying := lifecycleData(be)
+	retrying.JobName = jobName
+	retrying.Error = failure
+	retrying.RetryIn = int(math.Ceil(backoff.Seconds()))
+	h.emitLifecycle(ctx, EventTypeBuildRetrying, retrying)
+
+	ctx = backgroundContext(ctx)
+	time.AfterFunc(backoff, func() {
+		ctx, span := observability.Tracer().Start(ctx, "build.retry", trace.WithAttributes(
+			attribute.Int("build.retry", be.Retry),
+		))
+		defer span.End()
+
+		// startBuild tracks the new job, so its updates carry the new retry count
+		h.startBuild(ctx, be)
+	})
+}
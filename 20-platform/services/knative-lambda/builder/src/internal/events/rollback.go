@@ -0,0 +1,109 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"knative-lambda-builder/internal/build"
+	"knative-lambda-builder/internal/observability"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// ⏪ PARSER ROLLBACK
+// =============================================================================
+// lambda.rollback (or POST /v1/parsers/{thirdPartyId}/{parserId}/rollback)
+// redeploys a parser with one of its recorded image revisions, without
+// building anything. The revision must have been deployed before and the
+// registry must still serve it; then rollback.completed is emitted.
+// 📝 NOTE: Runs inline like teardown: on error the event is nacked and the
+// broker retries it, unless the revision can't be deployed at all
+
+// EventTypeRollbackCompleted is emitted once a parser runs the requested revision
+const EventTypeRollbackCompleted = "network.notifi.lambda.rollback.completed"
+
+// handleRollback processes rollback events
+func (h *Handler) handleRollback(ctx context.Context, event cloudevents.Event) error {
+	var rollback types.RollbackEvent
+	if err := event.DataAs(&rollback); err != nil {
+		log.Printf("ERROR: Failed to parse rollback event: %v", err)
+		return fmt.Errorf("failed to parse rollback event: %w", err)
+	}
+	if err := authorize(ctx, event, rollback.ThirdPartyId); err != nil {
+		return err
+	}
+	if rollback.ID == "" {
+		rollback.ID = event.ID()
+	}
+
+	_, err := h.Rollback(ctx, rollback)
+	var unavailable *build.RevisionUnavailableError
+	if errors.As(err, &unavailable) {
+		return nil // Retrying won't fix it
+	}
+	return err
+}
+
+// Rollback redeploys a parser with an earlier image revision and emits
+// rollback.completed
+// 📝 NOTE: Fails with a *build.RevisionUnavailableError when the revision
+// isn't recorded, was never deployed or is gone from the registry
+func (h *Handler) Rollback(ctx context.Context, rollback types.RollbackEvent) (types.RollbackCompletedEventData, error) {
+	ctx, span := observability.Tracer().Start(ctx, "services.rollback-parser-service")
+	defer span.End()
+	span.SetAttributes(attribute.String("rollback.image_tag", rollback.ImageTag))
+
+	be := types.BuildEvent{
+		ThirdPartyId: rollback.ThirdPartyId,
+		ParserId:     rollback.ParserId,
+		ID:           rollback.ID,
+		ImageTag:     rollback.ImageTag,
+		Rollback:     true,
+	}
+	completed := types.RollbackCompletedEventData{
+		ThirdPartyId: be.ThirdPartyId,
+		ParserId:     be.ParserId,
+		RollbackId:   be.ID,
+		ImageTag:     be.ImageTag,
+		Image:        h.buildOrchestrator.ImageURI(be),
+	}
+	log.Printf("Rolling back parser %s/%s to %s", be.ThirdPartyId, be.ParserId, completed.Image)
+
+	digest, err := h.buildOrchestrator.CheckRevision(ctx, be)
+	if err != nil {
+		log.Printf("ERROR: Rollback of %s/%s refused: %v", be.ThirdPartyId, be.ParserId, err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return completed, err
+	}
+	completed.ImageDigest = digest
+	// 🔧 The parser gets back the environment it ran with
+	if be.Env, err = h.buildOrchestrator.RevisionEnv(ctx, be); err != nil {
+		log.Printf("WARNING: Rolling back %s/%s without its environment: %v", be.ThirdPartyId, be.ParserId, err)
+	}
+
+	mode, err := h.parserService.CreateParserService(ctx, be)
+	completed.DeployMode = mode
+	if err != nil {
+		log.Printf("ERROR: Rollback of %s/%s failed: %v", be.ThirdPartyId, be.ParserId, err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		h.emitTriggerFailed(ctx, be, err)
+		return completed, fmt.Errorf("failed to deploy %s: %w", completed.Image, err)
+	}
+	if err := h.buildOrchestrator.RecordDeploy(ctx, be); err != nil {
+		log.Printf("WARNING: Failed to record the deploy of %s: %v", completed.Image, err)
+	}
+
+	log.Printf("⏪ Parser %s/%s rolled back to %s", be.ThirdPartyId, be.ParserId, completed.Image)
+	if err := h.emitter.Emit(ctx, EventTypeRollbackCompleted, be.ThirdPartyId+"/"+be.ParserId, completed); err != nil {
+		log.Printf("ERROR: Failed to emit %s: %v", EventTypeRollbackCompleted, err)
+	}
+	return completed, nil
+}
@@ -0,0 +1,51 @@
+package events
+
+import (
+	"context"
+	"log"
+
+	"knative-lambda-builder/internal/history"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 📦 SBOM STAGE
+// =============================================================================
+// Next to the parser tests, once the image is pushed: inventory it. The SBOM
+// job runs on its own and never holds up (or fails) the deploy; its location
+// is recorded on the build once the job uploaded it.
+
+// generateSBOM launches the SBOM job of a freshly pushed image
+func (h *Handler) generateSBOM(ctx context.Context, be types.BuildEvent) {
+	jobName, err := h.buildOrchestrator.RunSBOM(ctx, be)
+	if err != nil {
+		log.Printf("WARNING: Failed to start the SBOM job for %s/%s: %v", be.ThirdPartyId, be.ParserId, err)
+		return
+	}
+	h.builds.track(jobName, be)
+}
+
+// handleSBOMJobUpdate records where an SBOM job uploaded the SBOMs
+func (h *Handler) handleSBOMJobUpdate(ctx context.Context, resourceEvent *types.ResourceEventData) {
+	complete := resourceEvent.IsJobComplete()
+	if !complete && !resourceEvent.IsJobFailed() {
+		return // Still running
+	}
+
+	buildEvent, ok := h.builds.lookup(resourceEvent)
+	if !ok {
+		log.Printf("WARNING: SBOM job %s finished but matches no build, ignoring it", resourceEvent.Name)
+		return
+	}
+	if !complete {
+		log.Printf("WARNING: SBOM job %s failed, %s/%s has no SBOM", resourceEvent.Name, buildEvent.ThirdPartyId, buildEvent.ParserId)
+		return
+	}
+
+	location := h.buildOrchestrator.SBOMLocation(buildEvent)
+	log.Printf("📦 SBOMs of %s/%s uploaded to %s", buildEvent.ThirdPartyId, buildEvent.ParserId, location)
+	h.updateBuild(ctx, buildEvent, func(entry *history.Entry) { entry.SBOM = location })
+	if err := h.buildOrchestrator.RecordSBOM(ctx, buildEvent); err != nil {
+		log.Printf("WARNING: Failed to record the SBOM of %s: %v", h.buildOrchestrator.ImageURI(buildEvent), err)
+	}
+}
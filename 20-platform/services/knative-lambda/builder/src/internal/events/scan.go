@@ -0,0 +1,50 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"knative-lambda-builder/internal/build"
+	"knative-lambda-builder/internal/observability"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🛡️ VULNERABILITY SCAN GATE
+// =============================================================================
+// Before a parser is deployed, its image's ECR scan must have at most
+// SCAN_MAX_CRITICAL critical findings (see build.CheckScan). A blocked build
+// gets build.blocked with the findings, then fails at the scan stage.
+
+// EventTypeBuildBlocked is emitted when an image's scan blocks its deploy
+const EventTypeBuildBlocked = "network.notifi.lambda.build.blocked"
+
+// checkScan waits for the scan of a build's image; false when it blocks the
+// deploy (the build is then failed)
+func (h *Handler) checkScan(ctx context.Context, be types.BuildEvent) bool {
+	scan, err := h.buildOrchestrator.CheckScan(ctx, be)
+	if err == nil {
+		if scan != nil {
+			observability.ScanVerdicts.WithLabelValues("passed").Inc()
+		}
+		return true
+	}
+
+	var blocked *build.ScanBlockedError
+	if !errors.As(err, &blocked) {
+		// 📝 NOTE: CheckScan only fails with a *ScanBlockedError today
+		blocked = &build.ScanBlockedError{Image: h.buildOrchestrator.ImageURI(be), Reason: err.Error()}
+	}
+	observability.ScanVerdicts.WithLabelValues("blocked").Inc()
+	log.Printf("ERROR: %v", blocked)
+
+	data := lifecycleData(be)
+	data.Image = blocked.Image
+	data.Error = blocked.Reason
+	data.ScanStatus = blocked.Scan.Status
+	data.Findings = blocked.Scan.Findings
+	h.emitLifecycle(ctx, EventTypeBuildBlocked, data)
+	h.failBuild(ctx, be, StageScan, "", blocked.Error())
+	return false
+}
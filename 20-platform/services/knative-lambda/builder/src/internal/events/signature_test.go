@@ -0,0 +1,49 @@
+package events
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+func TestSignatureVerifier(t *testing.T) {
+	if _, err := NewSignatureVerifier([]byte("short")); err == nil {
+		t.Error("NewSignatureVerifier(short secret) error = nil, want an error")
+	}
+	verifier, err := NewSignatureVerifier([]byte(strings.Repeat("k", 32)))
+	if err != nil {
+		t.Fatalf("NewSignatureVerifier() error = %v", err)
+	}
+
+	newEvent := func() cloudevents.Event {
+		event := cloudevents.NewEvent()
+		event.SetID("b1")
+		event.SetType(EventTypeBuildStart)
+		event.SetSource("network.notifi.parsers.acme.p1")
+		if err := event.SetData(cloudevents.ApplicationJSON, []byte(`{"thirdPartyId":"acme","parserId":"p1"}`)); err != nil {
+			t.Fatalf("SetData() error = %v", err)
+		}
+		return event
+	}
+
+	signed := newEvent()
+	signed.SetExtension(SignatureExtension, verifier.Sign(signed))
+	if err := verifier.Verify(signed); err != nil {
+		t.Errorf("Verify(signed) error = %v, want nil", err)
+	}
+
+	if err := verifier.Verify(newEvent()); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("Verify(unsigned) error = %v, want %v", err, ErrUnsigned)
+	}
+
+	// The signature covers the payload
+	tampered := signed.Clone()
+	if err := tampered.SetData(cloudevents.ApplicationJSON, []byte(`{"thirdPartyId":"globex","parserId":"p1"}`)); err != nil {
+		t.Fatalf("SetData() error = %v", err)
+	}
+	if err := verifier.Verify(tampered); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify(tampered) error = %v, want %v", err, ErrInvalidSignature)
+	}
+}
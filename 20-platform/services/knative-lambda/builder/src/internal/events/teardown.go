@@ -0,0 +1,61 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.opentelemetry.io/otel/attribute"
+
+	"knative-lambda-builder/internal/observability"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🗑️ PARSER TEARDOWN
+// =============================================================================
+// lambda.teardown removes a parser's trigger and service, and its image tag
+// when asked to. It runs inline (deletes are quick): on error the event is
+// nacked and the broker retries it.
+
+// handleTeardown processes teardown events
+func (h *Handler) handleTeardown(ctx context.Context, event cloudevents.Event) error {
+	var teardown types.TeardownEvent
+	if err := event.DataAs(&teardown); err != nil {
+		log.Printf("ERROR: Failed to parse teardown event: %v", err)
+		return fmt.Errorf("failed to parse teardown event: %w", err)
+	}
+	if teardown.ThirdPartyId == "" || teardown.ParserId == "" {
+		log.Printf("ERROR: Teardown event %s lacks thirdPartyId or parserId", event.ID())
+		return nil // Retrying won't fix it
+	}
+	if err := authorize(ctx, event, teardown.ThirdPartyId); err != nil {
+		return err
+	}
+
+	ctx, span := observability.Tracer().Start(ctx, "services.remove-parser-service")
+	defer span.End()
+	span.SetAttributes(attribute.Bool("teardown.delete_image", teardown.DeleteImage))
+
+	be := types.BuildEvent{ThirdPartyId: teardown.ThirdPartyId, ParserId: teardown.ParserId, ID: teardown.ID}
+	log.Printf("Tearing down parser %s/%s (deleteImage=%t)", be.ThirdPartyId, be.ParserId, teardown.DeleteImage)
+
+	var errs []error
+	if err := h.parserService.RemoveParserService(ctx, be); err != nil {
+		errs = append(errs, fmt.Errorf("failed to remove parser %s/%s: %w", be.ThirdPartyId, be.ParserId, err))
+	}
+	if teardown.DeleteImage {
+		if err := h.buildOrchestrator.DeleteImage(ctx, be); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete the images of %s/%s: %w", be.ThirdPartyId, be.ParserId, err))
+		} else {
+			log.Printf("🗑️ Deleted the images of %s/%s", be.ThirdPartyId, be.ParserId)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		log.Printf("ERROR: %v", err)
+		return err
+	}
+	return nil
+}
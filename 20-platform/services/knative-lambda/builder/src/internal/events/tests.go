@@ -0,0 +1,82 @@
+package events
+
+import (
+	"context"
+	"log"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"knative-lambda-builder/internal/history"
+	"knative-lambda-builder/internal/observability"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🧪 PARSER TEST STAGE
+// =============================================================================
+// Between "image pushed" and "parser deployed": run the tenant's tests in the
+// built image and only deploy when they pass. The test output is attached to
+// the build record either way.
+
+// testParser launches the parser's tests, or deploys right away if it has none
+func (h *Handler) testParser(ctx context.Context, be types.BuildEvent) {
+	ctx, span := observability.Tracer().Start(ctx, "build.run-parser-tests")
+	defer span.End()
+
+	jobName, err := h.buildOrchestrator.RunParserTests(ctx, be)
+	if err != nil {
+		log.Printf("ERROR: Failed to start parser tests for %s/%s: %v", be.ThirdPartyId, be.ParserId, err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		h.failBuild(ctx, be, StageTest, "", err.Error())
+		return
+	}
+	if jobName == "" {
+		span.SetAttributes(attribute.Bool("build.tests", false))
+		h.deployParser(ctx, be)
+		return
+	}
+
+	h.builds.track(jobName, be)
+	span.SetAttributes(attribute.String("build.test_job", jobName))
+	h.recordStatus(ctx, be, history.StatusTesting, "running parser tests in job "+jobName)
+	h.recordPhase(ctx, be, history.PhaseTesting)
+}
+
+// handleTestJobUpdate deploys the parser once its test job passed
+func (h *Handler) handleTestJobUpdate(ctx context.Context, resourceEvent *types.ResourceEventData) {
+	passed := resourceEvent.IsJobComplete()
+	if !passed && !resourceEvent.IsJobFailed() {
+		return // Still running
+	}
+
+	buildEvent, ok := h.builds.lookup(resourceEvent)
+	if !ok {
+		log.Printf("WARNING: Test job %s finished but matches no build, ignoring it", resourceEvent.Name)
+		return
+	}
+	log.Printf("Test job %s finished (passed=%t) for ThirdPartyId=%s, ParserId=%s",
+		resourceEvent.Name, passed, buildEvent.ThirdPartyId, buildEvent.ParserId)
+
+	// 🏃‍♂️ Fetch the report and deploy in background (don't block event handler)
+	go func(be types.BuildEvent, jobName string) {
+		ctx := backgroundContext(ctx)
+
+		report, err := h.buildOrchestrator.TestReport(ctx, jobName)
+		if err != nil {
+			log.Printf("WARNING: Failed to get the report of test job %s: %v", jobName, err)
+		}
+		if report != "" {
+			if err := h.history.AttachReport(ctx, be.ThirdPartyId, be.ParserId, report); err != nil {
+				log.Printf("ERROR: Failed to attach test report for %s/%s: %v", be.ThirdPartyId, be.ParserId, err)
+			}
+		}
+
+		if !passed {
+			h.failBuild(ctx, be, StageTest, jobName, "parser tests failed in job "+jobName)
+			return
+		}
+		h.deployParser(ctx, be)
+	}(buildEvent, resourceEvent.Name)
+}
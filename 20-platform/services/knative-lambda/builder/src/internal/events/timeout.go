@@ -0,0 +1,121 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"knative-lambda-builder/internal/observability"
+	"knative-lambda-builder/internal/tenants"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// ⏱️ TIMED OUT BUILDS
+// =============================================================================
+// A build job past its activeDeadlineSeconds is failed by Kubernetes
+// (reason DeadlineExceeded); the reaper catches jobs still unfinished well
+// past it. Either way the build gets build.timeout, then build.failed:
+//
+//	build.started -> build.timeout -> build.failed (stage build)
+//
+// 📝 NOTE: Timed out builds aren't retried; a build that hangs once will
+// likely hang again
+
+// EventTypeBuildTimeout is emitted when a build ran out of time
+const EventTypeBuildTimeout = "network.notifi.lambda.build.timeout"
+
+// build.timeout reasons
+const (
+	TimeoutDeadlineExceeded = "deadline_exceeded" // Failed by Kubernetes (activeDeadlineSeconds)
+	TimeoutStale            = "stale"             // Reaped by the builder
+)
+
+// handleTimedOutBuild fails a build whose job exceeded its deadline
+// 📝 NOTE: Returns false when the job failed for another reason
+func (h *Handler) handleTimedOutBuild(ctx context.Context, be types.BuildEvent, jobName string, resourceEvent *types.ResourceEventData) bool {
+	if reason, _ := resourceEvent.JobFailure(); reason != "DeadlineExceeded" {
+		return false
+	}
+	if !h.requeues.claim(jobName) {
+		return true // Already handled on an earlier update of this job
+	}
+	h.timeOutBuild(ctx, be, jobName, TimeoutDeadlineExceeded,
+		fmt.Sprintf("build job %s exceeded its deadline of %s", jobName, h.buildOrchestrator.BuildTimeout()))
+	return true
+}
+
+// StartReaper reaps stale build jobs every interval until ctx is done
+func (h *Handler) StartReaper(ctx context.Context, interval time.Duration) {
+	if interval <= 0 || h.buildOrchestrator.BuildTimeout() <= 0 {
+		log.Printf("Build reaper disabled (interval %s, timeout %s)", interval, h.buildOrchestrator.BuildTimeout())
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.reapStaleBuilds(ctx)
+		}
+	}
+}
+
+// reapStaleBuilds deletes the build jobs stuck past the timeout and fails
+// their builds
+// 📝 NOTE: Every replica reaps; only the one whose delete succeeds reports
+func (h *Handler) reapStaleBuilds(ctx context.Context) {
+	stale, err := h.buildOrchestrator.StaleBuilds(ctx, time.Now())
+	if err != nil {
+		log.Printf("ERROR: Failed to look for stale build jobs: %v", err)
+		return
+	}
+
+	for _, job := range stale {
+		err := h.buildOrchestrator.DeleteBuildJob(ctx, job.Name)
+		if apierrors.IsNotFound(err) {
+			continue // Reaped by another replica
+		}
+		if err != nil {
+			log.Printf("ERROR: Failed to delete stale build job %s: %v", job.Name, err)
+			continue
+		}
+		if !h.requeues.claim(job.Name) {
+			continue
+		}
+
+		be, ok := h.builds.lookup(&types.ResourceEventData{
+			Kind: "Job",
+			Name: job.Name,
+			Metadata: types.ResourceMetadata{Labels: map[string]string{
+				tenants.LabelThirdPartyId: job.ThirdPartyId,
+				tenants.LabelParserId:     job.ParserId,
+			}},
+		})
+		if !ok {
+			log.Printf("WARNING: Reaped stale job %s, which matches no build", job.Name)
+			continue
+		}
+		h.timeOutBuild(ctx, be, job.Name, TimeoutStale,
+			fmt.Sprintf("build job %s still unfinished after %s, deleted it", job.Name, time.Since(job.CreatedAt).Round(time.Minute)))
+	}
+}
+
+// timeOutBuild reports a build that ran out of time and fails it
+func (h *Handler) timeOutBuild(ctx context.Context, be types.BuildEvent, jobName, reason, message string) {
+	observability.BuildTimeouts.WithLabelValues(reason).Inc()
+	log.Printf("ERROR: ⏱️ Build of ThirdPartyId=%s, ParserId=%s timed out: %s", be.ThirdPartyId, be.ParserId, message)
+
+	timeout := lifecycleData(be)
+	timeout.JobName = jobName
+	timeout.Reason = reason
+	timeout.Error = message
+	h.emitLifecycle(ctx, EventTypeBuildTimeout, timeout)
+	h.failBuild(ctx, be, StageBuild, jobName, message)
+}
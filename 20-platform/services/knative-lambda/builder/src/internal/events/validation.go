@@ -0,0 +1,104 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+
+	"knative-lambda-builder/contracts"
+	"knative-lambda-builder/internal/observability"
+)
+
+// =============================================================================
+// 📜 PAYLOAD VALIDATION
+// =============================================================================
+// Consumed events are checked against their contract before they're handled
+// 🎯 WHY: A typo'd field used to decode as an empty ThirdPartyId and end up in
+// a broken ECR path; now the producer gets a 400 listing what's wrong
+
+// RejectedSchema is the rejection reason (label of
+// knative_lambda_builder_events_rejected_total) of payloads violating their contract
+const RejectedSchema = "schema"
+
+// Rejection is the body of the response to an event the builder refuses
+type Rejection struct {
+	Error      string   `json:"error"`
+	EventType  string   `json:"type"`
+	EventID    string   `json:"id"`
+	Schema     string   `json:"schema,omitempty"`     // Contract the payload was checked against
+	Violations []string `json:"violations,omitempty"` // "<location>: <problem>"
+	RetryAfter int      `json:"retryAfterSeconds,omitempty"`
+}
+
+// result turns the rejection into the HTTP response of the receiver
+// 📝 NOTE: Broker transports don't retry 4xx results
+func (r Rejection) result(status int) protocol.Result {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return cehttp.NewResult(status, "%s", r.Error)
+	}
+	return cehttp.NewResult(status, "%s", body)
+}
+
+// validate checks a consumed event's payload against its contract
+// 📝 NOTE: The version is the one named by the dataschema attribute, else the latest
+func validate(event cloudevents.Event) error {
+	contract, ok := contracts.ForEvent(event.Type(), event.DataSchema())
+	if !ok || contract.Direction != contracts.Consumed {
+		return nil
+	}
+
+	err := contract.Validate(event.Data())
+	if err == nil {
+		return nil
+	}
+
+	observability.EventsRejected.WithLabelValues(observability.EventTypeLabel(event.Type()), RejectedSchema).Inc()
+	log.Printf("ERROR: Rejected %s event %s: %v", event.Type(), event.ID(), err)
+	return Rejection{
+		Error:      "payload violates its contract",
+		EventType:  event.Type(),
+		EventID:    event.ID(),
+		Schema:     contract.SchemaURI(),
+		Violations: contracts.Violations(err),
+	}.result(http.StatusBadRequest)
+}
+
+// refused turns a build the handler didn't accept into the event's response
+// 📝 NOTE: Rate limited builds get a 429 and builds refused by a full queue a
+// 503, which queue transports retry; an unusable callbackUrl, buildArgs or
+// env gets a 400
+func refused(event cloudevents.Event, err error) error {
+	var invalid interface{ InvalidRequest() bool }
+	if errors.As(err, &invalid) {
+		return Rejection{
+			Error:     err.Error(),
+			EventType: event.Type(),
+			EventID:   event.ID(),
+		}.result(http.StatusBadRequest)
+	}
+	var full *QueueFullError
+	if errors.As(err, &full) {
+		return Rejection{
+			Error:      full.Error(),
+			EventType:  event.Type(),
+			EventID:    event.ID(),
+			RetryAfter: full.RetryAfterSeconds(),
+		}.result(http.StatusServiceUnavailable)
+	}
+	var limited *RateLimitedError
+	if !errors.As(err, &limited) {
+		return err
+	}
+	return Rejection{
+		Error:      limited.Error(),
+		EventType:  event.Type(),
+		EventID:    event.ID(),
+		RetryAfter: limited.RetryAfterSeconds(),
+	}.result(http.StatusTooManyRequests)
+}
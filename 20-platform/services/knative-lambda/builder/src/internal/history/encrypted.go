@@ -0,0 +1,134 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// =============================================================================
+// 🔐 ENCRYPTED BUILD HISTORY
+// =============================================================================
+// Wraps a Store so the free-form fields of a tenant's builds (failure messages,
+// test reports) are sealed with the tenant's KMS key before they are stored.
+// Statuses and timestamps stay readable: badges and the reconciler need them.
+
+// sealedReportBytes caps test reports before sealing
+// 🎯 WHY: Sealing grows a value by about a third; the sealed report must still
+// fit in MaxReportBytes or truncation would corrupt it
+const sealedReportBytes = MaxReportBytes / 2
+
+// Sealer encrypts values per tenant (implemented by encryption.TenantKeys)
+// 📝 NOTE: Seal returns the plaintext for tenants without a key, Open returns
+// values that aren't sealed as is
+type Sealer interface {
+	Seal(ctx context.Context, thirdPartyId string, plaintext []byte) ([]byte, error)
+	Open(ctx context.Context, data []byte) ([]byte, error)
+}
+
+// EncryptedStore seals messages and test reports of the wrapped store
+type EncryptedStore struct {
+	Store
+	sealer Sealer
+}
+
+// NewEncryptedStore wraps a store with per-tenant encryption
+func NewEncryptedStore(inner Store, sealer Sealer) *EncryptedStore {
+	return &EncryptedStore{Store: inner, sealer: sealer}
+}
+
+// Record implements Store, sealing the message
+func (s *EncryptedStore) Record(ctx context.Context, thirdPartyId, parserId, status, message string) error {
+	sealed, err := s.seal(ctx, thirdPartyId, message)
+	if err != nil {
+		return err
+	}
+	return s.Store.Record(ctx, thirdPartyId, parserId, status, sealed)
+}
+
+// AttachReport implements Store, sealing the report
+func (s *EncryptedStore) AttachReport(ctx context.Context, thirdPartyId, parserId, report string) error {
+	sealed, err := s.seal(ctx, thirdPartyId, truncateReport(report, sealedReportBytes))
+	if err != nil {
+		return err
+	}
+	return s.Store.AttachReport(ctx, thirdPartyId, parserId, sealed)
+}
+
+// List implements Store, opening sealed fields
+// 📝 NOTE: A field that can't be opened (key disabled, no access) is masked
+// instead of failing, so statuses stay available
+func (s *EncryptedStore) List(ctx context.Context, thirdPartyId, parserId string) ([]Entry, error) {
+	entries, err := s.Store.List(ctx, thirdPartyId, parserId)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		entries[i].Message = s.open(ctx, entries[i].Message)
+		entries[i].TestReport = s.open(ctx, entries[i].TestReport)
+	}
+	return entries, nil
+}
+
+// Reencrypt seals every stored field of a tenant again with its current key
+// 🎯 PURPOSE: Key rotation; also encrypts history recorded before the tenant had a key
+// Returns the number of fields rewritten
+func (s *EncryptedStore) Reencrypt(ctx context.Context, thirdPartyId string) (int, error) {
+	parsers, err := s.Store.Parsers(ctx, thirdPartyId)
+	if err != nil {
+		return 0, err
+	}
+
+	rewritten := 0
+	for _, parserId := range parsers {
+		err := s.Store.Rewrite(ctx, thirdPartyId, parserId, func(entries []Entry) ([]Entry, error) {
+			count := 0 // Reset on conflict retries
+			for i := range entries {
+				for _, field := range []*string{&entries[i].Message, &entries[i].TestReport} {
+					if *field == "" {
+						continue
+					}
+					plaintext, err := s.sealer.Open(ctx, []byte(*field))
+					if err != nil {
+						return nil, fmt.Errorf("failed to open history of %s/%s: %w", thirdPartyId, parserId, err)
+					}
+					sealed, err := s.seal(ctx, thirdPartyId, string(plaintext))
+					if err != nil {
+						return nil, err
+					}
+					*field = sealed
+					count++
+				}
+			}
+			rewritten += count
+			return entries, nil
+		})
+		if err != nil {
+			return rewritten, err
+		}
+	}
+	return rewritten, nil
+}
+
+func (s *EncryptedStore) seal(ctx context.Context, thirdPartyId, value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	sealed, err := s.sealer.Seal(ctx, thirdPartyId, []byte(value))
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt build record of %s: %w", thirdPartyId, err)
+	}
+	return string(sealed), nil
+}
+
+func (s *EncryptedStore) open(ctx context.Context, value string) string {
+	if value == "" {
+		return ""
+	}
+	plaintext, err := s.sealer.Open(ctx, []byte(value))
+	if err != nil {
+		log.Printf("WARNING: Failed to decrypt build record: %v", err)
+		return "[encrypted]"
+	}
+	return string(plaintext)
+}
@@ -0,0 +1,613 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// =============================================================================
+// 📜 BUILD HISTORY
+// =============================================================================
+// This package records the outcome of every build per parser
+// 🎯 PURPOSE: Answer "what is the state of parser X?" (badges, APIs, dashboards)
+
+// Build statuses
+const (
+	StatusBuilding = "building"
+	StatusTesting  = "testing" // Image built, parser tests running
+	StatusPassing  = "passing"
+	StatusFailing  = "failing"
+)
+
+// Build phases: where a build is in the pipeline, finer than its status
+// 📋 queued -> building -> pushing -> [testing] -> deploying -> ready | failed
+const (
+	PhaseQueued    = "queued"    // Accepted, waiting for a build slot
+	PhaseBuilding  = "building"  // Kaniko job running
+	PhasePushing   = "pushing"   // Job done, image being pushed and recorded
+	PhaseTesting   = "testing"   // Parser tests running in the image
+	PhaseDeploying = "deploying" // Creating the parser's service and trigger
+	PhaseReady     = "ready"     // Deployed (status passing)
+	PhaseFailed    = "failed"    // Status failing
+)
+
+// MaxEntries is how many builds are kept per parser (newest first)
+const MaxEntries = 10
+
+// MaxReportBytes caps the test report kept per build (its tail is kept)
+// 🎯 WHY: All parsers share one ConfigMap, limited to 1MiB
+const MaxReportBytes = 4 << 10
+
+// Entry is a single build of a parser
+type Entry struct {
+	ThirdPartyId string    `json:"thirdPartyId"`
+	ParserId     string    `json:"parserId"`
+	BuildId      string    `json:"buildId,omitempty"` // id of the build.start (or API request)
+	Status       string    `json:"status"`
+	Phase        string    `json:"phase,omitempty"`      // Set by the builder as the build progresses
+	Message      string    `json:"message,omitempty"`    // Failure reason
+	TestReport   string    `json:"testReport,omitempty"` // Output of the parser's tests
+	SBOM         string    `json:"sbom,omitempty"`       // s3:// prefix of the image's SBOMs
+	DeployMode   string    `json:"deployMode,omitempty"` // "knative", or "fallback" without Knative Serving
+	JobName      string    `json:"jobName,omitempty"`    // Kaniko job (absent for cached builds)
+	Image        string    `json:"image,omitempty"`
+	Commit       string    `json:"commit,omitempty"` // Git commit built (builds from git)
+	StartedAt    time.Time `json:"startedAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// CurrentPhase returns the build's phase, derived from its status once it
+// finished (and for entries recorded before phases existed)
+func (e Entry) CurrentPhase() string {
+	switch {
+	case e.Status == StatusPassing:
+		return PhaseReady
+	case e.Status == StatusFailing:
+		return PhaseFailed
+	case e.Phase != "":
+		return e.Phase
+	}
+	return e.Status
+}
+
+// Store persists build history
+type Store interface {
+	// Record starts a new entry (StatusBuilding) or updates the latest one
+	Record(ctx context.Context, thirdPartyId, parserId, status, message string) error
+	// AttachReport stores a test report on the latest entry
+	AttachReport(ctx context.Context, thirdPartyId, parserId, report string) error
+	// List returns a parser's builds, newest first (empty if it never built)
+	List(ctx context.Context, thirdPartyId, parserId string) ([]Entry, error)
+	// Parsers returns the parserIds of a tenant that have a history
+	Parsers(ctx context.Context, thirdPartyId string) ([]string, error)
+	// Tenants returns the thirdPartyIds that have a history
+	Tenants(ctx context.Context) ([]string, error)
+	// Rewrite replaces a parser's entries with change(entries) (maintenance, e.g. re-encryption)
+	Rewrite(ctx context.Context, thirdPartyId, parserId string, change func([]Entry) ([]Entry, error)) error
+}
+
+// Latest returns a parser's most recent build, or nil if it never built
+func Latest(ctx context.Context, store Store, thirdPartyId, parserId string) (*Entry, error) {
+	entries, err := store.List(ctx, thirdPartyId, parserId)
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+// UpdateLatest changes the latest build of a parser (no-op if it never built)
+func UpdateLatest(ctx context.Context, store Store, thirdPartyId, parserId string, change func(*Entry)) error {
+	return store.Rewrite(ctx, thirdPartyId, parserId, func(entries []Entry) ([]Entry, error) {
+		if len(entries) > 0 {
+			change(&entries[0])
+		}
+		return entries, nil
+	})
+}
+
+// Builds returns every recorded build of a tenant, newest first
+func Builds(ctx context.Context, store Store, thirdPartyId string) ([]Entry, error) {
+	parsers, err := store.Parsers(ctx, thirdPartyId)
+	if err != nil {
+		return nil, err
+	}
+	var builds []Entry
+	for _, parserId := range parsers {
+		entries, err := store.List(ctx, thirdPartyId, parserId)
+		if err != nil {
+			return nil, err
+		}
+		builds = append(builds, entries...)
+	}
+	sort.SliceStable(builds, func(i, j int) bool { return builds[i].StartedAt.After(builds[j].StartedAt) })
+	return builds, nil
+}
+
+// BuildFinder is a Store that looks build ids up in an index instead of
+// scanning every parser's builds (ConfigMapStore, MemoryStore)
+// 🎯 WHY: Build status polls (GET /v1/builds/{id}, WatchBuild) find a build
+// every few seconds
+type BuildFinder interface {
+	// FindBuild returns the most recent entry of a build id, or nil if none has it
+	FindBuild(ctx context.Context, buildId string) (*Entry, error)
+}
+
+// FindBuild returns the most recent entry of a build id, or nil if none has it
+// 📝 NOTE: Stores without an index (see BuildFinder) are scanned whole;
+// requeued builds have one entry per attempt
+func FindBuild(ctx context.Context, store Store, buildId string) (*Entry, error) {
+	if finder, ok := store.(BuildFinder); ok {
+		return finder.FindBuild(ctx, buildId)
+	}
+	tenants, err := store.Tenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var found *Entry
+	for _, thirdPartyId := range tenants {
+		builds, err := Builds(ctx, store, thirdPartyId)
+		if err != nil {
+			return nil, err
+		}
+		for i := range builds {
+			if builds[i].BuildId == buildId && (found == nil || builds[i].StartedAt.After(found.StartedAt)) {
+				found = &builds[i]
+				break
+			}
+		}
+	}
+	return found, nil
+}
+
+// LoadBuild finds a build id in index and returns its entry as records has it
+// 🎯 WHY: The plain store can be scanned without KMS calls; only the build
+// found is read through the (decrypting) records store
+func LoadBuild(ctx context.Context, index, records Store, buildId string) (*Entry, error) {
+	found, err := FindBuild(ctx, index, buildId)
+	if err != nil || found == nil {
+		return nil, err
+	}
+	entries, err := records.List(ctx, found.ThirdPartyId, found.ParserId)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		if entries[i].BuildId == buildId && entries[i].StartedAt.Equal(found.StartedAt) {
+			return &entries[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// buildIndex maps build ids to the data keys of the parsers that have builds
+// with them (usually one)
+type buildIndex map[string][]string
+
+// indexKey is the data key of the build index
+// 📝 NOTE: Without a ".", it is never taken for a parser's key
+const indexKey = "build-index"
+
+// newBuildIndex indexes the builds of every parser among data keys
+func newBuildIndex(data map[string]string) (buildIndex, error) {
+	index := buildIndex{}
+	for key, raw := range data {
+		if !strings.Contains(key, ".") {
+			continue
+		}
+		entries, err := decode(raw)
+		if err != nil {
+			return nil, err
+		}
+		index.update(key, nil, entries)
+	}
+	return index, nil
+}
+
+// readBuildIndex returns the build index stored in data, or builds it for
+// history recorded before there was one
+func readBuildIndex(data map[string]string) (buildIndex, error) {
+	raw, ok := data[indexKey]
+	if !ok {
+		return newBuildIndex(data)
+	}
+	var index buildIndex
+	if err := json.Unmarshal([]byte(raw), &index); err != nil {
+		return nil, fmt.Errorf("failed to decode build index: %w", err)
+	}
+	return index, nil
+}
+
+// update reindexes a parser's builds, changed from before to after
+func (index buildIndex) update(key string, before, after []Entry) {
+	for _, entry := range before {
+		if keys := slices.DeleteFunc(index[entry.BuildId], func(k string) bool { return k == key }); len(keys) > 0 {
+			index[entry.BuildId] = keys
+		} else {
+			delete(index, entry.BuildId)
+		}
+	}
+	for _, entry := range after {
+		if entry.BuildId != "" && !slices.Contains(index[entry.BuildId], key) {
+			index[entry.BuildId] = append(index[entry.BuildId], key)
+		}
+	}
+}
+
+// find returns the most recent entry of a build id among the builds of the
+// parsers the index names for it
+func (index buildIndex) find(buildId string, list func(key string) ([]Entry, error)) (*Entry, error) {
+	var found *Entry
+	for _, key := range index[buildId] {
+		entries, err := list(key)
+		if err != nil {
+			return nil, err
+		}
+		for i := range entries {
+			if entries[i].BuildId == buildId && (found == nil || entries[i].StartedAt.After(found.StartedAt)) {
+				found = &entries[i]
+				break
+			}
+		}
+	}
+	return found, nil
+}
+
+// apply adds a status change to a parser's entries (newest first)
+// 📝 NOTE: "building" always opens a new entry; other statuses close the latest one
+func apply(entries []Entry, thirdPartyId, parserId, status, message string, now time.Time) []Entry {
+	if status == StatusBuilding || len(entries) == 0 {
+		entries = append([]Entry{{
+			ThirdPartyId: thirdPartyId,
+			ParserId:     parserId,
+			StartedAt:    now,
+		}}, entries...)
+	}
+	entries[0].Status = status
+	entries[0].Message = message
+	entries[0].UpdatedAt = now
+
+	if len(entries) > MaxEntries {
+		entries = entries[:MaxEntries]
+	}
+	return entries
+}
+
+// attachReport sets the latest entry's test report, truncated to MaxReportBytes
+func attachReport(entries []Entry, thirdPartyId, parserId, report string, now time.Time) []Entry {
+	if len(entries) == 0 {
+		entries = apply(nil, thirdPartyId, parserId, StatusTesting, "", now)
+	}
+	entries[0].TestReport = truncateReport(report, MaxReportBytes)
+	entries[0].UpdatedAt = now
+	return entries
+}
+
+// truncateReport keeps the last max bytes of a report (the summary is at the end)
+func truncateReport(report string, max int) string {
+	if len(report) > max {
+		return "...\n" + report[len(report)-max:]
+	}
+	return report
+}
+
+// dataKey is the ConfigMap key of a parser (keys allow [-._a-zA-Z0-9])
+func dataKey(thirdPartyId, parserId string) string {
+	return thirdPartyId + "." + parserId
+}
+
+// parsersOf returns the parserIds of a tenant among data keys, sorted
+// 📝 NOTE: thirdPartyIds can't contain ".", so the first "." splits the key
+func parsersOf[V any](data map[string]V, thirdPartyId string) []string {
+	var parsers []string
+	for key := range data {
+		if parserId, ok := strings.CutPrefix(key, thirdPartyId+"."); ok {
+			parsers = append(parsers, parserId)
+		}
+	}
+	sort.Strings(parsers)
+	return parsers
+}
+
+// tenantsOf returns the thirdPartyIds among data keys, sorted
+func tenantsOf[V any](data map[string]V) []string {
+	seen := map[string]bool{}
+	var tenants []string
+	for key := range data {
+		if thirdPartyId, _, ok := strings.Cut(key, "."); ok && !seen[thirdPartyId] {
+			seen[thirdPartyId] = true
+			tenants = append(tenants, thirdPartyId)
+		}
+	}
+	sort.Strings(tenants)
+	return tenants
+}
+
+// =============================================================================
+// 🗂️ CONFIGMAP-BACKED STORE
+// =============================================================================
+// One data key per parser holding its last MaxEntries builds as JSON, and the
+// build index (build-index) kept up to date with them
+
+// DefaultConfigMapName is the ConfigMap holding the build history
+const DefaultConfigMapName = "knative-lambda-build-history"
+
+// ConfigMapStore stores build history in a ConfigMap
+type ConfigMapStore struct {
+	clientset kubernetes.Interface
+	namespace string
+	name      string
+}
+
+// NewConfigMapStore creates a ConfigMap-backed build history store
+func NewConfigMapStore(clientset kubernetes.Interface, namespace string) *ConfigMapStore {
+	return &ConfigMapStore{
+		clientset: clientset,
+		namespace: namespace,
+		name:      DefaultConfigMapName,
+	}
+}
+
+// List implements Store
+func (s *ConfigMapStore) List(ctx context.Context, thirdPartyId, parserId string) ([]Entry, error) {
+	cm, err := s.clientset.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read build history: %w", err)
+	}
+	return decode(cm.Data[dataKey(thirdPartyId, parserId)])
+}
+
+// Record implements Store
+func (s *ConfigMapStore) Record(ctx context.Context, thirdPartyId, parserId, status, message string) error {
+	return s.update(ctx, thirdPartyId, parserId, func(entries []Entry, now time.Time) ([]Entry, error) {
+		return apply(entries, thirdPartyId, parserId, status, message, now), nil
+	})
+}
+
+// AttachReport implements Store
+func (s *ConfigMapStore) AttachReport(ctx context.Context, thirdPartyId, parserId, report string) error {
+	return s.update(ctx, thirdPartyId, parserId, func(entries []Entry, now time.Time) ([]Entry, error) {
+		return attachReport(entries, thirdPartyId, parserId, report, now), nil
+	})
+}
+
+// Rewrite implements Store
+func (s *ConfigMapStore) Rewrite(ctx context.Context, thirdPartyId, parserId string, change func([]Entry) ([]Entry, error)) error {
+	return s.update(ctx, thirdPartyId, parserId, func(entries []Entry, now time.Time) ([]Entry, error) {
+		return change(entries)
+	})
+}
+
+// Parsers implements Store
+func (s *ConfigMapStore) Parsers(ctx context.Context, thirdPartyId string) ([]string, error) {
+	cm, err := s.clientset.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read build history: %w", err)
+	}
+	return parsersOf(cm.Data, thirdPartyId), nil
+}
+
+// Tenants implements Store
+func (s *ConfigMapStore) Tenants(ctx context.Context) ([]string, error) {
+	cm, err := s.clientset.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read build history: %w", err)
+	}
+	return tenantsOf(cm.Data), nil
+}
+
+// FindBuild implements BuildFinder, with a single read of the ConfigMap
+func (s *ConfigMapStore) FindBuild(ctx context.Context, buildId string) (*Entry, error) {
+	cm, err := s.clientset.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read build history: %w", err)
+	}
+	index, err := readBuildIndex(cm.Data)
+	if err != nil {
+		return nil, err
+	}
+	return index.find(buildId, func(key string) ([]Entry, error) {
+		return decode(cm.Data[key])
+	})
+}
+
+// update applies a change to a parser's entries
+// 📝 NOTE: Builds finish concurrently, so update conflicts are retried
+func (s *ConfigMapStore) update(ctx context.Context, thirdPartyId, parserId string, change func([]Entry, time.Time) ([]Entry, error)) error {
+	configMaps := s.clientset.CoreV1().ConfigMaps(s.namespace)
+	key := dataKey(thirdPartyId, parserId)
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := configMaps.Get(ctx, s.name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			entries, err := change(nil, time.Now().UTC())
+			if err != nil {
+				return err
+			}
+			raw, err := encode(entries)
+			if err != nil {
+				return err
+			}
+			index := buildIndex{}
+			index.update(key, nil, entries)
+			rawIndex, err := json.Marshal(index)
+			if err != nil {
+				return fmt.Errorf("failed to encode build index: %w", err)
+			}
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      s.name,
+					Namespace: s.namespace,
+					Labels:    map[string]string{"app.kubernetes.io/part-of": "knative-lambda"},
+				},
+				Data: map[string]string{key: raw, indexKey: string(rawIndex)},
+			}
+			if _, err := configMaps.Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+				if apierrors.IsAlreadyExists(err) {
+					// Another replica created it first: retry as an update
+					return apierrors.NewConflict(corev1.Resource("configmaps"), s.name, err)
+				}
+				return fmt.Errorf("failed to create build history: %w", err)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read build history: %w", err)
+		}
+
+		before, err := decode(cm.Data[key])
+		if err != nil {
+			return err
+		}
+		index, err := readBuildIndex(cm.Data)
+		if err != nil {
+			return err
+		}
+		entries, err := change(slices.Clone(before), time.Now().UTC())
+		if err != nil {
+			return err
+		}
+		raw, err := encode(entries)
+		if err != nil {
+			return err
+		}
+		index.update(key, before, entries)
+		rawIndex, err := json.Marshal(index)
+		if err != nil {
+			return fmt.Errorf("failed to encode build index: %w", err)
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[key] = raw
+		cm.Data[indexKey] = string(rawIndex)
+
+		_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+func decode(raw string) ([]Entry, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var entries []Entry
+	if err := json.Unmarshal([]byte(raw), &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode build history: %w", err)
+	}
+	return entries, nil
+}
+
+func encode(entries []Entry) (string, error) {
+	raw, err := json.Marshal(entries)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode build history: %w", err)
+	}
+	return string(raw), nil
+}
+
+// =============================================================================
+// 🧪 IN-MEMORY STORE
+// =============================================================================
+
+// MemoryStore keeps build history in memory (tests, local development)
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string][]Entry
+	index   buildIndex
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[string][]Entry{}, index: buildIndex{}}
+}
+
+// Record implements Store
+func (s *MemoryStore) Record(ctx context.Context, thirdPartyId, parserId, status, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := dataKey(thirdPartyId, parserId)
+	s.set(key, apply(slices.Clone(s.entries[key]), thirdPartyId, parserId, status, message, time.Now().UTC()))
+	return nil
+}
+
+// AttachReport implements Store
+func (s *MemoryStore) AttachReport(ctx context.Context, thirdPartyId, parserId, report string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := dataKey(thirdPartyId, parserId)
+	s.set(key, attachReport(slices.Clone(s.entries[key]), thirdPartyId, parserId, report, time.Now().UTC()))
+	return nil
+}
+
+// set replaces a parser's entries and reindexes them (mu held)
+func (s *MemoryStore) set(key string, entries []Entry) {
+	s.index.update(key, s.entries[key], entries)
+	s.entries[key] = entries
+}
+
+// FindBuild implements BuildFinder
+func (s *MemoryStore) FindBuild(ctx context.Context, buildId string) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.index.find(buildId, func(key string) ([]Entry, error) {
+		return append([]Entry(nil), s.entries[key]...), nil
+	})
+}
+
+// List implements Store
+func (s *MemoryStore) List(ctx context.Context, thirdPartyId, parserId string) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Entry(nil), s.entries[dataKey(thirdPartyId, parserId)]...), nil
+}
+
+// Parsers implements Store
+func (s *MemoryStore) Parsers(ctx context.Context, thirdPartyId string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return parsersOf(s.entries, thirdPartyId), nil
+}
+
+// Tenants implements Store
+func (s *MemoryStore) Tenants(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return tenantsOf(s.entries), nil
+}
+
+// Rewrite implements Store
+func (s *MemoryStore) Rewrite(ctx context.Context, thirdPartyId, parserId string, change func([]Entry) ([]Entry, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := dataKey(thirdPartyId, parserId)
+	entries, err := change(append([]Entry(nil), s.entries[key]...))
+	if err != nil {
+		return err
+	}
+	s.set(key, entries)
+	return nil
+}
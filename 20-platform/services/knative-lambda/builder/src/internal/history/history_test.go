@@ -0,0 +1,189 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestMemoryStoreRecord(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	if latest, err := Latest(ctx, store, "acme", "p1"); err != nil || latest != nil {
+		t.Fatalf("Latest() on empty store = %v, %v; want nil, nil", latest, err)
+	}
+
+	store.Record(ctx, "acme", "p1", StatusBuilding, "")
+	store.Record(ctx, "acme", "p1", StatusFailing, "boom")
+	store.Record(ctx, "acme", "p1", StatusBuilding, "")
+	store.Record(ctx, "acme", "p1", StatusPassing, "")
+
+	entries, _ := store.List(ctx, "acme", "p1")
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 builds, got %d", len(entries))
+	}
+	if entries[0].Status != StatusPassing || entries[1].Status != StatusFailing || entries[1].Message != "boom" {
+		t.Errorf("unexpected history: %+v", entries)
+	}
+
+	for i := 0; i < MaxEntries+5; i++ {
+		store.Record(ctx, "acme", "p1", StatusBuilding, fmt.Sprint(i))
+	}
+	if entries, _ := store.List(ctx, "acme", "p1"); len(entries) != MaxEntries {
+		t.Errorf("history should be capped at %d entries, got %d", MaxEntries, len(entries))
+	}
+}
+
+func TestMemoryStoreAttachReport(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	store.Record(ctx, "acme", "p1", StatusBuilding, "")
+	store.Record(ctx, "acme", "p1", StatusTesting, "")
+	store.AttachReport(ctx, "acme", "p1", strings.Repeat("ok 1 - parses\n", 1000))
+	store.Record(ctx, "acme", "p1", StatusPassing, "")
+
+	latest, _ := Latest(ctx, store, "acme", "p1")
+	if latest.Status != StatusPassing || !strings.HasSuffix(latest.TestReport, "ok 1 - parses\n") {
+		t.Fatalf("unexpected latest build: %+v", latest)
+	}
+	if len(latest.TestReport) > MaxReportBytes+len("...\n") {
+		t.Errorf("report not truncated: %d bytes", len(latest.TestReport))
+	}
+}
+
+func TestFindBuild(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	for _, b := range []struct{ thirdPartyId, parserId, buildId string }{
+		{"acme", "p1", "b1"}, {"acme", "p2", "b2"}, {"globex", "p1", "b3"},
+	} {
+		store.Record(ctx, b.thirdPartyId, b.parserId, StatusBuilding, "")
+		UpdateLatest(ctx, store, b.thirdPartyId, b.parserId, func(entry *Entry) { entry.BuildId = b.buildId })
+	}
+
+	if tenants, _ := store.Tenants(ctx); len(tenants) != 2 || tenants[0] != "acme" {
+		t.Errorf("Tenants() = %v, want [acme globex]", tenants)
+	}
+	if builds, _ := Builds(ctx, store, "acme"); len(builds) != 2 {
+		t.Errorf("Builds(acme) = %d entries, want 2", len(builds))
+	}
+
+	found, err := FindBuild(ctx, store, "b3")
+	if err != nil || found == nil || found.ThirdPartyId != "globex" || found.ParserId != "p1" {
+		t.Fatalf("FindBuild(b3) = %+v, %v; want globex/p1", found, err)
+	}
+	if missing, err := FindBuild(ctx, store, "unknown"); err != nil || missing != nil {
+		t.Errorf("FindBuild(unknown) = %+v, %v; want nil", missing, err)
+	}
+}
+
+func TestConfigMapStoreFindBuild(t *testing.T) {
+	ctx := context.Background()
+	// History recorded before the build index existed
+	legacy, _ := encode([]Entry{{ThirdPartyId: "acme", ParserId: "p1", BuildId: "b1", Status: StatusPassing}})
+	clientset := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: DefaultConfigMapName, Namespace: "knative-lambda"},
+		Data:       map[string]string{dataKey("acme", "p1"): legacy},
+	})
+	store := NewConfigMapStore(clientset, "knative-lambda")
+	find := func(buildId string) *Entry {
+		t.Helper()
+		found, err := FindBuild(ctx, store, buildId)
+		if err != nil {
+			t.Fatalf("FindBuild(%s) = %v", buildId, err)
+		}
+		return found
+	}
+
+	if found := find("b1"); found == nil || found.ParserId != "p1" {
+		t.Errorf("FindBuild(b1) without an index = %+v, want acme/p1", found)
+	}
+
+	// The first change indexes every parser's builds, not just its own
+	store.Record(ctx, "acme", "p2", StatusBuilding, "")
+	UpdateLatest(ctx, store, "acme", "p2", func(entry *Entry) { entry.BuildId = "b2" })
+	cm, _ := clientset.CoreV1().ConfigMaps("knative-lambda").Get(ctx, DefaultConfigMapName, metav1.GetOptions{})
+	if cm.Data[indexKey] == "" {
+		t.Fatalf("no build index in %v", cm.Data)
+	}
+	if found := find("b1"); found == nil || found.ParserId != "p1" {
+		t.Errorf("FindBuild(b1) = %+v, want acme/p1", found)
+	}
+	if found := find("b2"); found == nil || found.ParserId != "p2" {
+		t.Errorf("FindBuild(b2) = %+v, want acme/p2", found)
+	}
+	if tenants, _ := store.Tenants(ctx); len(tenants) != 1 || tenants[0] != "acme" {
+		t.Errorf("Tenants() = %v, want [acme]", tenants)
+	}
+
+	// Builds past MaxEntries leave the index with their entries
+	for i := 0; i < MaxEntries; i++ {
+		store.Record(ctx, "acme", "p1", StatusBuilding, "")
+	}
+	if found := find("b1"); found != nil {
+		t.Errorf("FindBuild(b1) after %d newer builds = %+v, want nil", MaxEntries, found)
+	}
+	cm, _ = clientset.CoreV1().ConfigMaps("knative-lambda").Get(ctx, DefaultConfigMapName, metav1.GetOptions{})
+	if strings.Contains(cm.Data[indexKey], `"b1"`) {
+		t.Errorf("build index still holds b1: %s", cm.Data[indexKey])
+	}
+}
+
+func TestLoadBuild(t *testing.T) {
+	ctx := context.Background()
+	index, records := NewMemoryStore(), NewMemoryStore()
+	first := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	retry := first.Add(time.Hour)
+
+	// A requeued build has one entry per attempt; records holds the details
+	seed := func(store Store, message string) {
+		store.Rewrite(ctx, "acme", "p1", func([]Entry) ([]Entry, error) {
+			return []Entry{
+				{ThirdPartyId: "acme", ParserId: "p1", BuildId: "b1", Status: StatusFailing, Message: message, StartedAt: retry},
+				{ThirdPartyId: "acme", ParserId: "p1", BuildId: "b1", Status: StatusFailing, Message: "first attempt", StartedAt: first},
+			}, nil
+		})
+	}
+	seed(index, "")
+	seed(records, "kaniko exited with 1")
+
+	entry, err := LoadBuild(ctx, index, records, "b1")
+	if err != nil || entry == nil {
+		t.Fatalf("LoadBuild(b1) = %+v, %v; want an entry", entry, err)
+	}
+	if !entry.StartedAt.Equal(retry) || entry.Message != "kaniko exited with 1" {
+		t.Errorf("LoadBuild(b1) = %v %q, want the latest attempt as records has it", entry.StartedAt, entry.Message)
+	}
+	if missing, err := LoadBuild(ctx, index, records, "unknown"); err != nil || missing != nil {
+		t.Errorf("LoadBuild(unknown) = %+v, %v; want nil", missing, err)
+	}
+	if missing, err := LoadBuild(ctx, index, NewMemoryStore(), "b1"); err != nil || missing != nil {
+		t.Errorf("LoadBuild(b1) without records = %+v, %v; want nil", missing, err)
+	}
+}
+
+func TestCurrentPhase(t *testing.T) {
+	for _, tt := range []struct {
+		entry Entry
+		want  string
+	}{
+		{Entry{Status: StatusBuilding, Phase: PhaseQueued}, PhaseQueued},
+		{Entry{Status: StatusTesting, Phase: PhaseDeploying}, PhaseDeploying},
+		{Entry{Status: StatusPassing, Phase: PhaseDeploying}, PhaseReady},
+		{Entry{Status: StatusFailing, Phase: PhaseBuilding}, PhaseFailed},
+		{Entry{Status: StatusTesting}, PhaseTesting}, // Recorded before phases
+	} {
+		if got := tt.entry.CurrentPhase(); got != tt.want {
+			t.Errorf("CurrentPhase(%s/%s) = %s, want %s", tt.entry.Status, tt.entry.Phase, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,333 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// =============================================================================
+// ☸️ KUBERNETES CLIENT MANAGEMENT
+// =============================================================================
+// This package handles Kubernetes client creation and manifest application
+// 🎯 PURPOSE: Centralize all cluster operations (Jobs, Services, Triggers)
+
+// Client holds the Kubernetes clients used by the builder
+type Client struct {
+	Config    *rest.Config
+	Clientset kubernetes.Interface
+	Dynamic   dynamic.Interface
+	// Mapper maps kinds to resources from the API server's discovery (nil =
+	// guess the plural from the kind)
+	Mapper meta.RESTMapper
+}
+
+// NewClient creates a new Kubernetes client
+// 🎯 PURPOSE: Use in-cluster config when running as a pod, kubeconfig otherwise
+func NewClient() (*Client, error) {
+	restConfig, err := rest.InClusterConfig()
+	if err != nil {
+		// 🏠 LOCAL DEVELOPMENT: Fall back to ~/.kube/config (or $KUBECONFIG)
+		loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+		restConfig, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			loadingRules, &clientcmd.ConfigOverrides{}).ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load Kubernetes config: %w", err)
+		}
+	}
+
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes clientset: %w", err)
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	// 📝 NOTE: Discovery is cached, and fetched again when a kind is missing
+	// from the cache (e.g. a CRD installed after the builder started)
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(clientset.Discovery()))
+
+	return &Client{
+		Config:    restConfig,
+		Clientset: clientset,
+		Dynamic:   dynamicClient,
+		Mapper:    mapper,
+	}, nil
+}
+
+// =============================================================================
+// 📄 MANIFEST HANDLING
+// =============================================================================
+
+// DecodeManifests parses a (possibly multi-document) YAML manifest into objects
+// 🎯 PURPOSE: Rendered templates are YAML; the dynamic client wants unstructured objects
+func DecodeManifests(manifest []byte) ([]*unstructured.Unstructured, error) {
+	decoder := yamlutil.NewYAMLOrJSONDecoder(bytes.NewReader(manifest), 4096)
+
+	var objects []*unstructured.Unstructured
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("failed to decode manifest: %w", err)
+		}
+
+		// 📝 NOTE: Empty documents (e.g. a trailing "---") decode to nil maps
+		if len(obj.Object) == 0 {
+			continue
+		}
+		objects = append(objects, obj)
+	}
+
+	return objects, nil
+}
+
+// mapping returns the resource and scope of an object's kind
+// 🎯 WHY: Plurals aren't always kind + "s" (Ingress -> ingresses,
+// NetworkPolicy -> networkpolicies), and CRDs name theirs as they like
+func (c *Client) mapping(obj *unstructured.Unstructured) (*meta.RESTMapping, error) {
+	gvk := obj.GroupVersionKind()
+	if c.Mapper == nil {
+		// 📝 NOTE: Without discovery, guess the plural the way kubectl does and
+		// take the scope from the object
+		resource, _ := meta.UnsafeGuessKindToResource(gvk)
+		scope := meta.RESTScopeRoot
+		if obj.GetNamespace() != "" {
+			scope = meta.RESTScopeNamespace
+		}
+		return &meta.RESTMapping{Resource: resource, GroupVersionKind: gvk, Scope: scope}, nil
+	}
+	mapping, err := c.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the resource of %s: %w", gvk, err)
+	}
+	return mapping, nil
+}
+
+// ResourceFor returns the GroupVersionResource for an object
+func (c *Client) ResourceFor(obj *unstructured.Unstructured) (schema.GroupVersionResource, error) {
+	mapping, err := c.mapping(obj)
+	if err != nil {
+		return schema.GroupVersionResource{}, err
+	}
+	return mapping.Resource, nil
+}
+
+// resourceInterface returns the namespaced (or cluster-scoped) dynamic interface for an object
+func (c *Client) resourceInterface(obj *unstructured.Unstructured) (dynamic.ResourceInterface, error) {
+	mapping, err := c.mapping(obj)
+	if err != nil {
+		return nil, err
+	}
+	resource := c.Dynamic.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		return resource.Namespace(obj.GetNamespace()), nil
+	}
+	return resource, nil
+}
+
+// Create creates an object, failing if it already exists
+func (c *Client) Create(ctx context.Context, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	ri, err := c.resourceInterface(obj)
+	if err != nil {
+		return nil, err
+	}
+	created, err := ri.Create(ctx, obj, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s %s/%s: %w",
+			obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+	}
+	return created, nil
+}
+
+// DryRun validates an object with the API server without persisting it
+// (server-side dry run: schema, defaulting and admission webhooks)
+// 📝 NOTE: Existence is checked after validation, so an object that already
+// exists passed it
+func (c *Client) DryRun(ctx context.Context, obj *unstructured.Unstructured) error {
+	ri, err := c.resourceInterface(obj)
+	if err != nil {
+		return err
+	}
+	_, err = ri.Create(ctx, obj, metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("dry run of %s %s/%s failed: %w",
+			obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+	}
+	return nil
+}
+
+// Apply creates an object, or updates it in place when it already exists
+// 🎯 PURPOSE: Redeploying a parser must update the existing Knative Service
+func (c *Client) Apply(ctx context.Context, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	ri, err := c.resourceInterface(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := ri.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return c.Create(ctx, obj)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s %s/%s: %w",
+			obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+	}
+
+	// 🔄 Optimistic concurrency: carry over the live resourceVersion
+	obj.SetResourceVersion(existing.GetResourceVersion())
+	updated, err := ri.Update(ctx, obj, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update %s %s/%s: %w",
+			obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+	}
+	return updated, nil
+}
+
+// Update writes an object read from the cluster
+// 📝 NOTE: Unlike Apply, keeps the object's resourceVersion: it fails with a
+// conflict when the object changed since it was read
+func (c *Client) Update(ctx context.Context, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	ri, err := c.resourceInterface(obj)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := ri.Update(ctx, obj, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update %s %s/%s: %w",
+			obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+	}
+	return updated, nil
+}
+
+// Recreate deletes an object (if present), waits up to timeout until it is
+// gone and creates it again
+// 🎯 WHY: Some specs are immutable after creation (e.g. Trigger.spec.broker)
+// 📝 NOTE: Deleting only marks an object with finalizers (e.g. a
+// RabbitmqSource's, which removes its queue first); creating it again before
+// it is gone fails with AlreadyExists
+func (c *Client) Recreate(ctx context.Context, obj *unstructured.Unstructured, timeout time.Duration) (*unstructured.Unstructured, error) {
+	if err := c.Delete(ctx, obj); err != nil {
+		return nil, err
+	}
+	if err := c.WaitForDeletion(ctx, obj, timeout); err != nil {
+		return nil, err
+	}
+	return c.Create(ctx, obj)
+}
+
+// deletionPollInterval is how often WaitForDeletion checks an object
+var deletionPollInterval = 500 * time.Millisecond
+
+// WaitForDeletion polls until an object is gone, failing after timeout
+func (c *Client) WaitForDeletion(ctx context.Context, obj *unstructured.Unstructured, timeout time.Duration) error {
+	ri, err := c.resourceInterface(obj)
+	if err != nil {
+		return err
+	}
+	var finalizers []string
+	err = wait.PollUntilContextTimeout(ctx, deletionPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		live, err := ri.Get(ctx, obj.GetName(), metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		if err != nil {
+			// Transient apiserver errors: keep polling until the timeout
+			log.Printf("WARNING: Failed to get %s %s/%s: %v", obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+			return false, nil
+		}
+		finalizers = live.GetFinalizers()
+		return false, nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s %s/%s still exists %s after its deletion (finalizers: %v): %w",
+			obj.GetKind(), obj.GetNamespace(), obj.GetName(), timeout, finalizers, err)
+	}
+	return nil
+}
+
+// Get fetches the live state of an object
+func (c *Client) Get(ctx context.Context, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	ri, err := c.resourceInterface(obj)
+	if err != nil {
+		return nil, err
+	}
+	live, err := ri.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s %s/%s: %w",
+			obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+	}
+	return live, nil
+}
+
+// List returns the objects of a resource matching a label selector
+// 📝 NOTE: An empty namespace lists across all namespaces
+func (c *Client) List(ctx context.Context, gvr schema.GroupVersionResource, namespace, labelSelector string) ([]unstructured.Unstructured, error) {
+	var ri dynamic.ResourceInterface = c.Dynamic.Resource(gvr)
+	if namespace != "" {
+		ri = c.Dynamic.Resource(gvr).Namespace(namespace)
+	}
+	list, err := ri.List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", gvr.Resource, err)
+	}
+	return list.Items, nil
+}
+
+// Delete removes an object, treating "not found" as success
+func (c *Client) Delete(ctx context.Context, obj *unstructured.Unstructured) error {
+	ri, err := c.resourceInterface(obj)
+	if err != nil {
+		return err
+	}
+	propagation := metav1.DeletePropagationBackground
+	err = ri.Delete(ctx, obj.GetName(), metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete %s %s/%s: %w",
+			obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+	}
+	return nil
+}
+
+// ApplyManifest decodes a rendered manifest and applies every object in it
+// 🎯 PURPOSE: One call per rendered template
+func (c *Client) ApplyManifest(ctx context.Context, manifest []byte) ([]*unstructured.Unstructured, error) {
+	objects, err := DecodeManifests(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make([]*unstructured.Unstructured, 0, len(objects))
+	for _, obj := range objects {
+		result, err := c.Apply(ctx, obj)
+		if err != nil {
+			return applied, err
+		}
+		applied = append(applied, result)
+	}
+	return applied, nil
+}
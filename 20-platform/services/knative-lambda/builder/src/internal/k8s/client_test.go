@@ -0,0 +1,122 @@
+package k8s
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var rabbitmqSources = schema.GroupVersionResource{Group: "sources.knative.dev", Version: "v1alpha1", Resource: "rabbitmqsources"}
+
+func TestRecreateWaitsForFinalizers(t *testing.T) {
+	deletionPollInterval = time.Millisecond
+	ctx := context.Background()
+
+	source := &unstructured.Unstructured{}
+	source.SetAPIVersion("sources.knative.dev/v1alpha1")
+	source.SetKind("RabbitmqSource")
+	source.SetNamespace("knative-lambda")
+	source.SetName("lambda-acme-p1")
+
+	// 📝 NOTE: Like an object with finalizers, the source outlives its deletion
+	// until the finalizers are done (here: after 3 more gets)
+	newClient := func(finalizedAfter int) *Client {
+		live := source.DeepCopy()
+		live.SetFinalizers([]string{"rabbitmqsources.sources.knative.dev"})
+		dynamic := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), live)
+		deleting, gets := false, 0
+		dynamic.PrependReactor("delete", "rabbitmqsources", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if deleting {
+				return false, nil, nil
+			}
+			deleting = true
+			now := metav1.Now()
+			live.SetDeletionTimestamp(&now)
+			return true, nil, nil
+		})
+		dynamic.PrependReactor("get", "rabbitmqsources", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if !deleting {
+				return false, nil, nil
+			}
+			if gets++; gets == finalizedAfter {
+				deleting = false
+				return false, nil, dynamic.Tracker().Delete(rabbitmqSources, source.GetNamespace(), source.GetName())
+			}
+			return true, live, nil
+		})
+		return &Client{Dynamic: dynamic}
+	}
+
+	if _, err := newClient(3).Recreate(ctx, source, time.Minute); err != nil {
+		t.Fatalf("Recreate() = %v, want the source created once the finalizers are done", err)
+	}
+
+	_, err := newClient(1<<30).Recreate(ctx, source, 20*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "still exists") || !strings.Contains(err.Error(), "rabbitmqsources.sources.knative.dev") {
+		t.Errorf("Recreate() of a source that never goes away = %v, want a timeout naming its finalizers", err)
+	}
+}
+
+func TestResourceFor(t *testing.T) {
+	object := func(apiVersion, kind string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(apiVersion)
+		obj.SetKind(kind)
+		return obj
+	}
+
+	// Without discovery, plurals are guessed
+	guessed := &Client{}
+	for kind, want := range map[string]string{"Ingress": "ingresses", "NetworkPolicy": "networkpolicies", "Job": "jobs"} {
+		if gvr, err := guessed.ResourceFor(object("networking.k8s.io/v1", kind)); err != nil || gvr.Resource != want {
+			t.Errorf("guessed ResourceFor(%s) = %v, %v, want %s", kind, gvr.Resource, err, want)
+		}
+	}
+
+	// With discovery, the API server's plurals win (a CRD may name its plural
+	// as it likes), and unknown kinds fail
+	lambdas := schema.GroupVersion{Group: "lambda.notifi.network", Version: "v1"}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{lambdas})
+	mapper.AddSpecific(lambdas.WithKind("Lambda"), lambdas.WithResource("lambdafunctions"),
+		lambdas.WithResource("lambdafunction"), meta.RESTScopeNamespace)
+	discovered := &Client{Mapper: mapper}
+	if gvr, err := discovered.ResourceFor(object("lambda.notifi.network/v1", "Lambda")); err != nil || gvr.Resource != "lambdafunctions" {
+		t.Errorf("ResourceFor(Lambda) = %v, %v, want the discovered lambdafunctions", gvr.Resource, err)
+	}
+	if _, err := discovered.ResourceFor(object("lambda.notifi.network/v1", "Unknown")); err == nil {
+		t.Error("ResourceFor() of an unknown kind succeeded")
+	}
+}
+
+func TestSetOwner(t *testing.T) {
+	service := &unstructured.Unstructured{}
+	service.SetAPIVersion("serving.knative.dev/v1")
+	service.SetKind("Service")
+	service.SetNamespace("knative-lambda")
+	service.SetName("lambda-acme-p1")
+	service.SetUID("8c1f")
+
+	source := &unstructured.Unstructured{}
+	source.SetNamespace("knative-lambda")
+	if !SetOwner(source, service) || !SetOwner(source, service) {
+		t.Fatal("SetOwner() in the owner's namespace = false")
+	}
+	if refs := source.GetOwnerReferences(); len(refs) != 1 || refs[0].UID != "8c1f" || !*refs[0].Controller {
+		t.Errorf("owner references = %+v, want the Service as the only controller", refs)
+	}
+
+	trigger := &unstructured.Unstructured{}
+	trigger.SetNamespace("knative-eventing")
+	if SetOwner(trigger, service) || len(trigger.GetOwnerReferences()) != 0 {
+		t.Error("SetOwner() across namespaces set an owner")
+	}
+}
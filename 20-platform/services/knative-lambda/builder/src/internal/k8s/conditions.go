@@ -0,0 +1,52 @@
+package k8s
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// =============================================================================
+// 🚦 STATUS CONDITIONS
+// =============================================================================
+// Knative and most CRDs report readiness through status.conditions
+// 🎯 PURPOSE: Read conditions from unstructured objects without typed clients
+
+// Condition is a single entry of status.conditions
+type Condition struct {
+	Type    string
+	Status  string // "True", "False" or "Unknown"
+	Reason  string
+	Message string
+}
+
+// Conditions extracts status.conditions from an object
+func Conditions(obj *unstructured.Unstructured) []Condition {
+	raw, found, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if err != nil || !found {
+		return nil
+	}
+
+	conditions := make([]Condition, 0, len(raw))
+	for _, item := range raw {
+		c, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		condition := Condition{}
+		condition.Type, _ = c["type"].(string)
+		condition.Status, _ = c["status"].(string)
+		condition.Reason, _ = c["reason"].(string)
+		condition.Message, _ = c["message"].(string)
+		conditions = append(conditions, condition)
+	}
+	return conditions
+}
+
+// FindCondition returns the condition of the given type, if present
+func FindCondition(obj *unstructured.Unstructured, conditionType string) (Condition, bool) {
+	for _, c := range Conditions(obj) {
+		if c.Type == conditionType {
+			return c, true
+		}
+	}
+	return Condition{}, false
+}
@@ -0,0 +1,53 @@
+package k8s
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// =============================================================================
+// 🏷️ LABELS AND OWNERS
+// =============================================================================
+// Objects the builder creates are labelled with the build they belong to and,
+// where they have one, point at their parent object
+// 🎯 WHY: kubectl finds everything of a tenant, parser or build by label, and
+// Kubernetes garbage collects children with their parent
+
+// SetLabels adds labels to an object; they win over the same labels the
+// template set
+func SetLabels(obj *unstructured.Unstructured, labels map[string]string) {
+	merged := obj.GetLabels()
+	if merged == nil {
+		merged = map[string]string{}
+	}
+	for key, value := range labels {
+		merged[key] = value
+	}
+	obj.SetLabels(merged)
+}
+
+// SetOwner makes owner the controller of an object, so deleting owner
+// deletes it too
+// 📝 NOTE: Owners must live in the object's namespace (Kubernetes ignores
+// others), so objects elsewhere are left alone and false is returned
+func SetOwner(obj, owner *unstructured.Unstructured) bool {
+	if owner.GetUID() == "" || obj.GetNamespace() != owner.GetNamespace() {
+		return false
+	}
+	controller := true
+	ref := metav1.OwnerReference{
+		APIVersion: owner.GetAPIVersion(),
+		Kind:       owner.GetKind(),
+		Name:       owner.GetName(),
+		UID:        owner.GetUID(),
+		Controller: &controller,
+	}
+	refs := []metav1.OwnerReference{ref}
+	for _, existing := range obj.GetOwnerReferences() {
+		if existing.UID != owner.GetUID() && (existing.Controller == nil || !*existing.Controller) {
+			refs = append(refs, existing)
+		}
+	}
+	obj.SetOwnerReferences(refs)
+	return true
+}
@@ -0,0 +1,59 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// =============================================================================
+// 🩺 KNATIVE SERVING HEALTH
+// =============================================================================
+// Parsers are Knative Services, which need serving.knative.dev installed and
+// its control plane running. The builder checks both before deploying so it
+// can fall back to plain Kubernetes workloads instead of failing every deploy.
+
+// KnativeServingNamespace is where Knative Serving's control plane runs
+const KnativeServingNamespace = "knative-serving"
+
+// servingGroupVersion is the API parser services are created with
+const servingGroupVersion = "serving.knative.dev/v1"
+
+// servingComponents must have an available replica for Serving to be healthy
+var servingComponents = []string{"activator", "controller", "webhook"}
+
+// ServingStatus reports whether Knative Serving can run parser services
+// Returns the reason when it can't
+// 📝 NOTE: If the builder may not read Serving's deployments, only the API
+// discovery counts (RBAC is not a reason to degrade)
+func ServingStatus(ctx context.Context, clientset kubernetes.Interface) (bool, string) {
+	resources, err := clientset.Discovery().ServerResourcesForGroupVersion(servingGroupVersion)
+	if err != nil {
+		return false, fmt.Sprintf("%s is not installed: %v", servingGroupVersion, err)
+	}
+	found := false
+	for _, r := range resources.APIResources {
+		if r.Name == "services" {
+			found = true
+		}
+	}
+	if !found {
+		return false, fmt.Sprintf("%s does not serve services", servingGroupVersion)
+	}
+
+	for _, name := range servingComponents {
+		deployment, err := clientset.AppsV1().Deployments(KnativeServingNamespace).Get(ctx, name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsForbidden(err):
+			return true, ""
+		case err != nil:
+			return false, fmt.Sprintf("knative serving %s: %v", name, err)
+		case deployment.Status.AvailableReplicas == 0:
+			return false, fmt.Sprintf("knative serving %s has no available replica", name)
+		}
+	}
+	return true, ""
+}
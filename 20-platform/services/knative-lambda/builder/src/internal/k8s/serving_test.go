@@ -0,0 +1,51 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func servingDeployment(name string, available int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: KnativeServingNamespace},
+		Status:     appsv1.DeploymentStatus{AvailableReplicas: available},
+	}
+}
+
+func TestServingStatus(t *testing.T) {
+	ctx := context.Background()
+
+	// No serving.knative.dev API at all
+	clientset := fake.NewSimpleClientset()
+	if ok, reason := ServingStatus(ctx, clientset); ok || reason == "" {
+		t.Errorf("ServingStatus without Knative = %t %q, want unavailable", ok, reason)
+	}
+
+	install := func(clientset *fake.Clientset) {
+		clientset.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{{
+			GroupVersion: servingGroupVersion,
+			APIResources: []metav1.APIResource{{Name: "services", Kind: "Service", Namespaced: true}},
+		}}
+	}
+
+	// Installed, but the webhook is down
+	clientset = fake.NewSimpleClientset(
+		servingDeployment("activator", 1), servingDeployment("controller", 1), servingDeployment("webhook", 0))
+	install(clientset)
+	if ok, reason := ServingStatus(ctx, clientset); ok || reason != "knative serving webhook has no available replica" {
+		t.Errorf("ServingStatus with the webhook down = %t %q", ok, reason)
+	}
+
+	// Healthy
+	clientset = fake.NewSimpleClientset(
+		servingDeployment("activator", 1), servingDeployment("controller", 1), servingDeployment("webhook", 2))
+	install(clientset)
+	if ok, reason := ServingStatus(ctx, clientset); !ok {
+		t.Errorf("ServingStatus of a healthy install = unavailable (%s)", reason)
+	}
+}
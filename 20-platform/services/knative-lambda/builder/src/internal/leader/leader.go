@@ -0,0 +1,107 @@
+package leader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"knative-lambda-builder/internal/observability"
+)
+
+// =============================================================================
+// 👑 LEADER ELECTION
+// =============================================================================
+// Builder replicas share the build work (each event goes to one replica; jobs
+// and BuildRuns are claimed), but the loops that sweep the whole namespace
+// (canary controller, reaper, garbage collector, reconcilers) must run once.
+// The replica holding a Lease (coordination.k8s.io) runs them
+// 🎯 WHY: Two canary controllers step a rollout twice as fast; two
+// reconcilers race each other's deletes
+// 📝 NOTE: A replica shutting down releases the Lease, so the next leader
+// takes over at once instead of after the lease duration (zero-downtime
+// rollouts of the builder itself)
+// 💡 EXAMPLE: kubectl get lease knative-lambda-builder -n knative-lambda
+
+// Config is how replicas elect their leader
+type Config struct {
+	Enabled       bool          // false = this replica always leads (single replica)
+	Namespace     string        // Where the Lease lives
+	LeaseName     string        // Name of the Lease
+	Identity      string        // This replica's name in the Lease (its pod name)
+	LeaseDuration time.Duration // How long followers wait before taking over a leader that stopped renewing
+}
+
+// Validate checks an election can be run with the configuration
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Namespace == "" || c.LeaseName == "" || c.Identity == "" {
+		return fmt.Errorf("leader election needs a namespace, a lease name and an identity")
+	}
+	if c.LeaseDuration < 3*time.Second {
+		return fmt.Errorf("leader election lease duration %s is shorter than 3s", c.LeaseDuration)
+	}
+	return nil
+}
+
+// renewDeadline is how long the leader keeps trying to renew before it steps
+// down; leaders renew every retryPeriod
+func (c Config) renewDeadline() time.Duration { return c.LeaseDuration * 2 / 3 }
+func (c Config) retryPeriod() time.Duration   { return c.LeaseDuration / 5 }
+
+// Run calls lead while this replica is the leader, with a context canceled
+// when it stops leading, until ctx is done
+// 📝 NOTE: A replica that loses the Lease (e.g. the API server was
+// unreachable) stands again once lead returned
+func Run(ctx context.Context, clientset kubernetes.Interface, cfg Config, lead func(ctx context.Context)) {
+	if !cfg.Enabled {
+		observability.Leader.Set(1)
+		lead(ctx)
+		return
+	}
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta:  metav1.ObjectMeta{Namespace: cfg.Namespace, Name: cfg.LeaseName},
+		Client:     clientset.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: cfg.Identity},
+	}
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   cfg.LeaseDuration,
+		RenewDeadline:   cfg.renewDeadline(),
+		RetryPeriod:     cfg.retryPeriod(),
+		ReleaseOnCancel: true,
+		Name:            cfg.LeaseName,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				log.Printf("👑 %s leads (lease %s/%s)", cfg.Identity, cfg.Namespace, cfg.LeaseName)
+				observability.Leader.Set(1)
+				lead(ctx)
+			},
+			OnStoppedLeading: func() {
+				log.Printf("👑 %s stopped leading", cfg.Identity)
+				observability.Leader.Set(0)
+			},
+			OnNewLeader: func(identity string) {
+				if identity != cfg.Identity {
+					log.Printf("👑 %s leads", identity)
+				}
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to set up leader election: %v", err)
+		return
+	}
+
+	for ctx.Err() == nil {
+		elector.Run(ctx)
+	}
+}
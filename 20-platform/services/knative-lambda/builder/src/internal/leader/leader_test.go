@@ -0,0 +1,47 @@
+package leader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRun(t *testing.T) {
+	cfg := Config{Enabled: true, Namespace: "knative-lambda", LeaseName: "knative-lambda-builder",
+		Identity: "builder-a", LeaseDuration: 3 * time.Second}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() = %v", err)
+	}
+	if err := (Config{Enabled: true, Namespace: "knative-lambda", LeaseName: "l", Identity: "a", LeaseDuration: time.Second}).Validate(); err == nil {
+		t.Error("Validate() of a 1s lease succeeded")
+	}
+
+	// The replica takes the free Lease and leads until it stops, then releases it
+	clientset := fake.NewSimpleClientset()
+	ctx, cancel := context.WithCancel(context.Background())
+	leading := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		Run(ctx, clientset, cfg, func(ctx context.Context) { close(leading) })
+		close(stopped)
+	}()
+	select {
+	case <-leading:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the only replica never led")
+	}
+	lease, err := clientset.CoordinationV1().Leases("knative-lambda").Get(context.Background(), "knative-lambda-builder", metav1.GetOptions{})
+	if err != nil || lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != "builder-a" {
+		t.Fatalf("lease = %+v, %v, want it held by builder-a", lease, err)
+	}
+
+	cancel()
+	<-stopped
+	lease, _ = clientset.CoordinationV1().Leases("knative-lambda").Get(context.Background(), "knative-lambda-builder", metav1.GetOptions{})
+	if lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity != "" {
+		t.Errorf("lease held by %q after the leader stopped, want it released", *lease.Spec.HolderIdentity)
+	}
+}
@@ -0,0 +1,284 @@
+package observability
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// =============================================================================
+// 📊 METRICS
+// =============================================================================
+// Event metrics are aggregated per CloudEvent type only (no job/parser labels)
+// so apiserver event storms can't blow up series cardinality
+
+var (
+	// EventsReceived counts received CloudEvents by type
+	EventsReceived = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "knative_lambda_builder_events_received_total",
+			Help: "Total number of CloudEvents received by the builder",
+		},
+		[]string{"type"},
+	)
+
+	// EventsSampled counts received CloudEvents selected for tracing/verbose logging
+	EventsSampled = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "knative_lambda_builder_events_sampled_total",
+			Help: "Total number of CloudEvents selected for tracing and verbose logging",
+		},
+		[]string{"type"},
+	)
+
+	// EventsRejected counts received CloudEvents refused before handling
+	EventsRejected = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "knative_lambda_builder_events_rejected_total",
+			Help: "Total number of CloudEvents rejected before handling, by type and reason",
+		},
+		[]string{"type", "reason"},
+	)
+
+	// EventHandlingDuration observes how long routing an event took
+	EventHandlingDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "knative_lambda_builder_event_handling_duration_seconds",
+			Help:    "Time spent handling a CloudEvent (excluding background work)",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"type"},
+	)
+
+	// BuildPreemptions counts build jobs disrupted by preemption or eviction
+	BuildPreemptions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "knative_lambda_builder_build_preemptions_total",
+			Help: "Total number of build jobs preempted or evicted, by disruption reason",
+		},
+		[]string{"reason"},
+	)
+
+	// BuildsDeduplicated counts duplicate build requests that were ignored
+	BuildsDeduplicated = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "knative_lambda_builder_builds_deduplicated_total",
+			Help: "Total number of duplicate build requests ignored, by where the duplicate was found (memory or history)",
+		},
+		[]string{"match"},
+	)
+
+	// BuildsRateLimited counts build requests refused by their tenant's rate limit
+	BuildsRateLimited = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "knative_lambda_builder_builds_rate_limited_total",
+			Help: "Total number of build requests refused because their tenant exceeded its build rate limit",
+		},
+	)
+
+	// BuildsQueued tracks builds in the build queue (waiting for a worker or a slot)
+	BuildsQueued = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "knative_lambda_builder_builds_queued",
+			Help: "Number of builds waiting for a build worker or slot in this replica, by priority",
+		},
+		[]string{"priority"},
+	)
+
+	// BuildQueueWait observes how long builds waited for a slot (MAX_CONCURRENT_BUILDS), from acceptance
+	BuildQueueWait = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "knative_lambda_builder_build_queue_wait_seconds",
+			Help:    "Time builds waited for a build slot before launching their job, by priority",
+			Buckets: []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200, 3600},
+		},
+		[]string{"priority"},
+	)
+
+	// BuildBacklog tracks accepted builds whose job isn't created yet (BUILD_QUEUE_SIZE)
+	BuildBacklog = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "knative_lambda_builder_build_backlog",
+			Help: "Number of accepted builds in this replica whose Kaniko job isn't created yet",
+		},
+	)
+
+	// BuildsRefusedQueueFull counts build requests refused because the backlog was full
+	BuildsRefusedQueueFull = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "knative_lambda_builder_builds_queue_full_total",
+			Help: "Total number of build requests refused because BUILD_QUEUE_SIZE builds were already waiting",
+		},
+	)
+
+	// CallbacksDelivered counts build callbacks by outcome
+	CallbacksDelivered = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "knative_lambda_builder_callbacks_total",
+			Help: "Total number of build callbacks, by outcome (delivered, failed after retries)",
+		},
+		[]string{"outcome"},
+	)
+
+	// DeadLetters counts builds sent to the dead-letter sink
+	DeadLetters = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "knative_lambda_builder_dead_letters_total",
+			Help: "Total number of failed builds sent to the dead-letter sink, by outcome (sent, failed)",
+		},
+		[]string{"outcome"},
+	)
+
+	// BuildRequeues counts what happened to preempted builds
+	BuildRequeues = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "knative_lambda_builder_build_requeues_total",
+			Help: "Total number of preempted builds requeued (or given up on once out of retries)",
+		},
+		[]string{"outcome"},
+	)
+
+	// BuildRetries counts failed builds started again
+	BuildRetries = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "knative_lambda_builder_build_retries_total",
+			Help: "Total number of builds retried after their build job failed",
+		},
+	)
+
+	// BuildsTakenOver counts Pending builds taken over from a replica that
+	// stopped heartbeating them (BUILD_VISIBILITY_TIMEOUT)
+	BuildsTakenOver = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "knative_lambda_builder_builds_taken_over_total",
+			Help: "Total number of queued builds this replica took over from another replica",
+		},
+	)
+
+	// BuildTimeouts counts builds that ran out of time
+	BuildTimeouts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "knative_lambda_builder_build_timeouts_total",
+			Help: "Total number of builds failed for running too long, by reason (deadline_exceeded, stale)",
+		},
+		[]string{"reason"},
+	)
+
+	// ContextCleanups counts uploaded build contexts cleaned up after their image was confirmed
+	ContextCleanups = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "knative_lambda_builder_context_cleanups_total",
+			Help: "Total number of build contexts deleted or tagged for expiry, by action",
+		},
+		[]string{"action"},
+	)
+
+	// ContextReclaimedBytes sums the size of those build contexts
+	// 📝 NOTE: "tagged" bytes are reclaimed later, by the bucket lifecycle rule
+	ContextReclaimedBytes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "knative_lambda_builder_context_reclaimed_bytes_total",
+			Help: "Total size of build contexts deleted or tagged for expiry, by action",
+		},
+		[]string{"action"},
+	)
+
+	// GarbageCollected counts what the periodic garbage collection deleted
+	GarbageCollected = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "knative_lambda_builder_garbage_collected_total",
+			Help: "Total number of leftovers deleted by garbage collection, by kind (job, tempdir, context)",
+		},
+		[]string{"kind"},
+	)
+
+	// CanarySteps counts canary rollout transitions
+	CanarySteps = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "knative_lambda_builder_canary_steps_total",
+			Help: "Total number of canary rollout transitions, by outcome (started, stepped, promoted, aborted)",
+		},
+		[]string{"outcome"},
+	)
+
+	// ScanVerdicts counts the verdicts of the vulnerability scan gate
+	ScanVerdicts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "knative_lambda_builder_scan_verdicts_total",
+			Help: "Total number of images checked by the vulnerability scan gate, by verdict (passed, blocked)",
+		},
+		[]string{"verdict"},
+	)
+
+	// BlueGreenDeploys counts blue-green deploys that reached a verdict
+	BlueGreenDeploys = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "knative_lambda_builder_blue_green_deploys_total",
+			Help: "Total number of blue-green deploys, by outcome (flipped, aborted)",
+		},
+		[]string{"outcome"},
+	)
+
+	// AWSRetries counts AWS API calls retried after throttling or a transient error
+	AWSRetries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "knative_lambda_builder_aws_retries_total",
+			Help: "Total number of AWS API call retries, by service and operation",
+		},
+		[]string{"service", "operation"},
+	)
+
+	// Leader is 1 while this replica runs the builder's singleton loops (LEADER_ELECTION)
+	Leader = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "knative_lambda_builder_leader",
+			Help: "1 while this replica is the leader running the canary controller, reaper, garbage collector and reconcilers",
+		},
+	)
+)
+
+// knownEventTypes bounds the "type" label; everything else is reported as "other"
+var knownEventTypes = map[string]bool{}
+
+// RegisterEventTypes declares the event types that get their own label value
+func RegisterEventTypes(types ...string) {
+	for _, t := range types {
+		knownEventTypes[t] = true
+	}
+}
+
+// EventTypeLabel returns the metric label value for an event type
+func EventTypeLabel(eventType string) string {
+	if knownEventTypes[eventType] {
+		return eventType
+	}
+	return "other"
+}
+
+func init() {
+	prometheus.MustRegister(EventsReceived)
+	prometheus.MustRegister(EventsSampled)
+	prometheus.MustRegister(EventsRejected)
+	prometheus.MustRegister(BuildsDeduplicated)
+	prometheus.MustRegister(BuildsRateLimited)
+	prometheus.MustRegister(BuildsQueued)
+	prometheus.MustRegister(BuildQueueWait)
+	prometheus.MustRegister(BuildBacklog)
+	prometheus.MustRegister(BuildsRefusedQueueFull)
+	prometheus.MustRegister(DeadLetters)
+	prometheus.MustRegister(CallbacksDelivered)
+	prometheus.MustRegister(EventHandlingDuration)
+	prometheus.MustRegister(BuildPreemptions)
+	prometheus.MustRegister(BuildRequeues)
+	prometheus.MustRegister(BuildRetries)
+	prometheus.MustRegister(BuildsTakenOver)
+	prometheus.MustRegister(BuildTimeouts)
+	prometheus.MustRegister(ContextCleanups)
+	prometheus.MustRegister(ContextReclaimedBytes)
+	prometheus.MustRegister(GarbageCollected)
+	prometheus.MustRegister(CanarySteps)
+	prometheus.MustRegister(BlueGreenDeploys)
+	prometheus.MustRegister(ScanVerdicts)
+	prometheus.MustRegister(AWSRetries)
+	prometheus.MustRegister(Leader)
+	prometheus.MustRegister(NewRuntimeCollector())
+	prometheus.MustRegister(SelfProfiles)
+}
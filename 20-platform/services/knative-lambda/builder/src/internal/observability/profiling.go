@@ -0,0 +1,132 @@
+package observability
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// =============================================================================
+// 📸 SELF-PROFILING SNAPSHOTS
+// =============================================================================
+// A leak is much easier to diagnose from a profile taken while it happens
+// than from the metrics afterwards. The profiler checks heap and goroutines
+// every interval and, when a threshold is breached, writes heap and goroutine
+// profiles to disk (and optionally uploads them, e.g. to S3).
+// 📝 NOTE: At most one snapshot per cooldown, so a sustained breach doesn't
+// fill the disk
+
+// SelfProfiles counts snapshots taken, by the threshold that triggered them
+var SelfProfiles = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "runtime_self_profiles_total",
+		Help: "Profiling snapshots taken because a resource threshold was breached",
+	},
+	[]string{"trigger"},
+)
+
+// Profiles written per snapshot
+var snapshotProfiles = []string{"heap", "goroutine"}
+
+// SelfProfiler snapshots profiles when resource usage crosses a threshold
+type SelfProfiler struct {
+	Service    string        // Prefix of the profile file names
+	Dir        string        // Where profiles are written
+	Interval   time.Duration // How often usage is checked
+	Cooldown   time.Duration // Minimum time between two snapshots
+	HeapBytes  uint64        // Live heap threshold (0 = ignore)
+	Goroutines uint64        // Goroutine threshold (0 = ignore)
+
+	// Upload, when set, receives every profile after it was written
+	Upload func(ctx context.Context, name string, data []byte) error
+
+	last time.Time
+}
+
+// Start checks usage every Interval until ctx is done
+func (p *SelfProfiler) Start(ctx context.Context) {
+	if err := os.MkdirAll(p.Dir, 0o755); err != nil {
+		log.Printf("WARNING: Self-profiling disabled, cannot create %s: %v", p.Dir, err)
+		return
+	}
+	log.Printf("📸 Self-profiling every %s (heap > %d bytes or goroutines > %d) into %s",
+		p.Interval, p.HeapBytes, p.Goroutines, p.Dir)
+
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			p.Check(ctx, now)
+		}
+	}
+}
+
+// Check takes a snapshot if a threshold is breached and the cooldown passed
+// Returns the trigger ("" when no snapshot was taken)
+func (p *SelfProfiler) Check(ctx context.Context, now time.Time) string {
+	if !p.last.IsZero() && now.Sub(p.last) < p.Cooldown {
+		return ""
+	}
+
+	samples := readRuntimeSamples(sampleHeapLive, sampleGoroutines)
+	trigger := Breach(samples[sampleHeapLive].Uint64(), p.HeapBytes, samples[sampleGoroutines].Uint64(), p.Goroutines)
+	if trigger == "" {
+		return ""
+	}
+
+	p.last = now
+	SelfProfiles.WithLabelValues(trigger).Inc()
+	log.Printf("WARNING: %s threshold breached (heap=%d bytes, goroutines=%d), writing profiles",
+		trigger, samples[sampleHeapLive].Uint64(), samples[sampleGoroutines].Uint64())
+
+	stamp := now.UTC().Format("20060102T150405Z")
+	for _, profile := range snapshotProfiles {
+		name := fmt.Sprintf("%s-%s-%s.pb.gz", p.Service, stamp, profile)
+		if err := p.write(ctx, profile, name); err != nil {
+			log.Printf("ERROR: Failed to write %s profile: %v", profile, err)
+		}
+	}
+	return trigger
+}
+
+// Breach returns which threshold usage crossed ("" if none, 0 thresholds are ignored)
+func Breach(heapBytes, heapThreshold, goroutines, goroutineThreshold uint64) string {
+	switch {
+	case heapThreshold > 0 && heapBytes > heapThreshold:
+		return "heap"
+	case goroutineThreshold > 0 && goroutines > goroutineThreshold:
+		return "goroutines"
+	}
+	return ""
+}
+
+// write saves a runtime profile to Dir and hands it to Upload
+func (p *SelfProfiler) write(ctx context.Context, profile, name string) error {
+	var buf bytes.Buffer
+	if err := pprof.Lookup(profile).WriteTo(&buf, 0); err != nil {
+		return err
+	}
+
+	path := filepath.Join(p.Dir, name)
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	log.Printf("📸 Wrote %s", path)
+
+	if p.Upload != nil {
+		if err := p.Upload(ctx, name, buf.Bytes()); err != nil {
+			return fmt.Errorf("failed to upload %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package observability
+
+import (
+	"math"
+	"runtime/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// =============================================================================
+// 🫀 RUNTIME SELF-METRICS
+// =============================================================================
+// Resource usage of the process itself, read from runtime/metrics
+// 🎯 PURPOSE: The builder and the stooges (Go, Python, Node) export the same
+// runtime_* series, so one dashboard covers every service:
+//   runtime_gc_pause_seconds   (histogram) stop-the-world GC pauses
+//   runtime_gc_cycles_total    (counter)   completed GC cycles
+//   runtime_goroutines         (gauge)     live goroutines (Go only)
+//   runtime_heap_live_bytes    (gauge)     heap reachable at the last GC
+//   runtime_heap_total_bytes   (gauge)     heap memory held by the runtime
+//   runtime_heap_objects       (gauge)     heap objects (Go only)
+// 📝 NOTE: The client's go_* collector keeps running; these add a portable view
+
+// GCPauseBuckets are the pause histogram buckets, identical in every service
+var GCPauseBuckets = []float64{0.00001, 0.00005, 0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1}
+
+// runtime/metrics sample names
+const (
+	sampleGCPauses       = "/sched/pauses/total/gc:seconds"
+	sampleGCPausesLegacy = "/gc/pauses:seconds" // Before Go 1.22
+	sampleGCCycles       = "/gc/cycles/total:gc-cycles"
+	sampleGoroutines     = "/sched/goroutines:goroutines"
+	sampleHeapLive       = "/gc/heap/live:bytes"
+	sampleHeapObjects    = "/gc/heap/objects:objects"
+	sampleHeapInUse      = "/memory/classes/heap/objects:bytes"
+	sampleHeapUnused     = "/memory/classes/heap/unused:bytes"
+	sampleHeapFree       = "/memory/classes/heap/free:bytes"
+)
+
+var (
+	gcPauseDesc     = prometheus.NewDesc("runtime_gc_pause_seconds", "Stop-the-world garbage collection pauses", nil, nil)
+	gcCyclesDesc    = prometheus.NewDesc("runtime_gc_cycles_total", "Completed garbage collection cycles", nil, nil)
+	goroutinesDesc  = prometheus.NewDesc("runtime_goroutines", "Live goroutines", nil, nil)
+	heapLiveDesc    = prometheus.NewDesc("runtime_heap_live_bytes", "Heap bytes reachable at the last garbage collection", nil, nil)
+	heapTotalDesc   = prometheus.NewDesc("runtime_heap_total_bytes", "Heap memory held by the runtime (in use, unused and free spans)", nil, nil)
+	heapObjectsDesc = prometheus.NewDesc("runtime_heap_objects", "Objects on the heap", nil, nil)
+)
+
+// RuntimeCollector exports runtime/metrics under the shared runtime_* names
+type RuntimeCollector struct {
+	pauseSample string
+}
+
+// NewRuntimeCollector creates the collector for the running Go version
+func NewRuntimeCollector() *RuntimeCollector {
+	c := &RuntimeCollector{pauseSample: sampleGCPausesLegacy}
+	for _, d := range metrics.All() {
+		if d.Name == sampleGCPauses {
+			c.pauseSample = sampleGCPauses
+		}
+	}
+	return c
+}
+
+// Describe implements prometheus.Collector
+func (c *RuntimeCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{gcPauseDesc, gcCyclesDesc, goroutinesDesc, heapLiveDesc, heapTotalDesc, heapObjectsDesc} {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector
+func (c *RuntimeCollector) Collect(ch chan<- prometheus.Metric) {
+	samples := readRuntimeSamples(c.pauseSample, sampleGCCycles, sampleGoroutines, sampleHeapLive,
+		sampleHeapObjects, sampleHeapInUse, sampleHeapUnused, sampleHeapFree)
+
+	if h := samples[c.pauseSample]; h.Kind() == metrics.KindFloat64Histogram {
+		count, sum, buckets := FoldHistogram(h.Float64Histogram(), GCPauseBuckets)
+		ch <- prometheus.MustNewConstHistogram(gcPauseDesc, count, sum, buckets)
+	}
+
+	gauge := func(desc *prometheus.Desc, valueType prometheus.ValueType, names ...string) {
+		total := 0.0
+		for _, name := range names {
+			if samples[name].Kind() != metrics.KindUint64 {
+				return // Not supported by this Go version
+			}
+			total += float64(samples[name].Uint64())
+		}
+		ch <- prometheus.MustNewConstMetric(desc, valueType, total)
+	}
+	gauge(gcCyclesDesc, prometheus.CounterValue, sampleGCCycles)
+	gauge(goroutinesDesc, prometheus.GaugeValue, sampleGoroutines)
+	gauge(heapLiveDesc, prometheus.GaugeValue, sampleHeapLive)
+	gauge(heapObjectsDesc, prometheus.GaugeValue, sampleHeapObjects)
+	gauge(heapTotalDesc, prometheus.GaugeValue, sampleHeapInUse, sampleHeapUnused, sampleHeapFree)
+}
+
+// readRuntimeSamples reads runtime/metrics samples by name
+// 📝 NOTE: Names the running Go version doesn't know come back as KindBad
+func readRuntimeSamples(names ...string) map[string]metrics.Value {
+	samples := make([]metrics.Sample, len(names))
+	for i, name := range names {
+		samples[i].Name = name
+	}
+	metrics.Read(samples)
+
+	values := make(map[string]metrics.Value, len(samples))
+	for _, s := range samples {
+		values[s.Name] = s.Value
+	}
+	return values
+}
+
+// FoldHistogram maps a runtime histogram onto fixed Prometheus buckets
+// 🎯 WHY: runtime/metrics uses ~100 fine-grained buckets that change between
+// Go versions; fixed buckets keep series stable and comparable across services
+// 📝 NOTE: A runtime bucket counts towards every bound at or above its upper
+// edge; the sum is estimated from bucket midpoints
+func FoldHistogram(h *metrics.Float64Histogram, bounds []float64) (uint64, float64, map[float64]uint64) {
+	buckets := make(map[float64]uint64, len(bounds))
+	for _, b := range bounds {
+		buckets[b] = 0
+	}
+
+	var count uint64
+	var sum float64
+	for i, n := range h.Counts {
+		if n == 0 {
+			continue
+		}
+		lower, upper := h.Buckets[i], h.Buckets[i+1]
+		count += n
+
+		switch {
+		case math.IsInf(upper, 1):
+			sum += lower * float64(n)
+		case math.IsInf(lower, -1):
+			sum += upper * float64(n)
+		default:
+			sum += (lower + upper) / 2 * float64(n)
+		}
+
+		for _, b := range bounds {
+			if upper <= b {
+				buckets[b] += n
+			}
+		}
+	}
+	return count, sum, buckets
+}
@@ -0,0 +1,47 @@
+package observability
+
+import (
+	"math"
+	"runtime/metrics"
+	"testing"
+)
+
+func TestFoldHistogram(t *testing.T) {
+	h := &metrics.Float64Histogram{
+		Buckets: []float64{math.Inf(-1), 0.00002, 0.00004, 0.002, math.Inf(1)},
+		Counts:  []uint64{1, 3, 2, 1},
+	}
+
+	count, sum, buckets := FoldHistogram(h, GCPauseBuckets)
+	if count != 7 {
+		t.Errorf("count = %d, want 7", count)
+	}
+	want := map[float64]uint64{0.00001: 0, 0.00005: 4, 0.0001: 4, 0.001: 4, 0.005: 6, 1: 6}
+	for bound, n := range want {
+		if buckets[bound] != n {
+			t.Errorf("bucket %g = %d, want %d", bound, buckets[bound], n)
+		}
+	}
+	// 1*0.00002 + 3*0.00003 + 2*0.00102 + 1*0.002 (lower edge of the open bucket)
+	if math.Abs(sum-0.00415) > 1e-9 {
+		t.Errorf("sum = %g, want 0.00415", sum)
+	}
+}
+
+func TestBreach(t *testing.T) {
+	cases := []struct {
+		heap, heapLimit, goroutines, goroutineLimit uint64
+		want                                        string
+	}{
+		{100, 0, 100, 0, ""},
+		{100, 200, 100, 200, ""},
+		{300, 200, 100, 200, "heap"},
+		{100, 200, 300, 200, "goroutines"},
+		{300, 0, 300, 200, "goroutines"},
+	}
+	for _, c := range cases {
+		if got := Breach(c.heap, c.heapLimit, c.goroutines, c.goroutineLimit); got != c.want {
+			t.Errorf("Breach(%d, %d, %d, %d) = %q, want %q", c.heap, c.heapLimit, c.goroutines, c.goroutineLimit, got, c.want)
+		}
+	}
+}
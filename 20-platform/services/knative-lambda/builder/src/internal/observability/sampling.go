@@ -0,0 +1,142 @@
+package observability
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// =============================================================================
+// 🎲 PER-EVENT-TYPE SAMPLING
+// =============================================================================
+// resource.update events arrive for every Job status change in the namespace;
+// during apiserver event storms tracing and logging each one is expensive.
+// Sampling rates are configured per CloudEvent type, while build.start (the
+// actual pipelines) is always fully traced.
+
+// Span attributes the sampler looks at
+const (
+	AttrEventType = "cloudevents.event_type"
+	AttrEventID   = "cloudevents.event_id"
+)
+
+// alwaysSampled lists event types that ignore the configured rate
+var alwaysSampled = map[string]bool{
+	"network.notifi.lambda.build.start": true,
+}
+
+// SamplingPolicy maps CloudEvent types to sampling ratios (0.0 - 1.0)
+type SamplingPolicy struct {
+	rates       map[string]float64
+	defaultRate float64
+}
+
+// ParseSamplingPolicy parses "type=ratio,type=ratio" with a default ratio for other types
+// 💡 EXAMPLE: "dev.knative.apiserver.resource.update=0.05"
+func ParseSamplingPolicy(spec string, defaultRate float64) (*SamplingPolicy, error) {
+	if defaultRate < 0 || defaultRate > 1 {
+		return nil, fmt.Errorf("default sample rate %v must be between 0 and 1", defaultRate)
+	}
+
+	policy := &SamplingPolicy{rates: map[string]float64{}, defaultRate: defaultRate}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		eventType, rawRate, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid sampling entry %q, expected type=ratio", entry)
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(rawRate), 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return nil, fmt.Errorf("invalid sample rate %q for %s, expected 0.0-1.0", rawRate, eventType)
+		}
+		policy.rates[strings.TrimSpace(eventType)] = rate
+	}
+	return policy, nil
+}
+
+// Rate returns the sampling ratio for an event type
+func (p *SamplingPolicy) Rate(eventType string) float64 {
+	if alwaysSampled[eventType] {
+		return 1
+	}
+	if rate, ok := p.rates[eventType]; ok {
+		return rate
+	}
+	return p.defaultRate
+}
+
+// Sampled decides deterministically whether an event is sampled
+// 🎯 PURPOSE: Gate verbose per-event logging with the same ratio as traces
+// 📝 NOTE: Hashing the event ID keeps redeliveries of one event consistent
+func (p *SamplingPolicy) Sampled(eventType, eventID string) bool {
+	rate := p.Rate(eventType)
+	switch {
+	case rate >= 1:
+		return true
+	case rate <= 0:
+		return false
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(eventID))
+	return float64(h.Sum64()%10000) < rate*10000
+}
+
+// =============================================================================
+// 🔭 OPENTELEMETRY SAMPLER
+// =============================================================================
+
+// eventTypeSampler head-samples root spans by their CloudEvent attributes
+type eventTypeSampler struct {
+	policy *SamplingPolicy
+}
+
+// NewSampler returns a parent-based sampler applying the policy to root spans
+// 📝 NOTE: Child spans follow their parent so pipelines are never half-traced
+func NewSampler(policy *SamplingPolicy) sdktrace.Sampler {
+	return sdktrace.ParentBased(&eventTypeSampler{policy: policy})
+}
+
+// ShouldSample implements sdktrace.Sampler
+// 🎯 WHY: Uses SamplingPolicy.Sampled so traces and verbose logs pick the same events
+func (s *eventTypeSampler) ShouldSample(params sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	var eventType, eventID string
+	for _, attr := range params.Attributes {
+		switch string(attr.Key) {
+		case AttrEventType:
+			eventType = attr.Value.AsString()
+		case AttrEventID:
+			eventID = attr.Value.AsString()
+		}
+	}
+
+	result := sdktrace.SamplingResult{
+		Decision:   sdktrace.Drop,
+		Tracestate: trace.SpanContextFromContext(params.ParentContext).TraceState(),
+	}
+
+	// Spans that aren't CloudEvent handling (no event type) use the default ratio
+	if eventType == "" {
+		return sdktrace.TraceIDRatioBased(s.policy.defaultRate).ShouldSample(params)
+	}
+	if eventID == "" {
+		eventID = params.TraceID.String()
+	}
+	if s.policy.Sampled(eventType, eventID) {
+		result.Decision = sdktrace.RecordAndSample
+	}
+	return result
+}
+
+// Description implements sdktrace.Sampler
+func (s *eventTypeSampler) Description() string {
+	return "EventTypeSampler"
+}
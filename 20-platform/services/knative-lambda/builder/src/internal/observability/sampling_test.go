@@ -0,0 +1,49 @@
+package observability
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseSamplingPolicy(t *testing.T) {
+	policy, err := ParseSamplingPolicy("dev.knative.apiserver.resource.update=0.1, custom.type=0", 0.5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cases := map[string]float64{
+		"dev.knative.apiserver.resource.update": 0.1,
+		"custom.type":                           0,
+		"unlisted.type":                         0.5,
+		"network.notifi.lambda.build.start":     1, // always fully traced
+	}
+	for eventType, want := range cases {
+		if got := policy.Rate(eventType); got != want {
+			t.Errorf("Rate(%q) = %v, want %v", eventType, got, want)
+		}
+	}
+
+	for _, spec := range []string{"missing-ratio", "a=2", "a=abc"} {
+		if _, err := ParseSamplingPolicy(spec, 1); err == nil {
+			t.Errorf("ParseSamplingPolicy(%q) should fail", spec)
+		}
+	}
+}
+
+func TestSampledRatio(t *testing.T) {
+	policy, _ := ParseSamplingPolicy("storm=0.1", 1)
+
+	sampled := 0
+	for i := 0; i < 10000; i++ {
+		if policy.Sampled("storm", fmt.Sprintf("event-%d", i)) {
+			sampled++
+		}
+	}
+	if sampled < 800 || sampled > 1200 {
+		t.Errorf("sampled %d of 10000 events, want ~1000", sampled)
+	}
+
+	if policy.Sampled("storm", "event-1") != policy.Sampled("storm", "event-1") {
+		t.Errorf("sampling must be deterministic per event ID")
+	}
+}
@@ -0,0 +1,56 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// =============================================================================
+// 🔭 TRACING
+// =============================================================================
+
+// ServiceName identifies the builder in traces
+const ServiceName = "knative-lambda-builder"
+
+// Tracer returns the builder's tracer (a no-op until InitTracing installs a provider)
+func Tracer() trace.Tracer {
+	return otel.Tracer(ServiceName)
+}
+
+// InitTracing installs the global tracer provider with the per-event-type sampler
+// 📝 NOTE: Spans are exported over OTLP/HTTP only when OTEL_EXPORTER_OTLP_ENDPOINT is set
+func InitTracing(ctx context.Context, policy *SamplingPolicy) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" {
+		log.Printf("Tracing disabled (OTEL_EXPORTER_OTLP_ENDPOINT not set)")
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(NewSampler(policy)),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(ServiceName),
+		)),
+	)
+	otel.SetTracerProvider(tp)
+
+	return tp.Shutdown, nil
+}
@@ -0,0 +1,300 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"knative-lambda-builder/internal/config"
+	"knative-lambda-builder/internal/history"
+	"knative-lambda-builder/internal/k8s"
+	"knative-lambda-builder/internal/tenants"
+)
+
+// =============================================================================
+// 🧹 ORPHANED RESOURCE RECONCILER
+// =============================================================================
+// Periodically compares the build history and tenant registry against the
+// live cluster and registry, and flags (or, outside dry-run, deletes) what
+// no longer belongs to anything:
+//   - parser Services with no recorded build
+//   - Triggers/RabbitmqSources whose parser Service is gone, or whose
+//     source can no longer connect (e.g. its queue was deleted)
+//   - image repositories with no registered tenant (only ever flagged:
+//     deleting a repository deletes its images)
+//
+// Each pass is timeboxed; a pass cut short reports Complete=false.
+// 📝 NOTE: Only resources labelled by the builder are considered
+
+// Actions taken on an orphan
+const (
+	ActionFlagged = "flagged" // Dry-run, or a kind we never delete automatically
+	ActionDeleted = "deleted"
+	ActionFailed  = "failed"
+)
+
+var (
+	serviceResource        = schema.GroupVersionResource{Group: "serving.knative.dev", Version: "v1", Resource: "services"}
+	triggerResource        = schema.GroupVersionResource{Group: "eventing.knative.dev", Version: "v1", Resource: "triggers"}
+	rabbitmqSourceResource = schema.GroupVersionResource{Group: "sources.knative.dev", Version: "v1alpha1", Resource: "rabbitmqsources"}
+)
+
+// Orphan is a resource that no longer belongs to a build or tenant
+type Orphan struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Reason    string `json:"reason"`
+	Action    string `json:"action"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Report is the outcome of a reconcile pass
+type Report struct {
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	DryRun     bool      `json:"dryRun"`
+	Complete   bool      `json:"complete"` // False when the pass ran out of time
+	Orphans    []Orphan  `json:"orphans"`
+	Errors     []string  `json:"errors,omitempty"`
+}
+
+// RepositoryLister lists tenant image repositories (implemented by build.Orchestrator)
+type RepositoryLister interface {
+	TenantRepositories(ctx context.Context) (map[string]string, error)
+}
+
+// Reconciler finds orphaned resources
+type Reconciler struct {
+	cfg          *config.Config
+	k8sClient    *k8s.Client
+	repositories RepositoryLister
+	tenants      tenants.Store
+	history      history.Store
+
+	mu   sync.Mutex
+	last *Report
+}
+
+// NewReconciler creates a new orphan reconciler
+func NewReconciler(cfg *config.Config, k8sClient *k8s.Client, repositories RepositoryLister,
+	tenantStore tenants.Store, buildHistory history.Store) *Reconciler {
+	return &Reconciler{
+		cfg:          cfg,
+		k8sClient:    k8sClient,
+		repositories: repositories,
+		tenants:      tenantStore,
+		history:      buildHistory,
+	}
+}
+
+// Start runs a reconcile pass every OrphanReconcileInterval until ctx is done
+// 📝 NOTE: A zero interval disables periodic passes (the API can still run one)
+func (r *Reconciler) Start(ctx context.Context) {
+	if r.cfg.OrphanReconcileInterval <= 0 {
+		log.Printf("Orphan reconciler disabled (interval %s)", r.cfg.OrphanReconcileInterval)
+		return
+	}
+
+	ticker := time.NewTicker(r.cfg.OrphanReconcileInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report := r.Run(ctx, r.cfg.OrphanReconcileDryRun)
+			log.Printf("🧹 Orphan reconcile: %d orphans (dryRun=%t, complete=%t)",
+				len(report.Orphans), report.DryRun, report.Complete)
+		}
+	}
+}
+
+// LastReport returns the report of the most recent pass (nil before the first)
+func (r *Reconciler) LastReport() *Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.last
+}
+
+// Run performs a single timeboxed reconcile pass
+func (r *Reconciler) Run(ctx context.Context, dryRun bool) *Report {
+	ctx, cancel := context.WithTimeout(ctx, r.cfg.OrphanReconcileTimeout)
+	defer cancel()
+
+	report := &Report{StartedAt: time.Now().UTC(), DryRun: dryRun, Orphans: []Orphan{}}
+	steps := []func(context.Context, *Report) error{
+		r.reconcileServices,
+		r.reconcileTriggers,
+		r.reconcileSources,
+		r.reconcileRepositories,
+	}
+	report.Complete = true
+	for _, step := range steps {
+		if err := step(ctx, report); err != nil {
+			report.Errors = append(report.Errors, err.Error())
+		}
+		if ctx.Err() != nil {
+			report.Complete = false
+			report.Errors = append(report.Errors, fmt.Sprintf("timebox of %s exceeded", r.cfg.OrphanReconcileTimeout))
+			break
+		}
+	}
+	report.FinishedAt = time.Now().UTC()
+
+	r.mu.Lock()
+	r.last = report
+	r.mu.Unlock()
+	return report
+}
+
+// parserSelector selects the resources the builder created for parsers
+var parserSelector = tenants.LabelThirdPartyId + "," + tenants.LabelParserId
+
+// reconcileServices flags parser Services with no recorded build
+func (r *Reconciler) reconcileServices(ctx context.Context, report *Report) error {
+	namespace := r.cfg.KubernetesNamespace
+	if r.cfg.TenantNamespaces {
+		namespace = "" // Every tenant's namespace
+	}
+	services, err := r.k8sClient.List(ctx, serviceResource, namespace, parserSelector)
+	if err != nil {
+		return err
+	}
+	for i := range services {
+		svc := &services[i]
+		labels := svc.GetLabels()
+		latest, err := history.Latest(ctx, r.history, labels[tenants.LabelThirdPartyId], labels[tenants.LabelParserId])
+		if err != nil {
+			return err
+		}
+		if latest == nil {
+			r.handle(ctx, report, svc, "no build recorded for this parser", true)
+		}
+	}
+	return nil
+}
+
+// reconcileTriggers flags Triggers whose subscriber Service is gone
+func (r *Reconciler) reconcileTriggers(ctx context.Context, report *Report) error {
+	triggers, err := r.k8sClient.List(ctx, triggerResource, "", parserSelector)
+	if err != nil {
+		return err
+	}
+	for i := range triggers {
+		trigger := &triggers[i]
+		gone, err := r.refGone(ctx, trigger, "spec", "subscriber", "ref")
+		if err != nil {
+			return err
+		}
+		if gone {
+			r.handle(ctx, report, trigger, "subscriber Service no longer exists", true)
+		}
+	}
+	return nil
+}
+
+// reconcileSources flags RabbitmqSources whose sink is gone or that can't connect
+func (r *Reconciler) reconcileSources(ctx context.Context, report *Report) error {
+	sources, err := r.k8sClient.List(ctx, rabbitmqSourceResource, "", parserSelector)
+	if apierrors.IsNotFound(err) {
+		return nil // RabbitMQ source CRD not installed
+	}
+	if err != nil {
+		return err
+	}
+	for i := range sources {
+		source := &sources[i]
+		gone, err := r.refGone(ctx, source, "spec", "sink", "ref")
+		if err != nil {
+			return err
+		}
+		if gone {
+			r.handle(ctx, report, source, "sink Service no longer exists", true)
+			continue
+		}
+		// 🐰 A deleted queue/exchange surfaces as a False connection condition
+		for _, conditionType := range []string{"Deployed", "SinkProvided"} {
+			if c, found := k8s.FindCondition(source, conditionType); found && c.Status == "False" {
+				r.handle(ctx, report, source, fmt.Sprintf("%s=False (%s): %s", c.Type, c.Reason, c.Message), false)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// reconcileRepositories flags tenant repositories with no registered tenant
+func (r *Reconciler) reconcileRepositories(ctx context.Context, report *Report) error {
+	repositories, err := r.repositories.TenantRepositories(ctx)
+	if err != nil {
+		return err
+	}
+	for thirdPartyId, name := range repositories {
+		_, err := r.tenants.Get(ctx, thirdPartyId)
+		if errors.Is(err, tenants.ErrNotFound) {
+			report.Orphans = append(report.Orphans, Orphan{
+				Kind:   "Repository",
+				Name:   name,
+				Reason: "no tenant registered for " + thirdPartyId,
+				Action: ActionFlagged,
+			})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refGone reports whether the object referenced at path (a Knative KReference) no longer exists
+func (r *Reconciler) refGone(ctx context.Context, obj *unstructured.Unstructured, path ...string) (bool, error) {
+	ref, found, _ := unstructured.NestedMap(obj.Object, path...)
+	if !found {
+		return false, nil
+	}
+
+	target := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	target.SetAPIVersion(fmt.Sprint(ref["apiVersion"]))
+	target.SetKind(fmt.Sprint(ref["kind"]))
+	target.SetName(fmt.Sprint(ref["name"]))
+	namespace, _ := ref["namespace"].(string)
+	if namespace == "" {
+		namespace = obj.GetNamespace()
+	}
+	target.SetNamespace(namespace)
+
+	_, err := r.k8sClient.Get(ctx, target)
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	}
+	return false, err
+}
+
+// handle records an orphan and deletes it unless in dry-run (or not deletable)
+func (r *Reconciler) handle(ctx context.Context, report *Report, obj *unstructured.Unstructured, reason string, deletable bool) {
+	orphan := Orphan{
+		Kind:      obj.GetKind(),
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+		Reason:    reason,
+		Action:    ActionFlagged,
+	}
+	if deletable && !report.DryRun {
+		if err := r.k8sClient.Delete(ctx, obj); err != nil {
+			orphan.Action, orphan.Error = ActionFailed, err.Error()
+		} else {
+			orphan.Action = ActionDeleted
+			log.Printf("🧹 Deleted orphaned %s %s/%s: %s", orphan.Kind, orphan.Namespace, orphan.Name, reason)
+		}
+	}
+	report.Orphans = append(report.Orphans, orphan)
+}
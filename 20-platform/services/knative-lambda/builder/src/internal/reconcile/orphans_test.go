@@ -0,0 +1,87 @@
+package reconcile
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"knative-lambda-builder/internal/config"
+	"knative-lambda-builder/internal/history"
+	"knative-lambda-builder/internal/k8s"
+	"knative-lambda-builder/internal/tenants"
+)
+
+type fakeRepositories map[string]string
+
+func (f fakeRepositories) TenantRepositories(ctx context.Context) (map[string]string, error) {
+	return f, nil
+}
+
+type fakeTenants map[string]bool
+
+func (f fakeTenants) Get(ctx context.Context, thirdPartyId string) (*tenants.Tenant, error) {
+	if !f[thirdPartyId] {
+		return nil, tenants.ErrNotFound
+	}
+	return &tenants.Tenant{ThirdPartyId: thirdPartyId}, nil
+}
+func (f fakeTenants) List(ctx context.Context) ([]tenants.Tenant, error)   { return nil, nil }
+func (f fakeTenants) Put(ctx context.Context, tenant tenants.Tenant) error { return nil }
+
+func parserService(thirdPartyId, parserId string) *unstructured.Unstructured {
+	svc := &unstructured.Unstructured{}
+	svc.SetAPIVersion("serving.knative.dev/v1")
+	svc.SetKind("Service")
+	svc.SetNamespace("knative-lambda")
+	svc.SetName("lambda-" + thirdPartyId + "-" + parserId)
+	svc.SetLabels(map[string]string{
+		tenants.LabelThirdPartyId: thirdPartyId,
+		tenants.LabelParserId:     parserId,
+	})
+	return svc
+}
+
+func TestRunFlagsOrphans(t *testing.T) {
+	ctx := context.Background()
+	dynamic := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			serviceResource:        "ServiceList",
+			triggerResource:        "TriggerList",
+			rabbitmqSourceResource: "RabbitmqSourceList",
+		},
+		parserService("acme", "built"), parserService("acme", "orphan"))
+
+	buildHistory := history.NewMemoryStore()
+	buildHistory.Record(ctx, "acme", "built", history.StatusPassing, "")
+
+	cfg := &config.Config{KubernetesNamespace: "knative-lambda", OrphanReconcileTimeout: time.Minute}
+	r := NewReconciler(cfg, &k8s.Client{Dynamic: dynamic},
+		fakeRepositories{"acme": "knative-lambdas/acme", "gone": "knative-lambdas/gone"},
+		fakeTenants{"acme": true}, buildHistory)
+
+	report := r.Run(ctx, true)
+	if !report.Complete || len(report.Errors) != 0 {
+		t.Fatalf("unexpected errors: %v", report.Errors)
+	}
+
+	flagged := map[string]string{}
+	for _, o := range report.Orphans {
+		flagged[o.Name] = o.Action
+	}
+	if len(flagged) != 2 || flagged["lambda-acme-orphan"] != ActionFlagged || flagged["knative-lambdas/gone"] != ActionFlagged {
+		t.Errorf("unexpected orphans: %+v", report.Orphans)
+	}
+
+	// Outside dry-run, the orphaned Service is deleted
+	if report := r.Run(ctx, false); report.Orphans[0].Action != ActionDeleted {
+		t.Errorf("expected the orphaned Service to be deleted, got %+v", report.Orphans[0])
+	}
+	if r.LastReport() == nil || r.LastReport().DryRun {
+		t.Errorf("LastReport should return the latest pass")
+	}
+}
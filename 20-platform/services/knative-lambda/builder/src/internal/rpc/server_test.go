@@ -0,0 +1,238 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"knative-lambda-builder/internal/auth"
+	"knative-lambda-builder/internal/build"
+	"knative-lambda-builder/internal/events"
+	"knative-lambda-builder/internal/history"
+	"knative-lambda-builder/internal/rpc/buildv1"
+	"knative-lambda-builder/internal/types"
+)
+
+// fakeSubmitter accepts builds, or fails them with err; remembers the last one
+type fakeSubmitter struct {
+	err       error
+	submitted types.BuildEvent
+}
+
+func (s *fakeSubmitter) SubmitBuild(ctx context.Context, be types.BuildEvent) (types.BuildEvent, error) {
+	s.submitted = be
+	be.ID = "b1"
+	return be, s.err
+}
+
+func (s *fakeSubmitter) DryRun(ctx context.Context, be types.BuildEvent) (*types.DryRunResult, error) {
+	s.submitted = be
+	return &types.DryRunResult{ThirdPartyId: be.ThirdPartyId, ParserId: be.ParserId, ImageTag: "p1-v2",
+		DeployMode: "knative", Manifests: "kind: Job", Rejected: []types.RejectedObject{{Kind: "Service", Name: "p1", Error: "denied"}}}, s.err
+}
+
+func TestSubmitBuildCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want codes.Code
+	}{
+		{name: "accepted", want: codes.OK},
+		{name: "invalid", err: &build.InvalidResourcesError{Reason: "cpu request 8 is above the maximum 4"}, want: codes.InvalidArgument},
+		{name: "queue full", err: &events.QueueFullError{Size: 100, RetryAfter: 30 * time.Second}, want: codes.Unavailable},
+		{name: "rate limited", err: &events.RateLimitedError{ThirdPartyId: "acme", RetryAfter: time.Minute}, want: codes.ResourceExhausted},
+		{name: "failed", err: errors.New("etcd is down"), want: codes.Internal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewServer(&fakeSubmitter{err: tt.err}, history.NewMemoryStore(), history.NewMemoryStore())
+			got, err := server.SubmitBuild(context.Background(), &buildv1.SubmitBuildRequest{ThirdPartyId: "acme", ParserId: "p1"})
+			if code := status.Code(err); code != tt.want {
+				t.Fatalf("SubmitBuild() code = %s, want %s (%v)", code, tt.want, err)
+			}
+			if err == nil && (got.GetId() != "b1" || got.GetStatus() != buildv1.BuildStatus_BUILD_STATUS_ACCEPTED) {
+				t.Errorf("SubmitBuild() = %v, want b1 accepted", got)
+			}
+		})
+	}
+}
+
+func TestSubmitBuildAuthorization(t *testing.T) {
+	server := NewServer(&fakeSubmitter{}, history.NewMemoryStore(), history.NewMemoryStore())
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "ci", Tenants: []string{"acme"}})
+
+	if _, err := server.SubmitBuild(ctx, &buildv1.SubmitBuildRequest{ThirdPartyId: "acme", ParserId: "p1"}); err != nil {
+		t.Errorf("SubmitBuild(acme) = %v, want accepted", err)
+	}
+	_, err := server.SubmitBuild(ctx, &buildv1.SubmitBuildRequest{ThirdPartyId: "globex", ParserId: "p1"})
+	if code := status.Code(err); code != codes.PermissionDenied {
+		t.Errorf("SubmitBuild(globex) code = %s, want PermissionDenied", code)
+	}
+	_, err = server.SubmitBuild(ctx, &buildv1.SubmitBuildRequest{ThirdPartyId: "acme"})
+	if code := status.Code(err); code != codes.InvalidArgument {
+		t.Errorf("SubmitBuild(no parser) code = %s, want InvalidArgument", code)
+	}
+}
+
+// fakeWatchStream collects the builds sent and calls onSend after each
+type fakeWatchStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	sent   []*buildv1.Build
+	onSend func()
+}
+
+func (s *fakeWatchStream) Context() context.Context { return s.ctx }
+
+func (s *fakeWatchStream) Send(b *buildv1.Build) error {
+	s.sent = append(s.sent, b)
+	s.onSend()
+	return nil
+}
+
+func TestWatchBuild(t *testing.T) {
+	watchInterval = time.Millisecond
+	t.Cleanup(func() { watchInterval = 2 * time.Second })
+
+	for _, final := range []string{history.StatusPassing, history.StatusFailing} {
+		t.Run(final, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			store := history.NewMemoryStore()
+			store.Record(ctx, "acme", "p1", history.StatusBuilding, "")
+			history.UpdateLatest(ctx, store, "acme", "p1", func(entry *history.Entry) { entry.BuildId = "b1" })
+
+			// The build goes on after each state sent: testing, then final
+			next := []string{history.StatusTesting, final}
+			stream := &fakeWatchStream{ctx: ctx}
+			stream.onSend = func() {
+				if len(next) > 0 {
+					time.Sleep(time.Millisecond) // A later UpdatedAt
+					store.Record(ctx, "acme", "p1", next[0], "")
+					next = next[1:]
+				}
+			}
+
+			server := NewServer(&fakeSubmitter{}, store, store)
+			if err := server.WatchBuild(&buildv1.WatchBuildRequest{Id: "b1"}, stream); err != nil {
+				t.Fatalf("WatchBuild() = %v, want it to end with the build", err)
+			}
+			if len(stream.sent) != 3 || stream.sent[2].GetStatus() != buildStatuses[final] {
+				t.Errorf("WatchBuild() sent %v, want building, testing, %s", stream.sent, final)
+			}
+		})
+	}
+}
+
+func TestWatchBuildNotFound(t *testing.T) {
+	watchInterval, watchStartTimeout = time.Millisecond, 0
+	t.Cleanup(func() { watchInterval, watchStartTimeout = 2*time.Second, time.Minute })
+
+	ctx := context.Background()
+	store := history.NewMemoryStore()
+	store.Record(ctx, "globex", "p1", history.StatusBuilding, "")
+	history.UpdateLatest(ctx, store, "globex", "p1", func(entry *history.Entry) { entry.BuildId = "b1" })
+	server := NewServer(&fakeSubmitter{}, store, store)
+
+	// Other tenants' builds look missing
+	ctx = auth.WithPrincipal(ctx, &auth.Principal{Subject: "ci", Tenants: []string{"acme"}})
+	stream := &fakeWatchStream{ctx: ctx, onSend: func() {}}
+	err := server.WatchBuild(&buildv1.WatchBuildRequest{Id: "b1"}, stream)
+	if code := status.Code(err); code != codes.NotFound || len(stream.sent) != 0 {
+		t.Errorf("WatchBuild(globex's b1) = %v after %d sends, want NotFound", err, len(stream.sent))
+	}
+	if _, err := server.GetBuild(ctx, &buildv1.GetBuildRequest{Id: "b1"}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetBuild(globex's b1) = %v, want NotFound", err)
+	}
+}
+
+func TestSubmitBuildUnsafeIDs(t *testing.T) {
+	server := NewServer(&fakeSubmitter{}, history.NewMemoryStore(), history.NewMemoryStore())
+	for _, req := range []*buildv1.SubmitBuildRequest{
+		{ThirdPartyId: "acme", ParserId: ".."},
+		{ThirdPartyId: "acme", ParserId: "../p1"},
+		{ThirdPartyId: "acme/globex", ParserId: "p1"},
+		{ThirdPartyId: "ACME", ParserId: "p1"},
+	} {
+		_, err := server.SubmitBuild(context.Background(), req)
+		if code := status.Code(err); code != codes.InvalidArgument {
+			t.Errorf("SubmitBuild(%s/%s) code = %s, want InvalidArgument", req.GetThirdPartyId(), req.GetParserId(), code)
+		}
+	}
+}
+
+func TestSubmitBuildFields(t *testing.T) {
+	submitter := &fakeSubmitter{}
+	server := NewServer(submitter, history.NewMemoryStore(), history.NewMemoryStore())
+	req := &buildv1.SubmitBuildRequest{
+		ThirdPartyId:   "acme",
+		ParserId:       "p1",
+		Id:             "b1",
+		Rebuild:        true,
+		Priority:       "high",
+		Resources:      &buildv1.BuildResources{Requests: map[string]string{"memory": "3Gi"}, Limits: map[string]string{"cpu": "2"}},
+		Region:         "eu-west-1",
+		PushRoleArn:    "arn:aws:iam::123456789012:role/push",
+		Backend:        "buildkit",
+		Runtime:        "python",
+		CallbackUrl:    "https://ci.example.com/builds",
+		DeployStrategy: "canary",
+		BuildArgs:      map[string]string{"NODE_ENV": "production"},
+		Env:            map[string]string{"LOG_LEVEL": "debug"},
+		Dependencies:   map[string]string{"lodash": "^4.17.21"},
+		Source:         &buildv1.BuildSource{Git: &buildv1.GitSource{Url: "https://github.com/acme/parsers", Ref: "main", Path: "p1"}},
+		SourceSha256:   "abc",
+	}
+	if _, err := server.SubmitBuild(context.Background(), req); err != nil {
+		t.Fatalf("SubmitBuild() = %v", err)
+	}
+	want := types.BuildEvent{
+		ThirdPartyId:   "acme",
+		ParserId:       "p1",
+		ID:             "b1",
+		Rebuild:        true,
+		Priority:       "high",
+		Resources:      &types.BuildResources{Requests: map[string]string{"memory": "3Gi"}, Limits: map[string]string{"cpu": "2"}},
+		Region:         "eu-west-1",
+		PushRoleArn:    "arn:aws:iam::123456789012:role/push",
+		Backend:        "buildkit",
+		Runtime:        "python",
+		CallbackURL:    "https://ci.example.com/builds",
+		DeployStrategy: "canary",
+		BuildArgs:      map[string]string{"NODE_ENV": "production"},
+		Env:            map[string]string{"LOG_LEVEL": "debug"},
+		Dependencies:   map[string]string{"lodash": "^4.17.21"},
+		Source:         &types.BuildSource{Git: &types.GitSource{URL: "https://github.com/acme/parsers", Ref: "main", Path: "p1"}},
+		SourceSHA256:   "abc",
+	}
+	if !reflect.DeepEqual(submitter.submitted, want) {
+		t.Errorf("SubmitBuild() submitted %+v, want %+v", submitter.submitted, want)
+	}
+
+	// 🧾 Dry runs return what they rendered
+	got, err := server.SubmitBuild(context.Background(), &buildv1.SubmitBuildRequest{ThirdPartyId: "acme", ParserId: "p1", DryRun: true})
+	if err != nil {
+		t.Fatalf("SubmitBuild(dry_run) = %v", err)
+	}
+	if dryRun := got.GetDryRun(); dryRun.GetImageTag() != "p1-v2" || dryRun.GetManifests() != "kind: Job" ||
+		len(dryRun.GetRejected()) != 1 || dryRun.GetRejected()[0].GetKind() != "Service" {
+		t.Errorf("SubmitBuild(dry_run) = %v, want the rendered manifests", got)
+	}
+
+	for _, req := range []*buildv1.SubmitBuildRequest{
+		{ThirdPartyId: "acme", ParserId: "p1", Priority: "urgent"},
+		{ThirdPartyId: "acme", ParserId: "p1", Backend: "docker"},
+		{ThirdPartyId: "acme", ParserId: "p1", Runtime: "cobol"},
+		{ThirdPartyId: "acme", ParserId: "p1", DeployStrategy: "big-bang"},
+	} {
+		if _, err := server.SubmitBuild(context.Background(), req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("SubmitBuild(%v) = %v, want InvalidArgument", req, err)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"knative-lambda-builder/internal/k8s"
+	"knative-lambda-builder/internal/observability"
+	"knative-lambda-builder/internal/tenants"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🔵🟢 BLUE-GREEN DEPLOYS
+// =============================================================================
+// With the blue-green strategy a redeploy brings the new Knative revision
+// (green) up next to the one serving (blue) without giving it any traffic,
+// reachable through its "green" traffic tag. Once it is Ready and answers
+// BLUE_GREEN_SMOKE_PATH with a 2xx, all traffic flips to it and the blue
+// revision is deleted. Otherwise blue keeps all the traffic and the deploy fails.
+// 📝 NOTE: Runs inline, the deploy waits up to BLUE_GREEN_TIMEOUT
+
+// StrategyPolicy resolves the deploy strategy of tenants (implemented by tenants.DeployStrategyPolicy)
+type StrategyPolicy interface {
+	DeployStrategy(ctx context.Context, thirdPartyId string) (string, error)
+}
+
+// greenTag is the traffic tag the new revision is reachable through
+const greenTag = "green"
+
+// BlueGreenError is returned when the new revision of a blue-green deploy
+// never became Ready or failed its smoke test; the old one keeps serving
+type BlueGreenError struct {
+	Service  string // namespace/name of the Knative Service
+	Revision string // The new revision, if it was created
+	Reason   string
+}
+
+func (e *BlueGreenError) Error() string {
+	return fmt.Sprintf("blue-green deploy of %s aborted (revision %q): %s", e.Service, e.Revision, e.Reason)
+}
+
+// WithStrategyPolicy lets tenants pick their deploy strategy
+func (s *ParserService) WithStrategyPolicy(policy StrategyPolicy) *ParserService {
+	s.strategies = policy
+	return s
+}
+
+// deployStrategy resolves how a build's redeploy shifts traffic: the build's
+// own strategy, else its tenant's, else DEPLOY_STRATEGY
+func (s *ParserService) deployStrategy(ctx context.Context, be types.BuildEvent) (string, error) {
+	if be.Rollback {
+		return tenants.DeployStrategyRolling, nil // Rollbacks restore a known-good revision right away
+	}
+	strategy := be.DeployStrategy
+	if strategy == "" && s.strategies != nil {
+		var err error
+		if strategy, err = s.strategies.DeployStrategy(ctx, be.ThirdPartyId); err != nil {
+			return "", fmt.Errorf("failed to resolve the deploy strategy: %w", err)
+		}
+	}
+	if strategy == "" {
+		strategy = s.cfg.DeployStrategy
+	}
+	if _, err := tenants.ParseDeployStrategy(strategy); err != nil {
+		return "", err
+	}
+
+	switch {
+	case strategy == "" && len(s.canarySteps) > 0:
+		return tenants.DeployStrategyCanary, nil
+	case strategy == "":
+		return tenants.DeployStrategyRolling, nil
+	case strategy == tenants.DeployStrategyCanary && len(s.canarySteps) == 0:
+		log.Printf("WARNING: Canary requested for %s/%s without CANARY_STEPS, switching traffic at once",
+			be.ThirdPartyId, be.ParserId)
+		return tenants.DeployStrategyRolling, nil
+	}
+	return strategy, nil
+}
+
+// stableRevision returns the revision serving a live Knative Service: the
+// stable one of a canary in progress, else the latest Ready one ("" if none)
+func stableRevision(live *unstructured.Unstructured) string {
+	if stable := live.GetAnnotations()[AnnotationCanaryStable]; stable != "" {
+		return stable
+	}
+	ready, _, _ := unstructured.NestedString(live.Object, "status", "latestReadyRevisionName")
+	return ready
+}
+
+// deployBlueGreen applies the rendered Knative Service of a redeploy blue-green
+// 📋 STEPS:
+//  1. Apply it with all traffic pinned to the serving (blue) revision and the
+//     new (green) one tagged
+//  2. Wait for green to be Ready, then smoke test it through its tag
+//  3. Flip all traffic to green and delete blue (or back to blue on failure)
+func (s *ParserService) deployBlueGreen(ctx context.Context, svc *unstructured.Unstructured) error {
+	if _, found, _ := unstructured.NestedSlice(svc.Object, "spec", "traffic"); found || svc.GetKind() != "Service" {
+		_, err := s.k8sClient.Apply(ctx, svc) // The template routes traffic itself
+		return err
+	}
+	live, err := s.k8sClient.Get(ctx, svc)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	blue := ""
+	if live != nil {
+		blue = stableRevision(live)
+	}
+	if blue == "" {
+		// First deploy (or nothing Ready): nothing to protect
+		_, err := s.k8sClient.Apply(ctx, svc)
+		return err
+	}
+	name := svc.GetNamespace() + "/" + svc.GetName()
+
+	// =========================================================================
+	// 📍 STEP 1: GREEN NEXT TO BLUE
+	// =========================================================================
+	traffic := []interface{}{
+		map[string]interface{}{"revisionName": blue, "latestRevision": false, "percent": int64(100)},
+		map[string]interface{}{"latestRevision": true, "percent": int64(0), "tag": greenTag},
+	}
+	if err := unstructured.SetNestedSlice(svc.Object, traffic, "spec", "traffic"); err != nil {
+		return fmt.Errorf("failed to set blue-green traffic: %w", err)
+	}
+	if _, err := s.k8sClient.Apply(ctx, svc); err != nil {
+		return err
+	}
+	log.Printf("🔵 Blue-green deploy of %s: revision %s keeps the traffic while the new one starts", name, blue)
+
+	// =========================================================================
+	// 📍 STEP 2: READINESS AND SMOKE TEST
+	// =========================================================================
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.BlueGreenTimeout)
+	defer cancel()
+	green, err := s.waitForGreen(ctx, svc, blue)
+	if err == nil {
+		url := fmt.Sprintf("http://%s-%s.%s.svc.cluster.local%s", greenTag, svc.GetName(), svc.GetNamespace(), s.cfg.BlueGreenSmokePath)
+		err = smokeTest(ctx, url)
+	}
+	if err != nil {
+		observability.BlueGreenDeploys.WithLabelValues("aborted").Inc()
+		if restoreErr := s.routeAll(context.WithoutCancel(ctx), svc, blue); restoreErr != nil {
+			log.Printf("ERROR: Failed to route %s back to revision %s: %v", name, blue, restoreErr)
+		}
+		return &BlueGreenError{Service: name, Revision: green, Reason: err.Error()}
+	}
+
+	// =========================================================================
+	// 📍 STEP 3: FLIP AND RETIRE BLUE
+	// =========================================================================
+	if err := s.routeAll(ctx, svc, ""); err != nil {
+		return err
+	}
+	observability.BlueGreenDeploys.WithLabelValues("flipped").Inc()
+	log.Printf("🟢 Blue-green deploy of %s: revision %s serves all traffic", name, green)
+	if green == blue {
+		return nil // Nothing changed, no new revision
+	}
+	revision := &unstructured.Unstructured{}
+	revision.SetAPIVersion("serving.knative.dev/v1")
+	revision.SetKind("Revision")
+	revision.SetNamespace(svc.GetNamespace())
+	revision.SetName(blue)
+	// 📝 NOTE: Best effort; Knative's revision GC removes it eventually anyway
+	if err := s.k8sClient.Delete(ctx, revision); err != nil {
+		log.Printf("WARNING: Failed to retire revision %s of %s: %v", blue, name, err)
+	}
+	return nil
+}
+
+// waitForGreen waits until the latest revision of a Knative Service is Ready
+// and returns its name (blue when the deploy created no new revision)
+func (s *ParserService) waitForGreen(ctx context.Context, svc *unstructured.Unstructured, blue string) (string, error) {
+	var green string
+	var failed error
+	err := wait.PollUntilContextCancel(ctx, 2*time.Second, true, func(ctx context.Context) (bool, error) {
+		live, err := s.k8sClient.Get(ctx, svc)
+		if err != nil {
+			log.Printf("WARNING: Failed to check %s/%s: %v", svc.GetNamespace(), svc.GetName(), err)
+			return false, nil
+		}
+		observed, _, _ := unstructured.NestedInt64(live.Object, "status", "observedGeneration")
+		if observed < live.GetGeneration() {
+			return false, nil
+		}
+		green, _, _ = unstructured.NestedString(live.Object, "status", "latestCreatedRevisionName")
+		ready, _, _ := unstructured.NestedString(live.Object, "status", "latestReadyRevisionName")
+		if green != "" && green == ready {
+			return true, nil
+		}
+		if condition, _ := k8s.FindCondition(live, "ConfigurationsReady"); condition.Status == "False" {
+			failed = fmt.Errorf("revision not ready (reason=%s): %s", condition.Reason, condition.Message)
+			return false, failed
+		}
+		return false, nil
+	})
+	if failed != nil {
+		return green, failed
+	}
+	if err != nil {
+		return green, fmt.Errorf("revision not ready in %s", s.cfg.BlueGreenTimeout)
+	}
+	if green == blue {
+		log.Printf("Blue-green deploy of %s/%s created no new revision", svc.GetNamespace(), svc.GetName())
+	}
+	return green, nil
+}
+
+// smokeTest GETs url until it answers 2xx (up to 3 tries)
+func smokeTest(ctx context.Context, url string) error {
+	client := &http.Client{Timeout: 10 * time.Second}
+	var lastErr error
+	for attempt := 0; attempt < 3; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return lastErr
+			case <-time.After(2 * time.Second):
+			}
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return fmt.Errorf("invalid smoke test URL: %w", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("smoke test %s failed: %w", url, err)
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		lastErr = fmt.Errorf("smoke test %s answered %d", url, resp.StatusCode)
+	}
+	return lastErr
+}
+
+// routeAll sends all traffic of a Knative Service to one revision ("" = the latest)
+func (s *ParserService) routeAll(ctx context.Context, svc *unstructured.Unstructured, revision string) error {
+	return wait.ExponentialBackoffWithContext(ctx, wait.Backoff{Duration: time.Second, Factor: 2, Steps: 4}, func(ctx context.Context) (bool, error) {
+		live, err := s.k8sClient.Get(ctx, svc)
+		if err != nil {
+			return false, err
+		}
+		if err := endCanary(live, revision); err != nil {
+			return false, err
+		}
+		if _, err := s.k8sClient.Update(ctx, live); err != nil {
+			if apierrors.IsConflict(err) {
+				return false, nil // Changed meanwhile, try again
+			}
+			return false, err
+		}
+		return true, nil
+	})
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"knative-lambda-builder/internal/config"
+	"knative-lambda-builder/internal/tenants"
+	"knative-lambda-builder/internal/types"
+)
+
+type fixedStrategy string
+
+func (f fixedStrategy) DeployStrategy(ctx context.Context, thirdPartyId string) (string, error) {
+	return string(f), nil
+}
+
+func TestDeployStrategy(t *testing.T) {
+	ctx := context.Background()
+	s := &ParserService{cfg: &config.Config{}}
+	be := types.BuildEvent{ThirdPartyId: "acme", ParserId: "p1"}
+
+	// Nothing set: canary with CANARY_STEPS, rolling without
+	if got, _ := s.deployStrategy(ctx, be); got != tenants.DeployStrategyRolling {
+		t.Errorf("default strategy = %q, want rolling", got)
+	}
+	s.canarySteps = []int{10, 50}
+	if got, _ := s.deployStrategy(ctx, be); got != tenants.DeployStrategyCanary {
+		t.Errorf("default strategy with CANARY_STEPS = %q, want canary", got)
+	}
+
+	// The event wins over the tenant, which wins over DEPLOY_STRATEGY
+	s.cfg.DeployStrategy = tenants.DeployStrategyRolling
+	s.strategies = fixedStrategy(tenants.DeployStrategyBlueGreen)
+	if got, _ := s.deployStrategy(ctx, be); got != tenants.DeployStrategyBlueGreen {
+		t.Errorf("tenant strategy = %q, want blue-green", got)
+	}
+	be.DeployStrategy = tenants.DeployStrategyCanary
+	if got, _ := s.deployStrategy(ctx, be); got != tenants.DeployStrategyCanary {
+		t.Errorf("event strategy = %q, want canary", got)
+	}
+
+	// Rollbacks always switch at once; unknown strategies are refused
+	be.Rollback = true
+	if got, _ := s.deployStrategy(ctx, be); got != tenants.DeployStrategyRolling {
+		t.Errorf("rollback strategy = %q, want rolling", got)
+	}
+	be.Rollback, be.DeployStrategy = false, "big-bang"
+	if _, err := s.deployStrategy(ctx, be); err == nil {
+		t.Error("unknown strategy accepted")
+	}
+}
+
+func TestSmokeTest(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer healthy.Close()
+	if err := smokeTest(context.Background(), healthy.URL); err != nil {
+		t.Errorf("smoke test of a healthy revision failed: %v", err)
+	}
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer broken.Close()
+	if err := smokeTest(context.Background(), broken.URL); err == nil {
+		t.Error("smoke test of a broken revision passed")
+	}
+}
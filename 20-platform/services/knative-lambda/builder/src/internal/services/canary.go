@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"knative-lambda-builder/internal/config"
+	"knative-lambda-builder/internal/k8s"
+	"knative-lambda-builder/internal/observability"
+	"knative-lambda-builder/internal/tenants"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🐤 CANARY ROLLOUTS
+// =============================================================================
+// With CANARY_STEPS set (e.g. "10,50"), redeploying a parser that already runs
+// doesn't switch all its traffic at once: the new Knative revision gets the
+// first step's share, the revision that served until now (the stable one) the
+// rest. A controller loop moves to the next step every CANARY_STEP_INTERVAL
+// once the new revision is Ready, and sends it everything after the last step.
+// A new revision that fails to become Ready aborts the rollout: all traffic
+// goes back to the stable revision.
+//
+// The rollout's state lives in annotations on the Knative Service, so it
+// survives builder restarts.
+// 📝 NOTE: Used by the canary deploy strategy (the default when CANARY_STEPS
+// is set); first deploys, rollbacks and fallback mode deploy straight away
+
+// Annotations tracking a canary rollout on the Knative Service
+const (
+	AnnotationCanaryStable    = "knative-lambda.notifi.network/canary-stable-revision"
+	AnnotationCanaryStep      = "knative-lambda.notifi.network/canary-step"       // Index into CANARY_STEPS
+	AnnotationCanarySteppedAt = "knative-lambda.notifi.network/canary-stepped-at" // RFC3339
+)
+
+// canaryPollInterval is how often the controller looks at rollouts in progress
+const canaryPollInterval = 15 * time.Second
+
+var knativeServiceResource = schema.GroupVersionResource{Group: "serving.knative.dev", Version: "v1", Resource: "services"}
+
+// ParseCanarySteps parses CANARY_STEPS: increasing traffic percentages, each in [1, 99]
+func ParseCanarySteps(value string) ([]int, error) {
+	var steps []int
+	for _, raw := range strings.Split(value, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		percent, err := strconv.Atoi(strings.TrimSuffix(raw, "%"))
+		if err != nil || percent < 1 || percent > 99 {
+			return nil, fmt.Errorf("invalid canary step %q: want a percentage between 1 and 99", raw)
+		}
+		if len(steps) > 0 && percent <= steps[len(steps)-1] {
+			return nil, fmt.Errorf("canary steps must increase: %d after %d", percent, steps[len(steps)-1])
+		}
+		steps = append(steps, percent)
+	}
+	return steps, nil
+}
+
+// WithCanarySteps makes redeploys of running parsers go through traffic steps
+func (s *ParserService) WithCanarySteps(steps []int) *ParserService {
+	s.canarySteps = steps
+	return s
+}
+
+// canaryTraffic returns the traffic block giving percent to the latest revision
+// and the rest to the stable one
+func canaryTraffic(stable string, percent int) []interface{} {
+	if percent >= 100 {
+		return []interface{}{
+			map[string]interface{}{"latestRevision": true, "percent": int64(100)},
+		}
+	}
+	return []interface{}{
+		map[string]interface{}{"revisionName": stable, "latestRevision": false, "percent": int64(100 - percent)},
+		map[string]interface{}{"latestRevision": true, "percent": int64(percent)},
+	}
+}
+
+// setCanaryStep routes a step's share of traffic to the latest revision and records the step
+func (s *ParserService) setCanaryStep(svc *unstructured.Unstructured, stable string, step int) error {
+	if err := unstructured.SetNestedSlice(svc.Object, canaryTraffic(stable, s.canarySteps[step]), "spec", "traffic"); err != nil {
+		return fmt.Errorf("failed to set canary traffic: %w", err)
+	}
+	annotations := svc.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[AnnotationCanaryStable] = stable
+	annotations[AnnotationCanaryStep] = strconv.Itoa(step)
+	annotations[AnnotationCanarySteppedAt] = time.Now().UTC().Format(time.RFC3339)
+	svc.SetAnnotations(annotations)
+	return nil
+}
+
+// endCanary routes all traffic to one revision ("" = the latest) and forgets the rollout
+func endCanary(svc *unstructured.Unstructured, revision string) error {
+	traffic := canaryTraffic("", 100)
+	if revision != "" {
+		traffic = []interface{}{
+			map[string]interface{}{"revisionName": revision, "latestRevision": false, "percent": int64(100)},
+		}
+	}
+	if err := unstructured.SetNestedSlice(svc.Object, traffic, "spec", "traffic"); err != nil {
+		return fmt.Errorf("failed to set traffic: %w", err)
+	}
+	annotations := svc.GetAnnotations()
+	delete(annotations, AnnotationCanaryStable)
+	delete(annotations, AnnotationCanaryStep)
+	delete(annotations, AnnotationCanarySteppedAt)
+	svc.SetAnnotations(annotations)
+	return nil
+}
+
+// startCanary turns the rendered Knative Service of a redeploy into the first
+// step of a canary rollout
+// 📝 NOTE: Best effort; when the live Service can't be read the redeploy
+// switches all traffic at once, like without CANARY_STEPS
+func (s *ParserService) startCanary(ctx context.Context, svc *unstructured.Unstructured, be types.BuildEvent) {
+	if len(s.canarySteps) == 0 || svc.GetKind() != "Service" {
+		return
+	}
+	if _, found, _ := unstructured.NestedSlice(svc.Object, "spec", "traffic"); found {
+		return // The template routes traffic itself
+	}
+
+	live, err := s.k8sClient.Get(ctx, svc)
+	if apierrors.IsNotFound(err) {
+		return // First deploy: nothing to protect
+	}
+	if err != nil {
+		log.Printf("WARNING: Canary skipped for %s/%s: %v", be.ThirdPartyId, be.ParserId, err)
+		return
+	}
+	// 🎯 WHY: Redeploying during a rollout keeps the revision that was stable
+	// before it: the interrupted canary never proved itself
+	stable := stableRevision(live)
+	if stable == "" {
+		return // No Ready revision to fall back to
+	}
+
+	if err := s.setCanaryStep(svc, stable, 0); err != nil {
+		log.Printf("WARNING: Canary skipped for %s/%s: %v", be.ThirdPartyId, be.ParserId, err)
+		return
+	}
+	observability.CanarySteps.WithLabelValues("started").Inc()
+	log.Printf("🐤 Canary of %s/%s started: %d%% to the new revision, %d%% to %s",
+		be.ThirdPartyId, be.ParserId, s.canarySteps[0], 100-s.canarySteps[0], stable)
+}
+
+// StartCanaryController advances canary rollouts until ctx is cancelled
+func (s *ParserService) StartCanaryController(ctx context.Context) {
+	if len(s.canarySteps) == 0 {
+		log.Printf("Canary rollouts disabled (%s unset)", config.EnvCanarySteps)
+		return
+	}
+
+	ticker := time.NewTicker(canaryPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.advanceCanaries(ctx)
+		}
+	}
+}
+
+// advanceCanaries moves every rollout in progress forward (or aborts it)
+func (s *ParserService) advanceCanaries(ctx context.Context) {
+	parsers, err := s.k8sClient.List(ctx, knativeServiceResource, s.parserNamespaces(), tenants.LabelParserId)
+	if err != nil {
+		log.Printf("ERROR: Failed to list parser services for canaries: %v", err)
+		return
+	}
+	for i := range parsers {
+		svc := &parsers[i]
+		if svc.GetAnnotations()[AnnotationCanaryStable] == "" {
+			continue
+		}
+		if err := s.advanceCanary(ctx, svc); err != nil {
+			log.Printf("ERROR: Failed to advance the canary of %s/%s: %v", svc.GetNamespace(), svc.GetName(), err)
+		}
+	}
+}
+
+// advanceCanary moves one rollout to its next step once the current one lasted
+// CANARY_STEP_INTERVAL
+// 📋 STEPS:
+//  1. Wait for Knative to have reconciled the latest spec
+//  2. New revision failed to become Ready: abort, all traffic to the stable revision
+//  3. New revision still starting: wait
+//  4. Next step, or all traffic to the new revision after the last one
+func (s *ParserService) advanceCanary(ctx context.Context, svc *unstructured.Unstructured) error {
+	annotations := svc.GetAnnotations()
+	stable := annotations[AnnotationCanaryStable]
+	// 📝 NOTE: Unreadable state moves on right away rather than sticking forever
+	steppedAt, _ := time.Parse(time.RFC3339, annotations[AnnotationCanarySteppedAt])
+	if time.Since(steppedAt) < s.cfg.CanaryStepInterval {
+		return nil
+	}
+	step, _ := strconv.Atoi(annotations[AnnotationCanaryStep])
+
+	observed, _, _ := unstructured.NestedInt64(svc.Object, "status", "observedGeneration")
+	if observed < svc.GetGeneration() {
+		return nil
+	}
+	created, _, _ := unstructured.NestedString(svc.Object, "status", "latestCreatedRevisionName")
+	ready, _, _ := unstructured.NestedString(svc.Object, "status", "latestReadyRevisionName")
+
+	outcome := "stepped"
+	switch {
+	case created != ready:
+		condition, _ := k8s.FindCondition(svc, "ConfigurationsReady")
+		if condition.Status != "False" {
+			return nil // Still starting
+		}
+		log.Printf("⚠️ Canary of %s/%s aborted: revision %s not ready (reason=%s): %s",
+			svc.GetNamespace(), svc.GetName(), created, condition.Reason, condition.Message)
+		outcome = "aborted"
+		if err := endCanary(svc, stable); err != nil {
+			return err
+		}
+	case step+1 >= len(s.canarySteps):
+		outcome = "promoted"
+		if err := endCanary(svc, ""); err != nil {
+			return err
+		}
+	default:
+		step++
+		if err := s.setCanaryStep(svc, stable, step); err != nil {
+			return err
+		}
+	}
+
+	// 📝 NOTE: Update fails on a conflict, e.g. a redeploy since the list;
+	// the next tick looks at the Service again
+	if _, err := s.k8sClient.Update(ctx, svc); err != nil {
+		return err
+	}
+	observability.CanarySteps.WithLabelValues(outcome).Inc()
+	switch outcome {
+	case "stepped":
+		log.Printf("🐤 Canary of %s/%s: %d%% to revision %s", svc.GetNamespace(), svc.GetName(), s.canarySteps[step], ready)
+	case "promoted":
+		log.Printf("✅ Canary of %s/%s promoted: revision %s serves all traffic", svc.GetNamespace(), svc.GetName(), ready)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"knative-lambda-builder/internal/config"
+	"knative-lambda-builder/internal/k8s"
+	"knative-lambda-builder/internal/tenants"
+)
+
+func TestParseCanarySteps(t *testing.T) {
+	steps, err := ParseCanarySteps(" 10, 50% ")
+	if err != nil || len(steps) != 2 || steps[0] != 10 || steps[1] != 50 {
+		t.Errorf("ParseCanarySteps = %v, %v", steps, err)
+	}
+	if steps, err := ParseCanarySteps(""); err != nil || steps != nil {
+		t.Errorf("ParseCanarySteps(\"\") = %v, %v, want no canary", steps, err)
+	}
+	for _, invalid := range []string{"0", "100", "50,10", "ten"} {
+		if _, err := ParseCanarySteps(invalid); err == nil {
+			t.Errorf("ParseCanarySteps(%q) accepted", invalid)
+		}
+	}
+}
+
+// canaryService returns a Knative Service mid-rollout
+func canaryService(step int, created, ready string) *unstructured.Unstructured {
+	svc := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"latestCreatedRevisionName": created,
+			"latestReadyRevisionName":   ready,
+		},
+	}}
+	svc.SetAPIVersion("serving.knative.dev/v1")
+	svc.SetKind("Service")
+	svc.SetNamespace("knative-lambda")
+	svc.SetName("lambda-acme-p1")
+	svc.SetLabels(map[string]string{tenants.LabelParserId: "p1"})
+	svc.SetAnnotations(map[string]string{
+		AnnotationCanaryStable:    "lambda-acme-p1-00001",
+		AnnotationCanaryStep:      strconv.Itoa(step),
+		AnnotationCanarySteppedAt: time.Now().Add(-time.Hour).UTC().Format(time.RFC3339),
+	})
+	return svc
+}
+
+// trafficOf returns the percent each revision ("latest" for latestRevision) receives
+func trafficOf(t *testing.T, client *k8s.Client, svc *unstructured.Unstructured) map[string]int64 {
+	t.Helper()
+	live, err := client.Get(context.Background(), svc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	traffic, _, _ := unstructured.NestedSlice(live.Object, "spec", "traffic")
+	percents := map[string]int64{}
+	for _, raw := range traffic {
+		target := raw.(map[string]interface{})
+		name, _ := target["revisionName"].(string)
+		if latest, _ := target["latestRevision"].(bool); latest {
+			name = "latest"
+		}
+		percents[name] = target["percent"].(int64)
+	}
+	return percents
+}
+
+func TestAdvanceCanary(t *testing.T) {
+	ctx := context.Background()
+	newService := func(svc *unstructured.Unstructured) (*ParserService, *k8s.Client) {
+		client := &k8s.Client{Dynamic: dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+			map[schema.GroupVersionResource]string{knativeServiceResource: "ServiceList"}, svc)}
+		cfg := &config.Config{KubernetesNamespace: "knative-lambda", CanaryStepInterval: time.Minute}
+		return &ParserService{cfg: cfg, k8sClient: client, canarySteps: []int{10, 50}}, client
+	}
+
+	// New revision Ready: next step
+	svc := canaryService(0, "lambda-acme-p1-00002", "lambda-acme-p1-00002")
+	s, client := newService(svc)
+	s.advanceCanaries(ctx)
+	if got := trafficOf(t, client, svc); got["latest"] != 50 || got["lambda-acme-p1-00001"] != 50 {
+		t.Errorf("traffic after a step = %v, want 50/50", got)
+	}
+	// The step just happened: nothing moves before CANARY_STEP_INTERVAL
+	s.advanceCanaries(ctx)
+	if got := trafficOf(t, client, svc); got["latest"] != 50 {
+		t.Errorf("traffic moved before the step interval: %v", got)
+	}
+
+	// Last step done: promoted
+	svc = canaryService(1, "lambda-acme-p1-00002", "lambda-acme-p1-00002")
+	s, client = newService(svc)
+	s.advanceCanaries(ctx)
+	live, _ := client.Get(ctx, svc)
+	if got := trafficOf(t, client, svc); len(got) != 1 || got["latest"] != 100 {
+		t.Errorf("traffic after promotion = %v, want all to latest", got)
+	}
+	if _, ok := live.GetAnnotations()[AnnotationCanaryStable]; ok {
+		t.Error("promotion kept the canary annotations")
+	}
+
+	// New revision failed: aborted back to the stable revision
+	svc = canaryService(0, "lambda-acme-p1-00002", "lambda-acme-p1-00001")
+	unstructured.SetNestedSlice(svc.Object, []interface{}{
+		map[string]interface{}{"type": "ConfigurationsReady", "status": "False", "reason": "RevisionFailed"},
+	}, "status", "conditions")
+	s, client = newService(svc)
+	s.advanceCanaries(ctx)
+	if got := trafficOf(t, client, svc); len(got) != 1 || got["lambda-acme-p1-00001"] != 100 {
+		t.Errorf("traffic after abort = %v, want all to the stable revision", got)
+	}
+}
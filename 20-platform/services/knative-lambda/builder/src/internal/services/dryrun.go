@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"knative-lambda-builder/internal/k8s"
+	"knative-lambda-builder/internal/templates"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🧾 DRY RUNS
+// =============================================================================
+// What a deploy would apply, rendered but not applied: for debugging
+// template changes without touching running parsers
+
+// RenderManifests renders the service objects and trigger a build would deploy
+// Returns the deploy mode and the manifests (service template first)
+func (s *ParserService) RenderManifests(ctx context.Context, be types.BuildEvent) (string, [][]byte, error) {
+	serviceData, err := s.serviceData(ctx, be)
+	if err != nil {
+		return serviceData.DeployMode, nil, err
+	}
+	service, err := templates.RenderFile(s.serviceTemplatePath(serviceData.DeployMode), serviceData)
+	if err != nil {
+		return serviceData.DeployMode, nil, fmt.Errorf("failed to render service template: %w", err)
+	}
+	trigger, err := templates.RenderFile(s.cfg.TriggerTemplatePath, serviceData)
+	if err != nil {
+		return serviceData.DeployMode, nil, fmt.Errorf("failed to render trigger template: %w", err)
+	}
+	return serviceData.DeployMode, [][]byte{service, trigger}, nil
+}
+
+// ValidateManifests submits rendered manifests to the API server as a
+// server-side dry run and returns the objects it refused
+func (s *ParserService) ValidateManifests(ctx context.Context, manifests ...[]byte) ([]types.RejectedObject, error) {
+	var rejected []types.RejectedObject
+	for _, manifest := range manifests {
+		objects, err := k8s.DecodeManifests(manifest)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objects {
+			if err := s.k8sClient.DryRun(ctx, obj); err != nil {
+				rejected = append(rejected, types.RejectedObject{Kind: obj.GetKind(), Name: obj.GetName(), Error: err.Error()})
+			}
+		}
+	}
+	return rejected, nil
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"knative-lambda-builder/internal/k8s"
+	"knative-lambda-builder/internal/templates"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🪂 FALLBACK DEPLOYMENTS
+// =============================================================================
+// Without Knative Serving (not installed, or its control plane down) parsers
+// are deployed as a plain Deployment + Service + HPA instead, so they keep
+// receiving events from their trigger. The mode used ends up in the build record.
+
+// Deploy modes (DEPLOY_MODE also accepts "auto")
+const (
+	DeployModeAuto     = "auto"
+	DeployModeKnative  = "knative"
+	DeployModeFallback = "fallback"
+)
+
+// LabelDeployMode marks the objects of fallback deployments
+// 🎯 WHY: Knative creates a Service with the parser's name too; only ours may be deleted
+const LabelDeployMode = "knative-lambda.notifi.network/deploy-mode"
+
+// deployMode decides how a parser is deployed right now
+func (s *ParserService) deployMode(ctx context.Context) string {
+	switch s.cfg.DeployMode {
+	case DeployModeKnative, DeployModeFallback:
+		return s.cfg.DeployMode
+	case DeployModeAuto, "":
+	default:
+		log.Printf("WARNING: Unknown DEPLOY_MODE %q, detecting Knative Serving", s.cfg.DeployMode)
+	}
+
+	if ok, reason := k8s.ServingStatus(ctx, s.k8sClient.Clientset); !ok {
+		log.Printf("WARNING: Knative Serving unavailable (%s), deploying parsers in fallback mode", reason)
+		return DeployModeFallback
+	}
+	return DeployModeKnative
+}
+
+// removeOtherMode deletes what a parser's previous deployment in the other mode left behind
+// 📝 NOTE: Best effort; in fallback mode the serving API may not even exist
+func (s *ParserService) removeOtherMode(ctx context.Context, mode string, data types.ServiceTemplateData) {
+	path := s.cfg.FallbackTemplatePath
+	if mode == DeployModeFallback {
+		path = s.cfg.ServiceTemplatePath
+	}
+	manifest, err := templates.RenderFile(path, data)
+	if err != nil {
+		log.Printf("WARNING: Failed to render %s to clean up: %v", path, err)
+		return
+	}
+	objects, err := k8s.DecodeManifests(manifest)
+	if err != nil {
+		log.Printf("WARNING: Failed to decode %s to clean up: %v", path, err)
+		return
+	}
+
+	for _, obj := range objects {
+		live, err := s.k8sClient.Get(ctx, obj)
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				log.Printf("WARNING: Failed to look for a leftover %s: %v", obj.GetKind(), err)
+			}
+			continue
+		}
+		if mode == DeployModeKnative && live.GetLabels()[LabelDeployMode] != DeployModeFallback {
+			continue
+		}
+		if err := s.k8sClient.Delete(ctx, obj); err != nil {
+			log.Printf("WARNING: Failed to remove leftover %s %s: %v", obj.GetKind(), obj.GetName(), err)
+			continue
+		}
+		log.Printf("🧹 Removed %s %s/%s (parser now deployed in %s mode)",
+			obj.GetKind(), obj.GetNamespace(), obj.GetName(), mode)
+	}
+}
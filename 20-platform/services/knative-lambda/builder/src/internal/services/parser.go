@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"knative-lambda-builder/internal/aws"
+	"knative-lambda-builder/internal/build"
+	"knative-lambda-builder/internal/config"
+	"knative-lambda-builder/internal/k8s"
+	"knative-lambda-builder/internal/sidecars"
+	"knative-lambda-builder/internal/templates"
+	"knative-lambda-builder/internal/tenants"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🚀 PARSER SERVICE DEPLOYMENT
+// =============================================================================
+// This package deploys built parser images as Knative Services
+// 🎯 PURPOSE: Once Kaniko pushed the image, make it run and receive events
+
+// ParserService deploys parser images and wires their triggers
+type ParserService struct {
+	cfg          *config.Config
+	awsClient    *aws.Client
+	k8sClient    *k8s.Client
+	orchestrator *build.Orchestrator // Used to resolve image URIs consistently
+	sidecars     SidecarResolver
+	canarySteps  []int // Traffic steps of redeploys (nil = switch at once)
+	strategies   StrategyPolicy
+	namespaces   *tenants.NamespacePolicy // Parsers run in their tenant's namespace (nil = all in KubernetesNamespace)
+}
+
+// SidecarResolver finds the sidecars a parser runs with (implemented by sidecars.Resolver)
+type SidecarResolver interface {
+	Containers(ctx context.Context, thirdPartyId, parserId string) ([]corev1.Container, error)
+}
+
+// NewParserService creates a new parser service deployer
+func NewParserService(cfg *config.Config, awsClient *aws.Client, k8sClient *k8s.Client) *ParserService {
+	return &ParserService{
+		cfg:          cfg,
+		awsClient:    awsClient,
+		k8sClient:    k8sClient,
+		orchestrator: build.NewOrchestrator(cfg, awsClient, k8sClient),
+	}
+}
+
+// WithSidecars makes parsers run with the sidecars their tenant declared
+func (s *ParserService) WithSidecars(resolver SidecarResolver) *ParserService {
+	s.sidecars = resolver
+	return s
+}
+
+// WithTenantNamespaces makes parsers run in their tenant's namespace, created
+// with the policy's quota and NetworkPolicy on their first deploy
+func (s *ParserService) WithTenantNamespaces(policy tenants.NamespacePolicy) *ParserService {
+	s.namespaces = &policy
+	return s
+}
+
+// namespaceFor returns the namespace a tenant's parsers run in
+func (s *ParserService) namespaceFor(thirdPartyId string) string {
+	if s.namespaces != nil {
+		return tenants.NamespaceFor(thirdPartyId)
+	}
+	return s.cfg.KubernetesNamespace
+}
+
+// parserNamespaces returns where to list parsers ("" = every namespace)
+func (s *ParserService) parserNamespaces() string {
+	if s.namespaces != nil {
+		return ""
+	}
+	return s.cfg.KubernetesNamespace
+}
+
+// CreateParserService deploys (or updates) the parser and its trigger
+// Returns the deploy mode used ("knative" or "fallback")
+// 📋 STEPS:
+//  1. Render and apply the Knative Service with the freshly built image
+//     (a Deployment + Service + HPA when Knative Serving is unavailable);
+//     redeploys follow the deploy strategy (rolling, canary or blue-green)
+//  2. Render and (re)create the trigger routing events to it
+//  3. Wait until the trigger is Ready (returns *TriggerNotReadyError otherwise)
+//
+// 📝 NOTE: With tenant namespaces, the tenant's namespace is ensured first
+func (s *ParserService) CreateParserService(ctx context.Context, be types.BuildEvent) (string, error) {
+	// =========================================================================
+	// 📍 STEP 1: KNATIVE SERVICE (OR FALLBACK DEPLOYMENT)
+	// =========================================================================
+	serviceData, err := s.serviceData(ctx, be)
+	if err != nil {
+		return serviceData.DeployMode, err
+	}
+	if s.namespaces != nil {
+		if err := tenants.EnsureNamespace(ctx, s.k8sClient.Clientset, be.ThirdPartyId, *s.namespaces); err != nil {
+			return serviceData.DeployMode, err
+		}
+	}
+	// 📝 NOTE: The Knative Service and the fallback Service share a name, so
+	// the other mode's objects go first
+	s.removeOtherMode(ctx, serviceData.DeployMode, serviceData)
+
+	manifest, err := templates.RenderFile(s.serviceTemplatePath(serviceData.DeployMode), serviceData)
+	if err != nil {
+		return serviceData.DeployMode, fmt.Errorf("failed to render service template: %w", err)
+	}
+	services, err := k8s.DecodeManifests(manifest)
+	if err != nil {
+		return serviceData.DeployMode, err
+	}
+	// 📝 NOTE: Fallback Deployments always roll
+	strategy := tenants.DeployStrategyRolling
+	if serviceData.DeployMode == DeployModeKnative {
+		if strategy, err = s.deployStrategy(ctx, be); err != nil {
+			return serviceData.DeployMode, err
+		}
+	}
+	for _, obj := range services {
+		k8s.SetLabels(obj, build.ResourceLabels(be))
+		if strategy == tenants.DeployStrategyBlueGreen {
+			if err := s.deployBlueGreen(ctx, obj); err != nil {
+				return serviceData.DeployMode, err
+			}
+			continue
+		}
+		if strategy == tenants.DeployStrategyCanary {
+			s.startCanary(ctx, obj, be)
+		}
+		if _, err := s.k8sClient.Apply(ctx, obj); err != nil {
+			return serviceData.DeployMode, err
+		}
+	}
+	log.Printf("✅ Parser %s/%s deployed in %s mode (image: %s, %d sidecar(s))",
+		be.ThirdPartyId, be.ParserId, serviceData.DeployMode, serviceData.Image, len(serviceData.Sidecars))
+
+	// =========================================================================
+	// 📍 STEP 2: TRIGGER
+	// =========================================================================
+	// 📝 NOTE: Parts of the trigger spec are immutable, so we delete + create
+	manifest, err = templates.RenderFile(s.cfg.TriggerTemplatePath, serviceData)
+	if err != nil {
+		return serviceData.DeployMode, fmt.Errorf("failed to render trigger template: %w", err)
+	}
+	objects, err := k8s.DecodeManifests(manifest)
+	if err != nil {
+		return serviceData.DeployMode, err
+	}
+	// 👪 The trigger belongs to the parser's Service: deleting it deletes the trigger
+	owner := s.parserOwner(ctx, services)
+	for _, obj := range objects {
+		k8s.SetLabels(obj, build.ResourceLabels(be))
+		if owner != nil {
+			// 📝 NOTE: Not across namespaces: the default Trigger lives with the broker
+			k8s.SetOwner(obj, owner)
+		}
+		if _, err := s.k8sClient.Recreate(ctx, obj, s.cfg.DeletionTimeout); err != nil {
+			return serviceData.DeployMode, err
+		}
+	}
+	log.Printf("✅ Trigger created for %s/%s", be.ThirdPartyId, be.ParserId)
+
+	// =========================================================================
+	// 📍 STEP 3: VERIFY TRIGGER READINESS
+	// =========================================================================
+	// 🎯 WHY: A trigger that never connects leaves the parser deployed but idle
+	for _, obj := range objects {
+		if err := s.waitForTriggerReady(ctx, obj); err != nil {
+			return serviceData.DeployMode, err
+		}
+	}
+
+	return serviceData.DeployMode, nil
+}
+
+// parserOwner returns the live Service (Knative or fallback) among the
+// parser's objects, the owner of its trigger (nil if there is none)
+func (s *ParserService) parserOwner(ctx context.Context, objects []*unstructured.Unstructured) *unstructured.Unstructured {
+	for _, obj := range objects {
+		if obj.GetKind() != "Service" {
+			continue
+		}
+		live, err := s.k8sClient.Get(ctx, obj)
+		if err != nil {
+			log.Printf("WARNING: Failed to get the owner of the trigger: %v", err)
+			return nil
+		}
+		return live
+	}
+	return nil
+}
+
+// serviceData builds the data passed to the service and trigger templates
+func (s *ParserService) serviceData(ctx context.Context, be types.BuildEvent) (types.ServiceTemplateData, error) {
+	serviceData := types.ServiceTemplateData{
+		ThirdPartyId:         be.ThirdPartyId,
+		ParserId:             be.ParserId,
+		Image:                s.orchestrator.ImageURI(be),
+		DeployMode:           s.deployMode(ctx),
+		MinReplicas:          s.cfg.FallbackMinReplicas,
+		MaxReplicas:          s.cfg.FallbackMaxReplicas,
+		TargetCPUUtilization: s.cfg.FallbackTargetCPU,
+		Env:                  build.EnvVars(be),
+		Namespace:            s.namespaceFor(be.ThirdPartyId),
+	}
+	if be.Rebuild {
+		serviceData.RebuiltAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	if s.sidecars != nil {
+		containers, err := s.sidecars.Containers(ctx, be.ThirdPartyId, be.ParserId)
+		if err != nil {
+			return serviceData, fmt.Errorf("failed to resolve sidecars: %w", err)
+		}
+		if len(containers) > 0 {
+			serviceData.Sidecars = containers
+			serviceData.SharedVolume = sidecars.SharedVolumeName
+			serviceData.SharedMountPath = sidecars.SharedMountPath
+		}
+	}
+	return serviceData, nil
+}
+
+// serviceTemplatePath returns the service template of a deploy mode
+func (s *ParserService) serviceTemplatePath(mode string) string {
+	if mode == DeployModeFallback {
+		return s.cfg.FallbackTemplatePath
+	}
+	return s.cfg.ServiceTemplatePath
+}
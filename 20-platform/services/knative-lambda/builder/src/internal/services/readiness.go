@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"knative-lambda-builder/internal/k8s"
+)
+
+// =============================================================================
+// 🩺 TRIGGER READINESS
+// =============================================================================
+// Applying a trigger succeeding only means the apiserver accepted it. The
+// source/trigger may still fail to connect to RabbitMQ, leaving the parser
+// deployed but never invoked. We poll its conditions until it is Ready.
+
+// connectionConditions are the conditions (besides Ready) that tell us the
+// trigger is actually wired to the broker, per kind
+// 📝 NOTE: A condition that is present and False fails fast with its reason
+var connectionConditions = map[string][]string{
+	"RabbitmqSource": {"SinkProvided", "Deployed"},
+	"Trigger":        {"BrokerReady", "DependencyReady", "SubscriberResolved"},
+}
+
+// TriggerNotReadyError is returned when a trigger never became Ready
+// 🎯 PURPOSE: Lets the event handler emit a distinct trigger.failed event
+type TriggerNotReadyError struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Condition string // Condition that failed (or "Ready" on timeout)
+	Reason    string
+	Message   string
+}
+
+func (e *TriggerNotReadyError) Error() string {
+	return fmt.Sprintf("%s %s/%s not ready: condition %s (reason=%s): %s",
+		e.Kind, e.Namespace, e.Name, e.Condition, e.Reason, e.Message)
+}
+
+// waitForTriggerReady polls a trigger until it is Ready, retrying with exponential backoff
+func (s *ParserService) waitForTriggerReady(ctx context.Context, obj *unstructured.Unstructured) error {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.TriggerReadyTimeout)
+	defer cancel()
+
+	backoff := wait.Backoff{
+		Duration: 1 * time.Second,
+		Factor:   2.0,
+		Jitter:   0.1,
+		Steps:    1 << 30, // Bounded by the context timeout, not by steps
+		Cap:      15 * time.Second,
+	}
+
+	var lastErr *TriggerNotReadyError
+	err := wait.ExponentialBackoffWithContext(ctx, backoff, func(ctx context.Context) (bool, error) {
+		live, err := s.k8sClient.Get(ctx, obj)
+		if err != nil {
+			// Transient apiserver errors: keep retrying until the timeout
+			log.Printf("WARNING: Failed to get %s %s: %v", obj.GetKind(), obj.GetName(), err)
+			return false, nil
+		}
+
+		ready, notReady := checkTriggerReady(live)
+		if notReady != nil && notReady.Condition != "Ready" {
+			// 🛑 A connection condition is explicitly False: no point in waiting
+			return false, notReady
+		}
+		lastErr = notReady
+		return ready, nil
+	})
+
+	if err == nil {
+		log.Printf("✅ %s %s/%s is Ready", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+		return nil
+	}
+	if notReady, ok := err.(*TriggerNotReadyError); ok {
+		return notReady
+	}
+	if lastErr != nil {
+		return lastErr
+	}
+	return &TriggerNotReadyError{
+		Kind:      obj.GetKind(),
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+		Condition: "Ready",
+		Reason:    "Timeout",
+		Message:   fmt.Sprintf("not Ready after %s", s.cfg.TriggerReadyTimeout),
+	}
+}
+
+// checkTriggerReady inspects conditions; returns ready=true, or why it isn't
+func checkTriggerReady(live *unstructured.Unstructured) (bool, *TriggerNotReadyError) {
+	notReady := func(c k8s.Condition) *TriggerNotReadyError {
+		return &TriggerNotReadyError{
+			Kind:      live.GetKind(),
+			Namespace: live.GetNamespace(),
+			Name:      live.GetName(),
+			Condition: c.Type,
+			Reason:    c.Reason,
+			Message:   c.Message,
+		}
+	}
+
+	// ⏳ Conditions of an older spec say nothing about the one just applied
+	observed, _, _ := unstructured.NestedInt64(live.Object, "status", "observedGeneration")
+	if observed < live.GetGeneration() {
+		return false, notReady(k8s.Condition{Type: "Ready", Status: "Unknown", Reason: "Reconciling",
+			Message: fmt.Sprintf("generation %d not observed yet (observed %d)", live.GetGeneration(), observed)})
+	}
+
+	for _, conditionType := range connectionConditions[live.GetKind()] {
+		if c, found := k8s.FindCondition(live, conditionType); found && c.Status == "False" {
+			return false, notReady(c)
+		}
+	}
+
+	ready, found := k8s.FindCondition(live, "Ready")
+	if !found {
+		return false, notReady(k8s.Condition{Type: "Ready", Status: "Unknown", Reason: "NoStatus"})
+	}
+	if ready.Status != "True" {
+		// Ready=False is reported as Ready so we keep retrying: Knative often
+		// flips it while dependencies reconcile
+		ready.Type = "Ready"
+		return false, notReady(ready)
+	}
+	return true, nil
+}
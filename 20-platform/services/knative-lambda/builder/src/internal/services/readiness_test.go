@@ -0,0 +1,89 @@
+package services
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// liveTrigger returns a Trigger at generation 2 with status observed at observed
+func liveTrigger(observed int64, conditions ...map[string]interface{}) *unstructured.Unstructured {
+	list := make([]interface{}, len(conditions))
+	for i, c := range conditions {
+		list[i] = c
+	}
+	trigger := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"observedGeneration": observed,
+			"conditions":         list,
+		},
+	}}
+	trigger.SetAPIVersion("eventing.knative.dev/v1")
+	trigger.SetKind("Trigger")
+	trigger.SetNamespace("knative-lambda")
+	trigger.SetName("lambda-acme-p1")
+	trigger.SetGeneration(2)
+	return trigger
+}
+
+func condition(conditionType, status, reason string) map[string]interface{} {
+	return map[string]interface{}{"type": conditionType, "status": status, "reason": reason}
+}
+
+func TestCheckTriggerReady(t *testing.T) {
+	tests := []struct {
+		name          string
+		trigger       *unstructured.Unstructured
+		wantReady     bool
+		wantCondition string
+		wantReason    string
+	}{
+		{
+			name:      "ready",
+			trigger:   liveTrigger(2, condition("BrokerReady", "True", ""), condition("Ready", "True", "")),
+			wantReady: true,
+		},
+		{
+			name:          "connection condition false",
+			trigger:       liveTrigger(2, condition("BrokerReady", "False", "BrokerDoesNotExist"), condition("Ready", "False", "BrokerDoesNotExist")),
+			wantCondition: "BrokerReady",
+			wantReason:    "BrokerDoesNotExist",
+		},
+		{
+			name:          "ready not yet",
+			trigger:       liveTrigger(2, condition("Ready", "Unknown", "SubscriberNotResolved")),
+			wantCondition: "Ready",
+			wantReason:    "SubscriberNotResolved",
+		},
+		{
+			// The previous spec's conditions, Ready or not, don't count
+			name:          "stale generation",
+			trigger:       liveTrigger(1, condition("BrokerReady", "False", "BrokerDoesNotExist"), condition("Ready", "True", "")),
+			wantCondition: "Ready",
+			wantReason:    "Reconciling",
+		},
+		{
+			name:          "no status",
+			trigger:       liveTrigger(2),
+			wantCondition: "Ready",
+			wantReason:    "NoStatus",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ready, notReady := checkTriggerReady(tt.trigger)
+			if ready != tt.wantReady {
+				t.Fatalf("checkTriggerReady() ready = %v, want %v (%v)", ready, tt.wantReady, notReady)
+			}
+			if tt.wantReady {
+				if notReady != nil {
+					t.Errorf("checkTriggerReady() = %v, want no error", notReady)
+				}
+				return
+			}
+			if notReady == nil || notReady.Condition != tt.wantCondition || notReady.Reason != tt.wantReason {
+				t.Errorf("checkTriggerReady() = %v, want condition %s (reason=%s)", notReady, tt.wantCondition, tt.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"knative-lambda-builder/internal/k8s"
+	"knative-lambda-builder/internal/templates"
+	"knative-lambda-builder/internal/tenants"
+	"knative-lambda-builder/internal/types"
+)
+
+// =============================================================================
+// 🗑️ PARSER TEARDOWN
+// =============================================================================
+// Deploys only ever add resources; a lambda.teardown event removes a parser:
+// its trigger (Trigger or RabbitmqSource), its Knative Service and, if it ran
+// in fallback mode, its Deployment + Service + HPA.
+
+// triggerResources are swept by label, in case the trigger template changed
+// since the parser was deployed
+var triggerResources = []schema.GroupVersionResource{
+	{Group: "eventing.knative.dev", Version: "v1", Resource: "triggers"},
+	{Group: "sources.knative.dev", Version: "v1alpha1", Resource: "rabbitmqsources"},
+}
+
+// RemoveParserService deletes everything deployed for a parser
+// 📋 STEPS:
+//  1. Triggers first, so no event is routed to a parser being removed
+//  2. The Knative Service
+//  3. Fallback objects (only those carrying the fallback label)
+//
+// 📝 NOTE: Missing objects are skipped, so tearing down twice is harmless
+func (s *ParserService) RemoveParserService(ctx context.Context, be types.BuildEvent) error {
+	data := types.ServiceTemplateData{
+		ThirdPartyId:         be.ThirdPartyId,
+		ParserId:             be.ParserId,
+		Image:                s.orchestrator.ImageURI(be),
+		MinReplicas:          s.cfg.FallbackMinReplicas,
+		MaxReplicas:          s.cfg.FallbackMaxReplicas,
+		TargetCPUUtilization: s.cfg.FallbackTargetCPU,
+		Namespace:            s.namespaceFor(be.ThirdPartyId),
+	}
+
+	// =========================================================================
+	// 📍 STEP 1: TRIGGERS
+	// =========================================================================
+	var errs []error
+	if err := s.deleteRendered(ctx, s.cfg.TriggerTemplatePath, data, false); err != nil {
+		errs = append(errs, err)
+	}
+	selector := fmt.Sprintf("%s=%s,%s=%s",
+		tenants.LabelThirdPartyId, be.ThirdPartyId, tenants.LabelParserId, be.ParserId)
+	for _, gvr := range triggerResources {
+		objects, err := s.k8sClient.List(ctx, gvr, "", selector)
+		if err != nil {
+			log.Printf("WARNING: Failed to list %s of %s/%s: %v", gvr.Resource, be.ThirdPartyId, be.ParserId, err)
+			continue
+		}
+		for i := range objects {
+			if err := s.delete(ctx, &objects[i]); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		// Keep the service: a trigger left behind would deliver to nothing
+		return errors.Join(errs...)
+	}
+
+	// =========================================================================
+	// 📍 STEP 2 & 3: KNATIVE SERVICE, FALLBACK OBJECTS
+	// =========================================================================
+	if err := s.deleteRendered(ctx, s.cfg.ServiceTemplatePath, data, false); err != nil {
+		errs = append(errs, err)
+	}
+	if err := s.deleteRendered(ctx, s.cfg.FallbackTemplatePath, data, true); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	log.Printf("🗑️ Parser %s/%s torn down", be.ThirdPartyId, be.ParserId)
+	return nil
+}
+
+// deleteRendered deletes the objects a template renders for a parser
+// 📝 NOTE: fallbackOnly skips live objects without the fallback label (the
+// Knative Service creates a plain Service with the parser's name too)
+func (s *ParserService) deleteRendered(ctx context.Context, path string, data types.ServiceTemplateData, fallbackOnly bool) error {
+	manifest, err := templates.RenderFile(path, data)
+	if err != nil {
+		return fmt.Errorf("failed to render %s: %w", path, err)
+	}
+	objects, err := k8s.DecodeManifests(manifest)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, obj := range objects {
+		if fallbackOnly {
+			live, err := s.k8sClient.Get(ctx, obj)
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to get %s %s: %w", obj.GetKind(), obj.GetName(), err))
+				continue
+			}
+			if live.GetLabels()[LabelDeployMode] != DeployModeFallback {
+				continue
+			}
+		}
+		if err := s.delete(ctx, obj); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// delete removes one object, logging what was removed
+func (s *ParserService) delete(ctx context.Context, obj *unstructured.Unstructured) error {
+	if _, err := s.k8sClient.Get(ctx, obj); apierrors.IsNotFound(err) {
+		return nil // Already gone
+	}
+	if err := s.k8sClient.Delete(ctx, obj); err != nil {
+		return err
+	}
+	log.Printf("🗑️ Deleted %s %s/%s", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+	return nil
+}
@@ -0,0 +1,97 @@
+package share
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// =============================================================================
+// 🔗 SHARE LINKS
+// =============================================================================
+// Tenant developers usually have neither cluster nor AWS access. A share link
+// carries a signed, expiring token granting read access to one build's logs
+// and artifacts, nothing else.
+//
+// Token format: base64url(JSON claims) "." base64url(HMAC-SHA256(claims))
+// 📝 NOTE: Tokens are stateless; rotating the secret revokes every link
+
+// Errors returned by Verify
+var (
+	ErrInvalidToken = errors.New("invalid share token")
+	ErrExpiredToken = errors.New("share link expired")
+)
+
+// Claims identify the shared build
+type Claims struct {
+	ThirdPartyId string `json:"tid"`
+	ParserId     string `json:"pid"`
+	JobName      string `json:"job"`
+	IssuedBy     string `json:"by,omitempty"` // Who minted the link (for the logs)
+	ExpiresAt    int64  `json:"exp"`          // Unix seconds
+}
+
+// Expiry returns when the link stops working
+func (c Claims) Expiry() time.Time {
+	return time.Unix(c.ExpiresAt, 0).UTC()
+}
+
+// Signer mints and verifies share tokens
+type Signer struct {
+	secret []byte
+}
+
+// NewSigner creates a signer; the secret must be shared by all builder replicas
+func NewSigner(secret []byte) (*Signer, error) {
+	if len(secret) < 32 {
+		return nil, fmt.Errorf("share link secret must be at least 32 bytes, got %d", len(secret))
+	}
+	return &Signer{secret: secret}, nil
+}
+
+// Mint creates a token for the claims, valid until now+ttl
+func (s *Signer) Mint(claims Claims, now time.Time, ttl time.Duration) (string, error) {
+	claims.ExpiresAt = now.Add(ttl).Unix()
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode share claims: %w", err)
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(s.sign(encoded)), nil
+}
+
+// Verify checks a token's signature and expiry and returns its claims
+func (s *Signer) Verify(token string, now time.Time) (*Claims, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, s.sign(encoded)) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return nil, ErrExpiredToken
+	}
+	return &claims, nil
+}
+
+func (s *Signer) sign(encoded string) []byte {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write([]byte(encoded))
+	return h.Sum(nil)
+}
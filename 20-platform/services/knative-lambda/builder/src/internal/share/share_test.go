@@ -0,0 +1,49 @@
+package share
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMintVerify(t *testing.T) {
+	signer, err := NewSigner(bytes.Repeat([]byte("k"), 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+
+	token, err := signer.Mint(Claims{ThirdPartyId: "acme", ParserId: "p1", JobName: "build-acme-p1-1"}, now, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := signer.Verify(token, now.Add(59*time.Minute))
+	if err != nil || claims.ThirdPartyId != "acme" || claims.JobName != "build-acme-p1-1" {
+		t.Fatalf("Verify() = %+v, %v", claims, err)
+	}
+	if _, err := signer.Verify(token, now.Add(time.Hour)); !errors.Is(err, ErrExpiredToken) {
+		t.Errorf("expired token: got %v, want ErrExpiredToken", err)
+	}
+
+	// Changing the claims (e.g. another tenant) invalidates the signature
+	payload, signature, _ := strings.Cut(token, ".")
+	forged, _ := signer.Mint(Claims{ThirdPartyId: "other", ParserId: "p1"}, now, time.Hour)
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+	for _, bad := range []string{forgedPayload + "." + signature, payload, payload + ".AAAA", ""} {
+		if _, err := signer.Verify(bad, now); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Verify(%q) = %v, want ErrInvalidToken", bad, err)
+		}
+	}
+
+	other, _ := NewSigner(bytes.Repeat([]byte("x"), 32))
+	if _, err := other.Verify(token, now); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("token signed with another secret: got %v", err)
+	}
+
+	if _, err := NewSigner([]byte("short")); err == nil {
+		t.Error("NewSigner() should reject short secrets")
+	}
+}
@@ -0,0 +1,200 @@
+package sidecars
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"knative-lambda-builder/internal/tenants"
+)
+
+// =============================================================================
+// 🛺 PARSER SIDECARS
+// =============================================================================
+// Some parsers need helper containers next to them (a local cache, a protocol
+// adapter). Tenants can't run arbitrary images: operators publish a vetted
+// catalog and tenant records pick entries from it by name, per parser.
+//
+// 💡 EXAMPLE catalog file (SIDECAR_CATALOG_FILE):
+//
+//	[
+//	  {
+//	    "name": "redis-cache",
+//	    "image": "redis:7-alpine",
+//	    "args": ["--save", "", "--unixsocket", "/var/run/knative-lambda/shared/redis.sock"],
+//	    "resources": {"limits": {"cpu": "200m", "memory": "128Mi"}}
+//	  }
+//	]
+//
+// The builder mounts a shared emptyDir at SharedMountPath in the parser and
+// in every sidecar, and applies DefaultLimits to sidecars that don't set them
+
+// SharedVolumeName is the emptyDir shared by the parser and its sidecars
+const SharedVolumeName = "knative-lambda-shared"
+
+// SharedMountPath is where the shared volume is mounted in every container
+const SharedMountPath = "/var/run/knative-lambda/shared"
+
+// AllParsers selects sidecars for every parser of a tenant
+const AllParsers = "*"
+
+// DefaultLimits caps sidecars that don't declare their own limits
+var DefaultLimits = corev1.ResourceList{
+	corev1.ResourceCPU:    resource.MustParse("100m"),
+	corev1.ResourceMemory: resource.MustParse("128Mi"),
+}
+
+// Sidecar is a vetted container parsers may run next to them
+type Sidecar struct {
+	Name      string                      `json:"name"`
+	Image     string                      `json:"image"`
+	Command   []string                    `json:"command,omitempty"`
+	Args      []string                    `json:"args,omitempty"`
+	Env       map[string]string           `json:"env,omitempty"`
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// validName matches container names (DNS labels)
+var validName = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// Catalog holds the sidecars operators allow
+type Catalog struct {
+	sidecars map[string]Sidecar
+}
+
+// New validates a catalog
+func New(entries []Sidecar) (*Catalog, error) {
+	catalog := &Catalog{sidecars: map[string]Sidecar{}}
+	for _, sidecar := range entries {
+		switch {
+		case !validName.MatchString(sidecar.Name) || len(sidecar.Name) > 63:
+			return nil, fmt.Errorf("sidecar %q: name must be a DNS label", sidecar.Name)
+		case sidecar.Name == "user-container":
+			return nil, fmt.Errorf("sidecar %q: name is reserved for the parser", sidecar.Name)
+		case sidecar.Image == "":
+			return nil, fmt.Errorf("sidecar %q: image is required", sidecar.Name)
+		}
+		if _, dup := catalog.sidecars[sidecar.Name]; dup {
+			return nil, fmt.Errorf("sidecar %q: declared twice", sidecar.Name)
+		}
+		catalog.sidecars[sidecar.Name] = sidecar
+	}
+	return catalog, nil
+}
+
+// Load reads a catalog from a JSON file; an empty path yields an empty catalog
+func Load(path string) (*Catalog, error) {
+	if path == "" {
+		return New(nil)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sidecar catalog: %w", err)
+	}
+	var entries []Sidecar
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse sidecar catalog %s: %w", path, err)
+	}
+	return New(entries)
+}
+
+// Has reports whether a sidecar is in the catalog (implements tenants.SidecarCatalog)
+func (c *Catalog) Has(name string) bool {
+	_, ok := c.sidecars[name]
+	return ok
+}
+
+// Container builds the container spec of a catalog entry
+// 📝 NOTE: Missing limits default to DefaultLimits and missing requests to
+// the limits, so a sidecar never runs unbounded
+func (c *Catalog) Container(name string) (corev1.Container, error) {
+	sidecar, ok := c.sidecars[name]
+	if !ok {
+		return corev1.Container{}, fmt.Errorf("sidecar %q is not in the catalog", name)
+	}
+
+	limits := corev1.ResourceList{}
+	for resourceName, quantity := range DefaultLimits {
+		limits[resourceName] = quantity
+	}
+	for resourceName, quantity := range sidecar.Resources.Limits {
+		limits[resourceName] = quantity
+	}
+	requests := corev1.ResourceList{}
+	for resourceName, quantity := range limits {
+		requests[resourceName] = quantity
+	}
+	for resourceName, quantity := range sidecar.Resources.Requests {
+		requests[resourceName] = quantity
+	}
+
+	names := make([]string, 0, len(sidecar.Env))
+	for envName := range sidecar.Env {
+		names = append(names, envName)
+	}
+	sort.Strings(names)
+	env := make([]corev1.EnvVar, 0, len(names))
+	for _, envName := range names {
+		env = append(env, corev1.EnvVar{Name: envName, Value: sidecar.Env[envName]})
+	}
+
+	return corev1.Container{
+		Name:         sidecar.Name,
+		Image:        sidecar.Image,
+		Command:      sidecar.Command,
+		Args:         sidecar.Args,
+		Env:          env,
+		Resources:    corev1.ResourceRequirements{Limits: limits, Requests: requests},
+		VolumeMounts: []corev1.VolumeMount{{Name: SharedVolumeName, MountPath: SharedMountPath}},
+	}, nil
+}
+
+// =============================================================================
+// 🏢 PER-PARSER SELECTION
+// =============================================================================
+
+// Resolver finds the sidecars a parser runs with, from its tenant's record
+type Resolver struct {
+	catalog *Catalog
+	tenants tenants.Store
+}
+
+// NewResolver creates a resolver over a catalog and the tenant registry
+func NewResolver(catalog *Catalog, store tenants.Store) *Resolver {
+	return &Resolver{catalog: catalog, tenants: store}
+}
+
+// Containers returns the sidecar containers of a parser (nil if it has none)
+// 📝 NOTE: The tenant's "*" entries come first, then the parser's own
+func (r *Resolver) Containers(ctx context.Context, thirdPartyId, parserId string) ([]corev1.Container, error) {
+	tenant, err := r.tenants.Get(ctx, thirdPartyId)
+	if errors.Is(err, tenants.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var containers []corev1.Container
+	seen := map[string]bool{}
+	names := append(append([]string{}, tenant.Sidecars[AllParsers]...), tenant.Sidecars[parserId]...)
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		container, err := r.catalog.Container(name)
+		if err != nil {
+			return nil, err
+		}
+		containers = append(containers, container)
+	}
+	return containers, nil
+}
@@ -0,0 +1,123 @@
+package sidecars
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"knative-lambda-builder/internal/k8s"
+	"knative-lambda-builder/internal/templates"
+	"knative-lambda-builder/internal/tenants"
+	"knative-lambda-builder/internal/types"
+)
+
+// fakeTenants is a read-only tenant registry
+type fakeTenants map[string]tenants.Tenant
+
+func (f fakeTenants) Get(ctx context.Context, thirdPartyId string) (*tenants.Tenant, error) {
+	tenant, ok := f[thirdPartyId]
+	if !ok {
+		return nil, tenants.ErrNotFound
+	}
+	return &tenant, nil
+}
+
+func (f fakeTenants) List(ctx context.Context) ([]tenants.Tenant, error)   { return nil, nil }
+func (f fakeTenants) Put(ctx context.Context, tenant tenants.Tenant) error { return nil }
+
+func TestCatalog(t *testing.T) {
+	for _, invalid := range [][]Sidecar{
+		{{Name: "Redis", Image: "redis:7"}},
+		{{Name: "user-container", Image: "redis:7"}},
+		{{Name: "redis"}},
+		{{Name: "redis", Image: "redis:7"}, {Name: "redis", Image: "redis:6"}},
+	} {
+		if _, err := New(invalid); err == nil {
+			t.Errorf("New(%+v) accepted an invalid catalog", invalid)
+		}
+	}
+
+	catalog, err := New([]Sidecar{{
+		Name:      "redis",
+		Image:     "redis:7",
+		Env:       map[string]string{"B": "2", "A": "1"},
+		Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")}},
+	}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	container, err := catalog.Container("redis")
+	if err != nil {
+		t.Fatalf("Container: %v", err)
+	}
+	if got := container.Resources.Limits[corev1.ResourceMemory]; got.String() != "64Mi" {
+		t.Errorf("memory limit = %s, want the declared 64Mi", got.String())
+	}
+	if got := container.Resources.Limits[corev1.ResourceCPU]; got.String() != "100m" {
+		t.Errorf("cpu limit = %s, want the default 100m", got.String())
+	}
+	if got := container.Resources.Requests[corev1.ResourceMemory]; got.String() != "64Mi" {
+		t.Errorf("memory request = %s, want the limit", got.String())
+	}
+	if len(container.Env) != 2 || container.Env[0].Name != "A" {
+		t.Errorf("env = %+v, want sorted by name", container.Env)
+	}
+	if _, err := catalog.Container("unknown"); err == nil {
+		t.Errorf("Container returned a sidecar that isn't in the catalog")
+	}
+}
+
+func TestRenderSidecars(t *testing.T) {
+	catalog, err := New([]Sidecar{
+		{Name: "redis", Image: "redis:7", Args: []string{"--save", ""}},
+		{Name: "adapter", Image: "adapter:1"},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	resolver := NewResolver(catalog, fakeTenants{
+		"acme": {ThirdPartyId: "acme", Sidecars: map[string][]string{AllParsers: {"redis"}, "p1": {"adapter", "redis"}}},
+	})
+	ctx := context.Background()
+
+	containers, err := resolver.Containers(ctx, "acme", "p1")
+	if err != nil || len(containers) != 2 || containers[0].Name != "redis" || containers[1].Name != "adapter" {
+		t.Fatalf("Containers = %+v, %v; want redis then adapter", containers, err)
+	}
+	if others, err := resolver.Containers(ctx, "acme", "p2"); err != nil || len(others) != 1 {
+		t.Errorf("Containers(p2) = %+v, %v; want the tenant-wide redis", others, err)
+	}
+	if none, err := resolver.Containers(ctx, "unknown", "p1"); err != nil || none != nil {
+		t.Errorf("Containers(unregistered tenant) = %+v, %v; want none", none, err)
+	}
+
+	manifest, err := templates.RenderFile("../../templates/service.yaml.tpl", types.ServiceTemplateData{
+		ThirdPartyId:    "acme",
+		ParserId:        "p1",
+		Image:           "registry/acme:p1",
+		Sidecars:        containers,
+		SharedVolume:    SharedVolumeName,
+		SharedMountPath: SharedMountPath,
+	})
+	if err != nil {
+		t.Fatalf("RenderFile: %v", err)
+	}
+	objects, err := k8s.DecodeManifests(manifest)
+	if err != nil || len(objects) != 1 {
+		t.Fatalf("DecodeManifests = %d objects, %v\n%s", len(objects), err, manifest)
+	}
+	rendered, _, _ := unstructured.NestedSlice(objects[0].Object, "spec", "template", "spec", "containers")
+	if len(rendered) != 3 {
+		t.Fatalf("rendered %d containers, want the parser and 2 sidecars\n%s", len(rendered), manifest)
+	}
+	redis := rendered[1].(map[string]interface{})
+	if args, _, _ := unstructured.NestedStringSlice(redis, "args"); len(args) != 2 || args[1] != "" {
+		t.Errorf("redis args = %q, want [--save \"\"]", args)
+	}
+	if volumes, _, _ := unstructured.NestedSlice(objects[0].Object, "spec", "template", "spec", "volumes"); len(volumes) != 1 {
+		t.Errorf("volumes = %v, want the shared emptyDir", volumes)
+	}
+}
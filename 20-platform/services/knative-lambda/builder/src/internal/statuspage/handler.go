@@ -0,0 +1,106 @@
+package statuspage
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+)
+
+// =============================================================================
+// 🌐 STATUS PAGE HTTP
+// =============================================================================
+// GET /            → HTML page
+// GET /status.json → the same data as JSON
+// GET /health      → liveness of the status page itself
+
+// statusColors maps statuses to the colors used by the build badges
+var statusColors = map[string]string{
+	StatusOperational: "#4c1",
+	StatusDegraded:    "#dfb317",
+	StatusOutage:      "#e05d44",
+}
+
+var pageTemplate = template.Must(template.New("status").Funcs(template.FuncMap{
+	"color":  func(status string) string { return statusColors[status] },
+	"uptime": func(percent float64) string { return fmt.Sprintf("%.2f%%", percent) },
+	"dayColor": func(d Day) string {
+		switch {
+		case d.Up == d.Checks:
+			return statusColors[StatusOperational]
+		case d.Up == 0:
+			return statusColors[StatusOutage]
+		default:
+			return statusColors[StatusDegraded]
+		}
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="60">
+<title>Platform status</title>
+<style>
+body { font-family: Verdana, sans-serif; max-width: 760px; margin: 2em auto; color: #333; }
+.banner { color: #fff; padding: 1em; border-radius: 4px; font-size: 1.2em; }
+.service { border-bottom: 1px solid #eee; padding: 1em 0; }
+.bars { display: flex; gap: 1px; margin-top: .5em; }
+.bars span { flex: 1; height: 24px; max-width: 8px; border-radius: 1px; }
+.metrics { font-size: .8em; color: #777; }
+</style>
+</head>
+<body>
+<div class="banner" style="background: {{color .Status}}">All systems: {{.Status}}</div>
+<p class="metrics">Updated {{.UpdatedAt.Format "2006-01-02 15:04:05Z"}}</p>
+{{range .Services}}
+<div class="service">
+  <strong>{{.Name}}</strong>
+  {{if .Check}}{{if .Check.Up}}up ({{.Check.LatencyMs}} ms){{else}}down: {{.Check.Error}}{{end}}{{else}}pending{{end}}
+  · {{uptime .Uptime}} uptime
+  <div class="bars">{{range .History}}<span title="{{.Date}}: {{.Up}}/{{.Checks}}" style="background: {{dayColor .}}"></span>{{end}}</div>
+  {{if .Check}}{{with .Check.Metrics}}<p class="metrics">{{range $name, $value := .}}{{$name}}={{$value}} {{end}}</p>{{end}}{{end}}
+</div>
+{{end}}
+</body>
+</html>
+`))
+
+// Handler serves the status page
+func (m *Monitor) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /status.json", func(w http.ResponseWriter, r *http.Request) {
+		page, err := m.Page(r.Context())
+		if err != nil {
+			log.Printf("ERROR: Failed to build status page: %v", err)
+			http.Error(w, `{"error":"status unavailable"}`, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if err := json.NewEncoder(w).Encode(page); err != nil {
+			log.Printf("ERROR: Failed to encode status page: %v", err)
+		}
+	})
+
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		page, err := m.Page(r.Context())
+		if err != nil {
+			log.Printf("ERROR: Failed to build status page: %v", err)
+			http.Error(w, "status unavailable", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := pageTemplate.Execute(w, page); err != nil {
+			log.Printf("ERROR: Failed to render status page: %v", err)
+		}
+	})
+
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"status":"healthy"}`)
+	})
+
+	return mux
+}
@@ -0,0 +1,237 @@
+package statuspage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/expfmt"
+)
+
+// =============================================================================
+// 🩺 STATUS MONITOR
+// =============================================================================
+// Polls every target's readiness (and key metrics) on an interval, counts the
+// results in the uptime store and keeps the latest check for the page
+// 🎯 PURPOSE: The platform watching itself, visible to everyone
+
+// Overall statuses of the page
+const (
+	StatusOperational = "operational" // Every service up
+	StatusDegraded    = "degraded"    // Some services down
+	StatusOutage      = "outage"      // Every service down
+)
+
+// Check is the result of probing a target once
+type Check struct {
+	Up        bool               `json:"up"`
+	CheckedAt time.Time          `json:"checkedAt"`
+	LatencyMs int64              `json:"latencyMs"`
+	Error     string             `json:"error,omitempty"`
+	Metrics   map[string]float64 `json:"metrics,omitempty"`
+}
+
+// ServiceStatus is a service's entry on the status page
+type ServiceStatus struct {
+	Name    string  `json:"name"`
+	Check   *Check  `json:"check,omitempty"` // nil until the first poll
+	Uptime  float64 `json:"uptime"`          // Percent over the kept history
+	History []Day   `json:"history"`
+}
+
+// Page is the public status document
+type Page struct {
+	Status    string          `json:"status"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+	Services  []ServiceStatus `json:"services"`
+}
+
+// Monitor polls targets and builds the status page
+type Monitor struct {
+	targets  []Target
+	store    Store
+	client   *http.Client
+	interval time.Duration
+
+	mu     sync.RWMutex
+	latest map[string]Check
+	polled time.Time
+}
+
+// NewMonitor creates a monitor; each probe is bounded by timeout
+func NewMonitor(targets []Target, store Store, interval, timeout time.Duration) *Monitor {
+	return &Monitor{
+		targets:  targets,
+		store:    store,
+		client:   &http.Client{Timeout: timeout},
+		interval: interval,
+		latest:   map[string]Check{},
+	}
+}
+
+// Start polls right away and then every interval until ctx is done
+func (m *Monitor) Start(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		m.Poll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll probes every target concurrently and records the round
+func (m *Monitor) Poll(ctx context.Context) {
+	checks := make([]Check, len(m.targets))
+	var wg sync.WaitGroup
+	for i, target := range m.targets {
+		wg.Add(1)
+		go func(i int, target Target) {
+			defer wg.Done()
+			checks[i] = m.probe(ctx, target)
+		}(i, target)
+	}
+	wg.Wait()
+
+	round := make(map[string]bool, len(m.targets))
+	m.mu.Lock()
+	for i, target := range m.targets {
+		m.latest[target.Name] = checks[i]
+		round[target.Name] = checks[i].Up
+		if !checks[i].Up {
+			log.Printf("WARNING: %s is down: %s", target.Name, checks[i].Error)
+		}
+	}
+	m.polled = time.Now().UTC()
+	m.mu.Unlock()
+
+	if err := m.store.Record(ctx, round); err != nil {
+		log.Printf("ERROR: Failed to record uptime: %v", err)
+	}
+}
+
+// probe checks a target's readiness and scrapes its key metrics
+// 📝 NOTE: Metrics are best effort; only readiness decides up/down
+func (m *Monitor) probe(ctx context.Context, target Target) Check {
+	check := Check{CheckedAt: time.Now().UTC()}
+
+	start := time.Now()
+	err := m.get(ctx, target.ReadyURL, func(io.Reader) error { return nil })
+	check.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	check.Up = true
+
+	if target.MetricsURL != "" && len(target.Metrics) > 0 {
+		err := m.get(ctx, target.MetricsURL, func(body io.Reader) error {
+			var err error
+			check.Metrics, err = sumMetrics(body, target.Metrics)
+			return err
+		})
+		if err != nil {
+			log.Printf("WARNING: Failed to scrape metrics of %s: %v", target.Name, err)
+		}
+	}
+	return check
+}
+
+// get performs a GET and hands a 2xx body to read
+func (m *Monitor) get(ctx context.Context, url string, read func(io.Reader) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("invalid URL %s: %w", url, err)
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	return read(resp.Body)
+}
+
+// sumMetrics parses Prometheus text and sums the wanted families over their labels
+// 📝 NOTE: Histograms and summaries contribute their sample count
+func sumMetrics(body io.Reader, names []string) (map[string]float64, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse metrics: %w", err)
+	}
+
+	values := map[string]float64{}
+	for _, name := range names {
+		family, ok := families[name]
+		if !ok {
+			continue
+		}
+		total := 0.0
+		for _, metric := range family.GetMetric() {
+			switch {
+			case metric.Counter != nil:
+				total += metric.Counter.GetValue()
+			case metric.Gauge != nil:
+				total += metric.Gauge.GetValue()
+			case metric.Untyped != nil:
+				total += metric.Untyped.GetValue()
+			case metric.Histogram != nil:
+				total += float64(metric.Histogram.GetSampleCount())
+			case metric.Summary != nil:
+				total += float64(metric.Summary.GetSampleCount())
+			}
+		}
+		values[name] = total
+	}
+	return values, nil
+}
+
+// Page assembles the current status page (services in target order)
+func (m *Monitor) Page(ctx context.Context) (*Page, error) {
+	history, err := m.store.History(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	page := &Page{UpdatedAt: m.polled, Services: make([]ServiceStatus, 0, len(m.targets))}
+	up, checked := 0, 0
+	for _, target := range m.targets {
+		service := ServiceStatus{
+			Name:    target.Name,
+			Uptime:  Uptime(history[target.Name]),
+			History: history[target.Name],
+		}
+		if check, ok := m.latest[target.Name]; ok {
+			service.Check = &check
+			checked++
+			if check.Up {
+				up++
+			}
+		}
+		page.Services = append(page.Services, service)
+	}
+
+	switch {
+	case up == checked:
+		page.Status = StatusOperational
+	case up == 0:
+		page.Status = StatusOutage
+	default:
+		page.Status = StatusDegraded
+	}
+	return page, nil
+}
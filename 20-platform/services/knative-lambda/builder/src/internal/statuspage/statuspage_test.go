@@ -0,0 +1,70 @@
+package statuspage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMonitorPoll(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/metrics" {
+			fmt.Fprint(w, "# TYPE moe_requests_total counter\n"+
+				"moe_requests_total{status=\"200\"} 40\n"+
+				"moe_requests_total{status=\"500\"} 2\n")
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer up.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	store := NewMemoryStore()
+	monitor := NewMonitor([]Target{
+		{Name: "moe", ReadyURL: up.URL + "/health", MetricsURL: up.URL + "/metrics", Metrics: []string{"moe_requests_total"}},
+		{Name: "larry", ReadyURL: down.URL + "/health"},
+	}, store, time.Minute, time.Second)
+
+	ctx := context.Background()
+	monitor.Poll(ctx)
+	monitor.Poll(ctx)
+
+	page, err := monitor.Page(ctx)
+	if err != nil {
+		t.Fatalf("Page: %v", err)
+	}
+	if page.Status != StatusDegraded {
+		t.Errorf("status = %s, want %s", page.Status, StatusDegraded)
+	}
+	moe, larry := page.Services[0], page.Services[1]
+	if !moe.Check.Up || moe.Check.Metrics["moe_requests_total"] != 42 || moe.Uptime != 100 {
+		t.Errorf("unexpected moe status: %+v %+v", moe, moe.Check)
+	}
+	if larry.Check.Up || larry.Uptime != 0 || len(larry.History) != 1 || larry.History[0].Checks != 2 {
+		t.Errorf("unexpected larry status: %+v %+v", larry, larry.Check)
+	}
+}
+
+func TestAddCheck(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var days []Day
+	days = addCheck(days, true, start)
+	days = addCheck(days, false, start.Add(time.Hour))
+	if len(days) != 1 || days[0].Checks != 2 || Uptime(days) != 50 {
+		t.Fatalf("same day checks not aggregated: %+v", days)
+	}
+
+	for i := 1; i <= HistoryDays+10; i++ {
+		days = addCheck(days, true, start.AddDate(0, 0, i))
+	}
+	if len(days) != HistoryDays || days[len(days)-1].Date != start.AddDate(0, 0, HistoryDays+10).Format("2006-01-02") {
+		t.Errorf("history should keep the last %d days, got %d", HistoryDays, len(days))
+	}
+}
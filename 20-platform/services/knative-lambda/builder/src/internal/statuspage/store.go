@@ -0,0 +1,199 @@
+package statuspage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// =============================================================================
+// 📈 UPTIME HISTORY
+// =============================================================================
+// Checks are aggregated per service and UTC day: enough for the usual
+// "last 90 days" bars without storing every single probe
+
+// HistoryDays is how many days of uptime are kept per service
+const HistoryDays = 90
+
+// Day aggregates a service's checks over one UTC day
+type Day struct {
+	Date   string `json:"date"` // YYYY-MM-DD
+	Checks int    `json:"checks"`
+	Up     int    `json:"up"`
+}
+
+// Uptime returns the share of successful checks in percent (100 without checks)
+func Uptime(days []Day) float64 {
+	checks, up := 0, 0
+	for _, d := range days {
+		checks += d.Checks
+		up += d.Up
+	}
+	if checks == 0 {
+		return 100
+	}
+	return 100 * float64(up) / float64(checks)
+}
+
+// addCheck counts a check in a service's days (oldest first)
+func addCheck(days []Day, up bool, now time.Time) []Day {
+	date := now.UTC().Format("2006-01-02")
+	if len(days) == 0 || days[len(days)-1].Date != date {
+		days = append(days, Day{Date: date})
+	}
+	today := &days[len(days)-1]
+	today.Checks++
+	if up {
+		today.Up++
+	}
+	if len(days) > HistoryDays {
+		days = days[len(days)-HistoryDays:]
+	}
+	return days
+}
+
+// Store persists uptime history
+type Store interface {
+	// Record counts one polling round (service name -> up)
+	Record(ctx context.Context, checks map[string]bool) error
+	// History returns the days of every service (oldest first)
+	History(ctx context.Context) (map[string][]Day, error)
+}
+
+// =============================================================================
+// 🗂️ CONFIGMAP-BACKED STORE
+// =============================================================================
+
+// DefaultConfigMapName is the ConfigMap holding the uptime history
+const DefaultConfigMapName = "knative-lambda-status-history"
+
+// ConfigMapStore stores uptime history in a ConfigMap (one key per service)
+type ConfigMapStore struct {
+	clientset kubernetes.Interface
+	namespace string
+	name      string
+}
+
+// NewConfigMapStore creates a ConfigMap-backed uptime store
+func NewConfigMapStore(clientset kubernetes.Interface, namespace string) *ConfigMapStore {
+	return &ConfigMapStore{
+		clientset: clientset,
+		namespace: namespace,
+		name:      DefaultConfigMapName,
+	}
+}
+
+// History implements Store
+func (s *ConfigMapStore) History(ctx context.Context) (map[string][]Day, error) {
+	cm, err := s.clientset.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return map[string][]Day{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read uptime history: %w", err)
+	}
+
+	history := map[string][]Day{}
+	for name, raw := range cm.Data {
+		var days []Day
+		if err := json.Unmarshal([]byte(raw), &days); err != nil {
+			return nil, fmt.Errorf("failed to decode uptime history of %s: %w", name, err)
+		}
+		history[name] = days
+	}
+	return history, nil
+}
+
+// Record implements Store
+func (s *ConfigMapStore) Record(ctx context.Context, checks map[string]bool) error {
+	configMaps := s.clientset.CoreV1().ConfigMaps(s.namespace)
+	now := time.Now()
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := configMaps.Get(ctx, s.name, metav1.GetOptions{})
+		create := apierrors.IsNotFound(err)
+		if create {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      s.name,
+					Namespace: s.namespace,
+					Labels:    map[string]string{"app.kubernetes.io/part-of": "knative-lambda"},
+				},
+			}
+		} else if err != nil {
+			return fmt.Errorf("failed to read uptime history: %w", err)
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+
+		for name, up := range checks {
+			var days []Day
+			if raw := cm.Data[name]; raw != "" {
+				if err := json.Unmarshal([]byte(raw), &days); err != nil {
+					return fmt.Errorf("failed to decode uptime history of %s: %w", name, err)
+				}
+			}
+			raw, err := json.Marshal(addCheck(days, up, now))
+			if err != nil {
+				return fmt.Errorf("failed to encode uptime history of %s: %w", name, err)
+			}
+			cm.Data[name] = string(raw)
+		}
+
+		if create {
+			_, err = configMaps.Create(ctx, cm, metav1.CreateOptions{})
+			if apierrors.IsAlreadyExists(err) {
+				return apierrors.NewConflict(corev1.Resource("configmaps"), s.name, err)
+			}
+			return err
+		}
+		_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// =============================================================================
+// 🧪 IN-MEMORY STORE
+// =============================================================================
+
+// MemoryStore keeps uptime history in memory (tests, local development)
+type MemoryStore struct {
+	mu   sync.Mutex
+	days map[string][]Day
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{days: map[string][]Day{}}
+}
+
+// Record implements Store
+func (s *MemoryStore) Record(ctx context.Context, checks map[string]bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for name, up := range checks {
+		s.days[name] = addCheck(s.days[name], up, now)
+	}
+	return nil
+}
+
+// History implements Store
+func (s *MemoryStore) History(ctx context.Context) (map[string][]Day, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	history := make(map[string][]Day, len(s.days))
+	for name, days := range s.days {
+		history[name] = append([]Day(nil), days...)
+	}
+	return history, nil
+}
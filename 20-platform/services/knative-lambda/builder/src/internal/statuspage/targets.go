@@ -0,0 +1,87 @@
+package statuspage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// =============================================================================
+// 🎯 MONITORED SERVICES
+// =============================================================================
+// What the status page polls: a readiness URL per service, plus optionally its
+// Prometheus endpoint and the metric families worth showing publicly
+
+// Target is a service shown on the status page
+type Target struct {
+	Name       string   `json:"name"`
+	ReadyURL   string   `json:"readyUrl"`             // 2xx = up
+	MetricsURL string   `json:"metricsUrl,omitempty"` // Prometheus text endpoint
+	Metrics    []string `json:"metrics,omitempty"`    // Families shown (summed over labels)
+}
+
+// DefaultTargets are the builder and the stooges (Helm releases moe, larry and
+// curly in the stooges namespace), by in-cluster DNS name
+var DefaultTargets = []Target{
+	{
+		Name:       "knative-lambda-builder",
+		ReadyURL:   "http://knative-lambda-builder.knative-lambda.svc.cluster.local/readyz",
+		MetricsURL: "http://knative-lambda-builder.knative-lambda.svc.cluster.local/metrics",
+		Metrics: []string{
+			"knative_lambda_builder_events_received_total",
+			"knative_lambda_builder_build_preemptions_total",
+		},
+	},
+	{
+		Name:       "moe",
+		ReadyURL:   "http://moe-moe-service.stooges.svc.cluster.local:8080/health",
+		MetricsURL: "http://moe-moe-service.stooges.svc.cluster.local:8080/metrics",
+		Metrics:    []string{"moe_requests_total", "moe_larry_calls_total"},
+	},
+	{
+		Name:       "larry",
+		ReadyURL:   "http://larry-larry-service.stooges.svc.cluster.local:8081/health",
+		MetricsURL: "http://larry-larry-service.stooges.svc.cluster.local:8081/metrics",
+		Metrics:    []string{"larry_requests_total", "larry_curly_calls_total"},
+	},
+	{
+		Name:       "curly",
+		ReadyURL:   "http://curly-curly-service.stooges.svc.cluster.local:8082/health",
+		MetricsURL: "http://curly-curly-service.stooges.svc.cluster.local:8082/metrics",
+		Metrics:    []string{"curly_requests_total", "curly_processed_items_total"},
+	},
+}
+
+// validName matches names usable as ConfigMap keys (the uptime store's)
+var validName = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+// LoadTargets reads targets from a JSON file (DefaultTargets if path is empty)
+func LoadTargets(path string) ([]Target, error) {
+	if path == "" {
+		return DefaultTargets, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read targets: %w", err)
+	}
+	var targets []Target
+	if err := json.Unmarshal(raw, &targets); err != nil {
+		return nil, fmt.Errorf("failed to parse targets %s: %w", path, err)
+	}
+
+	seen := map[string]bool{}
+	for i, t := range targets {
+		if t.Name == "" || t.ReadyURL == "" {
+			return nil, fmt.Errorf("target %d: name and readyUrl are required", i)
+		}
+		if !validName.MatchString(t.Name) {
+			return nil, fmt.Errorf("target %q: name may only contain [-._a-zA-Z0-9]", t.Name)
+		}
+		if seen[t.Name] {
+			return nil, fmt.Errorf("target %q is defined twice", t.Name)
+		}
+		seen[t.Name] = true
+	}
+	return targets, nil
+}
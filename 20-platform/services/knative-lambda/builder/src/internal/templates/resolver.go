@@ -0,0 +1,199 @@
+package templates
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"knative-lambda-builder/storage"
+	bundled "knative-lambda-builder/templates"
+)
+
+// =============================================================================
+// 🗂️ TEMPLATE OVERRIDE HIERARCHY
+// =============================================================================
+// Templates are resolved by file name through three layers, highest priority
+// first:
+//  1. Remote source (e.g. s3://bucket/prefix/job.yaml.tpl), if configured
+//  2. Mounted files at the configured path (TEMPLATES_DIR, *_TEMPLATE_PATH)
+//  3. The defaults embedded in the binary
+//
+// A layer that is missing, unreadable or unreachable is skipped with a warning,
+// so a broken mount or bucket degrades to the defaults instead of failing builds.
+// So is a remote template without a supported schemaVersion stamp
+// 📝 NOTE: A parser runtime's templates live in runtimes/<runtime>/ and are
+// looked up by that path, e.g. runtimes/python/Dockerfile.tpl
+
+// RuntimesDir holds the templates of each parser runtime
+const RuntimesDir = "runtimes"
+
+// remoteTimeout bounds a single remote read
+const remoteTimeout = 5 * time.Second
+
+// RemoteSource fetches template overrides from object storage
+// 📝 NOTE: storage.ObjectStore satisfies it
+type RemoteSource interface {
+	Get(ctx context.Context, bucket, key string) (io.ReadCloser, error)
+}
+
+// Resolver reads templates through the override hierarchy
+type Resolver struct {
+	remote       RemoteSource // nil = no remote layer
+	remoteBucket string
+	remotePrefix string
+	embedded     fs.FS
+
+	mu     sync.Mutex
+	logged map[string]string // template path -> layer last used (log on change only)
+}
+
+// NewResolver creates a resolver over mounted files and the embedded defaults
+func NewResolver() *Resolver {
+	return &Resolver{embedded: bundled.FS, logged: map[string]string{}}
+}
+
+// WithRemote adds a remote layer: templates are looked up as bucket/prefix/<file name>
+func (r *Resolver) WithRemote(remote RemoteSource, bucket, prefix string) *Resolver {
+	r.remote = remote
+	r.remoteBucket = bucket
+	r.remotePrefix = prefix
+	return r
+}
+
+// defaultResolver is used by RenderFile and ReadFile
+var defaultResolver = NewResolver()
+
+// Use replaces the resolver used by RenderFile and ReadFile (call at startup)
+func Use(r *Resolver) {
+	defaultResolver = r
+}
+
+// ReadFile returns a template's content through the override hierarchy
+func ReadFile(path string) ([]byte, error) {
+	return defaultResolver.Read(path)
+}
+
+// Name returns the name a template is looked up by in the remote and embedded
+// layers: its file name, under runtimes/<runtime>/ for a runtime's templates
+func Name(templatePath string) string {
+	dir, file := filepath.Split(filepath.Clean(templatePath))
+	runtimeDir := filepath.Dir(filepath.Clean(dir))
+	if dir != "" && filepath.Base(runtimeDir) == RuntimesDir {
+		return path.Join(RuntimesDir, filepath.Base(dir), file)
+	}
+	return file
+}
+
+// Read returns a template's content from the highest priority layer that has it
+func (r *Resolver) Read(templatePath string) ([]byte, error) {
+	name := Name(templatePath)
+
+	// =========================================================================
+	// 📍 LAYER 1: REMOTE OVERRIDE
+	// =========================================================================
+	if r.remote != nil {
+		key := path.Join(r.remotePrefix, name)
+		uri := fmt.Sprintf("s3://%s/%s", r.remoteBucket, key)
+		content, err := r.readRemote(key)
+		if err == nil {
+			// 🔖 The bucket can change after the startup check: a template for
+			// another builder version must not be rendered
+			err = checkSchemaVersion(uri, content)
+		}
+		switch {
+		case err == nil:
+			r.used(templatePath, uri)
+			return content, nil
+		case !errors.Is(err, storage.ErrNotFound):
+			log.Printf("WARNING: Failed to read template %s, falling back: %v", uri, err)
+		}
+	}
+
+	// =========================================================================
+	// 📍 LAYER 2: MOUNTED FILE
+	// =========================================================================
+	content, err := os.ReadFile(templatePath)
+	if err == nil {
+		r.used(templatePath, templatePath)
+		return content, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("WARNING: Failed to read template %s, falling back: %v", templatePath, err)
+	}
+
+	// =========================================================================
+	// 📍 LAYER 3: EMBEDDED DEFAULT
+	// =========================================================================
+	content, err = fs.ReadFile(r.embedded, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template %s: not mounted and no embedded default", templatePath)
+	}
+	r.used(templatePath, "embedded:"+name)
+	return content, nil
+}
+
+// readRemote fetches one object from the remote layer
+func (r *Resolver) readRemote(key string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout)
+	defer cancel()
+
+	body, err := r.remote.Get(ctx, r.remoteBucket, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, body); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// used logs which layer a template came from, when it changes
+func (r *Resolver) used(templatePath, layer string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.logged[templatePath] != layer {
+		r.logged[templatePath] = layer
+		log.Printf("📄 Template %s resolved from %s", templatePath, layer)
+	}
+}
+
+// ParseS3URI splits s3://bucket/prefix into bucket and prefix
+func ParseS3URI(uri string) (bucket, prefix string, err error) {
+	rest, ok := strings.CutPrefix(uri, "s3://")
+	if !ok {
+		return "", "", fmt.Errorf("invalid S3 URI %q: expected s3://bucket/prefix", uri)
+	}
+	bucket, prefix, _ = strings.Cut(rest, "/")
+	if bucket == "" {
+		return "", "", fmt.Errorf("invalid S3 URI %q: missing bucket", uri)
+	}
+	return bucket, strings.Trim(prefix, "/"), nil
+}
+
+// EmbeddedPaths lists the embedded defaults as paths under dir
+// 🎯 PURPOSE: Check a bundle's compatibility even when nothing is mounted
+func EmbeddedPaths(dir string) []string {
+	var paths []string
+	fs.WalkDir(bundled.FS, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() && strings.HasSuffix(name, ".tpl") {
+			paths = append(paths, filepath.Join(dir, filepath.FromSlash(name)))
+		}
+		return nil
+	})
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,93 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"knative-lambda-builder/storage"
+)
+
+func TestResolverHierarchy(t *testing.T) {
+	dir := t.TempDir()
+	mounted := filepath.Join(dir, "job.yaml.tpl")
+	if err := os.WriteFile(mounted, []byte("mounted"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	read := func(r *Resolver, path string) string {
+		t.Helper()
+		content, err := r.Read(path)
+		if err != nil {
+			t.Fatalf("Read(%s): %v", path, err)
+		}
+		return string(content)
+	}
+
+	// Nothing mounted: embedded default
+	if got := read(NewResolver(), filepath.Join(dir, "service.yaml.tpl")); !strings.Contains(got, "kind: Service") {
+		t.Errorf("expected the embedded service template, got %q", got)
+	}
+	// Mounted file overrides the default
+	if got := read(NewResolver(), mounted); got != "mounted" {
+		t.Errorf("expected the mounted template, got %q", got)
+	}
+
+	// Remote overrides both, by file name under the prefix
+	remote := storage.NewFakeObjectStore()
+	remote.Seed("templates", "v2/job.yaml.tpl", []byte(stamped(MaxSchemaVersion)+"remote"))
+	r := NewResolver().WithRemote(remote, "templates", "v2")
+	if got := read(r, mounted); got != stamped(MaxSchemaVersion)+"remote" {
+		t.Errorf("expected the remote template, got %q", got)
+	}
+	if got := read(r, filepath.Join(dir, "Dockerfile.tpl")); !strings.Contains(got, "FROM") {
+		t.Errorf("expected the embedded Dockerfile when the remote has none, got %q", got)
+	}
+
+	// A remote template for another builder version degrades to the next layer
+	for _, version := range []string{stamped(MaxSchemaVersion + 1), stamped(MinSchemaVersion - 1), ""} {
+		remote.Seed("templates", "v2/job.yaml.tpl", []byte(version+"remote"))
+		if got := read(r, mounted); got != "mounted" {
+			t.Errorf("expected the mounted template over a remote one stamped %q, got %q", version, got)
+		}
+	}
+	remote.Seed("templates", "v2/service.yaml.tpl", []byte(stamped(MaxSchemaVersion+1)+"remote"))
+	if got := read(r, filepath.Join(dir, "service.yaml.tpl")); !strings.Contains(got, "kind: Service") {
+		t.Errorf("expected the embedded service template over an unsupported remote one, got %q", got)
+	}
+
+	// An unreachable remote degrades to the next layer
+	remote.Err = errors.New("connection refused")
+	if got := read(r, mounted); got != "mounted" {
+		t.Errorf("expected the mounted template with a broken remote, got %q", got)
+	}
+
+	// A runtime's templates are looked up under runtimes/<runtime>/
+	if got := read(NewResolver(), filepath.Join(dir, RuntimesDir, "python", "Dockerfile.tpl")); !strings.Contains(got, "pip install") {
+		t.Errorf("expected the embedded Python Dockerfile, got %q", got)
+	}
+	if name := Name("/templates/runtimes/go/main.go.tpl"); name != "runtimes/go/main.go.tpl" {
+		t.Errorf("Name = %q, want the path under runtimes/", name)
+	}
+
+	if _, err := NewResolver().Read(filepath.Join(dir, "unknown.tpl")); err == nil {
+		t.Errorf("expected an error for a template with no layer")
+	}
+}
+
+// stamped returns a schemaVersion stamp line
+func stamped(version int) string {
+	return fmt.Sprintf("{{- /* schemaVersion: %d */ -}}\n", version)
+}
+
+func TestParseS3URI(t *testing.T) {
+	bucket, prefix, err := ParseS3URI("s3://chart-templates/knative-lambda/v2/")
+	if err != nil || bucket != "chart-templates" || prefix != "knative-lambda/v2" {
+		t.Errorf("ParseS3URI = %q, %q, %v", bucket, prefix, err)
+	}
+	if _, _, err := ParseS3URI("https://example.com/templates"); err == nil {
+		t.Errorf("expected an error for a non-S3 URI")
+	}
+}
@@ -0,0 +1,57 @@
+package templates
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// =============================================================================
+// 📝 TEMPLATE PROCESSING
+// =============================================================================
+// This package renders the Go text/templates used by the builder
+// 🎯 PURPOSE: One place to load and execute job/service/trigger/context templates
+
+// RenderFile loads a template and executes it with the given data
+// 🎯 PURPOSE: Turn a *.tpl file into the final manifest or source file
+// 📝 NOTE: path is resolved through the override hierarchy (see resolver.go)
+func RenderFile(path string, data interface{}) ([]byte, error) {
+	content, err := ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Render(filepath.Base(path), string(content), data)
+}
+
+// funcs are the helpers available to every template
+// 📝 NOTE: toJson renders values as inline JSON, which is valid YAML;
+// shellQuote single-quotes a value for a shell command line
+var funcs = template.FuncMap{
+	"toJson": func(v interface{}) (string, error) {
+		raw, err := json.Marshal(v)
+		return string(raw), err
+	},
+	"shellQuote": func(s string) string {
+		return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+	},
+}
+
+// Render executes an in-memory template with the given data
+// 📝 NOTE: missingkey=error makes typos in templates fail loudly
+func Render(name, content string, data interface{}) ([]byte, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(funcs).Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to execute template %s: %w", name, err)
+	}
+
+	return buf.Bytes(), nil
+}
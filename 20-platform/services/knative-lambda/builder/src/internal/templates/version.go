@@ -0,0 +1,100 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// =============================================================================
+// 🔖 TEMPLATE SCHEMA VERSIONS
+// =============================================================================
+// Templates and the data the builder passes them evolve together. Every
+// template starts with a stamp (a template comment, so it renders to nothing):
+//
+//	{{- /* schemaVersion: 1 */ -}}
+//
+// The builder checks the stamps at startup so a template bundle from a newer
+// chart mounted into an older builder fails fast instead of at the first build
+// 📝 NOTE: Bump MaxSchemaVersion (and the stamps) whenever template data changes
+// incompatibly; raise MinSchemaVersion when the builder drops support for old templates
+
+// Supported template schema versions
+// 📝 NOTE: 2 added Sidecars/SharedVolume/SharedMountPath to the service template data,
+// 3 added DeployMode/MinReplicas/MaxReplicas/TargetCPUUtilization, 4 added RebuiltAt,
+// 5 added Retry to the job template data, 6 added ActiveDeadlineSeconds,
+// 7 added CacheRepo/CacheTTL, 8 added BuildKitAddr/BuildKitImage/BuildSecrets,
+// 9 added Platforms/NodeArch, 10 added Tag to the job and test job template data,
+// 11 added BuildArgs to the job template data and Env to the service template data,
+// 12 added RuntimeImage to the wrapper template data, 13 added ImageLabels to
+// the job template data, 14 added NpmrcSecret to the job template data and
+// Npmrc/Backend to the wrapper template data, 15 added RegistryAuthSecret to
+// the job template data, 16 added InsecureRegistry to the job template data
+// (and RegistryAuthSecret to the BuildKit job), 17 added PushRole/PushRoleSecret/
+// PushRoleKey to the job template data, 18 added ContextURL to the job template data,
+// 19 added PushRegion to the job template data, 20 added S3Endpoint/S3ForcePathStyle
+// to the job template data, 21 added Namespace to the service template data,
+// 22 added Resources to the job template data
+const (
+	MinSchemaVersion = 1
+	MaxSchemaVersion = 22
+)
+
+// schemaVersionStamp matches the stamp on a template's first line
+var schemaVersionStamp = regexp.MustCompile(`^\{\{-?\s*/\*\s*schemaVersion:\s*(\d+)\s*\*/\s*-?\}\}`)
+
+// SchemaVersion returns the version a template is stamped with
+func SchemaVersion(content []byte) (int, bool) {
+	match := schemaVersionStamp.FindSubmatch(content)
+	if match == nil {
+		return 0, false
+	}
+	version, err := strconv.Atoi(string(match[1]))
+	if err != nil {
+		return 0, false
+	}
+	return version, true
+}
+
+// CheckCompatibility verifies every template is stamped with a supported version
+// 📝 NOTE: Templates are read through the override hierarchy, so this checks
+// what will actually be rendered
+// 🎯 PURPOSE: Report every offending template at once with what to do about it
+func CheckCompatibility(paths ...string) error {
+	var errs []error
+	seen := map[string]bool{}
+	for _, path := range paths {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		content, err := ReadFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if err := checkSchemaVersion(path, content); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkSchemaVersion verifies a template is stamped with a supported version
+func checkSchemaVersion(path string, content []byte) error {
+	version, ok := SchemaVersion(content)
+	switch {
+	case !ok:
+		return fmt.Errorf("template %s has no schemaVersion stamp (expected {{- /* schemaVersion: N */ -}} on its first line)", path)
+	case version > MaxSchemaVersion:
+		return fmt.Errorf("template %s has schemaVersion %d but this builder supports %d to %d: upgrade the builder to the chart's version",
+			path, version, MinSchemaVersion, MaxSchemaVersion)
+	case version < MinSchemaVersion:
+		return fmt.Errorf("template %s has schemaVersion %d but this builder supports %d to %d: upgrade the templates",
+			path, version, MinSchemaVersion, MaxSchemaVersion)
+	}
+	return nil
+}
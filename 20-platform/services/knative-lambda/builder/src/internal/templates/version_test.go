@@ -0,0 +1,46 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBundledTemplatesAreCompatible(t *testing.T) {
+	paths, err := filepath.Glob("../../templates/*.tpl")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no templates found: %v", err)
+	}
+	if err := CheckCompatibility(paths...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	current := write("current.tpl", "{{- /* schemaVersion: 1 */ -}}\nkind: Job\n")
+	newer := write("newer.tpl", "{{/* schemaVersion: 99 */}}\nkind: Job\n")
+	unstamped := write("unstamped.tpl", "kind: Job\n")
+
+	if err := CheckCompatibility(current); err != nil {
+		t.Errorf("current template rejected: %v", err)
+	}
+	err := CheckCompatibility(current, newer, unstamped)
+	if err == nil || !strings.Contains(err.Error(), "schemaVersion 99") || !strings.Contains(err.Error(), "no schemaVersion stamp") {
+		t.Errorf("expected both incompatible templates to be reported, got %v", err)
+	}
+
+	// The stamp renders to nothing
+	out, err := Render("current.tpl", "{{- /* schemaVersion: 1 */ -}}\nkind: Job\n", nil)
+	if err != nil || string(out) != "kind: Job\n" {
+		t.Errorf("Render = %q, %v", out, err)
+	}
+}
@@ -0,0 +1,62 @@
+package tenants
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// =============================================================================
+// 🚦 PER-TENANT DEPLOY STRATEGIES
+// =============================================================================
+// How a redeploy moves a parser's traffic to its new Knative revision;
+// tenants may have their own (deployStrategy in their record), and a build
+// may pick one for itself
+
+// Deploy strategies
+const (
+	DeployStrategyRolling   = "rolling"    // All traffic at once
+	DeployStrategyCanary    = "canary"     // In CANARY_STEPS steps
+	DeployStrategyBlueGreen = "blue-green" // Smoke test the new revision, then all traffic at once
+)
+
+// DeployStrategies are the known deploy strategies
+var DeployStrategies = []string{DeployStrategyRolling, DeployStrategyCanary, DeployStrategyBlueGreen}
+
+// ParseDeployStrategy checks a deploy strategy ("" is the default)
+func ParseDeployStrategy(value string) (string, error) {
+	for _, strategy := range DeployStrategies {
+		if value == "" || value == strategy {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("invalid deployStrategy %q: must be one of %s", value, strings.Join(DeployStrategies, ", "))
+}
+
+// DeployStrategyPolicy resolves the deploy strategy of tenants
+// (implements services.StrategyPolicy)
+type DeployStrategyPolicy struct {
+	store    Store
+	fallback string
+}
+
+// NewDeployStrategyPolicy creates a strategy policy falling back to the given default
+func NewDeployStrategyPolicy(store Store, fallback string) *DeployStrategyPolicy {
+	return &DeployStrategyPolicy{store: store, fallback: fallback}
+}
+
+// DeployStrategy returns a tenant's strategy (the default if it has none or isn't registered)
+func (p *DeployStrategyPolicy) DeployStrategy(ctx context.Context, thirdPartyId string) (string, error) {
+	tenant, err := p.store.Get(ctx, thirdPartyId)
+	if errors.Is(err, ErrNotFound) {
+		return p.fallback, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if tenant.DeployStrategy == "" {
+		return p.fallback, nil
+	}
+	return ParseDeployStrategy(tenant.DeployStrategy)
+}
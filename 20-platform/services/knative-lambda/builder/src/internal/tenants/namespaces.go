@@ -0,0 +1,189 @@
+package tenants
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// =============================================================================
+// 🏘️ TENANT NAMESPACES
+// =============================================================================
+// With TENANT_NAMESPACES on, a tenant's parsers (Services, fallback
+// Deployments) run in the tenant's own namespace (NamespaceFor), created and
+// labelled on the first deploy, with a ResourceQuota and a NetworkPolicy
+// 🎯 WHY: One tenant's parsers can't use up the cluster, or be reached from
+// another tenant's
+// 📝 NOTE: The quota and the policy are brought in line with the platform's
+// settings on every deploy; edits made to them by hand don't last
+// 💡 EXAMPLE: TENANT_NAMESPACE_QUOTA="requests.cpu=4,requests.memory=8Gi,pods=20"
+
+// Names of the objects every tenant namespace gets
+const (
+	NamespaceQuotaName  = "knative-lambda-quota"
+	NetworkPolicyName   = "knative-lambda-ingress"
+	LabelNamespaceOwner = "app.kubernetes.io/part-of"
+	NamespaceOwner      = "knative-lambda"
+)
+
+// NamespacePolicy is what every tenant namespace gets besides its labels
+type NamespacePolicy struct {
+	Quota             corev1.ResourceList // ResourceQuota hard limits (empty = no quota)
+	AllowedNamespaces []string            // Namespaces whose pods may reach the parsers (empty = no NetworkPolicy)
+}
+
+// NamespaceLabels returns the labels of a tenant's namespace
+func NamespaceLabels(thirdPartyId string) map[string]string {
+	return map[string]string{
+		LabelThirdPartyId:   thirdPartyId,
+		LabelNamespaceOwner: NamespaceOwner,
+	}
+}
+
+// ParseQuota parses "resource=quantity" pairs, e.g. "requests.cpu=4,pods=20"
+func ParseQuota(value string) (corev1.ResourceList, error) {
+	quota := corev1.ResourceList{}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, quantity, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("quota %q is not resource=quantity", pair)
+		}
+		q, err := resource.ParseQuantity(strings.TrimSpace(quantity))
+		if err != nil {
+			return nil, fmt.Errorf("quota %q: %w", pair, err)
+		}
+		quota[corev1.ResourceName(strings.TrimSpace(name))] = q
+	}
+	return quota, nil
+}
+
+// EnsureNamespace creates (or relabels) a tenant's namespace and applies the
+// policy's ResourceQuota and NetworkPolicy to it
+func EnsureNamespace(ctx context.Context, clientset kubernetes.Interface, thirdPartyId string, policy NamespacePolicy) error {
+	name := NamespaceFor(thirdPartyId)
+	labels := NamespaceLabels(thirdPartyId)
+
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	_, err := clientset.CoreV1().Namespaces().Create(ctx, namespace, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		err = relabelNamespace(ctx, clientset, name, labels)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create namespace %s: %w", name, err)
+	}
+
+	if len(policy.Quota) > 0 {
+		quota := &corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: NamespaceQuotaName, Namespace: name, Labels: labels},
+			Spec:       corev1.ResourceQuotaSpec{Hard: policy.Quota},
+		}
+		if err := applyQuota(ctx, clientset, quota); err != nil {
+			return fmt.Errorf("failed to apply the quota of namespace %s: %w", name, err)
+		}
+	}
+	if len(policy.AllowedNamespaces) > 0 {
+		if err := applyNetworkPolicy(ctx, clientset, ingressPolicy(name, labels, policy.AllowedNamespaces)); err != nil {
+			return fmt.Errorf("failed to apply the network policy of namespace %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// relabelNamespace adds the tenant labels to an existing namespace
+// 📝 NOTE: Labels set by others are kept
+func relabelNamespace(ctx context.Context, clientset kubernetes.Interface, name string, labels map[string]string) error {
+	live, err := clientset.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if owner := live.Labels[LabelThirdPartyId]; owner != "" && owner != labels[LabelThirdPartyId] {
+		return fmt.Errorf("namespace belongs to tenant %s", owner)
+	}
+	changed := false
+	for key, value := range labels {
+		if live.Labels[key] != value {
+			if live.Labels == nil {
+				live.Labels = map[string]string{}
+			}
+			live.Labels[key] = value
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	_, err = clientset.CoreV1().Namespaces().Update(ctx, live, metav1.UpdateOptions{})
+	return err
+}
+
+// applyQuota creates a ResourceQuota, or updates the existing one
+func applyQuota(ctx context.Context, clientset kubernetes.Interface, quota *corev1.ResourceQuota) error {
+	quotas := clientset.CoreV1().ResourceQuotas(quota.Namespace)
+	_, err := quotas.Create(ctx, quota, metav1.CreateOptions{})
+	if !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	live, err := quotas.Get(ctx, quota.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	live.Labels, live.Spec = quota.Labels, quota.Spec
+	_, err = quotas.Update(ctx, live, metav1.UpdateOptions{})
+	return err
+}
+
+// applyNetworkPolicy creates a NetworkPolicy, or updates the existing one
+func applyNetworkPolicy(ctx context.Context, clientset kubernetes.Interface, policy *networkingv1.NetworkPolicy) error {
+	policies := clientset.NetworkingV1().NetworkPolicies(policy.Namespace)
+	_, err := policies.Create(ctx, policy, metav1.CreateOptions{})
+	if !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	live, err := policies.Get(ctx, policy.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	live.Labels, live.Spec = policy.Labels, policy.Spec
+	_, err = policies.Update(ctx, live, metav1.UpdateOptions{})
+	return err
+}
+
+// ingressPolicy only lets pods of the namespace itself and of the allowed
+// namespaces (Knative's ingress and activator, the brokers, the builder)
+// reach the namespace's pods
+func ingressPolicy(namespace string, labels map[string]string, allowed []string) *networkingv1.NetworkPolicy {
+	sources := []string{namespace}
+	for _, name := range allowed {
+		if name != namespace {
+			sources = append(sources, name)
+		}
+	}
+	sort.Strings(sources[1:])
+
+	peers := make([]networkingv1.NetworkPolicyPeer, 0, len(sources))
+	for _, source := range sources {
+		peers = append(peers, networkingv1.NetworkPolicyPeer{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{corev1.LabelMetadataName: source}},
+		})
+	}
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: NetworkPolicyName, Namespace: namespace, Labels: labels},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{}, // Every pod of the namespace
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress:     []networkingv1.NetworkPolicyIngressRule{{From: peers}},
+		},
+	}
+}
@@ -0,0 +1,57 @@
+package tenants
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestEnsureNamespace(t *testing.T) {
+	ctx := context.Background()
+	quota, err := ParseQuota("requests.cpu=4, requests.memory=8Gi,pods=20")
+	if err != nil {
+		t.Fatalf("ParseQuota() = %v", err)
+	}
+	if _, err := ParseQuota("pods"); err == nil {
+		t.Error("ParseQuota() of a pair without quantity succeeded")
+	}
+	policy := NamespacePolicy{Quota: quota, AllowedNamespaces: []string{"knative-serving", "lambda-acme"}}
+
+	// A namespace created by someone else gets the tenant labels, and keeps its own
+	clientset := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "lambda-acme",
+		Labels: map[string]string{"team": "integrations"}}})
+	for i := 0; i < 2; i++ {
+		if err := EnsureNamespace(ctx, clientset, "acme", policy); err != nil {
+			t.Fatalf("EnsureNamespace() #%d = %v", i+1, err)
+		}
+	}
+	namespace, _ := clientset.CoreV1().Namespaces().Get(ctx, "lambda-acme", metav1.GetOptions{})
+	if namespace.Labels[LabelThirdPartyId] != "acme" || namespace.Labels["team"] != "integrations" {
+		t.Errorf("namespace labels = %v", namespace.Labels)
+	}
+
+	live, err := clientset.CoreV1().ResourceQuotas("lambda-acme").Get(ctx, NamespaceQuotaName, metav1.GetOptions{})
+	if err != nil || live.Spec.Hard.Pods().Value() != 20 {
+		t.Errorf("quota = %+v, %v, want 20 pods", live, err)
+	}
+	netpol, err := clientset.NetworkingV1().NetworkPolicies("lambda-acme").Get(ctx, NetworkPolicyName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("network policy: %v", err)
+	}
+	var from []string
+	for _, peer := range netpol.Spec.Ingress[0].From {
+		from = append(from, peer.NamespaceSelector.MatchLabels[corev1.LabelMetadataName])
+	}
+	if len(from) != 2 || from[0] != "lambda-acme" || from[1] != "knative-serving" {
+		t.Errorf("ingress allowed from %v, want the namespace itself and knative-serving", from)
+	}
+
+	// Another tenant's namespace is never taken over
+	if err := EnsureNamespace(ctx, fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "lambda-acme",
+		Labels: map[string]string{LabelThirdPartyId: "other"}}}), "acme", policy); err == nil {
+		t.Error("EnsureNamespace() of another tenant's namespace succeeded")
+	}
+}
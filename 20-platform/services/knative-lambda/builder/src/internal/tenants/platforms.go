@@ -0,0 +1,70 @@
+package tenants
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// =============================================================================
+// 🖥️ PER-TENANT BUILD PLATFORMS
+// =============================================================================
+// The platforms parser images are built for ("linux/amd64,linux/arm64");
+// tenants may have their own (platforms in their record), e.g. to run their
+// parsers on Graviton nodes
+
+// SupportedPlatforms are the platforms images can be built for
+var SupportedPlatforms = []string{"linux/amd64", "linux/arm64"}
+
+// ParsePlatforms parses a comma separated platform list ("" is nil)
+// 📝 NOTE: The result is sorted and without duplicates
+func ParsePlatforms(value string) ([]string, error) {
+	seen := map[string]bool{}
+	var platforms []string
+	for _, platform := range strings.Split(value, ",") {
+		platform = strings.TrimSpace(platform)
+		if platform == "" || seen[platform] {
+			continue
+		}
+		supported := false
+		for _, p := range SupportedPlatforms {
+			supported = supported || p == platform
+		}
+		if !supported {
+			return nil, fmt.Errorf("invalid platforms %q: %q is not one of %s", value, platform, strings.Join(SupportedPlatforms, ", "))
+		}
+		seen[platform] = true
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+	return platforms, nil
+}
+
+// BuildPlatforms resolves the build platforms of tenants
+// (implements build.PlatformPolicy)
+type BuildPlatforms struct {
+	store    Store
+	fallback []string
+}
+
+// NewBuildPlatforms creates a platform policy falling back to the given default
+func NewBuildPlatforms(store Store, fallback []string) *BuildPlatforms {
+	return &BuildPlatforms{store: store, fallback: fallback}
+}
+
+// BuildPlatforms returns a tenant's platforms (the default if it has none or isn't registered)
+func (p *BuildPlatforms) BuildPlatforms(ctx context.Context, thirdPartyId string) ([]string, error) {
+	tenant, err := p.store.Get(ctx, thirdPartyId)
+	if errors.Is(err, ErrNotFound) {
+		return p.fallback, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tenant.Platforms == "" {
+		return p.fallback, nil
+	}
+	return ParsePlatforms(tenant.Platforms)
+}
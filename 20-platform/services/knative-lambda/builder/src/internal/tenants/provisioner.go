@@ -0,0 +1,464 @@
+package tenants
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"knative-lambda-builder/internal/aws"
+	"knative-lambda-builder/internal/awserrors"
+	"knative-lambda-builder/internal/config"
+)
+
+// =============================================================================
+// 🧰 TENANT PROVISIONING
+// =============================================================================
+// Onboarding a thirdPartyId touches S3, ECR, Kubernetes and the registry.
+// Every step is idempotent so "tenant create" can be re-run safely, and the
+// outcome of each step is collected into a report instead of failing fast.
+
+// Step outcomes reported back to the operator
+const (
+	StepCreated   = "created"
+	StepExists    = "exists"
+	StepUpdated   = "updated"
+	StepValidated = "validated" // Checked, nothing to create
+	StepSkipped   = "skipped"
+	StepFailed    = "failed"
+)
+
+// Labels applied to everything created for a tenant (and its parsers)
+const (
+	LabelThirdPartyId = "knative-lambda.notifi.network/third-party-id"
+	LabelParserId     = "knative-lambda.notifi.network/parser-id"
+)
+
+// Request describes a tenant to onboard
+type Request struct {
+	ThirdPartyId        string              `json:"thirdPartyId"`
+	RoleARN             string              `json:"roleArn,omitempty"`             // Optional IAM role allowed to read/write the S3 prefix
+	SourceRoleARN       string              `json:"sourceRoleArn,omitempty"`       // Optional IAM role the builder assumes to read sources
+	SourceBucket        string              `json:"sourceBucket,omitempty"`        // Optional tenant-owned source bucket
+	NotificationChannel string              `json:"notificationChannel,omitempty"` // Optional http(s) URL for build notifications
+	KMSKeyARN           string              `json:"kmsKeyArn,omitempty"`           // Optional KMS key encrypting build records and artifacts
+	ContextRetention    string              `json:"contextRetention,omitempty"`    // Optional build context retention ("0s" deletes them once built)
+	Sidecars            map[string][]string `json:"sidecars,omitempty"`            // Optional catalog sidecars per parserId ("*" = every parser)
+	BuildRateLimit      string              `json:"buildRateLimit,omitempty"`      // Optional builds per period ("30/1h")
+	Platforms           string              `json:"platforms,omitempty"`           // Optional platforms images are built for ("linux/amd64,linux/arm64")
+	DeployStrategy      string              `json:"deployStrategy,omitempty"`      // Optional deploy strategy (rolling, canary or blue-green)
+	BuildRequests       string              `json:"buildRequests,omitempty"`       // Optional requests of build pods ("cpu=1,memory=2Gi")
+	BuildLimits         string              `json:"buildLimits,omitempty"`         // Optional limits of build pods ("memory=6Gi")
+}
+
+// StepResult is the outcome of a single provisioning step
+type StepResult struct {
+	Step    string `json:"step"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// Report summarizes a provisioning run
+type Report struct {
+	Tenant  *Tenant      `json:"tenant,omitempty"`
+	Steps   []StepResult `json:"steps"`
+	Success bool         `json:"success"`
+}
+
+func (r *Report) add(step, status, message string) {
+	r.Steps = append(r.Steps, StepResult{Step: step, Status: status, Message: message})
+	if status == StepFailed {
+		r.Success = false
+	}
+}
+
+// RepositoryEnsurer creates image repositories (implemented by build.Orchestrator)
+type RepositoryEnsurer interface {
+	// EnsureRepository creates a tenant's repository if it is missing, and
+	// reports whether it did
+	EnsureRepository(ctx context.Context, thirdPartyId string) (created bool, err error)
+	RepositoryName(thirdPartyId string) string
+}
+
+// SidecarCatalog lists the sidecars tenants may pick (implemented by sidecars.Catalog)
+type SidecarCatalog interface {
+	Has(name string) bool
+}
+
+// Provisioner onboards tenants
+type Provisioner struct {
+	cfg          *config.Config
+	awsClient    *aws.Client
+	clientset    kubernetes.Interface
+	repositories RepositoryEnsurer
+	store        Store
+	sidecars     SidecarCatalog
+}
+
+// NewProvisioner creates a new tenant provisioner
+func NewProvisioner(cfg *config.Config, awsClient *aws.Client, clientset kubernetes.Interface,
+	repositories RepositoryEnsurer, store Store) *Provisioner {
+	return &Provisioner{
+		cfg:          cfg,
+		awsClient:    awsClient,
+		clientset:    clientset,
+		repositories: repositories,
+		store:        store,
+	}
+}
+
+// WithSidecarCatalog sets the catalog tenant sidecars are checked against
+// (without one, tenants can't declare sidecars)
+func (p *Provisioner) WithSidecarCatalog(catalog SidecarCatalog) *Provisioner {
+	p.sidecars = catalog
+	return p
+}
+
+// validateSidecars rejects sidecars that aren't in the catalog
+func (p *Provisioner) validateSidecars(selection map[string][]string) error {
+	for parserId, names := range selection {
+		for _, name := range names {
+			if p.sidecars == nil || !p.sidecars.Has(name) {
+				return fmt.Errorf("sidecar %q (parser %q) is not in the sidecar catalog", name, parserId)
+			}
+		}
+	}
+	return nil
+}
+
+// NamespaceFor returns the Kubernetes namespace reserved for a tenant
+func NamespaceFor(thirdPartyId string) string {
+	return "lambda-" + thirdPartyId
+}
+
+// Provision runs every onboarding step for a tenant
+// 📋 STEPS:
+//  1. S3 prefix (+ bucket policy statement when a role is given), or the
+//     source role check when the tenant keeps its own sources
+//  2. ECR repository
+//  3. Kubernetes namespace and service account
+//  4. Notification channel validation
+//  5. KMS key access check
+//  6. Tenant record in the registry
+func (p *Provisioner) Provision(ctx context.Context, req Request) (*Report, error) {
+	if err := ValidateThirdPartyId(req.ThirdPartyId); err != nil {
+		return nil, err
+	}
+	if _, err := ParseRetention(req.ContextRetention); err != nil {
+		return nil, err
+	}
+	if err := p.validateSidecars(req.Sidecars); err != nil {
+		return nil, err
+	}
+	if _, err := ParseRateLimit(req.BuildRateLimit); err != nil {
+		return nil, err
+	}
+	if _, err := ParsePlatforms(req.Platforms); err != nil {
+		return nil, err
+	}
+	if _, err := ParseDeployStrategy(req.DeployStrategy); err != nil {
+		return nil, err
+	}
+	if _, err := ParseBuildResources(req.BuildRequests, req.BuildLimits); err != nil {
+		return nil, err
+	}
+	if err := ValidateSourceRole(req.SourceRoleARN); err != nil {
+		return nil, err
+	}
+
+	report := &Report{Success: true}
+	now := time.Now().UTC()
+
+	tenant := Tenant{
+		ThirdPartyId:        req.ThirdPartyId,
+		Namespace:           NamespaceFor(req.ThirdPartyId),
+		ServiceAccount:      "lambda-" + req.ThirdPartyId,
+		S3Prefix:            req.ThirdPartyId + "/",
+		ECRRepository:       p.repositories.RepositoryName(req.ThirdPartyId),
+		RoleARN:             req.RoleARN,
+		SourceRoleARN:       req.SourceRoleARN,
+		SourceBucket:        req.SourceBucket,
+		NotificationChannel: req.NotificationChannel,
+		KMSKeyARN:           req.KMSKeyARN,
+		ContextRetention:    req.ContextRetention,
+		Sidecars:            req.Sidecars,
+		BuildRateLimit:      req.BuildRateLimit,
+		Platforms:           req.Platforms,
+		DeployStrategy:      req.DeployStrategy,
+		BuildRequests:       req.BuildRequests,
+		BuildLimits:         req.BuildLimits,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+
+	// Keep the original creation time when re-running onboarding
+	existing, err := p.store.Get(ctx, req.ThirdPartyId)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	if existing != nil {
+		tenant.CreatedAt = existing.CreatedAt
+	}
+
+	// =========================================================================
+	// 📍 STEP 1: S3 PREFIX AND BUCKET POLICY
+	// =========================================================================
+	p.provisionS3(ctx, tenant, report)
+
+	// =========================================================================
+	// 📍 STEP 2: ECR REPOSITORY
+	// =========================================================================
+	created, err := p.repositories.EnsureRepository(ctx, tenant.ThirdPartyId)
+	switch {
+	case err != nil:
+		report.add("ecr-repository", StepFailed, err.Error())
+	case created:
+		report.add("ecr-repository", StepCreated, tenant.ECRRepository)
+	default:
+		report.add("ecr-repository", StepExists, tenant.ECRRepository)
+	}
+
+	// =========================================================================
+	// 📍 STEP 3: KUBERNETES NAMESPACE AND SERVICE ACCOUNT
+	// =========================================================================
+	p.provisionKubernetes(ctx, tenant, report)
+
+	// =========================================================================
+	// 📍 STEP 4: NOTIFICATION CHANNEL
+	// =========================================================================
+	if tenant.NotificationChannel == "" {
+		report.add("notification-channel", StepSkipped, "no channel configured")
+	} else if u, err := url.Parse(tenant.NotificationChannel); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		report.add("notification-channel", StepFailed, "notification channel must be an http(s) URL")
+	} else {
+		report.add("notification-channel", StepValidated, u.Host)
+	}
+
+	// =========================================================================
+	// 📍 STEP 5: KMS KEY
+	// =========================================================================
+	p.checkKMSKey(ctx, tenant, existing, report)
+
+	// =========================================================================
+	// 📍 STEP 6: TENANT RECORD
+	// =========================================================================
+	// 📝 NOTE: Only record tenants whose infrastructure is fully in place
+	if !report.Success {
+		report.add("tenant-record", StepSkipped, "previous steps failed")
+		return report, nil
+	}
+	if err := p.store.Put(ctx, tenant); err != nil {
+		report.add("tenant-record", StepFailed, err.Error())
+		return report, nil
+	}
+	if existing != nil {
+		report.add("tenant-record", StepUpdated, "")
+	} else {
+		report.add("tenant-record", StepCreated, "")
+	}
+
+	report.Tenant = &tenant
+	log.Printf("Tenant %s provisioned (success=%t)", tenant.ThirdPartyId, report.Success)
+	return report, nil
+}
+
+// checkKMSKey verifies the builder can use the tenant's KMS key
+// 📝 NOTE: Changing the key only affects new writes; the report points at
+// "tenant reencrypt" to rewrite what is already stored
+func (p *Provisioner) checkKMSKey(ctx context.Context, tenant Tenant, existing *Tenant, report *Report) {
+	if tenant.KMSKeyARN == "" {
+		if existing != nil && existing.KMSKeyARN != "" {
+			report.add("kms-key", StepUpdated, "key removed, new build records are stored unencrypted")
+			return
+		}
+		report.add("kms-key", StepSkipped, "no KMS key given")
+		return
+	}
+
+	if _, _, err := p.awsClient.KMS.GenerateDataKey(ctx, tenant.KMSKeyARN); err != nil {
+		report.add("kms-key", StepFailed, err.Error())
+		return
+	}
+
+	switch {
+	case existing == nil:
+		report.add("kms-key", StepCreated, tenant.KMSKeyARN)
+	case existing.KMSKeyARN != tenant.KMSKeyARN:
+		report.add("kms-key", StepUpdated, fmt.Sprintf("%s (run `lambdactl tenant reencrypt %s` to re-encrypt stored data)",
+			tenant.KMSKeyARN, tenant.ThirdPartyId))
+	default:
+		report.add("kms-key", StepExists, tenant.KMSKeyARN)
+	}
+}
+
+// provisionS3 creates the tenant's source prefix and, if requested, grants its role access
+func (p *Provisioner) provisionS3(ctx context.Context, tenant Tenant, report *Report) {
+	backend, bucket, _ := p.cfg.SourceLocation()
+	switch backend {
+	case config.SourceBackendGCS:
+		report.add("s3-prefix", StepSkipped, "parser sources are kept in GCS")
+		return
+	case config.SourceBackendAzure:
+		report.add("s3-prefix", StepSkipped, "parser sources are kept in Azure Blob Storage")
+		return
+	}
+	if tenant.SourceRoleARN != "" || tenant.SourceBucket != "" {
+		p.checkSourceRole(ctx, tenant, bucket, report)
+		return
+	}
+	if bucket == "" {
+		report.add("s3-prefix", StepSkipped, "S3_SOURCE_BUCKET not configured")
+		return
+	}
+
+	// S3 has no real directories; a zero-byte "folder" object makes the prefix visible
+	_, err := p.awsClient.S3.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: awssdk.String(bucket),
+		Key:    awssdk.String(tenant.S3Prefix),
+	})
+	if err != nil {
+		report.add("s3-prefix", StepFailed, err.Error())
+	} else {
+		report.add("s3-prefix", StepExists, fmt.Sprintf("s3://%s/%s", bucket, tenant.S3Prefix))
+	}
+
+	if tenant.RoleARN == "" {
+		report.add("s3-bucket-policy", StepSkipped, "no role ARN given")
+		return
+	}
+
+	status, err := p.ensureBucketPolicyStatement(ctx, bucket, tenant)
+	if err != nil {
+		report.add("s3-bucket-policy", StepFailed, err.Error())
+		return
+	}
+	report.add("s3-bucket-policy", status, tenant.RoleARN)
+}
+
+// checkSourceRole verifies the builder can assume the role reading a tenant's own sources
+// 📝 NOTE: The tenant's bucket and role are theirs to set up; nothing is
+// created in the platform's bucket for them
+func (p *Provisioner) checkSourceRole(ctx context.Context, tenant Tenant, bucket string, report *Report) {
+	if tenant.SourceBucket != "" {
+		bucket = tenant.SourceBucket
+	}
+	report.add("s3-prefix", StepSkipped, fmt.Sprintf("sources are read from s3://%s/%s", bucket, tenant.S3Prefix))
+	if tenant.SourceRoleARN == "" {
+		report.add("s3-source-role", StepSkipped, "no source role given")
+		return
+	}
+	if _, err := p.awsClient.Roles("").Credentials(ctx, tenant.SourceRoleARN); err != nil {
+		report.add("s3-source-role", StepFailed, err.Error())
+		return
+	}
+	report.add("s3-source-role", StepExists, tenant.SourceRoleARN)
+}
+
+// bucketPolicy is the subset of an IAM policy document we need to edit
+type bucketPolicy struct {
+	Version   string                   `json:"Version"`
+	Statement []map[string]interface{} `json:"Statement"`
+}
+
+// ensureBucketPolicyStatement adds (or replaces) the tenant's statement, keyed by Sid
+func (p *Provisioner) ensureBucketPolicyStatement(ctx context.Context, bucket string, tenant Tenant) (string, error) {
+	policy := bucketPolicy{Version: "2012-10-17"}
+
+	out, err := p.awsClient.S3.GetBucketPolicy(ctx, &s3.GetBucketPolicyInput{Bucket: awssdk.String(bucket)})
+	if err != nil {
+		if !awserrors.HasCode(err, awserrors.NoSuchBucketPolicy) {
+			return "", fmt.Errorf("failed to read bucket policy: %w", err)
+		}
+	} else if err := json.Unmarshal([]byte(awssdk.ToString(out.Policy)), &policy); err != nil {
+		return "", fmt.Errorf("failed to decode bucket policy: %w", err)
+	}
+
+	sid := "KnativeLambdaTenant" + sanitizeSid(tenant.ThirdPartyId)
+	statement := map[string]interface{}{
+		"Sid":       sid,
+		"Effect":    "Allow",
+		"Principal": map[string]interface{}{"AWS": tenant.RoleARN},
+		"Action":    []string{"s3:GetObject", "s3:PutObject", "s3:DeleteObject"},
+		"Resource":  fmt.Sprintf("arn:aws:s3:::%s/%s*", bucket, tenant.S3Prefix),
+	}
+
+	status := StepCreated
+	replaced := false
+	for i, existing := range policy.Statement {
+		if existing["Sid"] == sid {
+			policy.Statement[i] = statement
+			status = StepUpdated
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		policy.Statement = append(policy.Statement, statement)
+	}
+
+	raw, err := json.Marshal(policy)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode bucket policy: %w", err)
+	}
+	if _, err := p.awsClient.S3.PutBucketPolicy(ctx, &s3.PutBucketPolicyInput{
+		Bucket: awssdk.String(bucket),
+		Policy: awssdk.String(string(raw)),
+	}); err != nil {
+		return "", fmt.Errorf("failed to write bucket policy: %w", err)
+	}
+	return status, nil
+}
+
+// sanitizeSid keeps only the characters IAM allows in a statement id
+func sanitizeSid(id string) string {
+	out := make([]rune, 0, len(id))
+	for _, r := range id {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			out = append(out, r)
+		}
+	}
+	return string(out)
+}
+
+// provisionKubernetes creates the tenant namespace and service account
+func (p *Provisioner) provisionKubernetes(ctx context.Context, tenant Tenant, report *Report) {
+	labels := NamespaceLabels(tenant.ThirdPartyId)
+
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: tenant.Namespace, Labels: labels},
+	}
+	_, err := p.clientset.CoreV1().Namespaces().Create(ctx, namespace, metav1.CreateOptions{})
+	switch {
+	case err == nil:
+		report.add("k8s-namespace", StepCreated, tenant.Namespace)
+	case apierrors.IsAlreadyExists(err):
+		report.add("k8s-namespace", StepExists, tenant.Namespace)
+	default:
+		report.add("k8s-namespace", StepFailed, err.Error())
+		report.add("k8s-service-account", StepSkipped, "namespace missing")
+		return
+	}
+
+	serviceAccount := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Name: tenant.ServiceAccount, Namespace: tenant.Namespace, Labels: labels},
+	}
+	_, err = p.clientset.CoreV1().ServiceAccounts(tenant.Namespace).Create(ctx, serviceAccount, metav1.CreateOptions{})
+	switch {
+	case err == nil:
+		report.add("k8s-service-account", StepCreated, tenant.ServiceAccount)
+	case apierrors.IsAlreadyExists(err):
+		report.add("k8s-service-account", StepExists, tenant.ServiceAccount)
+	default:
+		report.add("k8s-service-account", StepFailed, err.Error())
+	}
+}
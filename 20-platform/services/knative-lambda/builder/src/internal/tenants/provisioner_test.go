@@ -0,0 +1,119 @@
+package tenants
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+
+	"knative-lambda-builder/internal/config"
+	"knative-lambda-builder/registry"
+)
+
+// fakeRepositories ensures tenant repositories in a fake registry
+type fakeRepositories struct {
+	registry *registry.FakeRegistry
+}
+
+func (f fakeRepositories) EnsureRepository(ctx context.Context, thirdPartyId string) (bool, error) {
+	return f.registry.EnsureRepository(ctx, f.RepositoryName(thirdPartyId))
+}
+
+func (f fakeRepositories) RepositoryName(thirdPartyId string) string {
+	return "knative-lambdas/" + thirdPartyId
+}
+
+// stepStatuses maps each step of a report to its status
+func stepStatuses(report *Report) map[string]string {
+	statuses := map[string]string{}
+	for _, step := range report.Steps {
+		statuses[step.Step] = step.Status
+	}
+	return statuses
+}
+
+func TestProvision(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset()
+	repositories := registry.NewFakeRegistry()
+	store := NewConfigMapStore(clientset, "knative-lambda")
+	p := NewProvisioner(&config.Config{}, nil, clientset, fakeRepositories{registry: repositories}, store)
+	req := Request{ThirdPartyId: "acme", NotificationChannel: "https://hooks.example.com/acme"}
+
+	// First run creates everything
+	report, err := p.Provision(ctx, req)
+	if err != nil || !report.Success {
+		t.Fatalf("Provision() = %+v, %v", report, err)
+	}
+	want := map[string]string{
+		"s3-prefix":            StepSkipped, // No source bucket configured
+		"ecr-repository":       StepCreated,
+		"k8s-namespace":        StepCreated,
+		"k8s-service-account":  StepCreated,
+		"notification-channel": StepValidated,
+		"kms-key":              StepSkipped,
+		"tenant-record":        StepCreated,
+	}
+	for step, status := range want {
+		if got := stepStatuses(report)[step]; got != status {
+			t.Errorf("first run: step %s = %q, want %q", step, got, status)
+		}
+	}
+	if !repositories.HasRepository("knative-lambdas/acme") {
+		t.Error("first run: repository knative-lambdas/acme not created")
+	}
+	first, err := store.Get(ctx, "acme")
+	if err != nil {
+		t.Fatalf("Get(acme) = %v", err)
+	}
+
+	// Re-running finds everything in place and keeps the record's creation time
+	report, err = p.Provision(ctx, req)
+	if err != nil || !report.Success {
+		t.Fatalf("Provision() again = %+v, %v", report, err)
+	}
+	want["ecr-repository"] = StepExists
+	want["k8s-namespace"] = StepExists
+	want["k8s-service-account"] = StepExists
+	want["tenant-record"] = StepUpdated
+	for step, status := range want {
+		if got := stepStatuses(report)[step]; got != status {
+			t.Errorf("re-run: step %s = %q, want %q", step, got, status)
+		}
+	}
+	if again, _ := store.Get(ctx, "acme"); !again.CreatedAt.Equal(first.CreatedAt) {
+		t.Errorf("re-run: CreatedAt = %s, want %s", again.CreatedAt, first.CreatedAt)
+	}
+}
+
+func TestProvisionFailedSteps(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset()
+	repositories := registry.NewFakeRegistry()
+	store := NewConfigMapStore(clientset, "knative-lambda")
+	p := NewProvisioner(&config.Config{}, nil, clientset, fakeRepositories{registry: repositories}, store)
+
+	// No channel is skipped; an unusable one fails, and nothing is recorded
+	report, err := p.Provision(ctx, Request{ThirdPartyId: "acme"})
+	if err != nil || stepStatuses(report)["notification-channel"] != StepSkipped {
+		t.Errorf("Provision() without a channel = %+v, %v, want the channel skipped", report, err)
+	}
+	report, err = p.Provision(ctx, Request{ThirdPartyId: "globex", NotificationChannel: "ftp://hooks.example.com"})
+	if err != nil || report.Success || stepStatuses(report)["notification-channel"] != StepFailed ||
+		stepStatuses(report)["tenant-record"] != StepSkipped {
+		t.Errorf("Provision() with an ftp channel = %+v, %v, want it failed and unrecorded", report, err)
+	}
+
+	repositories.Err = errors.New("registry unavailable")
+	report, err = p.Provision(ctx, Request{ThirdPartyId: "initech"})
+	if err != nil || report.Success || stepStatuses(report)["ecr-repository"] != StepFailed {
+		t.Errorf("Provision() with a broken registry = %+v, %v, want the repository step failed", report, err)
+	}
+	if _, err := store.Get(ctx, "initech"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(initech) = %v, want it unrecorded", err)
+	}
+	if _, err := p.Provision(ctx, Request{ThirdPartyId: "Not Valid"}); err == nil {
+		t.Error("Provision() of an invalid thirdPartyId: want an error")
+	}
+}
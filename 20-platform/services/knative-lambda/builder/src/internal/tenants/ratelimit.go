@@ -0,0 +1,71 @@
+package tenants
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// =============================================================================
+// 🚦 PER-TENANT BUILD RATE LIMITS
+// =============================================================================
+// How many builds a tenant may submit per period ("<builds>/<period>", e.g.
+// "30/1h"); tenants may have their own (buildRateLimit in their record)
+
+// RateLimit allows Builds submissions per Per (zero = unlimited)
+type RateLimit struct {
+	Builds int
+	Per    time.Duration
+}
+
+// Unlimited reports whether the limit allows everything
+func (l RateLimit) Unlimited() bool {
+	return l.Builds == 0
+}
+
+// ParseRateLimit parses "<builds>/<period>" ("" is unlimited)
+func ParseRateLimit(value string) (RateLimit, error) {
+	if value == "" {
+		return RateLimit{}, nil
+	}
+	builds, period, ok := strings.Cut(value, "/")
+	n, err := strconv.Atoi(builds)
+	if !ok || err != nil || n < 1 {
+		return RateLimit{}, fmt.Errorf("invalid buildRateLimit %q: must be like \"30/1h\" (builds per period)", value)
+	}
+	per, err := time.ParseDuration(period)
+	if err != nil || per <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid buildRateLimit %q: must be like \"30/1h\" (builds per period)", value)
+	}
+	return RateLimit{Builds: n, Per: per}, nil
+}
+
+// BuildRateLimits resolves the build rate limit of tenants
+// (implements events.RateLimitPolicy)
+type BuildRateLimits struct {
+	store    Store
+	fallback RateLimit
+}
+
+// NewBuildRateLimits creates a rate limit policy falling back to the given default
+func NewBuildRateLimits(store Store, fallback RateLimit) *BuildRateLimits {
+	return &BuildRateLimits{store: store, fallback: fallback}
+}
+
+// BuildRateLimit returns a tenant's limit (the default if it has none or isn't registered)
+func (l *BuildRateLimits) BuildRateLimit(ctx context.Context, thirdPartyId string) (RateLimit, error) {
+	tenant, err := l.store.Get(ctx, thirdPartyId)
+	if errors.Is(err, ErrNotFound) {
+		return l.fallback, nil
+	}
+	if err != nil {
+		return RateLimit{}, err
+	}
+	if tenant.BuildRateLimit == "" {
+		return l.fallback, nil
+	}
+	return ParseRateLimit(tenant.BuildRateLimit)
+}
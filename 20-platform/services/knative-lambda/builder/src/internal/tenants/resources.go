@@ -0,0 +1,58 @@
+package tenants
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"knative-lambda-builder/internal/build"
+)
+
+// =============================================================================
+// 📏 PER-TENANT BUILD RESOURCES
+// =============================================================================
+// The requests and limits of build pods ("cpu=1,memory=2Gi"); tenants may
+// have their own (buildRequests/buildLimits in their record), e.g. when their
+// parsers pull in large dependencies. They override the platform's per
+// resource, and stay under BUILD_RESOURCE_MAX
+
+// ParseBuildResources parses the build requests and limits of a tenant
+func ParseBuildResources(requests, limits string) (corev1.ResourceRequirements, error) {
+	var resources corev1.ResourceRequirements
+	var err error
+	if resources.Requests, err = build.ParseResources(requests); err != nil {
+		return resources, fmt.Errorf("invalid build requests %q: %w", requests, err)
+	}
+	if resources.Limits, err = build.ParseResources(limits); err != nil {
+		return resources, fmt.Errorf("invalid build limits %q: %w", limits, err)
+	}
+	if err := build.ValidateResources(resources, nil); err != nil {
+		return resources, fmt.Errorf("invalid build resources: %w", err)
+	}
+	return resources, nil
+}
+
+// BuildResources resolves the build resources of tenants
+// (implements build.ResourcePolicy)
+type BuildResources struct {
+	store Store
+}
+
+// NewBuildResources creates a resource policy reading the tenants' records
+func NewBuildResources(store Store) *BuildResources {
+	return &BuildResources{store: store}
+}
+
+// BuildResources returns a tenant's requests and limits (none if it has none or isn't registered)
+func (r *BuildResources) BuildResources(ctx context.Context, thirdPartyId string) (corev1.ResourceRequirements, error) {
+	tenant, err := r.store.Get(ctx, thirdPartyId)
+	if errors.Is(err, ErrNotFound) {
+		return corev1.ResourceRequirements{}, nil
+	}
+	if err != nil {
+		return corev1.ResourceRequirements{}, err
+	}
+	return ParseBuildResources(tenant.BuildRequests, tenant.BuildLimits)
+}
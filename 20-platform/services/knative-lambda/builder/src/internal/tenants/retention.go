@@ -0,0 +1,53 @@
+package tenants
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// =============================================================================
+// 🧹 PER-TENANT RETENTION
+// =============================================================================
+// Tenants may keep their build contexts longer than the platform default
+// (contextRetention in their record), e.g. to audit what was built
+
+// ParseRetention parses a retention duration ("" is the zero value)
+func ParseRetention(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid contextRetention %q: must be a duration like \"72h\" (\"0s\" = delete once built)", value)
+	}
+	return d, nil
+}
+
+// ContextRetention resolves the build context retention of tenants
+// (implements build.RetentionPolicy)
+type ContextRetention struct {
+	store    Store
+	fallback time.Duration
+}
+
+// NewContextRetention creates a retention policy falling back to the given default
+func NewContextRetention(store Store, fallback time.Duration) *ContextRetention {
+	return &ContextRetention{store: store, fallback: fallback}
+}
+
+// ContextRetention returns a tenant's retention (the default if it has none or isn't registered)
+func (r *ContextRetention) ContextRetention(ctx context.Context, thirdPartyId string) (time.Duration, error) {
+	tenant, err := r.store.Get(ctx, thirdPartyId)
+	if errors.Is(err, ErrNotFound) {
+		return r.fallback, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if tenant.ContextRetention == "" {
+		return r.fallback, nil
+	}
+	return ParseRetention(tenant.ContextRetention)
+}
@@ -0,0 +1,51 @@
+package tenants
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"knative-lambda-builder/internal/aws"
+)
+
+// =============================================================================
+// 🔑 PER-TENANT SOURCE ACCESS
+// =============================================================================
+// Tenants may keep their parser sources private to their own AWS account:
+// their record names an IAM role (sourceRoleArn) the builder assumes to read
+// them, and optionally their own bucket (sourceBucket), laid out like the
+// platform's (<thirdPartyId>/<parserId>.js)
+// 🎯 WHY: The builder then needs no blanket access to tenant buckets, only
+// sts:AssumeRole on roles whose trust policy names it
+
+// ValidateSourceRole rejects source roles that aren't IAM role ARNs ("" is fine)
+func ValidateSourceRole(roleARN string) error {
+	if roleARN != "" && aws.RoleAccountID(roleARN) == "" {
+		return fmt.Errorf("invalid sourceRoleArn %q: expected arn:aws:iam::<account>:role/<name>", roleARN)
+	}
+	return nil
+}
+
+// SourceAccess resolves where tenants' sources are read from
+// (implements build.SourceAccessPolicy)
+type SourceAccess struct {
+	store Store
+}
+
+// NewSourceAccess creates a source access policy reading tenant records
+func NewSourceAccess(store Store) *SourceAccess {
+	return &SourceAccess{store: store}
+}
+
+// SourceAccess returns a tenant's source bucket and the role reading it
+// ("" = the platform's bucket, read with the builder's own credentials)
+func (s *SourceAccess) SourceAccess(ctx context.Context, thirdPartyId string) (string, string, error) {
+	tenant, err := s.store.Get(ctx, thirdPartyId)
+	if errors.Is(err, ErrNotFound) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return tenant.SourceBucket, tenant.SourceRoleARN, nil
+}
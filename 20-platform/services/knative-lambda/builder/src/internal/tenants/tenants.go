@@ -0,0 +1,173 @@
+package tenants
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// =============================================================================
+// 🏢 TENANT REGISTRY
+// =============================================================================
+// This package tracks the tenants (thirdPartyIds) onboarded onto the platform
+// 🎯 PURPOSE: One record per tenant describing what was provisioned for it
+
+// Tenant is the record kept for every onboarded thirdPartyId
+type Tenant struct {
+	ThirdPartyId        string              `json:"thirdPartyId"`
+	Namespace           string              `json:"namespace"`                     // Kubernetes namespace for the tenant
+	ServiceAccount      string              `json:"serviceAccount"`                // Service account inside Namespace
+	S3Prefix            string              `json:"s3Prefix"`                      // Prefix in the source bucket
+	ECRRepository       string              `json:"ecrRepository"`                 // Repository parser images are pushed to
+	RoleARN             string              `json:"roleArn,omitempty"`             // IAM role granted access to S3Prefix
+	SourceRoleARN       string              `json:"sourceRoleArn,omitempty"`       // IAM role the builder assumes to read the tenant's sources
+	SourceBucket        string              `json:"sourceBucket,omitempty"`        // The tenant's own source bucket (default: the platform's)
+	NotificationChannel string              `json:"notificationChannel,omitempty"` // Where build notifications go (URL)
+	KMSKeyARN           string              `json:"kmsKeyArn,omitempty"`           // Encrypts the tenant's build records and artifacts
+	ContextRetention    string              `json:"contextRetention,omitempty"`    // How long build contexts are kept (duration, default CONTEXT_RETENTION)
+	Sidecars            map[string][]string `json:"sidecars,omitempty"`            // Catalog sidecars per parserId ("*" = every parser)
+	BuildRateLimit      string              `json:"buildRateLimit,omitempty"`      // Builds per period ("30/1h", default BUILD_RATE_LIMIT)
+	Platforms           string              `json:"platforms,omitempty"`           // Platforms images are built for ("linux/amd64,linux/arm64", default BUILD_PLATFORMS)
+	DeployStrategy      string              `json:"deployStrategy,omitempty"`      // How redeploys shift traffic (rolling, canary or blue-green, default DEPLOY_STRATEGY)
+	BuildRequests       string              `json:"buildRequests,omitempty"`       // Requests of build pods ("cpu=1,memory=2Gi", default BUILD_RESOURCE_REQUESTS)
+	BuildLimits         string              `json:"buildLimits,omitempty"`         // Limits of build pods ("memory=6Gi", default BUILD_RESOURCE_LIMITS)
+	CreatedAt           time.Time           `json:"createdAt"`
+	UpdatedAt           time.Time           `json:"updatedAt"`
+}
+
+// validThirdPartyId matches ids that are safe in S3 keys, ECR repos and K8s names
+var validThirdPartyId = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// ValidateThirdPartyId rejects ids that can't be used in resource names
+func ValidateThirdPartyId(id string) error {
+	if len(id) == 0 || len(id) > 40 || !validThirdPartyId.MatchString(id) {
+		return fmt.Errorf("invalid thirdPartyId %q: must be 1-40 lowercase alphanumerics or '-'", id)
+	}
+	return nil
+}
+
+// Store persists tenant records
+type Store interface {
+	Get(ctx context.Context, thirdPartyId string) (*Tenant, error)
+	List(ctx context.Context) ([]Tenant, error)
+	Put(ctx context.Context, tenant Tenant) error
+}
+
+// ErrNotFound is returned when a tenant is not registered
+var ErrNotFound = fmt.Errorf("tenant not found")
+
+// =============================================================================
+// 🗂️ CONFIGMAP-BACKED STORE
+// =============================================================================
+// Tenant records are small and rarely written, so a single ConfigMap is enough:
+// one data key per thirdPartyId holding the JSON record
+
+// DefaultConfigMapName is the ConfigMap holding the tenant registry
+const DefaultConfigMapName = "knative-lambda-tenants"
+
+// ConfigMapStore stores tenants in a ConfigMap
+type ConfigMapStore struct {
+	clientset kubernetes.Interface
+	namespace string
+	name      string
+}
+
+// NewConfigMapStore creates a ConfigMap-backed tenant store
+func NewConfigMapStore(clientset kubernetes.Interface, namespace string) *ConfigMapStore {
+	return &ConfigMapStore{
+		clientset: clientset,
+		namespace: namespace,
+		name:      DefaultConfigMapName,
+	}
+}
+
+// Get returns a single tenant record
+func (s *ConfigMapStore) Get(ctx context.Context, thirdPartyId string) (*Tenant, error) {
+	cm, err := s.clientset.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tenant registry: %w", err)
+	}
+
+	raw, ok := cm.Data[thirdPartyId]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	var tenant Tenant
+	if err := json.Unmarshal([]byte(raw), &tenant); err != nil {
+		return nil, fmt.Errorf("failed to decode tenant %s: %w", thirdPartyId, err)
+	}
+	return &tenant, nil
+}
+
+// List returns all tenant records sorted by thirdPartyId
+func (s *ConfigMapStore) List(ctx context.Context) ([]Tenant, error) {
+	cm, err := s.clientset.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tenant registry: %w", err)
+	}
+
+	tenants := make([]Tenant, 0, len(cm.Data))
+	for id, raw := range cm.Data {
+		var tenant Tenant
+		if err := json.Unmarshal([]byte(raw), &tenant); err != nil {
+			return nil, fmt.Errorf("failed to decode tenant %s: %w", id, err)
+		}
+		tenants = append(tenants, tenant)
+	}
+
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].ThirdPartyId < tenants[j].ThirdPartyId })
+	return tenants, nil
+}
+
+// Put creates or replaces a tenant record
+// 📝 NOTE: Update conflicts surface as errors; callers retry the whole operation
+func (s *ConfigMapStore) Put(ctx context.Context, tenant Tenant) error {
+	raw, err := json.Marshal(tenant)
+	if err != nil {
+		return fmt.Errorf("failed to encode tenant %s: %w", tenant.ThirdPartyId, err)
+	}
+
+	configMaps := s.clientset.CoreV1().ConfigMaps(s.namespace)
+	cm, err := configMaps.Get(ctx, s.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      s.name,
+				Namespace: s.namespace,
+				Labels:    map[string]string{"app.kubernetes.io/part-of": "knative-lambda"},
+			},
+			Data: map[string]string{tenant.ThirdPartyId: string(raw)},
+		}
+		if _, err := configMaps.Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create tenant registry: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read tenant registry: %w", err)
+	}
+
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[tenant.ThirdPartyId] = string(raw)
+	if _, err := configMaps.Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update tenant registry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/itchyny/gojq"
+)
+
+// =============================================================================
+// 🔀 INBOUND PAYLOAD TRANSFORMATION
+// =============================================================================
+// Some producers still send legacy payload shapes. Instead of blocking on a
+// producer-side migration, operators configure jq expressions that map the
+// incoming event data into the canonical shape before it is parsed/validated.
+//
+// 💡 EXAMPLE rules file (EVENT_TRANSFORMS_FILE):
+//
+//	[
+//	  {
+//	    "name": "legacy-uploader",
+//	    "type": "network.notifi.lambda.build.start",
+//	    "source": "legacy-uploader/*",
+//	    "expression": "{thirdPartyId: .tenant.id, parserId: .parser_name}"
+//	  }
+//	]
+
+// Rule maps the data of matching events with a jq expression
+type Rule struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`             // Event type the rule applies to (exact match)
+	Source     string `json:"source,omitempty"` // Optional event source; a trailing "*" matches a prefix
+	Expression string `json:"expression"`       // jq expression producing a single JSON object
+
+	code *gojq.Code
+}
+
+// matches reports whether the rule applies to an event
+func (r *Rule) matches(eventType, source string) bool {
+	if r.Type != eventType {
+		return false
+	}
+	if r.Source == "" {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(r.Source, "*"); ok {
+		return strings.HasPrefix(source, prefix)
+	}
+	return r.Source == source
+}
+
+// Transformer applies the first matching rule to inbound event data
+type Transformer struct {
+	rules []Rule
+}
+
+// New compiles a set of rules
+func New(rules []Rule) (*Transformer, error) {
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Type == "" || rule.Expression == "" {
+			return nil, fmt.Errorf("transform rule %q: type and expression are required", rule.Name)
+		}
+		query, err := gojq.Parse(rule.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("transform rule %q: invalid expression: %w", rule.Name, err)
+		}
+		if rule.code, err = gojq.Compile(query); err != nil {
+			return nil, fmt.Errorf("transform rule %q: %w", rule.Name, err)
+		}
+	}
+	return &Transformer{rules: rules}, nil
+}
+
+// Load reads rules from a JSON file; an empty path yields a no-op transformer
+func Load(path string) (*Transformer, error) {
+	if path == "" {
+		return &Transformer{}, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transform rules: %w", err)
+	}
+	var rules []Rule
+	if err := json.Unmarshal(raw, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse transform rules %s: %w", path, err)
+	}
+	return New(rules)
+}
+
+// Apply maps event data with the first matching rule
+// 📝 NOTE: Returns the rule name ("" and the data unchanged if no rule matched)
+func (t *Transformer) Apply(eventType, source string, data []byte) ([]byte, string, error) {
+	for i := range t.rules {
+		rule := &t.rules[i]
+		if !rule.matches(eventType, source) {
+			continue
+		}
+
+		var input interface{}
+		if err := json.Unmarshal(data, &input); err != nil {
+			return nil, rule.Name, fmt.Errorf("transform rule %q: event data is not JSON: %w", rule.Name, err)
+		}
+
+		iter := rule.code.Run(input)
+		output, ok := iter.Next()
+		if !ok {
+			return nil, rule.Name, fmt.Errorf("transform rule %q produced no output", rule.Name)
+		}
+		if err, isErr := output.(error); isErr {
+			return nil, rule.Name, fmt.Errorf("transform rule %q: %w", rule.Name, err)
+		}
+		if _, isObject := output.(map[string]interface{}); !isObject {
+			return nil, rule.Name, fmt.Errorf("transform rule %q must produce a JSON object, got %T", rule.Name, output)
+		}
+
+		transformed, err := json.Marshal(output)
+		if err != nil {
+			return nil, rule.Name, fmt.Errorf("transform rule %q: %w", rule.Name, err)
+		}
+		return transformed, rule.Name, nil
+	}
+	return data, "", nil
+}
@@ -0,0 +1,39 @@
+package transform
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	transformer, err := New([]Rule{{
+		Name:       "legacy",
+		Type:       "network.notifi.lambda.build.start",
+		Source:     "legacy/*",
+		Expression: "{thirdPartyId: .tenant.id, parserId: .parser_name}",
+	}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	legacy := []byte(`{"tenant":{"id":"acme"},"parser_name":"p1"}`)
+	out, rule, err := transformer.Apply("network.notifi.lambda.build.start", "legacy/uploader", legacy)
+	if err != nil || rule != "legacy" {
+		t.Fatalf("Apply = %q, %v; want rule legacy", rule, err)
+	}
+	var got map[string]string
+	json.Unmarshal(out, &got)
+	if got["thirdPartyId"] != "acme" || got["parserId"] != "p1" {
+		t.Errorf("unexpected transformed payload: %s", out)
+	}
+
+	// Other sources pass through untouched
+	out, rule, err = transformer.Apply("network.notifi.lambda.build.start", "modern", legacy)
+	if err != nil || rule != "" || string(out) != string(legacy) {
+		t.Errorf("non-matching event should pass through, got %q %s %v", rule, out, err)
+	}
+
+	if _, err := New([]Rule{{Name: "bad", Type: "x", Expression: "{"}}); err == nil {
+		t.Error("invalid expression should fail to compile")
+	}
+}
@@ -0,0 +1,17 @@
+package transport
+
+// =============================================================================
+// 📨 KAFKA EVENT SOURCE
+// =============================================================================
+// Consumes build requests from a Kafka topic (BUILDER_TRANSPORT=kafka), for
+// producers that already publish to Kafka, without a KafkaSource bridge
+// 🎯 PURPOSE: Same handler as the HTTP receiver, different way in
+// 📝 NOTE: The consumer (franz-go) is only compiled with -tags kafka, which
+// the image build sets
+
+// KafkaConfig configures the Kafka consumer
+type KafkaConfig struct {
+	Brokers string // Comma separated seed brokers
+	Topic   string
+	Group   string // Consumer group; replicas share the topic's partitions
+}
@@ -0,0 +1,122 @@
+//go:build kafka
+
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// kafkaRetries is how often a failing event is handed to the handler again
+// before it is skipped
+// 🎯 WHY: A Kafka partition can't nack one record; retrying forever would stall it
+const kafkaRetries = 3
+
+// kafkaRetryBackoff is the delay before the first retry (doubles per attempt)
+const kafkaRetryBackoff = time.Second
+
+// KafkaConsumer feeds a topic's events to a handler
+type KafkaConsumer struct {
+	client  *kgo.Client
+	topic   string
+	handler EventHandler
+}
+
+// NewKafkaConsumer creates a consumer group member for the topic
+func NewKafkaConsumer(cfg KafkaConfig, handler EventHandler) (*KafkaConsumer, error) {
+	if cfg.Brokers == "" || cfg.Topic == "" || cfg.Group == "" {
+		return nil, errors.New("kafka brokers, topic and group are required")
+	}
+
+	client, err := kgo.NewClient(
+		kgo.SeedBrokers(strings.Split(cfg.Brokers, ",")...),
+		kgo.ConsumerGroup(cfg.Group),
+		kgo.ConsumeTopics(cfg.Topic),
+		kgo.ClientID("knative-lambda-builder"),
+		// Offsets are only committed once the event was handled
+		kgo.AutoCommitMarks(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka client: %w", err)
+	}
+
+	return &KafkaConsumer{client: client, topic: cfg.Topic, handler: handler}, nil
+}
+
+// Start consumes the topic until the context is cancelled
+// 📋 STEPS:
+//  1. Poll a batch of records
+//  2. Decode each into a CloudEvent and hand it to the handler (with retries)
+//  3. Mark it for commit, handled or skipped
+func (c *KafkaConsumer) Start(ctx context.Context) {
+	log.Printf("📨 Consuming build events from Kafka topic %s", c.topic)
+	defer func() {
+		if err := c.client.CommitMarkedOffsets(context.Background()); err != nil {
+			log.Printf("WARNING: Failed to commit kafka offsets: %v", err)
+		}
+		c.client.Close()
+	}()
+
+	for {
+		fetches := c.client.PollFetches(ctx)
+		if fetches.IsClientClosed() || ctx.Err() != nil {
+			return
+		}
+		fetches.EachError(func(topic string, partition int32, err error) {
+			log.Printf("ERROR: Failed to fetch from kafka %s[%d]: %v", topic, partition, err)
+		})
+
+		fetches.EachRecord(func(record *kgo.Record) {
+			c.handle(ctx, record)
+			c.client.MarkCommitRecords(record)
+		})
+	}
+}
+
+// handle delivers one record to the handler
+func (c *KafkaConsumer) handle(ctx context.Context, record *kgo.Record) {
+	headers := make(map[string]string, len(record.Headers))
+	for _, header := range record.Headers {
+		headers[strings.ToLower(header.Key)] = string(header.Value)
+	}
+	event, err := decodeEvent(message{
+		Headers: headers,
+		Body:    record.Value,
+		ID:      fmt.Sprintf("%s-%d-%d", record.Topic, record.Partition, record.Offset),
+		Source:  "kafka://" + record.Topic,
+	})
+	if err != nil {
+		log.Printf("ERROR: Skipping undecodable kafka record %s[%d]@%d: %v",
+			record.Topic, record.Partition, record.Offset, err)
+		return
+	}
+
+	backoff := kafkaRetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := c.handler(ctx, event)
+		if err == nil {
+			return
+		}
+		if rejected(err) {
+			log.Printf("ERROR: Skipping rejected kafka event %s: %v", event.ID(), err)
+			return
+		}
+		if attempt == kafkaRetries {
+			log.Printf("ERROR: Skipping kafka event %s after %d attempts: %v", event.ID(), attempt+1, err)
+			return
+		}
+		log.Printf("WARNING: Kafka event %s failed (attempt %d), retrying in %s: %v", event.ID(), attempt+1, backoff, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
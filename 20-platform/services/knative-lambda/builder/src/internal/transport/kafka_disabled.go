@@ -0,0 +1,19 @@
+//go:build !kafka
+
+package transport
+
+import (
+	"context"
+	"errors"
+)
+
+// KafkaConsumer is unavailable in builds without -tags kafka
+type KafkaConsumer struct{}
+
+// NewKafkaConsumer fails: this binary was built without the Kafka consumer
+func NewKafkaConsumer(cfg KafkaConfig, handler EventHandler) (*KafkaConsumer, error) {
+	return nil, errors.New("built without kafka support (go build -tags kafka)")
+}
+
+// Start does nothing
+func (c *KafkaConsumer) Start(ctx context.Context) {}
@@ -0,0 +1,206 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// =============================================================================
+// ⚡ NATS JETSTREAM TRANSPORT
+// =============================================================================
+// For clusters running NATS instead of the Knative broker
+// (BUILDER_TRANSPORT=nats): build requests are consumed from a JetStream
+// stream through a durable consumer, lifecycle events are published to it
+// 📝 NOTE: The stream must exist and capture both the request subject and
+// the events subject; the builder only creates its durable consumer
+
+// natsAckWait is how long JetStream waits for an ack before redelivering
+// 🎯 WHY: A request is acked once its build's job exists, which includes
+// uploading the build context
+const natsAckWait = 2 * time.Minute
+
+// natsPullBatch is how many messages are buffered ahead of the handler
+// 📝 NOTE: Kept small so buffered messages don't outlive natsAckWait
+const natsPullBatch = 4
+
+// NATSConfig configures the NATS JetStream transport
+type NATSConfig struct {
+	URL           string // nats://host:4222
+	Stream        string
+	Subject       string // Build requests are consumed from this subject
+	Durable       string // Durable consumer shared by the builder replicas
+	EventsSubject string // Lifecycle events go to <EventsSubject>.<event type>
+	MaxDeliveries int    // Attempts before a failing request is dropped
+}
+
+// NATSClient consumes build requests from JetStream and publishes events to it
+type NATSClient struct {
+	cfg  NATSConfig
+	conn *nats.Conn
+	js   jetstream.JetStream
+}
+
+// NewNATSClient connects to the NATS server
+// 📝 NOTE: The client reconnects on its own, for as long as the builder runs
+func NewNATSClient(cfg NATSConfig) (*NATSClient, error) {
+	if cfg.URL == "" || cfg.Stream == "" || cfg.Subject == "" || cfg.Durable == "" {
+		return nil, errors.New("nats url, stream, subject and durable are required")
+	}
+	if cfg.MaxDeliveries < 1 {
+		cfg.MaxDeliveries = 1
+	}
+
+	conn, err := nats.Connect(cfg.URL, nats.Name("knative-lambda-builder"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats: %w", err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create jetstream context: %w", err)
+	}
+	return &NATSClient{cfg: cfg, conn: conn, js: js}, nil
+}
+
+// Consume hands the stream's build requests to the handler until the
+// context is cancelled
+// 📋 STEPS:
+//  1. Create (or update) the durable consumer, with explicit acks
+//  2. Ack handled messages, nak failing ones (redelivered up to MaxDeliveries)
+//  3. Terminate messages that can't be decoded
+func (n *NATSClient) Consume(ctx context.Context, handler EventHandler) error {
+	consumer, err := n.js.CreateOrUpdateConsumer(ctx, n.cfg.Stream, jetstream.ConsumerConfig{
+		Durable:       n.cfg.Durable,
+		FilterSubject: n.cfg.Subject,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       natsAckWait,
+		MaxDeliver:    n.cfg.MaxDeliveries,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create consumer %s on stream %s: %w", n.cfg.Durable, n.cfg.Stream, err)
+	}
+
+	consumeCtx, err := consumer.Consume(func(msg jetstream.Msg) {
+		n.handle(ctx, msg, handler)
+	}, jetstream.PullMaxMessages(natsPullBatch), jetstream.ConsumeErrHandler(func(_ jetstream.ConsumeContext, err error) {
+		log.Printf("WARNING: NATS consumer %s: %v", n.cfg.Durable, err)
+	}))
+	if err != nil {
+		return fmt.Errorf("failed to consume %s: %w", n.cfg.Subject, err)
+	}
+	log.Printf("⚡ Consuming build events from NATS subject %s (stream %s)", n.cfg.Subject, n.cfg.Stream)
+
+	<-ctx.Done()
+	consumeCtx.Stop()
+	return n.conn.Drain()
+}
+
+// handle delivers one message to the handler, then acks or naks it
+func (n *NATSClient) handle(ctx context.Context, msg jetstream.Msg, handler EventHandler) {
+	headers := map[string]string{}
+	for key, values := range msg.Headers() {
+		if len(values) > 0 {
+			headers[headerName(key)] = values[0]
+		}
+	}
+
+	id := msg.Headers().Get(nats.MsgIdHdr)
+	delivered := uint64(1)
+	if meta, err := msg.Metadata(); err == nil {
+		if id == "" {
+			id = fmt.Sprintf("%s-%d", meta.Stream, meta.Sequence.Stream)
+		}
+		delivered = meta.NumDelivered
+	}
+
+	event, err := decodeEvent(message{
+		Headers: headers,
+		Body:    msg.Data(),
+		ID:      id,
+		Source:  "nats://" + msg.Subject(),
+	})
+	if err != nil {
+		log.Printf("ERROR: Terminating undecodable nats message %s: %v", id, err)
+		n.settle(msg.Term())
+		return
+	}
+
+	err = handler(ctx, event)
+	switch natsSettlement(err, delivered, n.cfg.MaxDeliveries) {
+	case natsTerm:
+		if rejected(err) {
+			log.Printf("ERROR: NATS event %s rejected, terminating it: %v", event.ID(), err)
+		} else {
+			log.Printf("ERROR: NATS event %s failed %d times, dropping it: %v", event.ID(), delivered, err)
+		}
+		n.settle(msg.Term())
+	case natsNak:
+		log.Printf("WARNING: NATS event %s failed (delivery %d), redelivering it: %v", event.ID(), delivered, err)
+		n.settle(msg.Nak())
+	default:
+		n.settle(msg.Ack())
+	}
+}
+
+// How a handled message is settled
+const (
+	natsAck  = "ack"  // Handled: done with it
+	natsNak  = "nak"  // Failed: redeliver it
+	natsTerm = "term" // Rejected, or out of deliveries: drop it
+)
+
+// natsSettlement decides how a message is settled once the handler returned
+// err on its delivered-th delivery
+// 📝 NOTE: NumDelivered counts this delivery, so the last one is maxDeliveries
+func natsSettlement(err error, delivered uint64, maxDeliveries int) string {
+	switch {
+	case err == nil:
+		return natsAck
+	case rejected(err), delivered >= uint64(maxDeliveries):
+		return natsTerm
+	}
+	return natsNak
+}
+
+// settle logs a failed ack/nak
+// 📝 NOTE: JetStream redelivers the message after natsAckWait
+func (n *NATSClient) settle(err error) {
+	if err != nil {
+		log.Printf("WARNING: Failed to ack nats message: %v", err)
+	}
+}
+
+// Publish sends an event to <EventsSubject>.<event type>, in binary mode
+// 📝 NOTE: Used as the sender of the builder's emitter (events.NewSenderEmitter)
+func (n *NATSClient) Publish(ctx context.Context, event cloudevents.Event) error {
+	msg := nats.NewMsg(n.cfg.EventsSubject + "." + event.Type())
+	msg.Header.Set("ce-specversion", event.SpecVersion())
+	msg.Header.Set("ce-id", event.ID())
+	msg.Header.Set("ce-type", event.Type())
+	msg.Header.Set("ce-source", event.Source())
+	if event.Subject() != "" {
+		msg.Header.Set("ce-subject", event.Subject())
+	}
+	if event.DataSchema() != "" {
+		msg.Header.Set("ce-dataschema", event.DataSchema())
+	}
+	if !event.Time().IsZero() {
+		msg.Header.Set("ce-time", event.Time().UTC().Format(time.RFC3339))
+	}
+	msg.Header.Set("content-type", event.DataContentType())
+	// Lets JetStream drop duplicates of the same event
+	msg.Header.Set(nats.MsgIdHdr, event.ID())
+	msg.Data = event.Data()
+
+	if _, err := n.js.PublishMsg(ctx, msg); err != nil {
+		return fmt.Errorf("failed to publish %s to nats: %w", event.Type(), err)
+	}
+	return nil
+}
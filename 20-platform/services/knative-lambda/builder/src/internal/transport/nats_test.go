@@ -0,0 +1,102 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+func TestNATSSettlement(t *testing.T) {
+	failed := errors.New("etcd is down")
+	tests := []struct {
+		name      string
+		err       error
+		delivered uint64
+		want      string
+	}{
+		{"handled", nil, 1, natsAck},
+		{"handled on the last delivery", nil, 5, natsAck},
+		{"failed, deliveries left", failed, 4, natsNak},
+		{"failed, last delivery", failed, 5, natsTerm},
+		{"rejected on the first delivery", cehttp.NewResult(http.StatusBadRequest, "invalid payload"), 1, natsTerm},
+		{"rate limited", cehttp.NewResult(http.StatusTooManyRequests, "over the limit"), 1, natsNak},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := natsSettlement(tt.err, tt.delivered, 5); got != tt.want {
+				t.Errorf("natsSettlement() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeMsg is a JetStream message recording how it was settled
+type fakeMsg struct {
+	jetstream.Msg
+	headers   nats.Header
+	data      []byte
+	delivered uint64
+	settled   []string
+}
+
+func (m *fakeMsg) Headers() nats.Header { return m.headers }
+func (m *fakeMsg) Data() []byte         { return m.data }
+func (m *fakeMsg) Subject() string      { return "lambda.builds" }
+func (m *fakeMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	return &jetstream.MsgMetadata{Stream: "LAMBDA", NumDelivered: m.delivered, Sequence: jetstream.SequencePair{Stream: 42}}, nil
+}
+func (m *fakeMsg) Ack() error  { m.settled = append(m.settled, natsAck); return nil }
+func (m *fakeMsg) Nak() error  { m.settled = append(m.settled, natsNak); return nil }
+func (m *fakeMsg) Term() error { m.settled = append(m.settled, natsTerm); return nil }
+
+// buildStartMsg returns a binary mode build.start on its delivered-th delivery
+func buildStartMsg(delivered uint64) *fakeMsg {
+	headers := nats.Header{}
+	headers.Set("ce-specversion", "1.0")
+	headers.Set("ce-id", "evt-1")
+	headers.Set("ce-type", "network.notifi.lambda.build.start")
+	headers.Set("ce-source", "console")
+	headers.Set("content-type", "application/json")
+	return &fakeMsg{headers: headers, data: []byte(`{"thirdPartyId":"acme","parserId":"p1"}`), delivered: delivered}
+}
+
+func TestNATSHandle(t *testing.T) {
+	client := &NATSClient{cfg: NATSConfig{MaxDeliveries: 3}}
+	tests := []struct {
+		name    string
+		msg     *fakeMsg
+		err     error
+		want    string
+		handled bool
+	}{
+		{name: "success", msg: buildStartMsg(1), want: natsAck, handled: true},
+		{name: "failed, redelivered", msg: buildStartMsg(2), err: errors.New("etcd is down"), want: natsNak, handled: true},
+		{name: "retries exhausted", msg: buildStartMsg(3), err: errors.New("etcd is down"), want: natsTerm, handled: true},
+		{name: "rejected", msg: buildStartMsg(1), err: cehttp.NewResult(http.StatusUnprocessableEntity, "missing parserId"), want: natsTerm, handled: true},
+		{name: "undecodable", msg: &fakeMsg{headers: nats.Header{"ce-specversion": {"1.0"}}, data: []byte("{}"), delivered: 1}, want: natsTerm},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var handled *cloudevents.Event
+			client.handle(context.Background(), tt.msg, func(ctx context.Context, event cloudevents.Event) error {
+				handled = &event
+				return tt.err
+			})
+			if len(tt.msg.settled) != 1 || tt.msg.settled[0] != tt.want {
+				t.Errorf("handle() settled the message with %v, want %s", tt.msg.settled, tt.want)
+			}
+			if (handled != nil) != tt.handled {
+				t.Fatalf("handle() called the handler: %t, want %t", handled != nil, tt.handled)
+			}
+			if handled != nil && (handled.ID() != "evt-1" || handled.Type() != "network.notifi.lambda.build.start") {
+				t.Errorf("handle() passed %s/%s, want evt-1 build.start", handled.ID(), handled.Type())
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package transport
+
+import (
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func TestAMQPHeaders(t *testing.T) {
+	headers := amqpHeaders(amqp.Delivery{
+		ContentType: "application/json",
+		Headers: amqp.Table{
+			"cloudEvents:id":   "evt-1",
+			"cloudEvents_type": "network.notifi.lambda.teardown",
+			"ce-source":        "console",
+			"ce_specversion":   "1.0",
+			"x-delivery-count": int64(2),
+		},
+	})
+
+	want := map[string]string{
+		"ce_id":            "evt-1",
+		"ce_type":          "network.notifi.lambda.teardown",
+		"ce_source":        "console",
+		"ce_specversion":   "1.0",
+		"x-delivery-count": "2",
+		"content-type":     "application/json",
+	}
+	for key, value := range want {
+		if headers[key] != value {
+			t.Errorf("amqpHeaders()[%q] = %q, want %q", key, headers[key], value)
+		}
+	}
+}
+
+func TestShouldRequeue(t *testing.T) {
+	tests := []struct {
+		name        string
+		headers     amqp.Table
+		redelivered bool
+		want        bool
+	}{
+		{"classic queue, first delivery", nil, false, true},
+		{"classic queue, redelivered", nil, true, false},
+		{"quorum queue, below the limit", amqp.Table{"x-delivery-count": int64(3)}, true, true},
+		{"quorum queue, last delivery", amqp.Table{"x-delivery-count": int64(4)}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldRequeue(tt.headers, tt.redelivered, 5); got != tt.want {
+				t.Errorf("shouldRequeue() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+)
+
+// =============================================================================
+// 🚚 EVENT TRANSPORTS
+// =============================================================================
+// How build requests reach the builder (BUILDER_TRANSPORT)
+// 📝 NOTE: The HTTP receiver stays mounted whatever the transport: the
+// ApiServerSource delivers job updates there when jobs aren't watched
+// (JOB_WATCH=false)
+
+// Transport names
+const (
+	HTTP     = "http"     // CloudEvents POSTed to the builder (Knative Trigger)
+	Kafka    = "kafka"    // Consumed from a Kafka topic
+	RabbitMQ = "rabbitmq" // Consumed from a RabbitMQ queue
+	NATS     = "nats"     // Consumed from a NATS JetStream stream, lifecycle events published there
+)
+
+// EventTypeBuildStart is the type given to plain (non-CloudEvent) payloads
+const EventTypeBuildStart = "network.notifi.lambda.build.start"
+
+// EventHandler processes one CloudEvent (events.Handler.HandleCloudEvent)
+type EventHandler func(ctx context.Context, event cloudevents.Event) error
+
+// message is a consumed broker message an event is decoded from
+type message struct {
+	Headers map[string]string // Keys lowercased, CloudEvent attributes as ce_*
+	Body    []byte
+	ID      string // Event id given to a plain payload
+	Source  string // Event source given to a plain payload
+}
+
+// decodeEvent turns a broker message into a CloudEvent
+// 📋 MODES (CloudEvents Kafka/AMQP bindings):
+//  1. Structured: content-type application/cloudevents+json, the whole event is the body
+//  2. Binary: attributes in ce_* headers, the body is the data
+//  3. Plain JSON payload (no ce_* headers): taken as a build.start
+func decodeEvent(msg message) (cloudevents.Event, error) {
+	event := cloudevents.NewEvent()
+	contentType := msg.Headers["content-type"]
+
+	if strings.HasPrefix(contentType, "application/cloudevents+json") {
+		if err := json.Unmarshal(msg.Body, &event); err != nil {
+			return event, fmt.Errorf("failed to decode structured event: %w", err)
+		}
+		return event, nil
+	}
+
+	if contentType == "" {
+		contentType = cloudevents.ApplicationJSON
+	}
+	if err := event.SetData(contentType, msg.Body); err != nil {
+		return event, fmt.Errorf("failed to set event data: %w", err)
+	}
+
+	if msg.Headers["ce_specversion"] != "" {
+		event.SetID(msg.Headers["ce_id"])
+		event.SetType(msg.Headers["ce_type"])
+		event.SetSource(msg.Headers["ce_source"])
+		event.SetSubject(msg.Headers["ce_subject"])
+		if at, err := time.Parse(time.RFC3339, msg.Headers["ce_time"]); err == nil {
+			event.SetTime(at)
+		}
+		for key, value := range msg.Headers {
+			name := strings.TrimPrefix(key, "ce_")
+			if name == key || isContextAttribute(name) {
+				continue
+			}
+			event.SetExtension(name, value)
+		}
+		return event, event.Validate()
+	}
+
+	event.SetID(msg.ID)
+	event.SetType(EventTypeBuildStart)
+	event.SetSource(msg.Source)
+	return event, nil
+}
+
+// headerName normalizes a message header name for decodeEvent
+// 📝 NOTE: CloudEvent attributes come as cloudEvents:*, cloudEvents_*, ce-*
+// or ce_* depending on the producer and binding; all become ce_*
+func headerName(key string) string {
+	name := strings.ToLower(key)
+	for _, prefix := range []string{"cloudevents:", "cloudevents_", "ce-", "ce_"} {
+		if strings.HasPrefix(name, prefix) {
+			return "ce_" + strings.TrimPrefix(name, prefix)
+		}
+	}
+	return name
+}
+
+// isContextAttribute reports ce_* headers that aren't extensions
+func isContextAttribute(name string) bool {
+	switch name {
+	case "specversion", "id", "type", "source", "subject", "time", "dataschema", "datacontenttype":
+		return true
+	}
+	return false
+}
+
+// rejected reports whether the handler refused the event itself (a 4xx
+// result, e.g. a payload violating its contract)
+// 🎯 WHY: Redelivering it would only be refused again
+// 📝 NOTE: Except a 429 (tenant over its build rate limit), which may pass later
+func rejected(err error) bool {
+	var result *cehttp.Result
+	if !protocol.ResultAs(err, &result) {
+		return false
+	}
+	return result.StatusCode >= 400 && result.StatusCode < 500 && result.StatusCode != http.StatusTooManyRequests
+}
@@ -0,0 +1,97 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+)
+
+func TestDecodeEvent(t *testing.T) {
+	data := []byte(`{"thirdPartyId":"acme","parserId":"p1"}`)
+
+	tests := []struct {
+		name       string
+		record     message
+		wantID     string
+		wantType   string
+		wantSource string
+	}{
+		{
+			name: "binary mode",
+			record: message{
+				Headers: map[string]string{
+					"ce_specversion": "1.0",
+					"ce_id":          "evt-1",
+					"ce_type":        "network.notifi.lambda.rebuild",
+					"ce_source":      "billing",
+					"content-type":   "application/json",
+				},
+				Body: data,
+			},
+			wantID: "evt-1", wantType: "network.notifi.lambda.rebuild", wantSource: "billing",
+		},
+		{
+			name: "structured mode",
+			record: message{
+				Headers: map[string]string{"content-type": "application/cloudevents+json; charset=utf-8"},
+				Body: []byte(`{"specversion":"1.0","id":"evt-2","type":"network.notifi.lambda.build.start",` +
+					`"source":"crm","datacontenttype":"application/json","data":{"thirdPartyId":"acme","parserId":"p1"}}`),
+			},
+			wantID: "evt-2", wantType: EventTypeBuildStart, wantSource: "crm",
+		},
+		{
+			name:   "plain payload",
+			record: message{Headers: map[string]string{}, Body: data, ID: "builds-2-42", Source: "kafka://builds"},
+			wantID: "builds-2-42", wantType: EventTypeBuildStart, wantSource: "kafka://builds",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := decodeEvent(tt.record)
+			if err != nil {
+				t.Fatalf("decodeEvent() error = %v", err)
+			}
+			if event.ID() != tt.wantID || event.Type() != tt.wantType || event.Source() != tt.wantSource {
+				t.Errorf("decodeEvent() = %s/%s/%s, want %s/%s/%s",
+					event.ID(), event.Type(), event.Source(), tt.wantID, tt.wantType, tt.wantSource)
+			}
+			var payload struct {
+				ThirdPartyId string `json:"thirdPartyId"`
+			}
+			if err := event.DataAs(&payload); err != nil || payload.ThirdPartyId != "acme" {
+				t.Errorf("DataAs() = %+v, %v; want the record's payload", payload, err)
+			}
+		})
+	}
+
+	// Binary mode without the required attributes is rejected
+	if _, err := decodeEvent(message{Headers: map[string]string{"ce_specversion": "1.0"}, Body: data}); err == nil {
+		t.Error("decodeEvent(binary without id/type/source) error = nil, want an error")
+	}
+}
+
+func TestRejected(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "bad request", err: cehttp.NewResult(http.StatusBadRequest, "invalid"), want: true},
+		{name: "wrapped", err: fmt.Errorf("failed to handle: %w", cehttp.NewResult(http.StatusUnprocessableEntity, "invalid")), want: true},
+		{name: "server error", err: cehttp.NewResult(http.StatusInternalServerError, "boom"), want: false},
+		{name: "rate limited", err: cehttp.NewResult(http.StatusTooManyRequests, "later"), want: false},
+		{name: "plain error", err: errors.New("boom"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rejected(tt.err); got != tt.want {
+				t.Errorf("rejected(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
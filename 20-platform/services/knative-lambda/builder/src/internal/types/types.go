@@ -1,5 +1,12 @@
 package types
 
+import (
+	"encoding/json"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
 // =============================================================================
 // 📋 CORE DATA TYPES
 // =============================================================================
@@ -9,23 +16,237 @@ package types
 // BuildEvent represents a request to build a new lambda function
 // 🎯 PURPOSE: This is the main trigger that starts our build process
 type BuildEvent struct {
-	ThirdPartyId string `json:"thirdPartyId"` // Who owns this lambda (like a customer ID)
-	ParserId     string `json:"parserId"`     // What type of parser to build
+	ThirdPartyId string `json:"thirdPartyId"`          // Who owns this lambda (like a customer ID)
+	ParserId     string `json:"parserId"`              // What type of parser to build
+	ID           string `json:"id,omitempty"`          // Optional unique identifier
+	Attempt      int    `json:"attempt,omitempty"`     // Requeue count after preemption (0 = first attempt)
+	Retry        int    `json:"-"`                     // Retry count after failed build jobs (0 = first try)
+	Priority     string `json:"priority,omitempty"`    // high, normal (default) or low: order in the build queue
+	CallbackURL  string `json:"callbackUrl,omitempty"` // Receives the build's outcome as a signed POST
+	Backend      string `json:"backend,omitempty"`     // kaniko or buildkit (empty = BUILD_BACKEND)
+	Runtime      string `json:"runtime,omitempty"`     // Language of the parser: node (default), python or go
+	PushRoleArn  string `json:"pushRoleArn,omitempty"` // IAM role the image is pushed with (see AWS_PUSH_ROLES_ALLOWED; empty = AWS_PUSH_ROLE_ARN)
+	Region       string `json:"region,omitempty"`      // AWS region whose ECR the image is pushed to (see AWS_PUSH_REGIONS_ALLOWED; empty = the registry's)
+	Rebuild      bool   `json:"-"`                     // Set for lambda.rebuild: bypass the cache, roll a new revision
+	ImageTag     string `json:"-"`                     // Image revision the build pushed, e.g. "p1-v3" (set once its job is created)
+	Rollback     bool   `json:"-"`                     // Set for lambda.rollback: deploy straight away, without a canary
+
+	// How a redeploy shifts traffic: rolling, canary or blue-green (empty = the tenant's, or DEPLOY_STRATEGY)
+	DeployStrategy string `json:"deployStrategy,omitempty"`
+	// Render and validate (server-side dry run) what the build would create, without creating it
+	DryRun bool `json:"dryRun,omitempty"`
+
+	BuildArgs map[string]string `json:"buildArgs,omitempty"` // Dockerfile ARGs of the build (--build-arg)
+	Env       map[string]string `json:"env,omitempty"`       // Environment of the deployed parser
+	// Extra npm dependencies, name -> version, merged into package.json (see NPM_DEPENDENCY_ALLOWLIST)
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+
+	Source *BuildSource `json:"source,omitempty"` // Where the parser comes from (nil = the source bucket)
+	// Requests and limits of the build pod over the tenant's and the platform's (see BUILD_RESOURCE_MAX)
+	Resources *BuildResources `json:"resources,omitempty"`
+	// SHA-256 (hex) the parser source must have; once the build started, the one it had
+	SourceSHA256 string `json:"sourceSha256,omitempty"`
+	SourceETag   string `json:"-"` // ETag of the parser source in the source bucket (set once the build started)
+
+	IdempotencyKey string         `json:"-"` // Recognizes redeliveries of the request (set once accepted)
+	Origin         *RequestOrigin `json:"-"` // The CloudEvent that requested the build (nil for API requests)
+	BatchId        string         `json:"-"` // The build.batch this build is part of, if any
+}
+
+// BuildResources sizes a build pod: quantities by resource (cpu, memory,
+// ephemeral-storage), e.g. {"memory": "3Gi"}
+type BuildResources struct {
+	Requests map[string]string `json:"requests,omitempty"`
+	Limits   map[string]string `json:"limits,omitempty"`
+}
+
+// BuildSource is where a build takes its parser from
+type BuildSource struct {
+	Git    *GitSource    `json:"git,omitempty"`
+	Inline *InlineSource `json:"inline,omitempty"`
+}
+
+// GitSource is a parser kept in a git repository
+type GitSource struct {
+	URL    string `json:"url"`              // https:// URL of the repository
+	Ref    string `json:"ref,omitempty"`    // Branch, tag or commit SHA (empty = the default branch)
+	Path   string `json:"path,omitempty"`   // Directory of the parser in the repository (empty = its root)
+	Commit string `json:"commit,omitempty"` // The commit Ref resolved to (set by the builder)
+}
+
+// Dependency is an extra npm dependency of a build
+type Dependency struct {
+	Name    string
+	Version string // Version, range or dist-tag
+}
+
+// InlineSource is a parser file sent in the build request itself
+type InlineSource struct {
+	Content  string `json:"content"`            // The parser file, base64-encoded
+	Encoding string `json:"encoding,omitempty"` // "base64" (default) or "gzip" (gzipped, then base64-encoded)
+}
+
+// DryRunResult is what a build would create, rendered and validated but not applied
+// 🎯 PURPOSE: Debug template changes without building or deploying anything
+type DryRunResult struct {
+	ThirdPartyId string           `json:"thirdPartyId"`
+	ParserId     string           `json:"parserId"`
+	ImageTag     string           `json:"imageTag"`           // Revision the build would push
+	DeployMode   string           `json:"deployMode"`         // knative or fallback
+	Manifests    string           `json:"manifests"`          // Multi-document YAML: build job, service objects, trigger
+	Rejected     []RejectedObject `json:"rejected,omitempty"` // Objects the API server refused
+}
+
+// RejectedObject is a rendered object that failed its server-side dry run
+type RejectedObject struct {
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// BatchBuildEvent asks the builder to build several parsers of a tenant
+// 🎯 PURPOSE: One request (and one batch.completed) for a tenant's bulk rebuilds
+type BatchBuildEvent struct {
+	ThirdPartyId string   `json:"thirdPartyId"`
+	ParserIds    []string `json:"parserIds"`
+	ID           string   `json:"id,omitempty"`       // Batch id (the event id when empty)
+	Priority     string   `json:"priority,omitempty"` // Priority of every build of the batch
+}
+
+// RequestOrigin is the CloudEvent a build was requested with, as received
+// 🎯 PURPOSE: Dead letters carry it so the request can be replayed as is
+type RequestOrigin struct {
+	Type   string          `json:"type"`
+	ID     string          `json:"id"`
+	Source string          `json:"source"`
+	Data   json.RawMessage `json:"data,omitempty"`
+}
+
+// TeardownEvent asks the builder to remove a deployed parser
+// 🎯 PURPOSE: Deploys only ever add resources; this is how stale parsers go away
+type TeardownEvent struct {
+	ThirdPartyId string `json:"thirdPartyId"`
+	ParserId     string `json:"parserId"`
+	ID           string `json:"id,omitempty"`          // Optional unique identifier
+	DeleteImage  bool   `json:"deleteImage,omitempty"` // Also delete the parser's image tag
+}
+
+// RollbackEvent asks the builder to redeploy a parser with an earlier image revision
+// 🎯 PURPOSE: Undo a bad deploy without rebuilding (or editing the Service by hand)
+type RollbackEvent struct {
+	ThirdPartyId string `json:"thirdPartyId"`
+	ParserId     string `json:"parserId"`
+	ImageTag     string `json:"imageTag"`     // Recorded revision to deploy, e.g. "p1-v3"
 	ID           string `json:"id,omitempty"` // Optional unique identifier
 }
 
+// RollbackCompletedEventData is the payload of network.notifi.lambda.rollback.completed
+type RollbackCompletedEventData struct {
+	ThirdPartyId string `json:"thirdPartyId"`
+	ParserId     string `json:"parserId"`
+	RollbackId   string `json:"rollbackId,omitempty"` // id of the rollback request
+	ImageTag     string `json:"imageTag"`
+	Image        string `json:"image"`                 // Full image reference deployed
+	ImageDigest  string `json:"imageDigest,omitempty"` // Digest the registry serves for the tag ("" when it can't be queried)
+	DeployMode   string `json:"deployMode,omitempty"`  // knative or fallback
+}
+
 // JobTemplateData holds ALL the information needed to create a Kaniko build job
 // 🎯 PURPOSE: This gets passed to our job template to fill in all the blanks
 type JobTemplateData struct {
 	Name         string // Unique name for this specific build job
 	Dockerfile   string // Which Dockerfile to use (usually just "Dockerfile")
 	Context      string // Where to find the source code (S3 path)
+	ContextURL   string // Presigned URL of Context the job downloads it from ("" = from S3, with its own credentials)
 	ImageTag     string // Full Docker image URI where result will be stored
+	Tag          string // Just the tag of ImageTag (the image revision, e.g. "p1-v3")
 	BucketName   string // S3 bucket for temporary build files
 	ThirdPartyId string // Customer/organization identifier
 	ParserId     string // Parser type identifier
 	Region       string // AWS region we're operating in
 	AccountId    string // AWS account ID for ECR permissions
+	KanikoImage  string // Kaniko executor image
+	Reproducible bool   // Pass --reproducible to Kaniko (strips timestamps from the image)
+	Attempt      int    // Build attempt (incremented when a preempted build is requeued)
+	Retry        int    // Retry count (incremented when a failed build is retried)
+
+	PriorityClassName     string // Optional PriorityClass of the build pod
+	ActiveDeadlineSeconds int64  // How long the job may run (0 = no deadline)
+
+	// CacheRepo is the tenant's Kaniko layer cache repository ("" = no layer cache)
+	CacheRepo string
+	CacheTTL  string // --cache-ttl, e.g. "168h0m0s"
+
+	// Platforms the image is built for, comma separated ("linux/amd64,linux/arm64");
+	// several make a manifest list (BuildKit only)
+	Platforms string
+	NodeArch  string // kubernetes.io/arch the build pod must run on ("" = any)
+
+	// Requests and limits of the Kaniko container (nil = none)
+	Resources *corev1.ResourceRequirements
+
+	// BuildKit backend (buildkit-job.yaml.tpl)
+	BuildKitAddr  string   // buildkitd address (buildctl --addr)
+	BuildKitImage string   // Image with buildctl
+	BuildSecrets  []string // Keys of the buildkit-secrets Secret passed as build secrets
+	NpmrcSecret   string   // Secret holding the .npmrc mounted into the job ("" = none)
+
+	// Secret holding the docker config (.dockerconfigjson) the job pushes with ("" = the backend's default)
+	RegistryAuthSecret string
+	InsecureRegistry   string // Registry host whose TLS certificate isn't verified ("" = none)
+
+	// Cross-account pushes: the IAM role the job pushes with, and the Secret
+	// (and key prefix) holding its temporary credentials ("" = the job's own credentials)
+	PushRole       string
+	PushRoleSecret string
+	PushRoleKey    string
+	// Regional pushes: the AWS region whose ECR the job pushes to ("" = the registry's own)
+	PushRegion string
+
+	// S3 somewhere else than AWS (LocalStack, MinIO) the job reads its context from ("" = AWS)
+	S3Endpoint       string
+	S3ForcePathStyle bool // Address buckets in the path (http://host/bucket/key), as MinIO wants
+
+	BuildArgs   []BuildArg   // The build event's build args, sorted by name
+	ImageLabels []ImageLabel // Labels of the pushed image (the commit of git sources), sorted by name
+}
+
+// BuildArg is a Dockerfile ARG passed to a build
+type BuildArg struct {
+	Name  string
+	Value string
+}
+
+// ImageLabel is a label of a built image
+type ImageLabel struct {
+	Name  string
+	Value string
+}
+
+// TestJobTemplateData holds the information needed to create a parser test job
+// 🎯 PURPOSE: Runs the tenant's {parserId}.test.js inside the freshly built image
+type TestJobTemplateData struct {
+	Name           string // Unique name for this test job
+	ThirdPartyId   string // Customer/organization identifier
+	ParserId       string // Parser type identifier
+	Image          string // The image under test
+	Tag            string // Its tag (the image revision)
+	TestFile       string // Test file inside the image's working directory
+	TimeoutSeconds int64  // activeDeadlineSeconds of the job
+}
+
+// SBOMJobTemplateData holds the information needed to create an SBOM job
+// 🎯 PURPOSE: Inventories the packages of a freshly pushed image (syft) into S3
+type SBOMJobTemplateData struct {
+	Name         string // Unique name for this SBOM job
+	ThirdPartyId string // Customer/organization identifier
+	ParserId     string // Parser type identifier
+	Image        string // The image to inventory
+	Tag          string // Its tag (the image revision)
+	SyftImage    string // Image with syft
+	Destination  string // s3:// prefix the SBOMs are uploaded under
+	Region       string // AWS region of the bucket
+	KMSKeyID     string // Tenant's KMS key (SSE-KMS), empty for the bucket default
 }
 
 // ServiceTemplateData holds info needed to create a Knative service
@@ -34,12 +255,56 @@ type ServiceTemplateData struct {
 	ThirdPartyId string // Customer identifier
 	ParserId     string // Parser type
 	Image        string // Full Docker image URI to deploy
+
+	// Sidecars run next to the parser (from the sidecar catalog); the parser and
+	// every sidecar mount the SharedVolume emptyDir at SharedMountPath
+	Sidecars        []corev1.Container
+	SharedVolume    string // "" when there are no sidecars
+	SharedMountPath string
+
+	// RebuiltAt, when set, is stamped on the pod template so a rebuilt image
+	// (same tag) rolls out as a new revision
+	RebuiltAt string
+
+	// DeployMode is "knative", or "fallback" when the parser runs as a plain
+	// Deployment + Service + HPA (the Replicas/TargetCPU fields size the HPA)
+	DeployMode           string
+	MinReplicas          int
+	MaxReplicas          int
+	TargetCPUUtilization int
+
+	// Env is the build event's environment for the parser container, sorted by name
+	Env []corev1.EnvVar
+
+	// Namespace the parser runs in: KUBERNETES_NAMESPACE, or the tenant's own
+	// with TENANT_NAMESPACES
+	Namespace string
 }
 
 // WrapperTemplateData holds info for generating wrapper.js
 // 🎯 PURPOSE: Creates the Node.js wrapper that loads the actual parser
 type WrapperTemplateData struct {
-	ParserId string // Used to locate and load the correct parser file
+	ParserId     string // Used to locate and load the correct parser file
+	BaseImage    string // Dockerfile FROM (pinned by digest in reproducible mode)
+	RuntimeImage string // Final stage of images built in two stages (Go parsers)
+	Npmrc        bool   // Build jobs mount an .npmrc (private npm registry)
+	Backend      string // Build backend (kaniko or buildkit): where the .npmrc is
+}
+
+// BuildInputs records everything that went into a build
+// 🎯 PURPOSE: Reproducible builds - same inputs must yield the same image digest
+type BuildInputs struct {
+	ThirdPartyId string `json:"thirdPartyId"`
+	ParserId     string `json:"parserId"`
+	BaseImage    string `json:"baseImage,omitempty"` // Runtime base image (templated Dockerfile only)
+	KanikoImage  string `json:"kanikoImage"`
+	Dockerfile   string `json:"dockerfile"` // "Dockerfile", or e.g. "p1.Dockerfile" when custom
+	// Custom Dockerfile origin, e.g. s3://sources/acme/p1.Dockerfile ("" = templated)
+	DockerfileSource string            `json:"dockerfileSource,omitempty"`
+	DockerfileSHA256 string            `json:"dockerfileSha256"` // sha256 of the Dockerfile built
+	BuildArgs        map[string]string `json:"buildArgs,omitempty"`
+	Files            map[string]string `json:"files"`         // Build context file path (slash-separated) -> sha256
+	ContextSHA256    string            `json:"contextSha256"` // sha256 of the context tarball
 }
 
 // ResourceEventData represents Kubernetes resource status updates
@@ -47,10 +312,127 @@ type WrapperTemplateData struct {
 type ResourceEventData struct {
 	Kind       string                 `json:"kind"`             // Type of K8s resource (Job, Pod, etc)
 	Name       string                 `json:"name"`             // Name of the specific resource
+	Metadata   ResourceMetadata       `json:"metadata"`         // Labels identify the build a Job belongs to
 	Status     map[string]interface{} `json:"status,omitempty"` // Current status info
 	BuildEvent BuildEvent             `json:"buildEvent"`       // Original build request that triggered this
 }
 
+// ResourceMetadata is the part of a resource's metadata the builder reads
+type ResourceMetadata struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// TriggerFailedEventData is the payload of network.notifi.lambda.trigger.failed
+// 🎯 PURPOSE: Tells consumers a parser is deployed but its trigger never became Ready
+type TriggerFailedEventData struct {
+	ThirdPartyId string `json:"thirdPartyId"`
+	ParserId     string `json:"parserId"`
+	Kind         string `json:"kind"`      // Trigger kind (Trigger, RabbitmqSource, ...)
+	Namespace    string `json:"namespace"` // Where the trigger lives
+	Name         string `json:"name"`
+	Condition    string `json:"condition"` // Failing condition type ("Ready" on timeout)
+	Reason       string `json:"reason,omitempty"`
+	Message      string `json:"message,omitempty"`
+}
+
+// AdminAuditEventData is the payload of network.notifi.lambda.admin.audit
+// 🎯 PURPOSE: Who did what through the admin API, reads included
+type AdminAuditEventData struct {
+	Caller       string    `json:"caller"`           // Token subject, "anonymous" without auth
+	Issuer       string    `json:"issuer,omitempty"` // Token issuer
+	Replica      string    `json:"replica"`          // Builder replica that served the call
+	Method       string    `json:"method"`
+	Path         string    `json:"path"`
+	Action       string    `json:"action"` // e.g. list, promote, demote, drop
+	Status       int       `json:"status"` // HTTP status answered
+	BuildId      string    `json:"buildId,omitempty"`
+	ThirdPartyId string    `json:"thirdPartyId,omitempty"`
+	ParserId     string    `json:"parserId,omitempty"`
+	At           time.Time `json:"at"`
+}
+
+// BuildLifecycleEventData is the payload of the builder's build.* events
+// (accepted, started, image.pushed, deployed, failed)
+// 🎯 PURPOSE: Lets downstream systems follow a build without scraping logs
+// 📝 NOTE: Fields a stage doesn't know yet are omitted; see the contracts for
+// which fields each event type carries
+type BuildLifecycleEventData struct {
+	ThirdPartyId string `json:"thirdPartyId"`
+	ParserId     string `json:"parserId"`
+	BuildId      string `json:"buildId,omitempty"` // id of the build.start payload, when it had one
+	Attempt      int    `json:"attempt,omitempty"` // Requeue count after preemption
+	Retry        int    `json:"retry,omitempty"`   // Retry count after failed build jobs
+	JobName      string `json:"jobName,omitempty"` // Kaniko job (absent for cached builds)
+	Image        string `json:"image,omitempty"`
+	ImageDigest  string `json:"imageDigest,omitempty"`       // Only for registries that can be queried (ECR)
+	Cached       bool   `json:"cached,omitempty"`            // Image reused, no Kaniko job ran
+	DeployMode   string `json:"deployMode,omitempty"`        // "knative" or "fallback"
+	Stage        string `json:"stage,omitempty"`             // build.failed: validate, build, test, scan or deploy
+	Reason       string `json:"reason,omitempty"`            // build.rejected: why the request was refused
+	RetryAfter   int    `json:"retryAfterSeconds,omitempty"` // build.rejected: when to submit again
+	RetryIn      int    `json:"retryInSeconds,omitempty"`    // build.retrying: when the build starts again
+	Error        string `json:"error,omitempty"`
+
+	// build.started, build.skipped, build.image.pushed, build.deployed: the parser source built
+	SourceSHA256 string `json:"sourceSha256,omitempty"`
+	SourceETag   string `json:"sourceEtag,omitempty"` // In the source bucket
+
+	// build.blocked: the image's vulnerability scan
+	ScanStatus string         `json:"scanStatus,omitempty"`
+	Findings   map[string]int `json:"findings,omitempty"` // Per severity (CRITICAL, HIGH, ...)
+}
+
+// DeadLetterEventData is the payload of network.notifi.lambda.build.deadletter
+// (and of the objects written to an S3 dead-letter sink)
+// 🎯 PURPOSE: Everything needed to investigate and replay a build that failed
+// for good: the request as it arrived and why its build failed
+type DeadLetterEventData struct {
+	ThirdPartyId string        `json:"thirdPartyId"`
+	ParserId     string        `json:"parserId"`
+	BuildId      string        `json:"buildId,omitempty"`
+	Attempt      int           `json:"attempt,omitempty"` // Requeue count after preemption
+	Stage        string        `json:"stage"`             // validate, build, test, scan or deploy
+	JobName      string        `json:"jobName,omitempty"`
+	Error        string        `json:"error"`
+	FailedAt     time.Time     `json:"failedAt"`
+	Request      RequestOrigin `json:"request"` // The original request
+}
+
+// BuildCallbackData is the body POSTed to a build's callbackUrl
+// 🎯 PURPOSE: The build's outcome, for receivers without CloudEvents
+type BuildCallbackData struct {
+	Status       string    `json:"status"` // deployed or failed
+	ThirdPartyId string    `json:"thirdPartyId"`
+	ParserId     string    `json:"parserId"`
+	BuildId      string    `json:"buildId"`
+	Attempt      int       `json:"attempt,omitempty"`
+	Image        string    `json:"image,omitempty"`
+	DeployMode   string    `json:"deployMode,omitempty"`
+	Stage        string    `json:"stage,omitempty"` // failed: validate, build, test, scan or deploy
+	Error        string    `json:"error,omitempty"`
+	FinishedAt   time.Time `json:"finishedAt"`
+}
+
+// BatchBuildResult is the outcome of one build of a batch
+type BatchBuildResult struct {
+	ParserId string `json:"parserId"`
+	BuildId  string `json:"buildId"`
+	Status   string `json:"status"`          // deployed, failed or rejected
+	Stage    string `json:"stage,omitempty"` // failed: validate, build, test, scan or deploy
+	Error    string `json:"error,omitempty"`
+}
+
+// BatchCompletedEventData is the payload of network.notifi.lambda.batch.completed
+// 🎯 PURPOSE: One event once every build of a batch has deployed or failed
+type BatchCompletedEventData struct {
+	ThirdPartyId string             `json:"thirdPartyId"`
+	BatchId      string             `json:"batchId"`
+	Deployed     int                `json:"deployed"`
+	Failed       int                `json:"failed"` // Failed or rejected builds
+	Builds       []BatchBuildResult `json:"builds"`
+}
+
 // =============================================================================
 // 🔍 HELPER METHODS
 // =============================================================================
@@ -59,6 +441,31 @@ type ResourceEventData struct {
 // 🎯 WHY: We need to know when builds finish so we can deploy the result
 // 📝 HOW: Looks for a "Complete" condition with "True" status in the job
 func (r *ResourceEventData) IsJobComplete() bool {
+	return r.hasJobCondition("Complete")
+}
+
+// IsJobFailed checks if a Kubernetes Job has failed (backoff limit or deadline reached)
+func (r *ResourceEventData) IsJobFailed() bool {
+	return r.hasJobCondition("Failed")
+}
+
+// JobFailure returns the reason and message of a Job's Failed condition
+func (r *ResourceEventData) JobFailure() (reason, message string) {
+	conditions, _ := r.Status["conditions"].([]interface{})
+	for _, cond := range conditions {
+		condition, ok := cond.(map[string]interface{})
+		if !ok || condition["type"] != "Failed" {
+			continue
+		}
+		reason, _ = condition["reason"].(string)
+		message, _ = condition["message"].(string)
+		return reason, message
+	}
+	return "", ""
+}
+
+// hasJobCondition checks for a Job condition of the given type with "True" status
+func (r *ResourceEventData) hasJobCondition(conditionType string) bool {
 	// Quick validation - only works for Job resources
 	if r.Kind != "Job" || r.Status == nil {
 		return false
@@ -71,8 +478,8 @@ func (r *ResourceEventData) IsJobComplete() bool {
 		return false
 	}
 
-	// Look through all conditions for the "Complete" one
-	// 🔍 WHAT WE'RE LOOKING FOR: type="Complete" AND status="True"
+	// Look through all conditions for the requested one
+	// 🔍 WHAT WE'RE LOOKING FOR: type=conditionType AND status="True"
 	for _, cond := range conditions {
 		condition, ok := cond.(map[string]interface{})
 		if !ok {
@@ -82,22 +489,11 @@ func (r *ResourceEventData) IsJobComplete() bool {
 		condType, typeOk := condition["type"].(string)
 		status, statusOk := condition["status"].(string)
 
-		// 🎯 SUCCESS: Found a Complete=True condition
-		if typeOk && statusOk && condType == "Complete" && status == "True" {
+		// 🎯 FOUND: e.g. a Complete=True condition
+		if typeOk && statusOk && condType == conditionType && status == "True" {
 			return true
 		}
 	}
 
 	return false
 }
-
-// =============================================================================
-// 📁 BUILD CONTEXT TEMPLATE CONFIGURATION
-// =============================================================================
-
-// BuildContextTemplate defines a template file to be processed for the build context
-type BuildContextTemplate struct {
-	SourceTplPath string                       // Relative path from project root
-	TargetName    string                       // Target filename in the tempDir
-	DataFunc      func(BuildEvent) interface{} // Function to get template data
-}
@@ -0,0 +1,298 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// 🔷 AZURE CONTAINER REGISTRY
+// =============================================================================
+// On AKS, images can be pushed to Azure Container Registry
+// (REGISTRY_PROVIDER=acr) instead of ECR. The registry URL is
+// <name>.azurecr.io/<prefix>, and every tenant gets a repository under the
+// prefix, as in ECR: <prefix>/<thirdPartyId>
+// 🎯 WHY: ACR creates repositories on the first push, so there's nothing to
+// create; the builder only checks it can reach the registry
+// 📝 NOTE: The builder signs in with Microsoft Entra Workload ID: the Entra
+// token is exchanged for an ACR refresh token, and that for an access token
+// scoped to the repository of each call
+
+// ACRScope is the scope of the Entra ID tokens exchanged for ACR refresh tokens
+const ACRScope = "https://management.azure.com/.default"
+
+// acrTokenUser is the user name ACR expects along with a refresh token
+const acrTokenUser = "00000000-0000-0000-0000-000000000000"
+
+// acrRefreshTokenReuse is how long an ACR refresh token (valid 3 hours) is reused
+const acrRefreshTokenReuse = time.Hour
+
+// acrManifestTypes are the manifests ImageDigest accepts
+var acrManifestTypes = []string{
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.oci.image.index.v1+json",
+}
+
+// ACR implements Registry on Azure Container Registry
+type ACR struct {
+	client      *http.Client
+	loginServer string                                    // e.g. acmeplatform.azurecr.io
+	endpoint    string                                    // https://<loginServer>
+	entraToken  func(ctx context.Context) (string, error) // nil = unauthenticated (tests)
+
+	mu           sync.Mutex // Guards the refresh token
+	refreshToken string
+	refreshed    time.Time
+}
+
+// NewACR creates an ACR-backed registry for a login server, signing in with
+// the given Entra ID tokens
+func NewACR(loginServer string, entraToken func(ctx context.Context) (string, error)) *ACR {
+	return &ACR{
+		client:      &http.Client{Timeout: time.Minute},
+		loginServer: loginServer,
+		endpoint:    "https://" + loginServer,
+		entraToken:  entraToken,
+	}
+}
+
+// WithEndpoint talks to the registry at another endpoint
+func (r *ACR) WithEndpoint(endpoint string) *ACR {
+	r.endpoint = strings.TrimSuffix(endpoint, "/")
+	return r
+}
+
+// IsACR reports whether a registry URL points at Azure Container Registry
+func IsACR(registryURL string) bool {
+	host, _, _ := strings.Cut(registryURL, "/")
+	return strings.Contains(host, ".azurecr.")
+}
+
+// EnsureRepository checks the builder can read a tenant's repository
+// 📝 NOTE: A missing repository is fine: Kaniko's first push creates it
+// (so the builder never creates one)
+func (r *ACR) EnsureRepository(ctx context.Context, repositoryName string) (bool, error) {
+	scope := "repository:" + repositoryName + ":metadata_read"
+	err := r.call(ctx, http.MethodGet, "/acr/v1/"+repositoryName, scope, nil, nil)
+	if isNotFound(err) {
+		log.Printf("ACR repository %s/%s doesn't exist yet, the first push creates it", r.loginServer, repositoryName)
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check ACR repository %s: %w", repositoryName, err)
+	}
+	return false, nil
+}
+
+// EnsureCacheRepository checks the builder can read a layer cache repository
+// 📝 NOTE: ACR has no per-repository expiry: cached layers are kept until an
+// ACR task (acr purge) or the registry's retention policy removes them
+func (r *ACR) EnsureCacheRepository(ctx context.Context, repositoryName string, expireAfter time.Duration) error {
+	_, err := r.EnsureRepository(ctx, repositoryName)
+	return err
+}
+
+// ImageDigest returns the digest of repositoryName:tag ("" if it doesn't exist)
+func (r *ACR) ImageDigest(ctx context.Context, repositoryName, tag string) (string, error) {
+	header := http.Header{"Accept": {strings.Join(acrManifestTypes, ", ")}}
+	scope := "repository:" + repositoryName + ":pull"
+	err := r.call(ctx, http.MethodHead, "/v2/"+repositoryName+"/manifests/"+url.PathEscape(tag), scope, header, nil)
+	if isNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get image %s:%s: %w", repositoryName, tag, err)
+	}
+	return header.Get("Docker-Content-Digest"), nil
+}
+
+// ListRepositories returns the ACR repositories whose name starts with prefix
+func (r *ACR) ListRepositories(ctx context.Context, prefix string) ([]string, error) {
+	const pageSize = 1000
+	var names []string
+	last := ""
+	for {
+		var page struct {
+			Repositories []string `json:"repositories"`
+		}
+		path := fmt.Sprintf("/acr/v1/_catalog?n=%d", pageSize)
+		if last != "" {
+			path += "&last=" + url.QueryEscape(last)
+		}
+		if err := r.call(ctx, http.MethodGet, path, "registry:catalog:*", nil, &page); err != nil {
+			return nil, fmt.Errorf("failed to list ACR repositories: %w", err)
+		}
+		for _, name := range page.Repositories {
+			if strings.HasPrefix(name, prefix) {
+				names = append(names, name)
+			}
+		}
+		if len(page.Repositories) < pageSize {
+			return names, nil
+		}
+		last = page.Repositories[len(page.Repositories)-1]
+	}
+}
+
+// DeleteImage removes the tag repositoryName:tag
+// 📝 NOTE: Only the tag is removed; the untagged manifest is left to the
+// registry's retention policy
+func (r *ACR) DeleteImage(ctx context.Context, repositoryName, tag string) error {
+	scope := "repository:" + repositoryName + ":delete"
+	err := r.call(ctx, http.MethodDelete, "/acr/v1/"+repositoryName+"/_tags/"+url.PathEscape(tag), scope, nil, nil)
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to delete image %s:%s: %w", repositoryName, tag, err)
+	}
+	return nil
+}
+
+// ImageScan implements Registry
+// 📝 NOTE: Defender for Containers findings aren't read: the scan gate is ECR and Harbor only
+func (r *ACR) ImageScan(ctx context.Context, repositoryName, tag string) (*ScanResult, error) {
+	return nil, nil
+}
+
+// DockerConfig returns the docker config.json Kaniko pushes with: a fresh
+// ACR refresh token for the login server
+// 🔐 Refresh tokens are valid for 3 hours; the config is rewritten after one,
+// so a build started with it has at least two
+func (r *ACR) DockerConfig(ctx context.Context) ([]byte, time.Duration, error) {
+	token, err := r.exchange(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	config, err := json.Marshal(map[string]any{
+		"auths": map[string]any{
+			r.loginServer: map[string]string{
+				"auth": base64.StdEncoding.EncodeToString([]byte(acrTokenUser + ":" + token)),
+			},
+		},
+	})
+	return config, acrRefreshTokenReuse, err
+}
+
+// exchange trades an Entra ID token for a new ACR refresh token
+func (r *ACR) exchange(ctx context.Context) (string, error) {
+	if r.entraToken == nil {
+		return "", fmt.Errorf("no Entra ID tokens to sign in to %s with", r.loginServer)
+	}
+	entraToken, err := r.entraToken(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get an Entra ID token: %w", err)
+	}
+	var exchanged struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	form := url.Values{
+		"grant_type":   {"access_token"},
+		"service":      {r.loginServer},
+		"access_token": {entraToken},
+	}
+	if err := r.postForm(ctx, "/oauth2/exchange", form, &exchanged); err != nil {
+		return "", fmt.Errorf("failed to get an ACR refresh token: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refreshToken, r.refreshed = exchanged.RefreshToken, time.Now()
+	return exchanged.RefreshToken, nil
+}
+
+// accessToken returns an ACR access token for scope ("" = unauthenticated)
+func (r *ACR) accessToken(ctx context.Context, scope string) (string, error) {
+	if r.entraToken == nil {
+		return "", nil
+	}
+	r.mu.Lock()
+	refreshToken := r.refreshToken
+	if time.Since(r.refreshed) > acrRefreshTokenReuse {
+		refreshToken = ""
+	}
+	r.mu.Unlock()
+	if refreshToken == "" {
+		var err error
+		if refreshToken, err = r.exchange(ctx); err != nil {
+			return "", err
+		}
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"service":       {r.loginServer},
+		"scope":         {scope},
+		"refresh_token": {refreshToken},
+	}
+	if err := r.postForm(ctx, "/oauth2/token", form, &token); err != nil {
+		return "", fmt.Errorf("failed to get an ACR access token: %w", err)
+	}
+	return token.AccessToken, nil
+}
+
+// postForm posts a form to the registry's token endpoints, decoding the response into out
+func (r *ACR) postForm(ctx context.Context, path string, form url.Values, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r.do(req, nil, out)
+}
+
+// call sends a request with an access token for scope; response headers are
+// copied into header (if not nil), the body decoded into out (if not nil)
+func (r *ACR) call(ctx context.Context, method, path, scope string, header http.Header, out any) error {
+	token, err := r.accessToken(ctx, scope)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, r.endpoint+path, nil)
+	if err != nil {
+		return err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r.do(req, header, out)
+}
+
+// do sends a request to the registry
+func (r *ACR) do(req *http.Request, header http.Header, out any) error {
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return &apiError{Service: "ACR", Status: resp.StatusCode, Message: strings.TrimSpace(string(message))}
+	}
+	if header != nil {
+		for name, values := range resp.Header {
+			header[name] = values
+		}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s %s: %w", req.Method, req.URL.Path, err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestACR(t *testing.T) {
+	var mu sync.Mutex
+	exchanges := 0
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/oauth2/exchange":
+			if r.FormValue("access_token") != "entra" || r.FormValue("service") != "acmeplatform.azurecr.io" {
+				http.Error(w, "bad exchange", http.StatusUnauthorized)
+				return
+			}
+			exchanges++
+			io.WriteString(w, `{"refresh_token": "refresh"}`)
+			return
+		case "/oauth2/token":
+			if r.FormValue("refresh_token") != "refresh" {
+				http.Error(w, "bad refresh token", http.StatusUnauthorized)
+				return
+			}
+			// 🔐 Access tokens carry their scope
+			json.NewEncoder(w).Encode(map[string]string{"access_token": "access " + r.FormValue("scope")})
+			return
+		}
+
+		scope, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer access ")
+		switch path := r.URL.Path; {
+		case r.Method == http.MethodGet && path == "/acr/v1/knative-lambdas/acme" && scope == "repository:knative-lambdas/acme:metadata_read":
+			io.WriteString(w, `{"imageName": "knative-lambdas/acme"}`)
+		case r.Method == http.MethodGet && path == "/acr/v1/knative-lambdas/newco" && scope == "repository:knative-lambdas/newco:metadata_read":
+			http.NotFound(w, r)
+		case r.Method == http.MethodHead && path == "/v2/knative-lambdas/acme/manifests/p1-1" && scope == "repository:knative-lambdas/acme:pull":
+			w.Header().Set("Docker-Content-Digest", "sha256:abc")
+		case r.Method == http.MethodHead && path == "/v2/knative-lambdas/acme/manifests/p1-2":
+			http.NotFound(w, r)
+		case r.Method == http.MethodGet && path == "/acr/v1/_catalog" && scope == "registry:catalog:*":
+			io.WriteString(w, `{"repositories": ["knative-lambdas/acme", "knative-lambdas/acme/cache", "other/app"]}`)
+		case r.Method == http.MethodDelete && strings.HasPrefix(path, "/acr/v1/knative-lambdas/acme/_tags/") && scope == "repository:knative-lambdas/acme:delete":
+			deleted = append(deleted, strings.TrimPrefix(path, "/acr/v1/knative-lambdas/acme/_tags/"))
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			http.Error(w, "unexpected "+r.Method+" "+path+" with scope "+scope, http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+	entra := func(context.Context) (string, error) { return "entra", nil }
+	r := NewACR("acmeplatform.azurecr.io", entra).WithEndpoint(server.URL)
+	ctx := context.Background()
+
+	// 🆕 Missing repositories are fine: the first push creates them
+	for _, name := range []string{"knative-lambdas/acme", "knative-lambdas/newco"} {
+		if created, err := r.EnsureRepository(ctx, name); err != nil || created {
+			t.Errorf("EnsureRepository(%s) = %v, %v, want nothing created", name, created, err)
+		}
+	}
+	if _, err := r.EnsureRepository(ctx, "elsewhere/acme"); err == nil {
+		t.Error("EnsureRepository without access: want an error")
+	}
+
+	if digest, err := r.ImageDigest(ctx, "knative-lambdas/acme", "p1-1"); err != nil || digest != "sha256:abc" {
+		t.Errorf("ImageDigest = %q, %v, want sha256:abc", digest, err)
+	}
+	if digest, err := r.ImageDigest(ctx, "knative-lambdas/acme", "p1-2"); err != nil || digest != "" {
+		t.Errorf("ImageDigest of a missing tag = %q, %v, want none", digest, err)
+	}
+
+	names, err := r.ListRepositories(ctx, "knative-lambdas/")
+	if err != nil || strings.Join(names, ",") != "knative-lambdas/acme,knative-lambdas/acme/cache" {
+		t.Errorf("ListRepositories = %v, %v", names, err)
+	}
+	if err := r.DeleteImage(ctx, "knative-lambdas/acme", "p1-1"); err != nil || strings.Join(deleted, ",") != "p1-1" {
+		t.Errorf("DeleteImage = %v (deleted %v)", err, deleted)
+	}
+	if exchanges != 1 {
+		t.Errorf("%d token exchanges, want 1 (the refresh token is reused)", exchanges)
+	}
+
+	// 🔐 Kaniko gets a fresh refresh token
+	config, keep, err := r.DockerConfig(ctx)
+	if err != nil || keep <= 0 || exchanges != 2 {
+		t.Fatalf("DockerConfig = %s, %v, %v (%d exchanges)", config, keep, err, exchanges)
+	}
+	auth := base64.StdEncoding.EncodeToString([]byte("00000000-0000-0000-0000-000000000000:refresh"))
+	if want := `{"auths":{"acmeplatform.azurecr.io":{"auth":"` + auth + `"}}}`; string(config) != want {
+		t.Errorf("DockerConfig = %s, want %s", config, want)
+	}
+	if !IsACR("acmeplatform.azurecr.io/knative-lambdas") || IsACR("us-central1-docker.pkg.dev/acme/knative-lambdas") {
+		t.Error("IsACR doesn't tell ACR from other registries")
+	}
+}
@@ -0,0 +1,366 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	ecrtypes "github.com/aws/aws-sdk-go-v2/service/ecr/types"
+
+	"knative-lambda-builder/internal/awserrors"
+)
+
+// =============================================================================
+// 🐳 ECR REPOSITORY MANAGEMENT
+// =============================================================================
+
+// ECRSettings are the settings of the tenant repositories
+type ECRSettings struct {
+	TagMutability string // MUTABLE or IMMUTABLE
+	ScanOnPush    bool
+	Encryption    string // AES256 or KMS (new repositories only)
+	KMSKey        string // KMS key of KMS encryption ("" = the AWS managed key)
+}
+
+// ECR implements Registry on Amazon ECR
+type ECR struct {
+	client   *ecr.Client
+	settings ECRSettings
+
+	// lifecyclePolicy is put on the tenant repositories the builder creates ("" = none)
+	lifecyclePolicy string
+
+	// clientOf creates the client of an IAM role and region (nil = neither is
+	// supported); clients caches them by role ARN and region
+	clientOf func(roleARN, region string) *ecr.Client
+	clients  sync.Map
+
+	// cachePolicies remembers the expiry (days) last applied to each cache
+	// repository, so the lifecycle policy is only put once per process
+	cachePolicies sync.Map
+}
+
+// NewECR creates an ECR-backed registry
+func NewECR(client *ecr.Client) *ECR {
+	return &ECR{
+		client:   client,
+		settings: ECRSettings{TagMutability: string(ecrtypes.ImageTagMutabilityMutable), ScanOnPush: true},
+	}
+}
+
+// WithSettings creates tenant repositories with other settings
+func (r *ECR) WithSettings(settings ECRSettings) *ECR {
+	r.settings = settings
+	return r
+}
+
+// WithClients lets calls be made as other IAM roles and in other regions
+// (see WithRole and WithRegion); roleARN and region are "" for the defaults
+func (r *ECR) WithClients(clientOf func(roleARN, region string) *ecr.Client) *ECR {
+	r.clientOf = clientOf
+	return r
+}
+
+// roleKey and regionKey are the context keys of the IAM role ECR calls are
+// made as and of the region they're made in
+type (
+	roleKey   struct{}
+	regionKey struct{}
+)
+
+// WithRole makes the ECR calls of ctx as an IAM role ("" = the registry's own
+// client), e.g. one of the account images are pushed into
+func WithRole(ctx context.Context, roleARN string) context.Context {
+	return context.WithValue(ctx, roleKey{}, roleARN)
+}
+
+// WithRegion makes the ECR calls of ctx in another AWS region ("" = the
+// registry's own), the one images are pushed to
+func WithRegion(ctx context.Context, region string) context.Context {
+	return context.WithValue(ctx, regionKey{}, region)
+}
+
+// clientFor returns the client of the role and region ctx calls are made as and in
+func (r *ECR) clientFor(ctx context.Context) *ecr.Client {
+	roleARN, _ := ctx.Value(roleKey{}).(string)
+	region, _ := ctx.Value(regionKey{}).(string)
+	if (roleARN == "" && region == "") || r.clientOf == nil {
+		return r.client
+	}
+	key := roleARN + "|" + region
+	if client, ok := r.clients.Load(key); ok {
+		return client.(*ecr.Client)
+	}
+	client, _ := r.clients.LoadOrStore(key, r.clientOf(roleARN, region))
+	return client.(*ecr.Client)
+}
+
+// encryption returns the encryption configuration of new repositories (nil = ECR's default, AES256)
+func (r *ECR) encryption() *ecrtypes.EncryptionConfiguration {
+	if r.settings.Encryption != string(ecrtypes.EncryptionTypeKms) {
+		return nil
+	}
+	encryption := &ecrtypes.EncryptionConfiguration{EncryptionType: ecrtypes.EncryptionTypeKms}
+	if r.settings.KMSKey != "" {
+		encryption.KmsKey = awssdk.String(r.settings.KMSKey)
+	}
+	return encryption
+}
+
+// WithLifecyclePolicy puts a lifecycle policy (JSON) on the tenant repositories created from now on
+// 🎯 WHY: Untagged and old images would otherwise pile up forever
+func (r *ECR) WithLifecyclePolicy(policy string) *ECR {
+	r.lifecyclePolicy = policy
+	return r
+}
+
+// IsECR reports whether a registry URL points at Amazon ECR
+func IsECR(registryURL string) bool {
+	return strings.Contains(registryURL, ".dkr.ecr.")
+}
+
+// ECRRegion returns the region of an ECR registry URL ("" if it isn't one)
+// 💡 EXAMPLE: 123456789012.dkr.ecr.us-west-2.amazonaws.com/knative-lambdas -> us-west-2
+func ECRRegion(registryURL string) string {
+	host, _, _ := strings.Cut(registryURL, "/")
+	_, rest, ok := strings.Cut(host, ".dkr.ecr.")
+	if !ok {
+		return ""
+	}
+	region, _, _ := strings.Cut(rest, ".")
+	return region
+}
+
+// ECRInRegion returns the same ECR registry URL (account and path) in another region
+func ECRInRegion(registryURL, region string) string {
+	current := ECRRegion(registryURL)
+	if current == "" {
+		return registryURL
+	}
+	return strings.Replace(registryURL, ".dkr.ecr."+current+".", ".dkr.ecr."+region+".", 1)
+}
+
+// EnsureRepository creates the ECR repository for a tenant if it is missing
+// 🎯 WHY: Kaniko cannot push to a repository that does not exist
+func (r *ECR) EnsureRepository(ctx context.Context, repositoryName string) (bool, error) {
+	_, err := r.clientFor(ctx).DescribeRepositories(ctx, &ecr.DescribeRepositoriesInput{
+		RepositoryNames: []string{repositoryName},
+	})
+	if err == nil {
+		return false, nil
+	}
+	if !awserrors.HasCode(err, awserrors.RepositoryNotFound) {
+		return false, fmt.Errorf("failed to describe ECR repository %s: %w", repositoryName, err)
+	}
+
+	log.Printf("Creating ECR repository %s", repositoryName)
+	_, err = r.clientFor(ctx).CreateRepository(ctx, &ecr.CreateRepositoryInput{
+		RepositoryName:     awssdk.String(repositoryName),
+		ImageTagMutability: ecrtypes.ImageTagMutability(r.settings.TagMutability),
+		ImageScanningConfiguration: &ecrtypes.ImageScanningConfiguration{
+			ScanOnPush: r.settings.ScanOnPush,
+		},
+		EncryptionConfiguration: r.encryption(),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to create ECR repository %s: %w", repositoryName, err)
+	}
+
+	// 📝 NOTE: Only new repositories get the policy; existing ones keep theirs.
+	// A failure doesn't fail the build, the repository just grows
+	if r.lifecyclePolicy != "" {
+		_, err = r.clientFor(ctx).PutLifecyclePolicy(ctx, &ecr.PutLifecyclePolicyInput{
+			RepositoryName:      awssdk.String(repositoryName),
+			LifecyclePolicyText: awssdk.String(r.lifecyclePolicy),
+		})
+		if err != nil {
+			log.Printf("WARNING: Failed to put the lifecycle policy of ECR repository %s: %v", repositoryName, err)
+		}
+	}
+	return true, nil
+}
+
+// EnsureCacheRepository creates a Kaniko layer cache repository if it is
+// missing and keeps its lifecycle policy expiring layers after expireAfter
+// 📝 NOTE: Cache layers are tagged by content hash; they aren't scanned
+func (r *ECR) EnsureCacheRepository(ctx context.Context, repositoryName string, expireAfter time.Duration) error {
+	days := int(math.Ceil(expireAfter.Hours() / 24))
+	if days < 1 {
+		days = 1
+	}
+	if applied, ok := r.cachePolicies.Load(repositoryName); ok && applied == days {
+		return nil
+	}
+
+	_, err := r.clientFor(ctx).DescribeRepositories(ctx, &ecr.DescribeRepositoriesInput{
+		RepositoryNames: []string{repositoryName},
+	})
+	if err != nil {
+		if !awserrors.HasCode(err, awserrors.RepositoryNotFound) {
+			return fmt.Errorf("failed to describe ECR repository %s: %w", repositoryName, err)
+		}
+		// 📝 NOTE: Always MUTABLE: Kaniko may push a cached layer's tag again
+		log.Printf("Creating ECR cache repository %s", repositoryName)
+		_, err = r.clientFor(ctx).CreateRepository(ctx, &ecr.CreateRepositoryInput{
+			RepositoryName:          awssdk.String(repositoryName),
+			ImageTagMutability:      ecrtypes.ImageTagMutabilityMutable,
+			EncryptionConfiguration: r.encryption(),
+		})
+		if err != nil && !awserrors.HasCode(err, awserrors.RepositoryAlreadyExists) {
+			return fmt.Errorf("failed to create ECR repository %s: %w", repositoryName, err)
+		}
+	}
+
+	policy := fmt.Sprintf(`{"rules":[{"rulePriority":1,"description":"Expire cached layers after %d days",`+
+		`"selection":{"tagStatus":"any","countType":"sinceImagePushed","countUnit":"days","countNumber":%d},`+
+		`"action":{"type":"expire"}}]}`, days, days)
+	_, err = r.clientFor(ctx).PutLifecyclePolicy(ctx, &ecr.PutLifecyclePolicyInput{
+		RepositoryName:      awssdk.String(repositoryName),
+		LifecyclePolicyText: awssdk.String(policy),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put the lifecycle policy of ECR repository %s: %w", repositoryName, err)
+	}
+	r.cachePolicies.Store(repositoryName, days)
+	return nil
+}
+
+// ReconcileRepository brings an existing tenant repository's tag mutability
+// and scan on push in line with the settings
+// Returns what changed (nothing if the repository doesn't exist)
+// 📝 NOTE: Encryption can't change once a repository exists; a mismatch is only logged
+func (r *ECR) ReconcileRepository(ctx context.Context, repositoryName string) ([]string, error) {
+	out, err := r.clientFor(ctx).DescribeRepositories(ctx, &ecr.DescribeRepositoriesInput{
+		RepositoryNames: []string{repositoryName},
+	})
+	if err != nil {
+		if awserrors.HasCode(err, awserrors.RepositoryNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to describe ECR repository %s: %w", repositoryName, err)
+	}
+	if len(out.Repositories) == 0 {
+		return nil, nil
+	}
+	repo := out.Repositories[0]
+
+	var changed []string
+	if string(repo.ImageTagMutability) != r.settings.TagMutability {
+		_, err := r.clientFor(ctx).PutImageTagMutability(ctx, &ecr.PutImageTagMutabilityInput{
+			RepositoryName:     awssdk.String(repositoryName),
+			ImageTagMutability: ecrtypes.ImageTagMutability(r.settings.TagMutability),
+		})
+		if err != nil {
+			return changed, fmt.Errorf("failed to set the tag mutability of ECR repository %s: %w", repositoryName, err)
+		}
+		changed = append(changed, fmt.Sprintf("tags %s -> %s", repo.ImageTagMutability, r.settings.TagMutability))
+	}
+	scanOnPush := repo.ImageScanningConfiguration != nil && repo.ImageScanningConfiguration.ScanOnPush
+	if scanOnPush != r.settings.ScanOnPush {
+		_, err := r.clientFor(ctx).PutImageScanningConfiguration(ctx, &ecr.PutImageScanningConfigurationInput{
+			RepositoryName:             awssdk.String(repositoryName),
+			ImageScanningConfiguration: &ecrtypes.ImageScanningConfiguration{ScanOnPush: r.settings.ScanOnPush},
+		})
+		if err != nil {
+			return changed, fmt.Errorf("failed to set the scan configuration of ECR repository %s: %w", repositoryName, err)
+		}
+		changed = append(changed, fmt.Sprintf("scan on push %t -> %t", scanOnPush, r.settings.ScanOnPush))
+	}
+	if repo.EncryptionConfiguration != nil && string(repo.EncryptionConfiguration.EncryptionType) != r.settings.Encryption {
+		log.Printf("WARNING: ECR repository %s is encrypted with %s, not %s; encryption can't change once a repository exists",
+			repositoryName, repo.EncryptionConfiguration.EncryptionType, r.settings.Encryption)
+	}
+	return changed, nil
+}
+
+// ImageDigest returns the digest of repositoryName:tag ("" if it doesn't exist)
+func (r *ECR) ImageDigest(ctx context.Context, repositoryName, tag string) (string, error) {
+	out, err := r.clientFor(ctx).DescribeImages(ctx, &ecr.DescribeImagesInput{
+		RepositoryName: awssdk.String(repositoryName),
+		ImageIds:       []ecrtypes.ImageIdentifier{{ImageTag: awssdk.String(tag)}},
+	})
+	if err != nil {
+		if awserrors.HasCode(err, awserrors.ImageNotFound, awserrors.RepositoryNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to describe image %s:%s: %w", repositoryName, tag, err)
+	}
+	if len(out.ImageDetails) == 0 {
+		return "", nil
+	}
+	return awssdk.ToString(out.ImageDetails[0].ImageDigest), nil
+}
+
+// ListRepositories returns the ECR repositories whose name starts with prefix
+func (r *ECR) ListRepositories(ctx context.Context, prefix string) ([]string, error) {
+	var names []string
+	paginator := ecr.NewDescribeRepositoriesPaginator(r.clientFor(ctx), &ecr.DescribeRepositoriesInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list ECR repositories: %w", err)
+		}
+		for _, repo := range page.Repositories {
+			if name := awssdk.ToString(repo.RepositoryName); strings.HasPrefix(name, prefix) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names, nil
+}
+
+// DeleteImage removes repositoryName:tag from ECR
+// 📝 NOTE: If other tags point to the same image, only this tag is removed
+func (r *ECR) DeleteImage(ctx context.Context, repositoryName, tag string) error {
+	out, err := r.clientFor(ctx).BatchDeleteImage(ctx, &ecr.BatchDeleteImageInput{
+		RepositoryName: awssdk.String(repositoryName),
+		ImageIds:       []ecrtypes.ImageIdentifier{{ImageTag: awssdk.String(tag)}},
+	})
+	if err != nil {
+		if awserrors.HasCode(err, awserrors.RepositoryNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to delete image %s:%s: %w", repositoryName, tag, err)
+	}
+	for _, failure := range out.Failures {
+		if failure.FailureCode == ecrtypes.ImageFailureCodeImageNotFound {
+			continue
+		}
+		return fmt.Errorf("failed to delete image %s:%s: %s", repositoryName, tag, awssdk.ToString(failure.FailureReason))
+	}
+	return nil
+}
+
+// ImageScan returns the scan ECR ran on repositoryName:tag (ScanOnPush)
+// 📝 NOTE: A scan that hasn't started yet is reported as pending
+func (r *ECR) ImageScan(ctx context.Context, repositoryName, tag string) (*ScanResult, error) {
+	out, err := r.clientFor(ctx).DescribeImageScanFindings(ctx, &ecr.DescribeImageScanFindingsInput{
+		RepositoryName: awssdk.String(repositoryName),
+		ImageId:        &ecrtypes.ImageIdentifier{ImageTag: awssdk.String(tag)},
+		MaxResults:     awssdk.Int32(1), // Only the severity counts are used
+	})
+	if err != nil {
+		if awserrors.HasCode(err, awserrors.ScanNotFound) {
+			return &ScanResult{Status: ScanPending}, nil
+		}
+		return nil, fmt.Errorf("failed to describe scan findings of %s:%s: %w", repositoryName, tag, err)
+	}
+
+	result := &ScanResult{Status: ScanPending, Findings: map[string]int{}}
+	if out.ImageScanStatus != nil {
+		result.Status = string(out.ImageScanStatus.Status)
+		result.Description = awssdk.ToString(out.ImageScanStatus.Description)
+	}
+	if out.ImageScanFindings != nil {
+		for severity, count := range out.ImageScanFindings.FindingSeverityCounts {
+			result.Findings[severity] = int(count)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,126 @@
+package registry
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// 🧪 FAKE REGISTRY
+// =============================================================================
+// In-memory Registry for unit tests of code built on this package
+
+// FakeRegistry records which repositories exist
+type FakeRegistry struct {
+	mu           sync.Mutex
+	repositories map[string]bool
+	images       map[string]string // "repository:tag" -> digest
+	scans        map[string]*ScanResult
+
+	// Err, when set, is returned by EnsureRepository (to test failure paths)
+	Err error
+}
+
+// NewFakeRegistry creates a fake registry with the given existing repositories
+func NewFakeRegistry(existing ...string) *FakeRegistry {
+	f := &FakeRegistry{repositories: map[string]bool{}, images: map[string]string{}, scans: map[string]*ScanResult{}}
+	for _, name := range existing {
+		f.repositories[name] = true
+	}
+	return f
+}
+
+// EnsureRepository implements Registry
+func (f *FakeRegistry) EnsureRepository(ctx context.Context, repositoryName string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return false, f.Err
+	}
+	created := !f.repositories[repositoryName]
+	f.repositories[repositoryName] = true
+	return created, nil
+}
+
+// EnsureCacheRepository implements Registry
+func (f *FakeRegistry) EnsureCacheRepository(ctx context.Context, repositoryName string, expireAfter time.Duration) error {
+	_, err := f.EnsureRepository(ctx, repositoryName)
+	return err
+}
+
+// HasRepository reports whether a repository exists (test assertions)
+func (f *FakeRegistry) HasRepository(repositoryName string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.repositories[repositoryName]
+}
+
+// PushImage makes repositoryName:tag point to digest (test setup)
+func (f *FakeRegistry) PushImage(repositoryName, tag, digest string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.repositories[repositoryName] = true
+	f.images[repositoryName+":"+tag] = digest
+}
+
+// ImageDigest implements Registry
+func (f *FakeRegistry) ImageDigest(ctx context.Context, repositoryName, tag string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return "", f.Err
+	}
+	return f.images[repositoryName+":"+tag], nil
+}
+
+// ListRepositories implements Registry
+func (f *FakeRegistry) ListRepositories(ctx context.Context, prefix string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	var names []string
+	for name := range f.repositories {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// DeleteImage implements Registry
+func (f *FakeRegistry) DeleteImage(ctx context.Context, repositoryName, tag string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return f.Err
+	}
+	delete(f.images, repositoryName+":"+tag)
+	return nil
+}
+
+// SetScan sets the vulnerability scan of repositoryName:tag (test setup)
+func (f *FakeRegistry) SetScan(repositoryName, tag string, scan *ScanResult) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.scans[repositoryName+":"+tag] = scan
+}
+
+// ImageScan implements Registry
+// 📝 NOTE: Images without a scan set report a pending one
+func (f *FakeRegistry) ImageScan(ctx context.Context, repositoryName, tag string) (*ScanResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	if scan, ok := f.scans[repositoryName+":"+tag]; ok {
+		return scan, nil
+	}
+	return &ScanResult{Status: ScanPending}, nil
+}
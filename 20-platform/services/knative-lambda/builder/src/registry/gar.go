@@ -0,0 +1,379 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// 🐳 GOOGLE ARTIFACT REGISTRY
+// =============================================================================
+// On GKE, images can be pushed to a Docker repository of Artifact Registry
+// (REGISTRY_PROVIDER=gar) instead of ECR. The registry URL is
+// <location>-docker.pkg.dev/<project>/<repository>: an Artifact Registry
+// repository holds the images of every tenant, and what ECR calls a
+// repository (<project>/<repository>/<thirdPartyId>) is a package in it
+// 🎯 WHY: Kaniko pushes new packages into an existing repository, so only the
+// Artifact Registry repository itself has to be created
+// 📝 NOTE: Talks to the Artifact Registry REST API, as the builder's
+// Kubernetes service account (Workload Identity)
+
+// garEndpoint is the Artifact Registry REST API
+const garEndpoint = "https://artifactregistry.googleapis.com/v1"
+
+// garOperationPoll is how often a repository creation is checked on
+const garOperationPoll = time.Second
+
+// GAR implements Registry on Google Artifact Registry
+type GAR struct {
+	client   *http.Client
+	endpoint string
+	location string                                    // e.g. us-central1
+	token    func(ctx context.Context) (string, error) // nil = unauthenticated (tests)
+
+	// cachePolicies remembers the expiry (days) last applied to each cache
+	// package, so the cleanup policy is only patched once per process
+	cachePolicies sync.Map
+	policiesMu    sync.Mutex // Serializes cleanup policy updates (read-modify-write)
+}
+
+// NewGAR creates an Artifact Registry-backed registry for a location,
+// authenticated with the given tokens
+func NewGAR(location string, token func(ctx context.Context) (string, error)) *GAR {
+	return &GAR{client: &http.Client{Timeout: time.Minute}, endpoint: garEndpoint, location: location, token: token}
+}
+
+// WithEndpoint talks to another Artifact Registry API endpoint
+func (r *GAR) WithEndpoint(endpoint string) *GAR {
+	r.endpoint = strings.TrimSuffix(endpoint, "/")
+	return r
+}
+
+// IsGAR reports whether a registry URL points at Google Artifact Registry
+func IsGAR(registryURL string) bool {
+	host, _, _ := strings.Cut(registryURL, "/")
+	return strings.HasSuffix(host, "-docker.pkg.dev")
+}
+
+// GARHost returns the registry host of an Artifact Registry location
+func GARHost(location string) string {
+	return location + "-docker.pkg.dev"
+}
+
+// apiError is a failed registry API call (Artifact Registry, ACR)
+type apiError struct {
+	Service string
+	Status  int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s returned %d: %s", e.Service, e.Status, e.Message)
+}
+
+// isNotFound reports whether an API call failed because the resource is missing
+func isNotFound(err error) bool {
+	var apiErr *apiError
+	return errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound
+}
+
+// isConflict reports whether an API call failed because the resource already exists
+func isConflict(err error) bool {
+	var apiErr *apiError
+	return errors.As(err, &apiErr) && apiErr.Status == http.StatusConflict
+}
+
+// garRepository is the part of an Artifact Registry repository the builder manages
+type garRepository struct {
+	Format          string                      `json:"format,omitempty"`
+	Description     string                      `json:"description,omitempty"`
+	CleanupPolicies map[string]garCleanupPolicy `json:"cleanupPolicies,omitempty"`
+}
+
+// garCleanupPolicy deletes the versions matching its condition
+type garCleanupPolicy struct {
+	ID        string         `json:"id"`
+	Action    string         `json:"action"`
+	Condition map[string]any `json:"condition,omitempty"`
+}
+
+// garOperation is a long-running operation (repository creation)
+type garOperation struct {
+	Name  string `json:"name"`
+	Done  bool   `json:"done"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// split splits a repository name (<project>/<repository>/<package>) into the
+// Artifact Registry repository's resource name and the package
+func (r *GAR) split(repositoryName string) (repository, pkg string, err error) {
+	parts := strings.SplitN(repositoryName, "/", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid Artifact Registry repository %q: expected <project>/<repository>/<image>", repositoryName)
+	}
+	repository = fmt.Sprintf("projects/%s/locations/%s/repositories/%s", parts[0], r.location, parts[1])
+	if len(parts) == 3 {
+		pkg = parts[2]
+	}
+	return repository, pkg, nil
+}
+
+// EnsureRepository creates the Artifact Registry repository holding a
+// tenant's package if it is missing
+// 🎯 WHY: Kaniko cannot push to a repository that does not exist
+func (r *GAR) EnsureRepository(ctx context.Context, repositoryName string) (bool, error) {
+	repository, _, err := r.split(repositoryName)
+	if err != nil {
+		return false, err
+	}
+	_, created, err := r.repository(ctx, repository)
+	return created, err
+}
+
+// repository returns an Artifact Registry repository, creating it if it is
+// missing (created reports whether it did)
+func (r *GAR) repository(ctx context.Context, repository string) (*garRepository, bool, error) {
+	var existing garRepository
+	err := r.call(ctx, http.MethodGet, repository, nil, &existing)
+	if err == nil {
+		return &existing, false, nil
+	}
+	if !isNotFound(err) {
+		return nil, false, fmt.Errorf("failed to get Artifact Registry repository %s: %w", repository, err)
+	}
+
+	log.Printf("Creating Artifact Registry repository %s", repository)
+	parent, id, _ := strings.Cut(repository, "/repositories/")
+	created := garRepository{Format: "DOCKER", Description: "knative-lambda parser images"}
+	var op garOperation
+	err = r.call(ctx, http.MethodPost, parent+"/repositories?repositoryId="+url.QueryEscape(id), created, &op)
+	if isConflict(err) {
+		return &created, false, nil // Created concurrently
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create Artifact Registry repository %s: %w", repository, err)
+	}
+	if err := r.wait(ctx, op); err != nil {
+		return nil, false, fmt.Errorf("failed to create Artifact Registry repository %s: %w", repository, err)
+	}
+	return &created, true, nil
+}
+
+// wait polls a long-running operation until it is done
+func (r *GAR) wait(ctx context.Context, op garOperation) error {
+	for !op.Done {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(garOperationPoll):
+		}
+		if err := r.call(ctx, http.MethodGet, op.Name, nil, &op); err != nil {
+			return fmt.Errorf("failed to get operation %s: %w", op.Name, err)
+		}
+	}
+	if op.Error != nil {
+		return errors.New(op.Error.Message)
+	}
+	return nil
+}
+
+// cachePolicyID turns a package name into a cleanup policy ID
+var cachePolicyID = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// EnsureCacheRepository creates the Artifact Registry repository holding a
+// Kaniko layer cache if it is missing, and keeps a cleanup policy deleting
+// the cache's layers after expireAfter
+// 📝 NOTE: Cleanup policies belong to the Artifact Registry repository: each
+// cache package gets its own, matching the package by name prefix
+func (r *GAR) EnsureCacheRepository(ctx context.Context, repositoryName string, expireAfter time.Duration) error {
+	days := int(math.Ceil(expireAfter.Hours() / 24))
+	if days < 1 {
+		days = 1
+	}
+	if applied, ok := r.cachePolicies.Load(repositoryName); ok && applied == days {
+		return nil
+	}
+	repository, pkg, err := r.split(repositoryName)
+	if err != nil {
+		return err
+	}
+
+	r.policiesMu.Lock()
+	defer r.policiesMu.Unlock()
+	existing, _, err := r.repository(ctx, repository)
+	if err != nil {
+		return err
+	}
+	policies := existing.CleanupPolicies
+	if policies == nil {
+		policies = map[string]garCleanupPolicy{}
+	}
+	id := "knative-lambda-cache-" + strings.Trim(cachePolicyID.ReplaceAllString(strings.ToLower(pkg), "-"), "-")
+	policies[id] = garCleanupPolicy{
+		ID:     id,
+		Action: "DELETE",
+		Condition: map[string]any{
+			"tagState":            "ANY",
+			"packageNamePrefixes": []string{pkg},
+			"olderThan":           fmt.Sprintf("%ds", days*24*60*60),
+		},
+	}
+	patch := garRepository{CleanupPolicies: policies}
+	if err := r.call(ctx, http.MethodPatch, repository+"?updateMask=cleanupPolicies", patch, nil); err != nil {
+		return fmt.Errorf("failed to put the cleanup policy of %s: %w", repositoryName, err)
+	}
+	r.cachePolicies.Store(repositoryName, days)
+	return nil
+}
+
+// ImageDigest returns the digest of repositoryName:tag ("" if it doesn't exist)
+func (r *GAR) ImageDigest(ctx context.Context, repositoryName, tag string) (string, error) {
+	repository, pkg, err := r.split(repositoryName)
+	if err != nil {
+		return "", err
+	}
+	var found struct {
+		Version string `json:"version"` // .../versions/sha256:...
+	}
+	err = r.call(ctx, http.MethodGet, repository+"/packages/"+url.PathEscape(pkg)+"/tags/"+url.PathEscape(tag), nil, &found)
+	if isNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get image %s:%s: %w", repositoryName, tag, err)
+	}
+	_, digest, _ := strings.Cut(found.Version, "/versions/")
+	digest, _ = url.PathUnescape(digest)
+	return digest, nil
+}
+
+// ListRepositories returns the packages whose name (as a repository name,
+// <project>/<repository>/<package>) starts with prefix
+func (r *GAR) ListRepositories(ctx context.Context, prefix string) ([]string, error) {
+	repository, _, err := r.split(prefix)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.SplitN(prefix, "/", 3)
+	base := parts[0] + "/" + parts[1] + "/"
+
+	var names []string
+	pageToken := ""
+	for {
+		var page struct {
+			Packages []struct {
+				Name string `json:"name"` // .../packages/<escaped package>
+			} `json:"packages"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		path := repository + "/packages?pageSize=1000"
+		if pageToken != "" {
+			path += "&pageToken=" + url.QueryEscape(pageToken)
+		}
+		err := r.call(ctx, http.MethodGet, path, nil, &page)
+		if isNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list Artifact Registry packages: %w", err)
+		}
+		for _, p := range page.Packages {
+			_, pkg, _ := strings.Cut(p.Name, "/packages/")
+			pkg, _ = url.PathUnescape(pkg)
+			if name := base + pkg; strings.HasPrefix(name, prefix) {
+				names = append(names, name)
+			}
+		}
+		if pageToken = page.NextPageToken; pageToken == "" {
+			return names, nil
+		}
+	}
+}
+
+// DeleteImage removes the tag repositoryName:tag
+// 📝 NOTE: Only the tag is removed; the untagged version is left to the
+// repository's cleanup policies
+func (r *GAR) DeleteImage(ctx context.Context, repositoryName, tag string) error {
+	repository, pkg, err := r.split(repositoryName)
+	if err != nil {
+		return err
+	}
+	err = r.call(ctx, http.MethodDelete, repository+"/packages/"+url.PathEscape(pkg)+"/tags/"+url.PathEscape(tag), nil, nil)
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to delete image %s:%s: %w", repositoryName, tag, err)
+	}
+	return nil
+}
+
+// ImageScan implements Registry
+// 📝 NOTE: Artifact Analysis findings aren't read (yet): the scan gate is ECR and Harbor only
+func (r *GAR) ImageScan(ctx context.Context, repositoryName, tag string) (*ScanResult, error) {
+	return nil, nil
+}
+
+// DockerConfig returns the docker config.json Kaniko pushes with: the gcr
+// credential helper (shipped in the Kaniko image) for the location's host
+// 🔐 The helper gets tokens of the build pod's service account (Workload
+// Identity), so no credentials are stored and the config never expires
+func (r *GAR) DockerConfig(ctx context.Context) ([]byte, time.Duration, error) {
+	config, err := json.Marshal(map[string]any{
+		"credHelpers": map[string]string{GARHost(r.location): "gcr"},
+	})
+	return config, 0, err
+}
+
+// call sends a request to the Artifact Registry API, decoding the response into out (if not nil)
+func (r *GAR) call(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		encoded, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, r.endpoint+"/"+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if r.token != nil {
+		token, err := r.token(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get an access token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return &apiError{Service: "artifact registry", Status: resp.StatusCode, Message: strings.TrimSpace(string(message))}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s %s: %w", method, path, err)
+	}
+	return nil
+}
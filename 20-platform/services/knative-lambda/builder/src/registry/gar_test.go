@@ -0,0 +1,90 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGAR(t *testing.T) {
+	const repository = "/projects/acme-platform/locations/us-central1/repositories/knative-lambdas"
+	var mu sync.Mutex
+	created := false
+	var patched map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Header.Get("Authorization") != "Bearer t0ken" {
+			http.Error(w, "unauthenticated", http.StatusUnauthorized)
+			return
+		}
+		switch path := r.URL.EscapedPath(); {
+		case r.Method == http.MethodGet && path == repository:
+			if !created {
+				http.NotFound(w, r)
+				return
+			}
+			io.WriteString(w, `{"format": "DOCKER"}`)
+		case r.Method == http.MethodPost && path == strings.TrimSuffix(repository, "/knative-lambdas") && r.URL.Query().Get("repositoryId") == "knative-lambdas":
+			created = true
+			io.WriteString(w, `{"name": "operations/1", "done": true}`)
+		case r.Method == http.MethodPatch && path == repository && r.URL.Query().Get("updateMask") == "cleanupPolicies":
+			json.NewDecoder(r.Body).Decode(&patched)
+			io.WriteString(w, `{}`)
+		// Packages are escaped into a single path segment
+		case r.Method == http.MethodGet && path == repository+"/packages/acme%2Fcache/tags/p1-1":
+			http.NotFound(w, r)
+		case r.Method == http.MethodGet && path == repository+"/packages/acme/tags/p1-1":
+			io.WriteString(w, `{"version": "`+strings.TrimPrefix(repository, "/")+`/packages/acme/versions/sha256:abc"}`)
+		case r.Method == http.MethodGet && path == repository+"/packages":
+			io.WriteString(w, `{"packages": [{"name": "x/packages/acme"}, {"name": "x/packages/acme%2Fcache"}, {"name": "x/packages/other"}]}`)
+		default:
+			http.Error(w, "unexpected "+r.Method+" "+path, http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+	token := func(context.Context) (string, error) { return "t0ken", nil }
+	r := NewGAR("us-central1", token).WithEndpoint(server.URL)
+	ctx := context.Background()
+
+	// 🆕 The Artifact Registry repository is created on first use
+	if ensured, err := r.EnsureRepository(ctx, "acme-platform/knative-lambdas/acme"); err != nil || !ensured || !created {
+		t.Fatalf("EnsureRepository = %v, %v (created %v)", ensured, err, created)
+	}
+	if ensured, err := r.EnsureRepository(ctx, "acme-platform/knative-lambdas/acme"); err != nil || ensured {
+		t.Errorf("EnsureRepository of an existing repository = %v, %v, want not created", ensured, err)
+	}
+	if err := r.EnsureCacheRepository(ctx, "acme-platform/knative-lambdas/acme/cache", 36*time.Hour); err != nil {
+		t.Fatalf("EnsureCacheRepository: %v", err)
+	}
+	policy, _ := json.Marshal(patched["cleanupPolicies"])
+	if !strings.Contains(string(policy), `"packageNamePrefixes":["acme/cache"]`) || !strings.Contains(string(policy), `"olderThan":"172800s"`) {
+		t.Errorf("cleanup policies = %s, want acme/cache expired after 2 days", policy)
+	}
+
+	digest, err := r.ImageDigest(ctx, "acme-platform/knative-lambdas/acme", "p1-1")
+	if err != nil || digest != "sha256:abc" {
+		t.Errorf("ImageDigest = %q, %v, want sha256:abc", digest, err)
+	}
+	if digest, err := r.ImageDigest(ctx, "acme-platform/knative-lambdas/acme/cache", "p1-1"); err != nil || digest != "" {
+		t.Errorf("ImageDigest of a missing tag = %q, %v, want none", digest, err)
+	}
+
+	names, err := r.ListRepositories(ctx, "acme-platform/knative-lambdas/ac")
+	if err != nil || strings.Join(names, ",") != "acme-platform/knative-lambdas/acme,acme-platform/knative-lambdas/acme/cache" {
+		t.Errorf("ListRepositories = %v, %v", names, err)
+	}
+
+	if config, valid, err := r.DockerConfig(ctx); string(config) != `{"credHelpers":{"us-central1-docker.pkg.dev":"gcr"}}` || valid != 0 || err != nil {
+		t.Errorf("DockerConfig = %s, %v, %v", config, valid, err)
+	}
+	if !IsGAR("us-central1-docker.pkg.dev/acme-platform/knative-lambdas") || IsGAR("123456789012.dkr.ecr.us-west-2.amazonaws.com") {
+		t.Error("IsGAR doesn't tell Artifact Registry from ECR")
+	}
+}
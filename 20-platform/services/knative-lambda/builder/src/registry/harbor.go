@@ -0,0 +1,293 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// ⚓ HARBOR
+// =============================================================================
+// Self-hosted installs can push images to Harbor (REGISTRY_PROVIDER=harbor).
+// Every tenant gets its own Harbor project, <HARBOR_PROJECT_PREFIX><thirdPartyId>,
+// holding one repository, parsers: <host>/<project>/parsers:<tag>
+// 🎯 WHY: Harbor only accepts pushes into an existing project, so the project
+// is created (with its quota and auto-scan setting) before the first push,
+// like ECR repositories are
+// 📝 NOTE: Talks to the Harbor v2.0 API with basic auth, ideally as a robot
+// account allowed to create projects
+
+// HarborRepository is the repository of parser images in a tenant's project
+const HarborRepository = "parsers"
+
+// harborPageSize is the page size of Harbor listings
+const harborPageSize = 100
+
+// HarborProject configures the projects created for tenants
+type HarborProject struct {
+	StorageLimit int64 // Quota in bytes (-1 = unlimited)
+	AutoScan     bool  // Scan images on push (Trivy)
+}
+
+// Harbor implements Registry on Harbor
+type Harbor struct {
+	client   *http.Client
+	host     string // Registry host, e.g. harbor.lab
+	endpoint string // e.g. https://harbor.lab
+	username string
+	password string
+	project  HarborProject
+
+	// projects remembers the projects known to exist, so they're only checked once per process
+	projects sync.Map
+}
+
+// NewHarbor creates a Harbor-backed registry at endpoint (https://host),
+// signing in with a (robot) account
+func NewHarbor(endpoint, username, password string, project HarborProject) *Harbor {
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	host := endpoint[strings.Index(endpoint, "://")+3:]
+	return &Harbor{
+		client:   &http.Client{Timeout: time.Minute},
+		host:     host,
+		endpoint: endpoint,
+		username: username,
+		password: password,
+		project:  project,
+	}
+}
+
+// harborProject is a project creation request
+type harborProject struct {
+	ProjectName  string            `json:"project_name"`
+	Metadata     map[string]string `json:"metadata"`
+	StorageLimit int64             `json:"storage_limit"`
+}
+
+// harborArtifact is the part of a Harbor artifact the builder reads
+type harborArtifact struct {
+	Digest       string `json:"digest"`
+	ScanOverview map[string]struct {
+		ScanStatus string `json:"scan_status"`
+		Summary    struct {
+			Summary map[string]int `json:"summary"` // Findings per severity (Critical, High, ...)
+		} `json:"summary"`
+	} `json:"scan_overview"`
+}
+
+// split splits a repository name (<project>/<repository>) into the project
+// and the repository path of the Harbor API (escaped twice: Harbor wants
+// the / of nested repositories as %252F)
+func (r *Harbor) split(repositoryName string) (project, repositoryPath string, err error) {
+	project, repository, ok := strings.Cut(repositoryName, "/")
+	if !ok || project == "" || repository == "" {
+		return "", "", fmt.Errorf("invalid Harbor repository %q: expected <project>/<repository>", repositoryName)
+	}
+	return project, "/api/v2.0/projects/" + url.PathEscape(project) + "/repositories/" + url.PathEscape(url.PathEscape(repository)), nil
+}
+
+// EnsureRepository creates the Harbor project of a tenant's repository if it is missing
+// 🎯 WHY: Kaniko cannot push to a project that does not exist
+func (r *Harbor) EnsureRepository(ctx context.Context, repositoryName string) (bool, error) {
+	project, _, err := r.split(repositoryName)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := r.projects.Load(project); ok {
+		return false, nil
+	}
+
+	created := false
+	err = r.call(ctx, http.MethodHead, "/api/v2.0/projects?project_name="+url.QueryEscape(project), nil, nil)
+	if isNotFound(err) {
+		log.Printf("Creating Harbor project %s", project)
+		err = r.call(ctx, http.MethodPost, "/api/v2.0/projects", harborProject{
+			ProjectName:  project,
+			Metadata:     map[string]string{"public": "false", "auto_scan": fmt.Sprint(r.project.AutoScan)},
+			StorageLimit: r.project.StorageLimit,
+		}, nil)
+		created = err == nil
+		if isConflict(err) {
+			err = nil // Created concurrently
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to create Harbor project %s: %w", project, err)
+		}
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check Harbor project %s: %w", project, err)
+	}
+	r.projects.Store(project, true)
+	return created, nil
+}
+
+// EnsureCacheRepository creates the Harbor project of a layer cache repository if it is missing
+// 📝 NOTE: Cached layers share the tenant's project (and quota); their
+// expiry is left to the project's tag retention rules
+func (r *Harbor) EnsureCacheRepository(ctx context.Context, repositoryName string, expireAfter time.Duration) error {
+	_, err := r.EnsureRepository(ctx, repositoryName)
+	return err
+}
+
+// artifact returns the artifact repositoryName:tag (nil if it doesn't exist)
+func (r *Harbor) artifact(ctx context.Context, repositoryName, tag, query string) (*harborArtifact, error) {
+	_, repositoryPath, err := r.split(repositoryName)
+	if err != nil {
+		return nil, err
+	}
+	var artifact harborArtifact
+	err = r.call(ctx, http.MethodGet, repositoryPath+"/artifacts/"+url.PathEscape(tag)+query, nil, &artifact)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image %s:%s: %w", repositoryName, tag, err)
+	}
+	return &artifact, nil
+}
+
+// ImageDigest returns the digest of repositoryName:tag ("" if it doesn't exist)
+func (r *Harbor) ImageDigest(ctx context.Context, repositoryName, tag string) (string, error) {
+	artifact, err := r.artifact(ctx, repositoryName, tag, "")
+	if err != nil || artifact == nil {
+		return "", err
+	}
+	return artifact.Digest, nil
+}
+
+// ListRepositories returns the Harbor repositories (<project>/<repository>)
+// whose name starts with prefix, across all projects
+func (r *Harbor) ListRepositories(ctx context.Context, prefix string) ([]string, error) {
+	var names []string
+	for page := 1; ; page++ {
+		var repositories []struct {
+			Name string `json:"name"`
+		}
+		path := fmt.Sprintf("/api/v2.0/repositories?page=%d&page_size=%d", page, harborPageSize)
+		if prefix != "" {
+			path += "&q=" + url.QueryEscape("name=~"+prefix)
+		}
+		if err := r.call(ctx, http.MethodGet, path, nil, &repositories); err != nil {
+			return nil, fmt.Errorf("failed to list Harbor repositories: %w", err)
+		}
+		for _, repository := range repositories {
+			if strings.HasPrefix(repository.Name, prefix) {
+				names = append(names, repository.Name)
+			}
+		}
+		if len(repositories) < harborPageSize {
+			return names, nil
+		}
+	}
+}
+
+// DeleteImage removes the tag repositoryName:tag
+// 📝 NOTE: Only the tag is removed; the untagged artifact is left to Harbor's
+// garbage collection
+func (r *Harbor) DeleteImage(ctx context.Context, repositoryName, tag string) error {
+	_, repositoryPath, err := r.split(repositoryName)
+	if err != nil {
+		return err
+	}
+	err = r.call(ctx, http.MethodDelete, repositoryPath+"/artifacts/"+url.PathEscape(tag)+"/tags/"+url.PathEscape(tag), nil, nil)
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to delete image %s:%s: %w", repositoryName, tag, err)
+	}
+	return nil
+}
+
+// ImageScan returns the scan Harbor ran on repositoryName:tag (auto-scan)
+// 📝 NOTE: Harbor's statuses are mapped to ECR's; a scan that hasn't started
+// yet is reported as pending
+func (r *Harbor) ImageScan(ctx context.Context, repositoryName, tag string) (*ScanResult, error) {
+	artifact, err := r.artifact(ctx, repositoryName, tag, "?with_scan_overview=true")
+	if err != nil {
+		return nil, err
+	}
+	if artifact == nil {
+		return &ScanResult{Status: ScanPending}, nil
+	}
+	for _, report := range artifact.ScanOverview {
+		result := &ScanResult{Findings: map[string]int{}}
+		switch report.ScanStatus {
+		case "Success":
+			result.Status = ScanComplete
+		case "Running":
+			result.Status = ScanInProgress
+		case "", "Pending", "Scheduled":
+			result.Status = ScanPending
+		default: // Error, Stopped
+			result.Status = "FAILED"
+			result.Description = "Harbor scan " + report.ScanStatus
+		}
+		for severity, count := range report.Summary.Summary {
+			result.Findings[strings.ToUpper(severity)] = count
+		}
+		return result, nil
+	}
+	return &ScanResult{Status: ScanPending}, nil
+}
+
+// DockerConfig returns the docker config Kaniko pushes with: the account's
+// credentials for the registry host
+func (r *Harbor) DockerConfig(ctx context.Context) ([]byte, time.Duration, error) {
+	config, err := json.Marshal(map[string]any{
+		"auths": map[string]any{
+			r.host: map[string]string{
+				"auth": base64.StdEncoding.EncodeToString([]byte(r.username + ":" + r.password)),
+			},
+		},
+	})
+	return config, 0, err
+}
+
+// call sends a request to the Harbor API, decoding the response into out (if not nil)
+func (r *Harbor) call(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		encoded, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, r.endpoint+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if r.username != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return &apiError{Service: "Harbor", Status: resp.StatusCode, Message: strings.TrimSpace(string(message))}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s %s: %w", method, path, err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestHarbor(t *testing.T) {
+	const repository = "/api/v2.0/projects/lambda-acme/repositories/parsers"
+	var mu sync.Mutex
+	var created []harborProject
+	checks := 0
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if user, password, _ := r.BasicAuth(); user != "robot$builder" || password != "s3cret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch path := r.URL.EscapedPath(); {
+		case r.Method == http.MethodHead && path == "/api/v2.0/projects":
+			checks++
+			if r.URL.Query().Get("project_name") != "lambda-globex" && len(created) == 0 {
+				http.NotFound(w, r)
+			}
+		case r.Method == http.MethodPost && path == "/api/v2.0/projects":
+			var project harborProject
+			json.NewDecoder(r.Body).Decode(&project)
+			created = append(created, project)
+			w.WriteHeader(http.StatusCreated)
+		// Nested repositories are escaped twice
+		case r.Method == http.MethodGet && path == repository+"%252Fcache/artifacts/p1-v1":
+			http.NotFound(w, r)
+		case r.Method == http.MethodGet && path == repository+"/artifacts/p1-v1":
+			io.WriteString(w, `{"digest": "sha256:abc", "scan_overview": {"application/vnd.security.vulnerability.report; version=1.1": {
+				"scan_status": "Success", "summary": {"summary": {"Critical": 2, "High": 5}}}}}`)
+		case r.Method == http.MethodGet && path == repository+"/artifacts/p1-v2":
+			io.WriteString(w, `{"digest": "sha256:def", "scan_overview": {"x": {"scan_status": "Error"}}}`)
+		case r.Method == http.MethodGet && path == repository+"/artifacts/p1-v3":
+			io.WriteString(w, `{"digest": "sha256:123"}`)
+		case r.Method == http.MethodGet && path == "/api/v2.0/repositories" && r.URL.Query().Get("q") == "name=~lambda-":
+			io.WriteString(w, `[{"name": "lambda-acme/parsers"}, {"name": "lambda-acme/parsers/cache"}, {"name": "other/app"}]`)
+		case r.Method == http.MethodDelete && strings.HasPrefix(path, repository+"/artifacts/p1-v1/tags/"):
+			deleted = append(deleted, strings.TrimPrefix(path, repository+"/artifacts/p1-v1/tags/"))
+		default:
+			http.Error(w, "unexpected "+r.Method+" "+path, http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+	r := NewHarbor(server.URL+"/", "robot$builder", "s3cret", HarborProject{StorageLimit: 10 << 30, AutoScan: true})
+	ctx := context.Background()
+
+	// 🆕 The tenant's project is created before the first push, then remembered
+	for i, name := range []string{"lambda-acme/parsers", "lambda-acme/parsers/cache"} {
+		ensured, err := r.EnsureRepository(ctx, name)
+		if err != nil || ensured != (i == 0) {
+			t.Fatalf("EnsureRepository(%s) = %v, %v, want created only the first time", name, ensured, err)
+		}
+	}
+	if len(created) != 1 || checks != 1 {
+		t.Fatalf("created %+v in %d checks, want lambda-acme once", created, checks)
+	}
+	if p := created[0]; p.ProjectName != "lambda-acme" || p.StorageLimit != 10<<30 || p.Metadata["auto_scan"] != "true" || p.Metadata["public"] != "false" {
+		t.Errorf("created project %+v, want a private, scanned lambda-acme with a 10GiB quota", p)
+	}
+	if ensured, err := r.EnsureRepository(ctx, "lambda-globex/parsers"); err != nil || ensured || len(created) != 1 {
+		t.Errorf("EnsureRepository of an existing project = %v, %v (created %d)", ensured, err, len(created))
+	}
+	if _, err := r.EnsureRepository(ctx, "parsers"); err == nil {
+		t.Error("EnsureRepository without a project: want an error")
+	}
+
+	if digest, err := r.ImageDigest(ctx, "lambda-acme/parsers", "p1-v1"); err != nil || digest != "sha256:abc" {
+		t.Errorf("ImageDigest = %q, %v, want sha256:abc", digest, err)
+	}
+	if digest, err := r.ImageDigest(ctx, "lambda-acme/parsers/cache", "p1-v1"); err != nil || digest != "" {
+		t.Errorf("ImageDigest of a missing tag = %q, %v, want none", digest, err)
+	}
+
+	// 🔍 Scan overviews are mapped to ECR's statuses and severities
+	for tag, want := range map[string]string{"p1-v1": ScanComplete, "p1-v2": "FAILED", "p1-v3": ScanPending} {
+		if scan, err := r.ImageScan(ctx, "lambda-acme/parsers", tag); err != nil || scan.Status != want {
+			t.Errorf("ImageScan(%s) = %+v, %v, want %s", tag, scan, err, want)
+		}
+	}
+	if scan, _ := r.ImageScan(ctx, "lambda-acme/parsers", "p1-v1"); scan.Findings["CRITICAL"] != 2 {
+		t.Errorf("ImageScan findings = %v, want 2 critical", scan.Findings)
+	}
+
+	names, err := r.ListRepositories(ctx, "lambda-")
+	if err != nil || strings.Join(names, ",") != "lambda-acme/parsers,lambda-acme/parsers/cache" {
+		t.Errorf("ListRepositories = %v, %v", names, err)
+	}
+	if err := r.DeleteImage(ctx, "lambda-acme/parsers", "p1-v1"); err != nil || strings.Join(deleted, ",") != "p1-v1" {
+		t.Errorf("DeleteImage = %v (deleted %v)", err, deleted)
+	}
+
+	// 🔐 Kaniko pushes with the robot account
+	config, keep, err := r.DockerConfig(ctx)
+	auth := base64.StdEncoding.EncodeToString([]byte("robot$builder:s3cret"))
+	if want := `{"auths":{"` + strings.TrimPrefix(server.URL, "http://") + `":{"auth":"` + auth + `"}}}`; string(config) != want || keep != 0 || err != nil {
+		t.Errorf("DockerConfig = %s, %v, %v, want %s", config, keep, err, want)
+	}
+}
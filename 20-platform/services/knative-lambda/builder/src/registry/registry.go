@@ -0,0 +1,87 @@
+package registry
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// =============================================================================
+// 🐳 CONTAINER REGISTRY
+// =============================================================================
+// Parser images are pushed to one repository per tenant
+// 🎯 PURPOSE: Keep repository management behind an interface (and easy to fake)
+
+// Registry manages the repositories images are pushed to
+type Registry interface {
+	// EnsureRepository creates the repository if it is missing, and reports
+	// whether it did
+	EnsureRepository(ctx context.Context, repositoryName string) (created bool, err error)
+	// EnsureCacheRepository creates a layer cache repository if it is missing,
+	// expiring layers pushed more than expireAfter ago
+	EnsureCacheRepository(ctx context.Context, repositoryName string, expireAfter time.Duration) error
+	// ImageDigest returns the digest a tag points to ("" if the image doesn't exist)
+	ImageDigest(ctx context.Context, repositoryName, tag string) (string, error)
+	// ListRepositories returns the repositories whose name starts with prefix
+	ListRepositories(ctx context.Context, prefix string) ([]string, error)
+	// DeleteImage removes a tag (a missing tag is not an error)
+	DeleteImage(ctx context.Context, repositoryName, tag string) error
+	// ImageScan returns the vulnerability scan of a tag (nil if the registry doesn't scan)
+	ImageScan(ctx context.Context, repositoryName, tag string) (*ScanResult, error)
+}
+
+// ScanResult is the vulnerability scan of an image
+type ScanResult struct {
+	Status      string         `json:"status"`                // One of the Scan* statuses (or another registry specific one)
+	Description string         `json:"description,omitempty"` // Why the scan failed, if it did
+	Findings    map[string]int `json:"findings,omitempty"`    // Findings per severity (CRITICAL, HIGH, MEDIUM, LOW, ...)
+}
+
+// Scan statuses (ECR's)
+const (
+	ScanPending     = "PENDING" // Not started yet
+	ScanInProgress  = "IN_PROGRESS"
+	ScanComplete    = "COMPLETE"
+	ScanActive      = "ACTIVE" // Enhanced (continuous) scanning: findings are up to date
+	ScanUnsupported = "UNSUPPORTED_IMAGE"
+)
+
+// Unmanaged is a registry whose repositories need no management
+// 🏠 LOCAL DEVELOPMENT: kind's local registry creates repositories on push
+type Unmanaged struct {
+	URL string
+}
+
+// EnsureRepository implements Registry (no-op)
+func (u Unmanaged) EnsureRepository(ctx context.Context, repositoryName string) (bool, error) {
+	log.Printf("Registry %s is not ECR, skipping repository check", u.URL)
+	return false, nil
+}
+
+// EnsureCacheRepository implements Registry (no-op)
+func (u Unmanaged) EnsureCacheRepository(ctx context.Context, repositoryName string, expireAfter time.Duration) error {
+	return nil
+}
+
+// ImageDigest implements Registry
+// 📝 NOTE: Unmanaged registries can't be queried, so images are never found
+// (builds are never skipped as cached)
+func (u Unmanaged) ImageDigest(ctx context.Context, repositoryName, tag string) (string, error) {
+	return "", nil
+}
+
+// ListRepositories implements Registry (unmanaged registries can't be listed)
+func (u Unmanaged) ListRepositories(ctx context.Context, prefix string) ([]string, error) {
+	return nil, nil
+}
+
+// DeleteImage implements Registry (no-op: unmanaged registries have no delete API we use)
+func (u Unmanaged) DeleteImage(ctx context.Context, repositoryName, tag string) error {
+	log.Printf("Registry %s is not ECR, leaving %s:%s in place", u.URL, repositoryName, tag)
+	return nil
+}
+
+// ImageScan implements Registry (unmanaged registries don't scan)
+func (u Unmanaged) ImageScan(ctx context.Context, repositoryName, tag string) (*ScanResult, error) {
+	return nil, nil
+}
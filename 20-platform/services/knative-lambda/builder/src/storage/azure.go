@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// =============================================================================
+// 🔷 AZURE BLOB STORAGE SOURCES
+// =============================================================================
+// On AKS, parser sources can be kept in an Azure Blob Storage container
+// (SOURCE_URI=az://account/container). The "bucket" of this store is
+// account/container. Only reads are needed, through the Blob REST API; the
+// builder authenticates with Microsoft Entra Workload ID: the federated
+// service account token AKS projects into the pod is exchanged for a
+// storage access token
+// 📝 NOTE: AZURE_STORAGE_BLOB_ENDPOINT (e.g. http://127.0.0.1:10000 for
+// Azurite) points it at another endpoint, where blobs are addressed as
+// {endpoint}/{account}/{container}/{blob}
+
+// azureBlobVersion is the Blob REST API version requested
+const azureBlobVersion = "2021-08-06"
+
+// azureStorageScope is the scope of storage access tokens
+const azureStorageScope = "https://storage.azure.com/.default"
+
+// AzureBlobSourceStore implements SourceStore on Azure Blob Storage
+type AzureBlobSourceStore struct {
+	client   *http.Client
+	endpoint string                                    // "" = https://{account}.blob.core.windows.net
+	token    func(ctx context.Context) (string, error) // nil = unauthenticated (emulator)
+}
+
+// NewAzureBlobSourceStore creates an Azure Blob-backed source store
+// authenticated by workload identity (or talking to AZURE_STORAGE_BLOB_ENDPOINT)
+func NewAzureBlobSourceStore() *AzureBlobSourceStore {
+	s := &AzureBlobSourceStore{client: &http.Client{Timeout: 5 * time.Minute}}
+	if endpoint := os.Getenv("AZURE_STORAGE_BLOB_ENDPOINT"); endpoint != "" {
+		return s.WithEndpoint(endpoint, nil)
+	}
+	s.token = WorkloadIdentityTokens(s.client, azureStorageScope)
+	return s
+}
+
+// WithEndpoint talks to another Blob endpoint, with the given tokens
+// (nil = unauthenticated)
+func (s *AzureBlobSourceStore) WithEndpoint(endpoint string, token func(ctx context.Context) (string, error)) *AzureBlobSourceStore {
+	s.endpoint = strings.TrimSuffix(endpoint, "/")
+	s.token = token
+	return s
+}
+
+// Head returns a blob's metadata without downloading it
+func (s *AzureBlobSourceStore) Head(ctx context.Context, bucket, key string) (ObjectInfo, error) {
+	resp, err := s.do(ctx, http.MethodHead, bucket, key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	resp.Body.Close()
+	size, _ := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	return ObjectInfo{ETag: resp.Header.Get("ETag"), Size: size}, nil
+}
+
+// Get opens a blob for reading; callers must close it
+func (s *AzureBlobSourceStore) Get(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// blobURL returns the URL of a blob of account/container
+func (s *AzureBlobSourceStore) blobURL(bucket, key string) (string, error) {
+	account, container, ok := strings.Cut(bucket, "/")
+	if !ok || account == "" || container == "" {
+		return "", fmt.Errorf("invalid Azure container %q: expected account/container", bucket)
+	}
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	blob := url.PathEscape(container) + "/" + strings.Join(segments, "/")
+	if s.endpoint == "" {
+		return fmt.Sprintf("https://%s.blob.core.windows.net/%s", account, blob), nil
+	}
+	return fmt.Sprintf("%s/%s/%s", s.endpoint, url.PathEscape(account), blob), nil
+}
+
+// do sends a HEAD or GET request for a blob
+func (s *AzureBlobSourceStore) do(ctx context.Context, method, bucket, key string) (*http.Response, error) {
+	u, err := s.blobURL(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-ms-version", azureBlobVersion)
+	if s.token != nil {
+		token, err := s.token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to authenticate to Azure Storage: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get az://%s/%s: %w", bucket, key, err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		resp.Body.Close()
+		return nil, fmt.Errorf("az://%s/%s: %w", bucket, key, ErrNotFound)
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to get az://%s/%s: %s: %s", bucket, key, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAzureBlobSourceStore(t *testing.T) {
+	exchanges := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tenant-1/oauth2/v2.0/token" {
+			exchanges++
+			r.ParseForm()
+			if r.PostForm.Get("client_assertion") != "federated" || r.PostForm.Get("client_id") != "client-1" {
+				http.Error(w, "invalid_client", http.StatusUnauthorized)
+				return
+			}
+			io.WriteString(w, `{"token_type": "Bearer", "access_token": "t0ken", "expires_in": 3599}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer t0ken" || r.Header.Get("x-ms-version") == "" {
+			http.Error(w, "unauthenticated", http.StatusForbidden)
+			return
+		}
+		// Keys keep their slashes: blobs are addressed as account/container/key
+		if r.URL.EscapedPath() != "/parsers/sources/acme/p1.js" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("ETag", `"0x8DC2B"`)
+		w.Header().Set("Content-Length", "25")
+		io.WriteString(w, "module.exports = () => {}")
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("federated\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AZURE_STORAGE_BLOB_ENDPOINT", "")
+	t.Setenv("AZURE_CLIENT_ID", "client-1")
+	t.Setenv("AZURE_TENANT_ID", "tenant-1")
+	t.Setenv("AZURE_FEDERATED_TOKEN_FILE", tokenFile)
+	t.Setenv("AZURE_AUTHORITY_HOST", server.URL)
+
+	s := NewAzureBlobSourceStore()
+	s.WithEndpoint(server.URL, s.token)
+	ctx := context.Background()
+
+	info, err := s.Head(ctx, "parsers/sources", "acme/p1.js")
+	if err != nil || info.ETag != `"0x8DC2B"` || info.Size != 25 {
+		t.Fatalf("Head = %+v, %v", info, err)
+	}
+	body, err := s.Get(ctx, "parsers/sources", "acme/p1.js")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	content, _ := io.ReadAll(body)
+	body.Close()
+	if string(content) != "module.exports = () => {}" {
+		t.Errorf("Get = %q", content)
+	}
+	if exchanges != 1 {
+		t.Errorf("%d token exchanges, want 1 (cached)", exchanges)
+	}
+
+	if _, err := s.Head(ctx, "parsers/sources", "acme/p2.js"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Head of a missing blob = %v, want ErrNotFound", err)
+	}
+	if _, err := s.Head(ctx, "sources", "acme/p1.js"); err == nil {
+		t.Error("Head without an account: want an error")
+	}
+}
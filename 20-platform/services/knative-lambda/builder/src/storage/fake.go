@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// 🧪 FAKE OBJECT STORE
+// =============================================================================
+// In-memory ObjectStore for unit tests of code built on this package
+
+// FakeObjectStore keeps objects in memory, keyed by "bucket/key"
+type FakeObjectStore struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	keys     map[string]string // KMS key of objects stored with PutEncrypted
+	tags     map[string]map[string]string
+	modified map[string]time.Time
+
+	// Err, when set, is returned by every operation (to test failure paths)
+	Err error
+}
+
+// NewFakeObjectStore creates an empty fake store
+func NewFakeObjectStore() *FakeObjectStore {
+	return &FakeObjectStore{
+		objects:  map[string][]byte{},
+		keys:     map[string]string{},
+		tags:     map[string]map[string]string{},
+		modified: map[string]time.Time{},
+	}
+}
+
+func fakeKey(bucket, key string) string {
+	return bucket + "/" + key
+}
+
+// Seed stores an object directly (test setup)
+func (f *FakeObjectStore) Seed(bucket, key string, content []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.objects[fakeKey(bucket, key)] = append([]byte(nil), content...)
+	f.modified[fakeKey(bucket, key)] = time.Now()
+	delete(f.keys, fakeKey(bucket, key))
+}
+
+// SetModified changes when an object was last modified (test setup)
+func (f *FakeObjectStore) SetModified(bucket, key string, at time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.modified[fakeKey(bucket, key)] = at
+}
+
+// Object returns a stored object's content (test assertions)
+func (f *FakeObjectStore) Object(bucket, key string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	content, ok := f.objects[fakeKey(bucket, key)]
+	return content, ok
+}
+
+// KMSKeyID returns the KMS key an object was stored with ("" if unencrypted)
+func (f *FakeObjectStore) KMSKeyID(bucket, key string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.keys[fakeKey(bucket, key)]
+}
+
+// Tags returns an object's tags (test assertions)
+func (f *FakeObjectStore) Tags(bucket, key string) map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.tags[fakeKey(bucket, key)]
+}
+
+// Head implements ObjectStore (the ETag is the content's MD5, like S3)
+func (f *FakeObjectStore) Head(ctx context.Context, bucket, key string) (ObjectInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return ObjectInfo{}, f.Err
+	}
+	content, ok := f.objects[fakeKey(bucket, key)]
+	if !ok {
+		return ObjectInfo{}, fmt.Errorf("%s: %w", fakeKey(bucket, key), ErrNotFound)
+	}
+	sum := md5.Sum(content)
+	return ObjectInfo{ETag: hex.EncodeToString(sum[:]), Size: int64(len(content)), KMSKeyID: f.keys[fakeKey(bucket, key)]}, nil
+}
+
+// Get implements ObjectStore
+func (f *FakeObjectStore) Get(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	content, ok := f.objects[fakeKey(bucket, key)]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", fakeKey(bucket, key), ErrNotFound)
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+// Put implements ObjectStore
+func (f *FakeObjectStore) Put(ctx context.Context, bucket, key string, body io.Reader) error {
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return f.Err
+	}
+	f.objects[fakeKey(bucket, key)] = content
+	f.modified[fakeKey(bucket, key)] = time.Now()
+	delete(f.keys, fakeKey(bucket, key))
+	delete(f.tags, fakeKey(bucket, key))
+	return nil
+}
+
+// PutEncrypted implements ObjectStore, remembering the KMS key
+func (f *FakeObjectStore) PutEncrypted(ctx context.Context, bucket, key string, body io.Reader, kmsKeyID string) error {
+	if err := f.Put(ctx, bucket, key, body); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keys[fakeKey(bucket, key)] = kmsKeyID
+	return nil
+}
+
+// Delete implements ObjectStore
+func (f *FakeObjectStore) Delete(ctx context.Context, bucket, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return f.Err
+	}
+	delete(f.objects, fakeKey(bucket, key))
+	delete(f.keys, fakeKey(bucket, key))
+	delete(f.tags, fakeKey(bucket, key))
+	delete(f.modified, fakeKey(bucket, key))
+	return nil
+}
+
+// Tag implements ObjectStore
+func (f *FakeObjectStore) Tag(ctx context.Context, bucket, key string, tags map[string]string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return f.Err
+	}
+	if _, ok := f.objects[fakeKey(bucket, key)]; !ok {
+		return fmt.Errorf("%s: %w", fakeKey(bucket, key), ErrNotFound)
+	}
+	copied := make(map[string]string, len(tags))
+	for name, value := range tags {
+		copied[name] = value
+	}
+	f.tags[fakeKey(bucket, key)] = copied
+	return nil
+}
+
+// List implements ObjectStore (sorted by key, like S3)
+func (f *FakeObjectStore) List(ctx context.Context, bucket, prefix string) ([]ObjectSummary, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	var objects []ObjectSummary
+	for name, content := range f.objects {
+		if key, ok := strings.CutPrefix(name, bucket+"/"); ok && strings.HasPrefix(key, prefix) {
+			objects = append(objects, ObjectSummary{Key: key, Size: int64(len(content)), LastModified: f.modified[name]})
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+// PresignGet implements Presigner with fake URLs naming the object and ttl
+func (f *FakeObjectStore) PresignGet(ctx context.Context, bucket, key string, ttl time.Duration) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return "", f.Err
+	}
+	return fmt.Sprintf("https://%s.s3.fake/%s?X-Amz-Expires=%d", bucket, key, int(ttl.Seconds())), nil
+}
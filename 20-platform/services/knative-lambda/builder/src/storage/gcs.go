@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// =============================================================================
+// ☁️ GOOGLE CLOUD STORAGE SOURCES
+// =============================================================================
+// On GKE, parser sources can be kept in a GCS bucket (SOURCE_URI=gs://bucket).
+// Only reads are needed, through the GCS JSON API; the builder authenticates
+// as its Kubernetes service account (Workload Identity) with tokens from the
+// metadata server
+// 📝 NOTE: STORAGE_EMULATOR_HOST (e.g. http://localhost:4443 for
+// fake-gcs-server) points it at an emulator, without authentication
+
+// gcsEndpoint is the GCS JSON API
+const gcsEndpoint = "https://storage.googleapis.com"
+
+// GCSSourceStore implements SourceStore on Google Cloud Storage
+type GCSSourceStore struct {
+	client   *http.Client
+	endpoint string
+	token    func(ctx context.Context) (string, error) // nil = unauthenticated (emulator)
+}
+
+// NewGCSSourceStore creates a GCS-backed source store authenticated by the
+// metadata server (or talking to STORAGE_EMULATOR_HOST)
+func NewGCSSourceStore() *GCSSourceStore {
+	s := &GCSSourceStore{client: &http.Client{Timeout: 5 * time.Minute}, endpoint: gcsEndpoint}
+	if emulator := os.Getenv("STORAGE_EMULATOR_HOST"); emulator != "" {
+		if !strings.Contains(emulator, "://") {
+			emulator = "http://" + emulator
+		}
+		return s.WithEndpoint(emulator, nil)
+	}
+	s.token = MetadataTokens(s.client)
+	return s
+}
+
+// WithEndpoint talks to another GCS JSON API endpoint, with the given tokens
+// (nil = unauthenticated)
+func (s *GCSSourceStore) WithEndpoint(endpoint string, token func(ctx context.Context) (string, error)) *GCSSourceStore {
+	s.endpoint = strings.TrimSuffix(endpoint, "/")
+	s.token = token
+	return s
+}
+
+// gcsObject is the part of a GCS object resource the builder reads
+type gcsObject struct {
+	ETag string `json:"etag"`
+	Size string `json:"size"` // int64 as a string
+}
+
+// Head returns an object's metadata without downloading it
+func (s *GCSSourceStore) Head(ctx context.Context, bucket, key string) (ObjectInfo, error) {
+	resp, err := s.get(ctx, bucket, key, false)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	defer resp.Body.Close()
+
+	var object gcsObject
+	if err := json.NewDecoder(resp.Body).Decode(&object); err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to decode gs://%s/%s metadata: %w", bucket, key, err)
+	}
+	size, _ := strconv.ParseInt(object.Size, 10, 64)
+	return ObjectInfo{ETag: object.ETag, Size: size}, nil
+}
+
+// Get opens an object for reading; callers must close it
+func (s *GCSSourceStore) Get(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	resp, err := s.get(ctx, bucket, key, true)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// get requests an object's metadata, or its content with media
+func (s *GCSSourceStore) get(ctx context.Context, bucket, key string, media bool) (*http.Response, error) {
+	u := fmt.Sprintf("%s/storage/v1/b/%s/o/%s", s.endpoint, url.PathEscape(bucket), url.PathEscape(key))
+	if media {
+		u += "?alt=media"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.token != nil {
+		token, err := s.token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to authenticate to GCS: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gs://%s/%s: %w", bucket, key, err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		resp.Body.Close()
+		return nil, fmt.Errorf("gs://%s/%s: %w", bucket, key, ErrNotFound)
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to get gs://%s/%s: %s: %s", bucket, key, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGCSSourceStore(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t0ken" {
+			http.Error(w, "unauthenticated", http.StatusUnauthorized)
+			return
+		}
+		// Keys are escaped into a single path segment
+		if r.URL.EscapedPath() != "/storage/v1/b/sources/o/acme%2Fp1.js" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("alt") == "media" {
+			io.WriteString(w, "module.exports = () => {}")
+			return
+		}
+		io.WriteString(w, `{"name": "acme/p1.js", "etag": "CJbX8Z6l", "size": "25"}`)
+	}))
+	defer server.Close()
+	token := func(context.Context) (string, error) { return "t0ken", nil }
+	s := NewGCSSourceStore().WithEndpoint(server.URL, token)
+	ctx := context.Background()
+
+	info, err := s.Head(ctx, "sources", "acme/p1.js")
+	if err != nil || info.ETag != "CJbX8Z6l" || info.Size != 25 {
+		t.Fatalf("Head = %+v, %v", info, err)
+	}
+	body, err := s.Get(ctx, "sources", "acme/p1.js")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	content, _ := io.ReadAll(body)
+	body.Close()
+	if string(content) != "module.exports = () => {}" {
+		t.Errorf("Get = %q", content)
+	}
+
+	if _, err := s.Head(ctx, "sources", "acme/p2.js"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Head of a missing object = %v, want ErrNotFound", err)
+	}
+	if _, err := s.WithEndpoint(server.URL, nil).Get(ctx, "sources", "acme/p1.js"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Get without a token = %v, want refused", err)
+	}
+}
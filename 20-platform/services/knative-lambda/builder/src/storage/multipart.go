@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// =============================================================================
+// 📤 STREAMING UPLOADS
+// =============================================================================
+// Put and PutEncrypted take any io.Reader, of unknown length: a body that fits
+// in one part is sent with a single PutObject, a larger one as a multipart
+// upload, one part at a time
+// 🎯 WHY: Build contexts are streamed from the tar/gzip writer straight into
+// S3, without a temp file; at most one part is held in memory per upload
+
+// partSize is the size of the parts of multipart uploads
+// 📝 NOTE: S3 wants at least 5 MiB for every part but the last, and at most
+// 10,000 parts: objects up to ~78 GiB
+const partSize = 8 << 20
+
+// maxParts is the most parts S3 takes in one upload
+const maxParts = 10000
+
+// upload streams body into an object, encrypted with kmsKeyID ("" = bucket default)
+func (s *S3ObjectStore) upload(ctx context.Context, bucket, key string, body io.Reader, kmsKeyID string) error {
+	part := make([]byte, partSize)
+	n, err := io.ReadFull(body, part)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		input := &s3.PutObjectInput{
+			Bucket: awssdk.String(bucket),
+			Key:    awssdk.String(key),
+			Body:   bytes.NewReader(part[:n]),
+		}
+		if kmsKeyID != "" {
+			input.ServerSideEncryption = s3types.ServerSideEncryptionAwsKms
+			input.SSEKMSKeyId = awssdk.String(kmsKeyID)
+		}
+		_, err := s.client.PutObject(ctx, input)
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read the object: %w", err)
+	}
+
+	create := &s3.CreateMultipartUploadInput{
+		Bucket: awssdk.String(bucket),
+		Key:    awssdk.String(key),
+	}
+	if kmsKeyID != "" {
+		create.ServerSideEncryption = s3types.ServerSideEncryptionAwsKms
+		create.SSEKMSKeyId = awssdk.String(kmsKeyID)
+	}
+	out, err := s.client.CreateMultipartUpload(ctx, create)
+	if err != nil {
+		return fmt.Errorf("failed to start multipart upload: %w", err)
+	}
+	uploadID := out.UploadId
+
+	parts, err := s.uploadParts(ctx, bucket, key, uploadID, body, part[:n])
+	if err != nil {
+		// 🧹 Parts of an abandoned upload are billed until aborted
+		if _, abortErr := s.client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+			Bucket:   awssdk.String(bucket),
+			Key:      awssdk.String(key),
+			UploadId: uploadID,
+		}); abortErr != nil {
+			log.Printf("WARNING: Failed to abort multipart upload of s3://%s/%s: %v", bucket, key, abortErr)
+		}
+		return err
+	}
+
+	if _, err := s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          awssdk.String(bucket),
+		Key:             awssdk.String(key),
+		UploadId:        uploadID,
+		MultipartUpload: &s3types.CompletedMultipartUpload{Parts: parts},
+	}); err != nil {
+		return fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+	return nil
+}
+
+// uploadParts uploads first, then the rest of body, as the parts of an upload
+func (s *S3ObjectStore) uploadParts(ctx context.Context, bucket, key string, uploadID *string, body io.Reader, first []byte) ([]s3types.CompletedPart, error) {
+	var parts []s3types.CompletedPart
+	part := first
+	for number := int32(1); ; number++ {
+		if number > maxParts {
+			return nil, fmt.Errorf("larger than %d parts of %d bytes", maxParts, partSize)
+		}
+		out, err := s.client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:     awssdk.String(bucket),
+			Key:        awssdk.String(key),
+			UploadId:   uploadID,
+			PartNumber: awssdk.Int32(number),
+			Body:       bytes.NewReader(part),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload part %d: %w", number, err)
+		}
+		parts = append(parts, s3types.CompletedPart{ETag: out.ETag, PartNumber: awssdk.Int32(number)})
+
+		n, err := io.ReadFull(body, first[:cap(first)])
+		if errors.Is(err, io.EOF) {
+			return parts, nil
+		}
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("failed to read the object: %w", err)
+		}
+		part = first[:n]
+	}
+}
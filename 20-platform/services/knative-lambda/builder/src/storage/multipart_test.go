@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// fakeS3 records the uploads it receives: whole objects and parts
+type fakeS3 struct {
+	mu        sync.Mutex
+	objects   map[string][]byte
+	parts     map[int][]byte
+	completed bool
+	aborted   bool
+	failPart  int // Part number answered with a 500 (0 = none)
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	body, _ := io.ReadAll(r.Body)
+	query := r.URL.Query()
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		fmt.Fprint(w, `<InitiateMultipartUploadResult><Bucket>tmp</Bucket><Key>k</Key><UploadId>u1</UploadId></InitiateMultipartUploadResult>`)
+	case r.Method == http.MethodPut && query.Has("partNumber"):
+		var number int
+		fmt.Sscan(query.Get("partNumber"), &number)
+		if number == f.failPart {
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		f.parts[number] = body
+		w.Header().Set("ETag", fmt.Sprintf(`"etag-%d"`, number))
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		f.completed = true
+		fmt.Fprint(w, `<CompleteMultipartUploadResult><Bucket>tmp</Bucket><Key>k</Key><ETag>"e"</ETag></CompleteMultipartUploadResult>`)
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		f.aborted = true
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		f.objects[r.URL.Path] = body
+	default:
+		http.Error(w, "unexpected "+r.Method+" "+r.URL.String(), http.StatusBadRequest)
+	}
+}
+
+func TestS3StreamingUpload(t *testing.T) {
+	fake := &fakeS3{objects: map[string][]byte{}, parts: map[int][]byte{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	s := NewS3ObjectStore(s3.New(s3.Options{
+		Region:       "us-west-2",
+		BaseEndpoint: awssdk.String(server.URL),
+		UsePathStyle: true,
+		Credentials:  awssdk.AnonymousCredentials{},
+		Retryer:      awssdk.NopRetryer{},
+	}))
+	ctx := context.Background()
+
+	// 📝 Fits in one part: a single PutObject
+	if err := s.Put(ctx, "tmp", "small.tar.gz", strings.NewReader("tiny")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if got := string(fake.objects["/tmp/small.tar.gz"]); got != "tiny" || len(fake.parts) != 0 {
+		t.Errorf("small object stored as %q with %d parts, want a single PutObject", got, len(fake.parts))
+	}
+
+	// 📤 Larger, of unknown length: streamed as parts
+	large := bytes.Repeat([]byte("0123456789abcdef"), (2*partSize+100)/16)
+	if err := s.Put(ctx, "tmp", "large.tar.gz", bytes.NewReader(large)); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if len(fake.parts) != 3 || !fake.completed {
+		t.Fatalf("large object uploaded in %d parts (completed %v), want 3", len(fake.parts), fake.completed)
+	}
+	if got := bytes.Join([][]byte{fake.parts[1], fake.parts[2], fake.parts[3]}, nil); !bytes.Equal(got, large) {
+		t.Errorf("parts hold %d bytes, want the %d uploaded", len(got), len(large))
+	}
+
+	// 🧹 A failed part aborts the upload
+	fake.failPart = 2
+	if err := s.Put(ctx, "tmp", "large.tar.gz", bytes.NewReader(large)); err == nil || !fake.aborted {
+		t.Errorf("Put with a failing part = %v (aborted %v), want an error and the upload aborted", err, fake.aborted)
+	}
+}
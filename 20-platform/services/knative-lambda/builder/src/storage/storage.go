@@ -0,0 +1,229 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"knative-lambda-builder/internal/awserrors"
+)
+
+// =============================================================================
+// 🗄️ OBJECT STORAGE
+// =============================================================================
+// Parser sources are read from, and build contexts written to, object storage
+// 🎯 PURPOSE: Keep the pipeline independent of the S3 SDK (and easy to fake)
+
+// ErrNotFound is returned when an object does not exist
+var ErrNotFound = errors.New("object not found")
+
+// ObjectInfo is an object's metadata
+type ObjectInfo struct {
+	ETag     string // Changes whenever the content changes
+	Size     int64
+	KMSKeyID string // KMS key it is encrypted with (SSE-KMS, "" = not KMS encrypted)
+}
+
+// ObjectSummary is an object found by List
+type ObjectSummary struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// ObjectStore reads and writes objects in buckets
+type ObjectStore interface {
+	Head(ctx context.Context, bucket, key string) (ObjectInfo, error)
+	Get(ctx context.Context, bucket, key string) (io.ReadCloser, error)
+	Put(ctx context.Context, bucket, key string, body io.Reader) error
+	PutEncrypted(ctx context.Context, bucket, key string, body io.Reader, kmsKeyID string) error
+	Delete(ctx context.Context, bucket, key string) error
+	Tag(ctx context.Context, bucket, key string, tags map[string]string) error
+	// List returns the objects whose key starts with prefix
+	List(ctx context.Context, bucket, prefix string) ([]ObjectSummary, error)
+}
+
+// SourceStore reads parser sources
+// 📝 NOTE: Every ObjectStore is one; GCSSourceStore reads them from GCS
+type SourceStore interface {
+	Head(ctx context.Context, bucket, key string) (ObjectInfo, error)
+	Get(ctx context.Context, bucket, key string) (io.ReadCloser, error)
+}
+
+// Presigner hands out time-limited URLs to download objects
+// 📝 NOTE: S3ObjectStore and FakeObjectStore are ones
+type Presigner interface {
+	PresignGet(ctx context.Context, bucket, key string, ttl time.Duration) (string, error)
+}
+
+// S3ObjectStore implements ObjectStore on Amazon S3
+type S3ObjectStore struct {
+	client *s3.Client
+}
+
+// NewS3ObjectStore creates an S3-backed object store
+func NewS3ObjectStore(client *s3.Client) *S3ObjectStore {
+	return &S3ObjectStore{client: client}
+}
+
+// Head returns an object's metadata without downloading it
+func (s *S3ObjectStore) Head(ctx context.Context, bucket, key string) (ObjectInfo, error) {
+	out, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: awssdk.String(bucket),
+		Key:    awssdk.String(key),
+	})
+	if err != nil {
+		var notFound *s3types.NotFound
+		if errors.As(err, &notFound) {
+			return ObjectInfo{}, fmt.Errorf("s3://%s/%s: %w", bucket, key, ErrNotFound)
+		}
+		return ObjectInfo{}, fmt.Errorf("failed to head s3://%s/%s: %w", bucket, key, err)
+	}
+	info := ObjectInfo{
+		ETag: strings.Trim(awssdk.ToString(out.ETag), `"`),
+		Size: awssdk.ToInt64(out.ContentLength),
+	}
+	if isKMS(out.ServerSideEncryption) {
+		info.KMSKeyID = awssdk.ToString(out.SSEKMSKeyId)
+	}
+	return info, nil
+}
+
+// Get opens an object for reading; callers must close it
+func (s *S3ObjectStore) Get(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: awssdk.String(bucket),
+		Key:    awssdk.String(key),
+	})
+	if err != nil {
+		var noSuchKey *s3types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, fmt.Errorf("s3://%s/%s: %w", bucket, key, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get s3://%s/%s: %w", bucket, key, err)
+	}
+	return out.Body, nil
+}
+
+// Put uploads an object (streamed, see multipart.go)
+func (s *S3ObjectStore) Put(ctx context.Context, bucket, key string, body io.Reader) error {
+	if err := s.upload(ctx, bucket, key, body, ""); err != nil {
+		return fmt.Errorf("failed to put s3://%s/%s: %w", bucket, key, err)
+	}
+	return nil
+}
+
+// PutEncrypted uploads an object encrypted server-side with a KMS key (SSE-KMS)
+// 🎯 WHY: Kaniko reads build contexts straight from S3, so they can't be
+// encrypted client-side; S3 decrypts them for roles allowed to use the key
+func (s *S3ObjectStore) PutEncrypted(ctx context.Context, bucket, key string, body io.Reader, kmsKeyID string) error {
+	if err := s.upload(ctx, bucket, key, body, kmsKeyID); err != nil {
+		return fmt.Errorf("failed to put s3://%s/%s (kms key %s): %w", bucket, key, kmsKeyID, err)
+	}
+	return nil
+}
+
+// PresignGet returns a URL anyone can download an object from for ttl
+// 📝 NOTE: Signed with the store's credentials; URLs signed with temporary
+// credentials stop working when those expire, whatever ttl says
+func (s *S3ObjectStore) PresignGet(ctx context.Context, bucket, key string, ttl time.Duration) (string, error) {
+	req, err := s3.NewPresignClient(s.client).PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: awssdk.String(bucket),
+		Key:    awssdk.String(key),
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign s3://%s/%s: %w", bucket, key, err)
+	}
+	return req.URL, nil
+}
+
+// Delete removes an object (deleting a missing object is not an error in S3)
+func (s *S3ObjectStore) Delete(ctx context.Context, bucket, key string) error {
+	if _, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: awssdk.String(bucket),
+		Key:    awssdk.String(key),
+	}); err != nil {
+		return fmt.Errorf("failed to delete s3://%s/%s: %w", bucket, key, err)
+	}
+	return nil
+}
+
+// Tag replaces an object's tags (e.g. to match a bucket lifecycle rule)
+func (s *S3ObjectStore) Tag(ctx context.Context, bucket, key string, tags map[string]string) error {
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tagSet := make([]s3types.Tag, 0, len(tags))
+	for _, name := range names {
+		tagSet = append(tagSet, s3types.Tag{Key: awssdk.String(name), Value: awssdk.String(tags[name])})
+	}
+	if _, err := s.client.PutObjectTagging(ctx, &s3.PutObjectTaggingInput{
+		Bucket:  awssdk.String(bucket),
+		Key:     awssdk.String(key),
+		Tagging: &s3types.Tagging{TagSet: tagSet},
+	}); err != nil {
+		return fmt.Errorf("failed to tag s3://%s/%s: %w", bucket, key, err)
+	}
+	return nil
+}
+
+// List returns the objects under a prefix, following every page
+func (s *S3ObjectStore) List(ctx context.Context, bucket, prefix string) ([]ObjectSummary, error) {
+	var objects []ObjectSummary
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: awssdk.String(bucket),
+		Prefix: awssdk.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list s3://%s/%s: %w", bucket, prefix, err)
+		}
+		for _, object := range page.Contents {
+			objects = append(objects, ObjectSummary{
+				Key:          awssdk.ToString(object.Key),
+				Size:         awssdk.ToInt64(object.Size),
+				LastModified: awssdk.ToTime(object.LastModified),
+			})
+		}
+	}
+	return objects, nil
+}
+
+// DefaultKMSKey returns the KMS key a bucket encrypts new objects with by
+// default; ok is false unless its default encryption is SSE-KMS (keyID is ""
+// for the AWS managed key)
+func (s *S3ObjectStore) DefaultKMSKey(ctx context.Context, bucket string) (keyID string, ok bool, err error) {
+	out, err := s.client.GetBucketEncryption(ctx, &s3.GetBucketEncryptionInput{Bucket: awssdk.String(bucket)})
+	if err != nil {
+		if awserrors.HasCode(err, awserrors.ServerSideEncryptionConfigurationAbsent) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("failed to get the encryption of s3://%s: %w", bucket, err)
+	}
+	if out.ServerSideEncryptionConfiguration == nil {
+		return "", false, nil
+	}
+	for _, rule := range out.ServerSideEncryptionConfiguration.Rules {
+		if d := rule.ApplyServerSideEncryptionByDefault; d != nil && isKMS(d.SSEAlgorithm) {
+			return awssdk.ToString(d.KMSMasterKeyID), true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// isKMS reports whether a server-side encryption is SSE-KMS (single or dual layer)
+func isKMS(sse s3types.ServerSideEncryption) bool {
+	return sse == s3types.ServerSideEncryptionAwsKms || sse == s3types.ServerSideEncryptionAwsKmsDsse
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenMargin renews tokens this long before they expire
+const tokenMargin = time.Minute
+
+// tokenCache holds an access token until shortly before it expires
+// 🎯 PURPOSE: One token request per hour instead of one per object read
+type tokenCache struct {
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// get returns the cached token, or a new one from fetch (token, lifetime)
+func (c *tokenCache) get(ctx context.Context, fetch func(ctx context.Context) (string, time.Duration, error)) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Now().Before(c.expires) {
+		return c.token, nil
+	}
+	token, lifetime, err := fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.token = token
+	c.expires = time.Now().Add(lifetime - tokenMargin)
+	return c.token, nil
+}
+
+// decodeToken reads an OAuth2 token response ({"access_token", "expires_in"})
+func decodeToken(body io.Reader) (string, time.Duration, error) {
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"` // Seconds
+	}
+	if err := json.NewDecoder(body).Decode(&token); err != nil {
+		return "", 0, fmt.Errorf("failed to decode token: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", 0, fmt.Errorf("no access_token in the token response")
+	}
+	return token.AccessToken, time.Duration(token.ExpiresIn) * time.Second, nil
+}
+
+// metadataHost serves the tokens of the node's (or workload's) service account
+const metadataHost = "metadata.google.internal"
+
+// MetadataTokens returns access tokens of the workload's Google service
+// account (Workload Identity on GKE), from the metadata server
+// 📝 NOTE: GCE_METADATA_HOST overrides the metadata server
+func MetadataTokens(client *http.Client) func(ctx context.Context) (string, error) {
+	tokens := &tokenCache{}
+	return func(ctx context.Context) (string, error) {
+		return tokens.get(ctx, func(ctx context.Context) (string, time.Duration, error) {
+			host := metadataHost
+			if override := os.Getenv("GCE_METADATA_HOST"); override != "" {
+				host = override
+			}
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+				"http://"+host+"/computeMetadata/v1/instance/service-accounts/default/token", nil)
+			if err != nil {
+				return "", 0, err
+			}
+			req.Header.Set("Metadata-Flavor", "Google")
+			resp, err := client.Do(req)
+			if err != nil {
+				return "", 0, fmt.Errorf("failed to reach the metadata server: %w", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return "", 0, fmt.Errorf("metadata server returned %s", resp.Status)
+			}
+			return decodeToken(resp.Body)
+		})
+	}
+}
+
+// azureAuthorityHost issues Entra ID tokens, unless AZURE_AUTHORITY_HOST says otherwise
+const azureAuthorityHost = "https://login.microsoftonline.com/"
+
+// WorkloadIdentityTokens returns Entra ID access tokens for scope, exchanged
+// for the federated service account token AKS projects into the pod
+// (Microsoft Entra Workload ID)
+// 📝 NOTE: AZURE_CLIENT_ID, AZURE_TENANT_ID, AZURE_FEDERATED_TOKEN_FILE and
+// AZURE_AUTHORITY_HOST are injected by the workload identity webhook
+func WorkloadIdentityTokens(client *http.Client, scope string) func(ctx context.Context) (string, error) {
+	tokens := &tokenCache{}
+	return func(ctx context.Context) (string, error) {
+		return tokens.get(ctx, func(ctx context.Context) (string, time.Duration, error) {
+			clientID, tenantID, tokenFile := os.Getenv("AZURE_CLIENT_ID"), os.Getenv("AZURE_TENANT_ID"), os.Getenv("AZURE_FEDERATED_TOKEN_FILE")
+			if clientID == "" || tenantID == "" || tokenFile == "" {
+				return "", 0, fmt.Errorf("workload identity is not configured (AZURE_CLIENT_ID, AZURE_TENANT_ID and AZURE_FEDERATED_TOKEN_FILE are required)")
+			}
+			// Re-read on every exchange: the kubelet rotates the projected token
+			assertion, err := os.ReadFile(tokenFile)
+			if err != nil {
+				return "", 0, fmt.Errorf("failed to read the federated token: %w", err)
+			}
+			authority := azureAuthorityHost
+			if override := os.Getenv("AZURE_AUTHORITY_HOST"); override != "" {
+				authority = override
+			}
+			form := url.Values{
+				"grant_type":            {"client_credentials"},
+				"client_id":             {clientID},
+				"scope":                 {scope},
+				"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+				"client_assertion":      {strings.TrimSpace(string(assertion))},
+			}
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+				strings.TrimSuffix(authority, "/")+"/"+url.PathEscape(tenantID)+"/oauth2/v2.0/token", strings.NewReader(form.Encode()))
+			if err != nil {
+				return "", 0, err
+			}
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			resp, err := client.Do(req)
+			if err != nil {
+				return "", 0, fmt.Errorf("failed to reach Entra ID: %w", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+				return "", 0, fmt.Errorf("Entra ID returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+			}
+			return decodeToken(resp.Body)
+		})
+	}
+}
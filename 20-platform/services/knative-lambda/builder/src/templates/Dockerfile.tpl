@@ -1,12 +1,24 @@
-FROM node:18-alpine
+{{- /* schemaVersion: 14 */ -}}
+FROM {{.BaseImage}}
 
 WORKDIR /app
 
+# Dependencies first: their layer is reused from the Kaniko cache as long as
+# package.json doesn't change
 COPY package.json .
-COPY index.js .
-COPY {{.ParserId}}.js .
-
+{{- if not .Npmrc}}
 RUN npm install
+{{- else if eq .Backend "buildkit"}}
+# Private registry credentials, mounted for this step only (never in a layer)
+RUN --mount=type=secret,id=npmrc,target=/root/.npmrc npm install
+{{- else}}
+# Private registry credentials, mounted into the Kaniko pod (never in a layer)
+RUN npm install --userconfig=/kaniko/npmrc/.npmrc
+{{- end}}
+
+COPY index.js .
+# Parser plus its optional {{.ParserId}}.test.js (the wildcard tolerates its absence)
+COPY {{.ParserId}}*.js ./
 
 ENV NODE_PATH=/app/node_modules
 
@@ -0,0 +1,159 @@
+{{- /* schemaVersion: 20 */ -}}
+# Receives a CloudEvent network.notifi.lambda.build.start (BuildKit backend)
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: "{{.Name}}"
+  namespace: "knative-lambda"
+  # Lets job updates be matched to their build (see the build registry)
+  labels:
+    knative-lambda.notifi.network/third-party-id: "{{.ThirdPartyId}}"
+    knative-lambda.notifi.network/parser-id: "{{.ParserId}}"
+    knative-lambda.notifi.network/build-backend: "buildkit"
+  annotations:
+    # How often the build was retried after a failed job (see BUILD_RETRIES)
+    knative-lambda.notifi.network/build-retry: "{{.Retry}}"
+    # The image revision the job pushes (see image revisions)
+    knative-lambda.notifi.network/image-tag: "{{.Tag}}"
+    {{- if .PushRegion}}
+    # The AWS region the job pushes to (see regional pushes)
+    knative-lambda.notifi.network/region: "{{.PushRegion}}"
+    {{- end}}
+spec:
+  ttlSecondsAfterFinished: 300
+  {{- if .ActiveDeadlineSeconds}}
+  # Fails the build once it runs too long (BUILD_TIMEOUT)
+  activeDeadlineSeconds: {{.ActiveDeadlineSeconds}}
+  {{- end}}
+  # Fail fast when the pod is preempted/evicted: the builder requeues with backoff
+  podFailurePolicy:
+    rules:
+    - action: FailJob
+      onPodConditions:
+      - type: DisruptionTarget
+  template:
+    metadata:
+      labels:
+        knative-lambda.notifi.network/build-attempt: "{{.Attempt}}"
+    spec:
+      serviceAccountName: "knative-lambda-builder"
+      {{- if .PriorityClassName}}
+      priorityClassName: "{{.PriorityClassName}}"
+      {{- end}}
+      # buildctl can't read S3: the context is downloaded first
+      initContainers:
+      - name: "fetch-context"
+        image: "amazon/aws-cli:2.15.30"
+        {{- if .ContextURL}}
+        # A presigned URL of the context: the job needs no access to the bucket (CONTEXT_PRESIGN_TTL)
+        command: ["curl", "--fail", "--silent", "--show-error", "--location", "--output", "/workspace/context.tar.gz", {{toJson .ContextURL}}]
+        {{- else}}
+        args: ["s3", "cp", "{{.Context}}", "/workspace/context.tar.gz"]
+        env:
+        - name: "AWS_REGION"
+          value: "{{.Region}}"
+        {{- if .S3Endpoint}}
+        # S3 somewhere else than AWS, e.g. LocalStack or MinIO (AWS_ENDPOINT_URL_S3)
+        - name: "AWS_ENDPOINT_URL_S3"
+          value: "{{.S3Endpoint}}"
+        {{- end}}
+        - name: "AWS_ACCESS_KEY_ID"
+          valueFrom:
+            secretKeyRef:
+              name: "ecr-secret"
+              key: "AWS_ACCESS_KEY_ID"
+              optional: true
+        - name: "AWS_SECRET_ACCESS_KEY"
+          valueFrom:
+            secretKeyRef:
+              name: "ecr-secret"
+              key: "AWS_SECRET_ACCESS_KEY"
+              optional: true
+        {{- end}}
+        volumeMounts:
+        - name: "workspace"
+          mountPath: "/workspace"
+      containers:
+      - name: "buildctl"
+        image: "{{.BuildKitImage}}"
+        command: ["/bin/sh", "-c"]
+        args:
+        - |
+          set -e
+          mkdir -p /workspace/context
+          tar -xzf /workspace/context.tar.gz -C /workspace/context
+          exec buildctl --addr "{{.BuildKitAddr}}" build \
+            --progress=plain \
+            --frontend=dockerfile.v0 \
+            {{- if .Platforms}}
+            --opt platform={{.Platforms}} \
+            {{- end}}
+            {{- if .Reproducible}}
+            --opt build-arg:SOURCE_DATE_EPOCH=0 \
+            --output type=image,name={{.ImageTag}},push=true,rewrite-timestamp=true{{if .InsecureRegistry}},registry.insecure=true{{end}} \
+            {{- else}}
+            --output type=image,name={{.ImageTag}},push=true{{if .InsecureRegistry}},registry.insecure=true{{end}} \
+            {{- end}}
+            {{- if .CacheRepo}}
+            --import-cache type=registry,ref={{.CacheRepo}}:buildkit{{if .InsecureRegistry}},registry.insecure=true{{end}} \
+            --export-cache type=registry,ref={{.CacheRepo}}:buildkit,mode=max,image-manifest=true,oci-mediatypes=true{{if .InsecureRegistry}},registry.insecure=true{{end}} \
+            {{- end}}
+            {{- range .BuildSecrets}}
+            --secret id={{.}},src=/run/build-secrets/{{.}} \
+            {{- end}}
+            {{- if .NpmrcSecret}}
+            --secret id=npmrc,src=/run/npmrc/.npmrc \
+            {{- end}}
+            {{- range .BuildArgs}}
+            --opt {{shellQuote (printf "build-arg:%s=%s" .Name .Value)}} \
+            {{- end}}
+            {{- range .ImageLabels}}
+            --opt {{shellQuote (printf "label:%s=%s" .Name .Value)}} \
+            {{- end}}
+            --local context=/workspace/context \
+            --local dockerfile=/workspace/context \
+            --opt filename={{.Dockerfile}}
+        env:
+        # Push credentials for the registry (a dockerconfigjson Secret)
+        - name: "DOCKER_CONFIG"
+          value: "/run/registry-auth"
+        volumeMounts:
+        - name: "workspace"
+          mountPath: "/workspace"
+        - name: "registry-auth"
+          mountPath: "/run/registry-auth"
+          readOnly: true
+        - name: "build-secrets"
+          mountPath: "/run/build-secrets"
+          readOnly: true
+        {{- if .NpmrcSecret}}
+        - name: "npmrc"
+          mountPath: "/run/npmrc"
+          readOnly: true
+        {{- end}}
+      volumes:
+      - name: "workspace"
+        emptyDir: {}
+      - name: "registry-auth"
+        secret:
+          # REGISTRY_AUTH_SECRET when the registry needs one (Artifact Registry, ACR, generic)
+          secretName: "{{or .RegistryAuthSecret "buildkit-registry-auth"}}"
+          optional: true
+          items:
+          - key: ".dockerconfigjson"
+            path: "config.json"
+      # Build secrets (RUN --mount=type=secret,id=<key>), see BUILDKIT_SECRETS
+      - name: "build-secrets"
+        secret:
+          secretName: "buildkit-secrets"
+          optional: true
+      {{- if .NpmrcSecret}}
+      # Private npm registry credentials (RUN --mount=type=secret,id=npmrc), see NPMRC_SECRET
+      - name: "npmrc"
+        secret:
+          secretName: "{{.NpmrcSecret}}"
+          items:
+          - key: ".npmrc"
+            path: ".npmrc"
+      {{- end}}
+      restartPolicy: "Never"
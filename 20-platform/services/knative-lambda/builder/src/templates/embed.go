@@ -0,0 +1,10 @@
+// Package templates holds the default templates, embedded into the builder
+// binary so it works without a templates volume
+package templates
+
+import "embed"
+
+// FS contains every *.tpl in this directory, and the parser runtimes' under runtimes/
+//
+//go:embed *.tpl runtimes/*/*.tpl
+var FS embed.FS
@@ -0,0 +1,110 @@
+{{- /* schemaVersion: 21 */ -}}
+# Parser deployed without Knative Serving: Deployment + Service + HPA
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: lambda-{{.ThirdPartyId}}-{{.ParserId}}
+  namespace: {{.Namespace}}
+  labels:
+    knative-lambda.notifi.network/third-party-id: "{{.ThirdPartyId}}"
+    knative-lambda.notifi.network/parser-id: "{{.ParserId}}"
+    knative-lambda.notifi.network/deploy-mode: fallback
+spec:
+  selector:
+    matchLabels:
+      knative-lambda.notifi.network/third-party-id: "{{.ThirdPartyId}}"
+      knative-lambda.notifi.network/parser-id: "{{.ParserId}}"
+  template:
+    metadata:
+      labels:
+        knative-lambda.notifi.network/third-party-id: "{{.ThirdPartyId}}"
+        knative-lambda.notifi.network/parser-id: "{{.ParserId}}"
+        knative-lambda.notifi.network/deploy-mode: fallback
+{{- if .RebuiltAt}}
+      annotations:
+        # Changes on every rebuild: rolls the pods onto the new image
+        knative-lambda.notifi.network/rebuilt-at: "{{.RebuiltAt}}"
+{{- end}}
+    spec:
+      containers:
+        - name: user-container
+          image: {{.Image}}
+          imagePullPolicy: Always # The tag is reused by every build of the parser
+          env:
+            - name: PORT
+              value: "8080"
+{{- range .Env}}
+            - {{toJson .}}
+{{- end}}
+          ports:
+            - containerPort: 8080
+          readinessProbe:
+            tcpSocket:
+              port: 8080
+          # The HPA scales on CPU, relative to this request
+          resources:
+            requests:
+              cpu: 100m
+              memory: 128Mi
+{{- if .SharedVolume}}
+          volumeMounts:
+            - name: {{.SharedVolume}}
+              mountPath: {{.SharedMountPath}}
+{{- end}}
+{{- range .Sidecars}}
+        - {{toJson .}}
+{{- end}}
+{{- if .SharedVolume}}
+      volumes:
+        - name: {{.SharedVolume}}
+          emptyDir: {}
+{{- end}}
+      tolerations:
+        - key: knative-spot
+          operator: Equal
+          value: "true"
+          effect: NoSchedule
+      nodeSelector:
+        knative-spot: "true"
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: lambda-{{.ThirdPartyId}}-{{.ParserId}}
+  namespace: {{.Namespace}}
+  labels:
+    knative-lambda.notifi.network/third-party-id: "{{.ThirdPartyId}}"
+    knative-lambda.notifi.network/parser-id: "{{.ParserId}}"
+    knative-lambda.notifi.network/deploy-mode: fallback
+spec:
+  selector:
+    knative-lambda.notifi.network/third-party-id: "{{.ThirdPartyId}}"
+    knative-lambda.notifi.network/parser-id: "{{.ParserId}}"
+  ports:
+    - name: http
+      port: 80
+      targetPort: 8080
+---
+apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: lambda-{{.ThirdPartyId}}-{{.ParserId}}
+  namespace: {{.Namespace}}
+  labels:
+    knative-lambda.notifi.network/third-party-id: "{{.ThirdPartyId}}"
+    knative-lambda.notifi.network/parser-id: "{{.ParserId}}"
+    knative-lambda.notifi.network/deploy-mode: fallback
+spec:
+  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: lambda-{{.ThirdPartyId}}-{{.ParserId}}
+  minReplicas: {{.MinReplicas}}
+  maxReplicas: {{.MaxReplicas}}
+  metrics:
+    - type: Resource
+      resource:
+        name: cpu
+        target:
+          type: Utilization
+          averageUtilization: {{.TargetCPUUtilization}}
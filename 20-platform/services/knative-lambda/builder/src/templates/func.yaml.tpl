@@ -1,3 +1,4 @@
+{{- /* schemaVersion: 1 */ -}}
 specVersion: 0.36.0
 name: wrapper
 runtime: node
@@ -1,3 +1,4 @@
+{{- /* schemaVersion: 1 */ -}}
 const { CloudEvent } = require('cloudevents');
 
 /**
@@ -1,27 +1,95 @@
+{{- /* schemaVersion: 22 */ -}}
 # Receives a CloudEvent network.notifi.lambda.build.start
 apiVersion: batch/v1
 kind: Job
 metadata:
   name: "{{.Name}}"
   namespace: "knative-lambda"
+  # Lets job updates be matched to their build (see the build registry)
+  labels:
+    knative-lambda.notifi.network/third-party-id: "{{.ThirdPartyId}}"
+    knative-lambda.notifi.network/parser-id: "{{.ParserId}}"
+  annotations:
+    # How often the build was retried after a failed job (see BUILD_RETRIES)
+    knative-lambda.notifi.network/build-retry: "{{.Retry}}"
+    # The image revision the job pushes (see image revisions)
+    knative-lambda.notifi.network/image-tag: "{{.Tag}}"
+    {{- if .PushRole}}
+    # The IAM role the job pushes as (see cross-account pushes)
+    knative-lambda.notifi.network/push-role: "{{.PushRole}}"
+    {{- end}}
+    {{- if .PushRegion}}
+    # The AWS region the job pushes to (see regional pushes)
+    knative-lambda.notifi.network/region: "{{.PushRegion}}"
+    {{- end}}
 spec:
   ttlSecondsAfterFinished: 300
+  {{- if .ActiveDeadlineSeconds}}
+  # Fails the build once it runs too long (BUILD_TIMEOUT)
+  activeDeadlineSeconds: {{.ActiveDeadlineSeconds}}
+  {{- end}}
+  # Fail fast when the pod is preempted/evicted: the builder requeues with backoff
+  podFailurePolicy:
+    rules:
+    - action: FailJob
+      onPodConditions:
+      - type: DisruptionTarget
   template:
+    metadata:
+      labels:
+        knative-lambda.notifi.network/build-attempt: "{{.Attempt}}"
     spec:
       serviceAccountName: "knative-lambda-builder"
+      {{- if .PriorityClassName}}
+      priorityClassName: "{{.PriorityClassName}}"
+      {{- end}}
+      {{- if .NodeArch}}
+      # Kaniko only builds for the platform it runs on
+      nodeSelector:
+        kubernetes.io/arch: "{{.NodeArch}}"
+      {{- end}}
       containers:
       - name: "kaniko"
-        image: "gcr.io/kaniko-project/executor:latest"
+        image: "{{.KanikoImage}}"
+        {{- if .Resources}}
+        # Sizing of the build: BUILD_RESOURCE_REQUESTS/LIMITS, the tenant's, the request's
+        resources: {{toJson .Resources}}
+        {{- end}}
         args:
         - "--dockerfile={{.Dockerfile}}"
+        {{- if .ContextURL}}
+        # A presigned URL of the context: the job needs no access to the bucket (CONTEXT_PRESIGN_TTL)
+        - {{toJson (printf "--context=%s" .ContextURL)}}
+        {{- else}}
         - "--context=s3://{{.BucketName}}/builds/{{.ThirdPartyId}}/{{.ParserId}}.tar.gz"
+        {{- end}}
         - "--destination={{.ImageTag}}"
+        {{- if .Platforms}}
+        - "--custom-platform={{.Platforms}}"
+        {{- end}}
+        {{- if .InsecureRegistry}}
+        # Lab registries with self-signed certificates, see REGISTRY_INSECURE
+        - "--skip-tls-verify-registry={{.InsecureRegistry}}"
+        {{- end}}
+        {{- if .CacheRepo}}
+        # Layers (npm install) are cached in the tenant's cache repository
         - "--cache=true"
-        - "--cache-ttl=24h"
+        - "--cache-repo={{.CacheRepo}}"
+        - "--cache-ttl={{.CacheTTL}}"
+        {{- end}}
         - "--use-new-run"
         - "--verbosity=debug"
         - "--log-format=text"
         - "--cleanup"
+        {{- if .Reproducible}}
+        - "--reproducible"
+        {{- end}}
+        {{- range .BuildArgs}}
+        - {{toJson (printf "--build-arg=%s=%s" .Name .Value)}}
+        {{- end}}
+        {{- range .ImageLabels}}
+        - {{toJson (printf "--label=%s=%s" .Name .Value)}}
+        {{- end}}
         env:
         - name: "AWS_SDK_LOAD_CONFIG"
           value: "true"
@@ -29,6 +97,31 @@ spec:
           value: "{{.Region}}"
         - name: "AWS_ECR_REGISTRY"
           value: "localhost:5000/knative-lambdas"
+        {{- if .S3Endpoint}}
+        # S3 somewhere else than AWS, e.g. LocalStack or MinIO (AWS_ENDPOINT_URL_S3)
+        - name: "S3_ENDPOINT"
+          value: "{{.S3Endpoint}}"
+        - name: "S3_FORCE_PATH_STYLE"
+          value: "{{.S3ForcePathStyle}}"
+        {{- end}}
+        {{- if .PushRoleSecret}}
+        # Temporary credentials of the push role, see AWS_PUSH_ROLE_ARN
+        - name: "AWS_ACCESS_KEY_ID"
+          valueFrom:
+            secretKeyRef:
+              name: "{{.PushRoleSecret}}"
+              key: "{{.PushRoleKey}}.AWS_ACCESS_KEY_ID"
+        - name: "AWS_SECRET_ACCESS_KEY"
+          valueFrom:
+            secretKeyRef:
+              name: "{{.PushRoleSecret}}"
+              key: "{{.PushRoleKey}}.AWS_SECRET_ACCESS_KEY"
+        - name: "AWS_SESSION_TOKEN"
+          valueFrom:
+            secretKeyRef:
+              name: "{{.PushRoleSecret}}"
+              key: "{{.PushRoleKey}}.AWS_SESSION_TOKEN"
+        {{- else}}
         - name: "AWS_ACCESS_KEY_ID"
           valueFrom:
             secretKeyRef:
@@ -41,15 +134,45 @@ spec:
               name: "ecr-secret"
               key: "AWS_SECRET_ACCESS_KEY"
               optional: true
+        {{- end}}
         volumeMounts:
         - name: "aws-credentials"
           mountPath: "/kaniko/.aws"
           readOnly: true
+        {{- if .NpmrcSecret}}
+        # Kaniko never snapshots /kaniko: RUN steps can read it, no layer keeps it
+        - name: "npmrc"
+          mountPath: "/kaniko/npmrc"
+          readOnly: true
+        {{- end}}
+        {{- if .RegistryAuthSecret}}
+        - name: "registry-auth"
+          mountPath: "/kaniko/.docker"
+          readOnly: true
+        {{- end}}
       volumes:
       - name: "aws-credentials"
         secret:
           secretName: "ecr-secret"
           optional: true
+      {{- if .NpmrcSecret}}
+      # Private npm registry credentials, see NPMRC_SECRET
+      - name: "npmrc"
+        secret:
+          secretName: "{{.NpmrcSecret}}"
+          items:
+          - key: ".npmrc"
+            path: ".npmrc"
+      {{- end}}
+      {{- if .RegistryAuthSecret}}
+      # Docker config Kaniko pushes with (Artifact Registry, ACR, generic), see REGISTRY_AUTH_SECRET
+      - name: "registry-auth"
+        secret:
+          secretName: "{{.RegistryAuthSecret}}"
+          items:
+          - key: ".dockerconfigjson"
+            path: "config.json"
+      {{- end}}
       - name: knative-lambda-config
         configMap:
           name: knative-lambda-config
@@ -1,3 +1,4 @@
+{{- /* schemaVersion: 1 */ -}}
 {
   "name": "event-handler",
   "version": "0.1.0",
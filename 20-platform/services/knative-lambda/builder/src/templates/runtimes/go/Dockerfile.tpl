@@ -0,0 +1,18 @@
+{{- /* schemaVersion: 12 */ -}}
+FROM {{.BaseImage}} AS build
+
+WORKDIR /src
+
+# The parser is package parser under parser/; `go mod tidy` resolves its
+# imports (and the wrapper's) into go.mod and go.sum
+COPY go.mod main.go ./
+COPY parser/ parser/
+RUN go mod tidy && \
+    CGO_ENABLED=0 go build -trimpath -ldflags "-s -w" -o /out/{{.ParserId}} .
+
+# Static binary only: no shell, no package manager, non-root
+FROM {{.RuntimeImage}}
+
+COPY --from=build /out/{{.ParserId}} /parser
+
+ENTRYPOINT ["/parser"]
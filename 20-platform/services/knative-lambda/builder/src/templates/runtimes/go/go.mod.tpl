@@ -0,0 +1,6 @@
+{{- /* schemaVersion: 1 */ -}}
+module lambda
+
+go 1.22
+
+require github.com/cloudevents/sdk-go/v2 v2.14.0
@@ -0,0 +1,54 @@
+{{- /* schemaVersion: 1 */ -}}
+// Command parser serves the {{.ParserId}} parser as a CloudEvents handler.
+//
+// The parser (package parser) must define
+//
+//	func Handle(ctx context.Context, data []byte) (any, error)
+//
+// called with the data of each received CloudEvent.
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+
+	"lambda/parser"
+)
+
+// handle runs the parser on an event and echoes the event
+func handle(ctx context.Context, event cloudevents.Event) (*cloudevents.Event, cloudevents.Result) {
+	// Execute Parser
+	processed, err := parser.Handle(ctx, event.Data())
+	if err != nil {
+		log.Printf("ERROR: {{.ParserId}} failed on event %s: %v", event.ID(), err)
+		return nil, cloudevents.NewHTTPResult(http.StatusInternalServerError, "parser failed: %v", err)
+	}
+	log.Printf("event %s: processed data: %v", event.ID(), processed)
+
+	// Return CloudEvent
+	response := cloudevents.NewEvent()
+	response.SetSource("event.handler")
+	response.SetType("echo")
+	if err := response.SetData(cloudevents.ApplicationJSON, event.Data()); err != nil {
+		return nil, cloudevents.NewHTTPResult(http.StatusInternalServerError, "failed to set response data: %v", err)
+	}
+	return &response, nil
+}
+
+func main() {
+	port := 8080
+	if p, err := strconv.Atoi(os.Getenv("PORT")); err == nil {
+		port = p
+	}
+	client, err := cloudevents.NewClientHTTP(cloudevents.WithPort(port))
+	if err != nil {
+		log.Fatalf("failed to create CloudEvents client: %v", err)
+	}
+	log.Printf("{{.ParserId}} listening on :%d", port)
+	log.Fatal(client.StartReceiver(context.Background(), handle))
+}
@@ -0,0 +1,19 @@
+{{- /* schemaVersion: 1 */ -}}
+FROM {{.BaseImage}}
+
+WORKDIR /app
+
+# Dependencies first: their layer is reused from the Kaniko cache as long as
+# the requirements don't change. parser-requirements.txt is the parser's own
+# {{.ParserId}}.requirements.txt (the wildcard tolerates its absence)
+COPY *requirements.txt ./
+RUN pip install --no-cache-dir -r requirements.txt && \
+    if [ -f parser-requirements.txt ]; then pip install --no-cache-dir -r parser-requirements.txt; fi
+
+COPY main.py {{.ParserId}}.py ./
+
+ENV PYTHONUNBUFFERED=1
+
+USER nobody
+
+ENTRYPOINT ["gunicorn", "--bind", "0.0.0.0:8080", "--workers", "2", "main:app"]
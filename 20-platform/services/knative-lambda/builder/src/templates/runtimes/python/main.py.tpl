@@ -0,0 +1,41 @@
+{{- /* schemaVersion: 1 */ -}}
+"""CloudEvent handler wrapping the {{.ParserId}} parser.
+
+The parser module ({{.ParserId}}.py) must define handle(data), called with the
+data of each received CloudEvent.
+"""
+import importlib.util
+import logging
+
+from cloudevents.http import CloudEvent, from_http, to_structured
+from flask import Flask, request
+
+logging.basicConfig(level=logging.INFO)
+log = logging.getLogger("event-handler")
+
+# Parser ids aren't always valid module names (e.g. "invoice-created"): load it by path
+spec = importlib.util.spec_from_file_location("parser", "/app/{{.ParserId}}.py")
+parser = importlib.util.module_from_spec(spec)
+spec.loader.exec_module(parser)
+
+app = Flask(__name__)
+
+
+@app.route("/", methods=["POST"])
+def handle():
+    # Execute Parser
+    event = from_http(request.headers, request.get_data())
+    processed = parser.handle(event.data)
+
+    log.info("event %s", event)
+    log.info("Processed data: %s", processed)
+
+    # Return CloudEvent
+    headers, body = to_structured(CloudEvent({"source": "event.handler", "type": "echo"}, event.data))
+    return body, 200, headers
+
+
+@app.route("/health/readiness")
+@app.route("/health/liveness")
+def health():
+    return "OK"
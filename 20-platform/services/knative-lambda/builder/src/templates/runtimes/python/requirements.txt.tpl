@@ -0,0 +1,4 @@
+{{- /* schemaVersion: 1 */ -}}
+cloudevents>=1.11,<2
+flask>=3.0,<4
+gunicorn>=22.0,<24
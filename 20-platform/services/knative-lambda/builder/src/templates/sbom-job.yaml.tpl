@@ -0,0 +1,89 @@
+{{- /* schemaVersion: 10 */ -}}
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: "{{.Name}}"
+  namespace: "knative-lambda"
+  labels:
+    knative-lambda.notifi.network/third-party-id: "{{.ThirdPartyId}}"
+    knative-lambda.notifi.network/parser-id: "{{.ParserId}}"
+  annotations:
+    # The image revision inventoried (see image revisions)
+    knative-lambda.notifi.network/image-tag: "{{.Tag}}"
+spec:
+  backoffLimit: 2 # Registry pulls and uploads flake
+  activeDeadlineSeconds: 900
+  ttlSecondsAfterFinished: 300
+  template:
+    spec:
+      serviceAccountName: "knative-lambda-builder"
+      # syft pulls the pushed image from the registry and writes both formats
+      initContainers:
+      - name: "syft"
+        image: "{{.SyftImage}}"
+        args:
+        - "scan"
+        - "registry:{{.Image}}"
+        - "--output"
+        - "spdx-json=/sbom/sbom.spdx.json"
+        - "--output"
+        - "cyclonedx-json=/sbom/sbom.cdx.json"
+        env:
+        # Pull credentials for the registry (a dockerconfigjson Secret)
+        - name: "DOCKER_CONFIG"
+          value: "/run/registry-auth"
+        volumeMounts:
+        - name: "sbom"
+          mountPath: "/sbom"
+        - name: "registry-auth"
+          mountPath: "/run/registry-auth"
+          readOnly: true
+        resources:
+          limits:
+            cpu: "1"
+            memory: "1Gi"
+      containers:
+      - name: "upload"
+        image: "amazon/aws-cli:2.15.30"
+        args:
+        - "s3"
+        - "cp"
+        - "/sbom"
+        - "{{.Destination}}"
+        - "--recursive"
+        {{- if .KMSKeyID}}
+        - "--sse"
+        - "aws:kms"
+        - "--sse-kms-key-id"
+        - "{{.KMSKeyID}}"
+        {{- end}}
+        env:
+        - name: "AWS_REGION"
+          value: "{{.Region}}"
+        - name: "AWS_ACCESS_KEY_ID"
+          valueFrom:
+            secretKeyRef:
+              name: "ecr-secret"
+              key: "AWS_ACCESS_KEY_ID"
+              optional: true
+        - name: "AWS_SECRET_ACCESS_KEY"
+          valueFrom:
+            secretKeyRef:
+              name: "ecr-secret"
+              key: "AWS_SECRET_ACCESS_KEY"
+              optional: true
+        volumeMounts:
+        - name: "sbom"
+          mountPath: "/sbom"
+          readOnly: true
+      volumes:
+      - name: "sbom"
+        emptyDir: {}
+      - name: "registry-auth"
+        secret:
+          secretName: "buildkit-registry-auth"
+          optional: true
+          items:
+          - key: ".dockerconfigjson"
+            path: "config.json"
+      restartPolicy: "Never"
@@ -1,14 +1,46 @@
+{{- /* schemaVersion: 21 */ -}}
 # Create parser services.serving.knative.dev
 apiVersion: serving.knative.dev/v1
 kind: Service
 metadata:
   name: lambda-{{.ThirdPartyId}}-{{.ParserId}}
-  namespace: knative-lambda
+  namespace: {{.Namespace}}
+  labels:
+    knative-lambda.notifi.network/third-party-id: "{{.ThirdPartyId}}"
+    knative-lambda.notifi.network/parser-id: "{{.ParserId}}"
 spec:
   template:
+{{- if .RebuiltAt}}
+    metadata:
+      annotations:
+        # Changes on every rebuild: a new revision, resolving the tag to the new digest
+        knative-lambda.notifi.network/rebuilt-at: "{{.RebuiltAt}}"
+{{- end}}
     spec:
       containers:
         - image: {{.Image}}
+{{- if .Env}}
+          env:
+{{- range .Env}}
+            - {{toJson .}}
+{{- end}}
+{{- end}}
+{{- if .SharedVolume}}
+          # With several containers Knative routes to the one declaring a port
+          ports:
+            - containerPort: 8080
+          volumeMounts:
+            - name: {{.SharedVolume}}
+              mountPath: {{.SharedMountPath}}
+{{- end}}
+{{- range .Sidecars}}
+        - {{toJson .}}
+{{- end}}
+{{- if .SharedVolume}}
+      volumes:
+        - name: {{.SharedVolume}}
+          emptyDir: {}
+{{- end}}
       tolerations:
         - key: knative-spot
           operator: Equal
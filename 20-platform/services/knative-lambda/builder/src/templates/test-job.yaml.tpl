@@ -0,0 +1,31 @@
+{{- /* schemaVersion: 10 */ -}}
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: "{{.Name}}"
+  namespace: "knative-lambda"
+  labels:
+    knative-lambda.notifi.network/third-party-id: "{{.ThirdPartyId}}"
+    knative-lambda.notifi.network/parser-id: "{{.ParserId}}"
+  annotations:
+    # The image revision under test (see image revisions)
+    knative-lambda.notifi.network/image-tag: "{{.Tag}}"
+spec:
+  backoffLimit: 0 # A failing test suite is a verdict, not a flake
+  activeDeadlineSeconds: {{.TimeoutSeconds}}
+  ttlSecondsAfterFinished: 300
+  template:
+    spec:
+      containers:
+      - name: "tests"
+        image: "{{.Image}}"
+        workingDir: "/app"
+        command: ["node", "--test", "{{.TestFile}}"]
+        env:
+        - name: "NODE_ENV"
+          value: "test"
+        resources:
+          limits:
+            cpu: "500m"
+            memory: "256Mi"
+      restartPolicy: "Never"
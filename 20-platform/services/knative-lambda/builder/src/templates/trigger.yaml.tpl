@@ -1,8 +1,12 @@
+{{- /* schemaVersion: 21 */ -}}
 apiVersion: eventing.knative.dev/v1
 kind: Trigger
 metadata:
   name: lambda-{{ .ThirdPartyId }}-{{ .ParserId }}-trigger
   namespace: knative-eventing # Same namespace as the broker
+  labels:
+    knative-lambda.notifi.network/third-party-id: "{{.ThirdPartyId}}"
+    knative-lambda.notifi.network/parser-id: "{{.ParserId}}"
 spec:
   broker: service-broker
   filter:
@@ -11,10 +15,14 @@ spec:
       source: network.notifi.parsers.{{ .ThirdPartyId }}.{{ .ParserId }}
   subscriber:
     ref:
+{{- if eq .DeployMode "fallback"}}
+      apiVersion: v1 # Plain Service in front of the fallback Deployment
+{{- else}}
       apiVersion: serving.knative.dev/v1
+{{- end}}
       kind: Service
       name: lambda-{{ .ThirdPartyId }}-{{ .ParserId }}
-      namespace: knative-lambda # Same namespace as the service
+      namespace: {{ .Namespace }} # Same namespace as the service
   delivery:
     retry: 5
     backoffPolicy: "exponential"
@@ -6,6 +6,7 @@ const { Resource } = require('@opentelemetry/resources');
 const { SemanticResourceAttributes } = require('@opentelemetry/semantic-conventions');
 const { getNodeAutoInstrumentations } = require('@opentelemetry/auto-instrumentations-node');
 const { trace, context } = require('@opentelemetry/api');
+const { registerRuntimeMetrics } = require('./runtime-metrics');
 
 // Initialize OpenTelemetry
 const sdk = new NodeSDK({
@@ -50,6 +51,9 @@ const processedItems = new promClient.Counter({
   registers: [register],
 });
 
+// Runtime self-metrics (runtime_gc_*, runtime_heap_*), named like the other stooges
+registerRuntimeMetrics(register);
+
 // Business logic simulation
 const processData = (inputData) => {
   // Simulate some data processing
@@ -151,6 +155,48 @@ app.get('/health', (req, res) => {
   });
 });
 
+// Downstream dependencies (reported on /topology) - Curly is the end of the line
+const dependencies = [];
+
+const toDot = (topology) => {
+  const color = (healthy) => (healthy ? 'green' : 'red');
+  const lines = ['digraph topology {'];
+  topology.nodes.forEach((node) => {
+    lines.push(`  "${node.name}" [color=${color(node.healthy)}, tooltip="${node.status}"];`);
+  });
+  topology.edges.forEach((edge) => {
+    lines.push(`  "${edge.from}" -> "${edge.to}" [color=${color(edge.healthy)}];`);
+  });
+  lines.push('}');
+  return lines.join('\n') + '\n';
+};
+
+app.get('/topology', (req, res) => {
+  const topology = {
+    service: 'curly',
+    nodes: [{ name: 'curly', healthy: true, status: 'healthy' }],
+    edges: [],
+  };
+  dependencies.forEach((dep) => {
+    topology.nodes.push({ name: dep.name, url: dep.url, healthy: false, status: 'unknown' });
+    topology.edges.push({ from: 'curly', to: dep.name, healthy: false });
+  });
+
+  const format = req.query.format || 'json';
+  if (format === 'dot') {
+    requestsTotal.labels(req.method, '/topology', '200').inc();
+    res.set('Content-Type', 'text/vnd.graphviz');
+    return res.send(toDot(topology));
+  }
+  if (format !== 'json') {
+    requestsTotal.labels(req.method, '/topology', '400').inc();
+    return res.status(400).json({ error: 'format must be json or dot' });
+  }
+
+  requestsTotal.labels(req.method, '/topology', '200').inc();
+  res.json(topology);
+});
+
 app.get('/metrics', async (req, res) => {
   res.set('Content-Type', register.contentType);
   const metrics = await register.metrics();
@@ -187,5 +233,6 @@ app.listen(PORT, () => {
   console.log('Endpoints:');
   console.log('  - GET /curly (main endpoint)');
   console.log('  - GET /health (health check)');
+  console.log('  - GET /topology (dependency graph, ?format=dot)');
   console.log('  - GET /metrics (Prometheus metrics)');
 }); 
\ No newline at end of file
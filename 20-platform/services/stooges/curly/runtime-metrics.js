@@ -0,0 +1,54 @@
+// Runtime self-metrics shared by all stooges and the knative-lambda builder.
+// Every service exports the same runtime_* series so one dashboard covers them
+// whatever the language. Node reports GC pauses/cycles from perf_hooks and
+// heap usage from V8; runtime_goroutines and runtime_heap_objects are Go only.
+const { PerformanceObserver } = require('perf_hooks');
+const v8 = require('v8');
+const promClient = require('prom-client');
+
+// Identical buckets in every service (see moe/runtime.go)
+const GC_PAUSE_BUCKETS = [0.00001, 0.00005, 0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1];
+
+const registerRuntimeMetrics = (register) => {
+  const gcPause = new promClient.Histogram({
+    name: 'runtime_gc_pause_seconds',
+    help: 'Stop-the-world garbage collection pauses',
+    buckets: GC_PAUSE_BUCKETS,
+    registers: [register],
+  });
+
+  const gcCycles = new promClient.Counter({
+    name: 'runtime_gc_cycles_total',
+    help: 'Completed garbage collection cycles',
+    registers: [register],
+  });
+
+  new promClient.Gauge({
+    name: 'runtime_heap_live_bytes',
+    help: 'Heap bytes in use by live objects',
+    registers: [register],
+    collect() {
+      this.set(v8.getHeapStatistics().used_heap_size);
+    },
+  });
+
+  new promClient.Gauge({
+    name: 'runtime_heap_total_bytes',
+    help: 'Heap memory held by the runtime',
+    registers: [register],
+    collect() {
+      this.set(v8.getHeapStatistics().total_heap_size);
+    },
+  });
+
+  // GC entries report their duration in milliseconds
+  const observer = new PerformanceObserver((list) => {
+    for (const entry of list.getEntries()) {
+      gcPause.observe(entry.duration / 1000);
+      gcCycles.inc();
+    }
+  });
+  observer.observe({ entryTypes: ['gc'] });
+};
+
+module.exports = { registerRuntimeMetrics, GC_PAUSE_BUCKETS };
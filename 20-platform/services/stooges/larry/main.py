@@ -1,6 +1,7 @@
 #!/usr/bin/env python3
 
 import json
+import os
 import time
 import uuid
 from datetime import datetime
@@ -9,7 +10,7 @@ from typing import Dict, Any
 import httpx
 import uvicorn
 from fastapi import FastAPI, Request, HTTPException
-from fastapi.responses import JSONResponse
+from fastapi.responses import JSONResponse, PlainTextResponse
 from prometheus_client import Counter, Histogram, generate_latest, CONTENT_TYPE_LATEST
 from opentelemetry import trace, propagate
 from opentelemetry.exporter.jaeger.thrift import JaegerExporter
@@ -20,6 +21,8 @@ from opentelemetry.sdk.resources import Resource
 from opentelemetry.sdk.trace import TracerProvider
 from opentelemetry.sdk.trace.export import BatchSpanProcessor
 
+import runtime_metrics
+
 # Initialize tracing
 trace.set_tracer_provider(
     TracerProvider(
@@ -67,6 +70,16 @@ CURLY_CALLS_TOTAL = Counter(
     ['status']
 )
 
+# Runtime self-metrics (runtime_gc_*), named like the other stooges
+runtime_metrics.register()
+
+# Downstream dependencies (reported on /topology)
+CURLY_SERVICE_URL = os.getenv("CURLY_SERVICE_URL", "http://localhost:8082")
+
+DEPENDENCIES = [
+    {"name": "curly", "url": CURLY_SERVICE_URL},
+]
+
 class ResponseModel:
     def __init__(self, service: str, message: str, trace_id: str, data: str):
         self.service = service
@@ -98,7 +111,7 @@ async def call_curly_service(trace_id: str) -> str:
                 propagate.inject(headers)
                 
                 response = await client.get(
-                    "http://localhost:8082/curly",
+                    f"{CURLY_SERVICE_URL}/curly",
                     headers=headers
                 )
                 
@@ -172,6 +185,52 @@ async def health_check():
             "quote": "I'm trying to think, but nothing happens!"
         }
 
+async def probe_health(url: str) -> Dict[str, Any]:
+    """Call a dependency's /health endpoint"""
+    try:
+        async with httpx.AsyncClient(timeout=2.0) as client:
+            response = await client.get(f"{url.rstrip('/')}/health")
+        if response.status_code == 200:
+            return {"healthy": True, "status": "healthy"}
+        return {"healthy": False, "status": f"HTTP {response.status_code}"}
+    except Exception:
+        return {"healthy": False, "status": "unreachable"}
+
+def to_dot(topology: Dict[str, Any]) -> str:
+    """Render a topology as a Graphviz digraph"""
+    color = lambda healthy: "green" if healthy else "red"
+    lines = ["digraph topology {"]
+    for node in topology["nodes"]:
+        lines.append(f'  "{node["name"]}" [color={color(node["healthy"])}, tooltip="{node["status"]}"];')
+    for edge in topology["edges"]:
+        lines.append(f'  "{edge["from"]}" -> "{edge["to"]}" [color={color(edge["healthy"])}];')
+    lines.append("}")
+    return "\n".join(lines) + "\n"
+
+@app.get("/topology")
+async def topology(format: str = "json"):
+    """Downstream dependencies and their health (JSON, or DOT with ?format=dot)"""
+    with tracer.start_as_current_span("topology"):
+        result = {
+            "service": "larry",
+            "nodes": [{"name": "larry", "healthy": True, "status": "healthy"}],
+            "edges": [],
+        }
+        for dep in DEPENDENCIES:
+            health = await probe_health(dep["url"])
+            result["nodes"].append({"name": dep["name"], "url": dep["url"], **health})
+            result["edges"].append({"from": "larry", "to": dep["name"], "healthy": health["healthy"]})
+
+        if format == "dot":
+            REQUEST_COUNT.labels(method="GET", endpoint="/topology", status="200").inc()
+            return PlainTextResponse(to_dot(result), media_type="text/vnd.graphviz")
+        if format != "json":
+            REQUEST_COUNT.labels(method="GET", endpoint="/topology", status="400").inc()
+            raise HTTPException(status_code=400, detail="format must be json or dot")
+
+        REQUEST_COUNT.labels(method="GET", endpoint="/topology", status="200").inc()
+        return result
+
 @app.get("/metrics")
 async def metrics():
     """Prometheus metrics endpoint"""
@@ -183,6 +242,7 @@ if __name__ == "__main__":
     print("Endpoints:")
     print("  - GET /larry (main endpoint)")
     print("  - GET /health (health check)")
+    print("  - GET /topology (dependency graph, ?format=dot)")
     print("  - GET /metrics (Prometheus metrics)")
     
     uvicorn.run(app, host="0.0.0.0", port=8081, log_level="info") 
\ No newline at end of file
@@ -0,0 +1,45 @@
+"""Runtime self-metrics shared by all stooges and the knative-lambda builder.
+
+Every service exports the same runtime_* series so one dashboard covers them
+whatever the language. CPython exposes GC pauses and cycles; the heap gauges
+(runtime_heap_*) have no cheap CPython equivalent and are Go/Node only.
+"""
+
+import gc
+import time
+
+from prometheus_client import Counter, Histogram
+
+# Identical buckets in every service (see moe/runtime.go)
+GC_PAUSE_BUCKETS = (0.00001, 0.00005, 0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1)
+
+GC_PAUSE = Histogram(
+    'runtime_gc_pause_seconds',
+    'Stop-the-world garbage collection pauses',
+    buckets=GC_PAUSE_BUCKETS,
+)
+
+# prometheus_client appends _total to counter names
+GC_CYCLES = Counter(
+    'runtime_gc_cycles',
+    'Completed garbage collection cycles',
+)
+
+_gc_started = None
+
+
+def _on_gc(phase, info):
+    """gc callback: CPython collections hold the GIL, so the whole run is a pause"""
+    global _gc_started
+    if phase == "start":
+        _gc_started = time.perf_counter()
+    elif phase == "stop" and _gc_started is not None:
+        GC_PAUSE.observe(time.perf_counter() - _gc_started)
+        GC_CYCLES.inc()
+        _gc_started = None
+
+
+def register():
+    """Start recording GC pauses (idempotent)"""
+    if _on_gc not in gc.callbacks:
+        gc.callbacks.append(_on_gc)
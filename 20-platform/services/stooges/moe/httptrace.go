@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HTTP client timing breakdown for downstream calls. Each phase of a request
+// (DNS lookup, TCP connect, TLS handshake, time to first byte) is recorded as
+// a span event on the calling span and observed in moe_http_client_phase_seconds,
+// so latency can be attributed to the network rather than to larry itself.
+
+var clientPhaseDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "moe_http_client_phase_seconds",
+		Help:    "Duration of HTTP client request phases (dns, connect, tls, ttfb)",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	},
+	[]string{"target", "phase"},
+)
+
+func init() {
+	prometheus.MustRegister(clientPhaseDuration)
+}
+
+// phaseTimer collects the timings of a single request
+type phaseTimer struct {
+	mu     sync.Mutex
+	target string
+	span   trace.Span
+	start  time.Time
+
+	dnsStart, connectStart, tlsStart time.Time
+}
+
+// record observes a phase and adds it to the span as an event
+func (p *phaseTimer) record(phase string, since time.Time, attrs ...attribute.KeyValue) {
+	if since.IsZero() {
+		return
+	}
+	d := time.Since(since)
+	clientPhaseDuration.WithLabelValues(p.target, phase).Observe(d.Seconds())
+	attrs = append(attrs, attribute.Float64("duration_ms", float64(d.Microseconds())/1000))
+	p.span.AddEvent("http."+phase, trace.WithAttributes(attrs...))
+}
+
+// withClientTrace instruments the requests made with the returned context
+func withClientTrace(ctx context.Context, target string) context.Context {
+	p := &phaseTimer{target: target, span: trace.SpanFromContext(ctx), start: time.Now()}
+
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			p.mu.Lock()
+			p.dnsStart = time.Now()
+			p.mu.Unlock()
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			p.record("dns", p.dnsStart, attribute.Int("addresses", len(info.Addrs)))
+		},
+		ConnectStart: func(network, addr string) {
+			p.mu.Lock()
+			p.connectStart = time.Now()
+			p.mu.Unlock()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			attrs := []attribute.KeyValue{attribute.String("net.peer.addr", addr)}
+			if err != nil {
+				attrs = append(attrs, attribute.String("error", err.Error()))
+			}
+			p.record("connect", p.connectStart, attrs...)
+		},
+		TLSHandshakeStart: func() {
+			p.mu.Lock()
+			p.tlsStart = time.Now()
+			p.mu.Unlock()
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			attrs := []attribute.KeyValue{attribute.String("tls.version", tls.VersionName(state.Version))}
+			if err != nil {
+				attrs = append(attrs, attribute.String("error", err.Error()))
+			}
+			p.record("tls", p.tlsStart, attrs...)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			p.span.SetAttributes(attribute.Bool("http.conn_reused", info.Reused))
+		},
+		GotFirstResponseByte: func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			p.record("ttfb", p.start)
+		},
+	})
+}
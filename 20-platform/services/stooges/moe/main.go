@@ -99,7 +99,7 @@ func callLarryService(ctx context.Context, traceID string) (string, error) {
 	)
 
 	client := &http.Client{Timeout: 30 * time.Second}
-	req, err := http.NewRequestWithContext(ctx, "GET", "http://localhost:8081/larry", nil)
+	req, err := http.NewRequestWithContext(withClientTrace(ctx, "larry"), "GET", larryServiceURL+"/larry", nil)
 	if err != nil {
 		larryCallsTotal.WithLabelValues("error").Inc()
 		span.SetAttributes(attribute.String("error", err.Error()))
@@ -217,9 +217,15 @@ func main() {
 		}
 	}()
 
+	// Optional profiling snapshots on heap/goroutine threshold breach
+	if profiler := newSelfProfilerFromEnv(); profiler != nil {
+		go profiler.start(context.Background())
+	}
+
 	// Setup HTTP handlers
 	http.HandleFunc("/moe", moeHandler)
 	http.HandleFunc("/health", healthHandler)
+	http.HandleFunc("/topology", topologyHandler)
 	http.Handle("/metrics", promhttp.Handler())
 
 	log.Println("MOE service starting on :8080")
@@ -227,6 +233,7 @@ func main() {
 	log.Println("Endpoints:")
 	log.Println("  - GET /moe (main endpoint)")
 	log.Println("  - GET /health (health check)")
+	log.Println("  - GET /topology (dependency graph, ?format=dot)")
 	log.Println("  - GET /metrics (Prometheus metrics)")
 
 	if err := http.ListenAndServe(":8080", nil); err != nil {
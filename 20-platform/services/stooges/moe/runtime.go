@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime/metrics"
+	"runtime/pprof"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Runtime self-metrics. MOE, LARRY, CURLY and the knative-lambda builder all
+// export the same runtime_* series (GC pauses, GC cycles, heap), so a single
+// dashboard covers every service whatever its language. They are read from
+// runtime/metrics and folded onto fixed buckets shared by all services.
+//
+// Optionally (SELF_PROFILE_INTERVAL > 0) MOE also checks its heap and
+// goroutines periodically and writes heap/goroutine profiles to
+// SELF_PROFILE_DIR when a threshold is breached.
+
+var gcPauseBuckets = []float64{0.00001, 0.00005, 0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1}
+
+const (
+	sampleGCPauses       = "/sched/pauses/total/gc:seconds"
+	sampleGCPausesLegacy = "/gc/pauses:seconds" // Before Go 1.22
+	sampleGCCycles       = "/gc/cycles/total:gc-cycles"
+	sampleGoroutines     = "/sched/goroutines:goroutines"
+	sampleHeapLive       = "/gc/heap/live:bytes"
+	sampleHeapObjects    = "/gc/heap/objects:objects"
+	sampleHeapInUse      = "/memory/classes/heap/objects:bytes"
+	sampleHeapUnused     = "/memory/classes/heap/unused:bytes"
+	sampleHeapFree       = "/memory/classes/heap/free:bytes"
+)
+
+var (
+	gcPauseDesc     = prometheus.NewDesc("runtime_gc_pause_seconds", "Stop-the-world garbage collection pauses", nil, nil)
+	gcCyclesDesc    = prometheus.NewDesc("runtime_gc_cycles_total", "Completed garbage collection cycles", nil, nil)
+	goroutinesDesc  = prometheus.NewDesc("runtime_goroutines", "Live goroutines", nil, nil)
+	heapLiveDesc    = prometheus.NewDesc("runtime_heap_live_bytes", "Heap bytes reachable at the last garbage collection", nil, nil)
+	heapTotalDesc   = prometheus.NewDesc("runtime_heap_total_bytes", "Heap memory held by the runtime (in use, unused and free spans)", nil, nil)
+	heapObjectsDesc = prometheus.NewDesc("runtime_heap_objects", "Objects on the heap", nil, nil)
+
+	selfProfilesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "runtime_self_profiles_total",
+			Help: "Profiling snapshots taken because a resource threshold was breached",
+		},
+		[]string{"trigger"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(newRuntimeCollector())
+	prometheus.MustRegister(selfProfilesTotal)
+}
+
+// runtimeCollector exports runtime/metrics under the shared runtime_* names
+type runtimeCollector struct {
+	pauseSample string
+}
+
+func newRuntimeCollector() *runtimeCollector {
+	c := &runtimeCollector{pauseSample: sampleGCPausesLegacy}
+	for _, d := range metrics.All() {
+		if d.Name == sampleGCPauses {
+			c.pauseSample = sampleGCPauses
+		}
+	}
+	return c
+}
+
+func (c *runtimeCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{gcPauseDesc, gcCyclesDesc, goroutinesDesc, heapLiveDesc, heapTotalDesc, heapObjectsDesc} {
+		ch <- d
+	}
+}
+
+func (c *runtimeCollector) Collect(ch chan<- prometheus.Metric) {
+	samples := readRuntimeSamples(c.pauseSample, sampleGCCycles, sampleGoroutines, sampleHeapLive,
+		sampleHeapObjects, sampleHeapInUse, sampleHeapUnused, sampleHeapFree)
+
+	if h := samples[c.pauseSample]; h.Kind() == metrics.KindFloat64Histogram {
+		count, sum, buckets := foldHistogram(h.Float64Histogram(), gcPauseBuckets)
+		ch <- prometheus.MustNewConstHistogram(gcPauseDesc, count, sum, buckets)
+	}
+
+	gauge := func(desc *prometheus.Desc, valueType prometheus.ValueType, names ...string) {
+		total := 0.0
+		for _, name := range names {
+			if samples[name].Kind() != metrics.KindUint64 {
+				return // Not supported by this Go version
+			}
+			total += float64(samples[name].Uint64())
+		}
+		ch <- prometheus.MustNewConstMetric(desc, valueType, total)
+	}
+	gauge(gcCyclesDesc, prometheus.CounterValue, sampleGCCycles)
+	gauge(goroutinesDesc, prometheus.GaugeValue, sampleGoroutines)
+	gauge(heapLiveDesc, prometheus.GaugeValue, sampleHeapLive)
+	gauge(heapObjectsDesc, prometheus.GaugeValue, sampleHeapObjects)
+	gauge(heapTotalDesc, prometheus.GaugeValue, sampleHeapInUse, sampleHeapUnused, sampleHeapFree)
+}
+
+// readRuntimeSamples reads runtime/metrics samples by name (unknown names are KindBad)
+func readRuntimeSamples(names ...string) map[string]metrics.Value {
+	samples := make([]metrics.Sample, len(names))
+	for i, name := range names {
+		samples[i].Name = name
+	}
+	metrics.Read(samples)
+
+	values := make(map[string]metrics.Value, len(samples))
+	for _, s := range samples {
+		values[s.Name] = s.Value
+	}
+	return values
+}
+
+// foldHistogram maps a runtime histogram onto fixed cumulative buckets; a
+// runtime bucket counts towards every bound at or above its upper edge and
+// the sum is estimated from bucket midpoints
+func foldHistogram(h *metrics.Float64Histogram, bounds []float64) (uint64, float64, map[float64]uint64) {
+	buckets := make(map[float64]uint64, len(bounds))
+	for _, b := range bounds {
+		buckets[b] = 0
+	}
+
+	var count uint64
+	var sum float64
+	for i, n := range h.Counts {
+		if n == 0 {
+			continue
+		}
+		lower, upper := h.Buckets[i], h.Buckets[i+1]
+		count += n
+
+		switch {
+		case math.IsInf(upper, 1):
+			sum += lower * float64(n)
+		case math.IsInf(lower, -1):
+			sum += upper * float64(n)
+		default:
+			sum += (lower + upper) / 2 * float64(n)
+		}
+
+		for _, b := range bounds {
+			if upper <= b {
+				buckets[b] += n
+			}
+		}
+	}
+	return count, sum, buckets
+}
+
+// selfProfiler snapshots heap and goroutine profiles when usage crosses a
+// threshold, at most once per cooldown
+type selfProfiler struct {
+	dir        string
+	interval   time.Duration
+	cooldown   time.Duration
+	heapBytes  uint64
+	goroutines uint64
+	last       time.Time
+}
+
+// newSelfProfilerFromEnv returns nil unless SELF_PROFILE_INTERVAL is set
+func newSelfProfilerFromEnv() *selfProfiler {
+	interval, err := time.ParseDuration(os.Getenv("SELF_PROFILE_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return nil
+	}
+	cooldown, err := time.ParseDuration(getEnvOrDefault("SELF_PROFILE_COOLDOWN", "15m"))
+	if err != nil {
+		cooldown = 15 * time.Minute
+	}
+	heapBytes, _ := strconv.ParseUint(getEnvOrDefault("SELF_PROFILE_HEAP_BYTES", "268435456"), 10, 64)
+	goroutines, _ := strconv.ParseUint(getEnvOrDefault("SELF_PROFILE_GOROUTINES", "10000"), 10, 64)
+
+	return &selfProfiler{
+		dir:        getEnvOrDefault("SELF_PROFILE_DIR", "/tmp/profiles"),
+		interval:   interval,
+		cooldown:   cooldown,
+		heapBytes:  heapBytes,
+		goroutines: goroutines,
+	}
+}
+
+func (p *selfProfiler) start(ctx context.Context) {
+	if err := os.MkdirAll(p.dir, 0o755); err != nil {
+		log.Printf("Self-profiling disabled, cannot create %s: %v", p.dir, err)
+		return
+	}
+	log.Printf("Self-profiling every %s (heap > %d bytes or goroutines > %d) into %s",
+		p.interval, p.heapBytes, p.goroutines, p.dir)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			p.check(now)
+		}
+	}
+}
+
+func (p *selfProfiler) check(now time.Time) {
+	if !p.last.IsZero() && now.Sub(p.last) < p.cooldown {
+		return
+	}
+
+	samples := readRuntimeSamples(sampleHeapLive, sampleGoroutines)
+	heap, goroutines := samples[sampleHeapLive].Uint64(), samples[sampleGoroutines].Uint64()
+
+	trigger := ""
+	switch {
+	case p.heapBytes > 0 && heap > p.heapBytes:
+		trigger = "heap"
+	case p.goroutines > 0 && goroutines > p.goroutines:
+		trigger = "goroutines"
+	default:
+		return
+	}
+
+	p.last = now
+	selfProfilesTotal.WithLabelValues(trigger).Inc()
+	log.Printf("MOE: %s threshold breached (heap=%d bytes, goroutines=%d), writing profiles", trigger, heap, goroutines)
+
+	stamp := now.UTC().Format("20060102T150405Z")
+	for _, profile := range []string{"heap", "goroutine"} {
+		var buf bytes.Buffer
+		if err := pprof.Lookup(profile).WriteTo(&buf, 0); err != nil {
+			log.Printf("Error writing %s profile: %v", profile, err)
+			continue
+		}
+		path := filepath.Join(p.dir, fmt.Sprintf("moe-%s-%s.pb.gz", stamp, profile))
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			log.Printf("Error writing %s: %v", path, err)
+			continue
+		}
+		log.Printf("MOE: wrote %s", path)
+	}
+}
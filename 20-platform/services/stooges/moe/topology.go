@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Topology describes a service, its downstream dependencies and their health.
+// Every stooge serves its own view on /topology; MOE also crawls the
+// dependencies' /topology endpoints and merges them into one graph.
+type Topology struct {
+	Service string         `json:"service"`
+	Nodes   []TopologyNode `json:"nodes"`
+	Edges   []TopologyEdge `json:"edges"`
+}
+
+type TopologyNode struct {
+	Name    string `json:"name"`
+	URL     string `json:"url,omitempty"`
+	Healthy bool   `json:"healthy"`
+	Status  string `json:"status"`
+}
+
+type TopologyEdge struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Healthy bool   `json:"healthy"`
+}
+
+// dependency is a configured downstream service
+type dependency struct {
+	Name string
+	URL  string
+}
+
+var (
+	larryServiceURL = getEnvOrDefault("LARRY_SERVICE_URL", "http://localhost:8081")
+
+	dependencies = []dependency{
+		{Name: "larry", URL: larryServiceURL},
+	}
+
+	probeClient = &http.Client{Timeout: 2 * time.Second}
+)
+
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// probeHealth calls a dependency's /health endpoint
+func probeHealth(ctx context.Context, baseURL string) (bool, string) {
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(baseURL, "/")+"/health", nil)
+	if err != nil {
+		return false, err.Error()
+	}
+	resp, err := probeClient.Do(req)
+	if err != nil {
+		return false, "unreachable"
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Sprintf("HTTP %d", resp.StatusCode)
+	}
+	return true, "healthy"
+}
+
+// localTopology is MOE's own view: itself and its direct dependencies
+func localTopology(ctx context.Context) Topology {
+	topology := Topology{
+		Service: "moe",
+		Nodes:   []TopologyNode{{Name: "moe", Healthy: true, Status: "healthy"}},
+	}
+	for _, dep := range dependencies {
+		healthy, status := probeHealth(ctx, dep.URL)
+		topology.Nodes = append(topology.Nodes, TopologyNode{Name: dep.Name, URL: dep.URL, Healthy: healthy, Status: status})
+		topology.Edges = append(topology.Edges, TopologyEdge{From: "moe", To: dep.Name, Healthy: healthy})
+	}
+	return topology
+}
+
+// fetchTopology gets a dependency's own /topology view
+func fetchTopology(ctx context.Context, baseURL string) (*Topology, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(baseURL, "/")+"/topology", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := probeClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	var topology Topology
+	if err := json.NewDecoder(resp.Body).Decode(&topology); err != nil {
+		return nil, err
+	}
+	return &topology, nil
+}
+
+// aggregateTopology merges the views of every reachable stooge, starting at MOE
+func aggregateTopology(ctx context.Context) Topology {
+	merged := localTopology(ctx)
+
+	nodes := map[string]int{}
+	for i, node := range merged.Nodes {
+		nodes[node.Name] = i
+	}
+	edges := map[string]bool{}
+	for _, edge := range merged.Edges {
+		edges[edge.From+"->"+edge.To] = true
+	}
+
+	visited := map[string]bool{"moe": true}
+	queue := append([]TopologyNode(nil), merged.Nodes[1:]...)
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		if visited[node.Name] || node.URL == "" || !node.Healthy {
+			continue
+		}
+		visited[node.Name] = true
+
+		downstream, err := fetchTopology(ctx, node.URL)
+		if err != nil {
+			log.Printf("Topology: failed to fetch %s topology: %v", node.Name, err)
+			continue
+		}
+
+		for _, n := range downstream.Nodes {
+			if i, ok := nodes[n.Name]; ok {
+				// Keep the URL we know; a service reports itself without one
+				if merged.Nodes[i].URL == "" {
+					merged.Nodes[i].URL = n.URL
+				}
+				continue
+			}
+			nodes[n.Name] = len(merged.Nodes)
+			merged.Nodes = append(merged.Nodes, n)
+			queue = append(queue, n)
+		}
+		for _, e := range downstream.Edges {
+			if !edges[e.From+"->"+e.To] {
+				edges[e.From+"->"+e.To] = true
+				merged.Edges = append(merged.Edges, e)
+			}
+		}
+	}
+	return merged
+}
+
+// toDOT renders a topology as a Graphviz digraph
+func toDOT(topology Topology) string {
+	color := func(healthy bool) string {
+		if healthy {
+			return "green"
+		}
+		return "red"
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph topology {\n")
+	for _, node := range topology.Nodes {
+		fmt.Fprintf(&b, "  %q [color=%s, tooltip=%q];\n", node.Name, color(node.Healthy), node.Status)
+	}
+	for _, edge := range topology.Edges {
+		fmt.Fprintf(&b, "  %q -> %q [color=%s];\n", edge.From, edge.To, color(edge.Healthy))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// topologyHandler serves the aggregated topology as JSON (default) or DOT (?format=dot).
+// ?scope=local returns only MOE's own view, like the other stooges.
+func topologyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "topology")
+	defer span.End()
+
+	var topology Topology
+	if r.URL.Query().Get("scope") == "local" {
+		topology = localTopology(ctx)
+	} else {
+		topology = aggregateTopology(ctx)
+	}
+
+	switch r.URL.Query().Get("format") {
+	case "dot":
+		w.Header().Set("Content-Type", "text/vnd.graphviz")
+		fmt.Fprint(w, toDOT(topology))
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(topology)
+	default:
+		http.Error(w, "format must be json or dot", http.StatusBadRequest)
+		requestsTotal.WithLabelValues(r.Method, "/topology", "400").Inc()
+		return
+	}
+
+	requestsTotal.WithLabelValues(r.Method, "/topology", "200").Inc()
+}